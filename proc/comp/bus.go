@@ -47,6 +47,17 @@ func (b *SimpleBus[T]) Clean() {
 	b.current = entry[T]{}
 }
 
+// Values returns the elements in transit, the first one to be read first.
+func (b *SimpleBus[T]) Values() []T {
+	var values []T
+	for _, e := range []entry[T]{b.current, b.pending} {
+		if e.exists {
+			values = append(values, e.t)
+		}
+	}
+	return values
+}
+
 type BufferEntry[T any] struct {
 	availableFromCycle int
 	t                  T
@@ -165,6 +176,16 @@ func (b *BufferedBus[T]) IsEmpty() bool {
 	return len(b.queue) == 0 && len(b.buffer) == 0
 }
 
+// Values returns the elements in transit, the first one to be read first: the
+// queued ones, then the buffered ones.
+func (b *BufferedBus[T]) Values() []T {
+	values := slices.Clone(b.queue)
+	for _, e := range b.buffer {
+		values = append(values, e.t)
+	}
+	return values
+}
+
 func (b *BufferedBus[T]) Connect(currentCycle int) {
 	if len(b.queue) == b.queueLength {
 		return
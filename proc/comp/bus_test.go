@@ -43,3 +43,19 @@ func busAssert(t *testing.T, expectedVal int, expectedExists bool, val int, exis
 	assert.Equal(t, expectedVal, val)
 	assert.Equal(t, expectedExists, exists)
 }
+
+func TestBusValues(t *testing.T) {
+	s := &comp.SimpleBus[int]{}
+	assert.Empty(t, s.Values())
+	s.Add(1)
+	s.Get()
+	s.Add(2)
+	assert.Equal(t, []int{1, 2}, s.Values())
+
+	b := comp.NewBufferedBus[int](2, 2)
+	b.Add(1, 0)
+	b.Add(2, 1)
+	b.Connect(1)
+	assert.Equal(t, []int{1, 2}, b.Values())
+	assert.Equal(t, 1, b.PendingRead())
+}
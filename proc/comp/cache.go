@@ -116,7 +116,7 @@ func (c *LRUCache) Write(addr int32, data []int8) {
 	panic("cache line doesn't exist")
 }
 
-func (c *LRUCache) PushLine(addr AlignedAddress, data []int8) []int8 {
+func (c *LRUCache) PushLine(addr AlignedAddress, data []int8) *Line {
 	newLine := Line{
 		Boundary: [2]AlignedAddress{addr, addr + AlignedAddress(c.lineLength)},
 		Data:     data,
@@ -124,9 +124,10 @@ func (c *LRUCache) PushLine(addr AlignedAddress, data []int8) []int8 {
 
 	c.lines = append([]Line{newLine}, c.lines...)
 	if len(c.lines) > c.numberOfLines {
-		c.lines = c.lines[:c.numberOfLines]
 		// Return the evicted line
-		return c.lines[len(c.lines)-1].Data
+		line := c.lines[c.numberOfLines]
+		c.lines = c.lines[:c.numberOfLines]
+		return &line
 	}
 	return nil
 }
@@ -69,3 +69,14 @@ func TestLRUCache_GetSubCacheLine(t *testing.T) {
 	assert.Equal(t, AlignedAddress(6), addr)
 	assert.Equal(t, []int8{2, 3}, data)
 }
+
+func TestLRUCache_PushLineEviction(t *testing.T) {
+	c := NewLRUCache(2, 4)
+	assert.Nil(t, c.PushLine(0, []int8{0, 1}))
+	assert.Nil(t, c.PushLine(2, []int8{2, 3}))
+
+	evicted := c.PushLine(4, []int8{4, 5})
+	assert.Equal(t, &Line{Boundary: [2]AlignedAddress{0, 2}, Data: []int8{0, 1}}, evicted)
+	_, exists := c.GetCacheLine(0)
+	assert.False(t, exists)
+}
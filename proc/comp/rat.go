@@ -5,6 +5,8 @@ type RAT[K comparable, V any] struct {
 	length int
 	values map[K][]V
 	idx    map[K]int
+	// sizes is the number of values written per key, up to length.
+	sizes map[K]int
 }
 
 func NewRAT[K comparable, V any](length int) *RAT[K, V] {
@@ -12,6 +14,7 @@ func NewRAT[K comparable, V any](length int) *RAT[K, V] {
 		length: length,
 		values: make(map[K][]V),
 		idx:    make(map[K]int),
+		sizes:  make(map[K]int),
 	}
 }
 
@@ -25,6 +28,7 @@ func (r *RAT[K, V]) Read(k K) (V, bool) {
 	return r.values[k][idx], true
 }
 
+// Find returns the most recent value matching the predicate.
 func (r *RAT[K, V]) Find(k K, predicate func(V) bool) (V, bool) {
 	var zero V
 
@@ -33,20 +37,13 @@ func (r *RAT[K, V]) Find(k K, predicate func(V) bool) (V, bool) {
 		return zero, false
 	}
 
-	for i := idx; i >= 0; i-- {
-		v := r.values[k][idx]
+	// Only the written values are considered, from the most recent one
+	for i := 0; i < r.sizes[k]; i++ {
+		v := r.values[k][(idx-i+r.length)%r.length]
 		if predicate(v) {
 			return v, true
 		}
 	}
-
-	for i := r.length - 1; i > idx; i-- {
-		v := r.values[k][idx]
-		if predicate(v) {
-			return v, true
-		}
-	}
-
 	return zero, false
 }
 
@@ -61,6 +58,7 @@ func (r *RAT[K, V]) Write(k K, value V) {
 
 	r.idx[k] = idx
 	r.values[k][idx] = value
+	r.sizes[k] = min(r.sizes[k]+1, r.length)
 }
 
 func (r *RAT[K, V]) Values() map[K]V {
@@ -73,22 +71,44 @@ func (r *RAT[K, V]) Values() map[K]V {
 
 func (r *RAT[K, V]) FindValues(predicate func(V) bool) map[K]V {
 	m := make(map[K]V)
-	for k, v := range r.idx {
-		found := false
-		for i := v; i >= 0; i-- {
-			if predicate(r.values[k][i]) {
-				m[k] = r.values[k][i]
-				found = true
-				break
-			}
+	for k := range r.idx {
+		if v, exists := r.Find(k, predicate); exists {
+			m[k] = v
 		}
-		if found {
-			continue
+	}
+	return m
+}
+
+// Max returns the greatest value matching the predicate according to less,
+// regardless of the order in which the values were written.
+func (r *RAT[K, V]) Max(k K, predicate func(V) bool, less func(a, b V) bool) (V, bool) {
+	var (
+		max   V
+		found bool
+	)
+	idx, exists := r.idx[k]
+	if !exists {
+		return max, false
+	}
+	for i := 0; i < r.sizes[k]; i++ {
+		v := r.values[k][(idx-i+r.length)%r.length]
+		if predicate(v) && (!found || less(max, v)) {
+			max = v
+			found = true
 		}
-		for i := r.length - 1; i > v; i-- {
-			if predicate(r.values[k][i]) {
-				m[k] = r.values[k][i]
-				break
+	}
+	return max, found
+}
+
+// FindAllValues returns, per key, all the values matching the predicate, from
+// the least recent one.
+func (r *RAT[K, V]) FindAllValues(predicate func(V) bool) map[K][]V {
+	m := make(map[K][]V)
+	for k, idx := range r.idx {
+		for i := r.sizes[k] - 1; i >= 0; i-- {
+			v := r.values[k][(idx-i+r.length)%r.length]
+			if predicate(v) {
+				m[k] = append(m[k], v)
 			}
 		}
 	}
@@ -29,3 +29,58 @@ func TestRat(t *testing.T) {
 		risc.T0: 2,
 	}, rat.Values())
 }
+
+func TestRatFind(t *testing.T) {
+	rat := comp.NewRAT[risc.RegisterType, int32](3)
+	_, exists := rat.Find(risc.T0, func(int32) bool { return true })
+	assert.False(t, exists)
+
+	rat.Write(risc.T0, 1)
+	rat.Write(risc.T0, 2)
+	v, exists := rat.Find(risc.T0, func(v int32) bool { return v < 2 })
+	assert.True(t, exists)
+	assert.Equal(t, int32(1), v)
+	// Slots never written aren't considered
+	_, exists = rat.Find(risc.T0, func(v int32) bool { return v < 1 })
+	assert.False(t, exists)
+	assert.Equal(t, map[risc.RegisterType]int32{}, rat.FindValues(func(v int32) bool { return v < 1 }))
+
+	// The oldest value is overwritten
+	rat.Write(risc.T0, 3)
+	rat.Write(risc.T0, 4)
+	_, exists = rat.Find(risc.T0, func(v int32) bool { return v < 2 })
+	assert.False(t, exists)
+	assert.Equal(t, map[risc.RegisterType]int32{
+		risc.T0: 3,
+	}, rat.FindValues(func(v int32) bool { return v < 4 }))
+}
+
+func TestRatMax(t *testing.T) {
+	rat := comp.NewRAT[risc.RegisterType, int32](3)
+	less := func(a, b int32) bool { return a < b }
+	rat.Write(risc.T0, 2)
+	rat.Write(risc.T0, 3)
+	rat.Write(risc.T0, 1)
+	rat.Write(risc.T1, 5)
+	v, exists := rat.Max(risc.T0, func(v int32) bool { return v != 3 }, less)
+	assert.True(t, exists)
+	assert.Equal(t, int32(2), v)
+	_, exists = rat.Max(risc.T2, func(int32) bool { return true }, less)
+	assert.False(t, exists)
+}
+
+func TestRatFindAll(t *testing.T) {
+	rat := comp.NewRAT[risc.RegisterType, int32](3)
+	rat.Write(risc.T0, 1)
+	rat.Write(risc.T0, 2)
+	rat.Write(risc.T0, 3)
+	rat.Write(risc.T0, 4)
+	rat.Write(risc.T1, 5)
+	assert.Equal(t, map[risc.RegisterType][]int32{
+		risc.T0: {2, 3, 4},
+		risc.T1: {5},
+	}, rat.FindAllValues(func(int32) bool { return true }))
+	assert.Equal(t, map[risc.RegisterType][]int32{
+		risc.T0: {2, 4},
+	}, rat.FindAllValues(func(v int32) bool { return v%2 == 0 }))
+}
@@ -0,0 +1,90 @@
+package proc
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/teivah/majorana/proc/latency"
+	"github.com/teivah/majorana/proc/ref"
+	"github.com/teivah/majorana/risc"
+)
+
+// complianceMemory is the memory of the virtual machines running the
+// compliance tests, large enough for their code and data.
+const complianceMemory = 2048
+
+// complianceTests returns the self-checking tests of a directory by instruction
+// type. The type is inferred from the file name: fcvt.wu.d.asm tests FcvtWuD.
+func complianceTests(t *testing.T, fsys fs.FS) map[risc.InstructionType][]string {
+	types := make(map[string]risc.InstructionType)
+	for it := risc.Add; it <= risc.Xori; it++ {
+		types[strings.ToLower(it.String())] = it
+	}
+
+	tests := make(map[risc.InstructionType][]string)
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(name) != ".asm" {
+			return err
+		}
+		mnemonic := strings.TrimSuffix(path.Base(name), ".asm")
+		it, exists := types[strings.ReplaceAll(mnemonic, ".", "")]
+		if !exists {
+			return fmt.Errorf("%s: unknown instruction %s", name, mnemonic)
+		}
+		tests[it] = append(tests[it], name)
+		return nil
+	})
+	require.NoError(t, err)
+	return tests
+}
+
+// TestCompliance runs the self-checking ISA tests of res/isa on every virtual
+// machine and on the reference model, in a subtest per virtual machine and
+// instruction type. A test exits with 0 once all its cases passed, or with the
+// number of the first case failing.
+func TestCompliance(t *testing.T) {
+	fsys := os.DirFS("../res/isa")
+	tests := complianceTests(t, fsys)
+	for it := risc.Add; it <= risc.Xori; it++ {
+		if len(tests[it]) == 0 {
+			t.Errorf("no compliance test for %v", it)
+		}
+	}
+
+	vms := slices.Clone(allVMs)
+	vms = append(vms, struct {
+		name    string
+		factory func(memory int, profile latency.Profile) virtualMachine
+	}{"Reference", func(m int, _ latency.Profile) virtualMachine { return ref.New(m) }})
+
+	for _, vm := range vms {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			for it := risc.Add; it <= risc.Xori; it++ {
+				for _, file := range tests[it] {
+					t.Run(fmt.Sprintf("%v/%s", it, file), func(t *testing.T) {
+						// Each VM parses its own application as the runners hold
+						// the values forwarded
+						app, err := risc.ParseFS(fsys, file)
+						require.NoError(t, err)
+						v := vm.factory(complianceMemory, latency.M1)
+						_, err = v.Run(app)
+						var exitErr *risc.ExitError
+						if errors.As(err, &exitErr) {
+							t.Fatalf("%s: test case %d failed", file, exitErr.Code)
+						}
+						require.NoError(t, err)
+						require.True(t, v.Context().Exited, "%s: the test didn't exit", file)
+					})
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package proc
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teivah/majorana/proc/latency"
+	"github.com/teivah/majorana/proc/mvp1"
+	"github.com/teivah/majorana/proc/mvp2"
+	"github.com/teivah/majorana/proc/mvp3"
+	"github.com/teivah/majorana/proc/mvp4"
+	"github.com/teivah/majorana/proc/mvp5"
+	"github.com/teivah/majorana/proc/mvp6-0"
+	"github.com/teivah/majorana/proc/mvp6-1"
+	"github.com/teivah/majorana/proc/mvp6-2"
+	"github.com/teivah/majorana/proc/mvp6-3"
+	"github.com/teivah/majorana/proc/mvp7-0"
+	"github.com/teivah/majorana/proc/mvp7-1"
+	"github.com/teivah/majorana/proc/mvp8-0"
+	"github.com/teivah/majorana/risc"
+	"github.com/teivah/majorana/test"
+)
+
+var allVMs = []struct {
+	name    string
+	factory func(memory int, profile latency.Profile) virtualMachine
+}{
+	{"MVP-1", func(m int, p latency.Profile) virtualMachine { return mvp1.NewCPU(false, m, p) }},
+	{"MVP-2", func(m int, p latency.Profile) virtualMachine { return mvp2.NewCPU(false, m, p) }},
+	{"MVP-3", func(m int, p latency.Profile) virtualMachine { return mvp3.NewCPU(false, m, p) }},
+	{"MVP-4", func(m int, p latency.Profile) virtualMachine { return mvp4.NewCPU(false, m, p) }},
+	{"MVP-5", func(m int, p latency.Profile) virtualMachine { return mvp5.NewCPU(false, m, p) }},
+	{"MVP-6.0", func(m int, p latency.Profile) virtualMachine { return mvp6_0.NewCPU(false, m, p, 2, 2) }},
+	{"MVP-6.1", func(m int, p latency.Profile) virtualMachine { return mvp6_1.NewCPU(false, m, p, 2, 2) }},
+	{"MVP-6.2", func(m int, p latency.Profile) virtualMachine { return mvp6_2.NewCPU(false, m, p, 2, 2) }},
+	{"MVP-6.3", func(m int, p latency.Profile) virtualMachine { return mvp6_3.NewCPU(false, m, p, 2, 2) }},
+	{"MVP-7.0", func(m int, p latency.Profile) virtualMachine { return mvp7_0.NewCPU(false, m, p, 2) }},
+	{"MVP-7.1", func(m int, p latency.Profile) virtualMachine { return mvp7_1.NewCPU(false, m, p, 2) }},
+	{"MVP-8", func(m int, p latency.Profile) virtualMachine { return mvp8_0.NewCPU(false, m, p, 3) }},
+}
+
+func words(words ...uint32) []byte {
+	b := make([]byte, 0, 4*len(words))
+	for _, word := range words {
+		b = binary.LittleEndian.AppendUint32(b, word)
+	}
+	return b
+}
+
+func TestELF(t *testing.T) {
+	// Sums an array from .data into .bss, starting from _start rather than
+	// from the beginning of .text.
+	app, err := risc.Parse(`.data
+array: .word 10, 20, 30, 40
+.bss
+result: .zero 4
+.text
+    li a0, 1
+_start:
+    la t0, array
+    li t1, 4
+    li t2, 0
+loop:
+    lw t3, 0(t0)
+    add t2, t2, t3
+    addi t0, t0, 4
+    addi t1, t1, -1
+    bnez t1, loop
+    la t0, result
+    sw t2, 0(t0)`)
+	require.NoError(t, err)
+	app.Entry = app.Labels["_start"]
+	data, err := risc.EncodeELF(app)
+	require.NoError(t, err)
+
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			app, err := risc.ParseELF(data, 1024)
+			require.NoError(t, err)
+			v := vm.factory(1024, latency.M1)
+			_, err = v.Run(app)
+			require.NoError(t, err)
+			assert.Equal(t, int32(0), v.Context().Registers[risc.A0])
+			assert.Equal(t, int8(100), v.Context().Memory[app.Labels["result"]])
+		})
+	}
+}
+
+func TestELFFixture(t *testing.T) {
+	// The same program, built independently of EncodeELF with the text at
+	// 0x100 and the data at 0x200.
+	data := test.ELF(t, 0x104, map[string]uint32{
+		"_start": 0x104,
+		"loop":   0x110,
+		"array":  0x200,
+		"result": 0x210,
+	}, test.ELFSegment{
+		Addr: 0x100,
+		Data: words(
+			0x00100513, // addi a0, zero, 1
+			0x20000293, // addi t0, zero, 0x200
+			0x00400313, // addi t1, zero, 4
+			0x00000393, // addi t2, zero, 0
+			0x0002ae03, // lw t3, 0(t0)
+			0x01c383b3, // add t2, t2, t3
+			0x00428293, // addi t0, t0, 4
+			0xfff30313, // addi t1, t1, -1
+			0xfe0318e3, // bne t1, zero, -16
+			0x20702823, // sw t2, 0x210(zero)
+		),
+		Exec: true,
+	}, test.ELFSegment{
+		Addr:    0x200,
+		Data:    words(10, 20, 30, 40),
+		MemSize: 0x14,
+	})
+
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			app, err := risc.ParseELF(data, 1024)
+			require.NoError(t, err)
+			v := vm.factory(1024, latency.M1)
+			_, err = v.Run(app)
+			require.NoError(t, err)
+			assert.Equal(t, int32(0), v.Context().Registers[risc.A0])
+			assert.Equal(t, int8(100), v.Context().Memory[0x210])
+			// The text isn't loaded in memory
+			assert.Equal(t, int8(0), v.Context().Memory[0x104])
+		})
+	}
+}
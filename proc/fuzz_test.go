@@ -0,0 +1,54 @@
+package proc
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/teivah/majorana/proc/latency"
+	"github.com/teivah/majorana/proc/ref"
+	"github.com/teivah/majorana/risc"
+	"github.com/teivah/majorana/test"
+)
+
+// FuzzRandomProgram runs a random program on every virtual machine in lockstep
+// with the reference model. The seed picks the program, the other inputs the
+// instruction mix, the dependency density and the memory footprint.
+func FuzzRandomProgram(f *testing.F) {
+	for seed := uint64(0); seed < 16; seed++ {
+		f.Add(seed, uint8(4), uint8(4), uint8(1), uint8(2), uint8(2), uint8(2), uint8(128), uint8(5))
+	}
+	// Memory bound
+	f.Add(uint64(16), uint8(1), uint8(1), uint8(0), uint8(6), uint8(6), uint8(1), uint8(200), uint8(0))
+	// Branch bound
+	f.Add(uint64(17), uint8(2), uint8(2), uint8(0), uint8(1), uint8(1), uint8(6), uint8(64), uint8(3))
+	// Long dependency chains
+	f.Add(uint64(18), uint8(4), uint8(4), uint8(4), uint8(1), uint8(1), uint8(1), uint8(255), uint8(8))
+
+	f.Fuzz(func(t *testing.T, seed uint64, alu, immediate, multiply, load, store, branch, dependency, footprint uint8) {
+		config := test.DefaultProgramConfig()
+		config.ALU = int(alu % 8)
+		config.Immediate = int(immediate % 8)
+		config.Multiply = int(multiply % 8)
+		config.Load = int(load % 8)
+		config.Store = int(store % 8)
+		config.Branch = int(branch % 8)
+		config.Dependency = float64(dependency) / math.MaxUint8
+		config.Memory = 8 << (footprint % 9)
+		if config.ALU+config.Immediate+config.Multiply+config.Load+config.Store+config.Branch == 0 {
+			config.ALU = 1
+		}
+
+		source := test.RandomProgram(rand.New(rand.NewPCG(seed, seed)), config)
+		for _, vm := range allVMs {
+			// The instructions hold the forwarded values, so they aren't shared
+			// between virtual machines
+			app, err := risc.Parse(source)
+			require.NoError(t, err, source)
+			if err := ref.Lockstep(vm.factory(config.Memory, latency.M1), app); err != nil {
+				t.Fatalf("%s: %v\n%s", vm.name, err, source)
+			}
+		}
+	})
+}
@@ -0,0 +1,661 @@
+package proc
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teivah/majorana/proc/latency"
+	"github.com/teivah/majorana/risc"
+)
+
+func TestRV32I(t *testing.T) {
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			v := vm.factory(64, latency.M1)
+			v.Context().Registers[risc.A0] = -32767
+			_, err := execute(t, v, `sh a0, 4(zero)
+fence
+lb t0, 4(zero)
+lbu t1, 5(zero)
+lh t2, 4(zero)
+lhu s2, 4(zero)
+sltiu s3, a0, -1
+sltu s4, zero, a0`)
+			require.NoError(t, err)
+			ctx := v.Context()
+			assert.Equal(t, int32(1), ctx.Registers[risc.T0])
+			assert.Equal(t, int32(128), ctx.Registers[risc.T1])
+			assert.Equal(t, int32(-32767), ctx.Registers[risc.T2])
+			assert.Equal(t, int32(32769), ctx.Registers[risc.S2])
+			assert.Equal(t, int32(1), ctx.Registers[risc.S3])
+			assert.Equal(t, int32(1), ctx.Registers[risc.S4])
+		})
+	}
+}
+
+func TestPseudoInstructions(t *testing.T) {
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			v := vm.factory(64, latency.M1)
+			v.Context().Registers[risc.A0] = -1
+			_, err := execute(t, v, `li s0, 305419896
+la s1, f
+tail f
+back:
+bgtu a0, s0, 1
+addi s2, s2, 100
+1:
+neg s3, s2
+j end
+f:
+addi s2, s2, 1
+j back
+end:`)
+			require.NoError(t, err)
+			ctx := v.Context()
+			assert.Equal(t, int32(305419896), ctx.Registers[risc.S0])
+			assert.Equal(t, int32(40), ctx.Registers[risc.S1])
+			assert.Equal(t, int32(1), ctx.Registers[risc.S2])
+			assert.Equal(t, int32(-1), ctx.Registers[risc.S3])
+		})
+	}
+}
+
+func TestCallReturn(t *testing.T) {
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			// The entry point computes fib(10) recursively, saving ra on the
+			// stack, then returns to halt.
+			v := vm.factory(256, latency.M1)
+			_, err := execute(t, v, `li sp, 256
+addi sp, sp, -4
+sw ra, 0(sp)
+li a0, 10
+call fib
+mv s0, a0
+li s1, 1
+lw ra, 0(sp)
+addi sp, sp, 4
+ret
+li s1, 2
+fib:
+li t0, 2
+blt a0, t0, base
+addi sp, sp, -12
+sw ra, 8(sp)
+sw a0, 4(sp)
+addi a0, a0, -1
+call fib
+sw a0, 0(sp)
+lw a0, 4(sp)
+addi a0, a0, -2
+call fib
+lw t0, 0(sp)
+add a0, a0, t0
+lw ra, 8(sp)
+addi sp, sp, 12
+base:
+ret`)
+			require.NoError(t, err)
+			ctx := v.Context()
+			assert.Equal(t, int32(55), ctx.Registers[risc.S0])
+			assert.Equal(t, int32(1), ctx.Registers[risc.S1])
+			assert.Equal(t, int32(256), ctx.Registers[risc.Sp])
+		})
+	}
+}
+
+func TestDataSegment(t *testing.T) {
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			v := vm.factory(64, latency.M1)
+			_, err := execute(t, v, `.text
+la t0, values
+lw t1, count(zero)
+loop:
+lw t2, 0(t0)
+add s0, s0, t2
+addi t0, t0, 4
+addi t1, t1, -1
+bnez t1, loop
+sw s0, sum(zero)
+.data
+count: .word 3
+values: .word 1, 20, 300
+.bss
+sum: .space 4`)
+			require.NoError(t, err)
+			ctx := v.Context()
+			assert.Equal(t, int32(321), ctx.Registers[risc.S0])
+			assert.Equal(t, []int8{65, 1, 0, 0}, ctx.Memory[16:20])
+		})
+	}
+}
+
+func TestMExtension(t *testing.T) {
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			v := vm.factory(64, latency.M1)
+			v.Context().Registers[risc.A0] = math.MinInt32
+			v.Context().Registers[risc.A1] = -1
+			_, err := execute(t, v, `mulh t0, a0, a0
+mulhsu t1, a1, a1
+mulhu t2, a1, a1
+div s2, a0, a1
+divu s3, a1, zero
+rem s4, a0, zero
+remu s5, a1, a0`)
+			require.NoError(t, err)
+			ctx := v.Context()
+			assert.Equal(t, int32(0x40000000), ctx.Registers[risc.T0])
+			assert.Equal(t, int32(-1), ctx.Registers[risc.T1])
+			assert.Equal(t, int32(-2), ctx.Registers[risc.T2])
+			assert.Equal(t, int32(math.MinInt32), ctx.Registers[risc.S2])
+			assert.Equal(t, int32(-1), ctx.Registers[risc.S3])
+			assert.Equal(t, int32(math.MinInt32), ctx.Registers[risc.S4])
+			assert.Equal(t, int32(math.MaxInt32), ctx.Registers[risc.S5])
+		})
+	}
+}
+
+func TestAExtension(t *testing.T) {
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			v := vm.factory(64, latency.M1)
+			_, err := execute(t, v, `addi t0, zero, 16
+addi t1, zero, 5
+add:
+amoadd.w t2, t1, (t0)
+addi t1, t1, -1
+bne t1, zero, add
+retry:
+lr.w t3, (t0)
+addi t3, t3, 100
+sc.w t4, t3, (t0)
+bne t4, zero, retry
+sc.w t5, t3, (t0)
+amoswap.w.aqrl s2, zero, (t0)
+lw s3, 16(zero)
+addi a1, zero, -1
+amomaxu.w s4, a1, (t0)`)
+			require.NoError(t, err)
+			ctx := v.Context()
+			assert.Equal(t, int32(14), ctx.Registers[risc.T2])
+			assert.Equal(t, int32(115), ctx.Registers[risc.T3])
+			assert.Equal(t, int32(0), ctx.Registers[risc.T4])
+			assert.Equal(t, int32(1), ctx.Registers[risc.T5])
+			assert.Equal(t, int32(115), ctx.Registers[risc.S2])
+			assert.Equal(t, int32(0), ctx.Registers[risc.S3])
+			assert.Equal(t, int32(0), ctx.Registers[risc.S4])
+			assert.Equal(t, []int8{-1, -1, -1, -1}, ctx.Memory[16:20])
+		})
+	}
+}
+
+func TestFDExtensions(t *testing.T) {
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			v := vm.factory(64, latency.M1)
+			_, err := execute(t, v, `addi t0, zero, 1
+addi t1, zero, 6
+addi t2, zero, 2
+addi s0, zero, 0
+fcvt.d.w ft0, zero
+fcvt.d.w ft2, t2
+loop:
+fcvt.d.w ft1, t0
+fmul.d ft3, ft1, ft1
+fdiv.d ft3, ft3, ft2
+fadd.d ft0, ft0, ft3
+fsd ft3, 0(s0)
+addi s0, s0, 8
+addi t0, t0, 1
+blt t0, t1, loop
+fld fa0, 32(zero)
+fcvt.w.d a0, ft0, rtz
+flt.d a1, fa0, ft0
+fcvt.s.d fs0, ft0
+fmadd.s fs1, fs0, fs0, fs0
+fsw fs1, 40(zero)
+flw fs2, 40(zero)
+fmv.x.w a2, fs2
+fsqrt.d fa1, fa0
+fcvt.s.w fs3, t1
+fsub.s fs3, fs3, fs0`)
+			require.NoError(t, err)
+			ctx := v.Context()
+			assert.Equal(t, math.Float64bits(27.5), ctx.FRegisters[risc.Ft0])
+			assert.Equal(t, math.Float64bits(12.5), ctx.FRegisters[risc.Fa0])
+			assert.Equal(t, math.Float64bits(math.Sqrt(12.5)), ctx.FRegisters[risc.Fa1])
+			assert.Equal(t, int32(27), ctx.Registers[risc.A0])
+			assert.Equal(t, int32(1), ctx.Registers[risc.A1])
+			assert.Equal(t, int32(math.Float32bits(783.75)), ctx.Registers[risc.A2])
+			assert.Equal(t, 0xffffffff00000000|uint64(math.Float32bits(783.75)), ctx.FRegisters[risc.Fs2])
+			assert.Equal(t, 0xffffffff00000000|uint64(math.Float32bits(-21.5)), ctx.FRegisters[risc.Fs3])
+		})
+	}
+}
+
+func TestCExtension(t *testing.T) {
+	// The loop starts at pc 2, the 32-bit addi at pc 62 straddles the first two
+	// L1I lines
+	src := "c.li a1, 3\nloop:\n" + strings.Repeat("c.addi a0, 1\n", 29) + `c.addi a1, -1
+addi a2, a2, 1
+c.bnez a1, loop
+c.jal function
+c.j end
+function:
+c.mv a3, a0
+c.jr ra
+end:
+c.mv a4, ra
+c.li ra, 0`
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			v := vm.factory(64, latency.M1)
+			_, err := execute(t, v, src)
+			require.NoError(t, err)
+			ctx := v.Context()
+			assert.Equal(t, int32(87), ctx.Registers[risc.A0])
+			assert.Equal(t, int32(0), ctx.Registers[risc.A1])
+			assert.Equal(t, int32(3), ctx.Registers[risc.A2])
+			assert.Equal(t, int32(87), ctx.Registers[risc.A3])
+			assert.Equal(t, int32(70), ctx.Registers[risc.A4])
+		})
+	}
+}
+
+func TestZicsrCounters(t *testing.T) {
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			v := vm.factory(64, latency.M1)
+			cycles, err := execute(t, v, `rdcycle s0
+rdinstret s1
+addi t0, zero, 50
+loop:
+addi t0, t0, -1
+bnez t0, loop
+rdcycle s2
+rdinstret s3`)
+			require.NoError(t, err)
+			ctx := v.Context()
+			assert.Greater(t, ctx.Registers[risc.S2], ctx.Registers[risc.S0])
+			assert.LessOrEqual(t, int(ctx.Registers[risc.S2]), cycles)
+			assert.GreaterOrEqual(t, ctx.Registers[risc.S3]-ctx.Registers[risc.S1], int32(100))
+		})
+	}
+}
+
+func TestInstret(t *testing.T) {
+	// The instructions fetched after the mispredicted branches and squashed
+	// aren't retired
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			v := vm.factory(64, latency.M1)
+			// The branch waits for the load, from memory
+			_, err := execute(t, v, `addi t0, zero, 10
+loop:
+addi t0, t0, -1
+bnez t0, loop
+lw t3, 32(zero)
+beqz t3, end
+addi t1, t1, 1
+addi t2, t2, 1
+addi t4, t4, 1
+addi t5, t5, 1
+addi t6, t6, 1
+addi s1, s1, 1
+end:
+addi s2, s2, 1`)
+			require.NoError(t, err)
+			assert.Equal(t, int32(0), v.Context().Registers[risc.T1])
+			assert.Equal(t, int64(1+10*2+3), v.Context().Instret)
+		})
+	}
+}
+
+func TestLatencyProfile(t *testing.T) {
+	// The memory is faster than on the M1.
+	fast := latency.Profile{
+		RegisterAccess: 1,
+		L1Access:       1,
+		L2Access:       4,
+		L3Access:       8,
+		MemoryAccess:   20,
+		Flush:          1,
+	}
+	instructions := `li t0, 8
+sw t0, 0(zero)
+fence
+loop:
+lw t1, 0(zero)
+add s0, s0, t1
+addi t0, t0, -1
+bnez t0, loop`
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			m1 := vm.factory(64, latency.M1)
+			m1Cycles, err := execute(t, m1, instructions)
+			require.NoError(t, err)
+			v := vm.factory(64, fast)
+			cycles, err := execute(t, v, instructions)
+			require.NoError(t, err)
+			assert.Equal(t, int32(64), m1.Context().Registers[risc.S0])
+			assert.Equal(t, int32(64), v.Context().Registers[risc.S0])
+			assert.Less(t, cycles, m1Cycles)
+		})
+	}
+}
+
+func TestExecutionLatency(t *testing.T) {
+	// The multiplications take 20 cycles instead of 1.
+	slowMul := latency.M1
+	slowMul.Execution = map[risc.InstructionType]int{risc.Mul: 20}
+	instructions := `li t0, 8
+li s0, 1
+li t1, 3
+loop:
+mul s0, s0, t1
+addi t0, t0, -1
+bnez t0, loop`
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			m1 := vm.factory(64, latency.M1)
+			m1Cycles, err := execute(t, m1, instructions)
+			require.NoError(t, err)
+			v := vm.factory(64, slowMul)
+			cycles, err := execute(t, v, instructions)
+			require.NoError(t, err)
+			assert.Equal(t, int32(6561), m1.Context().Registers[risc.S0])
+			assert.Equal(t, int32(6561), v.Context().Registers[risc.S0])
+			// Each of the 8 dependent multiplications delays the loop, even if
+			// part of its latency overlaps with the following instructions
+			assert.Greater(t, cycles, m1Cycles+8*10)
+		})
+	}
+}
+
+func TestSyscalls(t *testing.T) {
+	app, err := risc.Parse(`addi a0, zero, 1
+addi a1, zero, 0
+addi a2, zero, 3
+addi a7, zero, 64
+ecall
+mv s0, a0
+addi a0, zero, 0
+addi a1, zero, 32
+addi a2, zero, 4
+addi a7, zero, 63
+ecall
+mv s1, a0
+lw s2, 32(zero)
+addi a0, zero, 7
+addi a7, zero, 93
+ecall
+addi s3, zero, 1`)
+	require.NoError(t, err)
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			v := vm.factory(64, latency.M1)
+			var stdout strings.Builder
+			ctx := v.Context()
+			ctx.Env = &risc.Linux{Stdin: strings.NewReader("abcd"), Stdout: &stdout}
+			copy(ctx.Memory, []int8{'o', 'k', '\n'})
+			_, err := v.Run(app)
+			var exitErr *risc.ExitError
+			require.ErrorAs(t, err, &exitErr)
+			assert.Equal(t, int32(7), exitErr.Code)
+			assert.Equal(t, "ok\n", stdout.String())
+			assert.Equal(t, int32(3), ctx.Registers[risc.S0])
+			assert.Equal(t, int32(4), ctx.Registers[risc.S1])
+			assert.Equal(t, int32(0x64636261), ctx.Registers[risc.S2])
+			assert.Equal(t, int32(0), ctx.Registers[risc.S3])
+		})
+	}
+}
+
+func TestExecutionErrorDuringFlush(t *testing.T) {
+	// The branch executes in 20 cycles and fails as its label doesn't exist.
+	// The jump following it flushes the pipeline meanwhile, so the error is
+	// raised while the previous instructions are completed.
+	slowBranch := latency.M1
+	slowBranch.Execution = map[risc.InstructionType]int{risc.Beq: 20}
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			v := vm.factory(64, slowBranch)
+			app, err := risc.Parse(`beq zero, zero, target
+j end
+target:
+addi s0, zero, 1
+end:`)
+			require.NoError(t, err)
+			delete(app.Labels, "target")
+			_, err = v.Run(app)
+			require.ErrorContains(t, err, "label target does not exist")
+			assert.Equal(t, int32(0), v.Context().Registers[risc.S0])
+		})
+	}
+}
+
+func TestTraps(t *testing.T) {
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			// Each VM parses its own application as the runners hold the
+			// forwarded values. The misaligned load following the taken branch
+			// is squashed, so it must not trap.
+			app, err := risc.Parse(`addi t0, zero, 52
+csrw mtvec, t0
+beq zero, zero, skip
+lw t2, 1(zero)
+skip:
+addi t1, zero, 2
+lw t2, 0(t1)
+addi s6, s6, 1
+sw t2, 128(zero)
+addi s6, s6, 1
+unimp
+addi s6, s6, 1
+addi s2, zero, 1
+j end
+addi s0, s0, 1
+slli s1, s1, 4
+csrr t3, mcause
+or s1, s1, t3
+csrr s4, mepc
+addi t4, s4, 4
+jalr zero, t4, 0
+end:`)
+			require.NoError(t, err)
+			v := vm.factory(64, latency.M1)
+			_, err = v.Run(app)
+			require.NoError(t, err)
+			ctx := v.Context()
+			assert.Equal(t, int32(3), ctx.Registers[risc.S0])
+			assert.Equal(t, int32(0x472), ctx.Registers[risc.S1])
+			assert.Equal(t, int32(36), ctx.Registers[risc.S4])
+			assert.Equal(t, int32(3), ctx.Registers[risc.S6])
+			assert.Equal(t, int32(1), ctx.Registers[risc.S2])
+			assert.Equal(t, int32(0), ctx.Registers[risc.T2])
+		})
+	}
+}
+
+func TestUnhandledException(t *testing.T) {
+	app, err := risc.Parse(`addi t0, zero, 1
+lh t1, 0(t0)
+addi s0, zero, 1`)
+	require.NoError(t, err)
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			v := vm.factory(64, latency.M1)
+			_, err := v.Run(app)
+			var e *risc.Exception
+			require.ErrorAs(t, err, &e)
+			assert.Equal(t, risc.Exception{Cause: risc.CauseLoadAddressMisaligned, Pc: 4, Value: 1}, *e)
+		})
+	}
+}
+
+func TestReservedRoundingMode(t *testing.T) {
+	app, err := risc.Parse(`csrwi frm, 5
+fadd.s ft0, ft1, ft2
+addi s0, zero, 1`)
+	require.NoError(t, err)
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			v := vm.factory(64, latency.M1)
+			_, err := v.Run(app)
+			var e *risc.Exception
+			require.ErrorAs(t, err, &e)
+			// mtval holds fadd.s ft0, ft1, ft2, dyn
+			assert.Equal(t, risc.Exception{Cause: risc.CauseIllegalInstruction, Pc: 4, Value: 0x0020f053}, *e)
+			assert.Equal(t, int32(0), v.Context().Registers[risc.S0])
+		})
+	}
+}
+
+func TestMachineMode(t *testing.T) {
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			app, err := risc.Parse(`addi t0, zero, 48
+csrw mtvec, t0
+csrsi mstatus, 8
+addi sp, zero, 7
+csrwi mscratch, 9
+lw t1, 0(zero)
+addi t1, t1, 1
+csrr s1, mhartid
+ebreak
+csrr s5, mstatus
+addi s6, t1, 1
+j end
+csrrw sp, mscratch, sp
+csrr s3, mstatus
+csrr s4, mepc
+addi s4, s4, 4
+csrw mepc, s4
+wfi
+mret
+end:`)
+			require.NoError(t, err)
+			v := vm.factory(64, latency.M1)
+			_, err = v.Run(app)
+			require.NoError(t, err)
+			ctx := v.Context()
+			assert.Equal(t, int32(0), ctx.Registers[risc.S1])
+			assert.Equal(t, risc.MstatusMPP|risc.MstatusMPIE, ctx.Registers[risc.S3])
+			assert.Equal(t, int32(36), ctx.Registers[risc.S4])
+			assert.Equal(t, risc.MstatusMPP|risc.MstatusMPIE|risc.MstatusMIE, ctx.Registers[risc.S5])
+			assert.Equal(t, int32(2), ctx.Registers[risc.S6])
+			assert.Equal(t, int32(9), ctx.Registers[risc.Sp])
+			assert.Equal(t, int32(7), ctx.Mscratch)
+		})
+	}
+}
+
+func TestInterrupts(t *testing.T) {
+	for _, vm := range allVMs {
+		t.Run(vm.name, func(t *testing.T) {
+			t.Parallel()
+			app, err := risc.Parse(`addi t0, zero, 44
+csrw mtvec, t0
+li t1, 33570816
+addi t2, zero, 100
+sw t2, 0(t1)
+sw zero, 4(t1)
+addi t3, zero, 128
+csrw mie, t3
+csrsi mstatus, 8
+loop:
+beq s0, zero, loop
+j end
+addi s0, s0, 1
+csrr s1, mcause
+csrr s2, mepc
+addi t2, zero, -1
+sw t2, 4(t1)
+mret
+end:`)
+			require.NoError(t, err)
+			v := vm.factory(64, latency.M1)
+			_, err = v.Run(app)
+			require.NoError(t, err)
+			ctx := v.Context()
+			assert.Equal(t, int32(1), ctx.Registers[risc.S0])
+			assert.Equal(t, int32(risc.CauseMachineTimerInterrupt), ctx.Registers[risc.S1])
+			assert.Equal(t, int32(36), ctx.Registers[risc.S2])
+
+			// The instructions following the one raising the software interrupt
+			// are executed exactly once
+			app, err = risc.Parse(`addi t0, zero, 37
+csrw mtvec, t0
+li t1, 33554432
+addi t2, zero, 1
+csrwi mie, 8
+csrsi mstatus, 8
+sw t2, 0(t1)
+addi s0, s0, 1
+addi s0, s0, 1
+j end
+nop
+nop
+addi s1, s1, 1
+csrr s2, mcause
+csrr s3, mepc
+sw zero, 0(t1)
+mret
+end:`)
+			require.NoError(t, err)
+			v = vm.factory(64, latency.M1)
+			_, err = v.Run(app)
+			require.NoError(t, err)
+			ctx = v.Context()
+			assert.Equal(t, int32(2), ctx.Registers[risc.S0])
+			assert.Equal(t, int32(1), ctx.Registers[risc.S1])
+			assert.Equal(t, int32(risc.CauseMachineSoftwareInterrupt), ctx.Registers[risc.S2])
+			assert.GreaterOrEqual(t, ctx.Registers[risc.S3], int32(28))
+			assert.LessOrEqual(t, ctx.Registers[risc.S3], int32(36))
+
+			// The stores to msip and mtimecmp following a mispredicted branch,
+			// which executes in 20 cycles, aren't executed
+			app, err = risc.Parse(`li t1, 33554432
+li t4, 33570816
+addi t2, zero, 1
+beqz zero, end
+sw t2, 0(t1)
+sw t2, 0(t4)
+sw zero, 4(t4)
+end:
+nop`)
+			require.NoError(t, err)
+			slowBranch := latency.M1
+			slowBranch.Execution = map[risc.InstructionType]int{risc.Beqz: 20}
+			v = vm.factory(64, slowBranch)
+			_, err = v.Run(app)
+			require.NoError(t, err)
+			assert.Equal(t, int32(0), v.Context().CLINT.Msip)
+			assert.Equal(t, uint64(math.MaxUint64), v.Context().CLINT.Mtimecmp)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+// Package latency represents latency in cycles.
+package latency
+
+import "github.com/teivah/majorana/risc"
+
+// Profile is the latency profile of a core: the execution latency of each
+// instruction and the access latency of each memory level.
+type Profile struct {
+	// Execution holds the execution latency per instruction type. An
+	// instruction missing from it executes in a single cycle.
+	Execution      map[risc.InstructionType]int
+	RegisterAccess int
+	L1Access       int
+	L2Access       int
+	L3Access       int
+	MemoryAccess   int
+	Flush          int
+}
+
+// Cycles returns the number of cycles to execute an instruction.
+func (p Profile) Cycles(ins risc.InstructionType) int {
+	if cycles, exists := p.Execution[ins]; exists {
+		return cycles
+	}
+	return 1
+}
+
+// M1 is the Apple M1 profile. The loads, stores and atomics execute in a
+// single cycle like the other instructions, their memory accesses being
+// charged by the cache hierarchy.
+// Source https://www.7-cpu.com/cpu/Apple_M1.html
+var M1 = Profile{
+	RegisterAccess: 1,
+	L1Access:       3,
+	L2Access:       18,
+	L3Access:       18 + 32,  // 18 + 10 ns
+	MemoryAccess:   18 + 291, // 19 + 91 ns
+	Flush:          1,
+}
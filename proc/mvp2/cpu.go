@@ -3,7 +3,7 @@ package mvp2
 import (
 	"fmt"
 
-	"github.com/teivah/majorana/common/latency"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -13,14 +13,16 @@ const (
 )
 
 type CPU struct {
+	profile latency.Profile
 	ctx     *risc.Context
 	cycle   int
 	l1iFrom int32
 	l1iTo   int32
 }
 
-func NewCPU(debug bool, memoryBytes int) *CPU {
+func NewCPU(debug bool, memoryBytes int, profile latency.Profile) *CPU {
 	return &CPU{
+		profile: profile,
 		ctx:     risc.NewContext(debug, memoryBytes, false),
 		l1iFrom: -1,
 		l1iTo:   -1,
@@ -32,22 +34,41 @@ func (m *CPU) Context() *risc.Context {
 }
 
 func (m *CPU) Run(app risc.Application) (int, error) {
-loop:
-	var pc int32
-	for pc/4 < int32(len(app.Instructions)) {
+	if err := m.ctx.Load(app); err != nil {
+		return 0, err
+	}
+	pc := app.Entry
+	for pc < app.End() {
+		// A pending interrupt is taken before the next instruction
+		m.ctx.Cycles = int64(m.cycle)
+		if e := m.ctx.Interrupt(pc); e != nil {
+			var err error
+			if pc, err = m.ctx.Trap(e); err != nil {
+				return 0, err
+			}
+			continue
+		}
 		nextPc := m.fetchInstruction(pc)
 		r := m.decode(app, nextPc)
 		exe, ins, err := m.execute(app, r, pc)
 		if err != nil {
 			return 0, err
 		}
-		if exe.Return {
-			return m.cycle, nil
+		if exe.Exception != nil {
+			// The instruction doesn't retire
+			if pc, err = m.ctx.Trap(exe.Exception); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		m.ctx.Instret++
+		if err := m.ctx.Retire(pc, r, exe); err != nil {
+			return 0, err
 		}
 		if exe.PcChange {
 			pc = exe.NextPc
 		} else {
-			pc += 4
+			pc += app.Size(pc)
 		}
 
 		if exe.RegisterChange {
@@ -55,19 +76,14 @@ loop:
 			if m.ctx.Debug {
 				fmt.Println(ins, m.ctx.Registers)
 			}
-			m.cycle += latency.RegisterAccess
-		} else if exe.MemoryChange {
+			m.cycle += m.profile.RegisterAccess
+		}
+		if exe.MemoryChange {
 			m.ctx.WriteMemory(exe)
-			m.cycle += latency.MemoryAccess
+			m.cycle += m.profile.MemoryAccess
 		}
 	}
-	if m.ctx.Registers[risc.Ra] != 0 {
-		pc = m.ctx.Registers[risc.Ra]
-		m.ctx.Registers[risc.Ra] = 0
-		goto loop
-	}
-
-	return m.cycle, nil
+	return m.cycle, m.ctx.ExitError()
 }
 
 func (m *CPU) Stats() map[string]any {
@@ -76,7 +92,7 @@ func (m *CPU) Stats() map[string]any {
 
 func (m *CPU) fetchInstruction(pc int32) int32 {
 	if m.isPresentInL1i(pc) {
-		m.cycle += latency.L1Access
+		m.cycle += m.profile.L1Access
 	} else {
 		m.fetchL1i(pc)
 	}
@@ -89,13 +105,13 @@ func (m *CPU) isPresentInL1i(pc int32) bool {
 }
 
 func (m *CPU) fetchL1i(pc int32) {
-	m.cycle += latency.MemoryAccess
+	m.cycle += m.profile.MemoryAccess
 	m.l1iFrom = pc
 	m.l1iTo = pc + l1iSize
 }
 
 func (m *CPU) decode(app risc.Application, pc int32) risc.InstructionRunner {
-	r := app.Instructions[pc/4]
+	r := app.Fetch(pc)
 	m.cycle += cyclesDecode
 	return r
 }
@@ -107,13 +123,14 @@ func (m *CPU) execute(app risc.Application, r risc.InstructionRunner, pc int32)
 		for _, addr := range addrs {
 			memory = append(memory, m.ctx.Memory[addr])
 		}
-		m.cycle += latency.MemoryAccess
+		m.cycle += m.profile.MemoryAccess
 	}
 
+	m.ctx.Cycles = int64(m.cycle)
 	exe, err := r.Run(m.ctx, app.Labels, pc, memory, 0)
 	if err != nil {
 		return risc.Execution{}, 0, err
 	}
-	m.cycle += r.InstructionType().Cycles()
+	m.cycle += m.profile.Cycles(r.InstructionType())
 	return exe, r.InstructionType(), nil
 }
@@ -3,8 +3,8 @@ package mvp3
 import (
 	"fmt"
 
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -20,16 +20,18 @@ const (
 )
 
 type CPU struct {
-	ctx   *risc.Context
-	cycle int
-	mmu   *memoryManagementUnit
+	profile latency.Profile
+	ctx     *risc.Context
+	cycle   int
+	mmu     *memoryManagementUnit
 }
 
-func NewCPU(debug bool, memoryBytes int) *CPU {
+func NewCPU(debug bool, memoryBytes int, profile latency.Profile) *CPU {
 	ctx := risc.NewContext(debug, memoryBytes, false)
 	return &CPU{
-		ctx: ctx,
-		mmu: newMemoryManagementUnit(ctx),
+		profile: profile,
+		ctx:     ctx,
+		mmu:     newMemoryManagementUnit(ctx, profile),
 	}
 }
 
@@ -38,21 +40,41 @@ func (m *CPU) Context() *risc.Context {
 }
 
 func (m *CPU) Run(app risc.Application) (int, error) {
-	var pc int32
-	for pc/4 < int32(len(app.Instructions)) {
-		nextPc := m.fetchInstruction(pc)
+	if err := m.ctx.Load(app); err != nil {
+		return 0, err
+	}
+	pc := app.Entry
+	for pc < app.End() {
+		// A pending interrupt is taken before the next instruction
+		m.ctx.Cycles = int64(m.cycle)
+		if e := m.ctx.Interrupt(pc); e != nil {
+			var err error
+			if pc, err = m.ctx.Trap(e); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		nextPc := m.fetchInstruction(app, pc)
 		r := m.decode(app, nextPc)
 		exe, ins, err := m.execute(app, r, pc)
 		if err != nil {
 			return 0, err
 		}
-		if exe.Return {
-			break
+		if exe.Exception != nil {
+			// The instruction doesn't retire
+			if pc, err = m.ctx.Trap(exe.Exception); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		m.ctx.Instret++
+		if err := m.ctx.Retire(pc, r, exe); err != nil {
+			return 0, err
 		}
 		if exe.PcChange {
 			pc = exe.NextPc
 		} else {
-			pc += 4
+			pc += app.Size(pc)
 		}
 
 		if exe.RegisterChange {
@@ -60,43 +82,39 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 			if m.ctx.Debug {
 				fmt.Println(ins, m.ctx.Registers)
 			}
-			m.cycle += latency.RegisterAccess
-		} else if exe.MemoryChange {
+			m.cycle += m.profile.RegisterAccess
+		}
+		if exe.MemoryChange {
 			if m.mmu.doesExecutionMemoryChangesExistsInL1D(exe) {
 				m.mmu.writeExecutionMemoryChangesToL1D(exe)
-				m.cycle += latency.L1Access
+				m.cycle += m.profile.L1Access
 			} else {
 				m.ctx.WriteMemory(exe)
-				m.cycle += latency.MemoryAccess
+				m.cycle += m.profile.MemoryAccess
 			}
 		}
 	}
-	//if m.ctx.Registers[risc.Ra] != 0 {
-	//	pc = m.ctx.Registers[risc.Ra]
-	//	m.ctx.Registers[risc.Ra] = 0
-	//	goto loop
-	//}
 	m.cycle += m.mmu.flush()
-	return m.cycle, nil
+	return m.cycle, m.ctx.ExitError()
 }
 
 func (m *CPU) Stats() map[string]any {
 	return nil
 }
 
-func (m *CPU) fetchInstruction(pc int32) int32 {
-	if _, exists := m.mmu.getFromL1I([]int32{pc}); exists {
-		m.cycle += latency.L1Access
+func (m *CPU) fetchInstruction(app risc.Application, pc int32) int32 {
+	if addr, missing := m.mmu.missingFromL1I(pc, app.Size(pc)); !missing {
+		m.cycle += m.profile.L1Access
 	} else {
-		m.cycle += latency.MemoryAccess
-		m.mmu.pushLineToL1I(comp.AlignedAddress(pc), make([]int8, l1ICacheLineSize))
+		m.cycle += m.profile.MemoryAccess
+		m.mmu.pushLineToL1I(comp.AlignedAddress(addr), make([]int8, l1ICacheLineSize))
 	}
 
 	return pc
 }
 
 func (m *CPU) decode(app risc.Application, pc int32) risc.InstructionRunner {
-	r := app.Instructions[pc/4]
+	r := app.Fetch(pc)
 	m.cycle += cyclesDecode
 	return r
 }
@@ -105,13 +123,13 @@ func (m *CPU) execute(app risc.Application, r risc.InstructionRunner, pc int32)
 	addrs := r.MemoryRead(m.ctx, 0)
 	var memory []int8
 	if len(addrs) != 0 {
-		m.cycle += latency.L1Access
+		m.cycle += m.profile.L1Access
 		if mem, exists := m.mmu.getFromL1D(addrs); exists {
 			memory = mem
 		} else {
-			m.cycle += latency.MemoryAccess
-			line := m.mmu.fetchCacheLine(addrs[0])
-			m.mmu.pushLineToL1D(comp.AlignedAddress(addrs[0]), line)
+			m.cycle += m.profile.MemoryAccess
+			lineAddr, line := m.mmu.fetchCacheLine(addrs[0])
+			m.mmu.pushLineToL1D(lineAddr, line)
 			mem, exists := m.mmu.getFromL1D(addrs)
 			if !exists {
 				panic("cache line doesn't exist")
@@ -120,10 +138,11 @@ func (m *CPU) execute(app risc.Application, r risc.InstructionRunner, pc int32)
 		}
 	}
 
+	m.ctx.Cycles = int64(m.cycle)
 	exe, err := r.Run(m.ctx, app.Labels, pc, memory, 0)
 	if err != nil {
 		return risc.Execution{}, 0, err
 	}
-	m.cycle += r.InstructionType().Cycles()
+	m.cycle += m.profile.Cycles(r.InstructionType())
 	return exe, r.InstructionType(), nil
 }
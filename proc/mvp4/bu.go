@@ -18,7 +18,7 @@ func (bu *simpleBranchUnit) assert(runner risc.InstructionRunnerPc) {
 	} else if instructionType.IsConditionalBranch() {
 		// Assuming next instruction
 		bu.toCheck = true
-		bu.expectation = runner.Pc + 4
+		bu.expectation = runner.Pc + risc.InstructionSize(runner.Runner)
 	}
 }
 
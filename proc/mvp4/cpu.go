@@ -2,9 +2,10 @@ package mvp4
 
 import (
 	"fmt"
+	"strings"
 
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -30,19 +31,19 @@ type CPU struct {
 	memoryManagementUnit *memoryManagementUnit
 }
 
-func NewCPU(debug bool, memoryBytes int) *CPU {
+func NewCPU(debug bool, memoryBytes int, profile latency.Profile) *CPU {
 	bu := &simpleBranchUnit{}
 	ctx := risc.NewContext(debug, memoryBytes, false)
-	mmu := newMemoryManagementUnit(ctx)
+	mmu := newMemoryManagementUnit(ctx, profile)
 	return &CPU{
 		ctx:                  ctx,
-		fetchUnit:            newFetchUnit(mmu, latency.MemoryAccess),
+		fetchUnit:            newFetchUnit(mmu, profile.MemoryAccess),
 		decodeBus:            &comp.SimpleBus[int32]{},
 		decodeUnit:           &decodeUnit{},
 		executeBus:           &comp.SimpleBus[risc.InstructionRunnerPc]{},
-		executeUnit:          newExecuteUnit(bu, mmu),
+		executeUnit:          newExecuteUnit(bu, mmu, profile),
 		writeBus:             &comp.SimpleBus[risc.ExecutionContext]{},
-		writeUnit:            &writeUnit{},
+		writeUnit:            &writeUnit{profile: profile},
 		branchUnit:           bu,
 		memoryManagementUnit: mmu,
 	}
@@ -53,9 +54,14 @@ func (m *CPU) Context() *risc.Context {
 }
 
 func (m *CPU) Run(app risc.Application) (int, error) {
+	if err := m.ctx.Load(app); err != nil {
+		return 0, err
+	}
+	m.fetchUnit.pc = app.Entry
 	cycle := 0
 	for {
 		cycle++
+		m.ctx.Cycles = int64(cycle)
 		if m.ctx.Debug {
 			fmt.Printf("%d\n", int32(cycle))
 		}
@@ -69,7 +75,7 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 		// Create branch unit assertions
 
 		// Execute
-		flush, pc, ret, err := m.executeUnit.cycle(m.ctx, app, m.executeBus, m.writeBus)
+		flush, pc, err := m.executeUnit.cycle(m.ctx, app, m.executeBus, m.writeBus)
 		if err != nil {
 			return 0, err
 		}
@@ -77,9 +83,6 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 		// Write back
 		m.writeUnit.cycle(m.ctx, m.writeBus)
 
-		if ret {
-			break
-		}
 		if flush {
 			for !m.writeUnit.isEmpty() || !m.writeBus.IsEmpty() {
 				cycle++
@@ -90,22 +93,29 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 		}
 
 		if m.isComplete() {
-			//if m.ctx.Registers[risc.Ra] != 0 {
-			//	m.ctx.Registers[risc.Ra] = 0
-			//	m.fetchUnit.reset(m.ctx.Registers[risc.Ra])
-			//	continue
-			//}
 			break
 		}
 	}
 	cycle += m.memoryManagementUnit.flush()
-	return cycle, nil
+	return cycle, m.ctx.ExitError()
 }
 
 func (m *CPU) Stats() map[string]any {
 	return nil
 }
 
+// Pipeline returns the instructions in flight, stage by stage.
+func (m *CPU) Pipeline() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "decode bus: %#x\n", m.decodeBus.Values())
+	fmt.Fprintf(&sb, "execute bus: %v\n", m.executeBus.Values())
+	if !m.executeUnit.isEmpty() {
+		fmt.Fprintf(&sb, "execute unit: %v\n", m.executeUnit.runner)
+	}
+	fmt.Fprintf(&sb, "write bus: %v\n", m.writeBus.Values())
+	return sb.String()
+}
+
 func (m *CPU) flush(pc int32) {
 	m.fetchUnit.flush(pc)
 	m.decodeUnit.flush()
@@ -16,7 +16,7 @@ func (du *decodeUnit) cycle(app risc.Application, inBus *comp.SimpleBus[int32],
 	if !exists {
 		return
 	}
-	runner := app.Instructions[pc/4]
+	runner := app.Fetch(pc)
 	outBus.Add(risc.InstructionRunnerPc{
 		Runner: runner,
 		Pc:     pc,
@@ -3,12 +3,13 @@ package mvp4
 import (
 	"fmt"
 
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
 type executeUnit struct {
+	profile           latency.Profile
 	branchUnit        *simpleBranchUnit
 	processing        bool
 	pendingMemoryRead bool
@@ -19,15 +20,20 @@ type executeUnit struct {
 	mmu               *memoryManagementUnit
 }
 
-func newExecuteUnit(branchUnit *simpleBranchUnit, mmu *memoryManagementUnit) *executeUnit {
-	return &executeUnit{branchUnit: branchUnit, mmu: mmu}
+func newExecuteUnit(branchUnit *simpleBranchUnit, mmu *memoryManagementUnit, profile latency.Profile) *executeUnit {
+	return &executeUnit{branchUnit: branchUnit, mmu: mmu, profile: profile}
 }
 
-func (eu *executeUnit) cycle(ctx *risc.Context, app risc.Application, inBus *comp.SimpleBus[risc.InstructionRunnerPc], outBus *comp.SimpleBus[risc.ExecutionContext]) (bool, int32, bool, error) {
+func (eu *executeUnit) cycle(ctx *risc.Context, app risc.Application, inBus *comp.SimpleBus[risc.InstructionRunnerPc], outBus *comp.SimpleBus[risc.ExecutionContext]) (bool, int32, error) {
 	if eu.pendingMemoryRead {
 		eu.remainingCycles--
 		if eu.remainingCycles != 0 {
-			return false, 0, false, nil
+			return false, 0, nil
+		}
+		if eu.memory == nil && !outBus.IsEmpty() {
+			// A previous store isn't written to memory yet
+			eu.remainingCycles = 1
+			return false, 0, nil
 		}
 		eu.pendingMemoryRead = false
 		defer func() {
@@ -37,8 +43,8 @@ func (eu *executeUnit) cycle(ctx *risc.Context, app risc.Application, inBus *com
 		if eu.memory != nil {
 			memory = eu.memory
 		} else {
-			line := eu.mmu.fetchCacheLine(eu.addrs[0])
-			eu.mmu.pushLineToL1D(comp.AlignedAddress(eu.addrs[0]), line)
+			lineAddr, line := eu.mmu.fetchCacheLine(eu.addrs[0])
+			eu.mmu.pushLineToL1D(lineAddr, line)
 			m, exists := eu.mmu.getFromL1D(eu.addrs)
 			if !exists {
 				panic("cache line doesn't exist")
@@ -52,21 +58,30 @@ func (eu *executeUnit) cycle(ctx *risc.Context, app risc.Application, inBus *com
 	if !eu.processing {
 		runner, exists := inBus.Get()
 		if !exists {
-			return false, 0, false, nil
+			return false, 0, nil
+		}
+		if e := ctx.Interrupt(runner.Pc); e != nil {
+			// The interrupted instruction is executed once the trap handler
+			// returns
+			pc, err := ctx.Trap(e)
+			if err != nil {
+				return false, 0, err
+			}
+			return true, pc, nil
 		}
 		eu.runner = runner
-		eu.remainingCycles = runner.Runner.InstructionType().Cycles()
+		eu.remainingCycles = eu.profile.Cycles(runner.Runner.InstructionType())
 		eu.processing = true
 	}
 
 	eu.remainingCycles--
 	if eu.remainingCycles != 0 {
-		return false, 0, false, nil
+		return false, 0, nil
 	}
 
 	if !outBus.CanAdd() {
 		eu.remainingCycles = 1
-		return false, 0, false, nil
+		return false, 0, nil
 	}
 
 	runner := eu.runner
@@ -77,7 +92,7 @@ func (eu *executeUnit) cycle(ctx *risc.Context, app risc.Application, inBus *com
 	// written yet, we wait for it
 	if ctx.IsWriteDataHazard(runner.Runner.ReadRegisters()) {
 		eu.remainingCycles = 1
-		return false, 0, false, nil
+		return false, 0, nil
 	}
 
 	if ctx.Debug {
@@ -89,13 +104,13 @@ func (eu *executeUnit) cycle(ctx *risc.Context, app risc.Application, inBus *com
 		if m, exists := eu.mmu.getFromL1D(addrs); exists {
 			eu.memory = m
 			eu.pendingMemoryRead = true
-			eu.remainingCycles = latency.L1Access
+			eu.remainingCycles = eu.profile.L1Access
 		} else {
 			eu.addrs = addrs
 			eu.pendingMemoryRead = true
-			eu.remainingCycles = latency.MemoryAccess
+			eu.remainingCycles = eu.profile.MemoryAccess
 		}
-		return false, 0, false, nil
+		return false, 0, nil
 	}
 
 	defer func() {
@@ -104,19 +119,34 @@ func (eu *executeUnit) cycle(ctx *risc.Context, app risc.Application, inBus *com
 	return eu.run(ctx, app, outBus, nil)
 }
 
-func (eu *executeUnit) run(ctx *risc.Context, app risc.Application, outBus *comp.SimpleBus[risc.ExecutionContext], memory []int8) (bool, int32, bool, error) {
+func (eu *executeUnit) run(ctx *risc.Context, app risc.Application, outBus *comp.SimpleBus[risc.ExecutionContext], memory []int8) (bool, int32, error) {
 	execution, err := eu.runner.Runner.Run(ctx, app.Labels, eu.runner.Pc, memory, 0)
 	if err != nil {
-		return false, 0, false, err
+		return false, 0, err
 	}
-	if execution.Return {
-		return false, 0, true, err
+	if execution.Exception != nil {
+		// The instruction doesn't retire: the pipeline restarts from the trap
+		// handler
+		eu.processing = false
+		pc, err := ctx.Trap(execution.Exception)
+		if err != nil {
+			return false, 0, err
+		}
+		return true, pc, nil
+	}
+	ctx.Instret++
+	if err := ctx.Retire(eu.runner.Pc, eu.runner.Runner, execution); err != nil {
+		return false, 0, err
 	}
 
 	eu.processing = false
 	if execution.MemoryChange && eu.mmu.doesExecutionMemoryChangesExistsInL1D(execution) {
 		eu.mmu.writeExecutionMemoryChangesToL1D(execution)
-		return false, 0, false, nil
+		if !execution.RegisterChange {
+			return false, 0, nil
+		}
+		// Atomic instruction: the register still has to be written back
+		execution.MemoryChange = false
 	}
 
 	outBus.Add(risc.ExecutionContext{
@@ -127,10 +157,10 @@ func (eu *executeUnit) run(ctx *risc.Context, app risc.Application, outBus *comp
 	ctx.AddPendingWriteRegisters(eu.runner.Runner.WriteRegisters())
 
 	if execution.PcChange && eu.branchUnit.shouldFlushPipeline(execution.NextPc) {
-		return true, execution.NextPc, false, nil
+		return true, execution.NextPc, nil
 	}
 
-	return false, 0, false, nil
+	return false, 0, nil
 }
 
 func (eu *executeUnit) isEmpty() bool {
@@ -32,14 +32,19 @@ func (fu *fetchUnit) cycle(app risc.Application, ctx *risc.Context, outBus *comp
 	if fu.complete {
 		return
 	}
+	if fu.pc >= app.End() {
+		// Jump past the end of the application, e.g., on exit
+		fu.complete = true
+		return
+	}
 
 	if !fu.processing {
 		fu.processing = true
-		if _, exists := fu.mmu.getFromL1I([]int32{fu.pc}); exists {
+		if addr, missing := fu.mmu.missingFromL1I(fu.pc, app.Size(fu.pc)); !missing {
 			fu.remainingCycles = 1
 		} else {
 			fu.remainingCycles = fu.cyclesMemoryAccess
-			fu.mmu.pushLineToL1I(comp.AlignedAddress(fu.pc), make([]int8, l1ICacheLineSize))
+			fu.mmu.pushLineToL1I(comp.AlignedAddress(addr), make([]int8, l1ICacheLineSize))
 		}
 	}
 
@@ -52,8 +57,8 @@ func (fu *fetchUnit) cycle(app risc.Application, ctx *risc.Context, outBus *comp
 
 		fu.processing = false
 		currentPC := fu.pc
-		fu.pc += 4
-		if fu.pc/4 >= int32(len(app.Instructions)) {
+		fu.pc += app.Size(currentPC)
+		if fu.pc >= app.End() {
 			fu.complete = true
 		}
 		if ctx.Debug {
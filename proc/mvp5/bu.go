@@ -36,7 +36,7 @@ func (bu *btbBranchUnit) assert(runner risc.InstructionRunnerPc) {
 	} else if instructionType.IsConditionalBranch() {
 		// Assuming next instruction
 		bu.toCheck = true
-		bu.expectation = runner.Pc + 4
+		bu.expectation = runner.Pc + risc.InstructionSize(runner.Runner)
 	} else {
 		bu.toCheck = false
 	}
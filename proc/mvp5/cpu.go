@@ -2,9 +2,10 @@ package mvp5
 
 import (
 	"fmt"
+	"strings"
 
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -32,10 +33,10 @@ type CPU struct {
 	counterFlush int
 }
 
-func NewCPU(debug bool, memoryBytes int) *CPU {
+func NewCPU(debug bool, memoryBytes int, profile latency.Profile) *CPU {
 	ctx := risc.NewContext(debug, memoryBytes, false)
-	mmu := newMemoryManagementUnit(ctx)
-	fu := newFetchUnit(mmu, latency.MemoryAccess)
+	mmu := newMemoryManagementUnit(ctx, profile)
+	fu := newFetchUnit(mmu, profile.MemoryAccess)
 	du := &decodeUnit{}
 	bu := newBTBBranchUnit(4, fu, du)
 	return &CPU{
@@ -44,9 +45,9 @@ func NewCPU(debug bool, memoryBytes int) *CPU {
 		decodeBus:            &comp.SimpleBus[int32]{},
 		decodeUnit:           du,
 		executeBus:           &comp.SimpleBus[risc.InstructionRunnerPc]{},
-		executeUnit:          newExecuteUnit(bu, mmu),
+		executeUnit:          newExecuteUnit(bu, mmu, profile),
 		writeBus:             &comp.SimpleBus[risc.ExecutionContext]{},
-		writeUnit:            &writeUnit{},
+		writeUnit:            &writeUnit{profile: profile},
 		branchUnit:           bu,
 		memoryManagementUnit: mmu,
 	}
@@ -57,9 +58,14 @@ func (m *CPU) Context() *risc.Context {
 }
 
 func (m *CPU) Run(app risc.Application) (int, error) {
+	if err := m.ctx.Load(app); err != nil {
+		return 0, err
+	}
+	m.fetchUnit.pc = app.Entry
 	cycle := 0
 	for {
 		cycle++
+		m.ctx.Cycles = int64(cycle)
 		if m.ctx.Debug {
 			fmt.Printf("%d\n", int32(cycle))
 		}
@@ -71,7 +77,7 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 		m.decodeUnit.cycle(app, m.ctx, m.decodeBus, m.executeBus)
 
 		// Execute
-		flush, pc, ret, err := m.executeUnit.cycle(m.ctx, app, m.executeBus, m.writeBus)
+		flush, pc, err := m.executeUnit.cycle(m.ctx, app, m.executeBus, m.writeBus)
 		if err != nil {
 			return 0, err
 		}
@@ -82,9 +88,6 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 			fmt.Printf("\tRegisters: %v\n", m.ctx.Registers)
 		}
 
-		if ret {
-			break
-		}
 		if flush {
 			if m.ctx.Debug {
 				fmt.Printf("\tFlush to %d\n", pc/4)
@@ -99,16 +102,11 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 		}
 
 		if m.isComplete() {
-			//if m.ctx.Registers[risc.Ra] != 0 {
-			//	m.ctx.Registers[risc.Ra] = 0
-			//	m.fetchUnit.reset(m.ctx.Registers[risc.Ra])
-			//	continue
-			//}
 			break
 		}
 	}
 	cycle += m.memoryManagementUnit.flush()
-	return cycle, nil
+	return cycle, m.ctx.ExitError()
 }
 
 func (m *CPU) Stats() map[string]any {
@@ -117,6 +115,18 @@ func (m *CPU) Stats() map[string]any {
 	}
 }
 
+// Pipeline returns the instructions in flight, stage by stage.
+func (m *CPU) Pipeline() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "decode bus: %#x\n", m.decodeBus.Values())
+	fmt.Fprintf(&sb, "execute bus: %v\n", m.executeBus.Values())
+	if !m.executeUnit.isEmpty() {
+		fmt.Fprintf(&sb, "execute unit: %v\n", m.executeUnit.runner)
+	}
+	fmt.Fprintf(&sb, "write bus: %v\n", m.writeBus.Values())
+	return sb.String()
+}
+
 func (m *CPU) flush(pc int32) {
 	m.fetchUnit.flush(pc)
 	m.decodeUnit.flush()
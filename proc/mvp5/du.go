@@ -26,7 +26,7 @@ func (du *decodeUnit) cycle(app risc.Application, ctx *risc.Context, inBus *comp
 	if ctx.Debug {
 		fmt.Printf("\tDU: Decoding instruction %d\n", pc/4)
 	}
-	runner := app.Instructions[pc/4]
+	runner := app.Fetch(pc)
 	if runner.InstructionType().IsUnconditionalBranch() {
 		du.pendingBranchResolution = true
 	}
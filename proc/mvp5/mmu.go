@@ -3,22 +3,24 @@ package mvp5
 import (
 	"sort"
 
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
 type memoryManagementUnit struct {
-	ctx *risc.Context
-	l1i *comp.LRUCache
-	l1d *comp.LRUCache
+	profile latency.Profile
+	ctx     *risc.Context
+	l1i     *comp.LRUCache
+	l1d     *comp.LRUCache
 }
 
-func newMemoryManagementUnit(ctx *risc.Context) *memoryManagementUnit {
+func newMemoryManagementUnit(ctx *risc.Context, profile latency.Profile) *memoryManagementUnit {
 	return &memoryManagementUnit{
-		ctx: ctx,
-		l1i: comp.NewLRUCache(l1ICacheLineSize, l1ICacheSize),
-		l1d: comp.NewLRUCache(l1DCacheLineSize, liDCacheSize),
+		profile: profile,
+		ctx:     ctx,
+		l1i:     comp.NewLRUCache(l1ICacheLineSize, l1ICacheSize),
+		l1d:     comp.NewLRUCache(l1DCacheLineSize, liDCacheSize),
 	}
 }
 
@@ -34,6 +36,17 @@ func (u *memoryManagementUnit) getFromL1I(addrs []int32) ([]int8, bool) {
 	return memory, true
 }
 
+// missingFromL1I returns the first address of an instruction that isn't in
+// L1I, as an instruction may straddle two lines.
+func (u *memoryManagementUnit) missingFromL1I(pc, size int32) (int32, bool) {
+	for addr := pc; addr < pc+size; addr += 2 {
+		if _, exists := u.getFromL1I([]int32{addr}); !exists {
+			return addr, true
+		}
+	}
+	return 0, false
+}
+
 func (u *memoryManagementUnit) pushLineToL1I(addr comp.AlignedAddress, line []int8) {
 	u.l1i.PushLine(addr, line)
 }
@@ -89,24 +102,31 @@ func (u *memoryManagementUnit) getFromMemory(addrs []int32) []int8 {
 	return memory
 }
 
-func (u *memoryManagementUnit) fetchCacheLine(addr int32) []int8 {
+// fetchCacheLine returns the line holding addr, along with its address, so
+// that two lines never overlap.
+func (u *memoryManagementUnit) fetchCacheLine(addr int32) (comp.AlignedAddress, []int8) {
+	alignedAddr := getAlignedMemoryAddress(addr)
 	memory := make([]int8, 0, l1DCacheLineSize)
 	for i := 0; i < l1DCacheLineSize; i++ {
-		if int(addr)+i >= len(u.ctx.Memory) {
+		if int(alignedAddr)+i >= len(u.ctx.Memory) {
 			memory = append(memory, 0)
 		} else {
-			memory = append(memory, u.ctx.Memory[int(addr)+i])
+			memory = append(memory, u.ctx.Memory[int(alignedAddr)+i])
 		}
 	}
-	return memory
+	return alignedAddr, memory
+}
+
+func getAlignedMemoryAddress(addr int32) comp.AlignedAddress {
+	return comp.AlignedAddress(addr - (addr % l1DCacheLineSize))
 }
 
 func (u *memoryManagementUnit) pushLineToL1D(addr comp.AlignedAddress, line []int8) {
 	evicted := u.l1d.PushLine(addr, line)
-	if len(evicted) == 0 {
+	if evicted == nil {
 		return
 	}
-	u.writeToMemory(int32(addr), line)
+	u.writeToMemory(int32(evicted.Boundary[0]), evicted.Data)
 }
 
 func (u *memoryManagementUnit) writeToL1D(addr int32, data []int8) {
@@ -125,7 +145,7 @@ func (u *memoryManagementUnit) writeToMemory(addr int32, data []int8) {
 func (u *memoryManagementUnit) flush() int {
 	additionalCycles := 0
 	for _, line := range u.l1d.Lines() {
-		additionalCycles += latency.MemoryAccess
+		additionalCycles += u.profile.MemoryAccess
 		for i := 0; i < l1DCacheLineSize; i++ {
 			u.writeToMemory(int32(line.Boundary[0]), line.Data)
 		}
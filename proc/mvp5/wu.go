@@ -1,12 +1,13 @@
 package mvp5
 
 import (
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
 type writeUnit struct {
+	profile            latency.Profile
 	pendingMemoryWrite bool
 	cycles             int
 }
@@ -27,10 +28,11 @@ func (wu *writeUnit) cycle(ctx *risc.Context, inBus *comp.SimpleBus[risc.Executi
 	if execution.Execution.RegisterChange {
 		ctx.WriteRegister(execution.Execution)
 		ctx.DeletePendingWriteRegisters(execution.WriteRegisters)
-	} else if execution.Execution.MemoryChange {
+	}
+	if execution.Execution.MemoryChange {
 		// TODO Do after
 		wu.pendingMemoryWrite = true
-		wu.cycles = latency.MemoryAccess
+		wu.cycles = wu.profile.MemoryAccess
 		ctx.WriteMemory(execution.Execution)
 	}
 }
@@ -8,15 +8,17 @@ type btbBranchUnit struct {
 	btb         *branchTargetBuffer
 	fu          *fetchUnit
 	du          *decodeUnit
+	cu          *controlUnit
 	toCheck     bool
 	expectation int32
 }
 
-func newBTBBranchUnit(btbSize int, fu *fetchUnit, du *decodeUnit) *btbBranchUnit {
+func newBTBBranchUnit(btbSize int, fu *fetchUnit, du *decodeUnit, cu *controlUnit) *btbBranchUnit {
 	return &btbBranchUnit{
 		btb: newBranchTargetBuffer(btbSize),
 		fu:  fu,
 		du:  du,
+		cu:  cu,
 	}
 }
 
@@ -36,7 +38,7 @@ func (u *btbBranchUnit) assert(runner risc.InstructionRunnerPc) {
 	} else if instructionType.IsConditionalBranch() {
 		// Assuming next instruction
 		u.toCheck = true
-		u.expectation = runner.Pc + 4
+		u.expectation = runner.Pc + risc.InstructionSize(runner.Runner)
 	} else {
 		u.toCheck = false
 	}
@@ -53,6 +55,16 @@ func (u *btbBranchUnit) shouldFlushPipeline(pc int32) bool {
 	return u.expectation != pc
 }
 
+func (u *btbBranchUnit) notifyConditionalBranch(sequenceID int32) {
+	u.cu.notifyConditionalBranch(sequenceID)
+}
+
+// speculative returns whether an instruction follows a conditional branch not
+// resolved yet.
+func (u *btbBranchUnit) speculative(sequenceID int32) bool {
+	return u.cu.oldestPendingConditionalBranch() < sequenceID
+}
+
 func (u *btbBranchUnit) notifyJumpAddressResolved(pc, pcTo int32) {
 	u.btb.add(pc, pcTo)
 	u.fu.reset(pcTo, true)
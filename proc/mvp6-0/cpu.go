@@ -1,9 +1,13 @@
 package mvp6_0
 
 import (
-	"github.com/teivah/majorana/common/latency"
+	"fmt"
+	"math"
+	"strings"
+
 	"github.com/teivah/majorana/common/log"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -18,6 +22,7 @@ const (
 )
 
 type CPU struct {
+	profile              latency.Profile
 	ctx                  *risc.Context
 	fetchUnit            *fetchUnit
 	decodeBus            *comp.BufferedBus[int32]
@@ -34,7 +39,7 @@ type CPU struct {
 	counterFlush int
 }
 
-func NewCPU(debug bool, memoryBytes int, eu, wu int) *CPU {
+func NewCPU(debug bool, memoryBytes int, profile latency.Profile, eu, wu int) *CPU {
 	busSize := 2
 	multiplier := 1
 	decodeBus := comp.NewBufferedBus[int32](busSize*multiplier, busSize*multiplier)
@@ -43,28 +48,29 @@ func NewCPU(debug bool, memoryBytes int, eu, wu int) *CPU {
 	writeBus := comp.NewBufferedBus[risc.ExecutionContext](busSize, busSize)
 
 	ctx := risc.NewContext(debug, memoryBytes, false)
-	mmu := newMemoryManagementUnit(ctx)
-	fu := newFetchUnit(mmu, decodeBus)
+	mmu := newMemoryManagementUnit(ctx, profile)
+	fu := newFetchUnit(ctx, mmu, decodeBus, profile)
 	du := newDecodeUnit(decodeBus, controlBus)
-	bu := newBTBBranchUnit(4, fu, du)
-
-	eus := make([]*executeUnit, 0, eu)
-	for i := 0; i < eu; i++ {
-		eus = append(eus, newExecuteUnit(bu, executeBus, writeBus, mmu))
+	eus := make([]*executeUnit, eu)
+	cu := newControlUnit(controlBus, executeBus, eus)
+	bu := newBTBBranchUnit(4, fu, du, cu)
+	for i := range eus {
+		eus[i] = newExecuteUnit(bu, executeBus, writeBus, mmu, profile)
 	}
 
 	wus := make([]*writeUnit, 0, wu)
 	for i := 0; i < wu; i++ {
-		wus = append(wus, newWriteUnit(writeBus))
+		wus = append(wus, newWriteUnit(writeBus, mmu, profile))
 	}
 
 	return &CPU{
+		profile:              profile,
 		ctx:                  ctx,
 		fetchUnit:            fu,
 		decodeBus:            decodeBus,
 		decodeUnit:           du,
 		controlBus:           controlBus,
-		controlUnit:          newControlUnit(controlBus, executeBus),
+		controlUnit:          cu,
 		executeBus:           executeBus,
 		executeUnits:         eus,
 		writeBus:             writeBus,
@@ -79,12 +85,17 @@ func (m *CPU) Context() *risc.Context {
 }
 
 func (m *CPU) Run(app risc.Application) (int, error) {
+	if err := m.ctx.Load(app); err != nil {
+		return 0, err
+	}
+	m.fetchUnit.pc = app.Entry
 	defer func() {
 		log.Infou(m.ctx, "L3", m.memoryManagementUnit.l3.String())
 	}()
 	cycle := 0
 	for {
 		cycle++
+		m.ctx.Cycles = int64(cycle)
 		log.Info(m.ctx, "Cycle %d", cycle)
 		m.decodeBus.Connect(cycle)
 		m.controlBus.Connect(cycle)
@@ -102,22 +113,24 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 
 		// Execute
 		var (
-			flush bool
-			from  int32
-			pc    int32
-			ret   bool
+			flush      bool
+			sequenceID int32 = -1
+			pc         int32
+			exception  *risc.Exception
 		)
 		for _, eu := range m.executeUnits {
-			f, fp, p, r, err := eu.cycle(cycle, m.ctx, app)
+			f, id, p, err := eu.cycle(cycle, m.ctx, app, sequenceID)
 			if err != nil {
 				return 0, err
 			}
-			if f {
-				from = fp
+			// If several units flush, the oldest instruction wins
+			if f && (!flush || id < sequenceID) {
+				sequenceID = id
+				pc = p
+				exception = eu.exception
 			}
+			eu.exception = nil
 			flush = flush || f
-			pc = max(pc, p)
-			ret = ret || r
 		}
 
 		// Write back
@@ -126,48 +139,67 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 		}
 		log.Info(m.ctx, "\tRegisters: %v", m.ctx.Registers)
 
-		if ret {
-			log.Info(m.ctx, "\t🛑 Return")
-			m.counterFlush++
-			cycle++
-			m.writeBus.Connect(cycle)
-			for !m.areWriteUnitsEmpty() || !m.writeBus.IsEmpty() {
+		if flush {
+			// The instructions preceding the one flushing the pipeline are
+			// completed, the following ones are discarded
+			log.Info(m.ctx, "\t️⚠️ Executing previous unit cycles")
+			for {
+				cycle++
+				empty := true
+				for _, eu := range m.executeUnits {
+					if eu.isEmpty() {
+						continue
+					}
+					empty = false
+					f, id, p, err := eu.cycle(cycle, m.ctx, app, sequenceID)
+					if err != nil {
+						return 0, err
+					}
+					if f && id < sequenceID {
+						log.Info(m.ctx, "\t️⚠️️⚠️ Proposition of an inner flush")
+						sequenceID = id
+						pc = p
+						exception = eu.exception
+					}
+					eu.exception = nil
+				}
+				m.writeBus.Connect(cycle + 1)
 				for _, wu := range m.writeUnits {
-					wu.cycle(m.ctx, -1)
+					wu.cycle(m.ctx, sequenceID)
+				}
+				if empty && m.areWriteUnitsEmpty() && m.writeBus.IsEmpty() {
+					break
 				}
-				cycle++
-				m.writeBus.Connect(cycle)
 			}
-			break
-		}
-		if flush {
-			// TODO Same checks as in MVP 6.1
-			m.writeBus.Connect(cycle + 1)
-			for _, wu := range m.writeUnits {
-				for !wu.isEmpty() || !m.writeBus.IsEmpty() {
-					cycle++
-					wu.cycle(m.ctx, from)
+
+			if exception != nil {
+				// The previous instructions are completed, the trap can be taken
+				var err error
+				if pc, err = m.ctx.Trap(exception); err != nil {
+					return 0, err
 				}
 			}
 
+			// The instructions following the flush are executed again
+			if err := m.ctx.CommitRetirements(sequenceID); err != nil {
+				return 0, err
+			}
 			log.Info(m.ctx, "\t️⚠️ Flush to %d", pc/4)
 			m.flush(pc)
-			cycle += latency.Flush
+			cycle += m.profile.Flush
 			log.Info(m.ctx, "\tRegisters: %v", m.ctx.Registers)
 			continue
 		}
 
 		if m.isEmpty() {
-			//if m.ctx.Registers[risc.Ra] != 0 {
-			//	m.ctx.Registers[risc.Ra] = 0
-			//	m.fetchUnit.reset(m.ctx.Registers[risc.Ra], false)
-			//	continue
-			//}
 			break
 		}
 	}
 	cycle += m.memoryManagementUnit.flush()
-	return cycle, nil
+	if err := m.ctx.CommitRetirements(math.MaxInt32); err != nil {
+		return 0, err
+	}
+	return cycle, m.ctx.ExitError()
 }
 
 func (m *CPU) Stats() map[string]any {
@@ -187,6 +219,21 @@ func (m *CPU) Stats() map[string]any {
 	}
 }
 
+// Pipeline returns the instructions in flight, stage by stage.
+func (m *CPU) Pipeline() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "decode bus: %#x\n", m.decodeBus.Values())
+	fmt.Fprintf(&sb, "control bus: %v\n", m.controlBus.Values())
+	fmt.Fprintf(&sb, "execute bus: %v\n", m.executeBus.Values())
+	for i, eu := range m.executeUnits {
+		if !eu.isEmpty() {
+			fmt.Fprintf(&sb, "execute unit %d: %v\n", i, eu.runner)
+		}
+	}
+	fmt.Fprintf(&sb, "write bus: %v\n", m.writeBus.Values())
+	return sb.String()
+}
+
 func (m *CPU) flush(pc int32) {
 	m.fetchUnit.flush(pc)
 	m.decodeUnit.flush()
@@ -194,6 +241,7 @@ func (m *CPU) flush(pc int32) {
 	for _, executeUnit := range m.executeUnits {
 		executeUnit.flush()
 	}
+	m.memoryManagementUnit.cancelPendingRequests()
 	m.decodeBus.Clean()
 	m.controlBus.Clean()
 	m.executeBus.Clean()
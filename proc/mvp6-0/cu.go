@@ -1,6 +1,9 @@
 package mvp6_0
 
 import (
+	"math"
+	"slices"
+
 	"github.com/teivah/majorana/common/log"
 	"github.com/teivah/majorana/common/obs"
 	"github.com/teivah/majorana/proc/comp"
@@ -15,6 +18,10 @@ type controlUnit struct {
 	inBus    *comp.BufferedBus[risc.InstructionRunnerPc]
 	outBus   *comp.BufferedBus[*risc.InstructionRunnerPc]
 	pendings *comp.Queue[risc.InstructionRunnerPc]
+	eus      []*executeUnit
+	// pendingConditionalBranches holds the sequence IDs of the conditional
+	// branches not resolved yet
+	pendingConditionalBranches []int32
 
 	pushed            *obs.Gauge
 	pending           *obs.Gauge
@@ -26,10 +33,11 @@ type controlUnit struct {
 	blockedDataHazard int
 }
 
-func newControlUnit(inBus *comp.BufferedBus[risc.InstructionRunnerPc], outBus *comp.BufferedBus[*risc.InstructionRunnerPc]) *controlUnit {
+func newControlUnit(inBus *comp.BufferedBus[risc.InstructionRunnerPc], outBus *comp.BufferedBus[*risc.InstructionRunnerPc], eus []*executeUnit) *controlUnit {
 	return &controlUnit{
 		inBus:       inBus,
 		outBus:      outBus,
+		eus:         eus,
 		pendings:    comp.NewQueue[risc.InstructionRunnerPc](pendingLength),
 		pushed:      &obs.Gauge{},
 		pending:     &obs.Gauge{},
@@ -65,6 +73,9 @@ func (u *controlUnit) cycle(cycle int, ctx *risc.Context) {
 		push, stop := u.handleRunner(ctx, cycle, pushed, runner)
 		if push {
 			u.pendings.Remove(elem)
+			if runner.Runner.InstructionType().IsConditionalBranch() {
+				u.pendingConditionalBranches = append(u.pendingConditionalBranches, runner.SequenceID)
+			}
 			remaining--
 			pushed++
 		} else {
@@ -83,6 +94,9 @@ func (u *controlUnit) cycle(cycle int, ctx *risc.Context) {
 
 		push, stop := u.handleRunner(ctx, cycle, pushed, runner)
 		if push {
+			if runner.Runner.InstructionType().IsConditionalBranch() {
+				u.pendingConditionalBranches = append(u.pendingConditionalBranches, runner.SequenceID)
+			}
 			remaining--
 			pushed++
 		} else {
@@ -96,9 +110,18 @@ func (u *controlUnit) cycle(cycle int, ctx *risc.Context) {
 }
 
 func (u *controlUnit) handleRunner(ctx *risc.Context, cycle int, pushed int, runner risc.InstructionRunnerPc) (push, stop bool) {
-	if runner.Runner.InstructionType() == risc.Ret && !u.outBus.IsEmpty() {
+	if runner.Runner.InstructionType().IsSerializing() && !u.outBus.IsEmpty() {
 		return false, true
 	}
+	if insType := runner.Runner.InstructionType(); insType == risc.Ecall || insType == risc.Ebreak || insType == risc.Mret || insType == risc.Fence {
+		// The environment, the interrupted code or the instructions following
+		// a fence have to see the instructions being executed completed
+		for _, eu := range u.eus {
+			if !eu.isEmpty() {
+				return false, true
+			}
+		}
+	}
 
 	if pushed > 0 && runner.Runner.InstructionType().IsBranch() {
 		u.blockedBranch++
@@ -116,6 +139,21 @@ func (u *controlUnit) handleRunner(ctx *risc.Context, cycle int, pushed int, run
 	}
 }
 
+func (u *controlUnit) notifyConditionalBranch(sequenceID int32) {
+	u.pendingConditionalBranches = slices.DeleteFunc(u.pendingConditionalBranches, func(id int32) bool {
+		return id == sequenceID
+	})
+}
+
+// oldestPendingConditionalBranch returns the sequence ID of the oldest
+// conditional branch not resolved yet, or math.MaxInt32 if there is none.
+func (u *controlUnit) oldestPendingConditionalBranch() int32 {
+	if len(u.pendingConditionalBranches) == 0 {
+		return math.MaxInt32
+	}
+	return slices.Min(u.pendingConditionalBranches)
+}
+
 func (u *controlUnit) pushRunner(ctx *risc.Context, cycle int, runner *risc.InstructionRunnerPc) {
 	u.outBus.Add(runner, cycle)
 	ctx.AddPendingRegisters(runner.Runner)
@@ -124,6 +162,7 @@ func (u *controlUnit) pushRunner(ctx *risc.Context, cycle int, runner *risc.Inst
 
 func (u *controlUnit) flush() {
 	u.pendings = comp.NewQueue[risc.InstructionRunnerPc](pendingLength)
+	u.pendingConditionalBranches = nil
 }
 
 func (u *controlUnit) isEmpty() bool {
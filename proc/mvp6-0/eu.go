@@ -1,51 +1,112 @@
 package mvp6_0
 
 import (
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/common/log"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
 type executeUnit struct {
-	bu     *btbBranchUnit
-	inBus  *comp.BufferedBus[*risc.InstructionRunnerPc]
-	outBus *comp.BufferedBus[risc.ExecutionContext]
-	mmu    *memoryManagementUnit
+	profile latency.Profile
+	bu      *btbBranchUnit
+	inBus   *comp.BufferedBus[*risc.InstructionRunnerPc]
+	outBus  *comp.BufferedBus[risc.ExecutionContext]
+	mmu     *memoryManagementUnit
 
 	// Pending
-	coroutine func(cycle int, ctx *risc.Context, app risc.Application) (bool, int32, int32, bool, error)
+	coroutine func(cycle int, ctx *risc.Context, app risc.Application) (bool, int32, int32, error)
 	memory    []int8
 	runner    risc.InstructionRunnerPc
+	exception *risc.Exception
 }
 
-func newExecuteUnit(bu *btbBranchUnit, inBus *comp.BufferedBus[*risc.InstructionRunnerPc], outBus *comp.BufferedBus[risc.ExecutionContext], mmu *memoryManagementUnit) *executeUnit {
+func newExecuteUnit(bu *btbBranchUnit, inBus *comp.BufferedBus[*risc.InstructionRunnerPc], outBus *comp.BufferedBus[risc.ExecutionContext], mmu *memoryManagementUnit, profile latency.Profile) *executeUnit {
 	return &executeUnit{
-		bu:     bu,
-		inBus:  inBus,
-		outBus: outBus,
-		mmu:    mmu,
+		profile: profile,
+		bu:      bu,
+		inBus:   inBus,
+		outBus:  outBus,
+		mmu:     mmu,
 	}
 }
 
-func (u *executeUnit) cycle(cycle int, ctx *risc.Context, app risc.Application) (bool, int32, int32, bool, error) {
+// cycle executes the current instruction, or the next one from the bus, and
+// returns whether the pipeline has to be flushed, from which sequence ID and to
+// which pc. The instructions following before, if not -1, are discarded.
+func (u *executeUnit) cycle(cycle int, ctx *risc.Context, app risc.Application, before int32) (bool, int32, int32, error) {
 	if u.coroutine != nil {
+		if before != -1 && u.runner.SequenceID > before {
+			// A previous instruction flushes the pipeline
+			u.flush()
+			return false, 0, 0, nil
+		}
 		return u.coroutine(cycle, ctx, app)
 	}
 
 	runner, exists := u.inBus.Get()
 	if !exists {
-		return false, 0, 0, false, nil
+		return false, 0, 0, nil
+	}
+	if before != -1 && runner.SequenceID > before {
+		// A previous instruction executed during the same cycle flushes the
+		// pipeline
+		return false, 0, 0, nil
 	}
 	u.runner = *runner
+	if ins := u.runner.Runner.InstructionType(); isMemoryAccess(ins) {
+		ctx.AddPendingMemoryAccess(u.runner.SequenceID, ins.IsMemoryWrite() || ins.IsAtomic())
+	}
 	u.coroutine = u.coPrepareRun
+	if remainingCycles := u.profile.Cycles(u.runner.Runner.InstructionType()) - 1; remainingCycles > 0 {
+		// The instruction executes during the cycles of its latency
+		u.coroutine = func(cycle int, ctx *risc.Context, app risc.Application) (bool, int32, int32, error) {
+			remainingCycles--
+			if remainingCycles > 0 {
+				return false, 0, 0, nil
+			}
+			u.coroutine = u.coPrepareRun
+			return u.coPrepareRun(cycle, ctx, app)
+		}
+		return false, 0, 0, nil
+	}
 	return u.coPrepareRun(cycle, ctx, app)
 }
 
-func (u *executeUnit) coPrepareRun(cycle int, ctx *risc.Context, app risc.Application) (bool, int32, int32, bool, error) {
+func (u *executeUnit) coPrepareRun(cycle int, ctx *risc.Context, app risc.Application) (bool, int32, int32, error) {
 	if !u.outBus.CanAdd() {
 		log.Infou(ctx, "EU", "can't add")
-		return false, 0, 0, false, nil
+		return false, 0, 0, nil
+	}
+
+	if e := ctx.Interrupt(u.runner.Pc); e != nil {
+		// The instruction is interrupted: it's executed once the trap handler
+		// returns
+		u.coroutine = nil
+		return u.raise(ctx, e)
+	}
+
+	if ctx.StoresToDevice(u.runner.Runner, 0) &&
+		(ctx.PendingMemoryAccess(u.runner.SequenceID, true, nil) || u.bu.speculative(u.runner.SequenceID)) {
+		// The device is written by Run, which waits for the previous memory
+		// accesses and conditional branches
+		log.Infoi(ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "pending device store")
+		return false, 0, 0, nil
+	}
+
+	if ins := u.runner.Runner.InstructionType(); isMemoryAccess(ins) &&
+		ctx.PendingMemoryAccess(u.runner.SequenceID, ins.IsMemoryWrite() || ins.IsAtomic(), u.accessedAddrs(ctx)) {
+		// A previous memory access, possibly executed by the other unit, isn't
+		// completed yet
+		log.Infoi(ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "pending memory access")
+		return false, 0, 0, nil
+	}
+
+	if ins := u.runner.Runner.InstructionType(); (ins.IsMemoryWrite() || ins.IsAtomic()) && u.bu.speculative(u.runner.SequenceID) {
+		// The memory isn't written before the previous conditional branches are
+		// resolved
+		log.Infoi(ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "pending conditional branch")
+		return false, 0, 0, nil
 	}
 
 	// Create the branch unit assertions
@@ -57,27 +118,27 @@ func (u *executeUnit) coPrepareRun(cycle int, ctx *risc.Context, app risc.Applic
 	if len(addrs) != 0 {
 		memory, pending, exists := u.mmu.getFromL3(addrs)
 		if pending {
-			return false, 0, 0, false, nil
+			return false, 0, 0, nil
 		} else if exists {
 			u.memory = memory
-			remainingCycles := latency.L3Access - 1
-			u.coroutine = func(cycle int, ctx *risc.Context, app risc.Application) (bool, int32, int32, bool, error) {
+			remainingCycles := u.profile.L3Access - 1
+			u.coroutine = func(cycle int, ctx *risc.Context, app risc.Application) (bool, int32, int32, error) {
 				if remainingCycles > 0 {
 					remainingCycles--
-					return false, 0, 0, false, nil
+					return false, 0, 0, nil
 				}
 				return u.coRun(cycle, ctx, app)
 			}
-			return false, 0, 0, false, nil
+			return false, 0, 0, nil
 		} else {
-			remainingCycles := latency.MemoryAccess - 1
-			u.coroutine = func(cycle int, ctx *risc.Context, app risc.Application) (bool, int32, int32, bool, error) {
+			remainingCycles := u.profile.MemoryAccess - 1
+			u.coroutine = func(cycle int, ctx *risc.Context, app risc.Application) (bool, int32, int32, error) {
 				if remainingCycles > 0 {
 					remainingCycles--
-					return false, 0, 0, false, nil
+					return false, 0, 0, nil
 				}
-				line := u.mmu.fetchCacheLine(addrs[0])
-				u.mmu.pushLineToL3(comp.AlignedAddress(addrs[0]), line)
+				lineAddr, line := u.mmu.fetchCacheLine(addrs[0])
+				u.mmu.pushLineToL3(lineAddr, line)
 				m, _, exists := u.mmu.getFromL3(addrs)
 				if !exists {
 					panic("cache line doesn't exist")
@@ -85,26 +146,45 @@ func (u *executeUnit) coPrepareRun(cycle int, ctx *risc.Context, app risc.Applic
 				u.memory = m
 				return u.coRun(cycle, ctx, app)
 			}
-			return false, 0, 0, false, nil
+			return false, 0, 0, nil
 		}
 	}
 	return u.coRun(cycle, ctx, app)
 }
 
-func (u *executeUnit) coRun(cycle int, ctx *risc.Context, app risc.Application) (bool, int32, int32, bool, error) {
+func (u *executeUnit) coRun(cycle int, ctx *risc.Context, app risc.Application) (bool, int32, int32, error) {
 	u.coroutine = nil
+	if u.runner.Runner.InstructionType().IsAtomic() {
+		// The memory is read again so that the read-modify-write happens within
+		// a single cycle
+		memory, _, exists := u.mmu.getFromL3(u.runner.Runner.MemoryRead(ctx, 0))
+		if !exists {
+			panic("cache line doesn't exist")
+		}
+		u.memory = memory
+	}
 	execution, err := u.runner.Runner.Run(ctx, app.Labels, u.runner.Pc, u.memory, 0)
 	if err != nil {
-		return false, 0, 0, false, err
+		return false, 0, 0, err
+	}
+	if execution.Exception != nil {
+		return u.raise(ctx, execution.Exception)
 	}
-	if execution.Return {
-		return false, 0, 0, true, nil
+	ctx.RetireSpeculative(u.runner, execution)
+	if !execution.MemoryChange {
+		// The memory is read, if any
+		ctx.DeletePendingMemoryAccess(u.runner.SequenceID)
 	}
 
 	if execution.MemoryChange && u.mmu.doesExecutionMemoryChangesExistsInL3(execution) {
 		u.mmu.writeExecutionMemoryChangesToL3(execution)
-		ctx.DeletePendingRegisters(u.runner.Runner.ReadRegisters(), u.runner.Runner.WriteRegisters())
-		return false, 0, 0, false, nil
+		ctx.DeletePendingMemoryAccess(u.runner.SequenceID)
+		if !execution.RegisterChange {
+			ctx.DeletePendingRegisters(u.runner.Runner.ReadRegisters(), u.runner.Runner.WriteRegisters())
+			return false, 0, 0, nil
+		}
+		// Atomic instruction: the register still has to be written back
+		execution.MemoryChange = false
 	}
 
 	u.outBus.Add(risc.ExecutionContext{
@@ -120,13 +200,33 @@ func (u *executeUnit) coRun(cycle int, ctx *risc.Context, app risc.Application)
 			"notify jump address resolved from %d to %d", u.runner.Pc/4, execution.NextPc/4)
 		u.bu.notifyJumpAddressResolved(u.runner.Pc, execution.NextPc)
 	}
+	if u.runner.Runner.InstructionType().IsConditionalBranch() {
+		u.bu.notifyConditionalBranch(u.runner.SequenceID)
+	}
 	if execution.PcChange && u.bu.shouldFlushPipeline(execution.NextPc) {
 		log.Infoi(ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc,
 			"should be a flush")
-		return true, u.runner.Pc, execution.NextPc, false, nil
+		return true, u.runner.SequenceID, execution.NextPc, nil
+	}
+	if u.runner.Runner.InstructionType() == risc.Fence {
+		// The following instructions are executed again once the previous
+		// memory writes are completed
+		log.Infoi(ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "fence")
+		return true, u.runner.SequenceID, u.runner.Pc + risc.InstructionSize(u.runner.Runner), nil
 	}
 
-	return false, 0, 0, false, nil
+	return false, 0, 0, nil
+}
+
+// raise releases the pending registers of an instruction raising an exception,
+// which doesn't retire, and requests a flush: the trap is taken once the
+// previous instructions are completed.
+func (u *executeUnit) raise(ctx *risc.Context, e *risc.Exception) (bool, int32, int32, error) {
+	log.Infoi(ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "exception: %v", e)
+	ctx.DeletePendingRegisters(u.runner.Runner.ReadRegisters(), u.runner.Runner.WriteRegisters())
+	ctx.DeletePendingMemoryAccess(u.runner.SequenceID)
+	u.exception = e
+	return true, u.runner.SequenceID, 0, nil
 }
 
 func (u *executeUnit) flush() {
@@ -136,3 +236,12 @@ func (u *executeUnit) flush() {
 func (u *executeUnit) isEmpty() bool {
 	return u.coroutine == nil
 }
+
+// accessedAddrs returns the addresses read or written by the current runner.
+func (u *executeUnit) accessedAddrs(ctx *risc.Context) []int32 {
+	return append(u.runner.Runner.MemoryRead(ctx, 0), u.runner.Runner.MemoryWrite(ctx, 0)...)
+}
+
+func isMemoryAccess(ins risc.InstructionType) bool {
+	return ins.IsMemoryRead() || ins.IsMemoryWrite() || ins.IsAtomic()
+}
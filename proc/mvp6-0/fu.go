@@ -1,13 +1,15 @@
 package mvp6_0
 
 import (
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/common/log"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
 type fetchUnit struct {
+	profile        latency.Profile
+	ctx            *risc.Context
 	pc             int32
 	toCleanPending bool
 	outBus         *comp.BufferedBus[int32]
@@ -18,10 +20,12 @@ type fetchUnit struct {
 	remainingCycles int
 }
 
-func newFetchUnit(mmu *memoryManagementUnit, outBus *comp.BufferedBus[int32]) *fetchUnit {
+func newFetchUnit(ctx *risc.Context, mmu *memoryManagementUnit, outBus *comp.BufferedBus[int32], profile latency.Profile) *fetchUnit {
 	return &fetchUnit{
-		mmu:    mmu,
-		outBus: outBus,
+		profile: profile,
+		ctx:     ctx,
+		mmu:     mmu,
+		outBus:  outBus,
 	}
 }
 
@@ -43,14 +47,20 @@ func (u *fetchUnit) cycle(cycle int, app risc.Application, ctx *risc.Context) {
 
 func (u *fetchUnit) coFetch(cycle int, app risc.Application, ctx *risc.Context) {
 	u.coroutine = nil
+	if u.pc >= app.End() {
+		// Jump past the end of the application, e.g., on exit
+		u.coroutine = func(cycle int, app risc.Application, ctx *risc.Context) {}
+		u.complete = true
+		return
+	}
 	for i := 0; i < u.outBus.OutLength(); i++ {
 		if !u.outBus.CanAdd() {
 			log.Infou(ctx, "FU", "can't add")
 			return
 		}
 
-		if _, exists := u.mmu.getFromL1I([]int32{u.pc}); !exists {
-			u.remainingCycles = latency.MemoryAccess - 1
+		if addr, missing := u.mmu.missingFromL1I(u.pc, app.Size(u.pc)); missing {
+			u.remainingCycles = u.profile.MemoryAccess - 1
 			u.coroutine = func(cycle int, app risc.Application, ctx *risc.Context) {
 				if u.remainingCycles != 0 {
 					log.Infou(ctx, "FU", "pending memory access")
@@ -58,11 +68,15 @@ func (u *fetchUnit) coFetch(cycle int, app risc.Application, ctx *risc.Context)
 					return
 				}
 				u.coroutine = nil
-				u.mmu.pushLineToL1I(comp.AlignedAddress(u.pc), make([]int8, l1ICacheLineSize))
+				u.mmu.pushLineToL1I(comp.AlignedAddress(addr), make([]int8, l1ICacheLineSize))
+				if _, missing := u.mmu.missingFromL1I(u.pc, app.Size(u.pc)); missing {
+					// The instruction straddles two lines, the second one is fetched next
+					return
+				}
 
 				currentPc := u.pc
-				u.pc += 4
-				if u.pc/4 >= int32(len(app.Instructions)) {
+				u.pc += app.Size(currentPc)
+				if u.pc >= app.End() {
 					u.coroutine = func(cycle int, app risc.Application, ctx *risc.Context) {}
 					u.complete = true
 				}
@@ -73,8 +87,8 @@ func (u *fetchUnit) coFetch(cycle int, app risc.Application, ctx *risc.Context)
 		}
 
 		currentPc := u.pc
-		u.pc += 4
-		if u.pc/4 >= int32(len(app.Instructions)) {
+		u.pc += app.Size(currentPc)
+		if u.pc >= app.End() {
 			u.coroutine = func(cycle int, app risc.Application, ctx *risc.Context) {}
 			u.complete = true
 		}
@@ -84,12 +98,15 @@ func (u *fetchUnit) coFetch(cycle int, app risc.Application, ctx *risc.Context)
 }
 
 func (u *fetchUnit) reset(pc int32, cleanPending bool) {
+	u.ctx.IncSequenceID()
 	u.coroutine = nil
+	u.complete = false
 	u.pc = pc
 	u.toCleanPending = cleanPending
 }
 
 func (u *fetchUnit) flush(pc int32) {
+	u.ctx.IncSequenceID()
 	u.coroutine = nil
 	u.complete = false
 	u.pc = pc
@@ -1,22 +1,24 @@
 package mvp6_0
 
 import (
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/common/log"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
 type writeUnit struct {
+	profile     latency.Profile
 	memoryWrite risc.ExecutionContext
 	inBus       *comp.BufferedBus[risc.ExecutionContext]
+	mmu         *memoryManagementUnit
 
 	// Pending
 	coroutine func(ctx *risc.Context)
 }
 
-func newWriteUnit(inBus *comp.BufferedBus[risc.ExecutionContext]) *writeUnit {
-	return &writeUnit{inBus: inBus}
+func newWriteUnit(inBus *comp.BufferedBus[risc.ExecutionContext], mmu *memoryManagementUnit, profile latency.Profile) *writeUnit {
+	return &writeUnit{inBus: inBus, mmu: mmu, profile: profile}
 }
 
 func (u *writeUnit) cycle(ctx *risc.Context, before int32) {
@@ -34,10 +36,15 @@ func (u *writeUnit) cycle(ctx *risc.Context, before int32) {
 	}
 	if execution.Execution.RegisterChange {
 		ctx.WriteRegister(execution.Execution)
-		ctx.DeletePendingRegisters(execution.ReadRegisters, execution.WriteRegisters)
+		// An atomic instruction also writes to memory, the pending registers are
+		// deleted once the memory is written
+		if !execution.Execution.MemoryChange {
+			ctx.DeletePendingRegisters(execution.ReadRegisters, execution.WriteRegisters)
+		}
 		log.Infoi(ctx, "WU", execution.InstructionType, -1, "write to register")
-	} else if execution.Execution.MemoryChange {
-		remainingCycle := latency.MemoryAccess
+	}
+	if execution.Execution.MemoryChange {
+		remainingCycle := u.profile.MemoryAccess
 		log.Infoi(ctx, "WU", execution.InstructionType, -1, "pending memory write")
 
 		u.coroutine = func(ctx *risc.Context) {
@@ -48,12 +55,14 @@ func (u *writeUnit) cycle(ctx *risc.Context, before int32) {
 			}
 			u.coroutine = nil
 			ctx.WriteMemory(u.memoryWrite.Execution)
+			u.mmu.updateL3(u.memoryWrite.Execution)
+			ctx.DeletePendingMemoryAccess(u.memoryWrite.SequenceID)
 			ctx.DeletePendingRegisters(u.memoryWrite.ReadRegisters, u.memoryWrite.WriteRegisters)
 			log.Infoi(ctx, "WU", u.memoryWrite.InstructionType, -1, "write to memory")
 		}
 
 		u.memoryWrite = execution
-	} else {
+	} else if !execution.Execution.RegisterChange {
 		ctx.DeletePendingRegisters(execution.ReadRegisters, execution.WriteRegisters)
 		log.Infoi(ctx, "WU", execution.InstructionType, -1, "cleaning")
 	}
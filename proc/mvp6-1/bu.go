@@ -38,7 +38,7 @@ func (u *btbBranchUnit) assert(runner risc.InstructionRunnerPc) {
 	} else if instructionType.IsConditionalBranch() {
 		// Assuming next instruction
 		u.toCheck = true
-		u.expectation = runner.Pc + 4
+		u.expectation = runner.Pc + risc.InstructionSize(runner.Runner)
 	} else {
 		u.toCheck = false
 	}
@@ -55,8 +55,14 @@ func (u *btbBranchUnit) shouldFlushPipeline(pc int32) bool {
 	return u.expectation != pc
 }
 
-func (u *btbBranchUnit) notifyConditionalBranch() {
-	u.cu.notifyConditionalBranch()
+func (u *btbBranchUnit) notifyConditionalBranch(sequenceID int32) {
+	u.cu.notifyConditionalBranch(sequenceID)
+}
+
+// speculative returns whether an instruction follows a conditional branch not
+// resolved yet.
+func (u *btbBranchUnit) speculative(sequenceID int32) bool {
+	return u.cu.oldestPendingConditionalBranch() < sequenceID
 }
 
 func (u *btbBranchUnit) notifyUnconditionalJumpAddressResolved(pc, pcTo int32) {
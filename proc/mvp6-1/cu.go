@@ -1,6 +1,9 @@
 package mvp6_1
 
 import (
+	"math"
+	"slices"
+
 	"github.com/teivah/majorana/common/log"
 	"github.com/teivah/majorana/common/obs"
 	"github.com/teivah/majorana/proc/comp"
@@ -19,7 +22,9 @@ type controlUnit struct {
 	pushedRunnersInCurrentCycle  map[*risc.InstructionRunnerPc]bool
 	skippedInCurrentCycle        []risc.InstructionRunnerPc
 	pushedBranchInCurrentCycle   bool
-	pendingConditionalBranch     bool
+	// pendingConditionalBranches holds the sequence IDs of the conditional
+	// branches not resolved yet
+	pendingConditionalBranches []int32
 
 	// Monitoring
 	pushed            *obs.Gauge
@@ -80,7 +85,7 @@ func (u *controlUnit) cycle(cycle int, ctx *risc.Context) {
 				u.pushedBranchInCurrentCycle = true
 			}
 			if runner.Runner.InstructionType().IsConditionalBranch() {
-				u.pendingConditionalBranch = true
+				u.pendingConditionalBranches = append(u.pendingConditionalBranches, runner.SequenceID)
 			}
 		} else {
 			u.skippedInCurrentCycle = append(u.skippedInCurrentCycle, runner)
@@ -102,7 +107,7 @@ func (u *controlUnit) cycle(cycle int, ctx *risc.Context) {
 				u.pushedBranchInCurrentCycle = true
 			}
 			if runner.Runner.InstructionType().IsConditionalBranch() {
-				u.pendingConditionalBranch = true
+				u.pendingConditionalBranches = append(u.pendingConditionalBranches, runner.SequenceID)
 			}
 		} else {
 			u.pendings.Push(runner)
@@ -119,7 +124,7 @@ func (u *controlUnit) handleRunner(ctx *risc.Context, cycle int, runner *risc.In
 		return false, true
 	}
 
-	if runner.Runner.InstructionType() == risc.Ret && (!u.outBus.IsEmpty() || u.pendingConditionalBranch) {
+	if runner.Runner.InstructionType().IsSerializing() && (!u.outBus.IsEmpty() || len(u.pendingConditionalBranches) != 0) {
 		return false, true
 	}
 
@@ -141,7 +146,8 @@ func (u *controlUnit) handleRunner(ctx *risc.Context, cycle int, runner *risc.In
 	if should, previousRunner, register := u.shouldUseForwarding(runner, hazards, hazardTypes); should {
 		ch := make(chan int32, 1)
 		previousRunner.Forwarder = ch
-		previousRunner.ForwardRegister = register
+		// The forward register is only set on the receiver, as the previous
+		// runner may itself be waiting for a forwarded value
 		runner.Receiver = ch
 		runner.ForwardRegister = register
 
@@ -204,12 +210,18 @@ func (u *controlUnit) shouldUseForwarding(runner *risc.InstructionRunnerPc, haza
 	if len(hazardTypes) > 1 || !hazardTypes[risc.ReadAfterWrite] || len(hazards) > 1 {
 		return false, nil, risc.Zero
 	}
+	if runner.Runner.InstructionType().IsConditionalBranch() {
+		// A branch waiting for a forwarded value is resolved after the
+		// instructions following it, which write the registers in the meantime
+		return false, nil, risc.Zero
+	}
 
 	// Can we use forwarding with an instruction pushed in the previous cycle
 	for previousRunner := range u.pushedRunnersInPreviousCycle {
 		for _, writeRegister := range previousRunner.Runner.WriteRegisters() {
 			for _, readRegister := range runner.Runner.ReadRegisters() {
-				if readRegister == risc.Zero {
+				// A forwarded value is an integer register value
+				if readRegister == risc.Zero || readRegister.IsFloat() {
 					continue
 				}
 				if readRegister == writeRegister {
@@ -221,8 +233,19 @@ func (u *controlUnit) shouldUseForwarding(runner *risc.InstructionRunnerPc, haza
 	return false, nil, risc.Zero
 }
 
-func (u *controlUnit) notifyConditionalBranch() {
-	u.pendingConditionalBranch = false
+func (u *controlUnit) notifyConditionalBranch(sequenceID int32) {
+	u.pendingConditionalBranches = slices.DeleteFunc(u.pendingConditionalBranches, func(id int32) bool {
+		return id == sequenceID
+	})
+}
+
+// oldestPendingConditionalBranch returns the sequence ID of the oldest
+// conditional branch not resolved yet, or math.MaxInt32 if there is none.
+func (u *controlUnit) oldestPendingConditionalBranch() int32 {
+	if len(u.pendingConditionalBranches) == 0 {
+		return math.MaxInt32
+	}
+	return slices.Min(u.pendingConditionalBranches)
 }
 
 func (u *controlUnit) pushRunner(ctx *risc.Context, cycle int, runner *risc.InstructionRunnerPc) bool {
@@ -239,7 +262,7 @@ func (u *controlUnit) pushRunner(ctx *risc.Context, cycle int, runner *risc.Inst
 func (u *controlUnit) flush() {
 	u.pendings = comp.NewQueue[risc.InstructionRunnerPc](pendingLength)
 	u.pushedRunnersInPreviousCycle = nil
-	u.pendingConditionalBranch = false
+	u.pendingConditionalBranches = nil
 }
 
 func (u *controlUnit) isEmpty() bool {
@@ -10,7 +10,6 @@ import (
 )
 
 type decodeUnit struct {
-	ret                     bool
 	pendingBranchResolution bool
 	log                     string
 	inBus                   *comp.BufferedBus[int32]
@@ -42,9 +41,6 @@ func (u *decodeUnit) cycle(cycle int, app risc.Application, ctx *risc.Context) {
 	} else {
 		u.blocked.Push(0)
 	}
-	if u.ret {
-		return
-	}
 	if u.pendingBranchResolution {
 		log.Infou(ctx, "DU", "blocked")
 		return
@@ -58,10 +54,11 @@ func (u *decodeUnit) cycle(cycle int, app risc.Application, ctx *risc.Context) {
 		if !exists {
 			return
 		}
-		if int(pc)/4 >= len(app.Instructions) {
+		if pc >= app.End() {
+			// Past the end of the application
 			return
 		}
-		runner := app.Instructions[pc/4]
+		runner := app.Fetch(pc)
 		// Clear forward
 		runner.Forward(risc.Forward{})
 		log.Infoi(ctx, "DU", runner.InstructionType(), pc, "decoding")
@@ -73,17 +70,15 @@ func (u *decodeUnit) cycle(cycle int, app risc.Application, ctx *risc.Context) {
 			jump = true
 		}
 		u.outBus.Add(risc.InstructionRunnerPc{
-			Runner:     runner,
-			Pc:         pc,
-			SequenceID: ctx.SequenceID(pc),
+			Runner:       runner,
+			Pc:           pc,
+			SequenceID:   ctx.SequenceID(pc),
+			ProgramOrder: ctx.NextProgramOrder(),
 		}, cycle)
 		pushed++
 		if jump {
 			return
 		}
-		if runner.InstructionType() == risc.Ret {
-			u.ret = true
-		}
 	}
 }
 
@@ -93,7 +88,6 @@ func (u *decodeUnit) notifyBranchResolved() {
 
 func (u *decodeUnit) flush() {
 	u.pendingBranchResolution = false
-	u.ret = false
 }
 
 func (u *decodeUnit) isEmpty() bool {
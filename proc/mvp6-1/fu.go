@@ -2,9 +2,9 @@ package mvp6_1
 
 import (
 	co "github.com/teivah/majorana/common/coroutine"
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/common/log"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -15,6 +15,7 @@ type fuReq struct {
 }
 
 type fetchUnit struct {
+	profile latency.Profile
 	// TODO Should be everywhere in struct
 	ctx *risc.Context
 	co.Coroutine[fuReq, error]
@@ -24,13 +25,16 @@ type fetchUnit struct {
 	complete        bool
 	mmu             *memoryManagementUnit
 	remainingCycles int
+	// Address of the L1I line being fetched
+	missingAddr int32
 }
 
-func newFetchUnit(ctx *risc.Context, mmu *memoryManagementUnit, outBus *comp.BufferedBus[int32]) *fetchUnit {
+func newFetchUnit(ctx *risc.Context, mmu *memoryManagementUnit, outBus *comp.BufferedBus[int32], profile latency.Profile) *fetchUnit {
 	fu := &fetchUnit{
-		ctx:    ctx,
-		mmu:    mmu,
-		outBus: outBus,
+		profile: profile,
+		ctx:     ctx,
+		mmu:     mmu,
+		outBus:  outBus,
 	}
 	fu.Coroutine = co.New(fu.start)
 	fu.Coroutine.Pre(func(r fuReq) bool {
@@ -47,21 +51,28 @@ func newFetchUnit(ctx *risc.Context, mmu *memoryManagementUnit, outBus *comp.Buf
 }
 
 func (u *fetchUnit) start(r fuReq) error {
+	if u.pc >= r.app.End() {
+		// Jump past the end of the application, e.g., on exit
+		u.Checkpoint(func(fuReq) error { return nil })
+		u.complete = true
+		return nil
+	}
 	for i := 0; i < u.outBus.OutLength(); i++ {
 		if !u.outBus.CanAdd() {
 			log.Infou(r.ctx, "FU", "can't add")
 			return nil
 		}
 
-		if _, exists := u.mmu.getFromL1I([]int32{u.pc}); !exists {
-			u.remainingCycles = latency.MemoryAccess - 1
+		if addr, missing := u.mmu.missingFromL1I(u.pc, r.app.Size(u.pc)); missing {
+			u.missingAddr = addr
+			u.remainingCycles = u.profile.MemoryAccess - 1
 			u.Checkpoint(u.memoryAccess)
 			return nil
 		}
 
 		currentPc := u.pc
-		u.pc += 4
-		if u.pc/4 >= int32(len(r.app.Instructions)) {
+		u.pc += r.app.Size(currentPc)
+		if u.pc >= r.app.End() {
 			u.Checkpoint(func(fuReq) error { return nil })
 			u.complete = true
 		}
@@ -78,11 +89,18 @@ func (u *fetchUnit) memoryAccess(r fuReq) error {
 		return nil
 	}
 	u.Reset()
-	u.mmu.pushLineToL1I(comp.AlignedAddress(u.pc), make([]int8, l1ICacheLineSize))
+	u.mmu.pushLineToL1I(comp.AlignedAddress(u.missingAddr), make([]int8, l1ICacheLineSize))
+	if addr, missing := u.mmu.missingFromL1I(u.pc, r.app.Size(u.pc)); missing {
+		// The instruction straddles two lines
+		u.missingAddr = addr
+		u.remainingCycles = u.profile.MemoryAccess - 1
+		u.Checkpoint(u.memoryAccess)
+		return nil
+	}
 
 	currentPc := u.pc
-	u.pc += 4
-	if u.pc/4 >= int32(len(r.app.Instructions)) {
+	u.pc += r.app.Size(currentPc)
+	if u.pc >= r.app.End() {
 		u.Checkpoint(func(fuReq) error { return nil })
 		u.complete = true
 	}
@@ -95,6 +113,7 @@ func (u *fetchUnit) memoryAccess(r fuReq) error {
 func (u *fetchUnit) reset(pc int32, cleanPending bool) {
 	u.ctx.IncSequenceID()
 	u.Reset()
+	u.complete = false
 	u.pc = pc
 	u.toCleanPending = cleanPending
 }
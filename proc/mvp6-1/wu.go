@@ -2,9 +2,9 @@ package mvp6_1
 
 import (
 	co "github.com/teivah/majorana/common/coroutine"
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/common/log"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -14,14 +14,18 @@ type wuReq struct {
 }
 
 type writeUnit struct {
+	profile latency.Profile
 	co.Coroutine[wuReq, error]
 	memoryWrite risc.ExecutionContext
 	inBus       *comp.BufferedBus[risc.ExecutionContext]
+	mmu         *memoryManagementUnit
 }
 
-func newWriteUnit(inBus *comp.BufferedBus[risc.ExecutionContext]) *writeUnit {
+func newWriteUnit(inBus *comp.BufferedBus[risc.ExecutionContext], mmu *memoryManagementUnit, profile latency.Profile) *writeUnit {
 	wu := &writeUnit{
-		inBus: inBus,
+		profile: profile,
+		inBus:   inBus,
+		mmu:     mmu,
 	}
 	wu.Coroutine = co.New(wu.start)
 	return wu
@@ -37,10 +41,15 @@ func (u *writeUnit) start(r wuReq) error {
 	}
 	if execution.Execution.RegisterChange {
 		r.ctx.WriteRegister(execution.Execution)
-		r.ctx.DeletePendingRegisters(execution.ReadRegisters, execution.WriteRegisters)
+		// An atomic instruction also writes to memory, the pending registers are
+		// deleted once the memory is written
+		if !execution.Execution.MemoryChange {
+			r.ctx.DeletePendingRegisters(execution.ReadRegisters, execution.WriteRegisters)
+		}
 		log.Infoi(r.ctx, "WU", execution.InstructionType, execution.SequenceID, "write to register")
-	} else if execution.Execution.MemoryChange {
-		remainingCycle := latency.MemoryAccess
+	}
+	if execution.Execution.MemoryChange {
+		remainingCycle := u.profile.MemoryAccess
 		log.Infoi(r.ctx, "WU", execution.InstructionType, execution.SequenceID, "pending memory write")
 
 		u.Checkpoint(func(r wuReq) error {
@@ -51,13 +60,15 @@ func (u *writeUnit) start(r wuReq) error {
 			}
 			u.Reset()
 			r.ctx.WriteMemory(u.memoryWrite.Execution)
+			u.mmu.updateL3(u.memoryWrite.Execution)
+			r.ctx.DeletePendingMemoryAccess(u.memoryWrite.SequenceID)
 			r.ctx.DeletePendingRegisters(u.memoryWrite.ReadRegisters, u.memoryWrite.WriteRegisters)
 			log.Infoi(r.ctx, "WU", u.memoryWrite.InstructionType, execution.SequenceID, "write to memory")
 			return nil
 		})
 
 		u.memoryWrite = execution
-	} else {
+	} else if !execution.Execution.RegisterChange {
 		r.ctx.DeletePendingRegisters(execution.ReadRegisters, execution.WriteRegisters)
 		log.Infoi(r.ctx, "WU", execution.InstructionType, execution.SequenceID, "cleaning")
 	}
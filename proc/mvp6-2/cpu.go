@@ -1,9 +1,13 @@
 package mvp6_2
 
 import (
-	"github.com/teivah/majorana/common/latency"
+	"fmt"
+	"math"
+	"strings"
+
 	"github.com/teivah/majorana/common/log"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -18,6 +22,7 @@ const (
 )
 
 type CPU struct {
+	profile              latency.Profile
 	ctx                  *risc.Context
 	fetchUnit            *fetchUnit
 	decodeBus            *comp.BufferedBus[int32]
@@ -32,7 +37,7 @@ type CPU struct {
 	memoryManagementUnit *memoryManagementUnit
 }
 
-func NewCPU(debug bool, memoryBytes int, eu, wu int) *CPU {
+func NewCPU(debug bool, memoryBytes int, profile latency.Profile, eu, wu int) *CPU {
 	busSize := 2
 	multiplier := 1
 	decodeBus := comp.NewBufferedBus[int32](busSize*multiplier, busSize*multiplier)
@@ -42,13 +47,13 @@ func NewCPU(debug bool, memoryBytes int, eu, wu int) *CPU {
 
 	ctx := risc.NewContext(debug, memoryBytes, false)
 
+	mmu := newMemoryManagementUnit(ctx, profile)
 	wus := make([]*writeUnit, 0, wu)
 	for i := 0; i < wu; i++ {
-		wus = append(wus, newWriteUnit(ctx, writeBus))
+		wus = append(wus, newWriteUnit(ctx, writeBus, mmu, profile))
 	}
 
-	mmu := newMemoryManagementUnit(ctx)
-	fu := newFetchUnit(ctx, mmu, decodeBus)
+	fu := newFetchUnit(ctx, mmu, decodeBus, profile)
 	du := newDecodeUnit(ctx, decodeBus, controlBus)
 	cu := newControlUnit(ctx, controlBus, executeBus)
 	// TODO How about local context per unit?
@@ -56,10 +61,11 @@ func NewCPU(debug bool, memoryBytes int, eu, wu int) *CPU {
 
 	eus := make([]*executeUnit, 0, eu)
 	for i := 0; i < eu; i++ {
-		eus = append(eus, newExecuteUnit(bu, executeBus, writeBus, mmu))
+		eus = append(eus, newExecuteUnit(bu, executeBus, writeBus, mmu, profile))
 	}
 
 	return &CPU{
+		profile:              profile,
 		ctx:                  ctx,
 		fetchUnit:            fu,
 		decodeBus:            decodeBus,
@@ -80,12 +86,17 @@ func (m *CPU) Context() *risc.Context {
 }
 
 func (m *CPU) Run(app risc.Application) (int, error) {
+	if err := m.ctx.Load(app); err != nil {
+		return 0, err
+	}
+	m.fetchUnit.pc = app.Entry
 	defer func() {
 		log.Infou(m.ctx, "L3", m.memoryManagementUnit.l3.String())
 	}()
 	cycle := 0
 	for {
 		cycle++
+		m.ctx.Cycles = int64(cycle)
 		log.Info(m.ctx, "Cycle %d", cycle)
 		m.decodeBus.Connect(cycle)
 		m.controlBus.Connect(cycle)
@@ -105,8 +116,8 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 		var (
 			flush      bool
 			sequenceID int32
+			exception  *risc.Exception
 			pc         int32
-			ret        bool
 		)
 		for i, eu := range m.executeUnits {
 			log.Infou(m.ctx, "EU", "Execute unit %d", i)
@@ -115,12 +126,13 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 			if resp.err != nil {
 				return 0, resp.err
 			}
-			if resp.flush {
+			// If several units flush, the oldest instruction wins
+			if resp.flush && (!flush || resp.sequenceID < sequenceID) {
 				sequenceID = resp.sequenceID
+				exception = resp.exception
+				pc = resp.pc
 			}
 			flush = flush || resp.flush
-			pc = max(pc, resp.pc)
-			ret = ret || resp.isReturn
 		}
 
 		// Write back
@@ -129,19 +141,6 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 		}
 		log.Info(m.ctx, "\tRegisters: %v", m.ctx.Registers)
 
-		if ret {
-			log.Info(m.ctx, "\t🛑 Return")
-			cycle++
-			m.writeBus.Connect(cycle)
-			for !m.areWriteUnitsEmpty() || !m.writeBus.IsEmpty() {
-				for _, wu := range m.writeUnits {
-					_ = wu.Cycle(wuReq{-1})
-				}
-				cycle++
-				m.writeBus.Connect(cycle)
-			}
-			break
-		}
 		if flush {
 			// Execute pending instructions up to sequenceID.
 			log.Info(m.ctx, "\t️⚠️ Executing previous unit cycles")
@@ -159,31 +158,50 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 						isEmpty = false
 						resp := eu.Cycle(euReq{fromCycle, m.ctx, app})
 						if resp.err != nil {
-							return 0, nil
+							return 0, resp.err
 						}
 						if resp.flush {
 							log.Info(m.ctx, "\t️⚠️️⚠️ Proposition of an inner flush")
 							sequenceID = resp.sequenceID
+							exception = resp.exception
 							flush = resp.flush
 							pc = resp.pc
-							ret = resp.isReturn
+							// The following instructions, possibly held by a unit executed
+							// later in this cycle, are flushed as well
+							for _, unit := range m.executeUnits {
+								unit.sequenceID = sequenceID
+							}
 						}
 					}
 				}
 				m.writeBus.Connect(cycle + 1)
 				for _, wu := range m.writeUnits {
-					for !wu.isEmpty() || !m.writeBus.IsEmpty() {
+					for !wu.isEmpty() || m.writeBus.CanGet() {
 						_ = wu.Cycle(wuReq{sequenceID})
 					}
 				}
-				if isEmpty {
+				// The write bus may hold more executions than it can deliver
+				// in a cycle
+				if isEmpty && m.writeBus.IsEmpty() {
 					break
 				}
 			}
 
+			if exception != nil {
+				// The previous instructions are completed, the trap can be taken
+				var err error
+				if pc, err = m.ctx.Trap(exception); err != nil {
+					return 0, err
+				}
+			}
+
+			// The instructions following the flush are executed again
+			if err := m.ctx.CommitRetirements(sequenceID); err != nil {
+				return 0, err
+			}
 			log.Info(m.ctx, "\t️⚠️ Flush to %d", pc/4)
 			m.flush(pc)
-			cycle += latency.Flush
+			cycle += m.profile.Flush
 			log.Info(m.ctx, "\tRegisters: %v", m.ctx.Registers)
 			continue
 		}
@@ -193,8 +211,11 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 		}
 	}
 	cycle += m.memoryManagementUnit.flush()
-	m.ctx.Commit()
-	return cycle, nil
+	m.ctx.Commit(math.MaxInt32)
+	if err := m.ctx.CommitRetirements(math.MaxInt32); err != nil {
+		return 0, err
+	}
+	return cycle, m.ctx.ExitError()
 }
 
 func (m *CPU) Stats() map[string]any {
@@ -214,6 +235,21 @@ func (m *CPU) Stats() map[string]any {
 	}
 }
 
+// Pipeline returns the instructions in flight, stage by stage.
+func (m *CPU) Pipeline() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "decode bus: %#x\n", m.decodeBus.Values())
+	fmt.Fprintf(&sb, "control bus: %v\n", m.controlBus.Values())
+	fmt.Fprintf(&sb, "execute bus: %v\n", m.executeBus.Values())
+	for i, eu := range m.executeUnits {
+		if !eu.isEmpty() {
+			fmt.Fprintf(&sb, "execute unit %d: %v\n", i, eu.runner)
+		}
+	}
+	fmt.Fprintf(&sb, "write bus: %v\n", m.writeBus.Values())
+	return sb.String()
+}
+
 func (m *CPU) flush(pc int32) {
 	m.fetchUnit.flush(pc)
 	m.decodeUnit.flush()
@@ -221,6 +257,7 @@ func (m *CPU) flush(pc int32) {
 	for _, executeUnit := range m.executeUnits {
 		executeUnit.flush()
 	}
+	m.memoryManagementUnit.cancelPendingRequests()
 	m.decodeBus.Clean()
 	m.controlBus.Clean()
 	m.executeBus.Clean()
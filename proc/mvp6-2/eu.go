@@ -2,9 +2,9 @@ package mvp6_2
 
 import (
 	co "github.com/teivah/majorana/common/coroutine"
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/common/log"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -18,11 +18,12 @@ type euResp struct {
 	flush      bool
 	sequenceID int32
 	pc         int32
-	isReturn   bool
+	exception  *risc.Exception
 	err        error
 }
 
 type executeUnit struct {
+	profile latency.Profile
 	co.Coroutine[euReq, euResp]
 	bu     *btbBranchUnit
 	inBus  *comp.BufferedBus[*risc.InstructionRunnerPc]
@@ -35,16 +36,18 @@ type executeUnit struct {
 	sequenceID int32
 }
 
-func newExecuteUnit(bu *btbBranchUnit, inBus *comp.BufferedBus[*risc.InstructionRunnerPc], outBus *comp.BufferedBus[risc.ExecutionContext], mmu *memoryManagementUnit) *executeUnit {
+func newExecuteUnit(bu *btbBranchUnit, inBus *comp.BufferedBus[*risc.InstructionRunnerPc], outBus *comp.BufferedBus[risc.ExecutionContext], mmu *memoryManagementUnit, profile latency.Profile) *executeUnit {
 	eu := &executeUnit{
-		bu:     bu,
-		inBus:  inBus,
-		outBus: outBus,
-		mmu:    mmu,
+		profile: profile,
+		bu:      bu,
+		inBus:   inBus,
+		outBus:  outBus,
+		mmu:     mmu,
 	}
 	eu.Coroutine = co.New(eu.start)
 	eu.Coroutine.Pre(func(r euReq) bool {
-		if eu.sequenceID == 0 || eu.runner.Runner == nil {
+		// The runner of a unit not executing anything is already completed
+		if eu.sequenceID == 0 || eu.runner.Runner == nil || eu.isEmpty() {
 			return false
 		}
 		if eu.runner.SequenceID > eu.sequenceID {
@@ -61,8 +64,17 @@ func (u *executeUnit) start(r euReq) euResp {
 	if !exists {
 		return euResp{}
 	}
+	if u.sequenceID != 0 && runner.SequenceID > u.sequenceID {
+		// A previous instruction executed during the same cycle flushes the
+		// pipeline
+		return euResp{}
+	}
 	u.runner = *runner
-	return u.ExecuteWithCheckpoint(r, u.prepareRun)
+	if ins := u.runner.Runner.InstructionType(); isMemoryAccess(ins) {
+		r.ctx.AddPendingMemoryAccess(u.runner.SequenceID, ins.IsMemoryWrite() || ins.IsAtomic())
+	}
+	// The instruction executes during the cycles of its latency
+	return u.ExecuteWithCheckpointAfter(r, u.profile.Cycles(u.runner.Runner.InstructionType())-1, u.prepareRun)
 }
 
 func (u *executeUnit) prepareRun(r euReq) euResp {
@@ -85,6 +97,36 @@ func (u *executeUnit) prepareRun(r euReq) euResp {
 		u.runner.Receiver = nil
 	}
 
+	if e := r.ctx.Interrupt(u.runner.Pc); e != nil {
+		// The instruction is interrupted: it's executed once the trap handler
+		// returns
+		u.Reset()
+		return u.raise(r, e)
+	}
+
+	if r.ctx.StoresToDevice(u.runner.Runner, 0) &&
+		(r.ctx.PendingMemoryAccess(u.runner.SequenceID, true, nil) || u.bu.speculative(u.runner.SequenceID)) {
+		// The device is written by Run, which waits for the previous memory
+		// accesses and conditional branches
+		log.Infoi(r.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "pending device store")
+		return euResp{}
+	}
+
+	if ins := u.runner.Runner.InstructionType(); isMemoryAccess(ins) &&
+		r.ctx.PendingMemoryAccess(u.runner.SequenceID, ins.IsMemoryWrite() || ins.IsAtomic(), u.accessedAddrs(r.ctx)) {
+		// A previous memory access, possibly executed by the other unit, isn't
+		// completed yet
+		log.Infoi(r.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "pending memory access")
+		return euResp{}
+	}
+
+	if ins := u.runner.Runner.InstructionType(); (ins.IsMemoryWrite() || ins.IsAtomic()) && u.bu.speculative(u.runner.SequenceID) {
+		// The memory isn't written before the previous conditional branches are
+		// resolved
+		log.Infoi(r.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "pending conditional branch")
+		return euResp{}
+	}
+
 	// Create the branch unit assertions
 	u.bu.assert(u.runner)
 
@@ -97,7 +139,7 @@ func (u *executeUnit) prepareRun(r euReq) euResp {
 			return euResp{}
 		} else if exists {
 			u.memory = memory
-			remainingCycles := latency.L3Access - 1
+			remainingCycles := u.profile.L3Access - 1
 			u.Checkpoint(func(r euReq) euResp {
 				if remainingCycles > 0 {
 					remainingCycles--
@@ -107,15 +149,15 @@ func (u *executeUnit) prepareRun(r euReq) euResp {
 			})
 			return euResp{}
 		} else {
-			remainingCycles := latency.MemoryAccess - 1
+			remainingCycles := u.profile.MemoryAccess - 1
 			u.Checkpoint(func(r euReq) euResp {
 				if remainingCycles > 0 {
 					log.Infoi(r.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "pending memory access %d", remainingCycles)
 					remainingCycles--
 					return euResp{}
 				}
-				line := u.mmu.fetchCacheLine(addrs[0])
-				u.mmu.pushLineToL3(comp.AlignedAddress(addrs[0]), line)
+				lineAddr, line := u.mmu.fetchCacheLine(addrs[0])
+				u.mmu.pushLineToL3(lineAddr, line)
 				m, _, exists := u.mmu.getFromL3(addrs)
 				if !exists {
 					panic("cache line doesn't exist")
@@ -130,19 +172,38 @@ func (u *executeUnit) prepareRun(r euReq) euResp {
 }
 
 func (u *executeUnit) run(r euReq) euResp {
+	if u.runner.Runner.InstructionType().IsAtomic() {
+		// The memory is read again so that the read-modify-write happens within
+		// a single cycle
+		memory, _, exists := u.mmu.getFromL3(u.runner.Runner.MemoryRead(r.ctx, 0))
+		if !exists {
+			panic("cache line doesn't exist")
+		}
+		u.memory = memory
+	}
 	execution, err := u.runner.Runner.Run(r.ctx, r.app.Labels, u.runner.Pc, u.memory, 0)
 	if err != nil {
 		return euResp{err: err}
 	}
 	log.Infoi(r.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "execution result: %+v", execution)
-	if execution.Return {
-		return euResp{isReturn: true}
+	if execution.Exception != nil {
+		return u.raise(r, execution.Exception)
+	}
+	r.ctx.RetireSpeculative(u.runner, execution)
+	if !execution.MemoryChange {
+		// The memory is read, if any
+		r.ctx.DeletePendingMemoryAccess(u.runner.SequenceID)
 	}
 
 	if execution.MemoryChange && u.mmu.doesExecutionMemoryChangesExistsInL3(execution) {
 		u.mmu.writeExecutionMemoryChangesToL3(execution)
-		r.ctx.DeletePendingRegisters(u.runner.Runner.ReadRegisters(), u.runner.Runner.WriteRegisters())
-		return euResp{}
+		r.ctx.DeletePendingMemoryAccess(u.runner.SequenceID)
+		if !execution.RegisterChange {
+			r.ctx.DeletePendingRegisters(u.runner.Runner.ReadRegisters(), u.runner.Runner.WriteRegisters())
+			return euResp{}
+		}
+		// Atomic instruction: the register still has to be written back
+		execution.MemoryChange = false
 	}
 
 	u.outBus.Add(risc.ExecutionContext{
@@ -160,18 +221,25 @@ func (u *executeUnit) run(r euReq) euResp {
 			u.bu.notifyUnconditionalJumpAddressResolved(u.runner.Pc, execution.NextPc)
 		}
 		if u.runner.Runner.InstructionType().IsConditionalBranch() {
-			if execution.PcChange {
+			if execution.PcChange && execution.NextPc != u.runner.Pc+risc.InstructionSize(u.runner.Runner) {
 				// Branch taken (jump)
 				u.bu.notifyConditionalBranchTaken(u.runner.SequenceID)
 			} else {
-				// Branch not taken (next PC)
-				u.bu.notifyConditionalBranchNotTaken()
+				// Branch not taken (next PC), or taken to the next instruction
+				// without flushing the instructions following it
+				u.bu.notifyConditionalBranchNotTaken(u.runner.SequenceID)
 			}
 		}
 		if execution.PcChange && u.bu.shouldFlushPipeline(execution.NextPc) {
 			log.Infoi(r.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "should be a flush")
 			return euResp{flush: true, sequenceID: u.runner.SequenceID, pc: execution.NextPc}
 		}
+		if u.runner.Runner.InstructionType() == risc.Fence {
+			// The following instructions are executed again once the previous
+			// ones, including their memory writes, are completed
+			log.Infoi(r.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "fence")
+			return euResp{flush: true, sequenceID: u.runner.SequenceID, pc: u.runner.Pc + risc.InstructionSize(u.runner.Runner)}
+		}
 	} else {
 		u.runner.Forwarder <- execution.RegisterValue
 		log.Infoi(r.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "forward register value %d", execution.RegisterValue)
@@ -183,6 +251,21 @@ func (u *executeUnit) run(r euReq) euResp {
 	return euResp{}
 }
 
+// raise publishes an instruction raising an exception so that its pending
+// registers are released, and requests a flush: the trap is taken once the
+// previous instructions are completed.
+func (u *executeUnit) raise(r euReq, e *risc.Exception) euResp {
+	log.Infoi(r.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "exception: %v", e)
+	r.ctx.DeletePendingMemoryAccess(u.runner.SequenceID)
+	u.outBus.Add(risc.ExecutionContext{
+		SequenceID:      u.runner.SequenceID,
+		InstructionType: u.runner.Runner.InstructionType(),
+		WriteRegisters:  u.runner.Runner.WriteRegisters(),
+		ReadRegisters:   u.runner.Runner.ReadRegisters(),
+	}, r.cycle)
+	return euResp{flush: true, sequenceID: u.runner.SequenceID, exception: e}
+}
+
 func (u *executeUnit) flush() {
 	u.Reset()
 	u.sequenceID = 0
@@ -191,3 +274,13 @@ func (u *executeUnit) flush() {
 func (u *executeUnit) isEmpty() bool {
 	return u.IsStart()
 }
+
+// accessedAddrs returns the addresses read or written by the current runner.
+func (u *executeUnit) accessedAddrs(ctx *risc.Context) []int32 {
+	return append(u.runner.Runner.MemoryRead(ctx, u.runner.SequenceID),
+		u.runner.Runner.MemoryWrite(ctx, u.runner.SequenceID)...)
+}
+
+func isMemoryAccess(ins risc.InstructionType) bool {
+	return ins.IsMemoryRead() || ins.IsMemoryWrite() || ins.IsAtomic()
+}
@@ -2,9 +2,9 @@ package mvp6_2
 
 import (
 	co "github.com/teivah/majorana/common/coroutine"
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/common/log"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -13,16 +13,20 @@ type wuReq struct {
 }
 
 type writeUnit struct {
-	ctx *risc.Context
+	profile latency.Profile
+	ctx     *risc.Context
 	co.Coroutine[wuReq, error]
 	memoryWrite risc.ExecutionContext
 	inBus       *comp.BufferedBus[risc.ExecutionContext]
+	mmu         *memoryManagementUnit
 }
 
-func newWriteUnit(ctx *risc.Context, inBus *comp.BufferedBus[risc.ExecutionContext]) *writeUnit {
+func newWriteUnit(ctx *risc.Context, inBus *comp.BufferedBus[risc.ExecutionContext], mmu *memoryManagementUnit, profile latency.Profile) *writeUnit {
 	wu := &writeUnit{
-		ctx:   ctx,
-		inBus: inBus,
+		profile: profile,
+		ctx:     ctx,
+		inBus:   inBus,
+		mmu:     mmu,
 	}
 	wu.Coroutine = co.New(wu.start)
 	return wu
@@ -38,10 +42,15 @@ func (u *writeUnit) start(r wuReq) error {
 	}
 	if execution.Execution.RegisterChange {
 		u.ctx.TransactionWriteRegister(execution.Execution, execution.SequenceID)
-		u.ctx.DeletePendingRegisters(execution.ReadRegisters, execution.WriteRegisters)
+		// An atomic instruction also writes to memory, the pending registers are
+		// deleted once the memory is written
+		if !execution.Execution.MemoryChange {
+			u.ctx.DeletePendingRegisters(execution.ReadRegisters, execution.WriteRegisters)
+		}
 		log.Infoi(u.ctx, "WU", execution.InstructionType, execution.SequenceID, "write to register")
-	} else if execution.Execution.MemoryChange {
-		remainingCycle := latency.MemoryAccess
+	}
+	if execution.Execution.MemoryChange {
+		remainingCycle := u.profile.MemoryAccess
 		log.Infoi(u.ctx, "WU", execution.InstructionType, execution.SequenceID, "pending memory write")
 
 		u.Checkpoint(func(r wuReq) error {
@@ -52,21 +61,23 @@ func (u *writeUnit) start(r wuReq) error {
 			}
 			u.Reset()
 			u.ctx.WriteMemory(u.memoryWrite.Execution)
+			u.mmu.updateL3(u.memoryWrite.Execution)
+			u.ctx.DeletePendingMemoryAccess(u.memoryWrite.SequenceID)
 			u.ctx.DeletePendingRegisters(u.memoryWrite.ReadRegisters, u.memoryWrite.WriteRegisters)
 			log.Infoi(u.ctx, "WU", u.memoryWrite.InstructionType, execution.SequenceID, "write to memory")
 			return nil
 		})
 
 		u.memoryWrite = execution
-	} else {
+	} else if !execution.Execution.RegisterChange {
 		u.ctx.DeletePendingRegisters(execution.ReadRegisters, execution.WriteRegisters)
 		log.Infoi(u.ctx, "WU", execution.InstructionType, execution.SequenceID, "cleaning")
 	}
 	return nil
 }
 
-func (u *writeUnit) commit() {
-	u.ctx.Commit()
+func (u *writeUnit) commit(sequenceID int32) {
+	u.ctx.Commit(sequenceID)
 }
 
 func (u *writeUnit) rollback(sequenceID int32) {
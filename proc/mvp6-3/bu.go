@@ -40,7 +40,7 @@ func (u *btbBranchUnit) assert(runner risc.InstructionRunnerPc) {
 	} else if instructionType.IsConditionalBranch() {
 		// Assuming next instruction
 		u.toCheck = true
-		u.expectation = runner.Pc + 4
+		u.expectation = runner.Pc + risc.InstructionSize(runner.Runner)
 	} else {
 		u.toCheck = false
 	}
@@ -58,13 +58,20 @@ func (u *btbBranchUnit) shouldFlushPipeline(pc int32) bool {
 }
 
 func (u *btbBranchUnit) notifyConditionalBranchTaken(sequenceID int32) {
-	u.cu.notifyConditionalBranch()
+	u.cu.notifyConditionalBranch(sequenceID)
 	u.wu.rollback(sequenceID)
+	u.wu.commit(u.cu.oldestPendingConditionalBranch())
 }
 
-func (u *btbBranchUnit) notifyConditionalBranchNotTaken() {
-	u.cu.notifyConditionalBranch()
-	u.wu.commit()
+func (u *btbBranchUnit) notifyConditionalBranchNotTaken(sequenceID int32) {
+	u.cu.notifyConditionalBranch(sequenceID)
+	u.wu.commit(u.cu.oldestPendingConditionalBranch())
+}
+
+// speculative returns whether an instruction follows a conditional branch not
+// resolved yet.
+func (u *btbBranchUnit) speculative(sequenceID int32) bool {
+	return u.cu.oldestPendingConditionalBranch() < sequenceID
 }
 
 func (u *btbBranchUnit) notifyUnconditionalJumpAddressResolved(pc, pcTo int32) {
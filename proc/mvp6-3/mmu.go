@@ -3,23 +3,25 @@ package mvp6_3
 import (
 	"sort"
 
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
 type memoryManagementUnit struct {
+	profile  latency.Profile
 	ctx      *risc.Context
 	l1i      *comp.LRUCache
 	l3       *comp.LRUCache
 	pendings [][2]int32
 }
 
-func newMemoryManagementUnit(ctx *risc.Context) *memoryManagementUnit {
+func newMemoryManagementUnit(ctx *risc.Context, profile latency.Profile) *memoryManagementUnit {
 	return &memoryManagementUnit{
-		ctx: ctx,
-		l1i: comp.NewLRUCache(l1ICacheLineSize, l1ICacheSize),
-		l3:  comp.NewLRUCache(l3CacheLineSize, l3CacheSize),
+		profile: profile,
+		ctx:     ctx,
+		l1i:     comp.NewLRUCache(l1ICacheLineSize, l1ICacheSize),
+		l3:      comp.NewLRUCache(l3CacheLineSize, l3CacheSize),
 	}
 }
 
@@ -35,6 +37,17 @@ func (u *memoryManagementUnit) getFromL1I(addrs []int32) ([]int8, bool) {
 	return memory, true
 }
 
+// missingFromL1I returns the first address of an instruction that isn't in
+// L1I, as an instruction may straddle two lines.
+func (u *memoryManagementUnit) missingFromL1I(pc, size int32) (int32, bool) {
+	for addr := pc; addr < pc+size; addr += 2 {
+		if _, exists := u.getFromL1I([]int32{addr}); !exists {
+			return addr, true
+		}
+	}
+	return 0, false
+}
+
 func (u *memoryManagementUnit) pushLineToL1I(addr comp.AlignedAddress, line []int8) {
 	u.l1i.PushLine(addr, line)
 }
@@ -51,7 +64,8 @@ func (u *memoryManagementUnit) getFromL3(addrs []int32) ([]int8, bool, bool) {
 				}
 			}
 
-			u.pendings = append(u.pendings, [2]int32{addr, addr + l3CacheLineSize + 1})
+			alignedAddr := int32(getAlignedMemoryAddress(addr))
+			u.pendings = append(u.pendings, [2]int32{alignedAddr, alignedAddr + l3CacheLineSize})
 			return nil, false, false
 		}
 		memory = append(memory, v)
@@ -59,13 +73,25 @@ func (u *memoryManagementUnit) getFromL3(addrs []int32) ([]int8, bool, bool) {
 	return memory, false, true
 }
 
+// cancelPendingRequests cancels the requests of the flushed execute units,
+// otherwise a line that is never pushed would remain pending.
+func (u *memoryManagementUnit) cancelPendingRequests() {
+	u.pendings = nil
+}
+
 func (u *memoryManagementUnit) doesExecutionMemoryChangesExistsInL3(execution risc.Execution) bool {
 	addrs := make([]int32, 0, len(execution.MemoryChanges))
 	for addr := range execution.MemoryChanges {
 		addrs = append(addrs, addr)
 	}
-	_, _, exists := u.getFromL3(addrs)
-	return exists
+	// The lines aren't fetched in case of a write, hence no pending request is
+	// recorded
+	for _, addr := range addrs {
+		if _, exists := u.l3.Get(addr); !exists {
+			return false
+		}
+	}
+	return true
 }
 
 func (u *memoryManagementUnit) writeExecutionMemoryChangesToL3(execution risc.Execution) {
@@ -98,16 +124,23 @@ func (u *memoryManagementUnit) getFromMemory(addrs []int32) []int8 {
 	return memory
 }
 
-func (u *memoryManagementUnit) fetchCacheLine(addr int32) []int8 {
+// fetchCacheLine returns the line holding addr, along with its address, so
+// that two lines never overlap.
+func (u *memoryManagementUnit) fetchCacheLine(addr int32) (comp.AlignedAddress, []int8) {
+	alignedAddr := getAlignedMemoryAddress(addr)
 	memory := make([]int8, 0, l3CacheLineSize)
 	for i := 0; i < l3CacheLineSize; i++ {
-		if int(addr)+i >= len(u.ctx.Memory) {
+		if int(alignedAddr)+i >= len(u.ctx.Memory) {
 			memory = append(memory, 0)
 		} else {
-			memory = append(memory, u.ctx.Memory[int(addr)+i])
+			memory = append(memory, u.ctx.Memory[int(alignedAddr)+i])
 		}
 	}
-	return memory
+	return alignedAddr, memory
+}
+
+func getAlignedMemoryAddress(addr int32) comp.AlignedAddress {
+	return comp.AlignedAddress(addr - (addr % l3CacheLineSize))
 }
 
 func (u *memoryManagementUnit) pushLineToL3(addr comp.AlignedAddress, line []int8) {
@@ -122,10 +155,22 @@ func (u *memoryManagementUnit) pushLineToL3(addr comp.AlignedAddress, line []int
 			break
 		}
 	}
-	if len(evicted) == 0 {
+	if evicted == nil {
 		return
 	}
-	u.writeToMemory(int32(addr), line)
+	u.writeToMemory(int32(evicted.Boundary[0]), evicted.Data)
+}
+
+// updateL3 writes the memory changes written to memory by the write unit to
+// their L3 line, if any, as the line may have been fetched while the write
+// was pending.
+func (u *memoryManagementUnit) updateL3(execution risc.Execution) {
+	for addr, v := range execution.MemoryChanges {
+		alignedAddr := getAlignedMemoryAddress(addr)
+		if line, exists := u.l3.GetCacheLine(alignedAddr); exists {
+			line[addr-int32(alignedAddr)] = v
+		}
+	}
 }
 
 func (u *memoryManagementUnit) writeToL3(addr int32, data []int8) {
@@ -144,7 +189,7 @@ func (u *memoryManagementUnit) writeToMemory(addr int32, data []int8) {
 func (u *memoryManagementUnit) flush() int {
 	additionalCycles := 0
 	for _, line := range u.l3.Lines() {
-		additionalCycles += latency.MemoryAccess
+		additionalCycles += u.profile.MemoryAccess
 		for i := 0; i < l3CacheLineSize; i++ {
 			u.writeToMemory(int32(line.Boundary[0]), line.Data)
 		}
@@ -2,8 +2,8 @@ package mvp7_0
 
 import (
 	co "github.com/teivah/majorana/common/coroutine"
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -21,6 +21,10 @@ type ccWriteReq struct {
 	cycle int
 	addrs []int32
 	data  []int8
+	// atomic, if set, computes the data to write from the current content of
+	// the addresses (nil if nothing has to be written). It is called while the
+	// line is owned in the modified state.
+	atomic func(memory []int8) []int8
 }
 
 type ccWriteResp struct {
@@ -28,6 +32,7 @@ type ccWriteResp struct {
 }
 
 type cacheController struct {
+	profile   latency.Profile
 	ctx       *risc.Context
 	id        int
 	mmu       *memoryManagementUnit
@@ -43,8 +48,9 @@ type cacheController struct {
 	post func()
 }
 
-func newCacheController(id int, ctx *risc.Context, mmu *memoryManagementUnit, msi *msi) *cacheController {
+func newCacheController(id int, ctx *risc.Context, mmu *memoryManagementUnit, msi *msi, profile latency.Profile) *cacheController {
 	cc := &cacheController{
+		profile:   profile,
 		ctx:       ctx,
 		id:        id,
 		mmu:       mmu,
@@ -76,7 +82,7 @@ func (cc *cacheController) coSnoop(struct{}) struct{} {
 				return true
 			})
 		case writeBack:
-			cycles := latency.MemoryAccess
+			cycles := cc.profile.MemoryAccess
 			cc.snoop.Append(func(struct{}) bool {
 				if cycles > 0 {
 					cycles--
@@ -128,7 +134,7 @@ func (cc *cacheController) coRead(r ccReadReq) ccReadResp {
 				if _, exists := cc.l1d.GetCacheLine(getAlignedMemoryAddress(r.addrs)); exists {
 					panic("invalid state")
 				}
-				cycles := latency.MemoryAccess
+				cycles := cc.profile.MemoryAccess
 				lineAddr, data := cc.mmu.fetchCacheLine(r.addrs[0], l1DCacheLineSize)
 				return cc.read.ExecuteWithCheckpoint(r, func(r ccReadReq) ccReadResp {
 					if cycles > 0 {
@@ -160,7 +166,7 @@ func (cc *cacheController) coRead(r ccReadReq) ccReadResp {
 
 func (cc *cacheController) coReadFromL1(r ccReadReq) ccReadResp {
 	data := cc.getFromL1(r.addrs)
-	cycles := latency.L1Access
+	cycles := cc.profile.L1Access
 	return cc.read.ExecuteWithCheckpoint(r, func(r ccReadReq) ccReadResp {
 		if cycles > 0 {
 			cycles--
@@ -188,7 +194,7 @@ func (cc *cacheController) coWrite(r ccWriteReq) ccWriteResp {
 		}
 
 		if resp.fetchFromMemory {
-			cycles := latency.MemoryAccess
+			cycles := cc.profile.MemoryAccess
 			addr, line := cc.mmu.fetchCacheLine(r.addrs[0], l1DCacheLineSize)
 			return cc.write.ExecuteWithCheckpoint(r, func(r ccWriteReq) ccWriteResp {
 				if cycles > 0 {
@@ -199,7 +205,7 @@ func (cc *cacheController) coWrite(r ccWriteReq) ccWriteResp {
 				shouldEvict := cc.pushLineToL1(addr, line)
 				if shouldEvict != nil {
 					pending := cc.msi.evictExtraCacheLine(cc.id, shouldEvict.Boundary[0])
-					cycles = latency.L1Access
+					cycles = cc.profile.L1Access
 					cc.write.Checkpoint(func(r ccWriteReq) ccWriteResp {
 						if pending != nil && !pending.isDone() {
 							return ccWriteResp{}
@@ -227,14 +233,20 @@ func (cc *cacheController) coWrite(r ccWriteReq) ccWriteResp {
 
 // coWriteToL1 is called only if the line is already fetched.
 func (cc *cacheController) coWriteToL1(r ccWriteReq) ccWriteResp {
-	cycles := latency.L1Access
+	cycles := cc.profile.L1Access
 	return cc.write.ExecuteWithCheckpoint(r, func(r ccWriteReq) ccWriteResp {
 		if cycles > 0 {
 			cycles--
 			return ccWriteResp{}
 		}
 
-		cc.writeToL1(r.addrs, r.data)
+		data := r.data
+		if r.atomic != nil {
+			data = r.atomic(cc.getFromL1(r.addrs))
+		}
+		if data != nil {
+			cc.writeToL1(r.addrs, data)
+		}
 		cc.post()
 		cc.post = nil
 		cc.write.Reset()
@@ -281,7 +293,7 @@ func (cc *cacheController) flush() {
 	}
 	for k, sem := range cc.lockSems {
 		sem.Unlock()
-		delete(cc.rlockSems, k)
+		delete(cc.lockSems, k)
 	}
 }
 
@@ -294,7 +306,7 @@ func (cc *cacheController) export() int {
 			continue
 		}
 
-		additionalCycles += latency.MemoryAccess
+		additionalCycles += cc.profile.MemoryAccess
 		for i := 0; i < l3CacheLineSize; i++ {
 			cc.mmu.writeToMemory(line.Boundary[0], line.Data)
 		}
@@ -1,9 +1,13 @@
 package mvp7_0
 
 import (
-	"github.com/teivah/majorana/common/latency"
+	"fmt"
+	"math"
+	"strings"
+
 	"github.com/teivah/majorana/common/log"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -20,6 +24,7 @@ const (
 )
 
 type CPU struct {
+	profile              latency.Profile
 	ctx                  *risc.Context
 	fetchUnit            *fetchUnit
 	decodeBus            *comp.BufferedBus[int32]
@@ -36,7 +41,7 @@ type CPU struct {
 	msi                  *msi
 }
 
-func NewCPU(debug bool, memoryBytes int, parallelism int) *CPU {
+func NewCPU(debug bool, memoryBytes int, profile latency.Profile, parallelism int) *CPU {
 	busSize := 2
 	multiplier := 1
 	decodeBus := comp.NewBufferedBus[int32](busSize*multiplier, busSize*multiplier)
@@ -47,7 +52,7 @@ func NewCPU(debug bool, memoryBytes int, parallelism int) *CPU {
 	ctx := risc.NewContext(debug, memoryBytes, true)
 
 	mmu := newMemoryManagementUnit(ctx)
-	fu := newFetchUnit(ctx, decodeBus)
+	fu := newFetchUnit(ctx, decodeBus, profile)
 	du := newDecodeUnit(ctx, decodeBus, controlBus)
 	cu := newControlUnit(ctx, controlBus, executeBus)
 	bu := newBTBBranchUnit(ctx, 4, fu, du, cu)
@@ -57,13 +62,14 @@ func NewCPU(debug bool, memoryBytes int, parallelism int) *CPU {
 	ccs := make([]*cacheController, 0, parallelism)
 	msi := newMSI()
 	for i := 0; i < parallelism; i++ {
-		cc := newCacheController(i, ctx, mmu, msi)
+		cc := newCacheController(i, ctx, mmu, msi, profile)
 		ccs = append(ccs, cc)
-		eus = append(eus, newExecuteUnit(ctx, bu, executeBus, writeBus, mmu, cc))
+		eus = append(eus, newExecuteUnit(ctx, bu, executeBus, writeBus, mmu, cc, profile))
 		wus = append(wus, newWriteUnit(ctx, writeBus))
 	}
 
 	return &CPU{
+		profile:              profile,
 		ctx:                  ctx,
 		fetchUnit:            fu,
 		decodeBus:            decodeBus,
@@ -86,10 +92,15 @@ func (m *CPU) Context() *risc.Context {
 }
 
 func (m *CPU) Run(app risc.Application) (int, error) {
+	if err := m.ctx.Load(app); err != nil {
+		return 0, err
+	}
+	m.fetchUnit.pc = app.Entry
 	m.ctx.InitRAT()
 	cycle := 0
 	for {
 		cycle++
+		m.ctx.Cycles = int64(cycle)
 		log.Info(m.ctx, "Cycle %d", cycle)
 		m.decodeBus.Connect(cycle)
 		m.controlBus.Connect(cycle)
@@ -113,8 +124,8 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 		var (
 			flush      bool
 			sequenceID int32
+			exception  *risc.Exception
 			pc         int32
-			ret        bool
 		)
 		for i, eu := range m.executeUnits {
 			log.Infou(m.ctx, "EU", "Execute unit %d", i)
@@ -123,12 +134,13 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 			if resp.err != nil {
 				return 0, resp.err
 			}
-			if resp.flush {
+			// If several units flush, the oldest instruction wins
+			if resp.flush && (!flush || resp.sequenceID < sequenceID) {
 				sequenceID = resp.sequenceID
+				exception = resp.exception
+				pc = resp.pc
 			}
 			flush = flush || resp.flush
-			pc = max(pc, resp.pc)
-			ret = ret || resp.isReturn
 		}
 
 		// Write-back
@@ -142,19 +154,6 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 		}
 		log.Info(m.ctx, "\tRegisters: %v", m.ctx.Registers)
 
-		if ret {
-			log.Info(m.ctx, "\t🛑 Return")
-			cycle++
-			m.writeBus.Connect(cycle)
-			for !m.areWriteUnitsEmpty() || !m.writeBus.IsEmpty() {
-				for _, wu := range m.writeUnits {
-					_ = wu.Cycle(wuReq{-1})
-				}
-				cycle++
-				m.writeBus.Connect(cycle)
-			}
-			break
-		}
 		if flush {
 			// Execute pending instructions up to sequenceID.
 			log.Info(m.ctx, "\t️⚠️ Executing previous unit cycles")
@@ -177,31 +176,50 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 						isEmpty = false
 						resp := eu.Cycle(euReq{fromCycle, app})
 						if resp.err != nil {
-							return 0, nil
+							return 0, resp.err
 						}
 						if resp.flush {
 							log.Info(m.ctx, "\t️⚠️️⚠️ Proposition of an inner flush")
 							sequenceID = resp.sequenceID
+							exception = resp.exception
 							flush = resp.flush
 							pc = resp.pc
-							ret = resp.isReturn
+							// The following instructions, possibly held by a unit executed
+							// later in this cycle, are flushed as well
+							for _, unit := range m.executeUnits {
+								unit.sequenceID = sequenceID
+							}
 						}
 					}
 				}
 				m.writeBus.Connect(cycle + 1)
 				for _, wu := range m.writeUnits {
-					for !wu.isEmpty() || !m.writeBus.IsEmpty() {
+					for !wu.isEmpty() || m.writeBus.CanGet() {
 						_ = wu.Cycle(wuReq{sequenceID})
 					}
 				}
-				if isEmpty {
+				// The write bus may hold more executions than it can deliver
+				// in a cycle
+				if isEmpty && m.writeBus.IsEmpty() {
 					break
 				}
 			}
 
+			if exception != nil {
+				// The previous instructions are completed, the trap can be taken
+				var err error
+				if pc, err = m.ctx.Trap(exception); err != nil {
+					return 0, err
+				}
+			}
+
+			// The instructions following the flush are executed again
+			if err := m.ctx.CommitRetirements(sequenceID); err != nil {
+				return 0, err
+			}
 			log.Info(m.ctx, "\t️⚠️ Flush to %d", pc/4)
 			m.flush(pc)
-			cycle += latency.Flush
+			cycle += m.profile.Flush
 			log.Info(m.ctx, "\tRegisters: %v", m.ctx.Registers)
 			continue
 		}
@@ -236,10 +254,13 @@ func (m *CPU) Run(app risc.Application) (int, error) {
 		cycle += cc.export()
 	}
 
-	m.ctx.RATCommit()
+	m.ctx.RATCommit(math.MaxInt32)
 	m.ctx.RATFlush()
 	log.Info(m.ctx, "Registers: %v", m.ctx.Registers)
-	return cycle, nil
+	if err := m.ctx.CommitRetirements(math.MaxInt32); err != nil {
+		return 0, err
+	}
+	return cycle, m.ctx.ExitError()
 }
 
 func (m *CPU) Stats() map[string]any {
@@ -261,6 +282,21 @@ func (m *CPU) Stats() map[string]any {
 	}
 }
 
+// Pipeline returns the instructions in flight, stage by stage.
+func (m *CPU) Pipeline() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "decode bus: %#x\n", m.decodeBus.Values())
+	fmt.Fprintf(&sb, "control bus: %v\n", m.controlBus.Values())
+	fmt.Fprintf(&sb, "execute bus: %v\n", m.executeBus.Values())
+	for i, eu := range m.executeUnits {
+		if !eu.isEmpty() {
+			fmt.Fprintf(&sb, "execute unit %d: %v\n", i, eu.runner)
+		}
+	}
+	fmt.Fprintf(&sb, "write bus: %v\n", m.writeBus.Values())
+	return sb.String()
+}
+
 func (m *CPU) flush(pc int32) {
 	m.fetchUnit.flush(pc)
 	m.decodeUnit.flush()
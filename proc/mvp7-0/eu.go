@@ -6,6 +6,7 @@ import (
 	co "github.com/teivah/majorana/common/coroutine"
 	"github.com/teivah/majorana/common/log"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -18,12 +19,13 @@ type euResp struct {
 	flush      bool
 	sequenceID int32
 	pc         int32
-	isReturn   bool
+	exception  *risc.Exception
 	err        error
 }
 
 type executeUnit struct {
-	ctx *risc.Context
+	ctx     *risc.Context
+	profile latency.Profile
 	co.Coroutine[euReq, euResp]
 	bu     *btbBranchUnit
 	inBus  *comp.BufferedBus[*risc.InstructionRunnerPc]
@@ -36,20 +38,23 @@ type executeUnit struct {
 	runner     risc.InstructionRunnerPc
 	sequenceID int32
 	execution  risc.Execution
+	err        error
 }
 
-func newExecuteUnit(ctx *risc.Context, bu *btbBranchUnit, inBus *comp.BufferedBus[*risc.InstructionRunnerPc], outBus *comp.BufferedBus[risc.ExecutionContext], mmu *memoryManagementUnit, cc *cacheController) *executeUnit {
+func newExecuteUnit(ctx *risc.Context, bu *btbBranchUnit, inBus *comp.BufferedBus[*risc.InstructionRunnerPc], outBus *comp.BufferedBus[risc.ExecutionContext], mmu *memoryManagementUnit, cc *cacheController, profile latency.Profile) *executeUnit {
 	eu := &executeUnit{
-		ctx:    ctx,
-		bu:     bu,
-		inBus:  inBus,
-		outBus: outBus,
-		mmu:    mmu,
-		cc:     cc,
+		ctx:     ctx,
+		profile: profile,
+		bu:      bu,
+		inBus:   inBus,
+		outBus:  outBus,
+		mmu:     mmu,
+		cc:      cc,
 	}
 	eu.Coroutine = co.New(eu.start)
 	eu.Coroutine.Pre(func(r euReq) bool {
-		if eu.sequenceID == 0 || eu.runner.Runner == nil {
+		// The runner of a unit not executing anything is already completed
+		if eu.sequenceID == 0 || eu.runner.Runner == nil || eu.isEmpty() {
 			return false
 		}
 		if eu.runner.SequenceID > eu.sequenceID {
@@ -66,8 +71,17 @@ func (u *executeUnit) start(r euReq) euResp {
 	if !exists {
 		return euResp{}
 	}
+	if u.sequenceID != 0 && runner.SequenceID > u.sequenceID {
+		// A previous instruction executed during the same cycle flushes the
+		// pipeline
+		return euResp{}
+	}
 	u.runner = *runner
-	return u.ExecuteWithCheckpoint(r, u.prepareRun)
+	if ins := u.runner.Runner.InstructionType(); isMemoryAccess(ins) {
+		u.ctx.AddPendingMemoryAccess(u.runner.SequenceID, ins.IsMemoryWrite() || ins.IsAtomic())
+	}
+	// The instruction executes during the cycles of its latency
+	return u.ExecuteWithCheckpointAfter(r, u.profile.Cycles(u.runner.Runner.InstructionType())-1, u.prepareRun)
 }
 
 func (u *executeUnit) prepareRun(r euReq) euResp {
@@ -90,48 +104,137 @@ func (u *executeUnit) prepareRun(r euReq) euResp {
 		u.runner.Receiver = nil
 	}
 
+	if e := u.ctx.Interrupt(u.runner.Pc); e != nil {
+		// The instruction is interrupted: it's executed once the trap handler
+		// returns
+		u.Reset()
+		return u.raise(r, e)
+	}
+
 	// Create the branch unit assertions
 	u.bu.assert(u.runner)
 
 	log.Infoi(u.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "executing")
 
-	addrs := u.runner.Runner.MemoryRead(u.ctx, 0)
+	if u.runner.Runner.InstructionType().IsAtomic() {
+		// The read-modify-write is executed while the cache controller holds
+		// the line in the modified state
+		addrs := u.runner.Runner.MemoryWrite(u.ctx, u.runner.SequenceID)
+		if len(addrs) == 0 {
+			// The access raises an exception
+			return u.ExecuteWithReset(r, u.run)
+		}
+		return u.afterPreviousMemoryAccesses(r, true, addrs, func(r euReq) euResp {
+			resp := u.cc.write.Cycle(ccWriteReq{cycle: r.cycle, addrs: addrs, atomic: func(memory []int8) []int8 {
+				return u.runAtomic(r, memory)
+			}})
+			if !resp.done {
+				return euResp{}
+			}
+			u.ctx.DeletePendingMemoryAccess(u.runner.SequenceID)
+			return u.ExecuteWithReset(r, func(r euReq) euResp {
+				if u.err != nil {
+					return euResp{err: u.err}
+				}
+				u.ctx.RetireSpeculative(u.runner, u.execution)
+				// The memory is already written
+				execution := u.execution
+				execution.MemoryChange = false
+				return u.complete(r, execution)
+			})
+		})
+	}
+
+	addrs := u.runner.Runner.MemoryRead(u.ctx, u.runner.SequenceID)
 	if len(addrs) != 0 {
-		return u.ExecuteWithCheckpoint(r, func(r euReq) euResp {
+		return u.afterPreviousMemoryAccesses(r, false, addrs, func(r euReq) euResp {
 			resp := u.cc.read.Cycle(ccReadReq{r.cycle, addrs})
 			if !resp.done {
 				return euResp{}
 			}
+			u.ctx.DeletePendingMemoryAccess(u.runner.SequenceID)
 			u.memory = resp.data
 			return u.ExecuteWithReset(r, u.run)
 		})
 	}
+	if u.ctx.StoresToDevice(u.runner.Runner, u.runner.SequenceID) {
+		// The device is written by Run, which waits for the previous memory
+		// accesses and conditional branches
+		return u.afterPreviousMemoryAccesses(r, true, nil, func(r euReq) euResp {
+			u.ctx.DeletePendingMemoryAccess(u.runner.SequenceID)
+			return u.ExecuteWithReset(r, u.run)
+		})
+	}
 	return u.ExecuteWithReset(r, u.run)
 }
 
 func (u *executeUnit) run(r euReq) euResp {
-	execution, err := u.runner.Runner.Run(u.ctx, r.app.Labels, u.runner.Pc, u.memory, 0)
+	execution, err := u.runner.Runner.Run(u.ctx, r.app.Labels, u.runner.Pc, u.memory, u.runner.SequenceID)
 	if err != nil {
 		return euResp{err: err}
 	}
 	log.Infoi(u.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "execution result: %+v", execution)
-	if execution.Return {
-		return euResp{isReturn: true}
+	if execution.Exception != nil {
+		return u.raise(r, execution.Exception)
 	}
+	u.ctx.RetireSpeculative(u.runner, execution)
 
 	if execution.MemoryChange {
 		writeAddrs, data := executionToMemoryChanges(execution)
 		u.execution = execution
 
-		return u.ExecuteWithCheckpoint(r, func(r euReq) euResp {
-			resp := u.cc.write.Cycle(ccWriteReq{r.cycle, writeAddrs, data})
-			if resp.done {
+		return u.afterPreviousMemoryAccesses(r, true, writeAddrs, func(r euReq) euResp {
+			resp := u.cc.write.Cycle(ccWriteReq{cycle: r.cycle, addrs: writeAddrs, data: data})
+			if !resp.done {
+				return euResp{}
+			}
+			u.ctx.DeletePendingMemoryAccess(u.runner.SequenceID)
+			if !execution.RegisterChange {
 				u.Reset()
+				return euResp{}
 			}
-			return euResp{}
+			return u.ExecuteWithReset(r, func(r euReq) euResp {
+				// A system call also returns a value; the memory is already
+				// written
+				execution.MemoryChange = false
+				return u.complete(r, execution)
+			})
 		})
 	}
 
+	return u.complete(r, execution)
+}
+
+// afterPreviousMemoryAccesses executes f once the memory accesses preceding
+// the current one on the same addresses, possibly executed by another core, are
+// completed: a read waits for the previous writes, and a write for all the
+// previous accesses. A write also waits for the previous conditional branches
+// to be resolved.
+func (u *executeUnit) afterPreviousMemoryAccesses(r euReq, write bool, addrs []int32, f func(euReq) euResp) euResp {
+	return u.ExecuteWithCheckpoint(r, func(r euReq) euResp {
+		if u.ctx.PendingMemoryAccess(u.runner.SequenceID, write, addrs) ||
+			write && u.bu.speculative(u.runner.SequenceID) {
+			return euResp{}
+		}
+		return u.ExecuteWithCheckpoint(r, f)
+	})
+}
+
+// runAtomic executes an atomic instruction. As it may be executed long after
+// being dispatched, the registers are read using its sequence ID so that a
+// register renamed by a following instruction isn't read.
+func (u *executeUnit) runAtomic(r euReq, memory []int8) []int8 {
+	u.execution, u.err = u.runner.Runner.Run(u.ctx, r.app.Labels, u.runner.Pc, memory, u.runner.SequenceID)
+	if u.err != nil || !u.execution.MemoryChange {
+		return nil
+	}
+	_, data := executionToMemoryChanges(u.execution)
+	return data
+}
+
+// complete publishes the execution to the write unit and notifies the branch
+// unit.
+func (u *executeUnit) complete(r euReq, execution risc.Execution) euResp {
 	u.outBus.Add(risc.ExecutionContext{
 		SequenceID:      u.runner.SequenceID,
 		Execution:       execution,
@@ -147,18 +250,25 @@ func (u *executeUnit) run(r euReq) euResp {
 			u.bu.notifyUnconditionalJumpAddressResolved(u.runner.Pc, execution.NextPc)
 		}
 		if u.runner.Runner.InstructionType().IsConditionalBranch() {
-			if execution.PcChange {
+			if execution.PcChange && execution.NextPc != u.runner.Pc+risc.InstructionSize(u.runner.Runner) {
 				// Branch taken (jump)
 				u.bu.notifyConditionalBranchTaken(u.runner.SequenceID)
 			} else {
-				// Branch not taken (next PC)
-				u.bu.notifyConditionalBranchNotTaken()
+				// Branch not taken (next PC), or taken to the next instruction
+				// without flushing the instructions following it
+				u.bu.notifyConditionalBranchNotTaken(u.runner.SequenceID)
 			}
 		}
 		if execution.PcChange && u.bu.shouldFlushPipeline(execution.NextPc) {
 			log.Infoi(u.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "should be a flush")
 			return euResp{flush: true, sequenceID: u.runner.SequenceID, pc: execution.NextPc}
 		}
+		if u.runner.Runner.InstructionType() == risc.Fence {
+			// The following instructions are executed again once the previous
+			// ones, including their memory writes, are completed
+			log.Infoi(u.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "fence")
+			return euResp{flush: true, sequenceID: u.runner.SequenceID, pc: u.runner.Pc + risc.InstructionSize(u.runner.Runner)}
+		}
 	} else {
 		u.runner.Forwarder <- execution.RegisterValue
 		log.Infoi(u.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "forward register value %d", execution.RegisterValue)
@@ -197,6 +307,21 @@ func executionToMemoryChanges(execution risc.Execution) ([]int32, []int8) {
 	return addrs, memory
 }
 
+// raise publishes an instruction raising an exception so that its pending
+// registers are released, and requests a flush: the trap is taken once the
+// previous instructions are completed.
+func (u *executeUnit) raise(r euReq, e *risc.Exception) euResp {
+	log.Infoi(u.ctx, "EU", u.runner.Runner.InstructionType(), u.runner.Pc, "exception: %v", e)
+	u.ctx.DeletePendingMemoryAccess(u.runner.SequenceID)
+	u.outBus.Add(risc.ExecutionContext{
+		SequenceID:      u.runner.SequenceID,
+		InstructionType: u.runner.Runner.InstructionType(),
+		WriteRegisters:  u.runner.Runner.WriteRegisters(),
+		ReadRegisters:   u.runner.Runner.ReadRegisters(),
+	}, r.cycle)
+	return euResp{flush: true, sequenceID: u.runner.SequenceID, exception: e}
+}
+
 func (u *executeUnit) flush() {
 	u.Reset()
 	u.sequenceID = 0
@@ -206,3 +331,7 @@ func (u *executeUnit) flush() {
 func (u *executeUnit) isEmpty() bool {
 	return u.IsStart()
 }
+
+func isMemoryAccess(ins risc.InstructionType) bool {
+	return ins.IsMemoryRead() || ins.IsMemoryWrite() || ins.IsAtomic()
+}
@@ -122,11 +122,12 @@ func (m *msi) rLock(id int, addrs []int32) (msiResponse, func(), *comp.Sem) {
 				m.getSem(addrs).RUnlock()
 			}, m.getSem(addrs)
 	case modified:
-		if !m.getSem(addrs).Lock() {
+		// The cache controller releases the returned semaphore as a read lock
+		if !m.getSem(addrs).RLock() {
 			return msiResponse{wait: true}, noop, nil
 		}
 		return msiResponse{readFromL1: true}, func() {
-			m.getSem(addrs).Unlock()
+			m.getSem(addrs).RUnlock()
 		}, m.getSem(addrs)
 	case shared:
 		if !m.getSem(addrs).RLock() {
@@ -2,10 +2,11 @@ package mvp8_0
 
 import (
 	"fmt"
+	"sync"
 
 	co "github.com/teivah/majorana/common/coroutine"
-	"github.com/teivah/majorana/common/latency"
 	"github.com/teivah/majorana/proc/comp"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/risc"
 )
 
@@ -29,6 +30,10 @@ type ccWriteReq struct {
 	cycle int
 	addrs []int32
 	data  []int8
+	// atomic, if set, computes the data to write from the current content of
+	// the addresses (nil if nothing has to be written). It is called while the
+	// line is owned in the modified state.
+	atomic func(memory []int8) []int8
 }
 
 type ccWriteResp struct {
@@ -36,6 +41,7 @@ type ccWriteResp struct {
 }
 
 type cacheController struct {
+	profile     latency.Profile
 	ctx         *risc.Context
 	id          int
 	mmu         *memoryManagementUnit
@@ -47,13 +53,16 @@ type cacheController struct {
 	msi         *msi
 	l1RLockSems map[comp.AlignedAddress]*comp.Sem
 	l1LockSems  map[comp.AlignedAddress]*comp.Sem
+	// l3Lock is the L3 lock held by a read, released if the read is flushed
+	l3Lock *sync.Mutex
 
 	// Transient
 	post func()
 }
 
-func newCacheController(id int, ctx *risc.Context, mmu *memoryManagementUnit, msi *msi, l3 *comp.LRUCache) *cacheController {
+func newCacheController(id int, ctx *risc.Context, mmu *memoryManagementUnit, msi *msi, l3 *comp.LRUCache, profile latency.Profile) *cacheController {
 	cc := &cacheController{
+		profile:     profile,
 		ctx:         ctx,
 		id:          id,
 		mmu:         mmu,
@@ -113,9 +122,9 @@ func (cc *cacheController) coSnoop(struct{}) struct{} {
 		case l1WriteBack:
 			cc.assertAddrInState(req.alignedAddr, modified)
 			cc.msi.staleState = true
-			cycles1 := latency.L3Access
-			cycles2 := latency.MemoryAccess
-			cycles3 := latency.L3Access
+			cycles1 := cc.profile.L3Access
+			cycles2 := cc.profile.MemoryAccess
+			cycles3 := cc.profile.L3Access
 			cc.snoop.Append(func(struct{}) bool {
 				if cycles1 > 0 {
 					cycles1--
@@ -157,7 +166,7 @@ func (cc *cacheController) coSnoop(struct{}) struct{} {
 				}
 			})
 		case l3WriteBack:
-			cycles := latency.MemoryAccess
+			cycles := cc.profile.MemoryAccess
 			cc.snoop.Append(func(struct{}) bool {
 				if cycles > 0 {
 					cycles--
@@ -226,7 +235,7 @@ func (cc *cacheController) coRead(r ccReadReq) ccReadResp {
 					panic("invalid state")
 				}
 
-				return cc.read.ExecuteWithCheckpointAfter(r, latency.L3Access, func(r ccReadReq) ccReadResp {
+				return cc.read.ExecuteWithCheckpointAfter(r, cc.profile.L3Access, func(r ccReadReq) ccReadResp {
 					if cc.isAddressInL3(r.addrs) {
 						// Fetch from L3, sync to L1
 						l1Addr, l1Data, exists := cc.l3.GetSubCacheLine(r.addrs, l1DCacheLineSize)
@@ -249,16 +258,18 @@ func (cc *cacheController) coRead(r ccReadReq) ccReadResp {
 					} else {
 						// Fetch from memory, sync to L3, sync to L1
 						l3Addr, l3Data := cc.mmu.fetchCacheLine(r.addrs[0], l3CacheLineSize)
-						return cc.read.ExecuteWithCheckpointAfter(r, latency.MemoryAccess, func(r ccReadReq) ccReadResp {
+						return cc.read.ExecuteWithCheckpointAfter(r, cc.profile.MemoryAccess, func(r ccReadReq) ccReadResp {
 							return cc.read.ExecuteWithCheckpoint(r, func(r ccReadReq) ccReadResp {
 								mu := cc.msi.getL3Lock(r.addrs)
 								if !mu.TryLock() {
 									return ccReadResp{}
 								}
+								cc.l3Lock = mu
 
-								return cc.read.ExecuteWithCheckpointAfter(r, latency.L3Access, func(r ccReadReq) ccReadResp {
+								return cc.read.ExecuteWithCheckpointAfter(r, cc.profile.L3Access, func(r ccReadReq) ccReadResp {
 									shouldEvict := cc.pushLineToL3(l3Addr, l3Data)
 									mu.Unlock()
+									cc.l3Lock = nil
 									if shouldEvict != nil {
 										pending := cc.msi.evictL3ExtraCacheLine(cc.id, shouldEvict.Boundary[0])
 										cc.read.Checkpoint(func(r ccReadReq) ccReadResp {
@@ -304,7 +315,7 @@ func (cc *cacheController) coSyncReadFromL1(r ccReadReq) ccReadResp {
 
 func (cc *cacheController) coReadFromL1(r ccReadReq) ccReadResp {
 	data := cc.getFromL1(r.addrs)
-	return cc.read.ExecuteWithCheckpointAfter(r, latency.L1Access, func(r ccReadReq) ccReadResp {
+	return cc.read.ExecuteWithCheckpointAfter(r, cc.profile.L1Access, func(r ccReadReq) ccReadResp {
 		cc.post()
 		cc.post = nil
 		cc.read.Reset()
@@ -335,7 +346,7 @@ func (cc *cacheController) coWrite(r ccWriteReq) ccWriteResp {
 					panic("invalid state")
 				}
 
-				return cc.write.ExecuteWithCheckpointAfter(r, latency.L1Access, func(r ccWriteReq) ccWriteResp {
+				return cc.write.ExecuteWithCheckpointAfter(r, cc.profile.L1Access, func(r ccWriteReq) ccWriteResp {
 					shouldEvict := cc.pushLineToL1(l1Addr, l1Data)
 					if shouldEvict != nil {
 						pending := cc.msi.evictL1ExtraCacheLine(cc.id, shouldEvict.Boundary[0])
@@ -343,7 +354,7 @@ func (cc *cacheController) coWrite(r ccWriteReq) ccWriteResp {
 							if pending != nil && !pending.isDone() {
 								return ccWriteResp{}
 							}
-							return cc.write.ExecuteWithCheckpointAfter(r, latency.L1Access, cc.coWriteToL1)
+							return cc.write.ExecuteWithCheckpointAfter(r, cc.profile.L1Access, cc.coWriteToL1)
 						})
 						return ccWriteResp{}
 					}
@@ -352,8 +363,8 @@ func (cc *cacheController) coWrite(r ccWriteReq) ccWriteResp {
 			} else {
 				// Fetch from memory, sync to L3, sync to L1
 				l3Addr, l3Data := cc.mmu.fetchCacheLine(r.addrs[0], l3CacheLineSize)
-				return cc.write.ExecuteWithCheckpointAfter(r, latency.MemoryAccess, func(r ccWriteReq) ccWriteResp {
-					return cc.write.ExecuteWithCheckpointAfter(r, latency.L3Access, func(r ccWriteReq) ccWriteResp {
+				return cc.write.ExecuteWithCheckpointAfter(r, cc.profile.MemoryAccess, func(r ccWriteReq) ccWriteResp {
+					return cc.write.ExecuteWithCheckpointAfter(r, cc.profile.L3Access, func(r ccWriteReq) ccWriteResp {
 						mu := cc.msi.getL3Lock(r.addrs)
 						if !mu.TryLock() {
 							return ccWriteResp{}
@@ -395,7 +406,7 @@ func (cc *cacheController) coSyncWriteToL1(r ccWriteReq) ccWriteResp {
 			if pending != nil && !pending.isDone() {
 				return ccWriteResp{}
 			}
-			return cc.write.ExecuteWithCheckpointAfter(r, latency.L1Access, cc.coWriteToL1)
+			return cc.write.ExecuteWithCheckpointAfter(r, cc.profile.L1Access, cc.coWriteToL1)
 		})
 		return ccWriteResp{}
 	}
@@ -404,8 +415,14 @@ func (cc *cacheController) coSyncWriteToL1(r ccWriteReq) ccWriteResp {
 
 // coWriteToL1 is called only if the line is already fetched.
 func (cc *cacheController) coWriteToL1(r ccWriteReq) ccWriteResp {
-	return cc.write.ExecuteWithCheckpointAfter(r, latency.L1Access, func(r ccWriteReq) ccWriteResp {
-		cc.writeToL1(r.addrs, r.data)
+	return cc.write.ExecuteWithCheckpointAfter(r, cc.profile.L1Access, func(r ccWriteReq) ccWriteResp {
+		data := r.data
+		if r.atomic != nil {
+			data = r.atomic(cc.getFromL1(r.addrs))
+		}
+		if data != nil {
+			cc.writeToL1(r.addrs, data)
+		}
 		cc.post()
 		cc.post = nil
 		cc.write.Reset()
@@ -485,12 +502,27 @@ func (cc *cacheController) flush() {
 	cc.write.Reset()
 	for k, sem := range cc.l1RLockSems {
 		sem.RUnlock()
+		cc.evictUnsyncedL1Line(k)
 		delete(cc.l1RLockSems, k)
 	}
 	for k, sem := range cc.l1LockSems {
 		sem.Unlock()
-		delete(cc.l1RLockSems, k)
+		cc.evictUnsyncedL1Line(k)
+		delete(cc.l1LockSems, k)
+	}
+	if cc.l3Lock != nil {
+		cc.l3Lock.Unlock()
+		cc.l3Lock = nil
+	}
+}
+
+// evictUnsyncedL1Line evicts a line pushed to L1 by an access flushed before
+// the MSI state of the line was set.
+func (cc *cacheController) evictUnsyncedL1Line(addr comp.AlignedAddress) {
+	if cc.msi.states[msiEntry{cc.id, addr}] != invalid {
+		return
 	}
+	cc.l1d.EvictCacheLine(addr)
 }
 
 func (cc *cacheController) writeBack() int {
@@ -508,12 +540,12 @@ func (cc *cacheController) writeBack() int {
 				panic("invalid state")
 			}
 
-			additionalCycles += latency.L3Access
+			additionalCycles += cc.profile.L3Access
 			cc.writeToL3(line.Boundary[0], line.Data)
 			mu.Unlock()
 		} else {
 			// Line was evicted
-			additionalCycles += latency.MemoryAccess
+			additionalCycles += cc.profile.MemoryAccess
 			cc.mmu.writeToMemory(line.Boundary[0], line.Data)
 		}
 	}
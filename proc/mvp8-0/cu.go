@@ -1,6 +1,9 @@
 package mvp8_0
 
 import (
+	"math"
+	"slices"
+
 	"github.com/teivah/majorana/common/cache"
 	"github.com/teivah/majorana/common/ds"
 	"github.com/teivah/majorana/common/log"
@@ -23,8 +26,10 @@ type controlUnit struct {
 	pushedRunnersInCurrentCycle  map[*risc.InstructionRunnerPc]bool
 	skippedInCurrentCycle        []risc.InstructionRunnerPc
 	pushedBranchInCurrentCycle   bool
-	pendingConditionalBranch     bool
-	msi                          *msi
+	// pendingConditionalBranches holds the sequence IDs of the conditional
+	// branches not resolved yet
+	pendingConditionalBranches []int32
+	msi                        *msi
 	// An MSI copy, not necessarily up-to-date
 	// Used to distribute the work to the right core (right = has already fetched
 	// the cache line)
@@ -68,6 +73,9 @@ func (u *controlUnit) cycle(cycle int) {
 	if u.msi.staleState {
 		u.msiStatesCopy = u.msi.copyState()
 		u.msi.staleState = false
+		// Nothing is pushed during this cycle, so nothing can be forwarded
+		// during the next one
+		u.pushedRunnersInPreviousCycle = nil
 		// Return to simulate that it takes a cycle to sync the MSI state
 		return
 	}
@@ -105,7 +113,7 @@ func (u *controlUnit) cycle(cycle int) {
 				u.pushedBranchInCurrentCycle = true
 			}
 			if runner.Runner.InstructionType().IsConditionalBranch() {
-				u.pendingConditionalBranch = true
+				u.pendingConditionalBranches = append(u.pendingConditionalBranches, runner.SequenceID)
 			}
 		} else {
 			u.skippedInCurrentCycle = append(u.skippedInCurrentCycle, runner)
@@ -128,7 +136,7 @@ func (u *controlUnit) cycle(cycle int) {
 				u.pushedBranchInCurrentCycle = true
 			}
 			if runner.Runner.InstructionType().IsConditionalBranch() {
-				u.pendingConditionalBranch = true
+				u.pendingConditionalBranches = append(u.pendingConditionalBranches, runner.SequenceID)
 			}
 		} else {
 			u.pendings.Push(runner)
@@ -145,7 +153,7 @@ func (u *controlUnit) handleRunner(ctx *risc.Context, cycle int, runner *risc.In
 		return false, true
 	}
 
-	if runner.Runner.InstructionType() == risc.Ret && (!u.outBus.IsEmpty() || u.pendingConditionalBranch) {
+	if runner.Runner.InstructionType().IsSerializing() && (!u.outBus.IsEmpty() || len(u.pendingConditionalBranches) != 0) {
 		return false, true
 	}
 
@@ -166,7 +174,8 @@ func (u *controlUnit) handleRunner(ctx *risc.Context, cycle int, runner *risc.In
 	if should, previousRunner, register := u.shouldUseForwarding(runner, hazards, hazardTypes); should {
 		ch := make(chan int32, 1)
 		previousRunner.Forwarder = ch
-		previousRunner.ForwardRegister = register
+		// The forward register is only set on the receiver, as the previous
+		// runner may itself be waiting for a forwarded value
 		runner.Receiver = ch
 		runner.ForwardRegister = register
 
@@ -237,20 +246,40 @@ func (u *controlUnit) shouldUseForwarding(runner *risc.InstructionRunnerPc, haza
 		return false, nil, risc.Zero
 	}
 
-	// Can we use forwarding with an instruction pushed in the previous cycle
+	// A renamed instruction pushed in the current cycle may be the last one
+	// writing the register, in which case the value can't be forwarded
+	for currentRunner := range u.pushedRunnersInCurrentCycle {
+		for _, writeRegister := range currentRunner.Runner.WriteRegisters() {
+			if slices.Contains(runner.Runner.ReadRegisters(), writeRegister) {
+				return false, nil, risc.Zero
+			}
+		}
+	}
+
+	// Can we use forwarding with an instruction pushed in the previous cycle;
+	// if several of them write the register, the value of the last one is
+	// forwarded
+	var (
+		source   *risc.InstructionRunnerPc
+		register risc.RegisterType
+	)
 	for previousRunner := range u.pushedRunnersInPreviousCycle {
 		for _, writeRegister := range previousRunner.Runner.WriteRegisters() {
 			for _, readRegister := range runner.Runner.ReadRegisters() {
-				if readRegister == risc.Zero {
+				// A forwarded value is an integer register value
+				if readRegister == risc.Zero || readRegister.IsFloat() {
 					continue
 				}
-				if readRegister == writeRegister {
-					return true, previousRunner, readRegister
+				if readRegister == writeRegister && (source == nil || previousRunner.SequenceID > source.SequenceID) {
+					source, register = previousRunner, readRegister
 				}
 			}
 		}
 	}
-	return false, nil, risc.Zero
+	if source == nil {
+		return false, nil, risc.Zero
+	}
+	return true, source, register
 }
 
 func (u *controlUnit) shouldUseRenaming(hazards []risc.Hazard, hazardTypes map[risc.HazardType]bool) bool {
@@ -263,8 +292,19 @@ func (u *controlUnit) shouldUseRenaming(hazards []risc.Hazard, hazardTypes map[r
 	return true
 }
 
-func (u *controlUnit) notifyConditionalBranch() {
-	u.pendingConditionalBranch = false
+func (u *controlUnit) notifyConditionalBranch(sequenceID int32) {
+	u.pendingConditionalBranches = slices.DeleteFunc(u.pendingConditionalBranches, func(id int32) bool {
+		return id == sequenceID
+	})
+}
+
+// oldestPendingConditionalBranch returns the sequence ID of the oldest
+// conditional branch not resolved yet, or math.MaxInt32 if there is none.
+func (u *controlUnit) oldestPendingConditionalBranch() int32 {
+	if len(u.pendingConditionalBranches) == 0 {
+		return math.MaxInt32
+	}
+	return slices.Min(u.pendingConditionalBranches)
 }
 
 func (u *controlUnit) pushRunner(ctx *risc.Context, cycle int, runner *risc.InstructionRunnerPc) bool {
@@ -280,9 +320,20 @@ func (u *controlUnit) pushRunner(ctx *risc.Context, cycle int, runner *risc.Inst
 }
 
 func (u *controlUnit) getExecutionUnitIDPreference(runner *risc.InstructionRunnerPc) option.Optional[int] {
-	if runner.Runner.InstructionType().IsMemoryRead() {
-		addr := getL1AlignedMemoryAddress(runner.Runner.MemoryRead(u.ctx, runner.SequenceID))
-		readers := u.getLineReaders(addr)
+	if runner.Runner.InstructionType().IsAtomic() {
+		// An atomic instruction requires the line in the modified state
+		addrs := runner.Runner.MemoryWrite(u.ctx, runner.SequenceID)
+		if len(addrs) == 0 {
+			// The access raises an exception
+			return option.None[int]()
+		}
+		return u.getLineWriter(getL1AlignedMemoryAddress(addrs))
+	} else if runner.Runner.InstructionType().IsMemoryRead() {
+		addrs := runner.Runner.MemoryRead(u.ctx, runner.SequenceID)
+		if len(addrs) == 0 {
+			return option.None[int]()
+		}
+		readers := u.getLineReaders(getL1AlignedMemoryAddress(addrs))
 		if len(readers) == 0 {
 			return option.None[int]()
 		}
@@ -293,8 +344,11 @@ func (u *controlUnit) getExecutionUnitIDPreference(runner *risc.InstructionRunne
 		}
 		return option.Of[int](readers[v])
 	} else if runner.Runner.InstructionType().IsMemoryWrite() {
-		addr := getL1AlignedMemoryAddress(runner.Runner.MemoryWrite(u.ctx, runner.SequenceID))
-		return u.getLineWriter(addr)
+		addrs := runner.Runner.MemoryWrite(u.ctx, runner.SequenceID)
+		if len(addrs) == 0 {
+			return option.None[int]()
+		}
+		return u.getLineWriter(getL1AlignedMemoryAddress(addrs))
 	} else {
 		return option.None[int]()
 	}
@@ -324,7 +378,7 @@ func (u *controlUnit) getLineWriter(addr comp.AlignedAddress) option.Optional[in
 func (u *controlUnit) flush() {
 	u.pendings = comp.NewQueue[risc.InstructionRunnerPc](pendingLength)
 	u.pushedRunnersInPreviousCycle = nil
-	u.pendingConditionalBranch = false
+	u.pendingConditionalBranches = nil
 }
 
 func (u *controlUnit) isEmpty() bool {
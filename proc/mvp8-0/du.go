@@ -11,7 +11,6 @@ import (
 
 type decodeUnit struct {
 	ctx                     *risc.Context
-	ret                     bool
 	pendingBranchResolution bool
 	log                     string
 	inBus                   *comp.BufferedBus[int32]
@@ -44,9 +43,6 @@ func (u *decodeUnit) cycle(cycle int, app risc.Application) {
 	} else {
 		u.blocked.Push(0)
 	}
-	if u.ret {
-		return
-	}
 	if u.pendingBranchResolution {
 		log.Infou(u.ctx, "DU", "blocked")
 		return
@@ -60,10 +56,11 @@ func (u *decodeUnit) cycle(cycle int, app risc.Application) {
 		if !exists {
 			return
 		}
-		if int(pc)/4 >= len(app.Instructions) {
+		if pc >= app.End() {
+			// Past the end of the application
 			return
 		}
-		runner := app.Instructions[pc/4]
+		runner := app.Fetch(pc)
 		// Clear forward
 		runner.Forward(risc.Forward{})
 		log.Infoi(u.ctx, "DU", runner.InstructionType(), pc, "decoding")
@@ -75,17 +72,15 @@ func (u *decodeUnit) cycle(cycle int, app risc.Application) {
 			jump = true
 		}
 		u.outBus.Add(risc.InstructionRunnerPc{
-			Runner:     runner,
-			Pc:         pc,
-			SequenceID: u.ctx.SequenceID(pc),
+			Runner:       runner,
+			Pc:           pc,
+			SequenceID:   u.ctx.SequenceID(pc),
+			ProgramOrder: u.ctx.NextProgramOrder(),
 		}, cycle)
 		pushed++
 		if jump {
 			return
 		}
-		if runner.InstructionType() == risc.Ret {
-			u.ret = true
-		}
 	}
 }
 
@@ -95,7 +90,6 @@ func (u *decodeUnit) notifyBranchResolved() {
 
 func (u *decodeUnit) flush() {
 	u.pendingBranchResolution = false
-	u.ret = false
 }
 
 func (u *decodeUnit) isEmpty() bool {
@@ -153,11 +153,12 @@ func (m *msi) l1RLock(id int, addrs []int32) (msiResponse, func(), *comp.Sem) {
 				m.getL1Sem(addrs).RUnlock()
 			}, m.getL1Sem(addrs)
 	case modified:
-		if !m.getL1Sem(addrs).Lock() {
+		// The cache controller releases the returned semaphore as a read lock
+		if !m.getL1Sem(addrs).RLock() {
 			return msiResponse{wait: true}, noop, nil
 		}
 		return msiResponse{fromL1: true}, func() {
-			m.getL1Sem(addrs).Unlock()
+			m.getL1Sem(addrs).RUnlock()
 		}, m.getL1Sem(addrs)
 	case shared:
 		if !m.getL1Sem(addrs).RLock() {
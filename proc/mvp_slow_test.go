@@ -3,6 +3,7 @@ package proc
 import (
 	"testing"
 
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/proc/mvp1"
 	"github.com/teivah/majorana/proc/mvp2"
 	"github.com/teivah/majorana/proc/mvp3"
@@ -24,7 +25,7 @@ const (
 func TestSlowMvp1(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp1.NewCPU(false, memory)
+		return mvp1.NewCPU(false, memory, latency.M1)
 	}
 	testSlow(t, factory)
 }
@@ -32,7 +33,7 @@ func TestSlowMvp1(t *testing.T) {
 func TestSlowMvp2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp2.NewCPU(false, memory)
+		return mvp2.NewCPU(false, memory, latency.M1)
 	}
 	testSlow(t, factory)
 }
@@ -40,7 +41,7 @@ func TestSlowMvp2(t *testing.T) {
 func TestSlowMvp3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp3.NewCPU(false, memory)
+		return mvp3.NewCPU(false, memory, latency.M1)
 	}
 	testSlow(t, factory)
 }
@@ -48,7 +49,7 @@ func TestSlowMvp3(t *testing.T) {
 func TestSlowMvp4(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp4.NewCPU(false, memory)
+		return mvp4.NewCPU(false, memory, latency.M1)
 	}
 	testSlow(t, factory)
 }
@@ -56,7 +57,7 @@ func TestSlowMvp4(t *testing.T) {
 func TestSlowMvp5(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp5.NewCPU(false, memory)
+		return mvp5.NewCPU(false, memory, latency.M1)
 	}
 	testSlow(t, factory)
 }
@@ -64,7 +65,7 @@ func TestSlowMvp5(t *testing.T) {
 func TestSlowMvp6_0_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_0.NewCPU(false, memory, 2, 2)
+		return mvp6_0.NewCPU(false, memory, latency.M1, 2, 2)
 	}
 	testSlow(t, factory)
 }
@@ -72,7 +73,7 @@ func TestSlowMvp6_0_2x2(t *testing.T) {
 func TestSlowMvp6_0_3x3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_0.NewCPU(false, memory, 3, 3)
+		return mvp6_0.NewCPU(false, memory, latency.M1, 3, 3)
 	}
 	testSlow(t, factory)
 }
@@ -80,7 +81,7 @@ func TestSlowMvp6_0_3x3(t *testing.T) {
 func TestSlowMvp6_1_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_1.NewCPU(false, memory, 2, 2)
+		return mvp6_1.NewCPU(false, memory, latency.M1, 2, 2)
 	}
 	testSlow(t, factory)
 }
@@ -92,7 +93,7 @@ func TestSlowMvp6_1_3x3(t *testing.T) {
 func TestSlowMvp6_2_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_2.NewCPU(false, memory, 2, 2)
+		return mvp6_2.NewCPU(false, memory, latency.M1, 2, 2)
 	}
 	testSlow(t, factory)
 }
@@ -100,7 +101,7 @@ func TestSlowMvp6_2_2x2(t *testing.T) {
 func TestSlowMvp6_2_3x3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_2.NewCPU(false, memory, 3, 3)
+		return mvp6_2.NewCPU(false, memory, latency.M1, 3, 3)
 	}
 	testSlow(t, factory)
 }
@@ -108,7 +109,7 @@ func TestSlowMvp6_2_3x3(t *testing.T) {
 func TestSlowMvp6_3_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_3.NewCPU(false, memory, 2, 2)
+		return mvp6_3.NewCPU(false, memory, latency.M1, 2, 2)
 	}
 	testSlow(t, factory)
 }
@@ -116,7 +117,7 @@ func TestSlowMvp6_3_2x2(t *testing.T) {
 func TestSlowMvp6_3_3x3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_3.NewCPU(false, memory, 3, 3)
+		return mvp6_3.NewCPU(false, memory, latency.M1, 3, 3)
 	}
 	testSlow(t, factory)
 }
@@ -124,7 +125,7 @@ func TestSlowMvp6_3_3x3(t *testing.T) {
 func TestSlowMvp7_0_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp7_0.NewCPU(false, memory, 2)
+		return mvp7_0.NewCPU(false, memory, latency.M1, 2)
 	}
 	testSlow(t, factory)
 }
@@ -132,7 +133,7 @@ func TestSlowMvp7_0_2x2(t *testing.T) {
 func TestSlowMvp7_0_3x3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp7_0.NewCPU(false, memory, 3)
+		return mvp7_0.NewCPU(false, memory, latency.M1, 3)
 	}
 	testSlow(t, factory)
 }
@@ -140,7 +141,7 @@ func TestSlowMvp7_0_3x3(t *testing.T) {
 func TestSlowMvp7_1_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp7_1.NewCPU(false, memory, 2)
+		return mvp7_1.NewCPU(false, memory, latency.M1, 2)
 	}
 	testSlow(t, factory)
 }
@@ -148,7 +149,7 @@ func TestSlowMvp7_1_2x2(t *testing.T) {
 func TestSlowMvp7_1_3x3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp7_1.NewCPU(false, memory, 3)
+		return mvp7_1.NewCPU(false, memory, latency.M1, 3)
 	}
 	testSlow(t, factory)
 }
@@ -156,7 +157,7 @@ func TestSlowMvp7_1_3x3(t *testing.T) {
 func TestSlowMvp7_2_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp7_2.NewCPU(false, memory, 2)
+		return mvp7_2.NewCPU(false, memory, latency.M1, 2)
 	}
 	testSlow(t, factory)
 }
@@ -164,7 +165,7 @@ func TestSlowMvp7_2_2x2(t *testing.T) {
 func TestSlowMvp7_2_3x3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp7_2.NewCPU(false, memory, 3)
+		return mvp7_2.NewCPU(false, memory, latency.M1, 3)
 	}
 	testSlow(t, factory)
 }
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/teivah/majorana/common/bytes"
+	"github.com/teivah/majorana/proc/latency"
 	"github.com/teivah/majorana/proc/mvp1"
 	"github.com/teivah/majorana/proc/mvp2"
 	"github.com/teivah/majorana/proc/mvp3"
@@ -104,7 +105,7 @@ func bubsort(list []int32, size int) {
 func TestMvp1(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp1.NewCPU(false, memory)
+		return mvp1.NewCPU(false, memory, latency.M1)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -118,7 +119,7 @@ func TestMvp1(t *testing.T) {
 func TestMvp2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp2.NewCPU(false, memory)
+		return mvp2.NewCPU(false, memory, latency.M1)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -132,7 +133,7 @@ func TestMvp2(t *testing.T) {
 func TestMvp3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp3.NewCPU(false, memory)
+		return mvp3.NewCPU(false, memory, latency.M1)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -146,7 +147,7 @@ func TestMvp3(t *testing.T) {
 func TestMvp4(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp4.NewCPU(false, memory)
+		return mvp4.NewCPU(false, memory, latency.M1)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -160,7 +161,7 @@ func TestMvp4(t *testing.T) {
 func TestMvp5(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp5.NewCPU(false, memory)
+		return mvp5.NewCPU(false, memory, latency.M1)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -174,7 +175,7 @@ func TestMvp5(t *testing.T) {
 func TestMvp6_0_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_0.NewCPU(false, memory, 2, 2)
+		return mvp6_0.NewCPU(false, memory, latency.M1, 2, 2)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -188,7 +189,7 @@ func TestMvp6_0_2x2(t *testing.T) {
 func TestMvp6_0_3x3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_0.NewCPU(false, memory, 3, 3)
+		return mvp6_0.NewCPU(false, memory, latency.M1, 3, 3)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -202,7 +203,7 @@ func TestMvp6_0_3x3(t *testing.T) {
 func TestMvp6_1_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_1.NewCPU(false, memory, 2, 2)
+		return mvp6_1.NewCPU(false, memory, latency.M1, 2, 2)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -216,7 +217,7 @@ func TestMvp6_1_2x2(t *testing.T) {
 func TestMvp6_1_3x3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_1.NewCPU(false, memory, 3, 3)
+		return mvp6_1.NewCPU(false, memory, latency.M1, 3, 3)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -224,15 +225,14 @@ func TestMvp6_1_3x3(t *testing.T) {
 	//testStringLength(t, factory, 1024, testTo, false)
 	testStringCopy(t, factory, testTo*2, testTo, false)
 	testBubbleSort(t, testBubSort, factory, false)
-	// Not passing, fixed from MVP 6.2
-	//testConditionalBranch(t, factory, false)
+	testConditionalBranch(t, factory, false)
 	testSpectre(t, factory, false)
 }
 
 func TestMvp6_2_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_2.NewCPU(false, memory, 2, 2)
+		return mvp6_2.NewCPU(false, memory, latency.M1, 2, 2)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -246,7 +246,7 @@ func TestMvp6_2_2x2(t *testing.T) {
 func TestMvp6_2_3x3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_2.NewCPU(false, memory, 3, 3)
+		return mvp6_2.NewCPU(false, memory, latency.M1, 3, 3)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -260,7 +260,7 @@ func TestMvp6_2_3x3(t *testing.T) {
 func TestMvp6_3_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_3.NewCPU(false, memory, 2, 2)
+		return mvp6_3.NewCPU(false, memory, latency.M1, 2, 2)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -274,7 +274,7 @@ func TestMvp6_3_2x2(t *testing.T) {
 func TestMvp6_3_3x3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp6_3.NewCPU(false, memory, 3, 3)
+		return mvp6_3.NewCPU(false, memory, latency.M1, 3, 3)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -289,7 +289,7 @@ func TestMvp6_3_3x3(t *testing.T) {
 func TestMvp7_0_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp7_0.NewCPU(false, memory, 2)
+		return mvp7_0.NewCPU(false, memory, latency.M1, 2)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -303,7 +303,7 @@ func TestMvp7_0_2x2(t *testing.T) {
 func TestMvp7_0_3x3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp7_0.NewCPU(false, memory, 3)
+		return mvp7_0.NewCPU(false, memory, latency.M1, 3)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -317,7 +317,7 @@ func TestMvp7_0_3x3(t *testing.T) {
 func TestMvp7_1_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp7_1.NewCPU(false, memory, 2)
+		return mvp7_1.NewCPU(false, memory, latency.M1, 2)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -331,7 +331,7 @@ func TestMvp7_1_2x2(t *testing.T) {
 func TestMvp7_1_3x3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp7_1.NewCPU(false, memory, 3)
+		return mvp7_1.NewCPU(false, memory, latency.M1, 3)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -345,7 +345,7 @@ func TestMvp7_1_3x3(t *testing.T) {
 func TestMvp8_0_2x2(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp8_0.NewCPU(false, memory, 2)
+		return mvp8_0.NewCPU(false, memory, latency.M1, 2)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -359,7 +359,7 @@ func TestMvp8_0_2x2(t *testing.T) {
 func TestMvp8_0_3x3(t *testing.T) {
 	t.Parallel()
 	factory := func(memory int) virtualMachine {
-		return mvp8_0.NewCPU(false, memory, 3)
+		return mvp8_0.NewCPU(false, memory, latency.M1, 3)
 	}
 	testPrime(t, factory, memory, testFrom, testTo, false)
 	testSums(t, factory, memory, testFrom, testTo, false)
@@ -591,35 +591,35 @@ func printStats(t *testing.T, stats bool, cycle int, vm virtualMachine) {
 
 //func TestMvp1Jal(t *testing.T) {
 //	factory := func() virtualMachine {
-//		return mvp1.NewCPU(false, memory)
+//		return mvp1.NewCPU(false, memory, latency.M1)
 //	}
 //	testJal(t, factory)
 //}
 //
 //func TestMvp2Jal(t *testing.T) {
 //	factory := func() virtualMachine {
-//		return mvp2.NewCPU(false, memory)
+//		return mvp2.NewCPU(false, memory, latency.M1)
 //	}
 //	testJal(t, factory)
 //}
 //
 //func TestMvp3Jal(t *testing.T) {
 //	factory := func() virtualMachine {
-//		return mvp3.NewCPU(false, memory)
+//		return mvp3.NewCPU(false, memory, latency.M1)
 //	}
 //	testJal(t, factory)
 //}
 //
 //func TestMvp4Jal(t *testing.T) {
 //	factory := func() virtualMachine {
-//		return mvp4.NewCPU(false, memory)
+//		return mvp4.NewCPU(false, memory, latency.M1)
 //	}
 //	testJal(t, factory)
 //}
 //
 //func TestMvp5Jal(t *testing.T) {
 //	factory := func() virtualMachine {
-//		return mvp5.NewCPU(false, memory)
+//		return mvp5.NewCPU(false, memory, latency.M1)
 //	}
 //	testJal(t, factory)
 //}
@@ -668,13 +668,13 @@ func TestBenchmarks(t *testing.T) {
 
 	expected := map[string][]int{
 		"Prime": {
-			versionMVP1:   77969626,
-			versionMVP2:   1353346,
-			versionMVP3:   1353352,
-			versionMVP4:   451973,
-			versionMVP5:   401900,
-			versionMVP6_0: 401860,
-			versionMVP6_1: 351784,
+			versionMVP1:   77969577,
+			versionMVP2:   1353297,
+			versionMVP3:   1353303,
+			versionMVP4:   451924,
+			versionMVP5:   401851,
+			versionMVP6_0: 401859,
+			versionMVP6_1: 401858,
 			versionMVP6_2: 351784,
 			versionMVP6_3: 301710,
 			versionMVP7_0: 301714,
@@ -682,11 +682,11 @@ func TestBenchmarks(t *testing.T) {
 			versionMVP8:   301864,
 		},
 		"Sum": {
-			versionMVP1:   10409494,
-			versionMVP2:   1634638,
-			versionMVP3:   465310,
-			versionMVP4:   345743,
-			versionMVP5:   341648,
+			versionMVP1:   10208790,
+			versionMVP2:   1433934,
+			versionMVP3:   264606,
+			versionMVP4:   149137,
+			versionMVP5:   145042,
 			versionMVP6_0: 333721,
 			versionMVP6_1: 321432,
 			versionMVP6_2: 321432,
@@ -696,85 +696,85 @@ func TestBenchmarks(t *testing.T) {
 			versionMVP8:   126282,
 		},
 		"String copy": {
-			versionMVP1:   32349405,
-			versionMVP2:   7280967,
-			versionMVP3:   4201911,
-			versionMVP4:   3883985,
-			versionMVP5:   3853268,
-			versionMVP6_0: 3855996,
-			versionMVP6_1: 3834899,
-			versionMVP6_2: 3834899,
-			versionMVP6_3: 1956067,
-			versionMVP7_0: 303003,
-			versionMVP7_1: 303003,
-			versionMVP8:   254648,
+			versionMVP1:   31847645,
+			versionMVP2:   6779207,
+			versionMVP3:   3700151,
+			versionMVP4:   3392467,
+			versionMVP5:   3361750,
+			versionMVP6_0: 3855995,
+			versionMVP6_1: 3834903,
+			versionMVP6_2: 3834901,
+			versionMVP6_3: 1956069,
+			versionMVP7_0: 303005,
+			versionMVP7_1: 303005,
+			versionMVP8:   254650,
 		},
 		"String length": {
-			versionMVP1:   19622376,
-			versionMVP2:   3953646,
-			versionMVP3:   874593,
-			versionMVP4:   679223,
-			versionMVP5:   668984,
+			versionMVP1:   19120567,
+			versionMVP2:   3451837,
+			versionMVP3:   372784,
+			versionMVP4:   187966,
+			versionMVP5:   177727,
 			versionMVP6_0: 671976,
-			versionMVP6_1: 641250,
-			versionMVP6_2: 641250,
-			versionMVP6_3: 641250,
-			versionMVP7_0: 163635,
-			versionMVP7_1: 163635,
-			versionMVP8:   160378,
+			versionMVP6_1: 671664,
+			versionMVP6_2: 640941,
+			versionMVP6_3: 640941,
+			versionMVP7_0: 163637,
+			versionMVP7_1: 163637,
+			versionMVP8:   160380,
 		},
 		"Bubble sort": {
-			versionMVP1:   158852511,
-			versionMVP2:   42909111,
-			versionMVP3:   6380745,
-			versionMVP4:   4786503,
-			versionMVP5:   4746704,
-			versionMVP6_0: 2737247,
-			versionMVP6_1: 2677345,
-			versionMVP6_2: 2677345,
-			versionMVP6_3: 2677345,
-			versionMVP7_0: 24232735,
-			versionMVP7_1: 1229965,
-			versionMVP8:   943952,
+			versionMVP1:   154952111,
+			versionMVP2:   39008711,
+			versionMVP3:   2480345,
+			versionMVP4:   925905,
+			versionMVP5:   886106,
+			versionMVP6_0: 2717148,
+			versionMVP6_1: 2677346,
+			versionMVP6_2: 2677346,
+			versionMVP6_3: 2677346,
+			versionMVP7_0: 24516844,
+			versionMVP7_1: 1233343,
+			versionMVP8:   944137,
 		},
 	}
 
 	vms := []func(m int) virtualMachine{
 		versionMVP1: func(m int) virtualMachine {
-			return mvp1.NewCPU(false, m)
+			return mvp1.NewCPU(false, m, latency.M1)
 		},
 		versionMVP2: func(m int) virtualMachine {
-			return mvp2.NewCPU(false, m)
+			return mvp2.NewCPU(false, m, latency.M1)
 		},
 		versionMVP3: func(m int) virtualMachine {
-			return mvp3.NewCPU(false, m)
+			return mvp3.NewCPU(false, m, latency.M1)
 		},
 		versionMVP4: func(m int) virtualMachine {
-			return mvp4.NewCPU(false, m)
+			return mvp4.NewCPU(false, m, latency.M1)
 		},
 		versionMVP5: func(m int) virtualMachine {
-			return mvp5.NewCPU(false, m)
+			return mvp5.NewCPU(false, m, latency.M1)
 		},
 		versionMVP6_0: func(m int) virtualMachine {
-			return mvp6_0.NewCPU(false, m, 2, 2)
+			return mvp6_0.NewCPU(false, m, latency.M1, 2, 2)
 		},
 		versionMVP6_1: func(m int) virtualMachine {
-			return mvp6_1.NewCPU(false, m, 2, 2)
+			return mvp6_1.NewCPU(false, m, latency.M1, 2, 2)
 		},
 		versionMVP6_2: func(m int) virtualMachine {
-			return mvp6_2.NewCPU(false, m, 2, 2)
+			return mvp6_2.NewCPU(false, m, latency.M1, 2, 2)
 		},
 		versionMVP6_3: func(m int) virtualMachine {
-			return mvp6_3.NewCPU(false, m, 2, 2)
+			return mvp6_3.NewCPU(false, m, latency.M1, 2, 2)
 		},
 		versionMVP7_0: func(m int) virtualMachine {
-			return mvp7_0.NewCPU(false, m, 2)
+			return mvp7_0.NewCPU(false, m, latency.M1, 2)
 		},
 		versionMVP7_1: func(m int) virtualMachine {
-			return mvp7_1.NewCPU(false, m, 2)
+			return mvp7_1.NewCPU(false, m, latency.M1, 2)
 		},
 		versionMVP8: func(m int) virtualMachine {
-			return mvp8_0.NewCPU(false, m, 3)
+			return mvp8_0.NewCPU(false, m, latency.M1, 3)
 		},
 	}
 
@@ -0,0 +1,134 @@
+package ref
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/teivah/majorana/risc"
+)
+
+// VirtualMachine is a processor checked against the reference model.
+type VirtualMachine interface {
+	Run(app risc.Application) (int, error)
+	Context() *risc.Context
+}
+
+// StateKind is the kind of architectural state differing.
+type StateKind int
+
+const (
+	RegisterState StateKind = iota
+	FRegisterState
+	MemoryState
+	ExitState
+)
+
+// Mismatch is the first architectural state differing between a virtual
+// machine and the reference model.
+type Mismatch struct {
+	Kind StateKind
+	// Register is set for a RegisterState or FRegisterState, Address for a
+	// MemoryState.
+	Register risc.RegisterType
+	Address  int32
+	// Want is the value of the reference model, Got the one of the virtual
+	// machine. For an ExitState, they hold the exit code, if any, and WantErr
+	// and GotErr the errors returned by the runs.
+	Want    int64
+	Got     int64
+	WantErr error
+	GotErr  error
+}
+
+func (m *Mismatch) Error() string {
+	switch m.Kind {
+	case RegisterState:
+		return fmt.Sprintf("register %s: got %d, want %d", registerName(m.Register), m.Got, m.Want)
+	case FRegisterState:
+		return fmt.Sprintf("register %s: got %#x, want %#x", registerName(m.Register), uint64(m.Got), uint64(m.Want))
+	case MemoryState:
+		return fmt.Sprintf("memory byte %#x: got %d, want %d", m.Address, m.Got, m.Want)
+	default:
+		return fmt.Sprintf("exit: got %v, want %v", describeExit(m.Got, m.GotErr), describeExit(m.Want, m.WantErr))
+	}
+}
+
+func registerName(reg risc.RegisterType) string {
+	return strings.ToLower(reg.String())
+}
+
+func describeExit(code int64, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("exit status %d", code)
+}
+
+// Check runs an application on a virtual machine and on the reference model,
+// and returns the first architectural state differing as a *Mismatch, or nil if
+// there is none. The reference model starts from the initial registers and
+// memory of the virtual machine.
+//
+// The cycle and time counters depend on the timing of a processor: an
+// application reading them, or relying on timer interrupts, can't be checked.
+func Check(vm VirtualMachine, app risc.Application) error {
+	ctx := vm.Context()
+	ref := newReference(ctx)
+	_, wantErr := ref.Run(app)
+	_, gotErr := vm.Run(app)
+	return Compare(ref.ctx, wantErr, ctx, gotErr)
+}
+
+// newReference returns a reference model starting from the initial registers
+// and memory of a virtual machine.
+func newReference(ctx *risc.Context) *Machine {
+	ref := New(len(ctx.Memory))
+	ref.ctx.Registers = maps.Clone(ctx.Registers)
+	ref.ctx.FRegisters = maps.Clone(ctx.FRegisters)
+	copy(ref.ctx.Memory, ctx.Memory)
+	return ref
+}
+
+// Compare returns the first architectural state differing between the context
+// of the reference model and the one of a virtual machine, as a *Mismatch, or
+// nil if there is none. wantErr and gotErr are the errors returned by the
+// runs.
+func Compare(want *risc.Context, wantErr error, got *risc.Context, gotErr error) error {
+	if want.Exited != got.Exited || want.ExitCode != got.ExitCode || errorString(wantErr) != errorString(gotErr) {
+		return &Mismatch{
+			Kind:    ExitState,
+			Want:    int64(want.ExitCode),
+			Got:     int64(got.ExitCode),
+			WantErr: wantErr,
+			GotErr:  gotErr,
+		}
+	}
+	// x0 is hardwired to zero, whatever the map holds
+	for reg := risc.Ra; reg <= risc.T6; reg++ {
+		if w, g := want.Registers[reg], got.Registers[reg]; w != g {
+			return &Mismatch{Kind: RegisterState, Register: reg, Want: int64(w), Got: int64(g)}
+		}
+	}
+	for reg := risc.Ft0; reg <= risc.Ft11; reg++ {
+		if w, g := want.FRegisters[reg], got.FRegisters[reg]; w != g {
+			return &Mismatch{Kind: FRegisterState, Register: reg, Want: int64(w), Got: int64(g)}
+		}
+	}
+	if len(want.Memory) != len(got.Memory) {
+		return fmt.Errorf("memory of %d bytes, want %d", len(got.Memory), len(want.Memory))
+	}
+	for i := range want.Memory {
+		if w, g := want.Memory[i], got.Memory[i]; w != g {
+			return &Mismatch{Kind: MemoryState, Address: int32(i), Want: int64(w), Got: int64(g)}
+		}
+	}
+	return nil
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
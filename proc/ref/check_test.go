@@ -0,0 +1,73 @@
+package ref
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teivah/majorana/risc"
+)
+
+// faulty is a virtual machine corrupting its state once the application
+// completes.
+type faulty struct {
+	*Machine
+	corrupt func(ctx *risc.Context)
+}
+
+func (f faulty) Run(app risc.Application) (int, error) {
+	cycles, err := f.Machine.Run(app)
+	f.corrupt(f.ctx)
+	return cycles, err
+}
+
+func TestCheck(t *testing.T) {
+	app, err := risc.Parse(`li a0, 3
+li t0, 5
+sw t0, 4(zero)
+li a7, 93
+ecall`)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		corrupt func(ctx *risc.Context)
+		want    *Mismatch
+	}{
+		{"Same", func(*risc.Context) {}, nil},
+		{"Register", func(ctx *risc.Context) {
+			ctx.Registers[risc.T0] = 6
+		}, &Mismatch{Kind: RegisterState, Register: risc.T0, Want: 5, Got: 6}},
+		{"Zero register", func(ctx *risc.Context) {
+			ctx.Registers[risc.Zero] = 1
+		}, nil},
+		{"Floating-point register", func(ctx *risc.Context) {
+			ctx.FRegisters[risc.Fa0] = 1
+		}, &Mismatch{Kind: FRegisterState, Register: risc.Fa0, Want: 0, Got: 1}},
+		{"Memory", func(ctx *risc.Context) {
+			ctx.Memory[6] = 1
+		}, &Mismatch{Kind: MemoryState, Address: 6, Want: 0, Got: 1}},
+		{"Exit", func(ctx *risc.Context) {
+			ctx.ExitCode = 4
+		}, &Mismatch{Kind: ExitState, Want: 3, Got: 4, WantErr: &risc.ExitError{Code: 3}, GotErr: &risc.ExitError{Code: 3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Check(faulty{Machine: New(16), corrupt: tt.corrupt}, app)
+			if tt.want == nil {
+				require.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.want, err)
+		})
+	}
+}
+
+func TestMismatchError(t *testing.T) {
+	assert.Equal(t, "register t0: got 6, want 5",
+		(&Mismatch{Kind: RegisterState, Register: risc.T0, Want: 5, Got: 6}).Error())
+	assert.Equal(t, "memory byte 0x6: got 1, want 0",
+		(&Mismatch{Kind: MemoryState, Address: 6, Want: 0, Got: 1}).Error())
+	assert.Equal(t, "exit: got exit status 0, want exit status 3",
+		(&Mismatch{Kind: ExitState, Want: 3, WantErr: &risc.ExitError{Code: 3}}).Error())
+}
@@ -0,0 +1,140 @@
+package ref
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/teivah/majorana/risc"
+)
+
+// historyLength is the number of retirements preceding a divergence kept in
+// its history.
+const historyLength = 16
+
+// Pipelined is a virtual machine describing the instructions in flight.
+type Pipelined interface {
+	Pipeline() string
+}
+
+// Divergence is the first instruction retired differently by a virtual
+// machine and the reference model.
+type Divergence struct {
+	// Index is the position of the instruction in the retirement stream.
+	Index int
+	// Want is the retirement of the reference model, Got the one of the virtual
+	// machine; either is nil if the corresponding stream ended.
+	Want *risc.Retirement
+	Got  *risc.Retirement
+	// History holds the last retirements preceding the divergence, the oldest
+	// first.
+	History []risc.Retirement
+	// Pipeline describes the instructions in flight in the virtual machine once
+	// the divergence is detected, if the virtual machine is Pipelined.
+	Pipeline string
+}
+
+func (d *Divergence) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "retirement %d: got %s, want %s", d.Index, describeRetirement(d.Got), describeRetirement(d.Want))
+	if len(d.History) != 0 {
+		sb.WriteString("\nhistory:")
+		for _, r := range d.History {
+			fmt.Fprintf(&sb, "\n\t%s", r)
+		}
+	}
+	if d.Pipeline != "" {
+		sb.WriteString("\npipeline:")
+		for _, line := range strings.Split(strings.TrimSuffix(d.Pipeline, "\n"), "\n") {
+			fmt.Fprintf(&sb, "\n\t%s", line)
+		}
+	}
+	return sb.String()
+}
+
+func describeRetirement(r *risc.Retirement) string {
+	if r == nil {
+		return "end of stream"
+	}
+	return r.String()
+}
+
+// Lockstep runs an application on a virtual machine, comparing each
+// instruction it retires with the one retired by the reference model. The
+// virtual machine is stopped at the first instruction differing, returned as a
+// *Divergence. Otherwise, Lockstep returns the first architectural state
+// differing once the application completed, as Check does, or nil.
+//
+// As with Check, an application reading the cycle and time counters, or
+// relying on timer interrupts, can't be checked.
+func Lockstep(vm VirtualMachine, app risc.Application) error {
+	ctx := vm.Context()
+	ref := newReference(ctx)
+	// The instructions hold the values forwarded by the virtual machine
+	refApp := copyInstructions(app)
+	if err := ref.load(refApp); err != nil {
+		return err
+	}
+
+	var (
+		index      int
+		history    []risc.Retirement
+		divergence *Divergence
+	)
+	diverge := func(want, got *risc.Retirement) *Divergence {
+		divergence = &Divergence{
+			Index:   index,
+			Want:    want,
+			Got:     got,
+			History: history,
+		}
+		if p, ok := vm.(Pipelined); ok {
+			divergence.Pipeline = p.Pipeline()
+		}
+		return divergence
+	}
+
+	ctx.OnRetire = func(got risc.Retirement) error {
+		want, done, _ := ref.step(refApp)
+		if done {
+			return diverge(nil, &got)
+		}
+		if !want.Equal(got) {
+			return diverge(&want, &got)
+		}
+		history = append(history, got)
+		if len(history) > historyLength {
+			history = history[1:]
+		}
+		index++
+		return nil
+	}
+	_, gotErr := vm.Run(app)
+	ctx.OnRetire = nil
+	if divergence != nil {
+		return divergence
+	}
+
+	want, done, wantErr := ref.step(refApp)
+	if !done {
+		return diverge(&want, nil)
+	}
+	return Compare(ref.ctx, wantErr, ctx, gotErr)
+}
+
+// copyInstructions returns an application whose instructions are copies of the
+// ones of app.
+func copyInstructions(app risc.Application) risc.Application {
+	instructions := make([]risc.InstructionRunner, len(app.Instructions))
+	for i, runner := range app.Instructions {
+		if runner == nil {
+			continue
+		}
+		v := reflect.ValueOf(runner)
+		c := reflect.New(v.Elem().Type())
+		c.Elem().Set(v.Elem())
+		instructions[i] = c.Interface().(risc.InstructionRunner)
+	}
+	app.Instructions = instructions
+	return app
+}
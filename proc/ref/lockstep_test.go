@@ -0,0 +1,98 @@
+package ref
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/teivah/majorana/risc"
+)
+
+// diverging is a virtual machine corrupting the effects of the nth instruction
+// it retires, or not reporting the instructions from the nth if corrupt is nil.
+type diverging struct {
+	*Machine
+	n       int
+	corrupt func(r *risc.Retirement)
+}
+
+func (d diverging) Run(app risc.Application) (int, error) {
+	onRetire := d.ctx.OnRetire
+	retired := 0
+	d.ctx.OnRetire = func(r risc.Retirement) error {
+		defer func() { retired++ }()
+		switch {
+		case d.corrupt == nil && retired >= d.n:
+			return nil
+		case retired == d.n:
+			d.corrupt(&r)
+		}
+		return onRetire(r)
+	}
+	return d.Machine.Run(app)
+}
+
+func (d diverging) Pipeline() string {
+	return "execute unit: ecall\n"
+}
+
+func TestLockstep(t *testing.T) {
+	app, err := risc.Parse(`li a0, 3
+li t0, 5
+sw t0, 4(zero)
+li a7, 93
+ecall`)
+	require.NoError(t, err)
+
+	t.Run("Same", func(t *testing.T) {
+		require.NoError(t, Lockstep(New(16), app))
+	})
+
+	t.Run("Register", func(t *testing.T) {
+		err := Lockstep(diverging{Machine: New(16), n: 1, corrupt: func(r *risc.Retirement) {
+			r.Value = 6
+		}}, app)
+		var d *Divergence
+		require.ErrorAs(t, err, &d)
+		assert.Equal(t, 1, d.Index)
+		assert.Equal(t, "0x4: li t0, 5 [t0=5]", d.Want.String())
+		assert.Equal(t, "0x4: li t0, 5 [t0=6]", d.Got.String())
+		require.Len(t, d.History, 1)
+		assert.Equal(t, int32(0), d.History[0].Pc)
+		assert.Equal(t, "execute unit: ecall\n", d.Pipeline)
+	})
+
+	t.Run("Memory", func(t *testing.T) {
+		err := Lockstep(diverging{Machine: New(16), n: 2, corrupt: func(r *risc.Retirement) {
+			r.MemoryChanges = map[int32]int8{4: 5}
+		}}, app)
+		var d *Divergence
+		require.ErrorAs(t, err, &d)
+		assert.Equal(t, 2, d.Index)
+		assert.Len(t, d.History, 2)
+	})
+
+	t.Run("Missing retirement", func(t *testing.T) {
+		err := Lockstep(diverging{Machine: New(16), n: 4}, app)
+		var d *Divergence
+		require.ErrorAs(t, err, &d)
+		assert.Equal(t, 4, d.Index)
+		assert.Equal(t, "0x10: ecall", d.Want.String())
+		assert.Nil(t, d.Got)
+	})
+}
+
+func TestDivergenceError(t *testing.T) {
+	want := risc.Retirement{Pc: 4, Instruction: "li t0, 5", RegisterChange: true, Register: risc.T0, Value: 5}
+	d := &Divergence{
+		Index:    1,
+		Want:     &want,
+		History:  []risc.Retirement{{Pc: 0, Instruction: "li a0, 3", RegisterChange: true, Register: risc.A0, Value: 3}},
+		Pipeline: "write bus: []\n",
+	}
+	assert.Equal(t, `retirement 1: got end of stream, want 0x4: li t0, 5 [t0=5]
+history:
+	0x0: li a0, 3 [a0=3]
+pipeline:
+	write bus: []`, d.Error())
+}
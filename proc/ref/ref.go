@@ -0,0 +1,104 @@
+// Package ref is a functional reference model of the ISA. It executes an
+// application one instruction after the other, regardless of any timing, and
+// defines the architectural result (registers, memory and exit status) any
+// virtual machine has to reach.
+package ref
+
+import (
+	"github.com/teivah/majorana/risc"
+)
+
+// Machine is the reference model. It retires an instruction per cycle, so
+// that the cycle, time and instret counters are all equal.
+type Machine struct {
+	ctx *risc.Context
+	pc  int32
+}
+
+// New returns a reference model with a given amount of memory.
+func New(memoryBytes int) *Machine {
+	return &Machine{
+		ctx: risc.NewContext(false, memoryBytes, false),
+	}
+}
+
+func (m *Machine) Context() *risc.Context {
+	return m.ctx
+}
+
+// Run executes an application until it exits, returns from its entry point
+// or jumps past its end, and returns the number of instructions retired.
+func (m *Machine) Run(app risc.Application) (int, error) {
+	if err := m.load(app); err != nil {
+		return 0, err
+	}
+	for {
+		if _, done, err := m.step(app); done {
+			return int(m.ctx.Instret), err
+		}
+	}
+}
+
+func (m *Machine) load(app risc.Application) error {
+	if err := m.ctx.Load(app); err != nil {
+		return err
+	}
+	m.pc = app.Entry
+	return nil
+}
+
+// step executes the application until the next instruction retires, and
+// returns its retirement. Once the application completed, done is set along
+// with the error of the run, if any.
+func (m *Machine) step(app risc.Application) (r risc.Retirement, done bool, err error) {
+	for m.pc < app.End() {
+		pc := m.pc
+		m.ctx.Cycles = m.ctx.Instret
+		if e := m.ctx.Interrupt(pc); e != nil {
+			if m.pc, err = m.ctx.Trap(e); err != nil {
+				return risc.Retirement{}, true, err
+			}
+			continue
+		}
+		runner := app.Fetch(pc)
+		addrs := runner.MemoryRead(m.ctx, 0)
+		memory := make([]int8, 0, len(addrs))
+		for _, addr := range addrs {
+			memory = append(memory, m.ctx.Memory[addr])
+		}
+		exe, err := runner.Run(m.ctx, app.Labels, pc, memory, 0)
+		if err != nil {
+			return risc.Retirement{}, true, err
+		}
+		if exe.Exception != nil {
+			if m.pc, err = m.ctx.Trap(exe.Exception); err != nil {
+				return risc.Retirement{}, true, err
+			}
+			continue
+		}
+		m.ctx.Instret++
+		if exe.RegisterChange {
+			m.ctx.WriteRegister(exe)
+		}
+		if exe.MemoryChange {
+			m.ctx.WriteMemory(exe)
+		}
+		if exe.PcChange {
+			m.pc = exe.NextPc
+		} else {
+			m.pc += app.Size(pc)
+		}
+		r = risc.NewRetirement(pc, runner, exe)
+		if m.ctx.OnRetire != nil {
+			if err := m.ctx.OnRetire(r); err != nil {
+				return r, true, err
+			}
+		}
+		return r, false, nil
+	}
+	return risc.Retirement{}, true, m.ctx.ExitError()
+}
+
+func (m *Machine) Stats() map[string]any {
+	return nil
+}
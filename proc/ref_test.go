@@ -0,0 +1,85 @@
+package proc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/teivah/majorana/common/bytes"
+	"github.com/teivah/majorana/proc/latency"
+	"github.com/teivah/majorana/proc/ref"
+	"github.com/teivah/majorana/risc"
+	"github.com/teivah/majorana/test"
+)
+
+func writeWords(ctx *risc.Context, words ...int32) {
+	for i, word := range words {
+		b := bytes.BytesFromLowBits(word)
+		copy(ctx.Memory[4*i:], []int8{b[0], b[1], b[2], b[3]})
+	}
+}
+
+func TestReference(t *testing.T) {
+	programs := []struct {
+		name   string
+		file   string
+		memory int
+		init   func(ctx *risc.Context)
+	}{
+		{"Prime", "../res/prime-number.asm", 64, func(ctx *risc.Context) {
+			writeWords(ctx, 97)
+		}},
+		{"Prime with extra memory", "../res/prime-number-2.asm", 64, func(ctx *risc.Context) {
+			writeWords(ctx, 91)
+		}},
+		{"Sums", "../res/array-sum.asm", 256, func(ctx *risc.Context) {
+			words := make([]int32, 0, 64)
+			for i := int32(0); i < 64; i++ {
+				words = append(words, i)
+			}
+			writeWords(ctx, words...)
+			ctx.Registers[risc.A1] = 64
+		}},
+		{"String length", "../res/string-length.asm", 256, func(ctx *risc.Context) {
+			for i := 0; i < 200; i++ {
+				ctx.Memory[i] = '1'
+			}
+		}},
+		{"String copy", "../res/string-copy.asm", 256, func(ctx *risc.Context) {
+			for i := 0; i < 128; i++ {
+				ctx.Memory[i] = '1'
+			}
+			ctx.Registers[risc.A0] = 128
+			ctx.Registers[risc.A2] = 128
+		}},
+		{"Bubble sort", "../res/bubble-sort.asm", 80, func(ctx *risc.Context) {
+			words := make([]int32, 0, 20)
+			for i := int32(0); i < 20; i++ {
+				words = append(words, 20-i)
+			}
+			writeWords(ctx, words...)
+			ctx.Registers[risc.A1] = 20
+		}},
+		{"Conditional branch", "../res/conditional-branch.asm", 40, func(*risc.Context) {}},
+		{"Spectre", "../res/spectre.asm", 40, func(ctx *risc.Context) {
+			writeWords(ctx, 3, 1, 2, 3, 0, 0, 0, 0, 0, 42)
+		}},
+	}
+
+	for _, vm := range allVMs {
+		for _, program := range programs {
+			t.Run(fmt.Sprintf("%s - %s", vm.name, program.name), func(t *testing.T) {
+				t.Parallel()
+				instructions := test.ReadFile(t, program.file)
+				if program.file == "../res/array-sum.asm" {
+					instructions = fmt.Sprintf(instructions, "")
+				}
+				app, err := risc.Parse(instructions)
+				require.NoError(t, err)
+				v := vm.factory(program.memory, latency.M1)
+				program.init(v.Context())
+				require.NoError(t, ref.Lockstep(v, app))
+			})
+		}
+	}
+}
@@ -0,0 +1,47 @@
+# csrrc: atomic read and clear of the bits of a CSR
+.include "../test.inc"
+
+    li gp, 2
+    li a1, 0xff0f
+    csrw mscratch, a1
+    li a2, 0xf00f
+    csrrc a4, mscratch, a2
+    TEST_CHECK a4, 0xff0f
+    csrr a4, mscratch
+    TEST_CHECK a4, 0x0f00
+
+    # Read only, with x0 as the source
+    li gp, 3
+    csrrc a4, mscratch, zero
+    TEST_CHECK a4, 0x0f00
+
+    # Clearing every bit
+    li gp, 4
+    li a1, -1
+    csrw mscratch, a1
+    csrrc a4, mscratch, a1
+    TEST_CHECK a4, 0xffffffff
+    csrr a4, mscratch
+    TEST_CHECK a4, 0
+
+    # Write only, to x0
+    li gp, 5
+    li a1, 0x80000001
+    csrw mscratch, a1
+    li a2, 1
+    csrrc zero, mscratch, a2
+    bnez zero, fail
+    csrr a4, mscratch
+    TEST_CHECK a4, 0x80000000
+
+    # Clearing the accrued exception flags
+    li gp, 6
+    csrwi fflags, 0x1f
+    li a1, 0x11
+    csrrc a4, fflags, a1
+    TEST_CHECK a4, 0x1f
+    csrr a4, fflags
+    TEST_CHECK a4, 0x0e
+    csrw fcsr, zero
+
+    TEST_PASSFAIL
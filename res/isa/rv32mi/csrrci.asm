@@ -0,0 +1,34 @@
+# csrrci: atomic read and clear of the bits of a CSR with a 5-bit immediate
+.include "../test.inc"
+
+    li gp, 2
+    li a1, 0x11f
+    csrw mscratch, a1
+    csrrci a4, mscratch, 0x11
+    TEST_CHECK a4, 0x11f
+    csrr a4, mscratch
+    TEST_CHECK a4, 0x10e
+
+    # Read only, with a zero immediate
+    li gp, 3
+    csrrci a4, mscratch, 0
+    TEST_CHECK a4, 0x10e
+
+    # Write only, to x0
+    li gp, 4
+    csrrci zero, mscratch, 31
+    bnez zero, fail
+    csrr a4, mscratch
+    TEST_CHECK a4, 0x100
+
+    # Disabling the interrupts
+    li gp, 5
+    csrwi mstatus, 8
+    csrrci a4, mstatus, 8
+    andi a4, a4, 8
+    TEST_CHECK a4, 8
+    csrr a4, mstatus
+    andi a4, a4, 8
+    TEST_CHECK a4, 0
+
+    TEST_PASSFAIL
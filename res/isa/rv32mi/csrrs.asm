@@ -0,0 +1,49 @@
+# csrrs: atomic read and set of the bits of a CSR
+.include "../test.inc"
+
+    li gp, 2
+    li a1, 0x0f0f
+    csrw mscratch, a1
+    li a2, 0xf000
+    csrrs a4, mscratch, a2
+    TEST_CHECK a4, 0x0f0f
+    csrr a4, mscratch
+    TEST_CHECK a4, 0xff0f
+
+    # Read only, with x0 as the source
+    li gp, 3
+    csrrs a4, mscratch, zero
+    TEST_CHECK a4, 0xff0f
+
+    # Read-only CSRs can be read
+    li gp, 4
+    csrrs a4, mhartid, zero
+    TEST_CHECK a4, 0
+    csrrs a4, misa, zero
+    srli a4, a4, 30
+    TEST_CHECK a4, 1
+    csrr a4, misa
+    andi a4, a4, 0x100
+    TEST_CHECK a4, 0x100
+
+    # Setting the sign bit
+    li gp, 5
+    li a1, 0x80000000
+    csrrs zero, mscratch, a1
+    bnez zero, fail
+    csrr a4, mscratch
+    TEST_CHECK a4, 0x8000ff0f
+
+    # fflags through fcsr
+    li gp, 6
+    csrw fcsr, zero
+    li a1, 0x21
+    csrrs a4, fcsr, a1
+    TEST_CHECK a4, 0
+    csrr a4, fflags
+    TEST_CHECK a4, 1
+    csrr a4, frm
+    TEST_CHECK a4, 1
+    csrw fcsr, zero
+
+    TEST_PASSFAIL
@@ -0,0 +1,37 @@
+# csrrsi: atomic read and set of the bits of a CSR with a 5-bit immediate
+.include "../test.inc"
+
+    li gp, 2
+    li a1, 0x100
+    csrw mscratch, a1
+    csrrsi a4, mscratch, 0x11
+    TEST_CHECK a4, 0x100
+    csrr a4, mscratch
+    TEST_CHECK a4, 0x111
+
+    # Read only, with a zero immediate
+    li gp, 3
+    csrrsi a4, mscratch, 0
+    TEST_CHECK a4, 0x111
+    csrrsi a4, mhartid, 0
+    TEST_CHECK a4, 0
+
+    # Write only, to x0
+    li gp, 4
+    csrrsi zero, mscratch, 31
+    bnez zero, fail
+    csrr a4, mscratch
+    TEST_CHECK a4, 0x11f
+
+    # Accrued exception flags
+    li gp, 5
+    csrw fcsr, zero
+    csrrsi a4, fflags, 0x10
+    TEST_CHECK a4, 0
+    csrrsi a4, fflags, 0x01
+    TEST_CHECK a4, 0x10
+    csrr a4, fflags
+    TEST_CHECK a4, 0x11
+    csrw fcsr, zero
+
+    TEST_PASSFAIL
@@ -0,0 +1,58 @@
+# csrrw: atomic swap of a CSR and a register
+.include "../test.inc"
+
+    li gp, 2
+    csrw mscratch, zero
+    li a1, 0x12345678
+    csrrw a4, mscratch, a1
+    TEST_CHECK a4, 0
+
+    li gp, 3
+    li a1, 0x80000000
+    csrrw a4, mscratch, a1
+    TEST_CHECK a4, 0x12345678
+
+    # Source and destination in the same register
+    li gp, 4
+    li a4, -1
+    csrrw a4, mscratch, a4
+    TEST_CHECK a4, 0x80000000
+    csrr a4, mscratch
+    TEST_CHECK a4, 0xffffffff
+
+    # Write only, to x0
+    li gp, 5
+    li a1, 42
+    csrrw zero, mscratch, a1
+    bnez zero, fail
+    csrr a4, mscratch
+    TEST_CHECK a4, 42
+
+    # fcsr holds fflags and frm
+    li gp, 6
+    li a1, 0xffffffff
+    csrrw zero, fcsr, a1
+    csrr a4, fflags
+    TEST_CHECK a4, 0x1f
+    csrr a4, frm
+    TEST_CHECK a4, 7
+    li a1, 0x45
+    csrrw a4, fcsr, a1
+    TEST_CHECK a4, 0xff
+    csrr a4, frm
+    TEST_CHECK a4, 2
+    csrr a4, fflags
+    TEST_CHECK a4, 5
+    csrw fcsr, zero
+
+    # The value written is read right after
+    li gp, 7
+    li t0, 2
+loop:
+    csrrw zero, mscratch, t0
+    csrrw a4, mscratch, zero
+    bne a4, t0, fail
+    addi t0, t0, -1
+    bnez t0, loop
+
+    TEST_PASSFAIL
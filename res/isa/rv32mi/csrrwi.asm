@@ -0,0 +1,34 @@
+# csrrwi: atomic swap of a CSR and a 5-bit zero-extended immediate
+.include "../test.inc"
+
+    li gp, 2
+    csrw mscratch, zero
+    csrrwi a4, mscratch, 31
+    TEST_CHECK a4, 0
+    csrr a4, mscratch
+    TEST_CHECK a4, 31
+
+    li gp, 3
+    li a1, -1
+    csrw mscratch, a1
+    csrrwi a4, mscratch, 16
+    TEST_CHECK a4, 0xffffffff
+    csrr a4, mscratch
+    TEST_CHECK a4, 16
+
+    # Write only, to x0
+    li gp, 4
+    csrrwi zero, mscratch, 0
+    bnez zero, fail
+    csrr a4, mscratch
+    TEST_CHECK a4, 0
+
+    # Rounding mode
+    li gp, 5
+    csrrwi a4, frm, 4
+    TEST_CHECK a4, 0
+    csrr a4, fcsr
+    TEST_CHECK a4, 0x80
+    csrwi frm, 0
+
+    TEST_PASSFAIL
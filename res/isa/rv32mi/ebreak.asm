@@ -0,0 +1,53 @@
+# ebreak: breakpoint, trapping with the pc in mepc and mtval
+.include "../test.inc"
+
+    la t0, handler
+    csrw mtvec, t0
+    li s0, 0
+    li gp, 2
+ebreak1:
+    ebreak
+    TEST_CHECK s0, 1
+
+    li gp, 3
+    TEST_CHECK s2, 3
+
+    li gp, 4
+    la t2, ebreak1
+    bne s1, t2, fail
+    bne s3, t2, fail
+
+    # The registers are untouched, and execution resumes after the breakpoint
+    li gp, 5
+    li a1, 0x12345678
+    li a4, 0
+ebreak2:
+    ebreak
+    addi a4, a4, 1
+    TEST_CHECK a4, 1
+    TEST_CHECK a1, 0x12345678
+    TEST_CHECK s0, 2
+    la t2, ebreak2
+    bne s1, t2, fail
+
+    # In a loop
+    li gp, 6
+    li t1, 3
+loop:
+    ebreak
+    addi t1, t1, -1
+    bnez t1, loop
+    TEST_CHECK s0, 5
+
+    TEST_PASSFAIL
+
+# The trap handler counts the traps in s0 and records mepc, mcause and mtval in
+# s1, s2 and s3, before resuming after the instruction trapping.
+handler:
+    addi s0, s0, 1
+    csrr s1, mepc
+    csrr s2, mcause
+    csrr s3, mtval
+    addi t0, s1, 4
+    csrw mepc, t0
+    mret
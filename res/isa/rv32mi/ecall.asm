@@ -0,0 +1,46 @@
+# ecall: environment call, handled by the Linux system call interface
+.include "../test.inc"
+
+    # The break is queried with brk(0)
+    li gp, 2
+    li s1, 0x12345678
+    li a0, 0
+    li a7, 214
+    ecall
+    mv s0, a0
+    beqz s0, fail
+    TEST_CHECK s1, 0x12345678
+
+    # Then moved
+    li gp, 3
+    addi a0, s0, 64
+    li a7, 214
+    ecall
+    sub a4, a0, s0
+    TEST_CHECK a4, 64
+    mv s0, a0
+
+    # Unknown system call, returning -ENOSYS
+    li gp, 4
+    li a0, 0
+    li a7, 999
+    ecall
+    TEST_CHECK a0, 0xffffffda
+    TEST_CHECK a7, 999
+
+    # Querying doesn't move the break, in a loop
+    li gp, 5
+    li t0, 3
+loop:
+    li a0, 0
+    li a7, 214
+    ecall
+    bne a0, s0, fail
+    addi t0, t0, -1
+    bnez t0, loop
+
+    TEST_PASSFAIL
+
+.data
+tdat:
+    .word 0
@@ -0,0 +1,51 @@
+# illegal: illegal instruction, trapping with the pc in mepc
+.include "../test.inc"
+
+    la t0, handler
+    csrw mtvec, t0
+    li s0, 0
+    li gp, 2
+illegal1:
+    unimp
+    TEST_CHECK s0, 1
+
+    li gp, 3
+    TEST_CHECK s2, 2
+
+    li gp, 4
+    la t2, illegal1
+    bne s1, t2, fail
+
+    # An unknown CSR is an illegal instruction as well
+    li gp, 5
+illegal2:
+    csrr a4, 0x7ff
+    TEST_CHECK s0, 2
+    TEST_CHECK s2, 2
+    la t2, illegal2
+    bne s1, t2, fail
+
+    # Writing a read-only CSR
+    li gp, 6
+    li a1, 5
+illegal3:
+    csrw mhartid, a1
+    TEST_CHECK s0, 3
+    TEST_CHECK s2, 2
+    la t2, illegal3
+    bne s1, t2, fail
+    csrr a4, mhartid
+    TEST_CHECK a4, 0
+
+    TEST_PASSFAIL
+
+# The trap handler counts the traps in s0 and records mepc, mcause and mtval in
+# s1, s2 and s3, before resuming after the instruction trapping.
+handler:
+    addi s0, s0, 1
+    csrr s1, mepc
+    csrr s2, mcause
+    csrr s3, mtval
+    addi t0, s1, 4
+    csrw mepc, t0
+    mret
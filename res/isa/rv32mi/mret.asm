@@ -0,0 +1,37 @@
+# mret: return from a trap to mepc, restoring MIE from MPIE
+.include "../test.inc"
+
+    li gp, 2
+    la t0, label1
+    csrw mepc, t0
+    li t0, 0x80
+    csrw mstatus, t0
+    mret
+    j fail
+label1:
+    csrr a4, mstatus
+    TEST_CHECK a4, 0x1888
+
+    li gp, 3
+    la t0, label3
+    csrw mepc, t0
+    csrw mstatus, zero
+    mret
+    j fail
+label3:
+    csrr a4, mstatus
+    TEST_CHECK a4, 0x1880
+
+    # Return address computed right before
+    li gp, 4
+    li a4, 0
+    la t0, label4
+    addi t0, t0, -4
+    addi t0, t0, 4
+    csrw mepc, t0
+    mret
+    addi a4, a4, 1
+label4:
+    TEST_CHECK a4, 0
+
+    TEST_PASSFAIL
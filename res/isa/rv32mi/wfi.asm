@@ -0,0 +1,26 @@
+# wfi: wait for an interrupt, resuming right away with none enabled
+.include "../test.inc"
+
+    li gp, 2
+    li a1, 0x12345678
+    wfi
+    TEST_CHECK a1, 0x12345678
+
+    li gp, 3
+    li t0, 3
+    li a4, 0
+loop:
+    wfi
+    addi a4, a4, 1
+    addi t0, t0, -1
+    bnez t0, loop
+    TEST_CHECK a4, 3
+
+    # The interrupts are disabled
+    li gp, 4
+    csrw mstatus, zero
+    wfi
+    csrr a4, mstatus
+    TEST_CHECK a4, 0x1800
+
+    TEST_PASSFAIL
@@ -0,0 +1,34 @@
+# amoand.w: atomic bitwise and
+.include "../test.inc"
+
+    TEST_AMO_OP            2, amoand.w, 0x80000000, 0x80000000, 0xfffff800, amo_data
+    TEST_AMO_OP            3, amoand.w, 0, 0x7ffff800, 1, amo_data
+    TEST_AMO_OP            4, amoand.w, 1, 0xffffffff, 1, amo_data
+    TEST_AMO_OP            5, amoand.w, 1, 1, 0xffffffff, amo_data
+    TEST_AMO_OP            6, amoand.w, 0, 0x7fffffff, 0x80000000, amo_data
+    TEST_AMO_OP            7, amoand.w, 0, 0x80000000, 0x7fffffff, amo_data
+    TEST_AMO_OP            8, amoand.w, 0, 0, 0, amo_data
+    TEST_AMO_OP            9, amoand.w, 5, 5, 7, amo_data
+
+    TEST_AMO_OP            10, amoand.w.aq, 1, 3, 9, amo_data
+    TEST_AMO_OP            11, amoand.w.rl, 1, 9, 3, amo_data
+    TEST_AMO_OP            12, amoand.w.aqrl, 2, 0xfffffffe, 2, amo_data
+    TEST_AMO_ZERODEST      13, amoand.w, 0x02040608, 0x12345678, 0x0f0f0f0f, amo_data
+
+    # Operation applied twice in a row, the second one reading the first result
+    li gp, 14
+    la a3, amo_data
+    li a1, 0x00ff00ff
+    sw a1, 0(a3)
+    li a2, 0x0ff00ff0
+    amoand.w a4, a2, (a3)
+    amoand.w a5, a4, (a3)
+    lw a5, 0(a3)
+    TEST_CHECK a5, 0x00f000f0
+
+    TEST_PASSFAIL
+
+.data
+    .align 2
+amo_data:
+    .word 0
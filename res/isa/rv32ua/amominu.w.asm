@@ -0,0 +1,34 @@
+# amominu.w: atomic unsigned minimum
+.include "../test.inc"
+
+    TEST_AMO_OP            2, amominu.w, 0x80000000, 0x80000000, 0xfffff800, amo_data
+    TEST_AMO_OP            3, amominu.w, 1, 0x7ffff800, 1, amo_data
+    TEST_AMO_OP            4, amominu.w, 1, 0xffffffff, 1, amo_data
+    TEST_AMO_OP            5, amominu.w, 1, 1, 0xffffffff, amo_data
+    TEST_AMO_OP            6, amominu.w, 0x7fffffff, 0x7fffffff, 0x80000000, amo_data
+    TEST_AMO_OP            7, amominu.w, 0x7fffffff, 0x80000000, 0x7fffffff, amo_data
+    TEST_AMO_OP            8, amominu.w, 0, 0, 0, amo_data
+    TEST_AMO_OP            9, amominu.w, 5, 5, 7, amo_data
+
+    TEST_AMO_OP            10, amominu.w.aq, 3, 3, 9, amo_data
+    TEST_AMO_OP            11, amominu.w.rl, 3, 9, 3, amo_data
+    TEST_AMO_OP            12, amominu.w.aqrl, 2, 0xfffffffe, 2, amo_data
+    TEST_AMO_ZERODEST      13, amominu.w, 0x0f0f0f0f, 0x12345678, 0x0f0f0f0f, amo_data
+
+    # Operation applied twice in a row, the second one reading the first result
+    li gp, 14
+    la a3, amo_data
+    li a1, 0x00ff00ff
+    sw a1, 0(a3)
+    li a2, 0x0ff00ff0
+    amominu.w a4, a2, (a3)
+    amominu.w a5, a4, (a3)
+    lw a5, 0(a3)
+    TEST_CHECK a5, 0x00ff00ff
+
+    TEST_PASSFAIL
+
+.data
+    .align 2
+amo_data:
+    .word 0
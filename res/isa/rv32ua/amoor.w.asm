@@ -0,0 +1,34 @@
+# amoor.w: atomic bitwise or
+.include "../test.inc"
+
+    TEST_AMO_OP            2, amoor.w, 0xfffff800, 0x80000000, 0xfffff800, amo_data
+    TEST_AMO_OP            3, amoor.w, 0x7ffff801, 0x7ffff800, 1, amo_data
+    TEST_AMO_OP            4, amoor.w, 0xffffffff, 0xffffffff, 1, amo_data
+    TEST_AMO_OP            5, amoor.w, 0xffffffff, 1, 0xffffffff, amo_data
+    TEST_AMO_OP            6, amoor.w, 0xffffffff, 0x7fffffff, 0x80000000, amo_data
+    TEST_AMO_OP            7, amoor.w, 0xffffffff, 0x80000000, 0x7fffffff, amo_data
+    TEST_AMO_OP            8, amoor.w, 0, 0, 0, amo_data
+    TEST_AMO_OP            9, amoor.w, 7, 5, 7, amo_data
+
+    TEST_AMO_OP            10, amoor.w.aq, 11, 3, 9, amo_data
+    TEST_AMO_OP            11, amoor.w.rl, 11, 9, 3, amo_data
+    TEST_AMO_OP            12, amoor.w.aqrl, 0xfffffffe, 0xfffffffe, 2, amo_data
+    TEST_AMO_ZERODEST      13, amoor.w, 0x1f3f5f7f, 0x12345678, 0x0f0f0f0f, amo_data
+
+    # Operation applied twice in a row, the second one reading the first result
+    li gp, 14
+    la a3, amo_data
+    li a1, 0x00ff00ff
+    sw a1, 0(a3)
+    li a2, 0x0ff00ff0
+    amoor.w a4, a2, (a3)
+    amoor.w a5, a4, (a3)
+    lw a5, 0(a3)
+    TEST_CHECK a5, 0x0fff0fff
+
+    TEST_PASSFAIL
+
+.data
+    .align 2
+amo_data:
+    .word 0
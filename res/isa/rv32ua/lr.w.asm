@@ -0,0 +1,44 @@
+# lr.w: load-reserved word, reserving its address for sc.w
+.include "../test.inc"
+
+    li gp, 2
+    la a3, amo_data
+    li a1, 0x80000001
+    sw a1, 0(a3)
+    lr.w a4, (a3)
+    TEST_CHECK a4, 0x80000001
+
+    # The reservation lets the following sc.w succeed
+    li gp, 3
+    li a2, 0x12345678
+    sc.w a5, a2, (a3)
+    TEST_CHECK a5, 0
+    lw a4, 0(a3)
+    TEST_CHECK a4, 0x12345678
+
+    # Load into x0, still reserving the address
+    li gp, 4
+    lr.w zero, (a3)
+    bnez zero, fail
+    sc.w a5, zero, (a3)
+    TEST_CHECK a5, 0
+    lw a4, 0(a3)
+    TEST_CHECK a4, 0
+
+    # The orderings
+    li gp, 5
+    li a1, 7
+    sw a1, 0(a3)
+    lr.w.aq a4, (a3)
+    TEST_CHECK a4, 7
+    lr.w.aqrl a4, (a3)
+    TEST_CHECK a4, 7
+
+    TEST_PASSFAIL
+
+.data
+    .align 2
+amo_data:
+    .word 0
+amo_other:
+    .word 0
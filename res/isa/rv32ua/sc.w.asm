@@ -0,0 +1,68 @@
+# sc.w: store-conditional word, succeeding only on the address reserved by lr.w
+.include "../test.inc"
+
+    # Without any reservation, the store fails and writes a nonzero value
+    li gp, 2
+    la a3, amo_data
+    li a1, 5
+    sw a1, 0(a3)
+    li a2, 9
+    sc.w a5, a2, (a3)
+    beqz a5, fail
+    lw a4, 0(a3)
+    TEST_CHECK a4, 5
+
+    # Reserved address
+    li gp, 3
+    lr.w a4, (a3)
+    sc.w a5, a2, (a3)
+    TEST_CHECK a5, 0
+    lw a4, 0(a3)
+    TEST_CHECK a4, 9
+
+    # The reservation is consumed by the first store
+    li gp, 4
+    li a2, 11
+    sc.w a5, a2, (a3)
+    beqz a5, fail
+    lw a4, 0(a3)
+    TEST_CHECK a4, 9
+
+    # Another address than the one reserved
+    li gp, 5
+    la a1, amo_other
+    lr.w a4, (a3)
+    sc.w a5, a2, (a1)
+    beqz a5, fail
+    lw a4, 0(a1)
+    TEST_CHECK a4, 0
+
+    # Atomic increment in a loop, retried until the store succeeds
+    li gp, 6
+    li t0, 3
+loop:
+    lr.w a4, (a3)
+    addi a4, a4, 1
+    sc.w a5, a4, (a3)
+    bnez a5, loop
+    addi t0, t0, -1
+    bnez t0, loop
+    lw a4, 0(a3)
+    TEST_CHECK a4, 12
+
+    # Store into x0
+    li gp, 7
+    lr.w.aq a4, (a3)
+    sc.w.rl zero, a2, (a3)
+    bnez zero, fail
+    lw a4, 0(a3)
+    TEST_CHECK a4, 11
+
+    TEST_PASSFAIL
+
+.data
+    .align 2
+amo_data:
+    .word 0
+amo_other:
+    .word 0
@@ -0,0 +1,18 @@
+# fadd.d: double-precision addition
+.include "../test.inc"
+
+    TEST_FP_OP2_D          2, fadd.d, 0, 0x400c0000, 0x00000000, 0x40040000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          3, fadd.d, 1, 0xc0934800, 0x00000000, 0xc0934c66, 0x66666666, 0x3ff19999, 0x9999999a
+    TEST_FP_OP2_D          4, fadd.d, 1, 0x3fd33333, 0x33333334, 0x3fb99999, 0x9999999a, 0x3fc99999, 0x9999999a
+    TEST_FP_OP2_D          5, fadd.d, 1, 0x3ff00000, 0x00000000, 0x3ff00000, 0x00000000, 0x3ca00000, 0x00000000
+    TEST_FP_OP2_D          6, fadd.d, 0, 0x3fefffff, 0xffffffff, 0x3ff00000, 0x00000000, 0xbca00000, 0x00000000
+    TEST_FP_OP2_D          7, fadd.d, 5, 0x7ff00000, 0x00000000, 0x7fefffff, 0xffffffff, 0x7fefffff, 0xffffffff
+    TEST_FP_OP2_D          8, fadd.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000000, 0xfff00000, 0x00000000
+    TEST_FP_OP2_D          9, fadd.d, 0, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP2_D          10, fadd.d, 0, 0x80000000, 0x00000000, 0x80000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP2_D          11, fadd.d, 0, 0x00000000, 0x00000000, 0x3ff00000, 0x00000000, 0xbff00000, 0x00000000
+    TEST_FP_OP2_D          12, fadd.d, 0, 0x00000000, 0x00000000, 0x00000000, 0x00000001, 0x80000000, 0x00000001
+    TEST_FP_OP2_D          13, fadd.d, 0, 0x7ff80000, 0x00000000, 0x7ff80000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          14, fadd.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000001, 0x3ff00000, 0x00000000
+
+    TEST_PASSFAIL
@@ -0,0 +1,18 @@
+# fclass.d: double-precision classification
+.include "../test.inc"
+
+    TEST_FP_INT_OP_D       2, fclass.d, 0, 1, 0xfff00000, 0x00000000
+    TEST_FP_INT_OP_D       3, fclass.d, 0, 2, 0xbff00000, 0x00000000
+    TEST_FP_INT_OP_D       4, fclass.d, 0, 4, 0x80000000, 0x00000001
+    TEST_FP_INT_OP_D       5, fclass.d, 0, 8, 0x80000000, 0x00000000
+    TEST_FP_INT_OP_D       6, fclass.d, 0, 16, 0x00000000, 0x00000000
+    TEST_FP_INT_OP_D       7, fclass.d, 0, 32, 0x00000000, 0x00000001
+    TEST_FP_INT_OP_D       8, fclass.d, 0, 64, 0x3ff00000, 0x00000000
+    TEST_FP_INT_OP_D       9, fclass.d, 0, 128, 0x7ff00000, 0x00000000
+    TEST_FP_INT_OP_D       10, fclass.d, 0, 0x00000100, 0x7ff00000, 0x00000001
+    TEST_FP_INT_OP_D       11, fclass.d, 0, 0x00000200, 0x7ff80000, 0x00000000
+    TEST_FP_INT_OP_D       12, fclass.d, 0, 64, 0x7fefffff, 0xffffffff
+    TEST_FP_INT_OP_D       13, fclass.d, 0, 4, 0x800fffff, 0xffffffff
+    TEST_FP_INT_OP_D       14, fclass.d, 0, 0x00000200, 0xfff80000, 0x00000000
+
+    TEST_PASSFAIL
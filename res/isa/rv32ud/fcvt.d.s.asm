@@ -0,0 +1,15 @@
+# fcvt.d.s: conversion of a single to a double, always exact
+.include "../test.inc"
+
+    TEST_FCVT_D_S          2, 0, 0x3ff80000, 0x00000000, 0x3fc00000
+    TEST_FCVT_D_S          3, 0, 0xbff80000, 0x00000000, 0xbfc00000
+    TEST_FCVT_D_S          4, 0, 0x47efffff, 0xe0000000, 0x7f7fffff
+    TEST_FCVT_D_S          5, 0, 0x36a00000, 0x00000000, 0x00000001
+    TEST_FCVT_D_S          6, 0, 0xb80fffff, 0xc0000000, 0x807fffff
+    TEST_FCVT_D_S          7, 0, 0x7ff80000, 0x00000000, 0x7fc00000
+    TEST_FCVT_D_S          8, 16, 0x7ff80000, 0x00000000, 0x7f800001
+    TEST_FCVT_D_S          9, 0, 0xfff00000, 0x00000000, 0xff800000
+    TEST_FCVT_D_S          10, 0, 0x80000000, 0x00000000, 0x80000000
+    TEST_FCVT_D_S          11, 0, 0x7ff80000, 0x00000000, 0x7fc00123
+
+    TEST_PASSFAIL
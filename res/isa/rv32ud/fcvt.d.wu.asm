@@ -0,0 +1,14 @@
+# fcvt.d.wu: conversion of an unsigned word to a double, always exact
+.include "../test.inc"
+
+    TEST_INT_FP_OP_D       2, fcvt.d.wu, 0, 0x00000000, 0x00000000, 0
+    TEST_INT_FP_OP_D       3, fcvt.d.wu, 0, 0x3ff00000, 0x00000000, 1
+    TEST_INT_FP_OP_D       4, fcvt.d.wu, 0, 0x41efffff, 0xffe00000, 0xffffffff
+    TEST_INT_FP_OP_D       5, fcvt.d.wu, 0, 0x40000000, 0x00000000, 2
+    TEST_INT_FP_OP_D       6, fcvt.d.wu, 0, 0x41efffff, 0xffc00000, 0xfffffffe
+    TEST_INT_FP_OP_D       7, fcvt.d.wu, 0, 0x41dfffff, 0xffc00000, 0x7fffffff
+    TEST_INT_FP_OP_D       8, fcvt.d.wu, 0, 0x41e00000, 0x00000000, 0x80000000
+    TEST_INT_FP_OP_D       9, fcvt.d.wu, 0, 0x41700000, 0x10000000, 0x01000001
+    TEST_INT_FP_OP_D       10, fcvt.d.wu, 0, 0x41e00000, 0x00200000, 0x80000001
+
+    TEST_PASSFAIL
@@ -0,0 +1,19 @@
+# fcvt.s.d: conversion of a double to a single, rounded
+.include "../test.inc"
+
+    TEST_FCVT_S_D          2, 0, 0x3fc00000, 0x3ff80000, 0x00000000
+    TEST_FCVT_S_D          3, 0, 0xbfc00000, 0xbff80000, 0x00000000
+    TEST_FCVT_S_D          4, 1, 0x3eaaaaab, 0x3fd55555, 0x55555555
+    TEST_FCVT_S_D          5, 5, 0x7f800000, 0x7fefffff, 0xffffffff
+    TEST_FCVT_S_D          6, 5, 0xff800000, 0xffefffff, 0xffffffff
+    TEST_FCVT_S_D          7, 1, 0x3f800000, 0x3ff00000, 0x00400000
+    TEST_FCVT_S_D          8, 3, 0x00000000, 0x36900000, 0x00000000
+    TEST_FCVT_S_D          9, 0, 0x00000001, 0x36a00000, 0x00000000
+    TEST_FCVT_S_D          10, 0, 0x00800000, 0x38100000, 0x00000000
+    TEST_FCVT_S_D          11, 0, 0x7fc00000, 0x7ff80000, 0x00000000
+    TEST_FCVT_S_D          12, 16, 0x7fc00000, 0x7ff00000, 0x00000001
+    TEST_FCVT_S_D          13, 0, 0x7f800000, 0x7ff00000, 0x00000000
+    TEST_FCVT_S_D          14, 0, 0x80000000, 0x80000000, 0x00000000
+    TEST_FCVT_S_D          15, 0, 0x7fc00000, 0x7ff80000, 0x00000123
+
+    TEST_PASSFAIL
@@ -0,0 +1,30 @@
+# fcvt.w.d: conversion of a double to a signed word, saturating on overflow
+.include "../test.inc"
+
+    TEST_FP_INT_OP_D       2, fcvt.w.d, 0, 1, 0x3ff00000, 0x00000000
+    TEST_FP_INT_OP_D       3, fcvt.w.d, 0, 0xffffffff, 0xbff00000, 0x00000000
+    TEST_FP_INT_OP_D       4, fcvt.w.d, 1, 1, 0x3ff19999, 0x9999999a
+    TEST_FP_INT_OP_D       5, fcvt.w.d, 1, 0xffffffff, 0xbfeccccc, 0xcccccccd
+    TEST_FP_INT_OP_D       6, fcvt.w.d, 1, 2, 0x3ff80000, 0x00000000
+    TEST_FP_INT_OP_D       7, fcvt.w.d, 1, 2, 0x40040000, 0x00000000
+    TEST_FP_INT_OP_D       8, fcvt.w.d, 1, 0xfffffffe, 0xc0040000, 0x00000000
+    TEST_FP_INT_OP_D       9, fcvt.w.d, 0, 0x7fffffff, 0x41dfffff, 0xffc00000
+    TEST_FP_INT_OP_D       10, fcvt.w.d, 16, 0x7fffffff, 0x41dfffff, 0xffe00000
+    TEST_FP_INT_OP_D       11, fcvt.w.d, 16, 0x7fffffff, 0x41e00000, 0x00000000
+    TEST_FP_INT_OP_D       12, fcvt.w.d, 0, 0x80000000, 0xc1e00000, 0x00000000
+    TEST_FP_INT_OP_D       13, fcvt.w.d, 1, 0x80000000, 0xc1e00000, 0x00100000
+    TEST_FP_INT_OP_D       14, fcvt.w.d, 16, 0x80000000, 0xc1e00000, 0x00200000
+    TEST_FP_INT_OP_D       15, fcvt.w.d, 16, 0x7fffffff, 0x41efffff, 0xffe00000
+    TEST_FP_INT_OP_D       16, fcvt.w.d, 16, 0x7fffffff, 0x41f00000, 0x00000000
+    TEST_FP_INT_OP_D       17, fcvt.w.d, 16, 0x7fffffff, 0x7ff00000, 0x00000000
+    TEST_FP_INT_OP_D       18, fcvt.w.d, 16, 0x80000000, 0xfff00000, 0x00000000
+    TEST_FP_INT_OP_D       19, fcvt.w.d, 16, 0x7fffffff, 0x7ff80000, 0x00000000
+    TEST_FP_INT_OP_D       20, fcvt.w.d, 16, 0x7fffffff, 0x7ff00000, 0x00000001
+    TEST_FP_INT_OP_D       21, fcvt.w.d, 1, 0, 0xbfd99999, 0x9999999a
+
+    # Rounding toward zero, from the dynamic rounding mode of frm
+    csrwi frm, 1
+    TEST_FP_INT_OP_D       22, fcvt.w.d, 1, 2, 0x40073333, 0x33333333
+    csrwi frm, 0
+
+    TEST_PASSFAIL
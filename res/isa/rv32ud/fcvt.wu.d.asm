@@ -0,0 +1,30 @@
+# fcvt.wu.d: conversion of a double to an unsigned word, saturating on overflow
+.include "../test.inc"
+
+    TEST_FP_INT_OP_D       2, fcvt.wu.d, 0, 1, 0x3ff00000, 0x00000000
+    TEST_FP_INT_OP_D       3, fcvt.wu.d, 16, 0, 0xbff00000, 0x00000000
+    TEST_FP_INT_OP_D       4, fcvt.wu.d, 1, 1, 0x3ff19999, 0x9999999a
+    TEST_FP_INT_OP_D       5, fcvt.wu.d, 16, 0, 0xbfeccccc, 0xcccccccd
+    TEST_FP_INT_OP_D       6, fcvt.wu.d, 1, 2, 0x3ff80000, 0x00000000
+    TEST_FP_INT_OP_D       7, fcvt.wu.d, 1, 2, 0x40040000, 0x00000000
+    TEST_FP_INT_OP_D       8, fcvt.wu.d, 16, 0, 0xc0040000, 0x00000000
+    TEST_FP_INT_OP_D       9, fcvt.wu.d, 0, 0x7fffffff, 0x41dfffff, 0xffc00000
+    TEST_FP_INT_OP_D       10, fcvt.wu.d, 1, 0x80000000, 0x41dfffff, 0xffe00000
+    TEST_FP_INT_OP_D       11, fcvt.wu.d, 0, 0x80000000, 0x41e00000, 0x00000000
+    TEST_FP_INT_OP_D       12, fcvt.wu.d, 16, 0, 0xc1e00000, 0x00000000
+    TEST_FP_INT_OP_D       13, fcvt.wu.d, 16, 0, 0xc1e00000, 0x00100000
+    TEST_FP_INT_OP_D       14, fcvt.wu.d, 16, 0, 0xc1e00000, 0x00200000
+    TEST_FP_INT_OP_D       15, fcvt.wu.d, 0, 0xffffffff, 0x41efffff, 0xffe00000
+    TEST_FP_INT_OP_D       16, fcvt.wu.d, 16, 0xffffffff, 0x41f00000, 0x00000000
+    TEST_FP_INT_OP_D       17, fcvt.wu.d, 16, 0xffffffff, 0x7ff00000, 0x00000000
+    TEST_FP_INT_OP_D       18, fcvt.wu.d, 16, 0, 0xfff00000, 0x00000000
+    TEST_FP_INT_OP_D       19, fcvt.wu.d, 16, 0xffffffff, 0x7ff80000, 0x00000000
+    TEST_FP_INT_OP_D       20, fcvt.wu.d, 16, 0xffffffff, 0x7ff00000, 0x00000001
+    TEST_FP_INT_OP_D       21, fcvt.wu.d, 1, 0, 0xbfd99999, 0x9999999a
+
+    # Rounding toward zero, from the dynamic rounding mode of frm
+    csrwi frm, 1
+    TEST_FP_INT_OP_D       22, fcvt.wu.d, 1, 2, 0x40073333, 0x33333333
+    csrwi frm, 0
+
+    TEST_PASSFAIL
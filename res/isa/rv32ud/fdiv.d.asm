@@ -0,0 +1,18 @@
+# fdiv.d: double-precision division
+.include "../test.inc"
+
+    TEST_FP_OP2_D          2, fdiv.d, 1, 0x3ff27ddb, 0xf6c383ec, 0x400921fb, 0x53c8d4f1, 0x4005bf0a, 0x89f1b0dd
+    TEST_FP_OP2_D          3, fdiv.d, 1, 0xbfeff8b4, 0x3e1929a5, 0xc0934800, 0x00000000, 0x40934c66, 0x66666666
+    TEST_FP_OP2_D          4, fdiv.d, 0, 0x400c0000, 0x00000000, 0x401c0000, 0x00000000, 0x40000000, 0x00000000
+    TEST_FP_OP2_D          5, fdiv.d, 1, 0x3fd55555, 0x55555555, 0x3ff00000, 0x00000000, 0x40080000, 0x00000000
+    TEST_FP_OP2_D          6, fdiv.d, 8, 0x7ff00000, 0x00000000, 0x3ff00000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_OP2_D          7, fdiv.d, 8, 0xfff00000, 0x00000000, 0xbff00000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_OP2_D          8, fdiv.d, 16, 0x7ff80000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_OP2_D          9, fdiv.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000000, 0x7ff00000, 0x00000000
+    TEST_FP_OP2_D          10, fdiv.d, 0, 0x00000000, 0x00000000, 0x3ff00000, 0x00000000, 0x7ff00000, 0x00000000
+    TEST_FP_OP2_D          11, fdiv.d, 0, 0x00040000, 0x00000000, 0x00100000, 0x00000000, 0x40100000, 0x00000000
+    TEST_FP_OP2_D          12, fdiv.d, 5, 0x7ff00000, 0x00000000, 0x7fefffff, 0xffffffff, 0x3fe00000, 0x00000000
+    TEST_FP_OP2_D          13, fdiv.d, 0, 0x7ff80000, 0x00000000, 0x7ff80000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          14, fdiv.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000001, 0x3ff00000, 0x00000000
+
+    TEST_PASSFAIL
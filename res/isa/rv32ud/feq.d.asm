@@ -0,0 +1,18 @@
+# feq.d: double-precision quiet equality, raising NV on signaling NaNs only
+.include "../test.inc"
+
+    TEST_FP_CMP_OP_D       2, feq.d, 0, 1, 0xbff5c28f, 0x5c28f5c3, 0xbff5c28f, 0x5c28f5c3
+    TEST_FP_CMP_OP_D       3, feq.d, 0, 0, 0xbff5eb85, 0x1eb851ec, 0xbff5c28f, 0x5c28f5c3
+    TEST_FP_CMP_OP_D       4, feq.d, 0, 0, 0xbff5c28f, 0x5c28f5c3, 0xbff5eb85, 0x1eb851ec
+    TEST_FP_CMP_OP_D       5, feq.d, 0, 1, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_CMP_OP_D       6, feq.d, 0, 1, 0x80000000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_CMP_OP_D       7, feq.d, 0, 0, 0x7ff80000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_CMP_OP_D       8, feq.d, 0, 0, 0x3ff00000, 0x00000000, 0x7ff80000, 0x00000000
+    TEST_FP_CMP_OP_D       9, feq.d, 16, 0, 0x7ff00000, 0x00000001, 0x3ff00000, 0x00000000
+    TEST_FP_CMP_OP_D       10, feq.d, 0, 0, 0x7ff80000, 0x00000000, 0x7ff80000, 0x00000000
+    TEST_FP_CMP_OP_D       11, feq.d, 0, 0, 0xfff00000, 0x00000000, 0x7ff00000, 0x00000000
+    TEST_FP_CMP_OP_D       12, feq.d, 0, 1, 0x7ff00000, 0x00000000, 0x7ff00000, 0x00000000
+    TEST_FP_CMP_OP_D       13, feq.d, 0, 0, 0x00000000, 0x00000001, 0x00000000, 0x00000000
+    TEST_FP_CMP_OP_D       14, feq.d, 0, 0, 0x80000000, 0x00000001, 0x00000000, 0x00000001
+
+    TEST_PASSFAIL
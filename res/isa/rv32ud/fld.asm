@@ -0,0 +1,48 @@
+# fld: load of a double
+.include "../test.inc"
+
+    li gp, 2
+    la a1, tdat
+    fld fa0, 8(a1)
+    fsd fa0, 24(a1)
+    lw a4, 24(a1)
+    TEST_CHECK a4, 0x00000000
+    lw a4, 28(a1)
+    TEST_CHECK a4, 0x40000000
+
+    li gp, 3
+    la a1, tdat3
+    fld fa0, -16(a1)
+    fsd fa0, 8(a1)
+    lw a4, 8(a1)
+    TEST_CHECK a4, 0
+    lw a4, 12(a1)
+    TEST_CHECK a4, 0xbff00000
+
+    # A signaling NaN is loaded untouched
+    li gp, 4
+    la a1, tdat
+    fld fa0, 16(a1)
+    fsd fa0, 24(a1)
+    lw a4, 24(a1)
+    TEST_CHECK a4, 1
+    lw a4, 28(a1)
+    TEST_CHECK a4, 0x7ff00000
+
+    # Load consumed right away
+    li gp, 5
+    fld fa0, 8(a1)
+    fadd.d fa1, fa0, fa0
+    fcvt.w.d a4, fa1
+    TEST_CHECK a4, 4
+
+    TEST_PASSFAIL
+
+.data
+    .align 3
+tdat:
+    .word 0x00000000, 0xbff00000
+    .word 0x00000000, 0x40000000
+tdat3:
+    .word 0x00000001, 0x7ff00000
+    .word 0, 0
@@ -0,0 +1,18 @@
+# fle.d: double-precision signaling less-than-or-equal comparison, raising NV on any NaN
+.include "../test.inc"
+
+    TEST_FP_CMP_OP_D       2, fle.d, 0, 1, 0xbff5c28f, 0x5c28f5c3, 0xbff5c28f, 0x5c28f5c3
+    TEST_FP_CMP_OP_D       3, fle.d, 0, 1, 0xbff5eb85, 0x1eb851ec, 0xbff5c28f, 0x5c28f5c3
+    TEST_FP_CMP_OP_D       4, fle.d, 0, 0, 0xbff5c28f, 0x5c28f5c3, 0xbff5eb85, 0x1eb851ec
+    TEST_FP_CMP_OP_D       5, fle.d, 0, 1, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_CMP_OP_D       6, fle.d, 0, 1, 0x80000000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_CMP_OP_D       7, fle.d, 16, 0, 0x7ff80000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_CMP_OP_D       8, fle.d, 16, 0, 0x3ff00000, 0x00000000, 0x7ff80000, 0x00000000
+    TEST_FP_CMP_OP_D       9, fle.d, 16, 0, 0x7ff00000, 0x00000001, 0x3ff00000, 0x00000000
+    TEST_FP_CMP_OP_D       10, fle.d, 16, 0, 0x7ff80000, 0x00000000, 0x7ff80000, 0x00000000
+    TEST_FP_CMP_OP_D       11, fle.d, 0, 1, 0xfff00000, 0x00000000, 0x7ff00000, 0x00000000
+    TEST_FP_CMP_OP_D       12, fle.d, 0, 1, 0x7ff00000, 0x00000000, 0x7ff00000, 0x00000000
+    TEST_FP_CMP_OP_D       13, fle.d, 0, 0, 0x00000000, 0x00000001, 0x00000000, 0x00000000
+    TEST_FP_CMP_OP_D       14, fle.d, 0, 1, 0x80000000, 0x00000001, 0x00000000, 0x00000001
+
+    TEST_PASSFAIL
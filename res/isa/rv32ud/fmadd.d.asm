@@ -0,0 +1,17 @@
+# fmadd.d: double-precision fused multiply-add, rounded once
+.include "../test.inc"
+
+    TEST_FP_OP3_D          2, fmadd.d, 0, 0x400c0000, 0x00000000, 0x3ff00000, 0x00000000, 0x40040000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP3_D          3, fmadd.d, 1, 0x409350cc, 0xcccccccc, 0xbff00000, 0x00000000, 0xc0934c66, 0x66666666, 0x3ff19999, 0x9999999a
+    TEST_FP_OP3_D          4, fmadd.d, 0, 0xc0280000, 0x00000000, 0x40000000, 0x00000000, 0xc0140000, 0x00000000, 0xc0000000, 0x00000000
+    TEST_FP_OP3_D          5, fmadd.d, 0, 0x3e500000, 0x01000000, 0x3ff00000, 0x02000000, 0x3ff00000, 0x02000000, 0xbff00000, 0x00000000
+    TEST_FP_OP3_D          6, fmadd.d, 0, 0x00000000, 0x00000000, 0x3ff00000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_OP3_D          7, fmadd.d, 0, 0x00000000, 0x00000000, 0x3ff00000, 0x00000000, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP3_D          8, fmadd.d, 0, 0x80000000, 0x00000000, 0xbff00000, 0x00000000, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP3_D          9, fmadd.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000000, 0x00000000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP3_D          10, fmadd.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000000, 0x3ff00000, 0x00000000, 0xfff00000, 0x00000000
+    TEST_FP_OP3_D          11, fmadd.d, 0, 0x7fefffff, 0xffffffff, 0x7fefffff, 0xffffffff, 0x40000000, 0x00000000, 0xffefffff, 0xffffffff
+    TEST_FP_OP3_D          12, fmadd.d, 16, 0x7ff80000, 0x00000000, 0x3ff00000, 0x00000000, 0x3ff00000, 0x00000000, 0x7ff00000, 0x00000001
+    TEST_FP_OP3_D          13, fmadd.d, 1, 0x3ff00000, 0x00000000, 0x1a700000, 0x00000000, 0x1a700000, 0x00000000, 0x3ff00000, 0x00000000
+
+    TEST_PASSFAIL
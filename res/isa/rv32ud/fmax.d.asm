@@ -0,0 +1,18 @@
+# fmax.d: double-precision maximum, -0 being less than +0
+.include "../test.inc"
+
+    TEST_FP_OP2_D          2, fmax.d, 0, 0x40040000, 0x00000000, 0x40040000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          3, fmax.d, 0, 0x3ff19999, 0x9999999a, 0xc0934c66, 0x66666666, 0x3ff19999, 0x9999999a
+    TEST_FP_OP2_D          4, fmax.d, 0, 0x3ff19999, 0x9999999a, 0x3ff19999, 0x9999999a, 0xc0934c66, 0x66666666
+    TEST_FP_OP2_D          5, fmax.d, 0, 0xc0934c66, 0x66666666, 0x7ff80000, 0x00000000, 0xc0934c66, 0x66666666
+    TEST_FP_OP2_D          6, fmax.d, 0, 0xbff00000, 0x00000000, 0xc0000000, 0x00000000, 0xbff00000, 0x00000000
+    TEST_FP_OP2_D          7, fmax.d, 0, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP2_D          8, fmax.d, 0, 0x00000000, 0x00000000, 0x80000000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_OP2_D          9, fmax.d, 0, 0x7ff80000, 0x00000000, 0x7ff80000, 0x00000000, 0x7ff80000, 0x00000000
+    TEST_FP_OP2_D          10, fmax.d, 16, 0x3ff00000, 0x00000000, 0x7ff00000, 0x00000001, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          11, fmax.d, 16, 0x3ff00000, 0x00000000, 0x3ff00000, 0x00000000, 0x7ff00000, 0x00000001
+    TEST_FP_OP2_D          12, fmax.d, 0, 0x7ff00000, 0x00000000, 0x7ff00000, 0x00000000, 0x7fefffff, 0xffffffff
+    TEST_FP_OP2_D          13, fmax.d, 0, 0xffefffff, 0xffffffff, 0xfff00000, 0x00000000, 0xffefffff, 0xffffffff
+    TEST_FP_OP2_D          14, fmax.d, 0, 0x00000000, 0x00000001, 0x00000000, 0x00000001, 0x00000000, 0x00000000
+
+    TEST_PASSFAIL
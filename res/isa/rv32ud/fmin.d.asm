@@ -0,0 +1,18 @@
+# fmin.d: double-precision minimum, -0 being less than +0
+.include "../test.inc"
+
+    TEST_FP_OP2_D          2, fmin.d, 0, 0x3ff00000, 0x00000000, 0x40040000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          3, fmin.d, 0, 0xc0934c66, 0x66666666, 0xc0934c66, 0x66666666, 0x3ff19999, 0x9999999a
+    TEST_FP_OP2_D          4, fmin.d, 0, 0xc0934c66, 0x66666666, 0x3ff19999, 0x9999999a, 0xc0934c66, 0x66666666
+    TEST_FP_OP2_D          5, fmin.d, 0, 0xc0934c66, 0x66666666, 0x7ff80000, 0x00000000, 0xc0934c66, 0x66666666
+    TEST_FP_OP2_D          6, fmin.d, 0, 0xc0000000, 0x00000000, 0xc0000000, 0x00000000, 0xbff00000, 0x00000000
+    TEST_FP_OP2_D          7, fmin.d, 0, 0x80000000, 0x00000000, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP2_D          8, fmin.d, 0, 0x80000000, 0x00000000, 0x80000000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_OP2_D          9, fmin.d, 0, 0x7ff80000, 0x00000000, 0x7ff80000, 0x00000000, 0x7ff80000, 0x00000000
+    TEST_FP_OP2_D          10, fmin.d, 16, 0x3ff00000, 0x00000000, 0x7ff00000, 0x00000001, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          11, fmin.d, 16, 0x3ff00000, 0x00000000, 0x3ff00000, 0x00000000, 0x7ff00000, 0x00000001
+    TEST_FP_OP2_D          12, fmin.d, 0, 0x7fefffff, 0xffffffff, 0x7ff00000, 0x00000000, 0x7fefffff, 0xffffffff
+    TEST_FP_OP2_D          13, fmin.d, 0, 0xfff00000, 0x00000000, 0xfff00000, 0x00000000, 0xffefffff, 0xffffffff
+    TEST_FP_OP2_D          14, fmin.d, 0, 0x00000000, 0x00000000, 0x00000000, 0x00000001, 0x00000000, 0x00000000
+
+    TEST_PASSFAIL
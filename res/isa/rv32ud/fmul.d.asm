@@ -0,0 +1,18 @@
+# fmul.d: double-precision multiplication
+.include "../test.inc"
+
+    TEST_FP_OP2_D          2, fmul.d, 0, 0xc0040000, 0x00000000, 0x40040000, 0x00000000, 0xbff00000, 0x00000000
+    TEST_FP_OP2_D          3, fmul.d, 1, 0x40953a70, 0xa3d70a3d, 0xc0934c66, 0x66666666, 0xbff19999, 0x9999999a
+    TEST_FP_OP2_D          4, fmul.d, 1, 0x3fd33333, 0x33333334, 0x3fb99999, 0x9999999a, 0x40080000, 0x00000000
+    TEST_FP_OP2_D          5, fmul.d, 5, 0x7ff00000, 0x00000000, 0x7fefffff, 0xffffffff, 0x40000000, 0x00000000
+    TEST_FP_OP2_D          6, fmul.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_OP2_D          7, fmul.d, 0, 0x80000000, 0x00000000, 0x80000000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          8, fmul.d, 0, 0x00080000, 0x00000000, 0x00100000, 0x00000000, 0x3fe00000, 0x00000000
+    TEST_FP_OP2_D          9, fmul.d, 3, 0x00000000, 0x00000000, 0x00000000, 0x00000001, 0x3fe00000, 0x00000000
+    TEST_FP_OP2_D          10, fmul.d, 3, 0x00000000, 0x00000002, 0x00000000, 0x00000003, 0x3fe00000, 0x00000000
+    TEST_FP_OP2_D          11, fmul.d, 0, 0xfff00000, 0x00000000, 0x7ff00000, 0x00000000, 0xbff00000, 0x00000000
+    TEST_FP_OP2_D          12, fmul.d, 0, 0x7ff80000, 0x00000000, 0x7ff80000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          13, fmul.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000001, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          14, fmul.d, 1, 0x3ff00000, 0x04000000, 0x3ff00000, 0x02000000, 0x3ff00000, 0x02000000
+
+    TEST_PASSFAIL
@@ -0,0 +1,17 @@
+# fnmadd.d: double-precision negated fused multiply-add, rounded once
+.include "../test.inc"
+
+    TEST_FP_OP3_D          2, fnmadd.d, 0, 0xc00c0000, 0x00000000, 0x3ff00000, 0x00000000, 0x40040000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP3_D          3, fnmadd.d, 1, 0xc09350cc, 0xcccccccc, 0xbff00000, 0x00000000, 0xc0934c66, 0x66666666, 0x3ff19999, 0x9999999a
+    TEST_FP_OP3_D          4, fnmadd.d, 0, 0x40280000, 0x00000000, 0x40000000, 0x00000000, 0xc0140000, 0x00000000, 0xc0000000, 0x00000000
+    TEST_FP_OP3_D          5, fnmadd.d, 0, 0xbe500000, 0x01000000, 0x3ff00000, 0x02000000, 0x3ff00000, 0x02000000, 0xbff00000, 0x00000000
+    TEST_FP_OP3_D          6, fnmadd.d, 0, 0x80000000, 0x00000000, 0x3ff00000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_OP3_D          7, fnmadd.d, 0, 0x00000000, 0x00000000, 0x3ff00000, 0x00000000, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP3_D          8, fnmadd.d, 0, 0x00000000, 0x00000000, 0xbff00000, 0x00000000, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP3_D          9, fnmadd.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000000, 0x00000000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP3_D          10, fnmadd.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000000, 0x3ff00000, 0x00000000, 0xfff00000, 0x00000000
+    TEST_FP_OP3_D          11, fnmadd.d, 0, 0xffefffff, 0xffffffff, 0x7fefffff, 0xffffffff, 0x40000000, 0x00000000, 0xffefffff, 0xffffffff
+    TEST_FP_OP3_D          12, fnmadd.d, 16, 0x7ff80000, 0x00000000, 0x3ff00000, 0x00000000, 0x3ff00000, 0x00000000, 0x7ff00000, 0x00000001
+    TEST_FP_OP3_D          13, fnmadd.d, 1, 0xbff00000, 0x00000000, 0x1a700000, 0x00000000, 0x1a700000, 0x00000000, 0x3ff00000, 0x00000000
+
+    TEST_PASSFAIL
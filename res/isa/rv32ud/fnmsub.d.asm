@@ -0,0 +1,17 @@
+# fnmsub.d: double-precision negated fused multiply-subtract, rounded once
+.include "../test.inc"
+
+    TEST_FP_OP3_D          2, fnmsub.d, 0, 0xbff80000, 0x00000000, 0x3ff00000, 0x00000000, 0x40040000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP3_D          3, fnmsub.d, 1, 0xc0934800, 0x00000000, 0xbff00000, 0x00000000, 0xc0934c66, 0x66666666, 0x3ff19999, 0x9999999a
+    TEST_FP_OP3_D          4, fnmsub.d, 0, 0x40200000, 0x00000000, 0x40000000, 0x00000000, 0xc0140000, 0x00000000, 0xc0000000, 0x00000000
+    TEST_FP_OP3_D          5, fnmsub.d, 1, 0xc0000000, 0x02000000, 0x3ff00000, 0x02000000, 0x3ff00000, 0x02000000, 0xbff00000, 0x00000000
+    TEST_FP_OP3_D          6, fnmsub.d, 0, 0x00000000, 0x00000000, 0x3ff00000, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_OP3_D          7, fnmsub.d, 0, 0x80000000, 0x00000000, 0x3ff00000, 0x00000000, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP3_D          8, fnmsub.d, 0, 0x00000000, 0x00000000, 0xbff00000, 0x00000000, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP3_D          9, fnmsub.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000000, 0x00000000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP3_D          10, fnmsub.d, 0, 0xfff00000, 0x00000000, 0x7ff00000, 0x00000000, 0x3ff00000, 0x00000000, 0xfff00000, 0x00000000
+    TEST_FP_OP3_D          11, fnmsub.d, 5, 0xfff00000, 0x00000000, 0x7fefffff, 0xffffffff, 0x40000000, 0x00000000, 0xffefffff, 0xffffffff
+    TEST_FP_OP3_D          12, fnmsub.d, 16, 0x7ff80000, 0x00000000, 0x3ff00000, 0x00000000, 0x3ff00000, 0x00000000, 0x7ff00000, 0x00000001
+    TEST_FP_OP3_D          13, fnmsub.d, 1, 0x3ff00000, 0x00000000, 0x1a700000, 0x00000000, 0x1a700000, 0x00000000, 0x3ff00000, 0x00000000
+
+    TEST_PASSFAIL
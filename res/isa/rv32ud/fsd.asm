@@ -0,0 +1,56 @@
+# fsd: store of a double
+.include "../test.inc"
+
+    li gp, 2
+    la a1, tdat
+    li t0, 2
+    fcvt.d.w fa0, t0
+    fsd fa0, 8(a1)
+    lw a4, 8(a1)
+    TEST_CHECK a4, 0
+    lw a4, 12(a1)
+    TEST_CHECK a4, 0x40000000
+
+    li gp, 3
+    la a1, tdat3
+    li t0, -1
+    fcvt.d.w fa0, t0
+    fsd fa0, -16(a1)
+    lw a4, -16(a1)
+    TEST_CHECK a4, 0
+    lw a4, -12(a1)
+    TEST_CHECK a4, 0xbff00000
+
+    # A NaN-boxed single is stored with its boxing
+    li gp, 4
+    li t0, 0x40400000
+    fmv.w.x fa0, t0
+    fsd fa0, 0(a1)
+    lw a4, 0(a1)
+    TEST_CHECK a4, 0x40400000
+    lw a4, 4(a1)
+    TEST_CHECK a4, 0xffffffff
+
+    # The next word is untouched
+    lw a4, 8(a1)
+    TEST_CHECK a4, 0x0000abcd
+
+    # Store followed by a load of the same address
+    li gp, 5
+    li t0, 7
+    fcvt.d.w fa0, t0
+    fsd fa0, 0(a1)
+    fld fa1, 0(a1)
+    fcvt.w.d a4, fa1
+    TEST_CHECK a4, 7
+
+    TEST_PASSFAIL
+
+.data
+    .align 3
+tdat:
+    .word 0, 0
+    .word 0, 0
+tdat3:
+    .word 0, 0
+    .word 0x0000abcd
@@ -0,0 +1,15 @@
+# fsgnj.d: double-precision sign injection of the sign of rs2, on the raw bits, NaNs included
+.include "../test.inc"
+
+    TEST_FP_OP2_D          2, fsgnj.d, 0, 0xbff00000, 0x00000000, 0x3ff00000, 0x00000000, 0xbff00000, 0x00000000
+    TEST_FP_OP2_D          3, fsgnj.d, 0, 0x3ff00000, 0x00000000, 0xbff00000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          4, fsgnj.d, 0, 0xc0080000, 0x00000000, 0xc0080000, 0x00000000, 0xc0140000, 0x00000000
+    TEST_FP_OP2_D          5, fsgnj.d, 0, 0x401c0000, 0x00000000, 0x401c0000, 0x00000000, 0x401c0000, 0x00000000
+    TEST_FP_OP2_D          6, fsgnj.d, 0, 0x80000000, 0x00000000, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP2_D          7, fsgnj.d, 0, 0x00000000, 0x00000000, 0x80000000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_OP2_D          8, fsgnj.d, 0, 0xfff00000, 0x00000001, 0x7ff00000, 0x00000001, 0xbff00000, 0x00000000
+    TEST_FP_OP2_D          9, fsgnj.d, 0, 0x7ff80000, 0x00000001, 0xfff80000, 0x00000001, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          10, fsgnj.d, 0, 0xfff00000, 0x00000000, 0x7ff00000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP2_D          11, fsgnj.d, 0, 0xfff00000, 0x00000000, 0xfff00000, 0x00000000, 0xfff00000, 0x00000000
+
+    TEST_PASSFAIL
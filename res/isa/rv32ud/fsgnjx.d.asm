@@ -0,0 +1,15 @@
+# fsgnjx.d: double-precision sign injection of the sign of rs1 xor the sign of rs2, on the raw bits, NaNs included
+.include "../test.inc"
+
+    TEST_FP_OP2_D          2, fsgnjx.d, 0, 0xbff00000, 0x00000000, 0x3ff00000, 0x00000000, 0xbff00000, 0x00000000
+    TEST_FP_OP2_D          3, fsgnjx.d, 0, 0xbff00000, 0x00000000, 0xbff00000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          4, fsgnjx.d, 0, 0x40080000, 0x00000000, 0xc0080000, 0x00000000, 0xc0140000, 0x00000000
+    TEST_FP_OP2_D          5, fsgnjx.d, 0, 0x401c0000, 0x00000000, 0x401c0000, 0x00000000, 0x401c0000, 0x00000000
+    TEST_FP_OP2_D          6, fsgnjx.d, 0, 0x80000000, 0x00000000, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP2_D          7, fsgnjx.d, 0, 0x80000000, 0x00000000, 0x80000000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_OP2_D          8, fsgnjx.d, 0, 0xfff00000, 0x00000001, 0x7ff00000, 0x00000001, 0xbff00000, 0x00000000
+    TEST_FP_OP2_D          9, fsgnjx.d, 0, 0xfff80000, 0x00000001, 0xfff80000, 0x00000001, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          10, fsgnjx.d, 0, 0xfff00000, 0x00000000, 0x7ff00000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP2_D          11, fsgnjx.d, 0, 0x7ff00000, 0x00000000, 0xfff00000, 0x00000000, 0xfff00000, 0x00000000
+
+    TEST_PASSFAIL
@@ -0,0 +1,18 @@
+# fsqrt.d: double-precision square root
+.include "../test.inc"
+
+    TEST_FP_OP1_D          2, fsqrt.d, 1, 0x3ffc5bf8, 0x916f587b, 0x400921fb, 0x53c8d4f1
+    TEST_FP_OP1_D          3, fsqrt.d, 0, 0x40590000, 0x00000000, 0x40c38800, 0x00000000
+    TEST_FP_OP1_D          4, fsqrt.d, 0, 0x40000000, 0x00000000, 0x40100000, 0x00000000
+    TEST_FP_OP1_D          5, fsqrt.d, 1, 0x3ff6a09e, 0x667f3bcd, 0x40000000, 0x00000000
+    TEST_FP_OP1_D          6, fsqrt.d, 16, 0x7ff80000, 0x00000000, 0xbff00000, 0x00000000
+    TEST_FP_OP1_D          7, fsqrt.d, 0, 0x80000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP1_D          8, fsqrt.d, 0, 0x00000000, 0x00000000, 0x00000000, 0x00000000
+    TEST_FP_OP1_D          9, fsqrt.d, 0, 0x7ff00000, 0x00000000, 0x7ff00000, 0x00000000
+    TEST_FP_OP1_D          10, fsqrt.d, 16, 0x7ff80000, 0x00000000, 0xfff00000, 0x00000000
+    TEST_FP_OP1_D          11, fsqrt.d, 0, 0x1e600000, 0x00000000, 0x00000000, 0x00000001
+    TEST_FP_OP1_D          12, fsqrt.d, 1, 0x402a2744, 0xce9674f5, 0x40656000, 0x00000000
+    TEST_FP_OP1_D          13, fsqrt.d, 0, 0x7ff80000, 0x00000000, 0x7ff80000, 0x00000000
+    TEST_FP_OP1_D          14, fsqrt.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000001
+
+    TEST_PASSFAIL
@@ -0,0 +1,18 @@
+# fsub.d: double-precision subtraction
+.include "../test.inc"
+
+    TEST_FP_OP2_D          2, fsub.d, 0, 0x3ff80000, 0x00000000, 0x40040000, 0x00000000, 0x3ff00000, 0x00000000
+    TEST_FP_OP2_D          3, fsub.d, 1, 0xc09350cc, 0xcccccccc, 0xc0934c66, 0x66666666, 0x3ff19999, 0x9999999a
+    TEST_FP_OP2_D          4, fsub.d, 0, 0xbfb99999, 0x9999999a, 0x3fb99999, 0x9999999a, 0x3fc99999, 0x9999999a
+    TEST_FP_OP2_D          5, fsub.d, 0, 0x3fefffff, 0xffffffff, 0x3ff00000, 0x00000000, 0x3ca00000, 0x00000000
+    TEST_FP_OP2_D          6, fsub.d, 1, 0x3ff00000, 0x00000000, 0x3ff00000, 0x00000000, 0xbca00000, 0x00000000
+    TEST_FP_OP2_D          7, fsub.d, 0, 0x00000000, 0x00000000, 0x7fefffff, 0xffffffff, 0x7fefffff, 0xffffffff
+    TEST_FP_OP2_D          8, fsub.d, 0, 0x7ff00000, 0x00000000, 0x7ff00000, 0x00000000, 0xfff00000, 0x00000000
+    TEST_FP_OP2_D          9, fsub.d, 0, 0x00000000, 0x00000000, 0x00000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP2_D          10, fsub.d, 0, 0x00000000, 0x00000000, 0x80000000, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP2_D          11, fsub.d, 0, 0x40000000, 0x00000000, 0x3ff00000, 0x00000000, 0xbff00000, 0x00000000
+    TEST_FP_OP2_D          12, fsub.d, 0, 0x00000000, 0x00000002, 0x00000000, 0x00000001, 0x80000000, 0x00000001
+    TEST_FP_OP2_D          13, fsub.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000000, 0x7ff00000, 0x00000000
+    TEST_FP_OP2_D          14, fsub.d, 16, 0x7ff80000, 0x00000000, 0x7ff00000, 0x00000001, 0x3ff00000, 0x00000000
+
+    TEST_PASSFAIL
@@ -0,0 +1,21 @@
+# fadd.s: single-precision addition
+.include "../test.inc"
+
+    TEST_FP_OP2_S          2, fadd.s, 0, 0x40600000, 0x40200000, 0x3f800000
+    TEST_FP_OP2_S          3, fadd.s, 1, 0xc49a4000, 0xc49a6333, 0x3f8ccccd
+    TEST_FP_OP2_S          4, fadd.s, 1, 0x3f800000, 0x3f800000, 0x33800000
+    TEST_FP_OP2_S          5, fadd.s, 0, 0x3f7fffff, 0x3f800000, 0xb3800000
+    TEST_FP_OP2_S          6, fadd.s, 5, 0x7f800000, 0x7f7fffff, 0x7f7fffff
+    TEST_FP_OP2_S          7, fadd.s, 5, 0xff800000, 0xff7fffff, 0xff7fffff
+    TEST_FP_OP2_S          8, fadd.s, 16, 0x7fc00000, 0x7f800000, 0xff800000
+    TEST_FP_OP2_S          9, fadd.s, 0, 0x7f800000, 0x7f800000, 0x3f800000
+    TEST_FP_OP2_S          10, fadd.s, 0, 0x00000000, 0x00000000, 0x80000000
+    TEST_FP_OP2_S          11, fadd.s, 0, 0x80000000, 0x80000000, 0x80000000
+    TEST_FP_OP2_S          12, fadd.s, 0, 0x00000000, 0x3f800000, 0xbf800000
+    TEST_FP_OP2_S          13, fadd.s, 0, 0x00400000, 0x00800000, 0x80400000
+    TEST_FP_OP2_S          14, fadd.s, 0, 0x00000000, 0x00000001, 0x80000001
+    TEST_FP_OP2_S          15, fadd.s, 0, 0x7fc00000, 0x7fc00000, 0x3f800000
+    TEST_FP_OP2_S          16, fadd.s, 16, 0x7fc00000, 0x7f800001, 0x3f800000
+    TEST_FP_OP2_S          17, fadd.s, 16, 0x7fc00000, 0x3f800000, 0x7f800001
+
+    TEST_PASSFAIL
@@ -0,0 +1,18 @@
+# fclass.s: single-precision classification
+.include "../test.inc"
+
+    TEST_FP_INT_OP_S       2, fclass.s, 0, 1, 0xff800000
+    TEST_FP_INT_OP_S       3, fclass.s, 0, 2, 0xbf800000
+    TEST_FP_INT_OP_S       4, fclass.s, 0, 4, 0x80000001
+    TEST_FP_INT_OP_S       5, fclass.s, 0, 8, 0x80000000
+    TEST_FP_INT_OP_S       6, fclass.s, 0, 16, 0x00000000
+    TEST_FP_INT_OP_S       7, fclass.s, 0, 32, 0x00000001
+    TEST_FP_INT_OP_S       8, fclass.s, 0, 64, 0x3f800000
+    TEST_FP_INT_OP_S       9, fclass.s, 0, 128, 0x7f800000
+    TEST_FP_INT_OP_S       10, fclass.s, 0, 0x00000100, 0x7f800001
+    TEST_FP_INT_OP_S       11, fclass.s, 0, 0x00000200, 0x7fc00000
+    TEST_FP_INT_OP_S       12, fclass.s, 0, 64, 0x7f7fffff
+    TEST_FP_INT_OP_S       13, fclass.s, 0, 4, 0x807fffff
+    TEST_FP_INT_OP_S       14, fclass.s, 0, 0x00000200, 0xffc00000
+
+    TEST_PASSFAIL
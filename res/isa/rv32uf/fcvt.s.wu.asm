@@ -0,0 +1,16 @@
+# fcvt.s.wu: conversion of an unsigned word to a single
+.include "../test.inc"
+
+    TEST_INT_FP_OP_S       2, fcvt.s.wu, 0, 0x00000000, 0x00000000
+    TEST_INT_FP_OP_S       3, fcvt.s.wu, 0, 0x3f800000, 0x00000001
+    TEST_INT_FP_OP_S       4, fcvt.s.wu, 1, 0x4f800000, 0xffffffff
+    TEST_INT_FP_OP_S       5, fcvt.s.wu, 0, 0x40000000, 0x00000002
+    TEST_INT_FP_OP_S       6, fcvt.s.wu, 1, 0x4f800000, 0xfffffffe
+    TEST_INT_FP_OP_S       7, fcvt.s.wu, 1, 0x4f000000, 0x7fffffff
+    TEST_INT_FP_OP_S       8, fcvt.s.wu, 0, 0x4f000000, 0x80000000
+    TEST_INT_FP_OP_S       9, fcvt.s.wu, 1, 0x4b800000, 0x01000001
+    TEST_INT_FP_OP_S       10, fcvt.s.wu, 1, 0x4b800002, 0x01000003
+    TEST_INT_FP_OP_S       11, fcvt.s.wu, 1, 0x4f7f0000, 0xff000001
+    TEST_INT_FP_OP_S       12, fcvt.s.wu, 1, 0x4f000000, 0x7fffffc0
+
+    TEST_PASSFAIL
@@ -0,0 +1,35 @@
+# fcvt.w.s: conversion of a single to a signed word, saturating on overflow
+.include "../test.inc"
+
+    TEST_FP_INT_OP_S       2, fcvt.w.s, 0, 1, 0x3f800000
+    TEST_FP_INT_OP_S       3, fcvt.w.s, 0, 0xffffffff, 0xbf800000
+    TEST_FP_INT_OP_S       4, fcvt.w.s, 1, 1, 0x3f8ccccd
+    TEST_FP_INT_OP_S       5, fcvt.w.s, 1, 0xffffffff, 0xbf666666
+    TEST_FP_INT_OP_S       6, fcvt.w.s, 1, 2, 0x3fc00000
+    TEST_FP_INT_OP_S       7, fcvt.w.s, 1, 2, 0x40200000
+    TEST_FP_INT_OP_S       8, fcvt.w.s, 1, 0xfffffffe, 0xc0200000
+    TEST_FP_INT_OP_S       9, fcvt.w.s, 0, 0x7fffff80, 0x4effffff
+    TEST_FP_INT_OP_S       10, fcvt.w.s, 16, 0x7fffffff, 0x4f000000
+    TEST_FP_INT_OP_S       11, fcvt.w.s, 0, 0x80000000, 0xcf000000
+    TEST_FP_INT_OP_S       12, fcvt.w.s, 16, 0x80000000, 0xcf000001
+    TEST_FP_INT_OP_S       13, fcvt.w.s, 16, 0x7fffffff, 0x4f7fffff
+    TEST_FP_INT_OP_S       14, fcvt.w.s, 16, 0x7fffffff, 0x4f800000
+    TEST_FP_INT_OP_S       15, fcvt.w.s, 16, 0x7fffffff, 0x7f800000
+    TEST_FP_INT_OP_S       16, fcvt.w.s, 16, 0x80000000, 0xff800000
+    TEST_FP_INT_OP_S       17, fcvt.w.s, 16, 0x7fffffff, 0x7f800001
+    TEST_FP_INT_OP_S       18, fcvt.w.s, 1, 0, 0xbecccccd
+
+    # Rounding toward zero, from the dynamic rounding mode of frm
+    csrwi frm, 1
+    TEST_FP_INT_OP_S       19, fcvt.w.s, 1, 1, 0x3ff33333
+    TEST_FP_INT_OP_S       20, fcvt.w.s, 1, 0, 0xbf666666
+    csrwi frm, 0
+
+    # Explicit rounding mode
+    li gp, 21
+    li t0, 0x4039999a
+    fmv.w.x fa0, t0
+    fcvt.w.s a4, fa0, rtz
+    TEST_CHECK a4, 2
+
+    TEST_PASSFAIL
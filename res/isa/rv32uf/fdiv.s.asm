@@ -0,0 +1,19 @@
+# fdiv.s: single-precision division
+.include "../test.inc"
+
+    TEST_FP_OP2_S          2, fdiv.s, 1, 0x3f93eee0, 0x40490fdb, 0x402df854
+    TEST_FP_OP2_S          3, fdiv.s, 1, 0xbf7fc5a2, 0xc49a4000, 0x449a6333
+    TEST_FP_OP2_S          4, fdiv.s, 0, 0x40600000, 0x40e00000, 0x40000000
+    TEST_FP_OP2_S          5, fdiv.s, 1, 0x3eaaaaab, 0x3f800000, 0x40400000
+    TEST_FP_OP2_S          6, fdiv.s, 8, 0x7f800000, 0x3f800000, 0x00000000
+    TEST_FP_OP2_S          7, fdiv.s, 8, 0xff800000, 0xbf800000, 0x00000000
+    TEST_FP_OP2_S          8, fdiv.s, 16, 0x7fc00000, 0x00000000, 0x00000000
+    TEST_FP_OP2_S          9, fdiv.s, 16, 0x7fc00000, 0x7f800000, 0x7f800000
+    TEST_FP_OP2_S          10, fdiv.s, 0, 0x00000000, 0x3f800000, 0x7f800000
+    TEST_FP_OP2_S          11, fdiv.s, 0, 0xff800000, 0x7f800000, 0xbf800000
+    TEST_FP_OP2_S          12, fdiv.s, 0, 0x00200000, 0x00800000, 0x40800000
+    TEST_FP_OP2_S          13, fdiv.s, 5, 0x7f800000, 0x7f7fffff, 0x3f000000
+    TEST_FP_OP2_S          14, fdiv.s, 0, 0x7fc00000, 0x7fc00000, 0x3f800000
+    TEST_FP_OP2_S          15, fdiv.s, 16, 0x7fc00000, 0x7f800001, 0x3f800000
+
+    TEST_PASSFAIL
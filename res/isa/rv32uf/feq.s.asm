@@ -0,0 +1,18 @@
+# feq.s: single-precision quiet equality, raising NV on signaling NaNs only
+.include "../test.inc"
+
+    TEST_FP_CMP_OP_S       2, feq.s, 0, 1, 0xbfae147b, 0xbfae147b
+    TEST_FP_CMP_OP_S       3, feq.s, 0, 0, 0xbfaf5c29, 0xbfae147b
+    TEST_FP_CMP_OP_S       4, feq.s, 0, 0, 0xbfae147b, 0xbfaf5c29
+    TEST_FP_CMP_OP_S       5, feq.s, 0, 1, 0x00000000, 0x80000000
+    TEST_FP_CMP_OP_S       6, feq.s, 0, 1, 0x80000000, 0x00000000
+    TEST_FP_CMP_OP_S       7, feq.s, 0, 0, 0x7fc00000, 0x3f800000
+    TEST_FP_CMP_OP_S       8, feq.s, 0, 0, 0x3f800000, 0x7fc00000
+    TEST_FP_CMP_OP_S       9, feq.s, 16, 0, 0x7f800001, 0x3f800000
+    TEST_FP_CMP_OP_S       10, feq.s, 0, 0, 0x7fc00000, 0x7fc00000
+    TEST_FP_CMP_OP_S       11, feq.s, 0, 0, 0xff800000, 0x7f800000
+    TEST_FP_CMP_OP_S       12, feq.s, 0, 1, 0x7f800000, 0x7f800000
+    TEST_FP_CMP_OP_S       13, feq.s, 0, 0, 0x00000001, 0x00000000
+    TEST_FP_CMP_OP_S       14, feq.s, 0, 0, 0x80000001, 0x00000001
+
+    TEST_PASSFAIL
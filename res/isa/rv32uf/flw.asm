@@ -0,0 +1,49 @@
+# flw: load of a single, NaN-boxed
+.include "../test.inc"
+
+    li gp, 2
+    la a1, tdat
+    flw fa0, 4(a1)
+    fmv.x.w a4, fa0
+    TEST_CHECK a4, 0x40000000
+
+    li gp, 3
+    la a1, tdat4
+    flw fa0, -12(a1)
+    fmv.x.w a4, fa0
+    TEST_CHECK a4, 0x3f800000
+
+    # A signaling NaN is loaded untouched
+    li gp, 4
+    la a1, tdat
+    flw fa0, 8(a1)
+    fmv.x.w a4, fa0
+    TEST_CHECK a4, 0x7f800001
+
+    # The single is NaN-boxed
+    li gp, 5
+    flw fa0, 12(a1)
+    fsd fa0, 16(a1)
+    lw a4, 16(a1)
+    TEST_CHECK a4, 0x40400000
+    lw a4, 20(a1)
+    TEST_CHECK a4, 0xffffffff
+
+    # Load consumed right away
+    li gp, 6
+    flw fa0, 0(a1)
+    fadd.s fa1, fa0, fa0
+    fmv.x.w a4, fa1
+    TEST_CHECK a4, 0x40000000
+
+    TEST_PASSFAIL
+
+.data
+    .align 3
+tdat:
+    .word 0x3f800000
+    .word 0x40000000
+    .word 0x7f800001
+tdat4:
+    .word 0x40400000
+    .word 0, 0
@@ -0,0 +1,18 @@
+# fmadd.s: single-precision fused multiply-add, rounded once
+.include "../test.inc"
+
+    TEST_FP_OP3_S          2, fmadd.s, 0, 0x40600000, 0x3f800000, 0x40200000, 0x3f800000
+    TEST_FP_OP3_S          3, fmadd.s, 1, 0x449a8666, 0xbf800000, 0xc49a6333, 0x3f8ccccd
+    TEST_FP_OP3_S          4, fmadd.s, 0, 0xc1400000, 0x40000000, 0xc0a00000, 0xc0000000
+    TEST_FP_OP3_S          5, fmadd.s, 0, 0x3a000400, 0x3f800800, 0x3f800800, 0xbf800000
+    TEST_FP_OP3_S          6, fmadd.s, 0, 0x00000000, 0x3f800000, 0x00000000, 0x00000000
+    TEST_FP_OP3_S          7, fmadd.s, 0, 0x00000000, 0x3f800000, 0x00000000, 0x80000000
+    TEST_FP_OP3_S          8, fmadd.s, 0, 0x80000000, 0xbf800000, 0x00000000, 0x80000000
+    TEST_FP_OP3_S          9, fmadd.s, 16, 0x7fc00000, 0x7f800000, 0x00000000, 0x3f800000
+    TEST_FP_OP3_S          10, fmadd.s, 16, 0x7fc00000, 0x7f800000, 0x3f800000, 0xff800000
+    TEST_FP_OP3_S          11, fmadd.s, 0, 0x7f7fffff, 0x7f7fffff, 0x40000000, 0xff7fffff
+    TEST_FP_OP3_S          12, fmadd.s, 0, 0x7fc00000, 0x3f800000, 0x3f800000, 0x7fc00000
+    TEST_FP_OP3_S          13, fmadd.s, 16, 0x7fc00000, 0x3f800000, 0x3f800000, 0x7f800001
+    TEST_FP_OP3_S          14, fmadd.s, 1, 0x3f800000, 0x0d800000, 0x0d800000, 0x3f800000
+
+    TEST_PASSFAIL
@@ -0,0 +1,19 @@
+# fmax.s: single-precision maximum, -0 being less than +0
+.include "../test.inc"
+
+    TEST_FP_OP2_S          2, fmax.s, 0, 0x40200000, 0x40200000, 0x3f800000
+    TEST_FP_OP2_S          3, fmax.s, 0, 0x3f8ccccd, 0xc49a6333, 0x3f8ccccd
+    TEST_FP_OP2_S          4, fmax.s, 0, 0x3f8ccccd, 0x3f8ccccd, 0xc49a6333
+    TEST_FP_OP2_S          5, fmax.s, 0, 0xc49a6333, 0x7fc00000, 0xc49a6333
+    TEST_FP_OP2_S          6, fmax.s, 0, 0x40490fdb, 0x40490fdb, 0x322bcc77
+    TEST_FP_OP2_S          7, fmax.s, 0, 0xbf800000, 0xc0000000, 0xbf800000
+    TEST_FP_OP2_S          8, fmax.s, 0, 0x00000000, 0x00000000, 0x80000000
+    TEST_FP_OP2_S          9, fmax.s, 0, 0x00000000, 0x80000000, 0x00000000
+    TEST_FP_OP2_S          10, fmax.s, 0, 0x7fc00000, 0x7fc00000, 0x7fc00000
+    TEST_FP_OP2_S          11, fmax.s, 16, 0x3f800000, 0x7f800001, 0x3f800000
+    TEST_FP_OP2_S          12, fmax.s, 16, 0x3f800000, 0x3f800000, 0x7f800001
+    TEST_FP_OP2_S          13, fmax.s, 0, 0x7f800000, 0x7f800000, 0x7f7fffff
+    TEST_FP_OP2_S          14, fmax.s, 0, 0xff7fffff, 0xff800000, 0xff7fffff
+    TEST_FP_OP2_S          15, fmax.s, 0, 0x00000001, 0x00000001, 0x00000000
+
+    TEST_PASSFAIL
@@ -0,0 +1,18 @@
+# fmsub.s: single-precision fused multiply-subtract, rounded once
+.include "../test.inc"
+
+    TEST_FP_OP3_S          2, fmsub.s, 0, 0x3fc00000, 0x3f800000, 0x40200000, 0x3f800000
+    TEST_FP_OP3_S          3, fmsub.s, 1, 0x449a4000, 0xbf800000, 0xc49a6333, 0x3f8ccccd
+    TEST_FP_OP3_S          4, fmsub.s, 0, 0xc1000000, 0x40000000, 0xc0a00000, 0xc0000000
+    TEST_FP_OP3_S          5, fmsub.s, 1, 0x40000800, 0x3f800800, 0x3f800800, 0xbf800000
+    TEST_FP_OP3_S          6, fmsub.s, 0, 0x00000000, 0x3f800000, 0x00000000, 0x00000000
+    TEST_FP_OP3_S          7, fmsub.s, 0, 0x00000000, 0x3f800000, 0x00000000, 0x80000000
+    TEST_FP_OP3_S          8, fmsub.s, 0, 0x00000000, 0xbf800000, 0x00000000, 0x80000000
+    TEST_FP_OP3_S          9, fmsub.s, 16, 0x7fc00000, 0x7f800000, 0x00000000, 0x3f800000
+    TEST_FP_OP3_S          10, fmsub.s, 0, 0x7f800000, 0x7f800000, 0x3f800000, 0xff800000
+    TEST_FP_OP3_S          11, fmsub.s, 5, 0x7f800000, 0x7f7fffff, 0x40000000, 0xff7fffff
+    TEST_FP_OP3_S          12, fmsub.s, 0, 0x7fc00000, 0x3f800000, 0x3f800000, 0x7fc00000
+    TEST_FP_OP3_S          13, fmsub.s, 16, 0x7fc00000, 0x3f800000, 0x3f800000, 0x7f800001
+    TEST_FP_OP3_S          14, fmsub.s, 1, 0xbf800000, 0x0d800000, 0x0d800000, 0x3f800000
+
+    TEST_PASSFAIL
@@ -0,0 +1,19 @@
+# fmul.s: single-precision multiplication
+.include "../test.inc"
+
+    TEST_FP_OP2_S          2, fmul.s, 0, 0xc0200000, 0x40200000, 0xbf800000
+    TEST_FP_OP2_S          3, fmul.s, 1, 0x44a9d385, 0xc49a6333, 0xbf8ccccd
+    TEST_FP_OP2_S          4, fmul.s, 1, 0x3306ee2d, 0x40490fdb, 0x322bcc77
+    TEST_FP_OP2_S          5, fmul.s, 5, 0x7f800000, 0x7f7fffff, 0x40000000
+    TEST_FP_OP2_S          6, fmul.s, 5, 0xff800000, 0xff7fffff, 0x40000000
+    TEST_FP_OP2_S          7, fmul.s, 16, 0x7fc00000, 0x7f800000, 0x00000000
+    TEST_FP_OP2_S          8, fmul.s, 0, 0x80000000, 0x80000000, 0x3f800000
+    TEST_FP_OP2_S          9, fmul.s, 0, 0x00400000, 0x00800000, 0x3f000000
+    TEST_FP_OP2_S          10, fmul.s, 3, 0x00000000, 0x00000001, 0x3f000000
+    TEST_FP_OP2_S          11, fmul.s, 0, 0x00600000, 0x00c00000, 0x3f000000
+    TEST_FP_OP2_S          12, fmul.s, 0, 0xff800000, 0x7f800000, 0xbf800000
+    TEST_FP_OP2_S          13, fmul.s, 0, 0x7fc00000, 0x7fc00000, 0x3f800000
+    TEST_FP_OP2_S          14, fmul.s, 16, 0x7fc00000, 0x7f800001, 0x3f800000
+    TEST_FP_OP2_S          15, fmul.s, 1, 0x3f801000, 0x3f800800, 0x3f800800
+
+    TEST_PASSFAIL
@@ -0,0 +1,27 @@
+# fmv.w.x: raw move of an integer register to a single, NaNs untouched
+.include "../test.inc"
+
+    TEST_INT_FP_OP_S       2, fmv.w.x, 0, 0x3f800000, 0x3f800000
+    TEST_INT_FP_OP_S       3, fmv.w.x, 0, 0x7f800001, 0x7f800001
+    TEST_INT_FP_OP_S       4, fmv.w.x, 0, 0xffc00001, 0xffc00001
+    TEST_INT_FP_OP_S       5, fmv.w.x, 0, 0x80000000, 0x80000000
+    TEST_INT_FP_OP_S       6, fmv.w.x, 0, 0x12345678, 0x12345678
+    TEST_INT_FP_OP_S       7, fmv.w.x, 0, 0x00000000, 0x00000000
+
+    # The single is NaN-boxed
+    li gp, 8
+    la t0, fdat
+    li t1, 0x40400000
+    fmv.w.x fa3, t1
+    fsd fa3, 0(t0)
+    lw a4, 4(t0)
+    TEST_CHECK a4, 0xffffffff
+    lw a4, 0(t0)
+    TEST_CHECK a4, 0x40400000
+
+    TEST_PASSFAIL
+
+.data
+    .align 3
+fdat:
+    .word 0, 0
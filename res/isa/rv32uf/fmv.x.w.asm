@@ -0,0 +1,18 @@
+# fmv.x.w: raw move of a single to an integer register, NaNs untouched
+.include "../test.inc"
+
+    TEST_FP_INT_OP_S       2, fmv.x.w, 0, 0x3f800000, 0x3f800000
+    TEST_FP_INT_OP_S       3, fmv.x.w, 0, 0x7f800001, 0x7f800001
+    TEST_FP_INT_OP_S       4, fmv.x.w, 0, 0xffc00001, 0xffc00001
+    TEST_FP_INT_OP_S       5, fmv.x.w, 0, 0x80000000, 0x80000000
+    TEST_FP_INT_OP_S       6, fmv.x.w, 0, 0x12345678, 0x12345678
+    TEST_FP_INT_OP_S       7, fmv.x.w, 0, 0, 0x00000000
+
+    # The NaN-boxing is dropped
+    li gp, 8
+    li t0, 1
+    fcvt.d.w fa0, t0
+    fmv.x.w a4, fa0
+    TEST_CHECK a4, 0
+
+    TEST_PASSFAIL
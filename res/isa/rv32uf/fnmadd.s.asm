@@ -0,0 +1,18 @@
+# fnmadd.s: single-precision negated fused multiply-add, rounded once
+.include "../test.inc"
+
+    TEST_FP_OP3_S          2, fnmadd.s, 0, 0xc0600000, 0x3f800000, 0x40200000, 0x3f800000
+    TEST_FP_OP3_S          3, fnmadd.s, 1, 0xc49a8666, 0xbf800000, 0xc49a6333, 0x3f8ccccd
+    TEST_FP_OP3_S          4, fnmadd.s, 0, 0x41400000, 0x40000000, 0xc0a00000, 0xc0000000
+    TEST_FP_OP3_S          5, fnmadd.s, 0, 0xba000400, 0x3f800800, 0x3f800800, 0xbf800000
+    TEST_FP_OP3_S          6, fnmadd.s, 0, 0x80000000, 0x3f800000, 0x00000000, 0x00000000
+    TEST_FP_OP3_S          7, fnmadd.s, 0, 0x00000000, 0x3f800000, 0x00000000, 0x80000000
+    TEST_FP_OP3_S          8, fnmadd.s, 0, 0x00000000, 0xbf800000, 0x00000000, 0x80000000
+    TEST_FP_OP3_S          9, fnmadd.s, 16, 0x7fc00000, 0x7f800000, 0x00000000, 0x3f800000
+    TEST_FP_OP3_S          10, fnmadd.s, 16, 0x7fc00000, 0x7f800000, 0x3f800000, 0xff800000
+    TEST_FP_OP3_S          11, fnmadd.s, 0, 0xff7fffff, 0x7f7fffff, 0x40000000, 0xff7fffff
+    TEST_FP_OP3_S          12, fnmadd.s, 0, 0x7fc00000, 0x3f800000, 0x3f800000, 0x7fc00000
+    TEST_FP_OP3_S          13, fnmadd.s, 16, 0x7fc00000, 0x3f800000, 0x3f800000, 0x7f800001
+    TEST_FP_OP3_S          14, fnmadd.s, 1, 0xbf800000, 0x0d800000, 0x0d800000, 0x3f800000
+
+    TEST_PASSFAIL
@@ -0,0 +1,25 @@
+# fsgnjx.s: single-precision sign injection of the sign of rs1 xor the sign of rs2, on the raw bits, NaNs included
+.include "../test.inc"
+
+    TEST_FP_OP2_S          2, fsgnjx.s, 0, 0xbf800000, 0x3f800000, 0xbf800000
+    TEST_FP_OP2_S          3, fsgnjx.s, 0, 0xbf800000, 0xbf800000, 0x3f800000
+    TEST_FP_OP2_S          4, fsgnjx.s, 0, 0x40400000, 0xc0400000, 0xc0a00000
+    TEST_FP_OP2_S          5, fsgnjx.s, 0, 0x40e00000, 0x40e00000, 0x40e00000
+    TEST_FP_OP2_S          6, fsgnjx.s, 0, 0x80000000, 0x00000000, 0x80000000
+    TEST_FP_OP2_S          7, fsgnjx.s, 0, 0x80000000, 0x80000000, 0x00000000
+    TEST_FP_OP2_S          8, fsgnjx.s, 0, 0xff800001, 0x7f800001, 0xbf800000
+    TEST_FP_OP2_S          9, fsgnjx.s, 0, 0xffc00001, 0xffc00001, 0x3f800000
+    TEST_FP_OP2_S          10, fsgnjx.s, 0, 0xff800000, 0x7f800000, 0x80000000
+    TEST_FP_OP2_S          11, fsgnjx.s, 0, 0x7f800000, 0xff800000, 0xff800000
+
+    # An operand that isn't NaN-boxed reads as the canonical NaN
+    li gp, 12
+    li t0, 1
+    fcvt.d.w fa0, t0
+    li t0, 0x3f800000
+    fmv.w.x fa1, t0
+    fsgnjx.s fa3, fa0, fa1
+    fmv.x.w a4, fa3
+    TEST_CHECK a4, 0x7fc00000
+
+    TEST_PASSFAIL
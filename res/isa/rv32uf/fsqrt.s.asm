@@ -0,0 +1,18 @@
+# fsqrt.s: single-precision square root
+.include "../test.inc"
+
+    TEST_FP_OP1_S          2, fsqrt.s, 1, 0x3fe2dfc5, 0x40490fdb
+    TEST_FP_OP1_S          3, fsqrt.s, 0, 0x42c80000, 0x461c4000
+    TEST_FP_OP1_S          4, fsqrt.s, 0, 0x40000000, 0x40800000
+    TEST_FP_OP1_S          5, fsqrt.s, 1, 0x3fb504f3, 0x40000000
+    TEST_FP_OP1_S          6, fsqrt.s, 16, 0x7fc00000, 0xbf800000
+    TEST_FP_OP1_S          7, fsqrt.s, 0, 0x80000000, 0x80000000
+    TEST_FP_OP1_S          8, fsqrt.s, 0, 0x00000000, 0x00000000
+    TEST_FP_OP1_S          9, fsqrt.s, 0, 0x7f800000, 0x7f800000
+    TEST_FP_OP1_S          10, fsqrt.s, 16, 0x7fc00000, 0xff800000
+    TEST_FP_OP1_S          11, fsqrt.s, 1, 0x1a3504f3, 0x00000001
+    TEST_FP_OP1_S          12, fsqrt.s, 1, 0x41513a26, 0x432b0000
+    TEST_FP_OP1_S          13, fsqrt.s, 0, 0x7fc00000, 0x7fc00000
+    TEST_FP_OP1_S          14, fsqrt.s, 16, 0x7fc00000, 0x7f800001
+
+    TEST_PASSFAIL
@@ -0,0 +1,21 @@
+# fsub.s: single-precision subtraction
+.include "../test.inc"
+
+    TEST_FP_OP2_S          2, fsub.s, 0, 0x3fc00000, 0x40200000, 0x3f800000
+    TEST_FP_OP2_S          3, fsub.s, 1, 0xc49a8666, 0xc49a6333, 0x3f8ccccd
+    TEST_FP_OP2_S          4, fsub.s, 0, 0x3f7fffff, 0x3f800000, 0x33800000
+    TEST_FP_OP2_S          5, fsub.s, 1, 0x3f800000, 0x3f800000, 0xb3800000
+    TEST_FP_OP2_S          6, fsub.s, 0, 0x00000000, 0x7f7fffff, 0x7f7fffff
+    TEST_FP_OP2_S          7, fsub.s, 0, 0x00000000, 0xff7fffff, 0xff7fffff
+    TEST_FP_OP2_S          8, fsub.s, 0, 0x7f800000, 0x7f800000, 0xff800000
+    TEST_FP_OP2_S          9, fsub.s, 0, 0x7f800000, 0x7f800000, 0x3f800000
+    TEST_FP_OP2_S          10, fsub.s, 0, 0x00000000, 0x00000000, 0x80000000
+    TEST_FP_OP2_S          11, fsub.s, 0, 0x00000000, 0x80000000, 0x80000000
+    TEST_FP_OP2_S          12, fsub.s, 0, 0x40000000, 0x3f800000, 0xbf800000
+    TEST_FP_OP2_S          13, fsub.s, 0, 0x00000002, 0x00000001, 0x80000001
+    TEST_FP_OP2_S          14, fsub.s, 0, 0x7fc00000, 0x7fc00000, 0x3f800000
+    TEST_FP_OP2_S          15, fsub.s, 16, 0x7fc00000, 0x7f800000, 0x7f800000
+    TEST_FP_OP2_S          16, fsub.s, 0, 0x00000000, 0x3f800000, 0x3f800000
+    TEST_FP_OP2_S          17, fsub.s, 16, 0x7fc00000, 0x7f800001, 0x3f800000
+
+    TEST_PASSFAIL
@@ -0,0 +1,56 @@
+# fsw: store of a single, its low 32 bits
+.include "../test.inc"
+
+    li gp, 2
+    la a1, tdat
+    li t0, 0x40000000
+    fmv.w.x fa0, t0
+    fsw fa0, 4(a1)
+    lw a4, 4(a1)
+    TEST_CHECK a4, 0x40000000
+
+    li gp, 3
+    la a1, tdat4
+    li t0, 0xbf800000
+    fmv.w.x fa0, t0
+    fsw fa0, -12(a1)
+    lw a4, -12(a1)
+    TEST_CHECK a4, 0xbf800000
+
+    # A signaling NaN is stored untouched
+    li gp, 4
+    la a1, tdat
+    li t0, 0x7f800001
+    fmv.w.x fa0, t0
+    fsw fa0, 8(a1)
+    lw a4, 8(a1)
+    TEST_CHECK a4, 0x7f800001
+
+    # Only the low 32 bits of a double are stored, the next word is untouched
+    li gp, 5
+    li t0, 7
+    fcvt.d.w fa0, t0
+    fsw fa0, 12(a1)
+    lw a4, 12(a1)
+    TEST_CHECK a4, 0
+    lw a4, 16(a1)
+    TEST_CHECK a4, 0x0000abcd
+
+    # Store followed by a load of the same address
+    li gp, 6
+    li t0, 0x40400000
+    fmv.w.x fa0, t0
+    fsw fa0, 0(a1)
+    flw fa1, 0(a1)
+    fmv.x.w a4, fa1
+    TEST_CHECK a4, 0x40400000
+
+    TEST_PASSFAIL
+
+.data
+    .align 3
+tdat:
+    .word 0, 0, 0
+tdat4:
+    .word 0xffffffff
+    .word 0x0000abcd
@@ -0,0 +1,31 @@
+# add: addition, wrapping around on overflow
+.include "../test.inc"
+
+    TEST_RR_OP             2, add, 0, 0, 0
+    TEST_RR_OP             3, add, 2, 1, 1
+    TEST_RR_OP             4, add, 10, 3, 7
+    TEST_RR_OP             5, add, 0xffff8000, 0, 0xffff8000
+    TEST_RR_OP             6, add, 0x80000000, 0x80000000, 0
+    TEST_RR_OP             7, add, 0x7fff8000, 0x80000000, 0xffff8000
+    TEST_RR_OP             8, add, 0x00007fff, 0, 0x00007fff
+    TEST_RR_OP             9, add, 0x7fffffff, 0x7fffffff, 0
+    TEST_RR_OP             10, add, 0x80007ffe, 0x7fffffff, 0x00007fff
+    TEST_RR_OP             11, add, 0x80007fff, 0x80000000, 0x00007fff
+    TEST_RR_OP             12, add, 0x7fff7fff, 0x7fffffff, 0xffff8000
+    TEST_RR_OP             13, add, 0xffffffff, 0, 0xffffffff
+    TEST_RR_OP             14, add, 0, 0xffffffff, 1
+    TEST_RR_OP             15, add, 0xfffffffe, 0xffffffff, 0xffffffff
+    TEST_RR_OP             16, add, 0x80000000, 1, 0x7fffffff
+    TEST_RR_OP             17, add, 0x80000000, 0x7fffffff, 1
+    TEST_RR_OP             18, add, 0x80000001, 0x80000000, 1
+    TEST_RR_OP             19, add, 0x7fffffff, 0x80000000, 0xffffffff
+
+    TEST_RR_SRC1_EQ_DEST   20, add, 24, 13, 11
+    TEST_RR_SRC2_EQ_DEST   21, add, 24, 13, 11
+    TEST_RR_SRC12_EQ_DEST  22, add, 26, 13
+    TEST_RR_DEST_BYPASS    23, add, 24, 13, 11
+    TEST_RR_ZEROSRC1       24, add, 0xfffffff1, 0xfffffff1
+    TEST_RR_ZEROSRC2       25, add, 0xfffffff1, 0xfffffff1
+    TEST_RR_ZERODEST       26, add, 16, 30
+
+    TEST_PASSFAIL
@@ -0,0 +1,25 @@
+# addi: addition of a sign-extended immediate, wrapping around on overflow
+.include "../test.inc"
+
+    TEST_IMM_OP            2, addi, 0, 0, 0
+    TEST_IMM_OP            3, addi, 2, 1, 1
+    TEST_IMM_OP            4, addi, 10, 3, 7
+    TEST_IMM_OP            5, addi, 0xfffff800, 0, -2048
+    TEST_IMM_OP            6, addi, 0x80000000, 0x80000000, 0
+    TEST_IMM_OP            7, addi, 0x7ffff800, 0x80000000, -2048
+    TEST_IMM_OP            8, addi, 0x000007ff, 0, 2047
+    TEST_IMM_OP            9, addi, 0x7fffffff, 0x7fffffff, 0
+    TEST_IMM_OP            10, addi, 0x800007fe, 0x7fffffff, 2047
+    TEST_IMM_OP            11, addi, 0x800007ff, 0x80000000, 2047
+    TEST_IMM_OP            12, addi, 0x7ffff7ff, 0x7fffffff, -2048
+    TEST_IMM_OP            13, addi, 0xffffffff, 0, -1
+    TEST_IMM_OP            14, addi, 0, 0xffffffff, 1
+    TEST_IMM_OP            15, addi, 0xfffffffe, 0xffffffff, -1
+    TEST_IMM_OP            16, addi, 0x80000000, 0x7fffffff, 1
+
+    TEST_IMM_SRC1_EQ_DEST  17, addi, 24, 13, 11
+    TEST_IMM_DEST_BYPASS   18, addi, 24, 13, 11
+    TEST_IMM_ZEROSRC1      19, addi, 0xffffff0f, -241
+    TEST_IMM_ZERODEST      20, addi, 33, 50
+
+    TEST_PASSFAIL
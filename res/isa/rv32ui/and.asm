@@ -0,0 +1,20 @@
+# and: bitwise and
+.include "../test.inc"
+
+    TEST_RR_OP             2, and, 0x0f000f00, 0xff00ff00, 0x0f0f0f0f
+    TEST_RR_OP             3, and, 0x00f000f0, 0x0ff00ff0, 0xf0f0f0f0
+    TEST_RR_OP             4, and, 0x000f000f, 0x00ff00ff, 0x0f0f0f0f
+    TEST_RR_OP             5, and, 0xf000f000, 0xf00ff00f, 0xf0f0f0f0
+    TEST_RR_OP             6, and, 0, 0xffffffff, 0
+    TEST_RR_OP             7, and, 0xffffffff, 0xffffffff, 0xffffffff
+    TEST_RR_OP             8, and, 0, 0x80000000, 0x7fffffff
+
+    TEST_RR_SRC1_EQ_DEST   9, and, 9, 13, 11
+    TEST_RR_SRC2_EQ_DEST   10, and, 9, 13, 11
+    TEST_RR_SRC12_EQ_DEST  11, and, 13, 13
+    TEST_RR_DEST_BYPASS    12, and, 9, 13, 11
+    TEST_RR_ZEROSRC1       13, and, 0, 0xfffffff1
+    TEST_RR_ZEROSRC2       14, and, 0, 0xfffffff1
+    TEST_RR_ZERODEST       15, and, 16, 30
+
+    TEST_PASSFAIL
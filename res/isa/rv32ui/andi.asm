@@ -0,0 +1,18 @@
+# andi: bitwise and with a sign-extended immediate
+.include "../test.inc"
+
+    TEST_IMM_OP            2, andi, 0xff00ff00, 0xff00ff00, -241
+    TEST_IMM_OP            3, andi, 240, 0x0ff00ff0, 240
+    TEST_IMM_OP            4, andi, 15, 0x00ff00ff, 1807
+    TEST_IMM_OP            5, andi, 0, 0xf00ff00f, 240
+    TEST_IMM_OP            6, andi, 0x12345678, 0x12345678, -1
+    TEST_IMM_OP            7, andi, 0x12345000, 0x12345678, -2048
+    TEST_IMM_OP            8, andi, 0, 0, -2048
+    TEST_IMM_OP            9, andi, 0x000007ff, 0xffffffff, 2047
+
+    TEST_IMM_SRC1_EQ_DEST  10, andi, 9, 13, 11
+    TEST_IMM_DEST_BYPASS   11, andi, 9, 13, 11
+    TEST_IMM_ZEROSRC1      12, andi, 0, -241
+    TEST_IMM_ZERODEST      13, andi, 33, 50
+
+    TEST_PASSFAIL
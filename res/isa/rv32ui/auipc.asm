@@ -0,0 +1,37 @@
+# auipc: addition of an upper immediate to the pc
+.include "../test.inc"
+
+    li gp, 2
+loop:
+    auipc a4, 0
+label2:
+    auipc a5, 0
+    sub a4, a5, a4
+    TEST_CHECK a4, 4
+
+    li gp, 3
+label3:
+    auipc a4, 1
+    la a5, label3
+    sub a4, a4, a5
+    TEST_CHECK a4, 4096
+
+    li gp, 4
+label4:
+    auipc a4, 0xfffff
+    la a5, label4
+    sub a4, a4, a5
+    TEST_CHECK a4, 0xfffff000
+
+    li gp, 5
+label5:
+    auipc a4, 0x80000
+    la a5, label5
+    sub a4, a4, a5
+    TEST_CHECK a4, 0x80000000
+
+    li gp, 6
+    auipc zero, 0x12345
+    bnez zero, fail
+
+    TEST_PASSFAIL
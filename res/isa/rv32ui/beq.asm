@@ -0,0 +1,38 @@
+# beq: branch if equal
+.include "../test.inc"
+
+    TEST_BR2_OP_TAKEN      2, beq, 0, 0
+    TEST_BR2_OP_TAKEN      3, beq, 1, 1
+    TEST_BR2_OP_TAKEN      4, beq, 0xffffffff, 0xffffffff
+    TEST_BR2_OP_TAKEN      5, beq, 0x80000000, 0x80000000
+
+    TEST_BR2_OP_NOTTAKEN   6, beq, 0, 1
+    TEST_BR2_OP_NOTTAKEN   7, beq, 1, 0
+    TEST_BR2_OP_NOTTAKEN   8, beq, 0xffffffff, 1
+    TEST_BR2_OP_NOTTAKEN   9, beq, 1, 0xffffffff
+    TEST_BR2_OP_NOTTAKEN   10, beq, 0xfffffffe, 0xffffffff
+    TEST_BR2_OP_NOTTAKEN   11, beq, 0xffffffff, 0xfffffffe
+    TEST_BR2_OP_NOTTAKEN   12, beq, 0x80000000, 0x7fffffff
+    TEST_BR2_OP_NOTTAKEN   13, beq, 0x7fffffff, 0x80000000
+    TEST_BR2_OP_NOTTAKEN   14, beq, 0x80000000, 0
+    TEST_BR2_OP_NOTTAKEN   15, beq, 0, 0x80000000
+
+    # Taken branch to the next instruction
+    li gp, 16
+    li a1, 1
+    li a2, 1
+    beq a1, a2, next
+next:
+
+    # Backward branch closing a loop, on an operand written right before
+    li gp, 17
+    li a5, 0
+    li a2, 3
+    li a6, 1
+loop:
+    addi a5, a5, 1
+    slti t1, a5, 3
+    beq t1, a6, loop
+    TEST_CHECK a5, 3
+
+    TEST_PASSFAIL
@@ -0,0 +1,11 @@
+# beqz: branch if equal to zero
+.include "../test.inc"
+
+    TEST_BR1_OP_TAKEN      2, beqz, 0
+
+    TEST_BR1_OP_NOTTAKEN   3, beqz, 1
+    TEST_BR1_OP_NOTTAKEN   4, beqz, 0xffffffff
+    TEST_BR1_OP_NOTTAKEN   5, beqz, 0x80000000
+    TEST_BR1_OP_NOTTAKEN   6, beqz, 0x7fffffff
+
+    TEST_PASSFAIL
@@ -0,0 +1,37 @@
+# bge: branch if greater than or equal, signed
+.include "../test.inc"
+
+    TEST_BR2_OP_TAKEN      2, bge, 0, 0
+    TEST_BR2_OP_TAKEN      3, bge, 1, 0
+    TEST_BR2_OP_TAKEN      4, bge, 1, 1
+    TEST_BR2_OP_TAKEN      5, bge, 0xffffffff, 0xffffffff
+    TEST_BR2_OP_TAKEN      6, bge, 1, 0xffffffff
+    TEST_BR2_OP_TAKEN      7, bge, 0xffffffff, 0xfffffffe
+    TEST_BR2_OP_TAKEN      8, bge, 0x7fffffff, 0x80000000
+    TEST_BR2_OP_TAKEN      9, bge, 0x80000000, 0x80000000
+    TEST_BR2_OP_TAKEN      10, bge, 0, 0x80000000
+
+    TEST_BR2_OP_NOTTAKEN   11, bge, 0, 1
+    TEST_BR2_OP_NOTTAKEN   12, bge, 0xffffffff, 1
+    TEST_BR2_OP_NOTTAKEN   13, bge, 0xfffffffe, 0xffffffff
+    TEST_BR2_OP_NOTTAKEN   14, bge, 0x80000000, 0x7fffffff
+    TEST_BR2_OP_NOTTAKEN   15, bge, 0x80000000, 0
+
+    # Taken branch to the next instruction
+    li gp, 16
+    li a1, 1
+    li a2, 0
+    bge a1, a2, next
+next:
+
+    # Backward branch closing a loop, on an operand written right before
+    li gp, 17
+    li a5, 0
+    li a2, 3
+    li a6, 1
+loop:
+    addi a5, a5, 1
+    bge a2, a5, loop
+    TEST_CHECK a5, 4
+
+    TEST_PASSFAIL
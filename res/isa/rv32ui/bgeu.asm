@@ -0,0 +1,37 @@
+# bgeu: branch if greater than or equal, unsigned
+.include "../test.inc"
+
+    TEST_BR2_OP_TAKEN      2, bgeu, 0, 0
+    TEST_BR2_OP_TAKEN      3, bgeu, 1, 0
+    TEST_BR2_OP_TAKEN      4, bgeu, 1, 1
+    TEST_BR2_OP_TAKEN      5, bgeu, 0xffffffff, 0xffffffff
+    TEST_BR2_OP_TAKEN      6, bgeu, 0xffffffff, 1
+    TEST_BR2_OP_TAKEN      7, bgeu, 0xffffffff, 0xfffffffe
+    TEST_BR2_OP_TAKEN      8, bgeu, 0x80000000, 0x7fffffff
+    TEST_BR2_OP_TAKEN      9, bgeu, 0x80000000, 0x80000000
+    TEST_BR2_OP_TAKEN      10, bgeu, 0x80000000, 0
+
+    TEST_BR2_OP_NOTTAKEN   11, bgeu, 0, 1
+    TEST_BR2_OP_NOTTAKEN   12, bgeu, 1, 0xffffffff
+    TEST_BR2_OP_NOTTAKEN   13, bgeu, 0xfffffffe, 0xffffffff
+    TEST_BR2_OP_NOTTAKEN   14, bgeu, 0x7fffffff, 0x80000000
+    TEST_BR2_OP_NOTTAKEN   15, bgeu, 0, 0x80000000
+
+    # Taken branch to the next instruction
+    li gp, 16
+    li a1, 1
+    li a2, 0
+    bgeu a1, a2, next
+next:
+
+    # Backward branch closing a loop, on an operand written right before
+    li gp, 17
+    li a5, 0
+    li a2, 3
+    li a6, 1
+loop:
+    addi a5, a5, 1
+    bgeu a2, a5, loop
+    TEST_CHECK a5, 4
+
+    TEST_PASSFAIL
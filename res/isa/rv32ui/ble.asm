@@ -0,0 +1,37 @@
+# ble: branch if less than or equal, signed
+.include "../test.inc"
+
+    TEST_BR2_OP_TAKEN      2, ble, 0, 0
+    TEST_BR2_OP_TAKEN      3, ble, 0, 1
+    TEST_BR2_OP_TAKEN      4, ble, 1, 1
+    TEST_BR2_OP_TAKEN      5, ble, 0xffffffff, 0xffffffff
+    TEST_BR2_OP_TAKEN      6, ble, 0xffffffff, 1
+    TEST_BR2_OP_TAKEN      7, ble, 0xfffffffe, 0xffffffff
+    TEST_BR2_OP_TAKEN      8, ble, 0x80000000, 0x7fffffff
+    TEST_BR2_OP_TAKEN      9, ble, 0x80000000, 0x80000000
+    TEST_BR2_OP_TAKEN      10, ble, 0x80000000, 0
+
+    TEST_BR2_OP_NOTTAKEN   11, ble, 1, 0
+    TEST_BR2_OP_NOTTAKEN   12, ble, 1, 0xffffffff
+    TEST_BR2_OP_NOTTAKEN   13, ble, 0xffffffff, 0xfffffffe
+    TEST_BR2_OP_NOTTAKEN   14, ble, 0x7fffffff, 0x80000000
+    TEST_BR2_OP_NOTTAKEN   15, ble, 0, 0x80000000
+
+    # Taken branch to the next instruction
+    li gp, 16
+    li a1, 0
+    li a2, 1
+    ble a1, a2, next
+next:
+
+    # Backward branch closing a loop, on an operand written right before
+    li gp, 17
+    li a5, 0
+    li a2, 3
+    li a6, 1
+loop:
+    addi a5, a5, 1
+    ble a5, a2, loop
+    TEST_CHECK a5, 4
+
+    TEST_PASSFAIL
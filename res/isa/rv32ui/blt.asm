@@ -0,0 +1,37 @@
+# blt: branch if less than, signed
+.include "../test.inc"
+
+    TEST_BR2_OP_TAKEN      2, blt, 0, 1
+    TEST_BR2_OP_TAKEN      3, blt, 0xffffffff, 1
+    TEST_BR2_OP_TAKEN      4, blt, 0xfffffffe, 0xffffffff
+    TEST_BR2_OP_TAKEN      5, blt, 0x80000000, 0x7fffffff
+    TEST_BR2_OP_TAKEN      6, blt, 0x80000000, 0
+
+    TEST_BR2_OP_NOTTAKEN   7, blt, 0, 0
+    TEST_BR2_OP_NOTTAKEN   8, blt, 1, 0
+    TEST_BR2_OP_NOTTAKEN   9, blt, 1, 1
+    TEST_BR2_OP_NOTTAKEN   10, blt, 0xffffffff, 0xffffffff
+    TEST_BR2_OP_NOTTAKEN   11, blt, 1, 0xffffffff
+    TEST_BR2_OP_NOTTAKEN   12, blt, 0xffffffff, 0xfffffffe
+    TEST_BR2_OP_NOTTAKEN   13, blt, 0x7fffffff, 0x80000000
+    TEST_BR2_OP_NOTTAKEN   14, blt, 0x80000000, 0x80000000
+    TEST_BR2_OP_NOTTAKEN   15, blt, 0, 0x80000000
+
+    # Taken branch to the next instruction
+    li gp, 16
+    li a1, 0xffffffff
+    li a2, 1
+    blt a1, a2, next
+next:
+
+    # Backward branch closing a loop, on an operand written right before
+    li gp, 17
+    li a5, 0
+    li a2, 3
+    li a6, 1
+loop:
+    addi a5, a5, 1
+    blt a5, a2, loop
+    TEST_CHECK a5, 3
+
+    TEST_PASSFAIL
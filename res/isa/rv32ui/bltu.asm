@@ -0,0 +1,37 @@
+# bltu: branch if less than, unsigned
+.include "../test.inc"
+
+    TEST_BR2_OP_TAKEN      2, bltu, 0, 1
+    TEST_BR2_OP_TAKEN      3, bltu, 1, 0xffffffff
+    TEST_BR2_OP_TAKEN      4, bltu, 0xfffffffe, 0xffffffff
+    TEST_BR2_OP_TAKEN      5, bltu, 0x7fffffff, 0x80000000
+    TEST_BR2_OP_TAKEN      6, bltu, 0, 0x80000000
+
+    TEST_BR2_OP_NOTTAKEN   7, bltu, 0, 0
+    TEST_BR2_OP_NOTTAKEN   8, bltu, 1, 0
+    TEST_BR2_OP_NOTTAKEN   9, bltu, 1, 1
+    TEST_BR2_OP_NOTTAKEN   10, bltu, 0xffffffff, 0xffffffff
+    TEST_BR2_OP_NOTTAKEN   11, bltu, 0xffffffff, 1
+    TEST_BR2_OP_NOTTAKEN   12, bltu, 0xffffffff, 0xfffffffe
+    TEST_BR2_OP_NOTTAKEN   13, bltu, 0x80000000, 0x7fffffff
+    TEST_BR2_OP_NOTTAKEN   14, bltu, 0x80000000, 0x80000000
+    TEST_BR2_OP_NOTTAKEN   15, bltu, 0x80000000, 0
+
+    # Taken branch to the next instruction
+    li gp, 16
+    li a1, 1
+    li a2, 0xffffffff
+    bltu a1, a2, next
+next:
+
+    # Backward branch closing a loop, on an operand written right before
+    li gp, 17
+    li a5, 0
+    li a2, 3
+    li a6, 1
+loop:
+    addi a5, a5, 1
+    bltu a5, a2, loop
+    TEST_CHECK a5, 3
+
+    TEST_PASSFAIL
@@ -0,0 +1,37 @@
+# bne: branch if not equal
+.include "../test.inc"
+
+    TEST_BR2_OP_TAKEN      2, bne, 0, 1
+    TEST_BR2_OP_TAKEN      3, bne, 1, 0
+    TEST_BR2_OP_TAKEN      4, bne, 0xffffffff, 1
+    TEST_BR2_OP_TAKEN      5, bne, 1, 0xffffffff
+    TEST_BR2_OP_TAKEN      6, bne, 0xfffffffe, 0xffffffff
+    TEST_BR2_OP_TAKEN      7, bne, 0xffffffff, 0xfffffffe
+    TEST_BR2_OP_TAKEN      8, bne, 0x80000000, 0x7fffffff
+    TEST_BR2_OP_TAKEN      9, bne, 0x7fffffff, 0x80000000
+    TEST_BR2_OP_TAKEN      10, bne, 0x80000000, 0
+    TEST_BR2_OP_TAKEN      11, bne, 0, 0x80000000
+
+    TEST_BR2_OP_NOTTAKEN   12, bne, 0, 0
+    TEST_BR2_OP_NOTTAKEN   13, bne, 1, 1
+    TEST_BR2_OP_NOTTAKEN   14, bne, 0xffffffff, 0xffffffff
+    TEST_BR2_OP_NOTTAKEN   15, bne, 0x80000000, 0x80000000
+
+    # Taken branch to the next instruction
+    li gp, 16
+    li a1, 1
+    li a2, 0
+    bne a1, a2, next
+next:
+
+    # Backward branch closing a loop, on an operand written right before
+    li gp, 17
+    li a5, 0
+    li a2, 3
+    li a6, 1
+loop:
+    addi a5, a5, 1
+    bne a5, a2, loop
+    TEST_CHECK a5, 3
+
+    TEST_PASSFAIL
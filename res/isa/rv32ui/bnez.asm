@@ -0,0 +1,11 @@
+# bnez: branch if not equal to zero
+.include "../test.inc"
+
+    TEST_BR1_OP_TAKEN      2, bnez, 1
+    TEST_BR1_OP_TAKEN      3, bnez, 0xffffffff
+    TEST_BR1_OP_TAKEN      4, bnez, 0x80000000
+    TEST_BR1_OP_TAKEN      5, bnez, 0x7fffffff
+
+    TEST_BR1_OP_NOTTAKEN   6, bnez, 0
+
+    TEST_PASSFAIL
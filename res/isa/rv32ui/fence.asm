@@ -0,0 +1,31 @@
+# fence: ordering of the memory accesses
+.include "../test.inc"
+
+    li gp, 2
+    la a1, tdat
+    li a2, 0x12345678
+    sw a2, 0(a1)
+    fence
+    lw a4, 0(a1)
+    TEST_CHECK a4, 0x12345678
+
+    li gp, 3
+    li a2, 7
+    sb a2, 4(a1)
+    fence w, r
+    lbu a4, 4(a1)
+    TEST_CHECK a4, 7
+
+    li gp, 4
+    li a2, 0x00abcdef
+    fence rw, rw
+    sw a2, 4(a1)
+    fence iorw, iorw
+    lw a4, 4(a1)
+    TEST_CHECK a4, 0x00abcdef
+
+    TEST_PASSFAIL
+
+.data
+tdat:
+    .word 0, 0
@@ -0,0 +1,25 @@
+# j: unconditional jump, forward then backward
+.include "../test.inc"
+
+    li gp, 2
+    li a4, 1
+    j label2
+    addi a4, a4, 1
+    addi a4, a4, 1
+label1:
+    addi a4, a4, 1
+    j label3
+label2:
+    addi a4, a4, 1
+    j label1
+    addi a4, a4, 1
+label3:
+    TEST_CHECK a4, 3
+
+    li gp, 3
+    li ra, 0
+    j label4
+label4:
+    TEST_CHECK ra, 0
+
+    TEST_PASSFAIL
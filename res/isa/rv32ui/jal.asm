@@ -0,0 +1,39 @@
+# jal: jump and link of the return address
+.include "../test.inc"
+
+    li gp, 2
+    li ra, 0
+    jal ra, label1
+label2:
+    j fail
+label1:
+    la t2, label2
+    bne ra, t2, fail
+
+    li gp, 3
+    li a4, 1
+    jal zero, label3
+    addi a4, a4, 1
+    addi a4, a4, 1
+label3:
+    TEST_CHECK a4, 1
+
+    li gp, 4
+    jal a4, label4
+label5:
+    j fail
+label6:
+    j label7
+label4:
+    la t2, label5
+    bne a4, t2, fail
+    jal a5, label6
+label7:
+    bnez zero, fail
+
+    li gp, 5
+    jal zero, label8
+label8:
+    bnez zero, fail
+
+    TEST_PASSFAIL
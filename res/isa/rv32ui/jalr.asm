@@ -0,0 +1,44 @@
+# jalr: indirect jump and link, with the lowest bit of the target cleared
+.include "../test.inc"
+
+    li gp, 2
+    la t0, label1
+    jalr a4, t0, 0
+label2:
+    j fail
+label1:
+    la t2, label2
+    bne a4, t2, fail
+
+    li gp, 3
+    la t0, label3
+    addi t0, t0, -8
+    jalr a4, t0, 8
+    j fail
+label3:
+
+    li gp, 4
+    la a4, label4
+    jalr a4, a4, 0
+label5:
+    j fail
+label4:
+    la t2, label5
+    bne a4, t2, fail
+
+    li gp, 5
+    la t0, label6
+    addi t0, t0, 1
+    jalr zero, t0, 0
+    j fail
+label6:
+
+    li gp, 7
+    la t0, label7
+    addi t0, t0, 4
+    jalr a4, t0, -4
+    j fail
+label7:
+    bnez zero, fail
+
+    TEST_PASSFAIL
@@ -0,0 +1,41 @@
+# lb: load of a sign-extended byte
+.include "../test.inc"
+
+    TEST_LD_OP             2, lb, 0xffffffff, 0, tdat
+    TEST_LD_OP             3, lb, 0, 1, tdat
+    TEST_LD_OP             4, lb, 0xfffffff0, 2, tdat
+    TEST_LD_OP             5, lb, 15, 3, tdat
+
+    TEST_LD_OP             6, lb, 0xffffffff, -3, tdat4
+    TEST_LD_OP             7, lb, 0, -2, tdat4
+    TEST_LD_OP             8, lb, 0xfffffff0, -1, tdat4
+    TEST_LD_OP             9, lb, 15, 0, tdat4
+
+    TEST_LD_DEST_BYPASS    10, lb, 0, 1, tdat
+    TEST_LD_DEST_BYPASS    11, lb, 0xfffffff0, 2, tdat
+
+    # Base address with a large offset
+    li gp, 12
+    la a1, tdat
+    addi a1, a1, -32
+    lb a4, 35(a1)
+    TEST_CHECK a4, 15
+
+    # Load into x0
+    li gp, 13
+    la a1, tdat
+    lb zero, 0(a1)
+    bnez zero, fail
+
+    TEST_PASSFAIL
+
+.data
+tdat:
+tdat1:
+    .byte 0xff
+tdat2:
+    .byte 0x00
+tdat3:
+    .byte 0xf0
+tdat4:
+    .byte 0x0f
@@ -0,0 +1,41 @@
+# lbu: load of a zero-extended byte
+.include "../test.inc"
+
+    TEST_LD_OP             2, lbu, 255, 0, tdat
+    TEST_LD_OP             3, lbu, 0, 1, tdat
+    TEST_LD_OP             4, lbu, 240, 2, tdat
+    TEST_LD_OP             5, lbu, 15, 3, tdat
+
+    TEST_LD_OP             6, lbu, 255, -3, tdat4
+    TEST_LD_OP             7, lbu, 0, -2, tdat4
+    TEST_LD_OP             8, lbu, 240, -1, tdat4
+    TEST_LD_OP             9, lbu, 15, 0, tdat4
+
+    TEST_LD_DEST_BYPASS    10, lbu, 0, 1, tdat
+    TEST_LD_DEST_BYPASS    11, lbu, 240, 2, tdat
+
+    # Base address with a large offset
+    li gp, 12
+    la a1, tdat
+    addi a1, a1, -32
+    lbu a4, 35(a1)
+    TEST_CHECK a4, 15
+
+    # Load into x0
+    li gp, 13
+    la a1, tdat
+    lbu zero, 0(a1)
+    bnez zero, fail
+
+    TEST_PASSFAIL
+
+.data
+tdat:
+tdat1:
+    .byte 0xff
+tdat2:
+    .byte 0x00
+tdat3:
+    .byte 0xf0
+tdat4:
+    .byte 0x0f
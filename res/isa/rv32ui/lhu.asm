@@ -0,0 +1,41 @@
+# lhu: load of a zero-extended halfword
+.include "../test.inc"
+
+    TEST_LD_OP             2, lhu, 255, 0, tdat
+    TEST_LD_OP             3, lhu, 0x0000ff00, 2, tdat
+    TEST_LD_OP             4, lhu, 0x00000ff0, 4, tdat
+    TEST_LD_OP             5, lhu, 0x0000f00f, 6, tdat
+
+    TEST_LD_OP             6, lhu, 255, -6, tdat4
+    TEST_LD_OP             7, lhu, 0x0000ff00, -4, tdat4
+    TEST_LD_OP             8, lhu, 0x00000ff0, -2, tdat4
+    TEST_LD_OP             9, lhu, 0x0000f00f, 0, tdat4
+
+    TEST_LD_DEST_BYPASS    10, lhu, 0x0000ff00, 2, tdat
+    TEST_LD_DEST_BYPASS    11, lhu, 0x00000ff0, 4, tdat
+
+    # Base address with a large offset
+    li gp, 12
+    la a1, tdat
+    addi a1, a1, -32
+    lhu a4, 38(a1)
+    TEST_CHECK a4, 0x0000f00f
+
+    # Load into x0
+    li gp, 13
+    la a1, tdat
+    lhu zero, 0(a1)
+    bnez zero, fail
+
+    TEST_PASSFAIL
+
+.data
+tdat:
+tdat1:
+    .half 0x00ff
+tdat2:
+    .half 0xff00
+tdat3:
+    .half 0x0ff0
+tdat4:
+    .half 0xf00f
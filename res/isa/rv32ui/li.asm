@@ -0,0 +1,43 @@
+# li: load of a 12-bit immediate, compared with lui and addi
+.include "../test.inc"
+
+    li gp, 2
+    li a4, 0
+    bnez a4, fail
+
+    li gp, 3
+    li a4, 1
+    addi t2, zero, 1
+    bne a4, t2, fail
+
+    li gp, 4
+    li a4, 2047
+    addi t2, zero, 2047
+    bne a4, t2, fail
+
+    li gp, 5
+    li a4, -2048
+    lui t2, 0xfffff
+    addi t2, t2, 0x800
+    bne a4, t2, fail
+
+    li gp, 6
+    li a4, -1
+    addi t2, zero, -1
+    bne a4, t2, fail
+    srli a4, a4, 31
+    addi t2, zero, 1
+    bne a4, t2, fail
+
+    li gp, 7
+    li a4, 5
+    li a4, 7
+    addi a5, a4, 0
+    addi t2, zero, 7
+    bne a5, t2, fail
+
+    li gp, 8
+    li zero, 5
+    bnez zero, fail
+
+    TEST_PASSFAIL
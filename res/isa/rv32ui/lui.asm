@@ -0,0 +1,32 @@
+# lui: load of an upper immediate, the lower 12 bits cleared
+.include "../test.inc"
+
+    li gp, 2
+    lui a4, 0
+    TEST_CHECK a4, 0
+
+    li gp, 3
+    lui a4, 0xfffff
+    srai a4, a4, 1
+    TEST_CHECK a4, 0xfffff800
+
+    li gp, 4
+    lui a4, 0x7ffff
+    srai a4, a4, 20
+    TEST_CHECK a4, 0x000007ff
+
+    li gp, 5
+    lui a4, 0x80000
+    srai a4, a4, 20
+    TEST_CHECK a4, 0xfffff800
+
+    li gp, 6
+    li a4, -1
+    lui a4, 0x12345
+    TEST_CHECK a4, 0x12345000
+
+    li gp, 7
+    lui zero, 0x80000
+    bnez zero, fail
+
+    TEST_PASSFAIL
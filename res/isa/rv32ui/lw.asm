@@ -0,0 +1,41 @@
+# lw: load of a word
+.include "../test.inc"
+
+    TEST_LD_OP             2, lw, 0x00ff00ff, 0, tdat
+    TEST_LD_OP             3, lw, 0xff00ff00, 4, tdat
+    TEST_LD_OP             4, lw, 0x0ff00ff0, 8, tdat
+    TEST_LD_OP             5, lw, 0xf00ff00f, 12, tdat
+
+    TEST_LD_OP             6, lw, 0x00ff00ff, -12, tdat4
+    TEST_LD_OP             7, lw, 0xff00ff00, -8, tdat4
+    TEST_LD_OP             8, lw, 0x0ff00ff0, -4, tdat4
+    TEST_LD_OP             9, lw, 0xf00ff00f, 0, tdat4
+
+    TEST_LD_DEST_BYPASS    10, lw, 0xff00ff00, 4, tdat
+    TEST_LD_DEST_BYPASS    11, lw, 0x0ff00ff0, 8, tdat
+
+    # Base address with a large offset
+    li gp, 12
+    la a1, tdat
+    addi a1, a1, -32
+    lw a4, 44(a1)
+    TEST_CHECK a4, 0xf00ff00f
+
+    # Load into x0
+    li gp, 13
+    la a1, tdat
+    lw zero, 0(a1)
+    bnez zero, fail
+
+    TEST_PASSFAIL
+
+.data
+tdat:
+tdat1:
+    .word 0x00ff00ff
+tdat2:
+    .word 0xff00ff00
+tdat3:
+    .word 0x0ff00ff0
+tdat4:
+    .word 0xf00ff00f
@@ -0,0 +1,30 @@
+# mv: copy of a register
+.include "../test.inc"
+
+    li gp, 2
+    li a1, 0x12345678
+    mv a4, a1
+    TEST_CHECK a4, 0x12345678
+
+    li gp, 3
+    li a1, 0x80000000
+    mv a4, a1
+    mv a5, a4
+    TEST_CHECK a5, 0x80000000
+
+    li gp, 4
+    li a4, -1
+    mv a4, zero
+    TEST_CHECK a4, 0
+
+    li gp, 5
+    li a1, 42
+    mv a1, a1
+    TEST_CHECK a1, 42
+
+    li gp, 6
+    li a1, 42
+    mv zero, a1
+    bnez zero, fail
+
+    TEST_PASSFAIL
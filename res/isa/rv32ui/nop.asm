@@ -0,0 +1,18 @@
+# nop: instruction without effect
+.include "../test.inc"
+
+    li gp, 2
+    li a1, 0x12345678
+    nop
+    TEST_CHECK a1, 0x12345678
+
+    li gp, 3
+    li t0, 2
+loop:
+    nop
+    addi t0, t0, -1
+    nop
+    bnez t0, loop
+    TEST_CHECK t0, 0
+
+    TEST_PASSFAIL
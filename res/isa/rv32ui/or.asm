@@ -0,0 +1,20 @@
+# or: bitwise or
+.include "../test.inc"
+
+    TEST_RR_OP             2, or, 0xff0fff0f, 0xff00ff00, 0x0f0f0f0f
+    TEST_RR_OP             3, or, 0xfff0fff0, 0x0ff00ff0, 0xf0f0f0f0
+    TEST_RR_OP             4, or, 0x0fff0fff, 0x00ff00ff, 0x0f0f0f0f
+    TEST_RR_OP             5, or, 0xf0fff0ff, 0xf00ff00f, 0xf0f0f0f0
+    TEST_RR_OP             6, or, 0xffffffff, 0xffffffff, 0
+    TEST_RR_OP             7, or, 0xffffffff, 0xffffffff, 0xffffffff
+    TEST_RR_OP             8, or, 0xffffffff, 0x80000000, 0x7fffffff
+
+    TEST_RR_SRC1_EQ_DEST   9, or, 15, 13, 11
+    TEST_RR_SRC2_EQ_DEST   10, or, 15, 13, 11
+    TEST_RR_SRC12_EQ_DEST  11, or, 13, 13
+    TEST_RR_DEST_BYPASS    12, or, 15, 13, 11
+    TEST_RR_ZEROSRC1       13, or, 0xfffffff1, 0xfffffff1
+    TEST_RR_ZEROSRC2       14, or, 0xfffffff1, 0xfffffff1
+    TEST_RR_ZERODEST       15, or, 16, 30
+
+    TEST_PASSFAIL
@@ -0,0 +1,18 @@
+# ori: bitwise or with a sign-extended immediate
+.include "../test.inc"
+
+    TEST_IMM_OP            2, ori, 0xffffff0f, 0xff00ff00, -241
+    TEST_IMM_OP            3, ori, 0x0ff00ff0, 0x0ff00ff0, 240
+    TEST_IMM_OP            4, ori, 0x00ff07ff, 0x00ff00ff, 1807
+    TEST_IMM_OP            5, ori, 0xf00ff0ff, 0xf00ff00f, 240
+    TEST_IMM_OP            6, ori, 0xffffffff, 0x12345678, -1
+    TEST_IMM_OP            7, ori, 0xfffffe78, 0x12345678, -2048
+    TEST_IMM_OP            8, ori, 0xfffff800, 0, -2048
+    TEST_IMM_OP            9, ori, 0xffffffff, 0xffffffff, 2047
+
+    TEST_IMM_SRC1_EQ_DEST  10, ori, 15, 13, 11
+    TEST_IMM_DEST_BYPASS   11, ori, 15, 13, 11
+    TEST_IMM_ZEROSRC1      12, ori, 0xffffff0f, -241
+    TEST_IMM_ZERODEST      13, ori, 33, 50
+
+    TEST_PASSFAIL
@@ -0,0 +1,20 @@
+# ret: return to the address in ra
+.include "../test.inc"
+
+    li gp, 2
+    la ra, label1
+    ret
+    j fail
+label1:
+
+    li gp, 3
+    li a4, 0
+    jal ra, label2
+    TEST_CHECK a4, 1
+    j label3
+label2:
+    addi a4, a4, 1
+    ret
+label3:
+
+    TEST_PASSFAIL
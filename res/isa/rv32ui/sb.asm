@@ -0,0 +1,60 @@
+# sb: store of the low byte
+.include "../test.inc"
+
+    TEST_ST_OP             2, lb, sb, 0xffffffaa, 0, tdat
+    TEST_ST_OP             3, lb, sb, 0, 1, tdat
+    TEST_ST_OP             4, lh, sb, 0xffffefa0, 2, tdat
+    TEST_ST_OP             5, lb, sb, 10, 3, tdat
+
+    TEST_ST_OP             6, lb, sb, 0xffffffaa, -3, tdat9
+    TEST_ST_OP             7, lb, sb, 0, -2, tdat9
+    TEST_ST_OP             8, lb, sb, 0xffffffa0, -1, tdat9
+    TEST_ST_OP             9, lb, sb, 10, 0, tdat9
+
+    # Only the low bits are stored, the following ones are left untouched
+    li gp, 10
+    la a1, tdat10
+    li a2, 0x12345678
+    sb a2, 0(a1)
+    lbu a4, 0(a1)
+    TEST_CHECK a4, 0x78
+    lbu a4, 1(a1)
+    TEST_CHECK a4, 0xef
+
+    # Store followed by a load of the same address, in a loop
+    li gp, 11
+    li t0, 2
+    la a1, tdat
+loop:
+    sb t0, 0(a1)
+    lbu a4, 0(a1)
+    bne a4, t0, fail
+    addi t0, t0, -1
+    bnez t0, loop
+
+    TEST_PASSFAIL
+
+.data
+tdat:
+tdat1:
+    .byte 0xef
+tdat2:
+    .byte 0xef
+tdat3:
+    .byte 0xef
+tdat4:
+    .byte 0xef
+tdat5:
+    .byte 0xef
+tdat6:
+    .byte 0xef
+tdat7:
+    .byte 0xef
+tdat8:
+    .byte 0xef
+tdat9:
+    .byte 0xef
+tdat10:
+    .byte 0xef
+tdat11:
+    .byte 0xef
@@ -0,0 +1,60 @@
+# sh: store of the low halfword
+.include "../test.inc"
+
+    TEST_ST_OP             2, lh, sh, 170, 0, tdat
+    TEST_ST_OP             3, lh, sh, 0xffffaa00, 2, tdat
+    TEST_ST_OP             4, lw, sh, 0xbeef0aa0, 4, tdat
+    TEST_ST_OP             5, lh, sh, 0xffffa00a, 6, tdat
+
+    TEST_ST_OP             6, lh, sh, 170, -6, tdat9
+    TEST_ST_OP             7, lh, sh, 0xffffaa00, -4, tdat9
+    TEST_ST_OP             8, lh, sh, 0x00000aa0, -2, tdat9
+    TEST_ST_OP             9, lh, sh, 0xffffa00a, 0, tdat9
+
+    # Only the low bits are stored, the following ones are left untouched
+    li gp, 10
+    la a1, tdat10
+    li a2, 0x12345678
+    sh a2, 0(a1)
+    lhu a4, 0(a1)
+    TEST_CHECK a4, 0x5678
+    lhu a4, 2(a1)
+    TEST_CHECK a4, 0xbeef
+
+    # Store followed by a load of the same address, in a loop
+    li gp, 11
+    li t0, 2
+    la a1, tdat
+loop:
+    sh t0, 0(a1)
+    lhu a4, 0(a1)
+    bne a4, t0, fail
+    addi t0, t0, -1
+    bnez t0, loop
+
+    TEST_PASSFAIL
+
+.data
+tdat:
+tdat1:
+    .half 0xbeef
+tdat2:
+    .half 0xbeef
+tdat3:
+    .half 0xbeef
+tdat4:
+    .half 0xbeef
+tdat5:
+    .half 0xbeef
+tdat6:
+    .half 0xbeef
+tdat7:
+    .half 0xbeef
+tdat8:
+    .half 0xbeef
+tdat9:
+    .half 0xbeef
+tdat10:
+    .half 0xbeef
+tdat11:
+    .half 0xbeef
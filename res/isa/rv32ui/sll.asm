@@ -0,0 +1,39 @@
+# sll: logical left shift by the low 5 bits of rs2
+.include "../test.inc"
+
+    TEST_RR_OP             2, sll, 1, 1, 0
+    TEST_RR_OP             3, sll, 2, 1, 1
+    TEST_RR_OP             4, sll, 128, 1, 7
+    TEST_RR_OP             5, sll, 0x00004000, 1, 14
+    TEST_RR_OP             6, sll, 0x80000000, 1, 31
+    TEST_RR_OP             7, sll, 0xffffffff, 0xffffffff, 0
+    TEST_RR_OP             8, sll, 0xfffffffe, 0xffffffff, 1
+    TEST_RR_OP             9, sll, 0xffffff80, 0xffffffff, 7
+    TEST_RR_OP             10, sll, 0xffffc000, 0xffffffff, 14
+    TEST_RR_OP             11, sll, 0x80000000, 0xffffffff, 31
+    TEST_RR_OP             12, sll, 0x21212121, 0x21212121, 0
+    TEST_RR_OP             13, sll, 0x42424242, 0x21212121, 1
+    TEST_RR_OP             14, sll, 0x90909080, 0x21212121, 7
+    TEST_RR_OP             15, sll, 0x48484000, 0x21212121, 14
+    TEST_RR_OP             16, sll, 0x80000000, 0x21212121, 31
+    TEST_RR_OP             17, sll, 0x80000000, 0x80000000, 0
+    TEST_RR_OP             18, sll, 0, 0x80000000, 1
+    TEST_RR_OP             19, sll, 0, 0x80000000, 7
+    TEST_RR_OP             20, sll, 0, 0x80000000, 14
+    TEST_RR_OP             21, sll, 0, 0x80000000, 31
+    TEST_RR_OP             22, sll, 0x21212121, 0x21212121, 0xffffffc0
+    TEST_RR_OP             23, sll, 0x42424242, 0x21212121, 0xffffffc1
+    TEST_RR_OP             24, sll, 0x90909080, 0x21212121, 0xffffffc7
+    TEST_RR_OP             25, sll, 0x48484000, 0x21212121, 0xffffffce
+    TEST_RR_OP             26, sll, 0x80000000, 0x21212121, 0xffffffff
+    TEST_RR_OP             27, sll, 0x21212121, 0x21212121, 32
+
+    TEST_RR_SRC1_EQ_DEST   28, sll, 128, 0x80000001, 7
+    TEST_RR_SRC2_EQ_DEST   29, sll, 128, 0x80000001, 7
+    TEST_RR_SRC12_EQ_DEST  30, sll, 2, 0x80000001
+    TEST_RR_DEST_BYPASS    31, sll, 128, 0x80000001, 7
+    TEST_RR_ZEROSRC1       32, sll, 0, 0xfffffff1
+    TEST_RR_ZEROSRC2       33, sll, 0xfffffff1, 0xfffffff1
+    TEST_RR_ZERODEST       34, sll, 16, 30
+
+    TEST_PASSFAIL
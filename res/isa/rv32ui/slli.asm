@@ -0,0 +1,35 @@
+# slli: logical left shift by an immediate
+.include "../test.inc"
+
+    TEST_IMM_OP            2, slli, 1, 1, 0
+    TEST_IMM_OP            3, slli, 2, 1, 1
+    TEST_IMM_OP            4, slli, 128, 1, 7
+    TEST_IMM_OP            5, slli, 0x00004000, 1, 14
+    TEST_IMM_OP            6, slli, 0x80000000, 1, 31
+    TEST_IMM_OP            7, slli, 0xffffffff, 0xffffffff, 0
+    TEST_IMM_OP            8, slli, 0xfffffffe, 0xffffffff, 1
+    TEST_IMM_OP            9, slli, 0xffffff80, 0xffffffff, 7
+    TEST_IMM_OP            10, slli, 0xffffc000, 0xffffffff, 14
+    TEST_IMM_OP            11, slli, 0x80000000, 0xffffffff, 31
+    TEST_IMM_OP            12, slli, 0x21212121, 0x21212121, 0
+    TEST_IMM_OP            13, slli, 0x42424242, 0x21212121, 1
+    TEST_IMM_OP            14, slli, 0x90909080, 0x21212121, 7
+    TEST_IMM_OP            15, slli, 0x48484000, 0x21212121, 14
+    TEST_IMM_OP            16, slli, 0x80000000, 0x21212121, 31
+    TEST_IMM_OP            17, slli, 0x80000000, 0x80000000, 0
+    TEST_IMM_OP            18, slli, 0, 0x80000000, 1
+    TEST_IMM_OP            19, slli, 0, 0x80000000, 7
+    TEST_IMM_OP            20, slli, 0, 0x80000000, 14
+    TEST_IMM_OP            21, slli, 0, 0x80000000, 31
+    TEST_IMM_OP            22, slli, 0x81818181, 0x81818181, 0
+    TEST_IMM_OP            23, slli, 0x03030302, 0x81818181, 1
+    TEST_IMM_OP            24, slli, 0xc0c0c080, 0x81818181, 7
+    TEST_IMM_OP            25, slli, 0x60604000, 0x81818181, 14
+    TEST_IMM_OP            26, slli, 0x80000000, 0x81818181, 31
+
+    TEST_IMM_SRC1_EQ_DEST  27, slli, 128, 0x80000001, 7
+    TEST_IMM_DEST_BYPASS   28, slli, 128, 0x80000001, 7
+    TEST_IMM_ZEROSRC1      29, slli, 0, 31
+    TEST_IMM_ZERODEST      30, slli, 33, 31
+
+    TEST_PASSFAIL
@@ -0,0 +1,30 @@
+# slt: signed comparison
+.include "../test.inc"
+
+    TEST_RR_OP             2, slt, 0, 0, 0
+    TEST_RR_OP             3, slt, 0, 1, 1
+    TEST_RR_OP             4, slt, 1, 3, 7
+    TEST_RR_OP             5, slt, 0, 7, 3
+    TEST_RR_OP             6, slt, 0, 0, 0xffff8000
+    TEST_RR_OP             7, slt, 1, 0x80000000, 0
+    TEST_RR_OP             8, slt, 1, 0x80000000, 0xffff8000
+    TEST_RR_OP             9, slt, 1, 0, 0x00007fff
+    TEST_RR_OP             10, slt, 0, 0x7fffffff, 0
+    TEST_RR_OP             11, slt, 0, 0x7fffffff, 0x00007fff
+    TEST_RR_OP             12, slt, 1, 0x80000000, 0x00007fff
+    TEST_RR_OP             13, slt, 0, 0x7fffffff, 0xffff8000
+    TEST_RR_OP             14, slt, 0, 0, 0xffffffff
+    TEST_RR_OP             15, slt, 1, 0xffffffff, 1
+    TEST_RR_OP             16, slt, 0, 0xffffffff, 0xffffffff
+    TEST_RR_OP             17, slt, 1, 0x80000000, 0x7fffffff
+    TEST_RR_OP             18, slt, 0, 0x7fffffff, 0x80000000
+
+    TEST_RR_SRC1_EQ_DEST   19, slt, 1, 11, 13
+    TEST_RR_SRC2_EQ_DEST   20, slt, 1, 11, 13
+    TEST_RR_SRC12_EQ_DEST  21, slt, 0, 11
+    TEST_RR_DEST_BYPASS    22, slt, 1, 11, 13
+    TEST_RR_ZEROSRC1       23, slt, 0, 0xfffffff1
+    TEST_RR_ZEROSRC2       24, slt, 1, 0xfffffff1
+    TEST_RR_ZERODEST       25, slt, 16, 30
+
+    TEST_PASSFAIL
@@ -0,0 +1,27 @@
+# slti: signed comparison with a sign-extended immediate
+.include "../test.inc"
+
+    TEST_IMM_OP            2, slti, 0, 0, 0
+    TEST_IMM_OP            3, slti, 0, 1, 1
+    TEST_IMM_OP            4, slti, 1, 3, 7
+    TEST_IMM_OP            5, slti, 0, 7, 3
+    TEST_IMM_OP            6, slti, 0, 0, -2048
+    TEST_IMM_OP            7, slti, 1, 0x80000000, 0
+    TEST_IMM_OP            8, slti, 1, 0x80000000, -2048
+    TEST_IMM_OP            9, slti, 1, 0, 2047
+    TEST_IMM_OP            10, slti, 0, 0x7fffffff, 0
+    TEST_IMM_OP            11, slti, 0, 0x7fffffff, 2047
+    TEST_IMM_OP            12, slti, 1, 0x80000000, 2047
+    TEST_IMM_OP            13, slti, 0, 0x7fffffff, -2048
+    TEST_IMM_OP            14, slti, 0, 0, -1
+    TEST_IMM_OP            15, slti, 1, 0xffffffff, 1
+    TEST_IMM_OP            16, slti, 0, 0xffffffff, -1
+    TEST_IMM_OP            17, slti, 1, 0, 1
+    TEST_IMM_OP            18, slti, 0, 1, 1
+
+    TEST_IMM_SRC1_EQ_DEST  19, slti, 0, 13, 11
+    TEST_IMM_DEST_BYPASS   20, slti, 0, 13, 11
+    TEST_IMM_ZEROSRC1      21, slti, 0, -241
+    TEST_IMM_ZERODEST      22, slti, 33, 50
+
+    TEST_PASSFAIL
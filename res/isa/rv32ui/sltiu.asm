@@ -0,0 +1,27 @@
+# sltiu: unsigned comparison with a sign-extended immediate
+.include "../test.inc"
+
+    TEST_IMM_OP            2, sltiu, 0, 0, 0
+    TEST_IMM_OP            3, sltiu, 0, 1, 1
+    TEST_IMM_OP            4, sltiu, 1, 3, 7
+    TEST_IMM_OP            5, sltiu, 0, 7, 3
+    TEST_IMM_OP            6, sltiu, 1, 0, -2048
+    TEST_IMM_OP            7, sltiu, 0, 0x80000000, 0
+    TEST_IMM_OP            8, sltiu, 1, 0x80000000, -2048
+    TEST_IMM_OP            9, sltiu, 1, 0, 2047
+    TEST_IMM_OP            10, sltiu, 0, 0x7fffffff, 0
+    TEST_IMM_OP            11, sltiu, 0, 0x7fffffff, 2047
+    TEST_IMM_OP            12, sltiu, 0, 0x80000000, 2047
+    TEST_IMM_OP            13, sltiu, 1, 0x7fffffff, -2048
+    TEST_IMM_OP            14, sltiu, 1, 0, -1
+    TEST_IMM_OP            15, sltiu, 0, 0xffffffff, 1
+    TEST_IMM_OP            16, sltiu, 0, 0xffffffff, -1
+    TEST_IMM_OP            17, sltiu, 1, 0, 1
+    TEST_IMM_OP            18, sltiu, 0, 1, 1
+
+    TEST_IMM_SRC1_EQ_DEST  19, sltiu, 0, 13, 11
+    TEST_IMM_DEST_BYPASS   20, sltiu, 0, 13, 11
+    TEST_IMM_ZEROSRC1      21, sltiu, 1, -241
+    TEST_IMM_ZERODEST      22, sltiu, 33, 50
+
+    TEST_PASSFAIL
@@ -0,0 +1,30 @@
+# sltu: unsigned comparison
+.include "../test.inc"
+
+    TEST_RR_OP             2, sltu, 0, 0, 0
+    TEST_RR_OP             3, sltu, 0, 1, 1
+    TEST_RR_OP             4, sltu, 1, 3, 7
+    TEST_RR_OP             5, sltu, 0, 7, 3
+    TEST_RR_OP             6, sltu, 1, 0, 0xffff8000
+    TEST_RR_OP             7, sltu, 0, 0x80000000, 0
+    TEST_RR_OP             8, sltu, 1, 0x80000000, 0xffff8000
+    TEST_RR_OP             9, sltu, 1, 0, 0x00007fff
+    TEST_RR_OP             10, sltu, 0, 0x7fffffff, 0
+    TEST_RR_OP             11, sltu, 0, 0x7fffffff, 0x00007fff
+    TEST_RR_OP             12, sltu, 0, 0x80000000, 0x00007fff
+    TEST_RR_OP             13, sltu, 1, 0x7fffffff, 0xffff8000
+    TEST_RR_OP             14, sltu, 1, 0, 0xffffffff
+    TEST_RR_OP             15, sltu, 0, 0xffffffff, 1
+    TEST_RR_OP             16, sltu, 0, 0xffffffff, 0xffffffff
+    TEST_RR_OP             17, sltu, 0, 0x80000000, 0x7fffffff
+    TEST_RR_OP             18, sltu, 1, 0x7fffffff, 0x80000000
+
+    TEST_RR_SRC1_EQ_DEST   19, sltu, 1, 11, 13
+    TEST_RR_SRC2_EQ_DEST   20, sltu, 1, 11, 13
+    TEST_RR_SRC12_EQ_DEST  21, sltu, 0, 11
+    TEST_RR_DEST_BYPASS    22, sltu, 1, 11, 13
+    TEST_RR_ZEROSRC1       23, sltu, 1, 0xfffffff1
+    TEST_RR_ZEROSRC2       24, sltu, 0, 0xfffffff1
+    TEST_RR_ZERODEST       25, sltu, 16, 30
+
+    TEST_PASSFAIL
@@ -0,0 +1,39 @@
+# sra: arithmetic right shift by the low 5 bits of rs2
+.include "../test.inc"
+
+    TEST_RR_OP             2, sra, 1, 1, 0
+    TEST_RR_OP             3, sra, 0, 1, 1
+    TEST_RR_OP             4, sra, 0, 1, 7
+    TEST_RR_OP             5, sra, 0, 1, 14
+    TEST_RR_OP             6, sra, 0, 1, 31
+    TEST_RR_OP             7, sra, 0xffffffff, 0xffffffff, 0
+    TEST_RR_OP             8, sra, 0xffffffff, 0xffffffff, 1
+    TEST_RR_OP             9, sra, 0xffffffff, 0xffffffff, 7
+    TEST_RR_OP             10, sra, 0xffffffff, 0xffffffff, 14
+    TEST_RR_OP             11, sra, 0xffffffff, 0xffffffff, 31
+    TEST_RR_OP             12, sra, 0x21212121, 0x21212121, 0
+    TEST_RR_OP             13, sra, 0x10909090, 0x21212121, 1
+    TEST_RR_OP             14, sra, 0x00424242, 0x21212121, 7
+    TEST_RR_OP             15, sra, 0x00008484, 0x21212121, 14
+    TEST_RR_OP             16, sra, 0, 0x21212121, 31
+    TEST_RR_OP             17, sra, 0x80000000, 0x80000000, 0
+    TEST_RR_OP             18, sra, 0xc0000000, 0x80000000, 1
+    TEST_RR_OP             19, sra, 0xff000000, 0x80000000, 7
+    TEST_RR_OP             20, sra, 0xfffe0000, 0x80000000, 14
+    TEST_RR_OP             21, sra, 0xffffffff, 0x80000000, 31
+    TEST_RR_OP             22, sra, 0x21212121, 0x21212121, 0xffffffc0
+    TEST_RR_OP             23, sra, 0x10909090, 0x21212121, 0xffffffc1
+    TEST_RR_OP             24, sra, 0x00424242, 0x21212121, 0xffffffc7
+    TEST_RR_OP             25, sra, 0x00008484, 0x21212121, 0xffffffce
+    TEST_RR_OP             26, sra, 0, 0x21212121, 0xffffffff
+    TEST_RR_OP             27, sra, 0x21212121, 0x21212121, 32
+
+    TEST_RR_SRC1_EQ_DEST   28, sra, 0xff000000, 0x80000001, 7
+    TEST_RR_SRC2_EQ_DEST   29, sra, 0xff000000, 0x80000001, 7
+    TEST_RR_SRC12_EQ_DEST  30, sra, 0xc0000000, 0x80000001
+    TEST_RR_DEST_BYPASS    31, sra, 0xff000000, 0x80000001, 7
+    TEST_RR_ZEROSRC1       32, sra, 0, 0xfffffff1
+    TEST_RR_ZEROSRC2       33, sra, 0xfffffff1, 0xfffffff1
+    TEST_RR_ZERODEST       34, sra, 16, 30
+
+    TEST_PASSFAIL
@@ -0,0 +1,35 @@
+# srai: arithmetic right shift by an immediate
+.include "../test.inc"
+
+    TEST_IMM_OP            2, srai, 1, 1, 0
+    TEST_IMM_OP            3, srai, 0, 1, 1
+    TEST_IMM_OP            4, srai, 0, 1, 7
+    TEST_IMM_OP            5, srai, 0, 1, 14
+    TEST_IMM_OP            6, srai, 0, 1, 31
+    TEST_IMM_OP            7, srai, 0xffffffff, 0xffffffff, 0
+    TEST_IMM_OP            8, srai, 0xffffffff, 0xffffffff, 1
+    TEST_IMM_OP            9, srai, 0xffffffff, 0xffffffff, 7
+    TEST_IMM_OP            10, srai, 0xffffffff, 0xffffffff, 14
+    TEST_IMM_OP            11, srai, 0xffffffff, 0xffffffff, 31
+    TEST_IMM_OP            12, srai, 0x21212121, 0x21212121, 0
+    TEST_IMM_OP            13, srai, 0x10909090, 0x21212121, 1
+    TEST_IMM_OP            14, srai, 0x00424242, 0x21212121, 7
+    TEST_IMM_OP            15, srai, 0x00008484, 0x21212121, 14
+    TEST_IMM_OP            16, srai, 0, 0x21212121, 31
+    TEST_IMM_OP            17, srai, 0x80000000, 0x80000000, 0
+    TEST_IMM_OP            18, srai, 0xc0000000, 0x80000000, 1
+    TEST_IMM_OP            19, srai, 0xff000000, 0x80000000, 7
+    TEST_IMM_OP            20, srai, 0xfffe0000, 0x80000000, 14
+    TEST_IMM_OP            21, srai, 0xffffffff, 0x80000000, 31
+    TEST_IMM_OP            22, srai, 0x81818181, 0x81818181, 0
+    TEST_IMM_OP            23, srai, 0xc0c0c0c0, 0x81818181, 1
+    TEST_IMM_OP            24, srai, 0xff030303, 0x81818181, 7
+    TEST_IMM_OP            25, srai, 0xfffe0606, 0x81818181, 14
+    TEST_IMM_OP            26, srai, 0xffffffff, 0x81818181, 31
+
+    TEST_IMM_SRC1_EQ_DEST  27, srai, 0xff000000, 0x80000001, 7
+    TEST_IMM_DEST_BYPASS   28, srai, 0xff000000, 0x80000001, 7
+    TEST_IMM_ZEROSRC1      29, srai, 0, 31
+    TEST_IMM_ZERODEST      30, srai, 33, 31
+
+    TEST_PASSFAIL
@@ -0,0 +1,39 @@
+# srl: logical right shift by the low 5 bits of rs2
+.include "../test.inc"
+
+    TEST_RR_OP             2, srl, 1, 1, 0
+    TEST_RR_OP             3, srl, 0, 1, 1
+    TEST_RR_OP             4, srl, 0, 1, 7
+    TEST_RR_OP             5, srl, 0, 1, 14
+    TEST_RR_OP             6, srl, 0, 1, 31
+    TEST_RR_OP             7, srl, 0xffffffff, 0xffffffff, 0
+    TEST_RR_OP             8, srl, 0x7fffffff, 0xffffffff, 1
+    TEST_RR_OP             9, srl, 0x01ffffff, 0xffffffff, 7
+    TEST_RR_OP             10, srl, 0x0003ffff, 0xffffffff, 14
+    TEST_RR_OP             11, srl, 1, 0xffffffff, 31
+    TEST_RR_OP             12, srl, 0x21212121, 0x21212121, 0
+    TEST_RR_OP             13, srl, 0x10909090, 0x21212121, 1
+    TEST_RR_OP             14, srl, 0x00424242, 0x21212121, 7
+    TEST_RR_OP             15, srl, 0x00008484, 0x21212121, 14
+    TEST_RR_OP             16, srl, 0, 0x21212121, 31
+    TEST_RR_OP             17, srl, 0x80000000, 0x80000000, 0
+    TEST_RR_OP             18, srl, 0x40000000, 0x80000000, 1
+    TEST_RR_OP             19, srl, 0x01000000, 0x80000000, 7
+    TEST_RR_OP             20, srl, 0x00020000, 0x80000000, 14
+    TEST_RR_OP             21, srl, 1, 0x80000000, 31
+    TEST_RR_OP             22, srl, 0x21212121, 0x21212121, 0xffffffc0
+    TEST_RR_OP             23, srl, 0x10909090, 0x21212121, 0xffffffc1
+    TEST_RR_OP             24, srl, 0x00424242, 0x21212121, 0xffffffc7
+    TEST_RR_OP             25, srl, 0x00008484, 0x21212121, 0xffffffce
+    TEST_RR_OP             26, srl, 0, 0x21212121, 0xffffffff
+    TEST_RR_OP             27, srl, 0x21212121, 0x21212121, 32
+
+    TEST_RR_SRC1_EQ_DEST   28, srl, 0x01000000, 0x80000001, 7
+    TEST_RR_SRC2_EQ_DEST   29, srl, 0x01000000, 0x80000001, 7
+    TEST_RR_SRC12_EQ_DEST  30, srl, 0x40000000, 0x80000001
+    TEST_RR_DEST_BYPASS    31, srl, 0x01000000, 0x80000001, 7
+    TEST_RR_ZEROSRC1       32, srl, 0, 0xfffffff1
+    TEST_RR_ZEROSRC2       33, srl, 0xfffffff1, 0xfffffff1
+    TEST_RR_ZERODEST       34, srl, 16, 30
+
+    TEST_PASSFAIL
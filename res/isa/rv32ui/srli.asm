@@ -0,0 +1,35 @@
+# srli: logical right shift by an immediate
+.include "../test.inc"
+
+    TEST_IMM_OP            2, srli, 1, 1, 0
+    TEST_IMM_OP            3, srli, 0, 1, 1
+    TEST_IMM_OP            4, srli, 0, 1, 7
+    TEST_IMM_OP            5, srli, 0, 1, 14
+    TEST_IMM_OP            6, srli, 0, 1, 31
+    TEST_IMM_OP            7, srli, 0xffffffff, 0xffffffff, 0
+    TEST_IMM_OP            8, srli, 0x7fffffff, 0xffffffff, 1
+    TEST_IMM_OP            9, srli, 0x01ffffff, 0xffffffff, 7
+    TEST_IMM_OP            10, srli, 0x0003ffff, 0xffffffff, 14
+    TEST_IMM_OP            11, srli, 1, 0xffffffff, 31
+    TEST_IMM_OP            12, srli, 0x21212121, 0x21212121, 0
+    TEST_IMM_OP            13, srli, 0x10909090, 0x21212121, 1
+    TEST_IMM_OP            14, srli, 0x00424242, 0x21212121, 7
+    TEST_IMM_OP            15, srli, 0x00008484, 0x21212121, 14
+    TEST_IMM_OP            16, srli, 0, 0x21212121, 31
+    TEST_IMM_OP            17, srli, 0x80000000, 0x80000000, 0
+    TEST_IMM_OP            18, srli, 0x40000000, 0x80000000, 1
+    TEST_IMM_OP            19, srli, 0x01000000, 0x80000000, 7
+    TEST_IMM_OP            20, srli, 0x00020000, 0x80000000, 14
+    TEST_IMM_OP            21, srli, 1, 0x80000000, 31
+    TEST_IMM_OP            22, srli, 0x81818181, 0x81818181, 0
+    TEST_IMM_OP            23, srli, 0x40c0c0c0, 0x81818181, 1
+    TEST_IMM_OP            24, srli, 0x01030303, 0x81818181, 7
+    TEST_IMM_OP            25, srli, 0x00020606, 0x81818181, 14
+    TEST_IMM_OP            26, srli, 1, 0x81818181, 31
+
+    TEST_IMM_SRC1_EQ_DEST  27, srli, 0x01000000, 0x80000001, 7
+    TEST_IMM_DEST_BYPASS   28, srli, 0x01000000, 0x80000001, 7
+    TEST_IMM_ZEROSRC1      29, srli, 0, 31
+    TEST_IMM_ZERODEST      30, srli, 33, 31
+
+    TEST_PASSFAIL
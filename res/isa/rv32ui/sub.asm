@@ -0,0 +1,31 @@
+# sub: subtraction, wrapping around on overflow
+.include "../test.inc"
+
+    TEST_RR_OP             2, sub, 0, 0, 0
+    TEST_RR_OP             3, sub, 0, 1, 1
+    TEST_RR_OP             4, sub, 0xfffffffc, 3, 7
+    TEST_RR_OP             5, sub, 0x00008000, 0, 0xffff8000
+    TEST_RR_OP             6, sub, 0x80000000, 0x80000000, 0
+    TEST_RR_OP             7, sub, 0x80008000, 0x80000000, 0xffff8000
+    TEST_RR_OP             8, sub, 0xffff8001, 0, 0x00007fff
+    TEST_RR_OP             9, sub, 0x7fffffff, 0x7fffffff, 0
+    TEST_RR_OP             10, sub, 0x7fff8000, 0x7fffffff, 0x00007fff
+    TEST_RR_OP             11, sub, 0x7fff8001, 0x80000000, 0x00007fff
+    TEST_RR_OP             12, sub, 0x80007fff, 0x7fffffff, 0xffff8000
+    TEST_RR_OP             13, sub, 1, 0, 0xffffffff
+    TEST_RR_OP             14, sub, 0xfffffffe, 0xffffffff, 1
+    TEST_RR_OP             15, sub, 0, 0xffffffff, 0xffffffff
+    TEST_RR_OP             16, sub, 0x80000002, 1, 0x7fffffff
+    TEST_RR_OP             17, sub, 0x7ffffffe, 0x7fffffff, 1
+    TEST_RR_OP             18, sub, 0x7fffffff, 0x80000000, 1
+    TEST_RR_OP             19, sub, 0x80000001, 0x80000000, 0xffffffff
+
+    TEST_RR_SRC1_EQ_DEST   20, sub, 2, 13, 11
+    TEST_RR_SRC2_EQ_DEST   21, sub, 2, 13, 11
+    TEST_RR_SRC12_EQ_DEST  22, sub, 0, 13
+    TEST_RR_DEST_BYPASS    23, sub, 2, 13, 11
+    TEST_RR_ZEROSRC1       24, sub, 15, 0xfffffff1
+    TEST_RR_ZEROSRC2       25, sub, 0xfffffff1, 0xfffffff1
+    TEST_RR_ZERODEST       26, sub, 16, 30
+
+    TEST_PASSFAIL
@@ -0,0 +1,60 @@
+# sw: store of a word
+.include "../test.inc"
+
+    TEST_ST_OP             2, lw, sw, 0x00aa00aa, 0, tdat
+    TEST_ST_OP             3, lw, sw, 0xaa00aa00, 4, tdat
+    TEST_ST_OP             4, lw, sw, 0x0aa00aa0, 8, tdat
+    TEST_ST_OP             5, lw, sw, 0xa00aa00a, 12, tdat
+
+    TEST_ST_OP             6, lw, sw, 0x00aa00aa, -12, tdat9
+    TEST_ST_OP             7, lw, sw, 0xaa00aa00, -8, tdat9
+    TEST_ST_OP             8, lw, sw, 0x0aa00aa0, -4, tdat9
+    TEST_ST_OP             9, lw, sw, 0xa00aa00a, 0, tdat9
+
+    # Only the low bits are stored, the following ones are left untouched
+    li gp, 10
+    la a1, tdat10
+    li a2, 0x12345678
+    sw a2, 0(a1)
+    lw a4, 0(a1)
+    TEST_CHECK a4, 0x12345678
+    lw a4, 4(a1)
+    TEST_CHECK a4, 0xdeadbeef
+
+    # Store followed by a load of the same address, in a loop
+    li gp, 11
+    li t0, 2
+    la a1, tdat
+loop:
+    sw t0, 0(a1)
+    lw a4, 0(a1)
+    bne a4, t0, fail
+    addi t0, t0, -1
+    bnez t0, loop
+
+    TEST_PASSFAIL
+
+.data
+tdat:
+tdat1:
+    .word 0xdeadbeef
+tdat2:
+    .word 0xdeadbeef
+tdat3:
+    .word 0xdeadbeef
+tdat4:
+    .word 0xdeadbeef
+tdat5:
+    .word 0xdeadbeef
+tdat6:
+    .word 0xdeadbeef
+tdat7:
+    .word 0xdeadbeef
+tdat8:
+    .word 0xdeadbeef
+tdat9:
+    .word 0xdeadbeef
+tdat10:
+    .word 0xdeadbeef
+tdat11:
+    .word 0xdeadbeef
@@ -0,0 +1,20 @@
+# xor: bitwise exclusive or
+.include "../test.inc"
+
+    TEST_RR_OP             2, xor, 0xf00ff00f, 0xff00ff00, 0x0f0f0f0f
+    TEST_RR_OP             3, xor, 0xff00ff00, 0x0ff00ff0, 0xf0f0f0f0
+    TEST_RR_OP             4, xor, 0x0ff00ff0, 0x00ff00ff, 0x0f0f0f0f
+    TEST_RR_OP             5, xor, 0x00ff00ff, 0xf00ff00f, 0xf0f0f0f0
+    TEST_RR_OP             6, xor, 0xffffffff, 0xffffffff, 0
+    TEST_RR_OP             7, xor, 0, 0xffffffff, 0xffffffff
+    TEST_RR_OP             8, xor, 0xffffffff, 0x80000000, 0x7fffffff
+
+    TEST_RR_SRC1_EQ_DEST   9, xor, 6, 13, 11
+    TEST_RR_SRC2_EQ_DEST   10, xor, 6, 13, 11
+    TEST_RR_SRC12_EQ_DEST  11, xor, 0, 13
+    TEST_RR_DEST_BYPASS    12, xor, 6, 13, 11
+    TEST_RR_ZEROSRC1       13, xor, 0xfffffff1, 0xfffffff1
+    TEST_RR_ZEROSRC2       14, xor, 0xfffffff1, 0xfffffff1
+    TEST_RR_ZERODEST       15, xor, 16, 30
+
+    TEST_PASSFAIL
@@ -0,0 +1,18 @@
+# xori: bitwise exclusive or with a sign-extended immediate
+.include "../test.inc"
+
+    TEST_IMM_OP            2, xori, 0x00ff000f, 0xff00ff00, -241
+    TEST_IMM_OP            3, xori, 0x0ff00f00, 0x0ff00ff0, 240
+    TEST_IMM_OP            4, xori, 0x00ff07f0, 0x00ff00ff, 1807
+    TEST_IMM_OP            5, xori, 0xf00ff0ff, 0xf00ff00f, 240
+    TEST_IMM_OP            6, xori, 0xedcba987, 0x12345678, -1
+    TEST_IMM_OP            7, xori, 0xedcbae78, 0x12345678, -2048
+    TEST_IMM_OP            8, xori, 0xfffff800, 0, -2048
+    TEST_IMM_OP            9, xori, 0xfffff800, 0xffffffff, 2047
+
+    TEST_IMM_SRC1_EQ_DEST  10, xori, 6, 13, 11
+    TEST_IMM_DEST_BYPASS   11, xori, 6, 13, 11
+    TEST_IMM_ZEROSRC1      12, xori, 0xffffff0f, -241
+    TEST_IMM_ZERODEST      13, xori, 33, 50
+
+    TEST_PASSFAIL
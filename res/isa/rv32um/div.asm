@@ -0,0 +1,25 @@
+# div: signed division, rounded toward zero, with division by zero and overflow
+.include "../test.inc"
+
+    TEST_RR_OP             2, div, 3, 20, 6
+    TEST_RR_OP             3, div, 0xfffffffd, 0xffffffec, 6
+    TEST_RR_OP             4, div, 0xfffffffd, 20, 0xfffffffa
+    TEST_RR_OP             5, div, 3, 0xffffffec, 0xfffffffa
+    TEST_RR_OP             6, div, 0x80000000, 0x80000000, 1
+    TEST_RR_OP             7, div, 0x80000000, 0x80000000, 0xffffffff
+    TEST_RR_OP             8, div, 0xffffffff, 0x80000000, 0
+    TEST_RR_OP             9, div, 0xffffffff, 1, 0
+    TEST_RR_OP             10, div, 0xffffffff, 0, 0
+    TEST_RR_OP             11, div, 0xfffffff9, 7, 0xffffffff
+    TEST_RR_OP             12, div, 0x3fffffff, 0x7fffffff, 2
+    TEST_RR_OP             13, div, 0xfffffffd, 0xfffffff9, 2
+
+    TEST_RR_SRC1_EQ_DEST   14, div, 3, 20, 6
+    TEST_RR_SRC2_EQ_DEST   15, div, 3, 20, 6
+    TEST_RR_SRC12_EQ_DEST  16, div, 1, 20
+    TEST_RR_DEST_BYPASS    17, div, 3, 20, 6
+    TEST_RR_ZEROSRC1       18, div, 0, 20
+    TEST_RR_ZEROSRC2       19, div, 0xffffffff, 20
+    TEST_RR_ZERODEST       20, div, 20, 6
+
+    TEST_PASSFAIL
@@ -0,0 +1,25 @@
+# divu: unsigned division, with division by zero
+.include "../test.inc"
+
+    TEST_RR_OP             2, divu, 3, 20, 6
+    TEST_RR_OP             3, divu, 0x2aaaaaa7, 0xffffffec, 6
+    TEST_RR_OP             4, divu, 0, 20, 0xfffffffa
+    TEST_RR_OP             5, divu, 0, 0xffffffec, 0xfffffffa
+    TEST_RR_OP             6, divu, 0x80000000, 0x80000000, 1
+    TEST_RR_OP             7, divu, 0, 0x80000000, 0xffffffff
+    TEST_RR_OP             8, divu, 0xffffffff, 0x80000000, 0
+    TEST_RR_OP             9, divu, 0xffffffff, 1, 0
+    TEST_RR_OP             10, divu, 0xffffffff, 0, 0
+    TEST_RR_OP             11, divu, 0, 7, 0xffffffff
+    TEST_RR_OP             12, divu, 0x3fffffff, 0x7fffffff, 2
+    TEST_RR_OP             13, divu, 0x7ffffffc, 0xfffffff9, 2
+
+    TEST_RR_SRC1_EQ_DEST   14, divu, 3, 20, 6
+    TEST_RR_SRC2_EQ_DEST   15, divu, 3, 20, 6
+    TEST_RR_SRC12_EQ_DEST  16, divu, 1, 20
+    TEST_RR_DEST_BYPASS    17, divu, 3, 20, 6
+    TEST_RR_ZEROSRC1       18, divu, 0, 20
+    TEST_RR_ZEROSRC2       19, divu, 0xffffffff, 20
+    TEST_RR_ZERODEST       20, divu, 20, 6
+
+    TEST_PASSFAIL
@@ -0,0 +1,29 @@
+# mul: multiplication, low 32 bits of the product
+.include "../test.inc"
+
+    TEST_RR_OP             2, mul, 0, 0, 0
+    TEST_RR_OP             3, mul, 1, 1, 1
+    TEST_RR_OP             4, mul, 21, 3, 7
+    TEST_RR_OP             5, mul, 0, 0, 0xffff8000
+    TEST_RR_OP             6, mul, 0, 0x80000000, 0
+    TEST_RR_OP             7, mul, 0, 0x80000000, 0xffff8000
+    TEST_RR_OP             8, mul, 0x0000ff7f, 0xaaaaaaab, 0x0002fe7d
+    TEST_RR_OP             9, mul, 0x0000ff7f, 0x0002fe7d, 0xaaaaaaab
+    TEST_RR_OP             10, mul, 0, 0xff000000, 0xff000000
+    TEST_RR_OP             11, mul, 1, 0xffffffff, 0xffffffff
+    TEST_RR_OP             12, mul, 0xffffffff, 0xffffffff, 1
+    TEST_RR_OP             13, mul, 0xffffffff, 1, 0xffffffff
+    TEST_RR_OP             14, mul, 0x80000000, 0x80000000, 0xffffffff
+    TEST_RR_OP             15, mul, 0, 0x80000000, 0x80000000
+    TEST_RR_OP             16, mul, 1, 0x7fffffff, 0x7fffffff
+    TEST_RR_OP             17, mul, 0x80000000, 0x7fffffff, 0x80000000
+
+    TEST_RR_SRC1_EQ_DEST   18, mul, 143, 13, 11
+    TEST_RR_SRC2_EQ_DEST   19, mul, 143, 13, 11
+    TEST_RR_SRC12_EQ_DEST  20, mul, 169, 13
+    TEST_RR_DEST_BYPASS    21, mul, 143, 13, 11
+    TEST_RR_ZEROSRC1       22, mul, 0, 13
+    TEST_RR_ZEROSRC2       23, mul, 0, 13
+    TEST_RR_ZERODEST       24, mul, 13, 11
+
+    TEST_PASSFAIL
@@ -0,0 +1,29 @@
+# mulh: multiplication of signed operands, high 32 bits of the product
+.include "../test.inc"
+
+    TEST_RR_OP             2, mulh, 0, 0, 0
+    TEST_RR_OP             3, mulh, 0, 1, 1
+    TEST_RR_OP             4, mulh, 0, 3, 7
+    TEST_RR_OP             5, mulh, 0, 0, 0xffff8000
+    TEST_RR_OP             6, mulh, 0, 0x80000000, 0
+    TEST_RR_OP             7, mulh, 0x00004000, 0x80000000, 0xffff8000
+    TEST_RR_OP             8, mulh, 0xffff0081, 0xaaaaaaab, 0x0002fe7d
+    TEST_RR_OP             9, mulh, 0xffff0081, 0x0002fe7d, 0xaaaaaaab
+    TEST_RR_OP             10, mulh, 0x00010000, 0xff000000, 0xff000000
+    TEST_RR_OP             11, mulh, 0, 0xffffffff, 0xffffffff
+    TEST_RR_OP             12, mulh, 0xffffffff, 0xffffffff, 1
+    TEST_RR_OP             13, mulh, 0xffffffff, 1, 0xffffffff
+    TEST_RR_OP             14, mulh, 0, 0x80000000, 0xffffffff
+    TEST_RR_OP             15, mulh, 0x40000000, 0x80000000, 0x80000000
+    TEST_RR_OP             16, mulh, 0x3fffffff, 0x7fffffff, 0x7fffffff
+    TEST_RR_OP             17, mulh, 0xc0000000, 0x7fffffff, 0x80000000
+
+    TEST_RR_SRC1_EQ_DEST   18, mulh, 0, 13, 11
+    TEST_RR_SRC2_EQ_DEST   19, mulh, 0, 13, 11
+    TEST_RR_SRC12_EQ_DEST  20, mulh, 0, 13
+    TEST_RR_DEST_BYPASS    21, mulh, 0, 13, 11
+    TEST_RR_ZEROSRC1       22, mulh, 0, 13
+    TEST_RR_ZEROSRC2       23, mulh, 0, 13
+    TEST_RR_ZERODEST       24, mulh, 13, 11
+
+    TEST_PASSFAIL
@@ -0,0 +1,29 @@
+# mulhsu: multiplication of a signed and an unsigned operand, high 32 bits of the product
+.include "../test.inc"
+
+    TEST_RR_OP             2, mulhsu, 0, 0, 0
+    TEST_RR_OP             3, mulhsu, 0, 1, 1
+    TEST_RR_OP             4, mulhsu, 0, 3, 7
+    TEST_RR_OP             5, mulhsu, 0, 0, 0xffff8000
+    TEST_RR_OP             6, mulhsu, 0, 0x80000000, 0
+    TEST_RR_OP             7, mulhsu, 0x80004000, 0x80000000, 0xffff8000
+    TEST_RR_OP             8, mulhsu, 0xffff0081, 0xaaaaaaab, 0x0002fe7d
+    TEST_RR_OP             9, mulhsu, 0x0001fefe, 0x0002fe7d, 0xaaaaaaab
+    TEST_RR_OP             10, mulhsu, 0xff010000, 0xff000000, 0xff000000
+    TEST_RR_OP             11, mulhsu, 0xffffffff, 0xffffffff, 0xffffffff
+    TEST_RR_OP             12, mulhsu, 0xffffffff, 0xffffffff, 1
+    TEST_RR_OP             13, mulhsu, 0, 1, 0xffffffff
+    TEST_RR_OP             14, mulhsu, 0x80000000, 0x80000000, 0xffffffff
+    TEST_RR_OP             15, mulhsu, 0xc0000000, 0x80000000, 0x80000000
+    TEST_RR_OP             16, mulhsu, 0x3fffffff, 0x7fffffff, 0x7fffffff
+    TEST_RR_OP             17, mulhsu, 0x3fffffff, 0x7fffffff, 0x80000000
+
+    TEST_RR_SRC1_EQ_DEST   18, mulhsu, 0, 13, 11
+    TEST_RR_SRC2_EQ_DEST   19, mulhsu, 0, 13, 11
+    TEST_RR_SRC12_EQ_DEST  20, mulhsu, 0, 13
+    TEST_RR_DEST_BYPASS    21, mulhsu, 0, 13, 11
+    TEST_RR_ZEROSRC1       22, mulhsu, 0, 13
+    TEST_RR_ZEROSRC2       23, mulhsu, 0, 13
+    TEST_RR_ZERODEST       24, mulhsu, 13, 11
+
+    TEST_PASSFAIL
@@ -0,0 +1,29 @@
+# mulhu: multiplication of unsigned operands, high 32 bits of the product
+.include "../test.inc"
+
+    TEST_RR_OP             2, mulhu, 0, 0, 0
+    TEST_RR_OP             3, mulhu, 0, 1, 1
+    TEST_RR_OP             4, mulhu, 0, 3, 7
+    TEST_RR_OP             5, mulhu, 0, 0, 0xffff8000
+    TEST_RR_OP             6, mulhu, 0, 0x80000000, 0
+    TEST_RR_OP             7, mulhu, 0x7fffc000, 0x80000000, 0xffff8000
+    TEST_RR_OP             8, mulhu, 0x0001fefe, 0xaaaaaaab, 0x0002fe7d
+    TEST_RR_OP             9, mulhu, 0x0001fefe, 0x0002fe7d, 0xaaaaaaab
+    TEST_RR_OP             10, mulhu, 0xfe010000, 0xff000000, 0xff000000
+    TEST_RR_OP             11, mulhu, 0xfffffffe, 0xffffffff, 0xffffffff
+    TEST_RR_OP             12, mulhu, 0, 0xffffffff, 1
+    TEST_RR_OP             13, mulhu, 0, 1, 0xffffffff
+    TEST_RR_OP             14, mulhu, 0x7fffffff, 0x80000000, 0xffffffff
+    TEST_RR_OP             15, mulhu, 0x40000000, 0x80000000, 0x80000000
+    TEST_RR_OP             16, mulhu, 0x3fffffff, 0x7fffffff, 0x7fffffff
+    TEST_RR_OP             17, mulhu, 0x3fffffff, 0x7fffffff, 0x80000000
+
+    TEST_RR_SRC1_EQ_DEST   18, mulhu, 0, 13, 11
+    TEST_RR_SRC2_EQ_DEST   19, mulhu, 0, 13, 11
+    TEST_RR_SRC12_EQ_DEST  20, mulhu, 0, 13
+    TEST_RR_DEST_BYPASS    21, mulhu, 0, 13, 11
+    TEST_RR_ZEROSRC1       22, mulhu, 0, 13
+    TEST_RR_ZEROSRC2       23, mulhu, 0, 13
+    TEST_RR_ZERODEST       24, mulhu, 13, 11
+
+    TEST_PASSFAIL
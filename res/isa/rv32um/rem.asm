@@ -0,0 +1,25 @@
+# rem: signed remainder, with the sign of the dividend, division by zero and overflow
+.include "../test.inc"
+
+    TEST_RR_OP             2, rem, 2, 20, 6
+    TEST_RR_OP             3, rem, 0xfffffffe, 0xffffffec, 6
+    TEST_RR_OP             4, rem, 2, 20, 0xfffffffa
+    TEST_RR_OP             5, rem, 0xfffffffe, 0xffffffec, 0xfffffffa
+    TEST_RR_OP             6, rem, 0, 0x80000000, 1
+    TEST_RR_OP             7, rem, 0, 0x80000000, 0xffffffff
+    TEST_RR_OP             8, rem, 0x80000000, 0x80000000, 0
+    TEST_RR_OP             9, rem, 1, 1, 0
+    TEST_RR_OP             10, rem, 0, 0, 0
+    TEST_RR_OP             11, rem, 0, 7, 0xffffffff
+    TEST_RR_OP             12, rem, 1, 0x7fffffff, 2
+    TEST_RR_OP             13, rem, 0xffffffff, 0xfffffff9, 2
+
+    TEST_RR_SRC1_EQ_DEST   14, rem, 2, 20, 6
+    TEST_RR_SRC2_EQ_DEST   15, rem, 2, 20, 6
+    TEST_RR_SRC12_EQ_DEST  16, rem, 0, 20
+    TEST_RR_DEST_BYPASS    17, rem, 2, 20, 6
+    TEST_RR_ZEROSRC1       18, rem, 0, 20
+    TEST_RR_ZEROSRC2       19, rem, 20, 20
+    TEST_RR_ZERODEST       20, rem, 20, 6
+
+    TEST_PASSFAIL
@@ -0,0 +1,25 @@
+# remu: unsigned remainder, with division by zero
+.include "../test.inc"
+
+    TEST_RR_OP             2, remu, 2, 20, 6
+    TEST_RR_OP             3, remu, 2, 0xffffffec, 6
+    TEST_RR_OP             4, remu, 20, 20, 0xfffffffa
+    TEST_RR_OP             5, remu, 0xffffffec, 0xffffffec, 0xfffffffa
+    TEST_RR_OP             6, remu, 0, 0x80000000, 1
+    TEST_RR_OP             7, remu, 0x80000000, 0x80000000, 0xffffffff
+    TEST_RR_OP             8, remu, 0x80000000, 0x80000000, 0
+    TEST_RR_OP             9, remu, 1, 1, 0
+    TEST_RR_OP             10, remu, 0, 0, 0
+    TEST_RR_OP             11, remu, 7, 7, 0xffffffff
+    TEST_RR_OP             12, remu, 1, 0x7fffffff, 2
+    TEST_RR_OP             13, remu, 1, 0xfffffff9, 2
+
+    TEST_RR_SRC1_EQ_DEST   14, remu, 2, 20, 6
+    TEST_RR_SRC2_EQ_DEST   15, remu, 2, 20, 6
+    TEST_RR_SRC12_EQ_DEST  16, remu, 0, 20
+    TEST_RR_DEST_BYPASS    17, remu, 2, 20, 6
+    TEST_RR_ZEROSRC1       18, remu, 0, 20
+    TEST_RR_ZEROSRC2       19, remu, 20, 20
+    TEST_RR_ZERODEST       20, remu, 20, 6
+
+    TEST_PASSFAIL
@@ -1,7 +1,9 @@
 package risc
 
 import (
+	"cmp"
 	"fmt"
+	"slices"
 
 	"github.com/teivah/majorana/proc/comp"
 )
@@ -14,53 +16,211 @@ type ExecutionContext struct {
 	ReadRegisters   []RegisterType
 }
 
+func (e ExecutionContext) String() string {
+	return fmt.Sprintf("%s (sequence %d)", e.InstructionType, e.SequenceID)
+}
+
 type Application struct {
+	// Instructions is indexed by pc/4, or by pc/2 if the application is
+	// compressed. In the latter case, the second half of a 32-bit instruction is
+	// nil.
 	Instructions []InstructionRunner
-	Labels       map[string]int32
+	// Compressed is set if the application contains 16-bit instructions.
+	Compressed bool
+	Labels     map[string]int32
+	// DataLabels holds the labels of Labels defined in a data section, whose
+	// value is a memory address rather than a pc.
+	DataLabels map[string]bool
+	// Entry is the pc of the first instruction to execute.
+	Entry int32
+	// Segments is the initial memory image of the application.
+	Segments []Segment
+	// Warnings are the diagnostics of the parser not failing it.
+	Warnings []*ParseError
+}
+
+// Instruction returns the instruction starting at a given pc.
+func (app Application) Instruction(pc int32) (InstructionRunner, bool) {
+	if pc < 0 || pc%app.alignment() != 0 || pc >= app.End() {
+		return nil, false
+	}
+	runner := app.Instructions[pc/app.alignment()]
+	return runner, runner != nil
+}
+
+// Size returns the size in bytes of the instruction at a given pc.
+func (app Application) Size(pc int32) int32 {
+	if runner, exists := app.Instruction(pc); exists {
+		return InstructionSize(runner)
+	}
+	return app.alignment()
+}
+
+// End returns the pc following the last instruction.
+func (app Application) End() int32 {
+	return int32(len(app.Instructions)) * app.alignment()
+}
+
+func (app Application) alignment() int32 {
+	if app.Compressed {
+		return 2
+	}
+	return 4
+}
+
+// layout indexes instructions by their pc, by pc/2 if one of them is
+// compressed or misaligned on 4 bytes and by pc/4 otherwise.
+func layout(pcs []int32, runners []InstructionRunner) ([]InstructionRunner, bool) {
+	alignment := int32(4)
+	for i, runner := range runners {
+		if InstructionSize(runner) != 4 || pcs[i]%4 != 0 {
+			alignment = 2
+			break
+		}
+	}
+
+	var instructions []InstructionRunner
+	for i, runner := range runners {
+		end := int((pcs[i] + InstructionSize(runner)) / alignment)
+		if end > len(instructions) {
+			instructions = append(instructions, make([]InstructionRunner, end-len(instructions))...)
+		}
+		instructions[pcs[i]/alignment] = runner
+	}
+	return instructions, alignment == 2
+}
+
+// Segment is a contiguous chunk of memory, loaded at a given address before
+// the application starts.
+type Segment struct {
+	Address int32
+	Data    []int8
 }
 
 type Context struct {
-	Registers                   map[RegisterType]int32
+	Registers map[RegisterType]int32
+	// FRegisters holds the raw bits of the floating-point registers; a
+	// single-precision value is NaN-boxed in the 64 bits.
+	FRegisters map[RegisterType]uint64
+	// FCSR holds the accrued exception flags (bits 0-4) and the dynamic
+	// rounding mode (bits 5-7) of the floating-point instructions.
+	FCSR int32
+	// Cycles and Instret back the cycle, time and instret counters. They are
+	// maintained by the processor running the application.
+	Cycles  int64
+	Instret int64
+	// Env services the ecall and ebreak instructions.
+	Env Environment
+	// Exited is set once the application called exit, with ExitCode.
+	Exited   bool
+	ExitCode int32
+	// Mtvec holds the trap handler address; Mepc, Mcause and Mtval describe the
+	// last exception taken.
+	Mtvec  int32
+	Mepc   int32
+	Mcause int32
+	Mtval  int32
+	// Mstatus holds the global interrupt enable bits, Mie the enabled
+	// interrupts and Mip the pending ones, besides the ones raised by the CLINT.
+	// Mscratch is left to the trap handlers.
+	Mstatus                     int32
+	Mie                         int32
+	Mip                         int32
+	Mscratch                    int32
+	CLINT                       CLINT
 	Transaction                 map[RegisterType]transactionUnit
 	PendingWriteRegisters       map[RegisterType]int
 	PendingReadRegisters        map[RegisterType]int
 	pendingWriteMemoryIntention map[int32]map[int]struct{}
-	Memory                      []int8
-	Debug                       bool
+	// pendingMemoryAccesses holds the sequence ID of the memory accesses in
+	// flight, whether they write the memory and their addresses once known.
+	pendingMemoryAccesses map[int32]memoryAccess
+	Memory                []int8
+	Debug                 bool
+	// OnRetire, if set, is notified of each instruction retired, in program
+	// order. An error stops the processor.
+	OnRetire               func(Retirement) error
+	speculativeRetirements []speculativeRetirement
+	// decoded counts the instructions decoded, in program order.
+	decoded int64
 	// SequenceID represents a monotonic ID for the sequence.
 	// It increments during a jump.
-	sequenceID     int32
-	committedRAT   *comp.RAT[RegisterType, int32]
+	sequenceID int32
+	// committedRAT holds the last committed values, tagged with the sequence
+	// ID of the instruction writing them, as an instruction still in flight may
+	// have to read a value preceding the last one.
+	committedRAT *comp.RAT[RegisterType, transactionUnit]
+	// reservation is the address reserved by lr.w, if reserved is set.
+	reservation int32
+	reserved    bool
+	// brk is the program break, initially at the end of the application's
+	// segments.
+	brk            int32
+	initialBreak   int32
 	transactionRAT *comp.RAT[RegisterType, transactionUnit]
 	rat            bool
 }
 
 type transactionUnit struct {
 	sequenceID int32
-	value      int32
+	// value is the raw value of an integer or floating-point register.
+	value int64
 }
 
 const ratLength = 10
 
 func NewContext(debug bool, memoryBytes int, rat bool) *Context {
 	return &Context{
-		Registers:                   make(map[RegisterType]int32),
+		// Returning from the entry point exits
+		Registers:                   map[RegisterType]int32{Ra: ExitPc},
+		FRegisters:                  make(map[RegisterType]uint64),
 		Transaction:                 make(map[RegisterType]transactionUnit),
 		PendingWriteRegisters:       make(map[RegisterType]int),
 		PendingReadRegisters:        make(map[RegisterType]int),
 		pendingWriteMemoryIntention: make(map[int32]map[int]struct{}),
+		pendingMemoryAccesses:       make(map[int32]memoryAccess),
 		Memory:                      make([]int8, memoryBytes),
 		Debug:                       debug,
-		committedRAT:                comp.NewRAT[RegisterType, int32](ratLength),
+		Env:                         NewLinux(),
+		CLINT:                       newCLINT(),
+		committedRAT:                comp.NewRAT[RegisterType, transactionUnit](ratLength),
 		transactionRAT:              comp.NewRAT[RegisterType, transactionUnit](ratLength),
 		rat:                         rat,
 	}
 }
 
+// Load copies the memory image of an application into the memory.
+func (ctx *Context) Load(app Application) error {
+	for _, segment := range app.Segments {
+		if segment.Address < 0 || int(segment.Address)+len(segment.Data) > len(ctx.Memory) {
+			return fmt.Errorf("segment at %#x of %d bytes does not fit in %d bytes of memory",
+				segment.Address, len(segment.Data), len(ctx.Memory))
+		}
+		copy(ctx.Memory[segment.Address:], segment.Data)
+		ctx.initialBreak = max(ctx.initialBreak, segment.Address+int32(len(segment.Data)))
+	}
+	ctx.brk = ctx.initialBreak
+	return nil
+}
+
+func (ctx *Context) reserve(addr int32) {
+	ctx.reservation = addr
+	ctx.reserved = true
+}
+
+// releaseReservation invalidates the current reservation and returns whether
+// addr was reserved.
+func (ctx *Context) releaseReservation(addr int32) bool {
+	valid := ctx.reserved && ctx.reservation == addr
+	ctx.reserved = false
+	return valid
+}
+
 func (ctx *Context) Flush() {
 	ctx.PendingWriteRegisters = make(map[RegisterType]int)
 	ctx.PendingReadRegisters = make(map[RegisterType]int)
 	ctx.pendingWriteMemoryIntention = make(map[int32]map[int]struct{})
+	ctx.pendingMemoryAccesses = make(map[int32]memoryAccess)
 }
 
 func (ctx *Context) SequenceID(pc int32) int32 {
@@ -103,59 +263,157 @@ func (ctx *Context) DeletePendingWriteMemoryIntention(alignedAddr int32, id int)
 	delete(v, id)
 }
 
+// memoryAccess is a memory access in flight.
+type memoryAccess struct {
+	write bool
+	// addrs holds the accessed addresses, nil as long as they aren't known
+	addrs []int32
+}
+
+func (ctx *Context) AddPendingMemoryAccess(sequenceID int32, write bool) {
+	ctx.pendingMemoryAccesses[sequenceID] = memoryAccess{write: write}
+}
+
+// PendingMemoryAccess returns whether a memory access preceding sequenceID is
+// in flight on the same addresses. A read only has to wait for the previous
+// writes, whereas a write waits for all the previous accesses. The addresses
+// of the current access are recorded for the following ones; an access whose
+// addresses aren't known conflicts with all the others.
+func (ctx *Context) PendingMemoryAccess(sequenceID int32, write bool, addrs []int32) bool {
+	if access, exists := ctx.pendingMemoryAccesses[sequenceID]; exists && len(addrs) != 0 {
+		access.addrs = addrs
+		ctx.pendingMemoryAccesses[sequenceID] = access
+	}
+	for id, access := range ctx.pendingMemoryAccesses {
+		if id >= sequenceID || !(write || access.write) {
+			continue
+		}
+		if len(addrs) == 0 || access.addrs == nil || overlap(addrs, access.addrs) {
+			return true
+		}
+	}
+	return false
+}
+
+func overlap(a, b []int32) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (ctx *Context) DeletePendingMemoryAccess(sequenceID int32) {
+	delete(ctx.pendingMemoryAccesses, sequenceID)
+}
+
 func (ctx *Context) WriteRegister(exe Execution) {
-	ctx.Registers[exe.Register] = exe.RegisterValue
+	ctx.setRegister(exe.Register, exe.value())
+}
+
+// setRegister writes the raw value of an integer or floating-point register.
+func (ctx *Context) setRegister(register RegisterType, value int64) {
+	if register.IsFloat() {
+		ctx.FRegisters[register] = uint64(value)
+	} else {
+		ctx.Registers[register] = int32(value)
+	}
 }
 
 func (ctx *Context) TransactionWriteRegister(exe Execution, sequenceID int32) {
-	ctx.Transaction[exe.Register] = transactionUnit{sequenceID, exe.RegisterValue}
+	ctx.Transaction[exe.Register] = transactionUnit{sequenceID, exe.value()}
 }
 
-func (ctx *Context) Commit() {
+// Commit writes the registers written by the instructions preceding a
+// sequence ID. The following ones depend on a branch not resolved yet.
+func (ctx *Context) Commit(sequenceID int32) {
 	for register, tu := range ctx.Transaction {
-		ctx.Registers[register] = tu.value
+		if tu.sequenceID < sequenceID {
+			ctx.setRegister(register, tu.value)
+			delete(ctx.Transaction, register)
+		}
 	}
-	ctx.Transaction = make(map[RegisterType]transactionUnit)
 }
 
+// Rollback discards the registers written by the instructions following a
+// sequence ID.
 func (ctx *Context) Rollback(sequenceID int32) {
 	for register, tu := range ctx.Transaction {
-		if tu.sequenceID < sequenceID {
-			ctx.Registers[register] = tu.value
+		if tu.sequenceID > sequenceID {
+			delete(ctx.Transaction, register)
 		}
 	}
-	ctx.Transaction = make(map[RegisterType]transactionUnit)
 }
 
 func (ctx *Context) InitRAT() {
 	for k, v := range ctx.Registers {
-		ctx.committedRAT.Write(k, v)
+		ctx.committedRAT.Write(k, transactionUnit{value: int64(v)})
+	}
+	for k, v := range ctx.FRegisters {
+		ctx.committedRAT.Write(k, transactionUnit{value: int64(v)})
 	}
 }
 
 func (ctx *Context) TransactionRATWrite(exe Execution, sequenceID int32) {
-	ctx.transactionRAT.Write(exe.Register, transactionUnit{sequenceID, exe.RegisterValue})
-}
-
-func (ctx *Context) RATCommit() {
-	for register, tu := range ctx.transactionRAT.Values() {
-		ctx.committedRAT.Write(register, tu.value)
+	if v, _ := ctx.committedRAT.Read(exe.Register); sequenceID < v.sequenceID {
+		// A following instruction writing the register is already committed
+		return
 	}
-	ctx.transactionRAT = comp.NewRAT[RegisterType, transactionUnit](ratLength)
+	ctx.transactionRAT.Write(exe.Register, transactionUnit{sequenceID, exe.value()})
 }
 
-func (ctx *Context) RATRollback(sequenceID int32) {
-	for register, tu := range ctx.transactionRAT.FindValues(func(u transactionUnit) bool {
+// RATCommit commits the values written by the instructions preceding a
+// sequence ID, in the order of their sequence IDs, as an older instruction, a
+// load for instance, may complete last. The values written by the following
+// instructions are kept, as they depend on a branch not resolved yet.
+func (ctx *Context) RATCommit(sequenceID int32) {
+	for register, units := range ctx.transactionRAT.FindAllValues(func(u transactionUnit) bool {
 		return u.sequenceID < sequenceID
 	}) {
-		ctx.committedRAT.Write(register, tu.value)
+		for _, tu := range sortTransactions(units) {
+			ctx.committedRAT.Write(register, tu)
+		}
 	}
+	ctx.keepTransactions(func(u transactionUnit) bool {
+		return u.sequenceID >= sequenceID
+	})
+}
+
+// RATRollback discards the values written by the instructions following a
+// sequence ID.
+func (ctx *Context) RATRollback(sequenceID int32) {
+	ctx.keepTransactions(func(u transactionUnit) bool {
+		return u.sequenceID <= sequenceID
+	})
+}
+
+func (ctx *Context) keepTransactions(predicate func(transactionUnit) bool) {
+	kept := ctx.transactionRAT.FindAllValues(predicate)
 	ctx.transactionRAT = comp.NewRAT[RegisterType, transactionUnit](ratLength)
+	for register, units := range kept {
+		for _, tu := range sortTransactions(units) {
+			ctx.transactionRAT.Write(register, tu)
+		}
+	}
+}
+
+func sortTransactions(units []transactionUnit) []transactionUnit {
+	slices.SortStableFunc(units, func(a, b transactionUnit) int {
+		return cmp.Compare(a.sequenceID, b.sequenceID)
+	})
+	return units
+}
+
+func youngerTransaction(a, b transactionUnit) bool {
+	return a.sequenceID < b.sequenceID
 }
 
 func (ctx *Context) RATFlush() {
 	for k, v := range ctx.committedRAT.Values() {
-		ctx.Registers[k] = v
+		ctx.setRegister(k, v.value)
 	}
 }
 
@@ -210,8 +468,8 @@ func (ctx *Context) DeletePendingRegisters(readRegisters, writeRegisters []Regis
 func (ctx *Context) DeletePendingWriteRegisters(registers []RegisterType) {
 	for _, register := range registers {
 		ctx.PendingWriteRegisters[register]--
-		if ctx.Registers[register] <= 0 {
-			delete(ctx.Registers, register)
+		if ctx.PendingWriteRegisters[register] <= 0 {
+			delete(ctx.PendingWriteRegisters, register)
 		}
 	}
 }
@@ -321,9 +579,22 @@ type Execution struct {
 	RegisterChange bool
 	Register       RegisterType
 	RegisterValue  int32
+	// FRegisterValue is the value written if Register is a floating-point
+	// register.
+	FRegisterValue uint64
 	MemoryChange   bool
 	MemoryChanges  map[int32]int8
 	NextPc         int32
 	PcChange       bool
-	Return         bool
+	// Exception is set if the instruction raised an exception, in which case
+	// the execution doesn't hold any other change.
+	Exception *Exception
+}
+
+// value returns the raw value written to the register.
+func (exe Execution) value() int64 {
+	if exe.Register.IsFloat() {
+		return int64(exe.FRegisterValue)
+	}
+	return int64(exe.RegisterValue)
 }
@@ -0,0 +1,133 @@
+package risc
+
+import "math"
+
+// The CLINT registers, mapped in memory from CLINTBase. They are only
+// accessible using lw and sw.
+const (
+	CLINTBase     int32 = 0x2000000
+	CLINTMsip     int32 = CLINTBase
+	CLINTMtimecmp int32 = CLINTBase + 0x4000
+	CLINTMtime    int32 = CLINTBase + 0xbff8
+)
+
+// CLINT is the core-local interruptor of the hart: it raises the machine
+// software interrupt while msip is set, and the machine timer interrupt while
+// mtime is greater than or equal to mtimecmp. mtime is the cycle counter.
+type CLINT struct {
+	Msip     int32
+	Mtimecmp uint64
+}
+
+func newCLINT() CLINT {
+	// No timer interrupt until mtimecmp is written
+	return CLINT{Mtimecmp: math.MaxUint64}
+}
+
+func (ctx *Context) mtime() uint64 {
+	return uint64(ctx.Cycles)
+}
+
+// loadDevice returns the word at a given address if it belongs to a device.
+func (ctx *Context) loadDevice(addr int32) (int32, bool) {
+	switch addr {
+	case CLINTMsip:
+		return ctx.CLINT.Msip, true
+	case CLINTMtimecmp:
+		return int32(ctx.CLINT.Mtimecmp), true
+	case CLINTMtimecmp + 4:
+		return int32(ctx.CLINT.Mtimecmp >> 32), true
+	case CLINTMtime:
+		return int32(ctx.mtime()), true
+	case CLINTMtime + 4:
+		return int32(ctx.mtime() >> 32), true
+	}
+	return 0, false
+}
+
+// isDevice returns whether an address belongs to a device.
+func isDevice(addr int32) bool {
+	switch addr {
+	case CLINTMsip, CLINTMtimecmp, CLINTMtimecmp + 4, CLINTMtime, CLINTMtime + 4:
+		return true
+	}
+	return false
+}
+
+// overlapsDevice returns whether an access of size bytes at a given address
+// overlaps a device register.
+func overlapsDevice(addr, size int32) bool {
+	for _, device := range []int32{CLINTMsip, CLINTMtimecmp, CLINTMtimecmp + 4, CLINTMtime, CLINTMtime + 4} {
+		if addr < device+4 && device < addr+size {
+			return true
+		}
+	}
+	return false
+}
+
+// StoresToDevice returns whether an instruction stores to a device. As only sw
+// writes a device, the other stores raising an access fault, it's the only
+// instruction checked. As the device is written when the instruction is
+// executed, a processor executing instructions speculatively or out of order
+// has to execute it once the previous instructions are known to be retired and
+// their memory accesses completed.
+func (ctx *Context) StoresToDevice(runner InstructionRunner, sequenceID int32) bool {
+	if c, ok := runner.(*compressed); ok {
+		runner = c.InstructionRunner
+	}
+	op, ok := runner.(*sw)
+	if !ok {
+		return false
+	}
+	return isDevice(registerRead(ctx, op.forward, op.rd, sequenceID) + op.offset)
+}
+
+// storeDevice writes a word at a given address if it belongs to a device. As
+// mtime is the cycle counter, it can't be written.
+func (ctx *Context) storeDevice(addr, value int32) bool {
+	switch addr {
+	case CLINTMsip:
+		ctx.CLINT.Msip = value & 0x1
+	case CLINTMtimecmp:
+		ctx.CLINT.Mtimecmp = ctx.CLINT.Mtimecmp&^0xffffffff | uint64(uint32(value))
+	case CLINTMtimecmp + 4:
+		ctx.CLINT.Mtimecmp = ctx.CLINT.Mtimecmp&0xffffffff | uint64(uint32(value))<<32
+	case CLINTMtime, CLINTMtime + 4:
+	default:
+		return false
+	}
+	return true
+}
+
+// pendingInterrupts returns the value of mip: the interrupts raised by the
+// CLINT and the ones set in Mip.
+func (ctx *Context) pendingInterrupts() int32 {
+	mip := ctx.Mip
+	if ctx.CLINT.Msip != 0 {
+		mip |= MSIP
+	}
+	if ctx.mtime() >= ctx.CLINT.Mtimecmp {
+		mip |= MTIP
+	}
+	return mip
+}
+
+// Interrupt returns the interrupt to take before executing the instruction at
+// a given pc, or nil if the interrupts are disabled or none of the enabled ones
+// is pending. The external interrupt has the highest priority, then the
+// software and the timer ones.
+func (ctx *Context) Interrupt(pc int32) *Exception {
+	if ctx.Mstatus&MstatusMIE == 0 {
+		return nil
+	}
+	pending := ctx.pendingInterrupts() & ctx.Mie
+	switch {
+	case pending&MEIP != 0:
+		return &Exception{Cause: CauseMachineExternalInterrupt, Pc: pc}
+	case pending&MSIP != 0:
+		return &Exception{Cause: CauseMachineSoftwareInterrupt, Pc: pc}
+	case pending&MTIP != 0:
+		return &Exception{Cause: CauseMachineTimerInterrupt, Pc: pc}
+	}
+	return nil
+}
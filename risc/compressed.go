@@ -0,0 +1,863 @@
+package risc
+
+import (
+	"fmt"
+	"strings"
+)
+
+// compressed is a 16-bit instruction of the C extension. It runs as the 32-bit
+// instruction it expands to.
+type compressed struct {
+	InstructionRunner
+}
+
+func (op *compressed) Run(ctx *Context, labels map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	exe, err := op.InstructionRunner.Run(ctx, labels, pc, memory, sequenceID)
+	if err != nil {
+		return Execution{}, err
+	}
+	// A jump links the address of the next instruction, which is 2 bytes ahead
+	switch op.InstructionType() {
+	case Jal, Jalr:
+		exe.Register, exe.RegisterValue = IsRegisterChange(exe.Register, pc+2)
+	case Ebreak:
+		if exe.NextPc == pc+4 {
+			exe.NextPc = pc + 2
+		}
+	}
+	return exe, nil
+}
+
+// String returns the compressed instruction expanding to the underlying
+// instruction or, if there is none, the underlying instruction.
+func (op *compressed) String() string {
+	switch r := op.InstructionRunner.(type) {
+	case *add:
+		if r.rs1 == Zero {
+			return fmt.Sprintf("c.mv %s, %s", registerName(r.rd), registerName(r.rs2))
+		}
+		if r.rs1 == r.rd {
+			return fmt.Sprintf("c.add %s, %s", registerName(r.rd), registerName(r.rs2))
+		}
+	case *and:
+		return compressedArithmetic("c.and", r.rd, r.rs1, r.rs2, op)
+	case *or:
+		return compressedArithmetic("c.or", r.rd, r.rs1, r.rs2, op)
+	case *sub:
+		return compressedArithmetic("c.sub", r.rd, r.rs1, r.rs2, op)
+	case *xor:
+		return compressedArithmetic("c.xor", r.rd, r.rs1, r.rs2, op)
+	case *addi:
+		switch {
+		case r.rd == Zero && r.rs == Zero && r.imm == 0:
+			return "c.nop"
+		case r.rs == Zero:
+			return fmt.Sprintf("c.li %s, %d", registerName(r.rd), r.imm)
+		case r.rd == Sp && r.rs == Sp && (r.imm < -32 || r.imm > 31 || r.imm == 0):
+			return fmt.Sprintf("c.addi16sp sp, %d", r.imm)
+		case r.rs == Sp && r.rd != Sp:
+			return fmt.Sprintf("c.addi4spn %s, sp, %d", registerName(r.rd), r.imm)
+		case r.rs == r.rd:
+			return fmt.Sprintf("c.addi %s, %d", registerName(r.rd), r.imm)
+		}
+	case *andi:
+		if r.rs == r.rd {
+			return fmt.Sprintf("c.andi %s, %d", registerName(r.rd), r.imm)
+		}
+	case *slli:
+		return compressedShift("c.slli", r.rd, r.rs, r.imm, op)
+	case *srli:
+		return compressedShift("c.srli", r.rd, r.rs, r.imm, op)
+	case *srai:
+		return compressedShift("c.srai", r.rd, r.rs, r.imm, op)
+	case *beqz, *bnez, *j, *lw, *sw, *fload, *fstore:
+		s := op.InstructionRunner.String()
+		if base, ok := compressedStackBase(op.InstructionRunner); ok && base == Sp {
+			name, args, _ := strings.Cut(s, " ")
+			return "c." + name + "sp " + args
+		}
+		return "c." + s
+	case *ebreak:
+		return "c.ebreak"
+	case *jal:
+		if r.rd == Ra {
+			return "c.jal " + formatTarget(r.label, r.offset)
+		}
+	case *jalr:
+		if r.imm == 0 && r.rd == Ra {
+			return "c.jalr " + registerName(r.rs)
+		}
+		if r.imm == 0 && r.rd == Zero {
+			return "c.jr " + registerName(r.rs)
+		}
+	case *lui:
+		// The 6-bit immediate is written as the 20 upper bits
+		return fmt.Sprintf("c.lui %s, %#x", registerName(r.rd), uint32(r.imm)&0xfffff)
+	}
+	return op.InstructionRunner.String()
+}
+
+// compressedArithmetic formats c.and, c.or, c.sub and c.xor, whose destination
+// is also the first source.
+func compressedArithmetic(name string, rd, rs1, rs2 RegisterType, op *compressed) string {
+	if rd != rs1 {
+		return op.InstructionRunner.String()
+	}
+	return fmt.Sprintf("%s %s, %s", name, registerName(rd), registerName(rs2))
+}
+
+// compressedShift formats c.slli, c.srli and c.srai, whose destination is also
+// the source.
+func compressedShift(name string, rd, rs RegisterType, imm int32, op *compressed) string {
+	if rd != rs {
+		return op.InstructionRunner.String()
+	}
+	return fmt.Sprintf("%s %s, %d", name, registerName(rd), imm)
+}
+
+// compressedStackBase returns the base register of a load or a store.
+func compressedStackBase(runner InstructionRunner) (RegisterType, bool) {
+	switch r := runner.(type) {
+	case *lw:
+		return r.rs, true
+	case *sw:
+		return r.rd, true
+	case *fload:
+		return r.rs, true
+	case *fstore:
+		return r.rd, true
+	}
+	return 0, false
+}
+
+// InstructionSize returns the size in bytes of an instruction.
+func InstructionSize(runner InstructionRunner) int32 {
+	if _, ok := runner.(*compressed); ok {
+		return 2
+	}
+	return 4
+}
+
+// isCompressedEncoding returns whether the lowest bits of an instruction
+// denote a 16-bit encoding.
+func isCompressedEncoding(half uint16) bool {
+	return half&0x3 != 0x3
+}
+
+// DecodeCompressedInstruction decodes a single 16-bit instruction.
+func DecodeCompressedInstruction(half uint16) (InstructionRunner, error) {
+	runner, err := decodeCompressed(half)
+	if err != nil {
+		return nil, err
+	}
+	return &compressed{runner}, nil
+}
+
+func decodeCompressed(half uint16) (InstructionRunner, error) {
+	if !isCompressedEncoding(half) {
+		return nil, fmt.Errorf("invalid instruction %#04x: not a 16-bit encoding", half)
+	}
+
+	h := uint32(half)
+	funct3 := h >> 13
+	// Full register fields
+	rd := RegisterType((h >> 7) & 0x1f)
+	rs2 := RegisterType((h >> 2) & 0x1f)
+	// Register fields restricted to x8-x15
+	rdPrime := RegisterType((h>>2)&0x7) + S0
+	rs1Prime := RegisterType((h>>7)&0x7) + S0
+
+	switch h & 0x3 {
+	case 0x0:
+		switch funct3 {
+		case 0x0:
+			imm := bits(h, 12, 11)<<4 | bits(h, 10, 7)<<6 | bits(h, 6, 6)<<2 | bits(h, 5, 5)<<3
+			if imm != 0 {
+				return &addi{rd: rdPrime, rs: Sp, imm: int32(imm)}, nil
+			}
+		case 0x1:
+			return &fload{instructionType: Fld, rd: fregister(rdPrime), offset: offsetD(h), rs: rs1Prime}, nil
+		case 0x2:
+			return &lw{rd: rdPrime, offset: offsetW(h), rs: rs1Prime}, nil
+		case 0x3:
+			return &fload{instructionType: Flw, rd: fregister(rdPrime), offset: offsetW(h), rs: rs1Prime}, nil
+		case 0x5:
+			return &fstore{instructionType: Fsd, rs: fregister(rdPrime), offset: offsetD(h), rd: rs1Prime}, nil
+		case 0x6:
+			return &sw{rs: rdPrime, offset: offsetW(h), rd: rs1Prime}, nil
+		case 0x7:
+			return &fstore{instructionType: Fsw, rs: fregister(rdPrime), offset: offsetW(h), rd: rs1Prime}, nil
+		}
+	case 0x1:
+		imm := signExtend(bits(h, 12, 12)<<5|bits(h, 6, 2), 6)
+		switch funct3 {
+		case 0x0:
+			return &addi{rd: rd, rs: rd, imm: imm}, nil
+		case 0x1:
+			return &jal{rd: Ra, offset: offsetJ(h)}, nil
+		case 0x2:
+			return &addi{rd: rd, rs: Zero, imm: imm}, nil
+		case 0x3:
+			if rd == Sp {
+				imm := signExtend(bits(h, 12, 12)<<9|bits(h, 6, 6)<<4|bits(h, 5, 5)<<6|bits(h, 4, 3)<<7|bits(h, 2, 2)<<5, 10)
+				if imm != 0 {
+					return &addi{rd: Sp, rs: Sp, imm: imm}, nil
+				}
+				break
+			}
+			if imm != 0 && rd != Zero {
+				return &lui{rd: rd, imm: imm}, nil
+			}
+		case 0x4:
+			switch bits(h, 11, 10) {
+			case 0x0:
+				if bits(h, 12, 12) == 0 {
+					return &srli{rd: rs1Prime, rs: rs1Prime, imm: int32(bits(h, 6, 2))}, nil
+				}
+			case 0x1:
+				if bits(h, 12, 12) == 0 {
+					return &srai{rd: rs1Prime, rs: rs1Prime, imm: int32(bits(h, 6, 2))}, nil
+				}
+			case 0x2:
+				return &andi{rd: rs1Prime, rs: rs1Prime, imm: imm}, nil
+			case 0x3:
+				if bits(h, 12, 12) != 0 {
+					break
+				}
+				switch bits(h, 6, 5) {
+				case 0x0:
+					return &sub{rd: rs1Prime, rs1: rs1Prime, rs2: rdPrime}, nil
+				case 0x1:
+					return &xor{rd: rs1Prime, rs1: rs1Prime, rs2: rdPrime}, nil
+				case 0x2:
+					return &or{rd: rs1Prime, rs1: rs1Prime, rs2: rdPrime}, nil
+				case 0x3:
+					return &and{rd: rs1Prime, rs1: rs1Prime, rs2: rdPrime}, nil
+				}
+			}
+		case 0x5:
+			return &jal{rd: Zero, offset: offsetJ(h)}, nil
+		case 0x6:
+			return &beq{rs1: rs1Prime, rs2: Zero, offset: offsetB(h)}, nil
+		case 0x7:
+			return &bne{rs1: rs1Prime, rs2: Zero, offset: offsetB(h)}, nil
+		}
+	case 0x2:
+		switch funct3 {
+		case 0x0:
+			if bits(h, 12, 12) == 0 {
+				return &slli{rd: rd, rs: rd, imm: int32(bits(h, 6, 2))}, nil
+			}
+		case 0x1:
+			offset := bits(h, 12, 12)<<5 | bits(h, 6, 5)<<3 | bits(h, 4, 2)<<6
+			return &fload{instructionType: Fld, rd: fregister(rd), offset: int32(offset), rs: Sp}, nil
+		case 0x2:
+			if rd != Zero {
+				return &lw{rd: rd, offset: offsetLwsp(h), rs: Sp}, nil
+			}
+		case 0x3:
+			return &fload{instructionType: Flw, rd: fregister(rd), offset: offsetLwsp(h), rs: Sp}, nil
+		case 0x4:
+			if bits(h, 12, 12) == 0 {
+				if rs2 == Zero {
+					if rd != Zero {
+						return &jalr{rd: Zero, rs: rd}, nil
+					}
+					break
+				}
+				return &add{rd: rd, rs1: Zero, rs2: rs2}, nil
+			}
+			if rs2 == Zero {
+				if rd == Zero {
+					return &ebreak{}, nil
+				}
+				return &jalr{rd: Ra, rs: rd}, nil
+			}
+			return &add{rd: rd, rs1: rd, rs2: rs2}, nil
+		case 0x5:
+			offset := bits(h, 12, 10)<<3 | bits(h, 9, 7)<<6
+			return &fstore{instructionType: Fsd, rs: fregister(rs2), offset: int32(offset), rd: Sp}, nil
+		case 0x6:
+			return &sw{rs: rs2, offset: offsetSwsp(h), rd: Sp}, nil
+		case 0x7:
+			return &fstore{instructionType: Fsw, rs: fregister(rs2), offset: offsetSwsp(h), rd: Sp}, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported instruction %#04x", half)
+}
+
+// EncodeCompressedInstruction encodes a single 16-bit instruction located at
+// a given pc.
+func EncodeCompressedInstruction(op *compressed, pc int32, labels map[string]int32) (uint16, error) {
+	half, err := encodeCompressed(op.InstructionRunner, pc, labels)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", op, err)
+	}
+	return half, nil
+}
+
+func encodeCompressed(runner InstructionRunner, pc int32, labels map[string]int32) (uint16, error) {
+	switch r := runner.(type) {
+	case *addi:
+		switch {
+		case r.rd == Zero && r.rs == Zero && r.imm == 0:
+			return 0x0001, nil
+		case r.rs == Zero:
+			return encodeCI(0x2, r.rd, r.imm)
+		case r.rd == Sp && r.rs == Sp && (r.imm < -32 || r.imm > 31):
+			if err := checkCompressedRange(int64(r.imm), -512, 496, 16, true); err != nil {
+				return 0, err
+			}
+			i := uint32(r.imm)
+			return uint16(0x3<<13 | (i>>9&0x1)<<12 | uint32(Sp)<<7 | (i>>4&0x1)<<6 | (i>>6&0x1)<<5 |
+				(i>>7&0x3)<<3 | (i>>5&0x1)<<2 | 0x1), nil
+		case r.rs == Sp && r.rd != Sp:
+			rd, err := primeField(r.rd)
+			if err != nil {
+				return 0, err
+			}
+			if err := checkCompressedRange(int64(r.imm), 4, 1020, 4, true); err != nil {
+				return 0, err
+			}
+			i := uint32(r.imm)
+			return uint16((i>>4&0x3)<<11 | (i>>6&0xf)<<7 | (i>>2&0x1)<<6 | (i>>3&0x1)<<5 | rd<<2), nil
+		case r.rs == r.rd:
+			return encodeCI(0x0, r.rd, r.imm)
+		}
+	case *lui:
+		imm := r.imm
+		if imm >= 0xfffe0 {
+			// The 20 upper bits of a negative immediate
+			imm -= 0x100000
+		}
+		if r.rd == Zero || r.rd == Sp || imm == 0 {
+			break
+		}
+		return encodeCI(0x3, r.rd, imm)
+	case *srli:
+		return encodeCB(0x0, r.rd, r.rs, r.imm, 0, 31)
+	case *srai:
+		return encodeCB(0x1, r.rd, r.rs, r.imm, 0, 31)
+	case *andi:
+		return encodeCB(0x2, r.rd, r.rs, r.imm, -32, 31)
+	case *sub:
+		return encodeCA(0x0, r.rd, r.rs1, r.rs2)
+	case *xor:
+		return encodeCA(0x1, r.rd, r.rs1, r.rs2)
+	case *or:
+		return encodeCA(0x2, r.rd, r.rs1, r.rs2)
+	case *and:
+		return encodeCA(0x3, r.rd, r.rs1, r.rs2)
+	case *j:
+		return encodeCJ(0x5, r.label, pc, r.offset, labels)
+	case *jal:
+		switch r.rd {
+		case Zero:
+			return encodeCJ(0x5, r.label, pc, r.offset, labels)
+		case Ra:
+			return encodeCJ(0x1, r.label, pc, r.offset, labels)
+		}
+	case *beqz:
+		return encodeCBranch(0x6, r.rs, r.label, pc, r.offset, labels)
+	case *bnez:
+		return encodeCBranch(0x7, r.rs, r.label, pc, r.offset, labels)
+	case *beq:
+		if r.rs2 == Zero {
+			return encodeCBranch(0x6, r.rs1, r.label, pc, r.offset, labels)
+		}
+	case *bne:
+		if r.rs2 == Zero {
+			return encodeCBranch(0x7, r.rs1, r.label, pc, r.offset, labels)
+		}
+	case *slli:
+		if r.rd != r.rs || r.imm < 0 || r.imm > 31 {
+			break
+		}
+		return uint16(uint32(r.rd)<<7 | uint32(r.imm)<<2 | 0x2), nil
+	case *jalr:
+		if r.imm != 0 || r.rs == Zero {
+			break
+		}
+		switch r.rd {
+		case Zero:
+			return uint16(0x4<<13 | registerField(r.rs)<<7 | 0x2), nil
+		case Ra:
+			return uint16(0x4<<13 | 0x1<<12 | registerField(r.rs)<<7 | 0x2), nil
+		}
+	case *add:
+		if r.rs2 == Zero {
+			break
+		}
+		switch r.rs1 {
+		case Zero:
+			return uint16(0x4<<13 | registerField(r.rd)<<7 | registerField(r.rs2)<<2 | 0x2), nil
+		case r.rd:
+			return uint16(0x4<<13 | 0x1<<12 | registerField(r.rd)<<7 | registerField(r.rs2)<<2 | 0x2), nil
+		}
+	case *ebreak:
+		return 0x9002, nil
+	case *lw:
+		return encodeCMemory(0x2, r.rd, r.rs, r.offset, false)
+	case *sw:
+		return encodeCMemory(0x6, r.rs, r.rd, r.offset, false)
+	case *fload:
+		if r.instructionType == Fld {
+			return encodeCMemory(0x1, r.rd, r.rs, r.offset, true)
+		}
+		return encodeCMemory(0x3, r.rd, r.rs, r.offset, false)
+	case *fstore:
+		if r.instructionType == Fsd {
+			return encodeCMemory(0x5, r.rs, r.rd, r.offset, true)
+		}
+		return encodeCMemory(0x7, r.rs, r.rd, r.offset, false)
+	case *illegal:
+		return uint16(r.value), nil
+	}
+	return 0, fmt.Errorf("no compressed encoding")
+}
+
+// primeField returns the 3-bit field of a register among x8-x15 or f8-f15.
+func primeField(reg RegisterType) (uint32, error) {
+	field := registerField(reg)
+	if field < 8 || field > 15 {
+		return 0, fmt.Errorf("invalid register: %s", registerName(reg))
+	}
+	return field - 8, nil
+}
+
+// encodeCI encodes c.addi, c.li and c.lui, whose immediate is 6-bit signed.
+func encodeCI(funct3 uint32, rd RegisterType, imm int32) (uint16, error) {
+	if err := checkCompressedRange(int64(imm), -32, 31, 1, false); err != nil {
+		return 0, err
+	}
+	i := uint32(imm)
+	return uint16(funct3<<13 | (i>>5&0x1)<<12 | registerField(rd)<<7 | (i&0x1f)<<2 | 0x1), nil
+}
+
+// encodeCB encodes c.srli, c.srai and c.andi.
+func encodeCB(funct2 uint32, rd, rs RegisterType, imm, min, max int32) (uint16, error) {
+	field, err := primeField(rd)
+	if err != nil {
+		return 0, err
+	}
+	if rd != rs {
+		return 0, fmt.Errorf("different source and destination")
+	}
+	if err := checkCompressedRange(int64(imm), int64(min), int64(max), 1, false); err != nil {
+		return 0, err
+	}
+	i := uint32(imm)
+	return uint16(0x4<<13 | (i>>5&0x1)<<12 | funct2<<10 | field<<7 | (i&0x1f)<<2 | 0x1), nil
+}
+
+// encodeCA encodes c.sub, c.xor, c.or and c.and.
+func encodeCA(funct2 uint32, rd, rs1, rs2 RegisterType) (uint16, error) {
+	if rd != rs1 {
+		return 0, fmt.Errorf("different source and destination")
+	}
+	field1, err := primeField(rd)
+	if err != nil {
+		return 0, err
+	}
+	field2, err := primeField(rs2)
+	if err != nil {
+		return 0, err
+	}
+	return uint16(0x4<<13 | 0x3<<10 | field1<<7 | funct2<<5 | field2<<2 | 0x1), nil
+}
+
+// encodeCJ encodes c.j and c.jal.
+func encodeCJ(funct3 uint32, label string, pc, offset int32, labels map[string]int32) (uint16, error) {
+	offset, err := target(label, pc, offset, labels)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkCompressedRange(int64(offset), -2048, 2046, 2, false); err != nil {
+		return 0, err
+	}
+	o := uint32(offset)
+	return uint16(funct3<<13 | (o>>11&0x1)<<12 | (o>>4&0x1)<<11 | (o>>8&0x3)<<9 | (o>>10&0x1)<<8 |
+		(o>>6&0x1)<<7 | (o>>7&0x1)<<6 | (o>>1&0x7)<<3 | (o>>5&0x1)<<2 | 0x1), nil
+}
+
+// encodeCBranch encodes c.beqz and c.bnez.
+func encodeCBranch(funct3 uint32, rs RegisterType, label string, pc, offset int32, labels map[string]int32) (uint16, error) {
+	field, err := primeField(rs)
+	if err != nil {
+		return 0, err
+	}
+	offset, err = target(label, pc, offset, labels)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkCompressedRange(int64(offset), -256, 254, 2, false); err != nil {
+		return 0, err
+	}
+	o := uint32(offset)
+	return uint16(funct3<<13 | (o>>8&0x1)<<12 | (o>>3&0x3)<<10 | field<<7 | (o>>6&0x3)<<5 |
+		(o>>1&0x3)<<3 | (o>>5&0x1)<<2 | 0x1), nil
+}
+
+// encodeCMemory encodes the compressed loads and stores of a register at an
+// offset from a base, either sp or among x8-x15. double is set for the 8-byte
+// accesses.
+func encodeCMemory(funct3 uint32, reg, base RegisterType, offset int32, double bool) (uint16, error) {
+	o := uint32(offset)
+	store := funct3 >= 0x5
+	if base == Sp {
+		if double {
+			if err := checkCompressedRange(int64(offset), 0, 504, 8, false); err != nil {
+				return 0, err
+			}
+			if store {
+				return uint16(funct3<<13 | (o>>3&0x7)<<10 | (o>>6&0x7)<<7 | registerField(reg)<<2 | 0x2), nil
+			}
+			return uint16(funct3<<13 | (o>>5&0x1)<<12 | registerField(reg)<<7 | (o>>3&0x3)<<5 | (o>>6&0x7)<<2 | 0x2), nil
+		}
+		if err := checkCompressedRange(int64(offset), 0, 252, 4, false); err != nil {
+			return 0, err
+		}
+		if store {
+			return uint16(funct3<<13 | (o>>2&0xf)<<9 | (o>>6&0x3)<<7 | registerField(reg)<<2 | 0x2), nil
+		}
+		if reg == Zero {
+			return 0, fmt.Errorf("invalid register: zero")
+		}
+		return uint16(funct3<<13 | (o>>5&0x1)<<12 | registerField(reg)<<7 | (o>>2&0x7)<<4 | (o>>6&0x3)<<2 | 0x2), nil
+	}
+
+	regField, err := primeField(reg)
+	if err != nil {
+		return 0, err
+	}
+	baseField, err := primeField(base)
+	if err != nil {
+		return 0, err
+	}
+	if double {
+		if err := checkCompressedRange(int64(offset), 0, 248, 8, false); err != nil {
+			return 0, err
+		}
+		return uint16(funct3<<13 | (o>>3&0x7)<<10 | baseField<<7 | (o>>6&0x3)<<5 | regField<<2), nil
+	}
+	if err := checkCompressedRange(int64(offset), 0, 124, 4, false); err != nil {
+		return 0, err
+	}
+	return uint16(funct3<<13 | (o>>3&0x7)<<10 | baseField<<7 | (o>>2&0x1)<<6 | (o>>6&0x1)<<5 | regField<<2), nil
+}
+
+// bits returns the bits hi down to lo of a halfword.
+func bits(h uint32, hi, lo int) uint32 {
+	return (h >> lo) & (1<<(hi-lo+1) - 1)
+}
+
+// signExtend sign-extends a value of a given number of bits.
+func signExtend(v uint32, n int) int32 {
+	return int32(v<<(32-n)) >> (32 - n)
+}
+
+// offsetW returns the offset of c.lw, c.flw, c.sw and c.fsw.
+func offsetW(h uint32) int32 {
+	return int32(bits(h, 12, 10)<<3 | bits(h, 6, 6)<<2 | bits(h, 5, 5)<<6)
+}
+
+// offsetD returns the offset of c.fld and c.fsd.
+func offsetD(h uint32) int32 {
+	return int32(bits(h, 12, 10)<<3 | bits(h, 6, 5)<<6)
+}
+
+// offsetLwsp returns the offset of c.lwsp and c.flwsp.
+func offsetLwsp(h uint32) int32 {
+	return int32(bits(h, 12, 12)<<5 | bits(h, 6, 4)<<2 | bits(h, 3, 2)<<6)
+}
+
+// offsetSwsp returns the offset of c.swsp and c.fswsp.
+func offsetSwsp(h uint32) int32 {
+	return int32(bits(h, 12, 9)<<2 | bits(h, 8, 7)<<6)
+}
+
+// offsetJ returns the sign-extended offset of c.j and c.jal.
+func offsetJ(h uint32) int32 {
+	return signExtend(bits(h, 12, 12)<<11|
+		bits(h, 11, 11)<<4|
+		bits(h, 10, 9)<<8|
+		bits(h, 8, 8)<<10|
+		bits(h, 7, 7)<<6|
+		bits(h, 6, 6)<<7|
+		bits(h, 5, 3)<<1|
+		bits(h, 2, 2)<<5, 12)
+}
+
+// offsetB returns the sign-extended offset of c.beqz and c.bnez.
+func offsetB(h uint32) int32 {
+	return signExtend(bits(h, 12, 12)<<8|
+		bits(h, 11, 10)<<3|
+		bits(h, 6, 5)<<6|
+		bits(h, 4, 3)<<1|
+		bits(h, 2, 2)<<5, 9)
+}
+
+// expandCompressed validates the operands of a compressed instruction and
+// returns the mnemonic and the operands of the 32-bit instruction it expands
+// to.
+func expandCompressed(mnemonic string, elements []string, exprs *expressions) (string, []string, error) {
+	line := strings.Join(elements, ",")
+	args := make([]string, 0, len(elements))
+	for _, element := range elements {
+		args = append(args, strings.TrimSpace(element))
+	}
+
+	switch mnemonic {
+	case "c.add", "c.mv":
+		if err := validateArgs(2, args, line); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedRegister(args[0], false, true); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedRegister(args[1], false, true); err != nil {
+			return "", nil, err
+		}
+		if mnemonic == "c.mv" {
+			return "add", []string{args[0], "zero", args[1]}, nil
+		}
+		return "add", []string{args[0], args[0], args[1]}, nil
+	case "c.and", "c.or", "c.sub", "c.xor":
+		if err := validateArgs(2, args, line); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedRegister(args[0], true, false); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedRegister(args[1], true, false); err != nil {
+			return "", nil, err
+		}
+		return mnemonic[2:], []string{args[0], args[0], args[1]}, nil
+	case "c.addi", "c.li":
+		if err := validateArgs(2, args, line); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedRegister(args[0], false, true); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedImmediate(exprs, args[1], -32, 31, 1, mnemonic == "c.addi"); err != nil {
+			return "", nil, err
+		}
+		if mnemonic == "c.li" {
+			return "addi", []string{args[0], "zero", args[1]}, nil
+		}
+		return "addi", []string{args[0], args[0], args[1]}, nil
+	case "c.addi16sp":
+		if err := validateArgs(2, args, line); err != nil {
+			return "", nil, err
+		}
+		if err := checkStackPointer(args[0]); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedImmediate(exprs, args[1], -512, 496, 16, true); err != nil {
+			return "", nil, err
+		}
+		return "addi", []string{"sp", "sp", args[1]}, nil
+	case "c.addi4spn":
+		if err := validateArgs(3, args, line); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedRegister(args[0], true, false); err != nil {
+			return "", nil, err
+		}
+		if err := checkStackPointer(args[1]); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedImmediate(exprs, args[2], 4, 1020, 4, true); err != nil {
+			return "", nil, err
+		}
+		return "addi", args, nil
+	case "c.andi":
+		if err := validateArgs(2, args, line); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedRegister(args[0], true, false); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedImmediate(exprs, args[1], -32, 31, 1, false); err != nil {
+			return "", nil, err
+		}
+		return "andi", []string{args[0], args[0], args[1]}, nil
+	case "c.beqz", "c.bnez":
+		if err := validateArgs(2, args, line); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedRegister(args[0], true, false); err != nil {
+			return "", nil, err
+		}
+		return mnemonic[2:], args, nil
+	case "c.ebreak":
+		return "ebreak", nil, nil
+	case "c.j":
+		if err := validateArgs(1, args, line); err != nil {
+			return "", nil, err
+		}
+		return "j", args, nil
+	case "c.jal":
+		if err := validateArgs(1, args, line); err != nil {
+			return "", nil, err
+		}
+		return "jal", []string{"ra", args[0]}, nil
+	case "c.jalr", "c.jr":
+		if err := validateArgs(1, args, line); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedRegister(args[0], false, true); err != nil {
+			return "", nil, err
+		}
+		if mnemonic == "c.jalr" {
+			return "jalr", []string{"ra", args[0], "0"}, nil
+		}
+		return "jalr", []string{"zero", args[0], "0"}, nil
+	case "c.lui":
+		if err := validateArgs(2, args, line); err != nil {
+			return "", nil, err
+		}
+		rd, err := parseRegister(args[0])
+		if err != nil {
+			return "", nil, err
+		}
+		if rd == Zero || rd == Sp {
+			return "", nil, newDiagnostic(CodeInvalidRegister, args[0], "invalid register: %s", args[0])
+		}
+		imm, err := exprs.immediate(args[1])
+		if err != nil {
+			return "", nil, err
+		}
+		// The 6-bit immediate is sign-extended to the 20 upper bits
+		if checkCompressedRange(imm, 1, 31, 1, true) != nil && checkCompressedRange(imm, 0xfffe0, 0xfffff, 1, true) != nil {
+			return "", nil, fmt.Errorf("invalid immediate %d: out of range", imm)
+		}
+		return "lui", args, nil
+	case "c.nop":
+		return "addi", []string{"zero", "zero", "0"}, nil
+	case "c.slli", "c.srai", "c.srli":
+		if err := validateArgs(2, args, line); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedRegister(args[0], mnemonic != "c.slli", mnemonic == "c.slli"); err != nil {
+			return "", nil, err
+		}
+		if err := checkCompressedImmediate(exprs, args[1], 1, 31, 1, true); err != nil {
+			return "", nil, err
+		}
+		return mnemonic[2:], []string{args[0], args[0], args[1]}, nil
+	case "c.lw", "c.sw", "c.flw", "c.fsw", "c.fld", "c.fsd":
+		if err := validateArgs(2, args, line); err != nil {
+			return "", nil, err
+		}
+		float := mnemonic[2] == 'f'
+		if float {
+			if err := checkCompressedFRegister(args[0], true); err != nil {
+				return "", nil, err
+			}
+		} else if err := checkCompressedRegister(args[0], true, false); err != nil {
+			return "", nil, err
+		}
+		offset, rs, err := parseOffsetReg(args[1], exprs)
+		if err != nil {
+			return "", nil, err
+		}
+		if rs < S0 || rs > A5 {
+			return "", nil, newDiagnostic(CodeInvalidRegister, args[1], "invalid register: %s", args[1])
+		}
+		scale := int64(4)
+		if strings.HasSuffix(mnemonic, "d") {
+			scale = 8
+		}
+		if err := checkCompressedRange(int64(offset), 0, 31*scale, scale, false); err != nil {
+			return "", nil, err
+		}
+		return mnemonic[2:], args, nil
+	case "c.lwsp", "c.swsp", "c.flwsp", "c.fswsp", "c.fldsp", "c.fsdsp":
+		if err := validateArgs(2, args, line); err != nil {
+			return "", nil, err
+		}
+		float := mnemonic[2] == 'f'
+		if float {
+			if err := checkCompressedFRegister(args[0], false); err != nil {
+				return "", nil, err
+			}
+		} else if err := checkCompressedRegister(args[0], false, mnemonic == "c.lwsp"); err != nil {
+			return "", nil, err
+		}
+		offset, rs, err := parseOffsetReg(args[1], exprs)
+		if err != nil {
+			return "", nil, err
+		}
+		if rs != Sp {
+			return "", nil, newDiagnostic(CodeInvalidRegister, args[1], "invalid register: %s", args[1])
+		}
+		scale := int64(4)
+		if strings.HasSuffix(mnemonic, "dsp") {
+			scale = 8
+		}
+		if err := checkCompressedRange(int64(offset), 0, 63*scale, scale, false); err != nil {
+			return "", nil, err
+		}
+		return strings.TrimSuffix(mnemonic[2:], "sp"), args, nil
+	}
+	return "", nil, newDiagnostic(CodeUnknownInstruction, mnemonic, "invalid instruction type: %s", mnemonic)
+}
+
+// checkCompressedRegister checks whether an integer register can be encoded:
+// prime denotes the registers x8-x15 of the 3-bit fields.
+func checkCompressedRegister(s string, prime, nonZero bool) error {
+	reg, err := parseRegister(s)
+	if err != nil {
+		return err
+	}
+	if (prime && (reg < S0 || reg > A5)) || (nonZero && reg == Zero) {
+		return newDiagnostic(CodeInvalidRegister, s, "invalid register: %s", s)
+	}
+	return nil
+}
+
+// checkCompressedFRegister checks whether a floating-point register can be
+// encoded.
+func checkCompressedFRegister(s string, prime bool) error {
+	reg, err := parseFRegister(s)
+	if err != nil {
+		return err
+	}
+	if prime && (reg < Ft0+S0 || reg > Ft0+A5) {
+		return newDiagnostic(CodeInvalidRegister, s, "invalid register: %s", s)
+	}
+	return nil
+}
+
+func checkStackPointer(s string) error {
+	reg, err := parseRegister(s)
+	if err != nil {
+		return err
+	}
+	if reg != Sp {
+		return fmt.Errorf("invalid register: %s, expected sp", s)
+	}
+	return nil
+}
+
+// checkCompressedImmediate checks whether an immediate is in a range and a
+// multiple of a scale.
+func checkCompressedImmediate(exprs *expressions, s string, min, max, scale int64, nonZero bool) error {
+	imm, err := exprs.immediate(s)
+	if err != nil {
+		return err
+	}
+	if err := checkCompressedRange(imm, min, max, scale, nonZero); err != nil {
+		return newDiagnostic(CodeOutOfRange, s, "%v", err)
+	}
+	return nil
+}
+
+func checkCompressedRange(imm, min, max, scale int64, nonZero bool) error {
+	if imm < min || imm > max || imm%scale != 0 || (nonZero && imm == 0) {
+		return fmt.Errorf("invalid immediate %d: out of range", imm)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package risc
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CSR is the 12-bit address of a control and status register.
+type CSR uint16
+
+const (
+	CSRFflags   CSR = 0x001
+	CSRFrm      CSR = 0x002
+	CSRFcsr     CSR = 0x003
+	CSRMstatus  CSR = 0x300
+	CSRMisa     CSR = 0x301
+	CSRMie      CSR = 0x304
+	CSRMtvec    CSR = 0x305
+	CSRMscratch CSR = 0x340
+	CSRMepc     CSR = 0x341
+	CSRMcause   CSR = 0x342
+	CSRMtval    CSR = 0x343
+	CSRMip      CSR = 0x344
+	CSRCycle    CSR = 0xc00
+	CSRTime     CSR = 0xc01
+	CSRInstret  CSR = 0xc02
+	CSRCycleh   CSR = 0xc80
+	CSRTimeh    CSR = 0xc81
+	CSRInstreth CSR = 0xc82
+	CSRMhartid  CSR = 0xf14
+)
+
+// Bits of mstatus.
+const (
+	MstatusMIE  int32 = 1 << 3
+	MstatusMPIE int32 = 1 << 7
+	// MstatusMPP is the privilege mode before the trap, always the machine mode.
+	MstatusMPP int32 = 3 << 11
+)
+
+// Bits of mie and mip: the machine software, timer and external interrupts.
+const (
+	MSIP int32 = 1 << 3
+	MTIP int32 = 1 << 7
+	MEIP int32 = 1 << 11
+)
+
+// misa describes RV32IMAFDC.
+const misa int32 = 1<<30 | 1<<('A'-'A') | 1<<('C'-'A') | 1<<('D'-'A') | 1<<('F'-'A') | 1<<('I'-'A') | 1<<('M'-'A')
+
+var csrNames = map[CSR]string{
+	CSRFflags:   "fflags",
+	CSRFrm:      "frm",
+	CSRFcsr:     "fcsr",
+	CSRMstatus:  "mstatus",
+	CSRMisa:     "misa",
+	CSRMie:      "mie",
+	CSRMtvec:    "mtvec",
+	CSRMscratch: "mscratch",
+	CSRMepc:     "mepc",
+	CSRMcause:   "mcause",
+	CSRMtval:    "mtval",
+	CSRMip:      "mip",
+	CSRCycle:    "cycle",
+	CSRTime:     "time",
+	CSRInstret:  "instret",
+	CSRCycleh:   "cycleh",
+	CSRTimeh:    "timeh",
+	CSRInstreth: "instreth",
+	CSRMhartid:  "mhartid",
+}
+
+func (csr CSR) String() string {
+	if name, exists := csrNames[csr]; exists {
+		return name
+	}
+	return fmt.Sprintf("%#03x", uint16(csr))
+}
+
+// IsReadOnly returns true if the CSR can't be written, which is encoded in its
+// two most significant bits.
+func (csr CSR) IsReadOnly() bool {
+	return csr>>10 == 0x3
+}
+
+// ReadCSR returns the value of a CSR.
+// The time counter is incremented at each cycle.
+func (ctx *Context) ReadCSR(csr CSR) (int32, error) {
+	switch csr {
+	case CSRFflags:
+		return ctx.FCSR & 0x1f, nil
+	case CSRFrm:
+		return (ctx.FCSR >> 5) & 0x7, nil
+	case CSRFcsr:
+		return ctx.FCSR & 0xff, nil
+	case CSRMstatus:
+		return ctx.Mstatus | MstatusMPP, nil
+	case CSRMisa:
+		return misa, nil
+	case CSRMie:
+		return ctx.Mie, nil
+	case CSRMtvec:
+		return ctx.Mtvec, nil
+	case CSRMscratch:
+		return ctx.Mscratch, nil
+	case CSRMepc:
+		return ctx.Mepc, nil
+	case CSRMcause:
+		return ctx.Mcause, nil
+	case CSRMtval:
+		return ctx.Mtval, nil
+	case CSRMip:
+		return ctx.pendingInterrupts(), nil
+	case CSRCycle, CSRTime:
+		return int32(ctx.Cycles), nil
+	case CSRInstret:
+		return int32(ctx.Instret), nil
+	case CSRCycleh, CSRTimeh:
+		return int32(ctx.Cycles >> 32), nil
+	case CSRInstreth:
+		return int32(ctx.Instret >> 32), nil
+	case CSRMhartid:
+		// A single hart
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unsupported csr: %v", csr)
+}
+
+// WriteCSR writes the value of a CSR.
+func (ctx *Context) WriteCSR(csr CSR, value int32) error {
+	if csr.IsReadOnly() {
+		return fmt.Errorf("read-only csr: %v", csr)
+	}
+	switch csr {
+	case CSRFflags:
+		ctx.FCSR = ctx.FCSR&^0x1f | value&0x1f
+	case CSRFrm:
+		ctx.FCSR = ctx.FCSR&^0xe0 | (value&0x7)<<5
+	case CSRFcsr:
+		ctx.FCSR = value & 0xff
+	case CSRMstatus:
+		// Only the machine mode is supported, MPP can't be changed
+		ctx.Mstatus = value & (MstatusMIE | MstatusMPIE)
+	case CSRMisa:
+		// The extensions can't be disabled
+	case CSRMie:
+		ctx.Mie = value & (MSIP | MTIP | MEIP)
+	case CSRMtvec:
+		// Only the direct (0) and vectored (1) modes are valid
+		ctx.Mtvec = value &^ 0x2
+	case CSRMscratch:
+		ctx.Mscratch = value
+	case CSRMepc:
+		// The instructions are aligned on 2 bytes
+		ctx.Mepc = value &^ 0x1
+	case CSRMcause:
+		ctx.Mcause = value
+	case CSRMtval:
+		ctx.Mtval = value
+	case CSRMip:
+		// The pending interrupts are set by their sources
+	default:
+		return fmt.Errorf("unsupported csr: %v", csr)
+	}
+	return nil
+}
+
+// parseCSR parses a CSR, either its name or its address.
+func parseCSR(s string) (CSR, error) {
+	for csr, name := range csrNames {
+		if s == name {
+			return csr, nil
+		}
+	}
+	addr, err := strconv.ParseUint(s, 0, 12)
+	if err != nil {
+		return 0, fmt.Errorf("unknown csr: %v", s)
+	}
+	return CSR(addr), nil
+}
+
+// csrPseudoInstructions maps the pseudo-instructions reading a counter to the
+// counter.
+var csrPseudoInstructions = map[string]CSR{
+	"rdcycle":    CSRCycle,
+	"rdcycleh":   CSRCycleh,
+	"rdinstret":  CSRInstret,
+	"rdinstreth": CSRInstreth,
+	"rdtime":     CSRTime,
+	"rdtimeh":    CSRTimeh,
+}
+
+// expandCSRPseudoInstruction returns the mnemonic and the operands of the CSR
+// instruction a pseudo-instruction expands to.
+func expandCSRPseudoInstruction(mnemonic string, elements []string) (string, []string) {
+	args := make([]string, 0, len(elements))
+	for _, element := range elements {
+		args = append(args, strings.TrimSpace(element))
+	}
+
+	if csr, exists := csrPseudoInstructions[mnemonic]; exists {
+		return "csrrs", append(args, csr.String(), "zero")
+	}
+	switch mnemonic {
+	case "csrr":
+		return "csrrs", append(args, "zero")
+	case "csrw", "csrs", "csrc", "csrwi", "csrsi", "csrci":
+		return "csrr" + mnemonic[3:], append([]string{"zero"}, args...)
+	}
+	return mnemonic, elements
+}
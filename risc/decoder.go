@@ -0,0 +1,489 @@
+package risc
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	opcodeLoad    = 0x03
+	opcodeLoadFp  = 0x07
+	opcodeMiscMem = 0x0f
+	opcodeOpImm   = 0x13
+	opcodeAuipc   = 0x17
+	opcodeAmo     = 0x2f
+	opcodeStore   = 0x23
+	opcodeStoreFp = 0x27
+	opcodeOp      = 0x33
+	opcodeLui     = 0x37
+	opcodeMadd    = 0x43
+	opcodeMsub    = 0x47
+	opcodeNmsub   = 0x4b
+	opcodeNmadd   = 0x4f
+	opcodeOpFp    = 0x53
+	opcodeBranch  = 0x63
+	opcodeJalr    = 0x67
+	opcodeJal     = 0x6f
+	opcodeSystem  = 0x73
+)
+
+const (
+	funct7Base = 0x00
+	funct7Alt  = 0x20
+	funct7Mul  = 0x01
+)
+
+// Decode decodes a little-endian stream of RV32 instructions, where 16-bit
+// compressed instructions may be mixed with 32-bit ones.
+// Branch and jump targets are resolved from the immediate offsets, hence the
+// returned application doesn't contain any label.
+func Decode(code []byte) (Application, error) {
+	if len(code)%2 != 0 {
+		return Application{}, fmt.Errorf("invalid code length: %d bytes is not a multiple of 2", len(code))
+	}
+
+	var (
+		pcs     []int32
+		runners []InstructionRunner
+	)
+	for pc := 0; pc < len(code); {
+		runner, err := decodeAt(code[pc:])
+		if err != nil {
+			return Application{}, fmt.Errorf("pc %d: %v", pc, err)
+		}
+		pcs = append(pcs, int32(pc))
+		runners = append(runners, runner)
+		pc += int(InstructionSize(runner))
+	}
+
+	instructions, isCompressed := layout(pcs, runners)
+	return Application{
+		Instructions: instructions,
+		Compressed:   isCompressed,
+		Labels:       make(map[string]int32),
+	}, nil
+}
+
+// decodeAt decodes the instruction at the beginning of code, either 16 or 32
+// bits long. An instruction that can't be decoded raises an illegal
+// instruction exception once executed, with its bits in mtval.
+func decodeAt(code []byte) (InstructionRunner, error) {
+	half := binary.LittleEndian.Uint16(code)
+	if isCompressedEncoding(half) {
+		runner, err := DecodeCompressedInstruction(half)
+		if err != nil {
+			return &compressed{&illegal{cause: CauseIllegalInstruction, value: int32(half)}}, nil
+		}
+		return runner, nil
+	}
+	if len(code) < 4 {
+		return nil, fmt.Errorf("truncated instruction %#04x", half)
+	}
+	word := binary.LittleEndian.Uint32(code)
+	runner, err := DecodeInstruction(word)
+	if err != nil {
+		return &illegal{cause: CauseIllegalInstruction, value: int32(word)}, nil
+	}
+	return runner, nil
+}
+
+// DecodeInstruction decodes a single 32-bit instruction word.
+func DecodeInstruction(word uint32) (InstructionRunner, error) {
+	if word&0x3 != 0x3 {
+		return nil, fmt.Errorf("invalid instruction %#08x: not a 32-bit encoding", word)
+	}
+
+	opcode := word & 0x7f
+	rd := RegisterType((word >> 7) & 0x1f)
+	funct3 := (word >> 12) & 0x7
+	rs1 := RegisterType((word >> 15) & 0x1f)
+	rs2 := RegisterType((word >> 20) & 0x1f)
+	funct7 := word >> 25
+
+	switch opcode {
+	case opcodeOp:
+		switch funct7 {
+		case funct7Base:
+			switch funct3 {
+			case 0x0:
+				return &add{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x1:
+				return &sll{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x2:
+				return &slt{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x3:
+				return &sltu{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x4:
+				return &xor{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x5:
+				return &srl{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x6:
+				return &or{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x7:
+				return &and{rd: rd, rs1: rs1, rs2: rs2}, nil
+			}
+		case funct7Alt:
+			switch funct3 {
+			case 0x0:
+				return &sub{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x5:
+				return &sra{rd: rd, rs1: rs1, rs2: rs2}, nil
+			}
+		case funct7Mul:
+			switch funct3 {
+			case 0x0:
+				return &mul{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x1:
+				return &mulh{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x2:
+				return &mulhsu{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x3:
+				return &mulhu{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x4:
+				return &div{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x5:
+				return &divu{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x6:
+				return &rem{rd: rd, rs1: rs1, rs2: rs2}, nil
+			case 0x7:
+				return &remu{rd: rd, rs1: rs1, rs2: rs2}, nil
+			}
+		}
+	case opcodeOpImm:
+		imm := immI(word)
+		switch funct3 {
+		case 0x0:
+			return &addi{rd: rd, rs: rs1, imm: imm}, nil
+		case 0x1:
+			if funct7 == funct7Base {
+				return &slli{rd: rd, rs: rs1, imm: int32(rs2)}, nil
+			}
+		case 0x2:
+			return &slti{rd: rd, rs: rs1, imm: imm}, nil
+		case 0x3:
+			return &sltiu{rd: rd, rs: rs1, imm: imm}, nil
+		case 0x4:
+			return &xori{rd: rd, rs: rs1, imm: imm}, nil
+		case 0x5:
+			switch funct7 {
+			case funct7Base:
+				return &srli{rd: rd, rs: rs1, imm: int32(rs2)}, nil
+			case funct7Alt:
+				return &srai{rd: rd, rs: rs1, imm: int32(rs2)}, nil
+			}
+		case 0x6:
+			return &ori{rd: rd, rs: rs1, imm: imm}, nil
+		case 0x7:
+			return &andi{rd: rd, rs: rs1, imm: imm}, nil
+		}
+	case opcodeLoad:
+		offset := immI(word)
+		switch funct3 {
+		case 0x0:
+			return &lb{rd: rd, offset: offset, rs: rs1}, nil
+		case 0x1:
+			return &lh{rd: rd, offset: offset, rs: rs1}, nil
+		case 0x2:
+			return &lw{rd: rd, offset: offset, rs: rs1}, nil
+		case 0x4:
+			return &lbu{rd: rd, offset: offset, rs: rs1}, nil
+		case 0x5:
+			return &lhu{rd: rd, offset: offset, rs: rs1}, nil
+		}
+	case opcodeMiscMem:
+		if funct3 == 0x0 {
+			return &fence{}, nil
+		}
+	case opcodeStore:
+		offset := immS(word)
+		switch funct3 {
+		case 0x0:
+			return &sb{rs: rs2, offset: offset, rd: rs1}, nil
+		case 0x1:
+			return &sh{rs: rs2, offset: offset, rd: rs1}, nil
+		case 0x2:
+			return &sw{rs: rs2, offset: offset, rd: rs1}, nil
+		}
+	case opcodeBranch:
+		offset := immB(word)
+		switch funct3 {
+		case 0x0:
+			return &beq{rs1: rs1, rs2: rs2, offset: offset}, nil
+		case 0x1:
+			return &bne{rs1: rs1, rs2: rs2, offset: offset}, nil
+		case 0x4:
+			return &blt{rs1: rs1, rs2: rs2, offset: offset}, nil
+		case 0x5:
+			return &bge{rs1: rs1, rs2: rs2, offset: offset}, nil
+		case 0x6:
+			return &bltu{rs1: rs1, rs2: rs2, offset: offset}, nil
+		case 0x7:
+			return &bgeu{rs1: rs1, rs2: rs2, offset: offset}, nil
+		}
+	case opcodeAmo:
+		if funct3 != 0x2 {
+			break
+		}
+		switch funct7 >> 2 {
+		case 0x02:
+			if rs2 == Zero {
+				return &lrw{rd: rd, rs: rs1}, nil
+			}
+		case 0x03:
+			return &scw{rd: rd, rs1: rs1, rs2: rs2}, nil
+		default:
+			if instructionType, ok := amoFunct5[funct7>>2]; ok {
+				return &amo{instructionType: instructionType, rd: rd, rs1: rs1, rs2: rs2}, nil
+			}
+		}
+	case opcodeLoadFp:
+		offset := immI(word)
+		switch funct3 {
+		case 0x2:
+			return &fload{instructionType: Flw, rd: fregister(rd), offset: offset, rs: rs1}, nil
+		case 0x3:
+			return &fload{instructionType: Fld, rd: fregister(rd), offset: offset, rs: rs1}, nil
+		}
+	case opcodeStoreFp:
+		offset := immS(word)
+		switch funct3 {
+		case 0x2:
+			return &fstore{instructionType: Fsw, rs: fregister(rs2), offset: offset, rd: rs1}, nil
+		case 0x3:
+			return &fstore{instructionType: Fsd, rs: fregister(rs2), offset: offset, rd: rs1}, nil
+		}
+	case opcodeMadd, opcodeMsub, opcodeNmsub, opcodeNmadd:
+		format := funct7 & 0x3
+		if format > 1 || funct3 == 0x5 || funct3 == 0x6 {
+			break
+		}
+		return &fop{
+			instructionType: fmaOpcodes[opcode][format],
+			rd:              fregister(rd),
+			rs1:             fregister(rs1),
+			rs2:             fregister(rs2),
+			rs3:             fregister(RegisterType(funct7 >> 2)),
+			rm:              int32(funct3),
+		}, nil
+	case opcodeOpFp:
+		return decodeOpFp(word, rd, funct3, rs1, rs2, funct7)
+	case opcodeJal:
+		return &jal{rd: rd, offset: immJ(word)}, nil
+	case opcodeJalr:
+		if funct3 == 0x0 {
+			return &jalr{rd: rd, rs: rs1, imm: immI(word)}, nil
+		}
+	case opcodeLui:
+		return &lui{rd: rd, imm: immU(word)}, nil
+	case opcodeAuipc:
+		return &auipc{rd: rd, imm: immU(word)}, nil
+	case opcodeSystem:
+		switch word {
+		case 0x00000073:
+			return &ecall{}, nil
+		case 0x00100073:
+			return &ebreak{}, nil
+		case 0x30200073:
+			return &mret{}, nil
+		case 0x10500073:
+			return &wfi{}, nil
+		}
+		if instructionType, ok := csrFunct3[funct3]; ok {
+			return &csr{instructionType: instructionType, rd: rd, rs: rs1, uimm: int32(rs1), csr: CSR(word >> 20)}, nil
+		}
+	}
+	return nil, unsupportedInstruction(word)
+}
+
+func unsupportedInstruction(word uint32) error {
+	return fmt.Errorf("unsupported instruction %#08x", word)
+}
+
+// amoFunct5 maps the funct5 field of an AMO instruction to its type.
+var amoFunct5 = map[uint32]InstructionType{
+	0x00: AmoaddW,
+	0x01: AmoswapW,
+	0x04: AmoxorW,
+	0x08: AmoorW,
+	0x0c: AmoandW,
+	0x10: AmominW,
+	0x14: AmomaxW,
+	0x18: AmominuW,
+	0x1c: AmomaxuW,
+}
+
+// csrFunct3 maps the funct3 field of a SYSTEM instruction to its CSR
+// instruction type.
+var csrFunct3 = map[uint32]InstructionType{
+	0x1: Csrrw,
+	0x2: Csrrs,
+	0x3: Csrrc,
+	0x5: Csrrwi,
+	0x6: Csrrsi,
+	0x7: Csrrci,
+}
+
+// immI returns the sign-extended immediate of an I-type instruction.
+func immI(word uint32) int32 {
+	return int32(word) >> 20
+}
+
+// immS returns the sign-extended immediate of an S-type instruction.
+func immS(word uint32) int32 {
+	return (int32(word)>>25)<<5 | int32((word>>7)&0x1f)
+}
+
+// immB returns the sign-extended immediate of a B-type instruction.
+func immB(word uint32) int32 {
+	return (int32(word)>>31)<<12 |
+		int32((word>>7)&0x1)<<11 |
+		int32((word>>25)&0x3f)<<5 |
+		int32((word>>8)&0xf)<<1
+}
+
+// immU returns the upper immediate of a U-type instruction, not shifted.
+func immU(word uint32) int32 {
+	return int32(word) >> 12
+}
+
+// immJ returns the sign-extended immediate of a J-type instruction.
+func immJ(word uint32) int32 {
+	return (int32(word)>>31)<<20 |
+		int32((word>>12)&0xff)<<12 |
+		int32((word>>20)&0x1)<<11 |
+		int32((word>>21)&0x3ff)<<1
+}
+
+// fregister returns the floating-point register of a register field.
+func fregister(reg RegisterType) RegisterType {
+	return Ft0 + reg
+}
+
+// fmaOpcodes maps the opcode of a fused multiply-add to its single and double
+// instruction types.
+var fmaOpcodes = map[uint32][2]InstructionType{
+	opcodeMadd:  {FmaddS, FmaddD},
+	opcodeMsub:  {FmsubS, FmsubD},
+	opcodeNmsub: {FnmsubS, FnmsubD},
+	opcodeNmadd: {FnmaddS, FnmaddD},
+}
+
+// opFpFunct5 maps the funct5 field of the OP-FP instructions whose funct3 is
+// a rounding mode to their single and double instruction types.
+var opFpFunct5 = map[uint32][2]InstructionType{
+	0x00: {FaddS, FaddD},
+	0x01: {FsubS, FsubD},
+	0x02: {FmulS, FmulD},
+	0x03: {FdivS, FdivD},
+}
+
+func decodeOpFp(word uint32, rd RegisterType, funct3 uint32, rs1, rs2 RegisterType, funct7 uint32) (InstructionRunner, error) {
+	funct5 := funct7 >> 2
+	format := funct7 & 0x3
+	if format > 1 {
+		return nil, unsupportedInstruction(word)
+	}
+	isDouble := format == 1
+	pick := func(s, d InstructionType) InstructionType {
+		if isDouble {
+			return d
+		}
+		return s
+	}
+	rounding := funct3 != 0x5 && funct3 != 0x6
+	op := &fop{rd: fregister(rd), rs1: fregister(rs1), rs2: fregister(rs2)}
+	if rounding {
+		switch funct5 {
+		case 0x00, 0x01, 0x02, 0x03, 0x0b, 0x08, 0x18, 0x1a:
+			op.rm = int32(funct3)
+		}
+	}
+
+	switch funct5 {
+	case 0x00, 0x01, 0x02, 0x03:
+		if !rounding {
+			break
+		}
+		op.instructionType = opFpFunct5[funct5][format]
+		return op, nil
+	case 0x0b:
+		if !rounding || rs2 != Zero {
+			break
+		}
+		op.instructionType = pick(FsqrtS, FsqrtD)
+		return op, nil
+	case 0x04:
+		if funct3 > 0x2 {
+			break
+		}
+		op.instructionType = [][2]InstructionType{{FsgnjS, FsgnjD}, {FsgnjnS, FsgnjnD}, {FsgnjxS, FsgnjxD}}[funct3][format]
+		return op, nil
+	case 0x05:
+		if funct3 > 0x1 {
+			break
+		}
+		op.instructionType = pick(FminS, FminD)
+		if funct3 == 0x1 {
+			op.instructionType = pick(FmaxS, FmaxD)
+		}
+		return op, nil
+	case 0x08:
+		switch {
+		case !rounding:
+		case !isDouble && rs2 == 1:
+			op.instructionType = FcvtSD
+			return op, nil
+		case isDouble && rs2 == Zero:
+			op.instructionType = FcvtDS
+			return op, nil
+		}
+	case 0x14:
+		if funct3 > 0x2 {
+			break
+		}
+		op.rd = rd
+		op.instructionType = [][2]InstructionType{{FleS, FleD}, {FltS, FltD}, {FeqS, FeqD}}[funct3][format]
+		return op, nil
+	case 0x18:
+		if !rounding || rs2 > 1 {
+			break
+		}
+		op.rd = rd
+		op.instructionType = pick(FcvtWS, FcvtWD)
+		if rs2 == 1 {
+			op.instructionType = pick(FcvtWuS, FcvtWuD)
+		}
+		return op, nil
+	case 0x1a:
+		if !rounding || rs2 > 1 {
+			break
+		}
+		op.rs1 = rs1
+		op.instructionType = pick(FcvtSW, FcvtDW)
+		if rs2 == 1 {
+			op.instructionType = pick(FcvtSWu, FcvtDWu)
+		}
+		return op, nil
+	case 0x1c:
+		if rs2 != Zero {
+			break
+		}
+		op.rd = rd
+		switch {
+		case funct3 == 0x0 && !isDouble:
+			op.instructionType = FmvXW
+			return op, nil
+		case funct3 == 0x1:
+			op.instructionType = pick(FclassS, FclassD)
+			return op, nil
+		}
+	case 0x1e:
+		if rs2 != Zero || funct3 != 0x0 || isDouble {
+			break
+		}
+		op.rs1 = rs1
+		op.instructionType = FmvWX
+		return op, nil
+	}
+	return nil, unsupportedInstruction(word)
+}
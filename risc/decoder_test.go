@@ -0,0 +1,263 @@
+package risc
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encode(words ...uint32) []byte {
+	code := make([]byte, 0, 4*len(words))
+	for _, word := range words {
+		code = binary.LittleEndian.AppendUint32(code, word)
+	}
+	return code
+}
+
+func TestDecode(t *testing.T) {
+	app, err := Decode(encode(
+		0x00000293, // addi t0, zero, 0
+		0x00a00313, // addi t1, zero, 10
+		0x00000393, // addi t2, zero, 0
+		0x005383b3, // add t2, t2, t0
+		0x00128293, // addi t0, t0, 1
+		0xfe62cce3, // blt t0, t1, -8
+		0x00702223, // sw t2, 4(zero)
+		0x00402503, // lw a0, 4(zero)
+		0x0080006f, // jal zero, 8
+		0xfff00513, // addi a0, zero, -1
+		0x12345337, // lui t1, 0x12345
+		0x40435393, // srai t2, t1, 4
+	))
+	require.NoError(t, err)
+	require.Len(t, app.Instructions, 12)
+
+	r := NewRunner(app, 8)
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(45), r.Ctx.Registers[A0])
+	assert.Equal(t, int32(0x12345000), r.Ctx.Registers[T1])
+	assert.Equal(t, int32(0x1234500), r.Ctx.Registers[T2])
+	assert.Equal(t, int8(45), r.Ctx.Memory[4])
+}
+
+func TestDecodeInstruction(t *testing.T) {
+	tests := []struct {
+		word uint32
+		want InstructionType
+	}{
+		{0x006283b3, Add},
+		{0x406283b3, Sub},
+		{0x026283b3, Mul},
+		{0x0262c3b3, Div},
+		{0x0262e3b3, Rem},
+		{0x026293b3, Mulh},
+		{0x0262a3b3, Mulhsu},
+		{0x0262b3b3, Mulhu},
+		{0x0262d3b3, Divu},
+		{0x0262f3b3, Remu},
+		{0x1002a3af, LrW},
+		{0x1862a3af, ScW},
+		{0x0862a3af, AmoswapW},
+		{0x0062a3af, AmoaddW},
+		{0x2062a3af, AmoxorW},
+		{0x6062a3af, AmoandW},
+		{0x4062a3af, AmoorW},
+		{0x8062a3af, AmominW},
+		{0xa062a3af, AmomaxW},
+		{0xc062a3af, AmominuW},
+		{0xe662a3af, AmomaxuW},
+		{0x00629393, Slli},
+		{0x0062d393, Srli},
+		{0x4062d393, Srai},
+		{0x00528023, Sb},
+		{0x00529023, Sh},
+		{0x00029383, Lh},
+		{0x0002c383, Lbu},
+		{0x0002d383, Lhu},
+		{0x0052b393, Sltiu},
+		{0x0ff0000f, Fence},
+		{0x00000097, Auipc},
+		{0x000080e7, Jalr},
+		{0x00000073, Ecall},
+		{0x00100073, Ebreak},
+		{0x30200073, Mret},
+		{0x10500073, Wfi},
+		{0x00852007, Flw},
+		{0xff813587, Fld},
+		{0x0082a227, Fsw},
+		{0x01f5b827, Fsd},
+		{0x00c5f553, FaddS},
+		{0x0a209053, FsubD},
+		{0x1349b953, FmulD},
+		{0x185221d3, FdivS},
+		{0x5a05f553, FsqrtD},
+		{0x68c5f543, FmaddS},
+		{0x1a20c04b, FnmsubD},
+		{0x20c5a553, FsgnjxS},
+		{0x2ac59553, FmaxD},
+		{0x4015f553, FcvtSD},
+		{0x42058553, FcvtDS},
+		{0xa2c5a553, FeqD},
+		{0xa0c59553, FltS},
+		{0xc2159553, FcvtWuD},
+		{0xd005f553, FcvtSW},
+		{0xe0058553, FmvXW},
+		{0xe2059553, FclassD},
+		{0xf0058553, FmvWX},
+	}
+	for _, tt := range tests {
+		runner, err := DecodeInstruction(tt.word)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, runner.InstructionType(), "%#08x", tt.word)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	_, err := Decode([]byte{0x13, 0x00, 0x00})
+	assert.Error(t, err)
+
+	_, err = DecodeInstruction(0x00000000)
+	assert.Error(t, err)
+
+	// sret
+	_, err = DecodeInstruction(0x10200073)
+	assert.Error(t, err)
+
+	// lr.w with a nonzero rs2
+	_, err = DecodeInstruction(0x1062a3af)
+	assert.Error(t, err)
+
+	// fadd.s with a reserved rounding mode
+	_, err = DecodeInstruction(0x00c5d553)
+	assert.Error(t, err)
+}
+
+func TestDecodeCompressedInstruction(t *testing.T) {
+	tests := []struct {
+		half uint16
+		want InstructionRunner
+	}{
+		{0x0808, &addi{rd: A0, rs: Sp, imm: 16}},
+		{0x2588, &fload{instructionType: Fld, rd: Fa0, offset: 8, rs: A1}},
+		{0x42d0, &lw{rd: A2, offset: 4, rs: A3}},
+		{0x7ce0, &fload{instructionType: Flw, rd: Fs0, offset: 124, rs: S1}},
+		{0xbffc, &fstore{instructionType: Fsd, rs: Fa5, offset: 248, rd: A5}},
+		{0xc138, &sw{rs: A4, offset: 64, rd: A0}},
+		{0xe204, &fstore{instructionType: Fsw, rs: Fs1, offset: 0, rd: A2}},
+		{0x0001, &addi{rd: Zero, rs: Zero, imm: 0}},
+		{0x1281, &addi{rd: T0, rs: T0, imm: -32}},
+		{0x2ffd, &jal{rd: Ra, offset: 2046}},
+		{0x457d, &addi{rd: A0, rs: Zero, imm: 31}},
+		{0x7101, &addi{rd: Sp, rs: Sp, imm: -512}},
+		{0x6085, &lui{rd: Ra, imm: 1}},
+		{0x757d, &lui{rd: A0, imm: -1}},
+		{0x807d, &srli{rd: S0, rs: S0, imm: 31}},
+		{0x858d, &srai{rd: A1, rs: A1, imm: 3}},
+		{0x9a7d, &andi{rd: A2, rs: A2, imm: -1}},
+		{0x8e99, &sub{rd: A3, rs1: A3, rs2: A4}},
+		{0x8fa1, &xor{rd: A5, rs1: A5, rs2: S0}},
+		{0x8cc9, &or{rd: S1, rs1: S1, rs2: A0}},
+		{0x8df1, &and{rd: A1, rs1: A1, rs2: A2}},
+		{0xb001, &jal{rd: Zero, offset: -2048}},
+		{0xd101, &beq{rs1: A0, rs2: Zero, offset: -256}},
+		{0xecfd, &bne{rs1: S1, rs2: Zero, offset: 254}},
+		{0x0316, &slli{rd: T1, rs: T1, imm: 5}},
+		{0x30fe, &fload{instructionType: Fld, rd: Ft1, offset: 504, rs: Sp}},
+		{0x50fe, &lw{rd: Ra, offset: 252, rs: Sp}},
+		{0x6112, &fload{instructionType: Flw, rd: Ft2, offset: 4, rs: Sp}},
+		{0x8282, &jalr{rd: Zero, rs: T0}},
+		{0x857e, &add{rd: A0, rs1: Zero, rs2: T6}},
+		{0x9002, &ebreak{}},
+		{0x9582, &jalr{rd: Ra, rs: A1}},
+		{0x994e, &add{rd: S2, rs1: S2, rs2: S3}},
+		{0xa46e, &fstore{instructionType: Fsd, rs: Fs11, offset: 8, rd: Sp}},
+		{0xdffe, &sw{rs: T6, offset: 252, rd: Sp}},
+		{0xe002, &fstore{instructionType: Fsw, rs: Ft0, offset: 0, rd: Sp}},
+	}
+	for _, tt := range tests {
+		runner, err := DecodeCompressedInstruction(tt.half)
+		require.NoError(t, err, "%#04x", tt.half)
+		assert.Equal(t, &compressed{tt.want}, runner, "%#04x", tt.half)
+		assert.Equal(t, int32(2), InstructionSize(runner))
+	}
+
+	for _, half := range []uint16{
+		0x0000, // Illegal instruction
+		0x1082, // c.slli with a shift amount above 31
+		0x9c01, // c.subw
+		0x6101, // c.addi16sp with a zero immediate
+		0x4002, // c.lwsp with rd = 0
+		0x8002, // c.jr with rs1 = 0
+		0x0003, // 32-bit encoding
+	} {
+		_, err := DecodeCompressedInstruction(half)
+		assert.Error(t, err, "%#04x", half)
+	}
+}
+
+func TestDecodeMixedLengths(t *testing.T) {
+	code := []byte{
+		0x29, 0x45, // c.li a0, 10
+		0x93, 0x05, 0x00, 0x00, // addi a1, zero, 0
+		0xaa, 0x95, // c.add a1, a0
+		0x7d, 0x15, // c.addi a0, -1
+		0x75, 0xfd, // c.bnez a0, -4
+		0x11, 0x20, // c.jal 4
+		0x21, 0xa0, // c.j 8
+		0x13, 0x06, 0x70, 0x00, // addi a2, zero, 7
+		0x82, 0x80, // c.jr ra
+	}
+	app, err := Decode(code)
+	require.NoError(t, err)
+	assert.True(t, app.Compressed)
+	assert.Equal(t, int32(len(code)), app.End())
+	assert.Equal(t, int32(4), app.Size(2))
+	_, exists := app.Instruction(4)
+	assert.False(t, exists)
+
+	r := NewRunner(app, 8)
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(55), r.Ctx.Registers[A1])
+	assert.Equal(t, int32(7), r.Ctx.Registers[A2])
+	// c.jal links the next instruction, 2 bytes ahead
+	assert.Equal(t, int32(14), r.Ctx.Registers[Ra])
+
+	_, err = Decode(code[:4])
+	assert.Error(t, err)
+}
+
+func TestDecodeCSR(t *testing.T) {
+	tests := []struct {
+		word uint32
+		want InstructionRunner
+	}{
+		{0xc0002573, &csr{instructionType: Csrrs, rd: A0, csr: CSRCycle}},                 // rdcycle a0
+		{0x003295f3, &csr{instructionType: Csrrw, rd: A1, rs: T0, uimm: 5, csr: CSRFcsr}}, // csrrw a1, fcsr, t0
+		{0x0011f073, &csr{instructionType: Csrrci, rs: Gp, uimm: 3, csr: CSRFflags}},      // csrci fflags, 3
+	}
+	for _, tt := range tests {
+		runner, err := DecodeInstruction(tt.word)
+		require.NoError(t, err, "%#08x", tt.word)
+		assert.Equal(t, tt.want, runner, "%#08x", tt.word)
+	}
+
+	_, err := DecodeInstruction(0x00004073) // funct3 = 4
+	assert.Error(t, err)
+}
+
+func TestDecodeFloatOperands(t *testing.T) {
+	runner, err := DecodeInstruction(0x1a20c04b) // fnmsub.d ft0, ft1, ft2, ft3, rmm
+	require.NoError(t, err)
+	assert.Equal(t, &fop{instructionType: FnmsubD, rd: Ft0, rs1: Ft1, rs2: Ft2, rs3: Ft3, rm: rmRMM}, runner)
+
+	runner, err = DecodeInstruction(0xa2c5a553) // feq.d a0, fa1, fa2
+	require.NoError(t, err)
+	assert.Equal(t, []RegisterType{A0}, runner.WriteRegisters())
+	assert.Equal(t, []RegisterType{Fa1, Fa2}, runner.ReadRegisters())
+
+	runner, err = DecodeInstruction(0x01f5b827) // fsd ft11, 16(a1)
+	require.NoError(t, err)
+	assert.Equal(t, &fstore{instructionType: Fsd, rs: Ft11, offset: 16, rd: A1}, runner)
+}
@@ -0,0 +1,175 @@
+package risc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Severity is the severity of a parse diagnostic.
+type Severity int
+
+const (
+	// SeverityError fails the parse.
+	SeverityError Severity = iota
+	// SeverityWarning is reported without failing the parse.
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return fmt.Sprintf("severity %d", int(s))
+}
+
+// ErrorCode is the machine-readable kind of a parse diagnostic.
+type ErrorCode string
+
+const (
+	CodeSyntax               ErrorCode = "syntax"
+	CodeUnknownInstruction   ErrorCode = "unknown-instruction"
+	CodeOperandCount         ErrorCode = "operand-count"
+	CodeInvalidRegister      ErrorCode = "invalid-register"
+	CodeInvalidExpression    ErrorCode = "invalid-expression"
+	CodeUndefinedSymbol      ErrorCode = "undefined-symbol"
+	CodeOutOfRange           ErrorCode = "out-of-range"
+	CodeDuplicateLabel       ErrorCode = "duplicate-label"
+	CodeUnsupportedDirective ErrorCode = "unsupported-directive"
+	CodeWriteToZero          ErrorCode = "write-to-zero"
+)
+
+// Location is a position in the source of an application. Line and Column
+// start at 1.
+type Location struct {
+	File   string
+	Line   int
+	Column int
+}
+
+func (l Location) String() string {
+	file := l.File
+	if file == "" {
+		file = "<input>"
+	}
+	return fmt.Sprintf("%s:%d:%d", file, l.Line, l.Column)
+}
+
+// ParseError is a diagnostic located in the source of an application. Line
+// and Column start at 1. If the line comes from a macro, they locate it in the
+// body of the macro and ExpandedFrom lists the invocations it was expanded
+// from, the innermost first.
+type ParseError struct {
+	File         string
+	Line         int
+	Column       int
+	Severity     Severity
+	Code         ErrorCode
+	Message      string
+	ExpandedFrom []Location
+}
+
+func (e *ParseError) Error() string {
+	s := fmt.Sprintf("%v: %v: %s [%s]", Location{e.File, e.Line, e.Column}, e.Severity, e.Message, e.Code)
+	for _, invocation := range e.ExpandedFrom {
+		s += fmt.Sprintf(" (expanded from %v)", invocation)
+	}
+	return s
+}
+
+// ParseErrors is the list of errors returned by Parse, in source order.
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	lines := make([]string, 0, len(e))
+	for _, err := range e {
+		lines = append(lines, err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// diagnostic is an error caused by a token of a line, which locates it.
+type diagnostic struct {
+	code    ErrorCode
+	token   string
+	message string
+}
+
+func (d *diagnostic) Error() string {
+	return d.message
+}
+
+func newDiagnostic(code ErrorCode, token, format string, args ...any) *diagnostic {
+	return &diagnostic{code: code, token: token, message: fmt.Sprintf(format, args...)}
+}
+
+// newParseError locates an error in a source line. The column is the one of
+// the token causing the error if known, of the statement otherwise.
+func newParseError(input inputLine, source sourceLine, severity Severity, err error) *ParseError {
+	e := &ParseError{
+		File:         input.file,
+		Line:         input.line,
+		Column:       source.column + 1,
+		Severity:     severity,
+		Code:         CodeSyntax,
+		Message:      err.Error(),
+		ExpandedFrom: input.expandedFrom,
+	}
+	var d *diagnostic
+	if errors.As(err, &d) {
+		e.Code = d.code
+		if i := locateToken(source, d.token); i != -1 {
+			e.Column += i
+		} else if d.token != "" && d.token == source.label {
+			e.Column = source.labelColumn + 1
+		}
+	}
+	return e
+}
+
+// locateToken returns the 0-based offset of a token in a statement, or -1.
+// The operands are searched first, an operand equal to the token taking
+// precedence over one containing it, so that the a of add a0, a, a1 isn't
+// located in a0.
+func locateToken(source sourceLine, token string) int {
+	if token == "" {
+		return -1
+	}
+	for _, operand := range source.operands {
+		if operand.text == token {
+			return operand.column
+		}
+	}
+	for _, operand := range source.operands {
+		if i := indexWord(operand.text, token); i != -1 {
+			return operand.column + i
+		}
+	}
+	return strings.Index(source.statement, token)
+}
+
+// indexWord returns the index of the first occurrence of a token that isn't
+// part of a longer name, or -1.
+func indexWord(s, token string) int {
+	isName := func(i int) bool {
+		if i < 0 || i >= len(s) {
+			return false
+		}
+		c := s[i]
+		return c == '_' || c == '.' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+	}
+	for offset := 0; ; {
+		i := strings.Index(s[offset:], token)
+		if i == -1 {
+			return -1
+		}
+		i += offset
+		if !isLabel(token) || !isName(i-1) && !isName(i+len(token)) {
+			return i
+		}
+		offset = i + 1
+	}
+}
@@ -0,0 +1,84 @@
+package risc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseErrors(t *testing.T) {
+	_, err := ParseFile("main.s", `main:
+  addi t0, t7, 1
+	add t0, t1
+foo t0, t1, t2
+main: nop
+  beq t0, t1, missing
+.data
+.byte 1, 0x100
+.foo
+.text
+addi t0, zero, 1
+li t1, (2`)
+	require.Error(t, err)
+
+	var errs ParseErrors
+	require.True(t, errors.As(err, &errs))
+	type location struct {
+		line, column int
+		code         ErrorCode
+	}
+	var got []location
+	for _, e := range errs {
+		assert.Equal(t, "main.s", e.File)
+		assert.Equal(t, SeverityError, e.Severity)
+		got = append(got, location{e.Line, e.Column, e.Code})
+	}
+	assert.Equal(t, []location{
+		{2, 12, CodeInvalidRegister},
+		{3, 2, CodeOperandCount},
+		{4, 1, CodeUnknownInstruction},
+		{5, 1, CodeDuplicateLabel},
+		{6, 15, CodeUndefinedSymbol},
+		{8, 10, CodeOutOfRange},
+		{9, 1, CodeUnsupportedDirective},
+		{12, 8, CodeInvalidExpression},
+	}, got)
+	assert.Equal(t, "main.s:2:12: error: unknown register: t7 [invalid-register]", errs[0].Error())
+}
+
+func TestParseErrorOperandColumn(t *testing.T) {
+	for source, column := range map[string]int{
+		"add a0, a, a1":          9,
+		"add a0, a0, a":          13,
+		"lw a0, a(a1)":           8,
+		"sw a1, 4(a)":            10,
+		"addi a0, a0, a + 1":     14,
+		"  addi a0,a1,  a":       16,
+		"l: addi a0, a, 1":       13,
+		".data\n.byte 1, 1, 256": 13,
+	} {
+		_, err := Parse(source)
+		var errs ParseErrors
+		require.True(t, errors.As(err, &errs), source)
+		assert.Equal(t, column, errs[0].Column, source)
+	}
+}
+
+func TestParseWarnings(t *testing.T) {
+	app, err := Parse(`nop
+addi zero, zero, 0
+addi zero, t0, 1
+j end
+jr ra
+csrw mscratch, t0
+  add zero, t0, t1
+end:`)
+	require.NoError(t, err)
+	require.Len(t, app.Warnings, 2)
+	assert.Equal(t, ParseError{Line: 3, Column: 6, Severity: SeverityWarning, Code: CodeWriteToZero,
+		Message: "addi writes to zero: the result is discarded"}, *app.Warnings[0])
+	assert.Equal(t, 7, app.Warnings[1].Line)
+	assert.Equal(t, 7, app.Warnings[1].Column)
+}
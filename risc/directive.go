@@ -0,0 +1,425 @@
+package risc
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// section is an assembler section. The text section holds the instructions,
+// whereas the other ones are laid out in memory, in this order, from address
+// 0.
+type section int
+
+const (
+	sectionText section = iota
+	sectionData
+	sectionRodata
+	sectionBss
+)
+
+var sectionNames = map[string]section{
+	".text":   sectionText,
+	".data":   sectionData,
+	".rodata": sectionRodata,
+	".bss":    sectionBss,
+}
+
+// metadataDirectives are the directives emitted by compilers that don't affect
+// the application.
+var metadataDirectives = map[string]bool{
+	".globl":     true,
+	".global":    true,
+	".local":     true,
+	".file":      true,
+	".ident":     true,
+	".type":      true,
+	".size":      true,
+	".option":    true,
+	".attribute": true,
+}
+
+// dataSections is the order of the data sections in memory.
+var dataSections = []section{sectionData, sectionRodata, sectionBss}
+
+// parseSectionDirective returns the section selected by a directive, either
+// the section name itself or .section followed by the name.
+func parseSectionDirective(directive, args string) (section, bool, error) {
+	if directive == ".section" {
+		name, _, _ := strings.Cut(args, ",")
+		s, exists := sectionNames[strings.TrimSpace(name)]
+		if !exists {
+			return 0, true, newDiagnostic(CodeUnsupportedDirective, strings.TrimSpace(name), "unsupported section: %s", strings.TrimSpace(name))
+		}
+		return s, true, nil
+	}
+	s, exists := sectionNames[directive]
+	return s, exists, nil
+}
+
+// sourceLine is a line stripped of its comment and split into an optional
+// label and a statement: a directive or an instruction.
+type sourceLine struct {
+	label     string
+	statement string
+	// labelColumn and column are the 0-based offsets of the label and of the
+	// statement in the line.
+	labelColumn int
+	column      int
+	// operands are the comma-separated operands of the statement.
+	operands []operand
+}
+
+// operand is an operand of a statement, trimmed, with its 0-based offset in
+// the statement.
+type operand struct {
+	text   string
+	column int
+}
+
+// splitStatementOperands locates the operands following the mnemonic or the
+// directive of a statement.
+func splitStatementOperands(statement string) []operand {
+	i := strings.Index(statement, " ")
+	if i == -1 {
+		return nil
+	}
+	split, err := splitOperands(statement[i+1:])
+	if err != nil {
+		return nil
+	}
+	operands := make([]operand, 0, len(split))
+	column := i + 1
+	for _, s := range split {
+		trimmed := strings.TrimLeft(s, " ")
+		operands = append(operands, operand{
+			text:   strings.TrimSpace(s),
+			column: column + len(s) - len(trimmed),
+		})
+		column += len(s) + 1
+	}
+	return operands
+}
+
+// splitSourceLine strips the comment of a line, ignoring the # characters of
+// a string or character literal, and splits the label preceding the statement. The tabs
+// separating the operands are replaced with spaces.
+func splitSourceLine(line string) sourceLine {
+	b := []byte(line)
+	var quote byte
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '\t':
+			b[i] = ' '
+		case c == '#':
+			b = b[:i]
+		}
+	}
+	trimmed := strings.TrimLeft(string(b), " \r")
+	indent := len(b) - len(trimmed)
+	line = strings.TrimSpace(trimmed)
+
+	head, tail, found := strings.Cut(line, " ")
+	if strings.HasSuffix(head, ":") {
+		statement := strings.TrimSpace(tail)
+		column := indent + len(head)
+		if found {
+			column += 1 + len(tail) - len(strings.TrimLeft(tail, " "))
+		}
+		return sourceLine{
+			label:       head[:len(head)-1],
+			statement:   statement,
+			labelColumn: indent,
+			column:      column,
+			operands:    splitStatementOperands(statement),
+		}
+	}
+	return sourceLine{statement: line, labelColumn: indent, column: indent, operands: splitStatementOperands(line)}
+}
+
+// dataLayout is the memory image of the data sections.
+type dataLayout struct {
+	segments []Segment
+	labels   map[string]int32
+}
+
+// sectionContent is the content of a data section being assembled.
+type sectionContent struct {
+	data []int8
+	// alignment is the largest alignment required by the section.
+	alignment int32
+	labels    map[string]int32
+}
+
+// layoutData assembles the data sections of an application: the lines of the
+// text section are skipped, as they are parsed afterward. known holds the
+// labels found by the first pass, or nil during the first pass.
+// The errors are indexed by line.
+func layoutData(lines []inputLine, known map[string]int32) (dataLayout, map[int]ParseErrors) {
+	exprs := newExpressions(known)
+	contents := make(map[section]*sectionContent)
+	for _, s := range dataSections {
+		contents[s] = &sectionContent{alignment: 4, labels: make(map[string]int32)}
+	}
+
+	errs := make(map[int]ParseErrors)
+	defined := make(map[string]bool)
+	current := sectionText
+	for i, input := range lines {
+		if input.err != nil {
+			continue
+		}
+		source := splitSourceLine(input.text)
+		fail := func(err error) {
+			errs[i] = append(errs[i], newParseError(input, source, SeverityError, err))
+		}
+		directive, args, _ := strings.Cut(source.statement, " ")
+		args = strings.TrimSpace(args)
+		s, isSection, err := parseSectionDirective(directive, args)
+		if isSection {
+			if err != nil {
+				fail(err)
+				continue
+			}
+			current = s
+			if source.label == "" {
+				continue
+			}
+		}
+		if directive == ".equ" || directive == ".set" {
+			// The errors are reported by the parser of the text section
+			_ = exprs.define(args)
+			continue
+		}
+		if current == sectionText {
+			continue
+		}
+
+		content := contents[current]
+		if source.label != "" {
+			if defined[source.label] {
+				fail(newDiagnostic(CodeDuplicateLabel, source.label, "label %s already defined", source.label))
+			} else {
+				defined[source.label] = true
+				content.labels[source.label] = int32(len(content.data))
+			}
+		}
+		if source.statement == "" || isSection {
+			continue
+		}
+		if err := content.assemble(current, directive, args, exprs); err != nil {
+			fail(err)
+		}
+	}
+
+	layout := dataLayout{labels: make(map[string]int32)}
+	var addr int32
+	for _, s := range dataSections {
+		content := contents[s]
+		if len(content.data) == 0 {
+			for label := range content.labels {
+				layout.labels[label] = addr
+			}
+			continue
+		}
+		addr = alignAddress(addr, content.alignment)
+		for label, offset := range content.labels {
+			layout.labels[label] = addr + offset
+		}
+		layout.segments = append(layout.segments, Segment{Address: addr, Data: content.data})
+		addr += int32(len(content.data))
+	}
+	return layout, errs
+}
+
+// assemble appends the data of a directive to a section.
+func (c *sectionContent) assemble(s section, directive, args string, exprs *expressions) error {
+	if !strings.HasPrefix(directive, ".") {
+		return newDiagnostic(CodeSyntax, directive, "instruction outside the text section: %s", directive)
+	}
+	if metadataDirectives[directive] {
+		return nil
+	}
+	switch directive {
+	case ".align", ".p2align":
+		n, err := exprs.constant(args)
+		if err != nil {
+			return err
+		}
+		if n < 0 || n > 12 {
+			return newDiagnostic(CodeOutOfRange, args, "invalid alignment: %d", n)
+		}
+		alignment := int32(1) << n
+		c.alignment = max(c.alignment, alignment)
+		c.pad(int(alignAddress(int32(len(c.data)), alignment)) - len(c.data))
+		return nil
+	case ".space", ".zero":
+		size, err := exprs.constant(args)
+		if err != nil {
+			return err
+		}
+		if size < 0 {
+			return newDiagnostic(CodeOutOfRange, args, "invalid size: %d", size)
+		}
+		c.pad(int(size))
+		return nil
+	case ".byte", ".half", ".word", ".ascii", ".asciz", ".string":
+	default:
+		return newDiagnostic(CodeUnsupportedDirective, directive, "unsupported directive: %s", directive)
+	}
+
+	if s == sectionBss {
+		return fmt.Errorf("initialized data in .bss: %s", directive)
+	}
+	values, err := splitDirectiveArgs(args)
+	if err != nil {
+		return err
+	}
+	switch directive {
+	case ".byte", ".half", ".word":
+		size := map[string]int{".byte": 1, ".half": 2, ".word": 4}[directive]
+		for _, value := range values {
+			v, err := exprs.eval(value)
+			if err != nil {
+				return err
+			}
+			n := v.value
+			if n < -(1<<(8*size-1)) || n > 1<<(8*size)-1 {
+				return newDiagnostic(CodeOutOfRange, value, "value out of range: %d", n)
+			}
+			for i := 0; i < size; i++ {
+				c.data = append(c.data, int8(n>>(8*i)))
+			}
+		}
+	case ".ascii", ".asciz", ".string":
+		for _, value := range values {
+			str, err := parseStringLiteral(value)
+			if err != nil {
+				return err
+			}
+			for i := 0; i < len(str); i++ {
+				c.data = append(c.data, int8(str[i]))
+			}
+			if directive != ".ascii" {
+				c.data = append(c.data, 0)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *sectionContent) pad(n int) {
+	c.data = append(c.data, make([]int8, n)...)
+}
+
+func alignAddress(addr, alignment int32) int32 {
+	return (addr + alignment - 1) / alignment * alignment
+}
+
+// isLabel returns whether a string is a valid label name.
+func isLabel(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for _, c := range s {
+		if !(c == '_' || c == '.' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// splitOperands splits comma-separated operands, ignoring the commas of a
+// string or character literal, such as "a,b" or ','. The operands aren't
+// trimmed.
+func splitOperands(s string) ([]string, error) {
+	var (
+		operands []string
+		quote    byte
+		start    int
+	)
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			operands = append(operands, s[start:i])
+			start = i + 1
+		}
+	}
+	switch quote {
+	case '"':
+		return nil, fmt.Errorf("unterminated string: %s", s)
+	case '\'':
+		return nil, fmt.Errorf("unterminated character: %s", s)
+	}
+	return append(operands, s[start:]), nil
+}
+
+// splitDirectiveArgs splits the comma-separated arguments of a directive,
+// ignoring the commas of a string or character literal.
+func splitDirectiveArgs(args string) ([]string, error) {
+	values, err := splitOperands(args)
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		values[i] = strings.TrimSpace(value)
+		if values[i] == "" {
+			return nil, fmt.Errorf("missing argument: %s", args)
+		}
+	}
+	return values, nil
+}
+
+// parseStringLiteral parses a double-quoted string, with the escape sequences
+// of the GNU assembler.
+func parseStringLiteral(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("invalid string: %s", s)
+	}
+	s = s[1 : len(s)-1]
+	var sb strings.Builder
+	for len(s) > 0 {
+		if s[0] == '\\' && len(s) > 1 && s[1] >= '0' && s[1] <= '7' {
+			// Octal escape of up to 3 digits, such as \0
+			end := 2
+			for end < len(s) && end < 4 && s[end] >= '0' && s[end] <= '7' {
+				end++
+			}
+			n, _ := strconv.ParseUint(s[1:end], 8, 16)
+			if n > math.MaxUint8 {
+				return "", fmt.Errorf("invalid escape sequence: %s", s[:end])
+			}
+			sb.WriteByte(byte(n))
+			s = s[end:]
+			continue
+		}
+		c, _, tail, err := strconv.UnquoteChar(s, '"')
+		if err != nil {
+			return "", fmt.Errorf("invalid string: %v", err)
+		}
+		if c > math.MaxUint8 {
+			sb.WriteRune(c)
+		} else {
+			sb.WriteByte(byte(c))
+		}
+		s = tail
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,85 @@
+package risc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDataSegments(t *testing.T) {
+	app, err := Parse(`.globl main
+.text
+main:
+la t0, msg
+lw t1, words(zero)
+lbu t2, 1(t0)
+.data
+words: .word -2, 258
+halves:
+.half 3
+.byte 1, -1 # comment
+msg: .asciz "a,b#\n"
+.align 3
+str: .string "x\0"
+.ascii "y"
+.section .rodata
+table: .word main, end
+.bss
+buffer: .space 6
+.text
+end:
+nop`)
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(0), app.Labels["words"])
+	assert.Equal(t, int32(8), app.Labels["halves"])
+	assert.Equal(t, int32(12), app.Labels["msg"])
+	assert.Equal(t, int32(24), app.Labels["str"])
+	assert.Equal(t, int32(28), app.Labels["table"])
+	assert.Equal(t, int32(36), app.Labels["buffer"])
+	assert.Equal(t, int32(0), app.Labels["main"])
+	assert.Equal(t, int32(16), app.Labels["end"])
+
+	require.Len(t, app.Segments, 3)
+	assert.Equal(t, Segment{Address: 0, Data: []int8{
+		-2, -1, -1, -1, 2, 1, 0, 0,
+		3, 0, 1, -1,
+		'a', ',', 'b', '#', '\n', 0, 0, 0,
+		0, 0, 0, 0,
+		'x', 0, 0, 'y',
+	}}, app.Segments[0])
+	assert.Equal(t, Segment{Address: 28, Data: []int8{0, 0, 0, 0, 16, 0, 0, 0}}, app.Segments[1])
+	assert.Equal(t, Segment{Address: 36, Data: make([]int8, 6)}, app.Segments[2])
+
+	r := NewRunner(app, 64)
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(12), r.Ctx.Registers[T0])
+	assert.Equal(t, int32(-2), r.Ctx.Registers[T1])
+	assert.Equal(t, int32(','), r.Ctx.Registers[T2])
+}
+
+func TestDataSegmentsErrors(t *testing.T) {
+	for _, s := range []string{
+		".bss\n.word 1",
+		".data\n.byte 256",
+		".data\n.ascii \"a",
+		".data\n.foo 1",
+		".data\nnop",
+		".data\n.word missing",
+		".data\nfoo: .word 1\n.text\nfoo:",
+		".section .debug",
+		".text\n.byte 1",
+	} {
+		_, err := Parse(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestTextAlignment(t *testing.T) {
+	app, err := Parse(`c.nop
+.align 3
+end:`)
+	require.NoError(t, err)
+	assert.Equal(t, int32(8), app.Labels["end"])
+}
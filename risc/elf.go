@@ -0,0 +1,349 @@
+package risc
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// ParseELF loads a statically linked RV32 executable.
+// Every loadable segment becomes part of the memory image, except for the bytes
+// of the executable sections: as the instructions and the data have separate
+// address spaces, these are decoded into instructions placed at their virtual
+// address instead. The symbol table is exposed as labels. The segments must fit
+// in memory bytes.
+func ParseELF(data []byte, memory int) (Application, error) {
+	f, err := elf.NewFile(bytes.NewReader(data))
+	if err != nil {
+		return Application{}, err
+	}
+	defer f.Close()
+
+	if f.Class != elf.ELFCLASS32 || f.Data != elf.ELFDATA2LSB {
+		return Application{}, fmt.Errorf("unsupported elf: expected a 32-bit little-endian file, got %v %v", f.Class, f.Data)
+	}
+	if f.Machine != elf.EM_RISCV {
+		return Application{}, fmt.Errorf("unsupported elf machine: %v", f.Machine)
+	}
+	if f.Type != elf.ET_EXEC {
+		return Application{}, fmt.Errorf("unsupported elf type: %v", f.Type)
+	}
+	if f.Entry%2 != 0 {
+		return Application{}, fmt.Errorf("misaligned entry point: %#x", f.Entry)
+	}
+
+	var code []*elf.Section
+	for _, section := range f.Sections {
+		if section.Type == elf.SHT_PROGBITS && section.Flags&elf.SHF_EXECINSTR != 0 {
+			code = append(code, section)
+		}
+	}
+	isCode := func(offset uint64) bool {
+		for _, section := range code {
+			if offset >= section.Offset && offset < section.Offset+section.Size {
+				return true
+			}
+		}
+		return false
+	}
+
+	var segments []Segment
+	for _, prog := range f.Progs {
+		if prog.Type != elf.PT_LOAD || prog.Memsz == 0 {
+			continue
+		}
+		if prog.Filesz > prog.Memsz {
+			return Application{}, fmt.Errorf("segment at %#x: file size %d exceeds memory size %d",
+				prog.Vaddr, prog.Filesz, prog.Memsz)
+		}
+		if prog.Vaddr+prog.Memsz > uint64(memory) {
+			return Application{}, fmt.Errorf("segment at %#x of %d bytes does not fit in %d bytes of memory",
+				prog.Vaddr, prog.Memsz, memory)
+		}
+		content, err := io.ReadAll(prog.Open())
+		if err != nil {
+			return Application{}, fmt.Errorf("segment at %#x: %v", prog.Vaddr, err)
+		}
+		segment := Segment{
+			Address: int32(prog.Vaddr),
+			Data:    make([]int8, prog.Memsz),
+		}
+		codeBytes := 0
+		for i, b := range content {
+			if isCode(prog.Off + uint64(i)) {
+				codeBytes++
+				continue
+			}
+			segment.Data[i] = int8(b)
+		}
+		if uint64(codeBytes) == prog.Memsz {
+			// The segment holds only instructions
+			continue
+		}
+		segments = append(segments, segment)
+	}
+
+	var (
+		pcs     []int32
+		runners []InstructionRunner
+	)
+	for _, section := range code {
+		if section.Addr%2 != 0 || section.Size%2 != 0 {
+			return Application{}, fmt.Errorf("section %s: misaligned code", section.Name)
+		}
+		data, err := section.Data()
+		if err != nil {
+			return Application{}, fmt.Errorf("section %s: %v", section.Name, err)
+		}
+
+		for i := 0; i < len(data); {
+			pc := int32(section.Addr) + int32(i)
+			runner, err := decodeAt(data[i:])
+			if err != nil {
+				return Application{}, fmt.Errorf("section %s: pc %#x: %v", section.Name, pc, err)
+			}
+			pcs = append(pcs, pc)
+			runners = append(runners, runner)
+			i += int(InstructionSize(runner))
+		}
+	}
+	if len(runners) == 0 {
+		return Application{}, fmt.Errorf("no executable section")
+	}
+	instructions, isCompressed := layout(pcs, runners)
+
+	labels := make(map[string]int32)
+	symbols, err := f.Symbols()
+	if err != nil && err != elf.ErrNoSymbols {
+		return Application{}, err
+	}
+	for _, symbol := range symbols {
+		if symbol.Name == "" || symbol.Section == elf.SHN_UNDEF {
+			continue
+		}
+		switch elf.ST_TYPE(symbol.Info) {
+		case elf.STT_NOTYPE, elf.STT_FUNC, elf.STT_OBJECT:
+			labels[symbol.Name] = int32(symbol.Value)
+		}
+	}
+
+	return Application{
+		Instructions: instructions,
+		Compressed:   isCompressed,
+		Labels:       labels,
+		Entry:        int32(f.Entry),
+		Segments:     segments,
+	}, nil
+}
+
+// efRiscvRVC is the flag of an executable containing compressed
+// instructions.
+const efRiscvRVC = 0x1
+
+// EncodeELF assembles an application into a statically linked RV32
+// executable, with the labels in the symbol table. The text is a loadable
+// executable segment at address 0, followed by one loadable segment per data
+// segment. As the instructions and the data have separate address spaces, the
+// data segments may overlap the text, which ParseELF doesn't load in memory: it
+// loads the same application back.
+func EncodeELF(app Application) ([]byte, error) {
+	const (
+		ehdrSize = 52
+		phdrSize = 32
+		shdrSize = 40
+		symSize  = 16
+	)
+	code, err := Encode(app)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		body     bytes.Buffer
+		shstrtab = []byte{0}
+		strtab   = []byte{0}
+		sections = []elf.Section32{{}}
+		progs    []elf.Prog32
+	)
+	phnum := len(app.Segments)
+	if len(code) != 0 {
+		phnum++
+	}
+	offset := func() uint32 {
+		return uint32(ehdrSize + phdrSize*phnum + body.Len())
+	}
+	align := func() {
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+	}
+	name := func(table *[]byte, s string) uint32 {
+		idx := uint32(len(*table))
+		*table = append(append(*table, s...), 0)
+		return idx
+	}
+
+	if len(code) != 0 {
+		progs = append(progs, elf.Prog32{
+			Type:   uint32(elf.PT_LOAD),
+			Off:    offset(),
+			Filesz: uint32(len(code)),
+			Memsz:  uint32(len(code)),
+			Flags:  uint32(elf.PF_R | elf.PF_X),
+			Align:  4,
+		})
+	}
+	sections = append(sections, elf.Section32{
+		Name:      name(&shstrtab, ".text"),
+		Type:      uint32(elf.SHT_PROGBITS),
+		Flags:     uint32(elf.SHF_ALLOC | elf.SHF_EXECINSTR),
+		Off:       offset(),
+		Size:      uint32(len(code)),
+		Addralign: 4,
+	})
+	body.Write(code)
+	for _, segment := range app.Segments {
+		align()
+		data := make([]byte, len(segment.Data))
+		for i, b := range segment.Data {
+			data[i] = byte(b)
+		}
+		prog := elf.Prog32{
+			Type:   uint32(elf.PT_LOAD),
+			Off:    offset(),
+			Vaddr:  uint32(segment.Address),
+			Paddr:  uint32(segment.Address),
+			Filesz: uint32(len(data)),
+			Memsz:  uint32(len(data)),
+			Flags:  uint32(elf.PF_R | elf.PF_W),
+			Align:  4,
+		}
+		section := elf.Section32{
+			Name:      name(&shstrtab, ".data"),
+			Type:      uint32(elf.SHT_PROGBITS),
+			Flags:     uint32(elf.SHF_ALLOC | elf.SHF_WRITE),
+			Addr:      uint32(segment.Address),
+			Off:       offset(),
+			Size:      uint32(len(data)),
+			Addralign: 4,
+		}
+		if isZero(data) {
+			prog.Filesz = 0
+			section.Name = name(&shstrtab, ".bss")
+			section.Type = uint32(elf.SHT_NOBITS)
+		}
+		progs = append(progs, prog)
+		sections = append(sections, section)
+		body.Write(data[:prog.Filesz])
+	}
+
+	labels := make([]string, 0, len(app.Labels))
+	for label := range app.Labels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	syms := []elf.Sym32{{}}
+	for _, label := range labels {
+		addr := uint32(app.Labels[label])
+		symbolType := elf.STT_FUNC
+		if app.DataLabels[label] {
+			symbolType = elf.STT_OBJECT
+		}
+		shndx := uint16(elf.SHN_ABS)
+		for i := 1; i < len(app.Segments)+2; i++ {
+			// The text section, at index 1, overlaps the data sections
+			if (i == 1) == app.DataLabels[label] {
+				continue
+			}
+			if addr >= sections[i].Addr && addr < sections[i].Addr+sections[i].Size {
+				shndx = uint16(i)
+				break
+			}
+		}
+		syms = append(syms, elf.Sym32{
+			Name:  name(&strtab, label),
+			Value: addr,
+			Info:  elf.ST_INFO(elf.STB_GLOBAL, symbolType),
+			Shndx: shndx,
+		})
+	}
+
+	align()
+	symtabIdx := len(sections)
+	sections = append(sections, elf.Section32{
+		Name:      name(&shstrtab, ".symtab"),
+		Type:      uint32(elf.SHT_SYMTAB),
+		Off:       offset(),
+		Size:      uint32(symSize * len(syms)),
+		Link:      uint32(symtabIdx + 1),
+		Info:      1,
+		Addralign: 4,
+		Entsize:   symSize,
+	})
+	if err := binary.Write(&body, binary.LittleEndian, syms); err != nil {
+		return nil, err
+	}
+	sections = append(sections, elf.Section32{
+		Name:      name(&shstrtab, ".strtab"),
+		Type:      uint32(elf.SHT_STRTAB),
+		Off:       offset(),
+		Size:      uint32(len(strtab)),
+		Addralign: 1,
+	})
+	body.Write(strtab)
+	shstrndx := len(sections)
+	sections = append(sections, elf.Section32{
+		Name:      name(&shstrtab, ".shstrtab"),
+		Type:      uint32(elf.SHT_STRTAB),
+		Off:       offset(),
+		Addralign: 1,
+	})
+	sections[shstrndx].Size = uint32(len(shstrtab))
+	body.Write(shstrtab)
+	align()
+	shoff := offset()
+
+	var flags uint32
+	if app.Compressed {
+		flags |= efRiscvRVC
+	}
+	hdr := elf.Header32{
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(elf.EM_RISCV),
+		Version:   uint32(elf.EV_CURRENT),
+		Entry:     uint32(app.Entry),
+		Phoff:     ehdrSize,
+		Shoff:     shoff,
+		Flags:     flags,
+		Ehsize:    ehdrSize,
+		Phentsize: phdrSize,
+		Phnum:     uint16(len(progs)),
+		Shentsize: shdrSize,
+		Shnum:     uint16(len(sections)),
+		Shstrndx:  uint16(shstrndx),
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS32)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	var out bytes.Buffer
+	for _, v := range []any{hdr, progs, body.Bytes(), sections} {
+		if err := binary.Write(&out, binary.LittleEndian, v); err != nil {
+			return nil, err
+		}
+	}
+	return out.Bytes(), nil
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+package risc
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseELF(t *testing.T) {
+	app, err := Parse(`.data
+array: .word 10, 20, 30, 40
+.bss
+result: .zero 4
+.text
+skipped:
+    li a0, 1
+_start:
+    la t0, array
+    li t1, 4
+    li t2, 0
+loop:
+    lw t3, 0(t0)
+    add t2, t2, t3
+    addi t0, t0, 4
+    addi t1, t1, -1
+    bnez t1, loop
+    la t0, result
+    sw t2, 0(t0)`)
+	require.NoError(t, err)
+	app.Entry = app.Labels["_start"]
+	data, err := EncodeELF(app)
+	require.NoError(t, err)
+
+	elfApp, err := ParseELF(data, 1024)
+	require.NoError(t, err)
+	assert.Equal(t, app.Entry, elfApp.Entry)
+	assert.Equal(t, app.Labels, elfApp.Labels)
+	assert.Len(t, elfApp.Instructions, len(app.Instructions))
+	// .data and .bss, the text isn't loaded in memory
+	assert.Equal(t, app.Segments, elfApp.Segments)
+
+	r := NewRunner(elfApp, 1024)
+	r.Ctx.Memory[app.Labels["result"]] = -1
+	require.NoError(t, r.Run())
+	// The instruction before the entry point must not be executed
+	assert.Equal(t, int32(0), r.Ctx.Registers[A0])
+	assert.Equal(t, int32(100), r.Ctx.Registers[T2])
+	assert.Equal(t, int8(100), r.Ctx.Memory[app.Labels["result"]])
+	// The data overlapping the text addresses is loaded
+	assert.Equal(t, int8(10), r.Ctx.Memory[app.Labels["array"]])
+}
+
+// nopELF encodes an application made of a single nop.
+func nopELF(t *testing.T) []byte {
+	app, err := Parse("nop")
+	require.NoError(t, err)
+	data, err := EncodeELF(app)
+	require.NoError(t, err)
+	return data
+}
+
+func TestParseELFErrors(t *testing.T) {
+	_, err := ParseELF([]byte("not an elf"), 1024)
+	assert.Error(t, err)
+
+	// No executable section
+	app, err := Parse(`.data
+.word 1`)
+	require.NoError(t, err)
+	data, err := EncodeELF(app)
+	require.NoError(t, err)
+	_, err = ParseELF(data, 1024)
+	assert.Error(t, err)
+
+	// Segment larger than the memory
+	_, err = ParseELF(nopELF(t), 2)
+	assert.Error(t, err)
+
+	// File size larger than the memory size
+	data = nopELF(t)
+	// Memsz of the first program header
+	binary.LittleEndian.PutUint32(data[52+20:], 1)
+	_, err = ParseELF(data, 1024)
+	assert.Error(t, err)
+}
+
+func TestParseELFIllegalInstruction(t *testing.T) {
+	// An unsupported instruction (sret) is loaded and raises an exception once
+	// executed
+	data := nopELF(t)
+	f, err := elf.NewFile(bytes.NewReader(data))
+	require.NoError(t, err)
+	copy(data[f.Section(".text").Offset:], encode(0x10200073))
+	app, err := ParseELF(data, 16)
+	require.NoError(t, err)
+	var e *Exception
+	require.ErrorAs(t, NewRunner(app, 16).Run(), &e)
+	assert.Equal(t, CauseIllegalInstruction, e.Cause)
+	assert.Equal(t, int32(0x10200073), e.Value)
+}
+
+func TestLoadOutOfMemory(t *testing.T) {
+	ctx := NewContext(false, 16, false)
+	err := ctx.Load(Application{Segments: []Segment{{Address: 8, Data: make([]int8, 16)}}})
+	assert.Error(t, err)
+}
+
+func TestEncodeELF(t *testing.T) {
+	app, err := Parse(`.data
+array: .word 10, 20, 30, 40
+result: .word 0
+.text
+_start:
+    la t0, array
+    li t1, 4
+loop:
+    lw t3, 0(t0)
+    c.add t2, t3
+    addi t0, t0, 4
+    addi t1, t1, -1
+    bnez t1, loop
+    la t0, result
+    sw t2, 0(t0)`)
+	require.NoError(t, err)
+	data, err := EncodeELF(app)
+	require.NoError(t, err)
+
+	elfApp, err := ParseELF(data, 1024)
+	require.NoError(t, err)
+	assert.True(t, elfApp.Compressed)
+	assert.Equal(t, app.Labels, elfApp.Labels)
+	assert.Equal(t, app.Segments, elfApp.Segments)
+	assert.Len(t, elfApp.Instructions, len(app.Instructions))
+
+	r := NewRunner(elfApp, 1024)
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(100), r.Ctx.Registers[T2])
+	assert.Equal(t, int8(100), r.Ctx.Memory[app.Labels["result"]])
+}
+
+func TestELFRoundTrip(t *testing.T) {
+	// The text spans addresses 0 to 40, overlapping the data and the words
+	// read and written after it
+	app, err := Parse(`.data
+value: .word 7
+.text
+    lw t0, 0(zero)
+    lw t1, 4(zero)
+    lw t2, 32(zero)
+    add t3, t0, t1
+    add t3, t3, t2
+    sw t3, 36(zero)
+    lw t4, 36(zero)
+    addi t5, t4, 1
+    sw t5, 8(zero)
+    mv t6, t5`)
+	require.NoError(t, err)
+	data, err := EncodeELF(app)
+	require.NoError(t, err)
+	elfApp, err := ParseELF(data, 64)
+	require.NoError(t, err)
+
+	want := NewRunner(app, 64)
+	require.NoError(t, want.Run())
+	got := NewRunner(elfApp, 64)
+	require.NoError(t, got.Run())
+	assert.Equal(t, want.Ctx.Registers, got.Ctx.Registers)
+	assert.Equal(t, want.Ctx.Memory, got.Ctx.Memory)
+	// The memory doesn't hold the instructions
+	assert.Equal(t, int32(7), got.Ctx.Registers[T0])
+	assert.Equal(t, int32(0), got.Ctx.Registers[T1])
+	assert.Equal(t, int32(0), got.Ctx.Registers[T2])
+	assert.Equal(t, int8(8), got.Ctx.Memory[8])
+}
@@ -0,0 +1,381 @@
+package risc
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Encode assembles the instructions of an application into a flat
+// little-endian image, where the instruction at pc is written at offset pc.
+// It is the inverse of Decode: the branch and jump targets are resolved to
+// offsets from the labels of the application.
+func Encode(app Application) ([]byte, error) {
+	code := make([]byte, app.End())
+	for pc := int32(0); pc < app.End(); pc += app.alignment() {
+		runner, exists := app.Instruction(pc)
+		if !exists {
+			continue
+		}
+		if c, ok := runner.(*compressed); ok {
+			half, err := EncodeCompressedInstruction(c, pc, app.Labels)
+			if err != nil {
+				return nil, fmt.Errorf("pc %d: %v", pc, err)
+			}
+			binary.LittleEndian.PutUint16(code[pc:], half)
+			continue
+		}
+		word, err := EncodeInstruction(runner, pc, app.Labels)
+		if err != nil {
+			return nil, fmt.Errorf("pc %d: %v", pc, err)
+		}
+		binary.LittleEndian.PutUint32(code[pc:], word)
+	}
+	return code, nil
+}
+
+// EncodeInstruction encodes a single 32-bit instruction located at a given pc.
+// A pseudo-instruction is encoded as the instruction it stands for.
+func EncodeInstruction(runner InstructionRunner, pc int32, labels map[string]int32) (uint32, error) {
+	word, err := encodeInstruction(runner, pc, labels)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %v", runner, err)
+	}
+	return word, nil
+}
+
+func encodeInstruction(runner InstructionRunner, pc int32, labels map[string]int32) (uint32, error) {
+	switch op := runner.(type) {
+	case *add:
+		return encodeR(opcodeOp, 0x0, funct7Base, op.rd, op.rs1, op.rs2), nil
+	case *sll:
+		return encodeR(opcodeOp, 0x1, funct7Base, op.rd, op.rs1, op.rs2), nil
+	case *slt:
+		return encodeR(opcodeOp, 0x2, funct7Base, op.rd, op.rs1, op.rs2), nil
+	case *sltu:
+		return encodeR(opcodeOp, 0x3, funct7Base, op.rd, op.rs1, op.rs2), nil
+	case *xor:
+		return encodeR(opcodeOp, 0x4, funct7Base, op.rd, op.rs1, op.rs2), nil
+	case *srl:
+		return encodeR(opcodeOp, 0x5, funct7Base, op.rd, op.rs1, op.rs2), nil
+	case *or:
+		return encodeR(opcodeOp, 0x6, funct7Base, op.rd, op.rs1, op.rs2), nil
+	case *and:
+		return encodeR(opcodeOp, 0x7, funct7Base, op.rd, op.rs1, op.rs2), nil
+	case *sub:
+		return encodeR(opcodeOp, 0x0, funct7Alt, op.rd, op.rs1, op.rs2), nil
+	case *sra:
+		return encodeR(opcodeOp, 0x5, funct7Alt, op.rd, op.rs1, op.rs2), nil
+	case *mul:
+		return encodeR(opcodeOp, 0x0, funct7Mul, op.rd, op.rs1, op.rs2), nil
+	case *mulh:
+		return encodeR(opcodeOp, 0x1, funct7Mul, op.rd, op.rs1, op.rs2), nil
+	case *mulhsu:
+		return encodeR(opcodeOp, 0x2, funct7Mul, op.rd, op.rs1, op.rs2), nil
+	case *mulhu:
+		return encodeR(opcodeOp, 0x3, funct7Mul, op.rd, op.rs1, op.rs2), nil
+	case *div:
+		return encodeR(opcodeOp, 0x4, funct7Mul, op.rd, op.rs1, op.rs2), nil
+	case *divu:
+		return encodeR(opcodeOp, 0x5, funct7Mul, op.rd, op.rs1, op.rs2), nil
+	case *rem:
+		return encodeR(opcodeOp, 0x6, funct7Mul, op.rd, op.rs1, op.rs2), nil
+	case *remu:
+		return encodeR(opcodeOp, 0x7, funct7Mul, op.rd, op.rs1, op.rs2), nil
+	case *addi:
+		return encodeI(opcodeOpImm, 0x0, op.rd, op.rs, op.imm)
+	case *slti:
+		return encodeI(opcodeOpImm, 0x2, op.rd, op.rs, op.imm)
+	case *sltiu:
+		return encodeI(opcodeOpImm, 0x3, op.rd, op.rs, op.imm)
+	case *xori:
+		return encodeI(opcodeOpImm, 0x4, op.rd, op.rs, op.imm)
+	case *ori:
+		return encodeI(opcodeOpImm, 0x6, op.rd, op.rs, op.imm)
+	case *andi:
+		return encodeI(opcodeOpImm, 0x7, op.rd, op.rs, op.imm)
+	case *slli:
+		return encodeShift(0x1, funct7Base, op.rd, op.rs, op.imm)
+	case *srli:
+		return encodeShift(0x5, funct7Base, op.rd, op.rs, op.imm)
+	case *srai:
+		return encodeShift(0x5, funct7Alt, op.rd, op.rs, op.imm)
+	case *li:
+		return encodeI(opcodeOpImm, 0x0, op.rd, Zero, op.imm)
+	case *mv:
+		return encodeI(opcodeOpImm, 0x0, op.rd, op.rs, 0)
+	case *nop:
+		return encodeI(opcodeOpImm, 0x0, Zero, Zero, 0)
+	case *lb:
+		return encodeI(opcodeLoad, 0x0, op.rd, op.rs, op.offset)
+	case *lh:
+		return encodeI(opcodeLoad, 0x1, op.rd, op.rs, op.offset)
+	case *lw:
+		return encodeI(opcodeLoad, 0x2, op.rd, op.rs, op.offset)
+	case *lbu:
+		return encodeI(opcodeLoad, 0x4, op.rd, op.rs, op.offset)
+	case *lhu:
+		return encodeI(opcodeLoad, 0x5, op.rd, op.rs, op.offset)
+	case *sb:
+		return encodeS(opcodeStore, 0x0, op.rd, op.rs, op.offset)
+	case *sh:
+		return encodeS(opcodeStore, 0x1, op.rd, op.rs, op.offset)
+	case *sw:
+		return encodeS(opcodeStore, 0x2, op.rd, op.rs, op.offset)
+	case *beq:
+		return encodeB(0x0, op.rs1, op.rs2, op.label, pc, op.offset, labels)
+	case *bne:
+		return encodeB(0x1, op.rs1, op.rs2, op.label, pc, op.offset, labels)
+	case *blt:
+		return encodeB(0x4, op.rs1, op.rs2, op.label, pc, op.offset, labels)
+	case *bge:
+		return encodeB(0x5, op.rs1, op.rs2, op.label, pc, op.offset, labels)
+	case *bltu:
+		return encodeB(0x6, op.rs1, op.rs2, op.label, pc, op.offset, labels)
+	case *bgeu:
+		return encodeB(0x7, op.rs1, op.rs2, op.label, pc, op.offset, labels)
+	case *ble:
+		return encodeB(0x5, op.rs2, op.rs1, op.label, pc, op.offset, labels)
+	case *beqz:
+		return encodeB(0x0, op.rs, Zero, op.label, pc, op.offset, labels)
+	case *bnez:
+		return encodeB(0x1, op.rs, Zero, op.label, pc, op.offset, labels)
+	case *jal:
+		return encodeJ(op.rd, op.label, pc, op.offset, labels)
+	case *j:
+		return encodeJ(Zero, op.label, pc, op.offset, labels)
+	case *jalr:
+		return encodeI(opcodeJalr, 0x0, op.rd, op.rs, op.imm)
+	case *ret:
+		return encodeI(opcodeJalr, 0x0, Zero, Ra, 0)
+	case *lui:
+		return encodeU(opcodeLui, op.rd, op.imm)
+	case *auipc:
+		return encodeU(opcodeAuipc, op.rd, op.imm)
+	case *fence:
+		// fence iorw, iorw
+		return 0x0ff0000f, nil
+	case *ecall:
+		return 0x00000073, nil
+	case *ebreak:
+		return 0x00100073, nil
+	case *mret:
+		return 0x30200073, nil
+	case *wfi:
+		return 0x10500073, nil
+	case *csr:
+		funct3, ok := reverseLookup(csrFunct3, op.instructionType)
+		if !ok {
+			break
+		}
+		rs1 := op.rs
+		if funct3 >= 0x5 {
+			if op.uimm < 0 || op.uimm > 31 {
+				return 0, fmt.Errorf("invalid immediate %d: out of range", op.uimm)
+			}
+			rs1 = RegisterType(op.uimm)
+		}
+		return uint32(op.csr&0xfff)<<20 | registerField(rs1)<<15 | funct3<<12 | registerField(op.rd)<<7 | opcodeSystem, nil
+	case *amo:
+		funct5, ok := reverseLookup(amoFunct5, op.instructionType)
+		if !ok {
+			break
+		}
+		return encodeR(opcodeAmo, 0x2, funct5<<2, op.rd, op.rs1, op.rs2), nil
+	case *lrw:
+		return encodeR(opcodeAmo, 0x2, 0x02<<2, op.rd, op.rs, Zero), nil
+	case *scw:
+		return encodeR(opcodeAmo, 0x2, 0x03<<2, op.rd, op.rs1, op.rs2), nil
+	case *fload:
+		if op.instructionType == Fld {
+			return encodeI(opcodeLoadFp, 0x3, op.rd, op.rs, op.offset)
+		}
+		return encodeI(opcodeLoadFp, 0x2, op.rd, op.rs, op.offset)
+	case *fstore:
+		if op.instructionType == Fsd {
+			return encodeS(opcodeStoreFp, 0x3, op.rd, op.rs, op.offset)
+		}
+		return encodeS(opcodeStoreFp, 0x2, op.rd, op.rs, op.offset)
+	case *fop:
+		return encodeFop(op)
+	case *illegal:
+		return uint32(op.value), nil
+	}
+	return 0, fmt.Errorf("unsupported instruction type: %v", runner.InstructionType())
+}
+
+// registerField returns the 5-bit field of an integer or floating-point
+// register.
+func registerField(reg RegisterType) uint32 {
+	if reg.IsFloat() {
+		return uint32(reg - Ft0)
+	}
+	return uint32(reg) & 0x1f
+}
+
+// reverseLookup returns the key of a decoder table mapping a field to a value.
+func reverseLookup[K comparable, V comparable](table map[K]V, value V) (K, bool) {
+	for k, v := range table {
+		if v == value {
+			return k, true
+		}
+	}
+	var zero K
+	return zero, false
+}
+
+// checkRange checks whether an immediate fits in a signed field of n bits
+// and is a multiple of scale.
+func checkRange(imm int32, n int, scale int32) error {
+	min, max := int32(-1)<<(n-1), int32(1)<<(n-1)-1
+	if imm < min || imm > max || imm%scale != 0 {
+		return fmt.Errorf("invalid immediate %d: out of range", imm)
+	}
+	return nil
+}
+
+// target returns the offset of a branch or a jump, from its label if any.
+func target(label string, pc, offset int32, labels map[string]int32) (int32, error) {
+	addr, err := jumpAddress(labels, label, pc, offset)
+	if err != nil {
+		return 0, err
+	}
+	return addr - pc, nil
+}
+
+func encodeR(opcode, funct3, funct7 uint32, rd, rs1, rs2 RegisterType) uint32 {
+	return funct7<<25 | registerField(rs2)<<20 | registerField(rs1)<<15 | funct3<<12 | registerField(rd)<<7 | opcode
+}
+
+func encodeI(opcode, funct3 uint32, rd, rs1 RegisterType, imm int32) (uint32, error) {
+	if err := checkRange(imm, 12, 1); err != nil {
+		return 0, err
+	}
+	return uint32(imm)<<20 | registerField(rs1)<<15 | funct3<<12 | registerField(rd)<<7 | opcode, nil
+}
+
+func encodeShift(funct3, funct7 uint32, rd, rs1 RegisterType, shamt int32) (uint32, error) {
+	if shamt < 0 || shamt > 31 {
+		return 0, fmt.Errorf("invalid shift amount %d: out of range", shamt)
+	}
+	return encodeR(opcodeOpImm, funct3, funct7, rd, rs1, RegisterType(shamt)), nil
+}
+
+// encodeS encodes a store of rs2 at an offset from the base rs1.
+func encodeS(opcode, funct3 uint32, rs1, rs2 RegisterType, imm int32) (uint32, error) {
+	if err := checkRange(imm, 12, 1); err != nil {
+		return 0, err
+	}
+	u := uint32(imm)
+	return (u>>5&0x7f)<<25 | registerField(rs2)<<20 | registerField(rs1)<<15 | funct3<<12 | (u&0x1f)<<7 | opcode, nil
+}
+
+func encodeB(funct3 uint32, rs1, rs2 RegisterType, label string, pc, offset int32, labels map[string]int32) (uint32, error) {
+	offset, err := target(label, pc, offset, labels)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkRange(offset, 13, 2); err != nil {
+		return 0, err
+	}
+	u := uint32(offset)
+	return (u>>12&0x1)<<31 | (u>>5&0x3f)<<25 | registerField(rs2)<<20 | registerField(rs1)<<15 |
+		funct3<<12 | (u>>1&0xf)<<8 | (u>>11&0x1)<<7 | opcodeBranch, nil
+}
+
+func encodeJ(rd RegisterType, label string, pc, offset int32, labels map[string]int32) (uint32, error) {
+	offset, err := target(label, pc, offset, labels)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkRange(offset, 21, 2); err != nil {
+		return 0, err
+	}
+	u := uint32(offset)
+	return (u>>20&0x1)<<31 | (u>>1&0x3ff)<<21 | (u>>11&0x1)<<20 | (u>>12&0xff)<<12 | registerField(rd)<<7 | opcodeJal, nil
+}
+
+// encodeU encodes an upper immediate, either signed or as the 20 unsigned
+// upper bits.
+func encodeU(opcode uint32, rd RegisterType, imm int32) (uint32, error) {
+	if imm < -1<<19 || imm > 0xfffff {
+		return 0, fmt.Errorf("invalid immediate %d: out of range", imm)
+	}
+	return (uint32(imm)&0xfffff)<<12 | registerField(rd)<<7 | opcode, nil
+}
+
+// fpEncodings describes the OP-FP encodings: funct7 holds the operation and
+// the format, funct3 is either fixed or the rounding mode if negative, and rs2
+// is either fixed or a register if negative.
+var fpEncodings = map[InstructionType]struct {
+	funct7 uint32
+	funct3 int
+	rs2    int
+}{
+	FaddS:   {0x00, -1, -1},
+	FaddD:   {0x01, -1, -1},
+	FsubS:   {0x04, -1, -1},
+	FsubD:   {0x05, -1, -1},
+	FmulS:   {0x08, -1, -1},
+	FmulD:   {0x09, -1, -1},
+	FdivS:   {0x0c, -1, -1},
+	FdivD:   {0x0d, -1, -1},
+	FsqrtS:  {0x2c, -1, 0},
+	FsqrtD:  {0x2d, -1, 0},
+	FsgnjS:  {0x10, 0, -1},
+	FsgnjD:  {0x11, 0, -1},
+	FsgnjnS: {0x10, 1, -1},
+	FsgnjnD: {0x11, 1, -1},
+	FsgnjxS: {0x10, 2, -1},
+	FsgnjxD: {0x11, 2, -1},
+	FminS:   {0x14, 0, -1},
+	FminD:   {0x15, 0, -1},
+	FmaxS:   {0x14, 1, -1},
+	FmaxD:   {0x15, 1, -1},
+	FcvtSD:  {0x20, -1, 1},
+	FcvtDS:  {0x21, -1, 0},
+	FleS:    {0x50, 0, -1},
+	FleD:    {0x51, 0, -1},
+	FltS:    {0x50, 1, -1},
+	FltD:    {0x51, 1, -1},
+	FeqS:    {0x50, 2, -1},
+	FeqD:    {0x51, 2, -1},
+	FcvtWS:  {0x60, -1, 0},
+	FcvtWD:  {0x61, -1, 0},
+	FcvtWuS: {0x60, -1, 1},
+	FcvtWuD: {0x61, -1, 1},
+	FcvtSW:  {0x68, -1, 0},
+	FcvtDW:  {0x69, -1, 0},
+	FcvtSWu: {0x68, -1, 1},
+	FcvtDWu: {0x69, -1, 1},
+	FmvXW:   {0x70, 0, 0},
+	FclassS: {0x70, 1, 0},
+	FclassD: {0x71, 1, 0},
+	FmvWX:   {0x78, 0, 0},
+}
+
+func encodeFop(op *fop) (uint32, error) {
+	if op.rm < 0 || op.rm > 7 {
+		return 0, fmt.Errorf("invalid rounding mode: %d", op.rm)
+	}
+	for opcode, types := range fmaOpcodes {
+		for format, instructionType := range types {
+			if instructionType == op.instructionType {
+				funct7 := registerField(op.rs3)<<2 | uint32(format)
+				return encodeR(opcode, uint32(op.rm), funct7, op.rd, op.rs1, op.rs2), nil
+			}
+		}
+	}
+	encoding, exists := fpEncodings[op.instructionType]
+	if !exists {
+		return 0, fmt.Errorf("unsupported instruction type: %v", op.instructionType)
+	}
+	funct3 := uint32(op.rm)
+	if encoding.funct3 >= 0 {
+		funct3 = uint32(encoding.funct3)
+	}
+	rs2 := op.rs2
+	if encoding.rs2 >= 0 {
+		rs2 = RegisterType(encoding.rs2)
+	}
+	return encodeR(opcodeOpFp, funct3, encoding.funct7, op.rd, op.rs1, rs2), nil
+}
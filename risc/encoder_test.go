@@ -0,0 +1,146 @@
+package risc
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeInstruction(t *testing.T) {
+	tests := []struct {
+		source string
+		want   uint32
+	}{
+		{"addi a0, zero, 1", 0x00100513},
+		{"li t1, 10", 0x00a00313},
+		{"add t2, t2, t0", 0x005383b3},
+		{"loop: blt t0, t1, loop", 0x0062c063},
+		{"ble t1, t0, -8", 0xfe62dce3},
+		{"sw t2, 4(zero)", 0x00702223},
+		{"lw a0, 4(zero)", 0x00402503},
+		{"j 8", 0x0080006f},
+		{"lui t1, 0x12345", 0x12345337},
+		{"srai t2, t1, 4", 0x40435393},
+		{"ret", 0x00008067},
+		{"lr.w t2, (t0)", 0x1002a3af},
+		{"csrrw a1, fcsr, t0", 0x003295f3},
+		{"fadd.s ft0, ft1, ft2, rne", 0x00208053},
+		{"fmadd.d ft0, ft1, ft2, ft3", 0x1a20f043},
+		{"ecall", 0x00000073},
+	}
+	for _, tt := range tests {
+		app, err := Parse(tt.source)
+		require.NoError(t, err, tt.source)
+		runner, _ := app.Instruction(0)
+		word, err := EncodeInstruction(runner, 0, app.Labels)
+		require.NoError(t, err, tt.source)
+		assert.Equal(t, tt.want, word, "%s: %#08x", tt.source, word)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	for _, runner := range []InstructionRunner{
+		&addi{rd: T0, rs: T0, imm: 2048},
+		&beq{rs1: T0, rs2: T1, offset: 3},
+		&jal{rd: Ra, label: "missing"},
+		&slli{rd: T0, rs: T0, imm: 32},
+		&lui{rd: T0, imm: 0x100000},
+	} {
+		_, err := EncodeInstruction(runner, 0, map[string]int32{})
+		assert.Error(t, err, runner.String())
+	}
+	for _, runner := range []InstructionRunner{
+		&add{rd: T0, rs1: T1, rs2: T2},
+		&addi{rd: T0, rs: T0, imm: 32},
+		&lw{rd: T0, offset: 0, rs: T1},
+		&sw{rs: A0, offset: 2, rd: Sp},
+	} {
+		_, err := EncodeCompressedInstruction(&compressed{runner}, 0, map[string]int32{})
+		assert.Error(t, err, runner.String())
+	}
+}
+
+// TestEncodeDecode checks that encoding a decoded instruction returns an
+// instruction decoded identically.
+func TestEncodeDecode(t *testing.T) {
+	for h := 0; h < 1<<16; h++ {
+		runner, err := DecodeCompressedInstruction(uint16(h))
+		if err != nil {
+			continue
+		}
+		half, err := EncodeCompressedInstruction(runner.(*compressed), 0, nil)
+		require.NoError(t, err, "%#04x: %s", h, runner)
+		got, err := DecodeCompressedInstruction(half)
+		require.NoError(t, err)
+		require.Equal(t, runner, got, "%#04x: %s", h, runner)
+	}
+
+	rnd := rand.New(rand.NewSource(0))
+	for i := 0; i < 1<<18; i++ {
+		word := rnd.Uint32() | 0x3
+		runner, err := DecodeInstruction(word)
+		if err != nil {
+			continue
+		}
+		encoded, err := EncodeInstruction(runner, 0, nil)
+		require.NoError(t, err, "%#08x: %s", word, runner)
+		got, err := DecodeInstruction(encoded)
+		require.NoError(t, err)
+		require.Equal(t, runner, got, "%#08x: %s", word, runner)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	paths, err := filepath.Glob("../res/*.asm")
+	require.NoError(t, err)
+	paths = append(paths, "")
+	for _, path := range paths {
+		source := `main:
+    c.li a0, 5
+    call f
+    beqz a0, main
+    fsw fa0, 4(sp)
+    c.fsdsp fs0, 8(sp)
+    tail main
+f:
+    c.addi a0, -1
+    c.bnez a0, f
+    c.jr ra`
+		if path != "" {
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+			source = string(data)
+		}
+		app, err := Parse(source)
+		require.NoError(t, err, path)
+		code, err := Encode(app)
+		require.NoError(t, err, path)
+		require.Len(t, code, int(app.End()))
+
+		// The decoded application encodes to the same image
+		decoded, err := Decode(code)
+		require.NoError(t, err, path)
+		assert.Equal(t, app.Compressed, decoded.Compressed, path)
+		again, err := Encode(decoded)
+		require.NoError(t, err, path)
+		assert.Equal(t, code, again, path)
+	}
+}
+
+func TestEncodeIntelHex(t *testing.T) {
+	app, err := Parse(`.data
+value: .byte 1, 2
+.text
+li a0, 1`)
+	require.NoError(t, err)
+	data, err := EncodeIntelHex(app)
+	require.NoError(t, err)
+	assert.Equal(t, `:0400000013051000D4
+:0400000500000000F7
+:00000001FF
+`, string(data))
+}
@@ -0,0 +1,436 @@
+package risc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// expressions evaluates the expressions of the operands and directives: numbers
+// in any base, character literals, symbols, arithmetic, comparisons and the
+// relocation functions.
+type expressions struct {
+	// constants holds the .equ constants.
+	constants map[string]symbolValue
+	// labels holds the labels defined so far by the current pass.
+	labels map[string]int32
+	// known holds the labels found by the first pass. It's nil during the
+	// first pass, where a symbol not defined yet evaluates to 0.
+	known map[string]int32
+	// pc is the pc of the instruction being parsed.
+	pc int32
+	// pcrelHi holds the offset loaded by the auipc at a given pc using
+	// %pcrel_hi, for the %pcrel_lo referring to it.
+	pcrelHi map[int32]int32
+}
+
+func newExpressions(known map[string]int32) *expressions {
+	return &expressions{
+		constants: make(map[string]symbolValue),
+		labels:    make(map[string]int32),
+		known:     known,
+		pcrelHi:   make(map[int32]int32),
+	}
+}
+
+// symbolValue is the value of a symbol or of an expression. It's relocatable
+// if it depends on the address of a label.
+type symbolValue struct {
+	value       int64
+	relocatable bool
+}
+
+// immediate evaluates an expression fitting in 32 bits.
+func (e *expressions) immediate(s string) (int64, error) {
+	v, err := e.eval(s)
+	if err != nil {
+		return 0, err
+	}
+	if v.value < math.MinInt32 || v.value > math.MaxInt32 {
+		return 0, newDiagnostic(CodeOutOfRange, s, "immediate out of range: %s", s)
+	}
+	return v.value, nil
+}
+
+// upperImmediate evaluates the 20-bit immediate of lui or auipc. It's
+// sign-extended the way the instruction is decoded, as 0x80000 and -524288 are
+// the same immediate.
+func (e *expressions) upperImmediate(s string) (int32, error) {
+	imm, err := e.immediate(s)
+	if err != nil {
+		return 0, err
+	}
+	if imm < -1<<19 || imm > 0xfffff {
+		return 0, newDiagnostic(CodeOutOfRange, s, "immediate out of range: %s", s)
+	}
+	return signExtend(uint32(imm), 20), nil
+}
+
+// constant evaluates an expression that mustn't depend on a label, as it
+// changes the size of the application.
+func (e *expressions) constant(s string) (int64, error) {
+	v, err := e.eval(s)
+	if err != nil {
+		return 0, err
+	}
+	if v.relocatable {
+		return 0, newDiagnostic(CodeInvalidExpression, s, "expression depends on a label: %s", s)
+	}
+	return v.value, nil
+}
+
+// target evaluates the target of a branch or a jump: a label name, resolved
+// when the instruction is executed, or an expression. A number is an offset
+// from the pc, whereas an expression depending on a label is an address.
+func (e *expressions) target(s string) (string, int32, error) {
+	if _, exists := e.constants[s]; !exists {
+		_, defined := e.labels[s]
+		_, found := e.known[s]
+		if defined || found || e.known == nil && isForwardLabel(s) {
+			return s, 0, nil
+		}
+	}
+	v, err := e.eval(s)
+	if err != nil {
+		return "", 0, err
+	}
+	if v.relocatable {
+		return "", int32(v.value) - e.pc, nil
+	}
+	return "", int32(v.value), nil
+}
+
+func (e *expressions) eval(s string) (symbolValue, error) {
+	p := &expressionParser{s: s, e: e}
+	v, err := p.parse(0)
+	if err != nil {
+		var d *diagnostic
+		if errors.As(err, &d) {
+			return symbolValue{}, err
+		}
+		return symbolValue{}, newDiagnostic(CodeInvalidExpression, s, "invalid expression %q: %v", s, err)
+	}
+	p.skipSpaces()
+	if p.i != len(p.s) {
+		return symbolValue{}, newDiagnostic(CodeInvalidExpression, s, "invalid expression %q: unexpected %q", s, p.s[p.i:])
+	}
+	return v, nil
+}
+
+// symbol returns the value of a constant or of a label.
+func (e *expressions) symbol(name string) (symbolValue, error) {
+	if v, exists := e.constants[name]; exists {
+		return v, nil
+	}
+	if addr, exists := e.labels[name]; exists {
+		return symbolValue{value: int64(addr), relocatable: true}, nil
+	}
+	if addr, exists := e.known[name]; exists {
+		return symbolValue{value: int64(addr), relocatable: true}, nil
+	}
+	if e.known == nil {
+		// Defined afterward
+		return symbolValue{relocatable: true}, nil
+	}
+	return symbolValue{}, newDiagnostic(CodeUndefinedSymbol, name, "symbol %s does not exist", name)
+}
+
+// define defines a .equ constant.
+func (e *expressions) define(args string) error {
+	name, expr, found := strings.Cut(args, ",")
+	name = strings.TrimSpace(name)
+	if !found || !isLabel(name) {
+		return newDiagnostic(CodeSyntax, args, "invalid constant: %s", args)
+	}
+	v, err := e.eval(strings.TrimSpace(expr))
+	if err != nil {
+		return err
+	}
+	e.constants[name] = v
+	return nil
+}
+
+// binaryOperators lists the binary operators by increasing precedence. As with
+// the GNU assembler, a true comparison is -1 and a false one 0.
+var binaryOperators = [][]string{
+	{"||"},
+	{"&&"},
+	{"|"},
+	{"^"},
+	{"&"},
+	{"==", "!=", "<>"},
+	{"<=", ">=", "<", ">"},
+	{"<<", ">>"},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// expressionParser is a recursive descent parser of an expression.
+type expressionParser struct {
+	s string
+	i int
+	e *expressions
+}
+
+func (p *expressionParser) skipSpaces() {
+	for p.i < len(p.s) && (p.s[p.i] == ' ' || p.s[p.i] == '\t') {
+		p.i++
+	}
+}
+
+// parse parses the operations of a given precedence or higher.
+func (p *expressionParser) parse(precedence int) (symbolValue, error) {
+	if precedence == len(binaryOperators) {
+		return p.parseUnary()
+	}
+	left, err := p.parse(precedence + 1)
+	if err != nil {
+		return symbolValue{}, err
+	}
+	for {
+		p.skipSpaces()
+		operator := p.operator()
+		// % followed by a letter is a relocation function
+		if !slices.Contains(binaryOperators[precedence], operator) ||
+			operator == "%" && p.i+1 < len(p.s) && isSymbolChar(p.s[p.i+1]) && !isDigit(p.s[p.i+1]) {
+			return left, nil
+		}
+		p.i += len(operator)
+		right, err := p.parse(precedence + 1)
+		if err != nil {
+			return symbolValue{}, err
+		}
+		if left, err = apply(operator, left, right); err != nil {
+			return symbolValue{}, err
+		}
+	}
+}
+
+// operator returns the longest binary operator at the current position, so
+// that | isn't read as the first half of ||, or "".
+func (p *expressionParser) operator() string {
+	operator := ""
+	for _, ops := range binaryOperators {
+		for _, op := range ops {
+			if len(op) > len(operator) && strings.HasPrefix(p.s[p.i:], op) {
+				operator = op
+			}
+		}
+	}
+	return operator
+}
+
+func apply(operator string, left, right symbolValue) (symbolValue, error) {
+	v := symbolValue{relocatable: left.relocatable || right.relocatable}
+	switch operator {
+	case "||":
+		v = symbolValue{value: boolValue(left.value != 0 || right.value != 0)}
+	case "&&":
+		v = symbolValue{value: boolValue(left.value != 0 && right.value != 0)}
+	case "==":
+		v = symbolValue{value: -boolValue(left.value == right.value)}
+	case "!=", "<>":
+		v = symbolValue{value: -boolValue(left.value != right.value)}
+	case "<":
+		v = symbolValue{value: -boolValue(left.value < right.value)}
+	case "<=":
+		v = symbolValue{value: -boolValue(left.value <= right.value)}
+	case ">":
+		v = symbolValue{value: -boolValue(left.value > right.value)}
+	case ">=":
+		v = symbolValue{value: -boolValue(left.value >= right.value)}
+	case "|":
+		v.value = left.value | right.value
+	case "^":
+		v.value = left.value ^ right.value
+	case "&":
+		v.value = left.value & right.value
+	case "<<":
+		v.value = left.value << right.value
+	case ">>":
+		v.value = left.value >> right.value
+	case "+":
+		v.value = left.value + right.value
+	case "-":
+		v.value = left.value - right.value
+		// The distance between two labels doesn't depend on their address
+		v.relocatable = left.relocatable != right.relocatable
+	case "*":
+		v.value = left.value * right.value
+	case "/", "%":
+		if right.value == 0 {
+			return symbolValue{}, fmt.Errorf("division by zero")
+		}
+		if operator == "/" {
+			v.value = left.value / right.value
+		} else {
+			v.value = left.value % right.value
+		}
+	}
+	return v, nil
+}
+
+// boolValue returns 1 if b is true, 0 otherwise.
+func boolValue(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (p *expressionParser) parseUnary() (symbolValue, error) {
+	p.skipSpaces()
+	if p.i == len(p.s) {
+		return symbolValue{}, fmt.Errorf("missing operand")
+	}
+	switch c := p.s[p.i]; {
+	case c == '-' || c == '+' || c == '~':
+		p.i++
+		v, err := p.parseUnary()
+		if err != nil {
+			return symbolValue{}, err
+		}
+		switch c {
+		case '-':
+			v.value = -v.value
+		case '~':
+			v.value = ^v.value
+		}
+		return v, nil
+	case c == '(':
+		p.i++
+		v, err := p.parse(0)
+		if err != nil {
+			return symbolValue{}, err
+		}
+		return v, p.expect(')')
+	case c == '%':
+		return p.parseRelocation()
+	case c == '\'':
+		return p.parseChar()
+	case isDigit(c):
+		start := p.i
+		for p.i < len(p.s) && isSymbolChar(p.s[p.i]) {
+			p.i++
+		}
+		n, err := strconv.ParseUint(p.s[start:p.i], 0, 64)
+		if err != nil {
+			return symbolValue{}, fmt.Errorf("invalid number: %s", p.s[start:p.i])
+		}
+		return symbolValue{value: int64(n)}, nil
+	case isSymbolChar(c):
+		start := p.i
+		for p.i < len(p.s) && isSymbolChar(p.s[p.i]) {
+			p.i++
+		}
+		return p.e.symbol(p.s[start:p.i])
+	}
+	return symbolValue{}, fmt.Errorf("unexpected %q", p.s[p.i:])
+}
+
+func (p *expressionParser) expect(c byte) error {
+	p.skipSpaces()
+	if p.i == len(p.s) || p.s[p.i] != c {
+		return fmt.Errorf("expected %q", c)
+	}
+	p.i++
+	return nil
+}
+
+// parseRelocation parses a relocation function: %hi and %lo split an address
+// loaded by lui and addi, whereas %pcrel_hi and %pcrel_lo split an offset from
+// the pc of an auipc, whose label is the argument of %pcrel_lo.
+func (p *expressionParser) parseRelocation() (symbolValue, error) {
+	start := p.i
+	p.i++
+	for p.i < len(p.s) && isSymbolChar(p.s[p.i]) {
+		p.i++
+	}
+	function := p.s[start:p.i]
+	if err := p.expect('('); err != nil {
+		return symbolValue{}, err
+	}
+	v, err := p.parse(0)
+	if err != nil {
+		return symbolValue{}, err
+	}
+	if err := p.expect(')'); err != nil {
+		return symbolValue{}, err
+	}
+
+	switch function {
+	case "%hi":
+		hi, _ := splitImmediate(int32(v.value))
+		v.value = int64(hi & 0xfffff)
+	case "%lo":
+		_, lo := splitImmediate(int32(v.value))
+		v.value = int64(lo)
+	case "%pcrel_hi":
+		offset := int32(v.value) - p.e.pc
+		p.e.pcrelHi[p.e.pc] = offset
+		hi, _ := splitImmediate(offset)
+		v.value = int64(hi & 0xfffff)
+	case "%pcrel_lo":
+		offset, exists := p.e.pcrelHi[int32(v.value)]
+		if !exists && p.e.known != nil {
+			return symbolValue{}, fmt.Errorf("no %%pcrel_hi at %d", v.value)
+		}
+		_, lo := splitImmediate(offset)
+		v.value = int64(lo)
+	default:
+		return symbolValue{}, fmt.Errorf("unsupported relocation: %s", function)
+	}
+	return v, nil
+}
+
+// parseChar parses a character literal, such as 'a' or '\n'.
+func (p *expressionParser) parseChar() (symbolValue, error) {
+	end := p.i + 1
+	for end < len(p.s) && p.s[end] != '\'' {
+		if p.s[end] == '\\' {
+			end++
+		}
+		end++
+	}
+	if end >= len(p.s) {
+		return symbolValue{}, fmt.Errorf("unterminated character")
+	}
+	literal := p.s[p.i : end+1]
+	c, err := strconv.Unquote(literal)
+	if err != nil || len(c) != 1 {
+		if literal == `'\0'` {
+			c = "\x00"
+		} else {
+			return symbolValue{}, fmt.Errorf("invalid character: %s", literal)
+		}
+	}
+	p.i += len(literal)
+	return symbolValue{value: int64(c[0])}, nil
+}
+
+// isForwardLabel returns whether a target not defined yet during the first pass
+// is a label name: labels such as 1f are accepted as long as they aren't
+// numbers.
+func isForwardLabel(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isSymbolChar(s[i]) {
+			return false
+		}
+	}
+	_, err := strconv.ParseUint(s, 0, 64)
+	return err != nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isSymbolChar(c byte) bool {
+	return c == '_' || c == '.' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c)
+}
@@ -0,0 +1,135 @@
+package risc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImmediateExpressions(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{}, 0, map[int]int8{},
+		`.equ SIZE, 4
+.equ MASK, (1 << SIZE) - 1
+addi t0, zero, 0xff
+addi t1, zero, 0b1010
+addi t2, zero, 'a'
+addi s0, zero, -0x10
+addi s1, zero, '\n'
+andi s2, t0, MASK
+addi s3, zero, SIZE * 3 + 2 % 3
+li s4, ~0x7f & 0xfff
+li s5, 0xffffffff`, map[RegisterType]int32{
+			T0: 255,
+			T1: 10,
+			T2: 'a',
+			S0: -16,
+			S1: '\n',
+			S2: 15,
+			S3: 14,
+			S4: 0xf80,
+			S5: -1,
+		}, map[int]int8{})
+}
+
+func TestCharacterOperands(t *testing.T) {
+	// The commas, quotes and # of a character literal aren't separators
+	app, err := Parse(`li a0, ','
+addi a1, zero, '#' # comment
+li a2, '\''
+lbu a3, 0(zero)
+lbu a4, 1(zero)
+.data
+.byte ',', '"'`)
+	require.NoError(t, err)
+	r := NewRunner(app, 8)
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(','), r.Ctx.Registers[A0])
+	assert.Equal(t, int32('#'), r.Ctx.Registers[A1])
+	assert.Equal(t, int32('\''), r.Ctx.Registers[A2])
+	assert.Equal(t, int32(','), r.Ctx.Registers[A3])
+	assert.Equal(t, int32('"'), r.Ctx.Registers[A4])
+}
+
+func TestHiLo(t *testing.T) {
+	app, err := Parse(`.equ ADDR, 0x12345678
+lui t0, %hi(ADDR)
+addi t0, t0, %lo(ADDR)
+lui a1, %hi(value)
+lw t1, %lo(value)(a1)
+lui a2, %hi(value+4)
+addi a2, a2, %lo(value+4)
+lw t2, 0(a2)
+.data
+.space 0x800
+value: .word 42, -3`)
+	require.NoError(t, err)
+	r := NewRunner(app, 0x808)
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(0x12345678), r.Ctx.Registers[T0])
+	assert.Equal(t, int32(42), r.Ctx.Registers[T1])
+	assert.Equal(t, int32(-3), r.Ctx.Registers[T2])
+}
+
+func TestPcRelative(t *testing.T) {
+	// As emitted by a compiler
+	app, err := Parse(`	.text
+	.globl	main
+	.type	main, @function
+main:
+	nop
+.Lpcrel_hi0:
+	auipc	a0,%pcrel_hi(msg)
+	addi	a0,a0,%pcrel_lo(.Lpcrel_hi0)
+.Lpcrel_hi1:
+	auipc	a1,%pcrel_hi(msg+1)
+	lbu	a1,%pcrel_lo(.Lpcrel_hi1)(a1)
+	.size	main, .-main
+	.section	.rodata
+msg:
+	.string	"hi"`)
+	require.NoError(t, err)
+	r := NewRunner(app, 4)
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(0), r.Ctx.Registers[A0])
+	assert.Equal(t, int32('i'), r.Ctx.Registers[A1])
+}
+
+func TestLabelExpressions(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{}, 0, map[int]int8{},
+		`la t0, end+4
+li t1, end - start
+start:
+j start+8
+addi s0, s0, 1
+addi s1, s1, 1
+end:`, map[RegisterType]int32{T0: 28, T1: 12, S0: 0, S1: 1}, map[int]int8{})
+}
+
+func TestNumericBranchOffsets(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{}, 0, map[int]int8{},
+		`beq zero, zero, 8
+addi s0, s0, 1
+jal ra, 0x8
+addi s1, s1, 1
+bnez zero, -4
+addi s2, s2, 1`, map[RegisterType]int32{S0: 0, S1: 0, S2: 1, Ra: 12}, map[int]int8{})
+}
+
+func TestExpressionErrors(t *testing.T) {
+	for _, s := range []string{
+		"addi t0, t0, 1/0",
+		"addi t0, t0, missing",
+		"addi t0, t0, 0x100000000",
+		"addi t0, t0, (1",
+		"addi t0, t0, 'ab'",
+		"lui t0, %foo(1)",
+		"addi t0, t0, %pcrel_lo(label)\nlabel:",
+		"beq zero, zero, missing",
+		".equ 1, 2",
+		".data\n.space label\nlabel:",
+	} {
+		_, err := Parse(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -0,0 +1,565 @@
+package risc
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Rounding modes of the rm field and of fcsr.frm.
+const (
+	rmRNE = 0 // Round to nearest, ties to even
+	rmRTZ = 1 // Round towards zero
+	rmRDN = 2 // Round down
+	rmRUP = 3 // Round up
+	rmRMM = 4 // Round to nearest, ties to max magnitude
+	rmDYN = 7 // Dynamic rounding mode, read from fcsr.frm
+)
+
+// Accrued exception flags of fcsr.fflags.
+const (
+	flagNX = 1 << 0 // Inexact
+	flagUF = 1 << 1 // Underflow
+	flagOF = 1 << 2 // Overflow
+	flagDZ = 1 << 3 // Divide by zero
+	flagNV = 1 << 4 // Invalid operation
+)
+
+// The precision used for the exact computations. It covers the whole double
+// exponent range so that an addition or a fused multiply-add is never rounded
+// before the final rounding.
+const exactPrecision = 4500
+
+// The precision of the truncated quotients and square roots; the remaining
+// bits are summarized by a sticky bit.
+const truncatedPrecision = 200
+
+type fpFormat struct {
+	mbits int // Fraction bits
+	ebits int // Exponent bits
+	bias  int
+	qnan  uint64 // Canonical NaN
+}
+
+var (
+	single = fpFormat{mbits: 23, ebits: 8, bias: 127, qnan: 0x7fc00000}
+	double = fpFormat{mbits: 52, ebits: 11, bias: 1023, qnan: 0x7ff8000000000000}
+)
+
+func (f fpFormat) emin() int {
+	return 1 - f.bias
+}
+
+func (f fpFormat) signBit() uint64 {
+	return 1 << (f.mbits + f.ebits)
+}
+
+func (f fpFormat) expField(bits uint64) uint64 {
+	return (bits >> f.mbits) & (1<<f.ebits - 1)
+}
+
+func (f fpFormat) fraction(bits uint64) uint64 {
+	return bits & (1<<f.mbits - 1)
+}
+
+func (f fpFormat) negative(bits uint64) bool {
+	return bits&f.signBit() != 0
+}
+
+func (f fpFormat) isNaN(bits uint64) bool {
+	return f.expField(bits) == 1<<f.ebits-1 && f.fraction(bits) != 0
+}
+
+// isSNaN returns whether bits is a signaling NaN, whose most significant
+// fraction bit is clear.
+func (f fpFormat) isSNaN(bits uint64) bool {
+	return f.isNaN(bits) && bits&(1<<(f.mbits-1)) == 0
+}
+
+func (f fpFormat) isInf(bits uint64) bool {
+	return f.expField(bits) == 1<<f.ebits-1 && f.fraction(bits) == 0
+}
+
+func (f fpFormat) isZero(bits uint64) bool {
+	return bits&^f.signBit() == 0
+}
+
+func (f fpFormat) inf(negative bool) uint64 {
+	return f.withSign(uint64(1<<f.ebits-1)<<f.mbits, negative)
+}
+
+func (f fpFormat) maxFinite(negative bool) uint64 {
+	return f.withSign(uint64(1<<f.ebits-2)<<f.mbits|(1<<f.mbits-1), negative)
+}
+
+func (f fpFormat) zero(negative bool) uint64 {
+	return f.withSign(0, negative)
+}
+
+func (f fpFormat) withSign(bits uint64, negative bool) uint64 {
+	if negative {
+		return bits | f.signBit()
+	}
+	return bits &^ f.signBit()
+}
+
+// unbox returns the value of a register in the given format. A single that
+// isn't properly NaN-boxed is read as the canonical NaN.
+func (f fpFormat) unbox(raw uint64) uint64 {
+	if f == double {
+		return raw
+	}
+	if raw>>32 != 0xffffffff {
+		return single.qnan
+	}
+	return raw & 0xffffffff
+}
+
+// box returns the register value of bits in the given format.
+func (f fpFormat) box(bits uint64) uint64 {
+	if f == double {
+		return bits
+	}
+	return bits | 0xffffffff00000000
+}
+
+// toBig returns the exact value of a finite number.
+func (f fpFormat) toBig(bits uint64) *big.Float {
+	var v float64
+	if f == double {
+		v = math.Float64frombits(bits)
+	} else {
+		v = float64(math.Float32frombits(uint32(bits)))
+	}
+	return new(big.Float).SetPrec(exactPrecision).SetFloat64(v)
+}
+
+// round rounds a finite non-zero value to the format. If sticky is set, the
+// exact value is slightly greater in magnitude than x.
+func (f fpFormat) round(x *big.Float, sticky bool, rm int) (uint64, int) {
+	negative := x.Signbit()
+	magnitude := new(big.Float).SetPrec(x.Prec()).Abs(x)
+	// x is in [2^e, 2^(e+1))
+	e := magnitude.MantExp(nil) - 1
+
+	// Tininess is detected after rounding, with an unbounded exponent range
+	unbounded, _ := roundToQuantum(magnitude, e-f.mbits, sticky, rm, negative)
+	tiny := unbounded.Cmp(new(big.Float).SetMantExp(big.NewFloat(1), f.emin())) < 0
+
+	quantum := max(e, f.emin()) - f.mbits
+	n, inexact := roundToInteger(new(big.Float).SetMantExp(magnitude, -quantum), sticky, rm, negative)
+
+	flags := 0
+	if inexact {
+		flags |= flagNX
+		if tiny {
+			flags |= flagUF
+		}
+	}
+
+	// Renormalize if the rounding carried into a new bit
+	if n.BitLen() > f.mbits+1 {
+		n.Rsh(n, 1)
+		quantum++
+	}
+
+	if n.Sign() == 0 {
+		return f.zero(negative), flags
+	}
+	if n.BitLen() <= f.mbits {
+		// Subnormal
+		return f.withSign(n.Uint64(), negative), flags
+	}
+	exp := quantum + f.mbits + f.bias
+	if exp >= 1<<f.ebits-1 {
+		flags |= flagOF | flagNX
+		switch {
+		case rm == rmRTZ,
+			rm == rmRDN && !negative,
+			rm == rmRUP && negative:
+			return f.maxFinite(negative), flags
+		default:
+			return f.inf(negative), flags
+		}
+	}
+	return f.withSign(uint64(exp)<<f.mbits|f.fraction(n.Uint64()), negative), flags
+}
+
+// roundToQuantum rounds the magnitude x to a multiple of 2^quantum.
+func roundToQuantum(x *big.Float, quantum int, sticky bool, rm int, negative bool) (*big.Float, bool) {
+	n, inexact := roundToInteger(new(big.Float).SetMantExp(x, -quantum), sticky, rm, negative)
+	v := new(big.Float).SetPrec(exactPrecision).SetInt(n)
+	return v.SetMantExp(v, quantum), inexact
+}
+
+// roundToInteger rounds the non-negative magnitude x of a value to an integer.
+// negative is the sign of the value, used by the directed rounding modes.
+func roundToInteger(x *big.Float, sticky bool, rm int, negative bool) (*big.Int, bool) {
+	n, _ := x.Int(nil)
+	remainder := new(big.Float).SetPrec(x.Prec()).Sub(x, new(big.Float).SetPrec(x.Prec()).SetInt(n))
+	half := remainder.Cmp(big.NewFloat(0.5))
+	inexact := remainder.Sign() != 0 || sticky
+	if !inexact {
+		return n, false
+	}
+
+	var up bool
+	switch rm {
+	case rmRNE:
+		up = half > 0 || half == 0 && (sticky || n.Bit(0) == 1)
+	case rmRMM:
+		up = half >= 0
+	case rmRTZ:
+		up = false
+	case rmRDN:
+		up = negative
+	case rmRUP:
+		up = !negative
+	}
+	if up {
+		n.Add(n, big.NewInt(1))
+	}
+	return n, true
+}
+
+// exactZero returns the sign of an exact zero result, which is negative only
+// when rounding down.
+func exactZero(rm int) bool {
+	return rm == rmRDN
+}
+
+// nanResult returns the canonical NaN if one of the operands is a NaN, and
+// whether the invalid flag has to be raised.
+func (f fpFormat) nanResult(operands ...uint64) (uint64, int, bool) {
+	isNaN := false
+	flags := 0
+	for _, op := range operands {
+		if f.isNaN(op) {
+			isNaN = true
+			if f.isSNaN(op) {
+				flags |= flagNV
+			}
+		}
+	}
+	return f.qnan, flags, isNaN
+}
+
+func (f fpFormat) add(a, b uint64, rm int) (uint64, int) {
+	if nan, flags, isNaN := f.nanResult(a, b); isNaN {
+		return nan, flags
+	}
+	switch {
+	case f.isInf(a) && f.isInf(b):
+		if f.negative(a) != f.negative(b) {
+			return f.qnan, flagNV
+		}
+		return a, 0
+	case f.isInf(a):
+		return a, 0
+	case f.isInf(b):
+		return b, 0
+	case f.isZero(a) && f.isZero(b):
+		if f.negative(a) == f.negative(b) {
+			return a, 0
+		}
+		return f.zero(exactZero(rm)), 0
+	}
+
+	sum := new(big.Float).SetPrec(exactPrecision).Add(f.toBig(a), f.toBig(b))
+	if sum.Sign() == 0 {
+		return f.zero(exactZero(rm)), 0
+	}
+	return f.round(sum, false, rm)
+}
+
+func (f fpFormat) sub(a, b uint64, rm int) (uint64, int) {
+	return f.add(a, b^f.signBit(), rm)
+}
+
+func (f fpFormat) mul(a, b uint64, rm int) (uint64, int) {
+	if nan, flags, isNaN := f.nanResult(a, b); isNaN {
+		return nan, flags
+	}
+	negative := f.negative(a) != f.negative(b)
+	switch {
+	case f.isInf(a) && f.isZero(b), f.isZero(a) && f.isInf(b):
+		return f.qnan, flagNV
+	case f.isInf(a), f.isInf(b):
+		return f.inf(negative), 0
+	case f.isZero(a), f.isZero(b):
+		return f.zero(negative), 0
+	}
+	return f.round(new(big.Float).SetPrec(exactPrecision).Mul(f.toBig(a), f.toBig(b)), false, rm)
+}
+
+func (f fpFormat) div(a, b uint64, rm int) (uint64, int) {
+	if nan, flags, isNaN := f.nanResult(a, b); isNaN {
+		return nan, flags
+	}
+	negative := f.negative(a) != f.negative(b)
+	switch {
+	case f.isInf(a) && f.isInf(b), f.isZero(a) && f.isZero(b):
+		return f.qnan, flagNV
+	case f.isInf(a):
+		return f.inf(negative), 0
+	case f.isInf(b), f.isZero(a):
+		return f.zero(negative), 0
+	case f.isZero(b):
+		return f.inf(negative), flagDZ
+	}
+	quotient := new(big.Float).SetPrec(truncatedPrecision).SetMode(big.ToZero).Quo(f.toBig(a), f.toBig(b))
+	return f.round(quotient, quotient.Acc() != big.Exact, rm)
+}
+
+func (f fpFormat) sqrt(a uint64, rm int) (uint64, int) {
+	if nan, flags, isNaN := f.nanResult(a); isNaN {
+		return nan, flags
+	}
+	switch {
+	case f.isZero(a):
+		return a, 0
+	case f.negative(a):
+		return f.qnan, flagNV
+	case f.isInf(a):
+		return a, 0
+	}
+	x := f.toBig(a)
+	root := new(big.Float).SetPrec(truncatedPrecision).SetMode(big.ToZero).Sqrt(x)
+	// The accuracy of Sqrt isn't computed, the root is checked by squaring it
+	square := new(big.Float).SetPrec(2*truncatedPrecision).Mul(root, root)
+	cmp := square.Cmp(x)
+	if cmp > 0 {
+		ulp := new(big.Float).SetMantExp(big.NewFloat(1), root.MantExp(nil)-truncatedPrecision)
+		root = new(big.Float).SetPrec(truncatedPrecision).Sub(root, ulp)
+	}
+	return f.round(root, cmp != 0, rm)
+}
+
+// fma returns a*b+c, negating the product and the addend as requested.
+func (f fpFormat) fma(a, b, c uint64, negateProduct, negateAddend bool, rm int) (uint64, int) {
+	// The invalid operation is signaled even if the addend is a quiet NaN
+	if f.isInf(a) && f.isZero(b) || f.isZero(a) && f.isInf(b) {
+		return f.qnan, flagNV
+	}
+	if nan, flags, isNaN := f.nanResult(a, b, c); isNaN {
+		return nan, flags
+	}
+	productNegative := f.negative(a) != f.negative(b) != negateProduct
+	if negateAddend {
+		c ^= f.signBit()
+	}
+	productInf := f.isInf(a) || f.isInf(b)
+	switch {
+	case productInf && f.isInf(c):
+		if productNegative != f.negative(c) {
+			return f.qnan, flagNV
+		}
+		return c, 0
+	case productInf:
+		return f.inf(productNegative), 0
+	case f.isInf(c):
+		return c, 0
+	}
+
+	productZero := f.isZero(a) || f.isZero(b)
+	if productZero && f.isZero(c) {
+		if productNegative == f.negative(c) {
+			return c, 0
+		}
+		return f.zero(exactZero(rm)), 0
+	}
+
+	product := new(big.Float).SetPrec(exactPrecision).Mul(f.toBig(a), f.toBig(b))
+	if productNegative != product.Signbit() {
+		product.Neg(product)
+	}
+	sum := new(big.Float).SetPrec(exactPrecision).Add(product, f.toBig(c))
+	if sum.Sign() == 0 {
+		return f.zero(exactZero(rm)), 0
+	}
+	return f.round(sum, false, rm)
+}
+
+// less returns whether a < b for non-NaN values, with -0 < +0.
+func (f fpFormat) less(a, b uint64) bool {
+	if f.isZero(a) && f.isZero(b) {
+		return f.negative(a) && !f.negative(b)
+	}
+	if f.isInf(a) || f.isInf(b) {
+		switch {
+		case a == b:
+			return false
+		case f.isInf(a):
+			return f.negative(a)
+		default:
+			return !f.negative(b)
+		}
+	}
+	return f.toBig(a).Cmp(f.toBig(b)) < 0
+}
+
+func (f fpFormat) minMax(a, b uint64, isMax bool) (uint64, int) {
+	flags := 0
+	if f.isSNaN(a) || f.isSNaN(b) {
+		flags = flagNV
+	}
+	switch {
+	case f.isNaN(a) && f.isNaN(b):
+		return f.qnan, flags
+	case f.isNaN(a):
+		return b, flags
+	case f.isNaN(b):
+		return a, flags
+	}
+	if f.less(a, b) != isMax {
+		return a, flags
+	}
+	return b, flags
+}
+
+// compare returns the result of feq, flt or fle. feq only signals on a
+// signaling NaN whereas flt and fle signal on any NaN.
+func (f fpFormat) compare(a, b uint64, instructionType InstructionType) (int32, int) {
+	if f.isNaN(a) || f.isNaN(b) {
+		switch instructionType {
+		case FeqS, FeqD:
+			if f.isSNaN(a) || f.isSNaN(b) {
+				return 0, flagNV
+			}
+			return 0, 0
+		default:
+			return 0, flagNV
+		}
+	}
+
+	// Unlike fmin and fmax, the comparisons consider that -0 = +0
+	equal := a == b || f.isZero(a) && f.isZero(b)
+	var result bool
+	switch instructionType {
+	case FeqS, FeqD:
+		result = equal
+	case FltS, FltD:
+		result = !equal && f.less(a, b)
+	case FleS, FleD:
+		result = equal || f.less(a, b)
+	default:
+		panic(instructionType)
+	}
+	if result {
+		return 1, 0
+	}
+	return 0, 0
+}
+
+// class returns the fclass mask of a value.
+func (f fpFormat) class(a uint64) int32 {
+	negative := f.negative(a)
+	switch {
+	case f.isInf(a) && negative:
+		return 1 << 0
+	case f.isInf(a):
+		return 1 << 7
+	case f.isSNaN(a):
+		return 1 << 8
+	case f.isNaN(a):
+		return 1 << 9
+	case f.isZero(a) && negative:
+		return 1 << 3
+	case f.isZero(a):
+		return 1 << 4
+	case f.expField(a) == 0 && negative:
+		return 1 << 2
+	case f.expField(a) == 0:
+		return 1 << 5
+	case negative:
+		return 1 << 1
+	default:
+		return 1 << 6
+	}
+}
+
+// sign returns the result of fsgnj, fsgnjn or fsgnjx.
+func (f fpFormat) sign(a, b uint64, instructionType InstructionType) uint64 {
+	switch instructionType {
+	case FsgnjS, FsgnjD:
+		return f.withSign(a, f.negative(b))
+	case FsgnjnS, FsgnjnD:
+		return f.withSign(a, !f.negative(b))
+	case FsgnjxS, FsgnjxD:
+		return f.withSign(a, f.negative(a) != f.negative(b))
+	default:
+		panic(instructionType)
+	}
+}
+
+// fromInt converts an integer to the format.
+func (f fpFormat) fromInt(n int64, rm int) (uint64, int) {
+	if n == 0 {
+		return f.zero(false), 0
+	}
+	return f.round(new(big.Float).SetPrec(exactPrecision).SetInt64(n), false, rm)
+}
+
+// toInt converts a value to a 32-bit integer, saturating out-of-range values.
+func (f fpFormat) toInt(a uint64, unsigned bool, rm int) (int32, int) {
+	lower, upper := int64(math.MinInt32), int64(math.MaxInt32)
+	if unsigned {
+		lower, upper = 0, math.MaxUint32
+	}
+	switch {
+	case f.isNaN(a):
+		return int32(upper), flagNV
+	case f.isInf(a) && f.negative(a):
+		return int32(lower), flagNV
+	case f.isInf(a):
+		return int32(upper), flagNV
+	}
+
+	x := f.toBig(a)
+	negative := x.Signbit()
+	n, inexact := roundToInteger(x.Abs(x), false, rm, negative)
+	if negative {
+		n.Neg(n)
+	}
+	switch {
+	case n.Cmp(big.NewInt(lower)) < 0:
+		return int32(lower), flagNV
+	case n.Cmp(big.NewInt(upper)) > 0:
+		return int32(upper), flagNV
+	case inexact:
+		return int32(n.Int64()), flagNX
+	default:
+		return int32(n.Int64()), 0
+	}
+}
+
+// convert converts a value from the format to another format.
+func (f fpFormat) convert(a uint64, to fpFormat, rm int) (uint64, int) {
+	if _, flags, isNaN := f.nanResult(a); isNaN {
+		return to.qnan, flags
+	}
+	switch {
+	case f.isInf(a):
+		return to.inf(f.negative(a)), 0
+	case f.isZero(a):
+		return to.zero(f.negative(a)), 0
+	}
+	return to.round(f.toBig(a), false, rm)
+}
+
+// roundingMode returns the rounding mode of an instruction, resolving the
+// dynamic rounding mode from fcsr.
+func (ctx *Context) roundingMode(rm int32) (int, error) {
+	if rm == rmDYN {
+		rm = (ctx.FCSR >> 5) & 0x7
+	}
+	if rm > rmRMM {
+		return 0, fmt.Errorf("invalid rounding mode: %d", rm)
+	}
+	return int(rm), nil
+}
+
+// raiseFlags accrues exception flags into fcsr.
+func (ctx *Context) raiseFlags(flags int) {
+	ctx.FCSR |= int32(flags)
+}
@@ -0,0 +1,211 @@
+package risc
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	sOne    = 0x3f800000
+	sTwo    = 0x40000000
+	sThree  = 0x40400000
+	sHalf   = 0x3f000000
+	sMax    = 0x7f7fffff
+	sInf    = 0x7f800000
+	sNegInf = 0xff800000
+	sSNaN   = 0x7f800001
+	sNegZ   = 0x80000000
+)
+
+func TestFloatRounding(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      uint64
+		rm        int
+		want      uint64
+		wantFlags int
+	}{
+		{"rne", sOne, sThree, rmRNE, 0x3eaaaaab, flagNX},
+		{"rtz", sOne, sThree, rmRTZ, 0x3eaaaaaa, flagNX},
+		{"rdn", sOne, sThree, rmRDN, 0x3eaaaaaa, flagNX},
+		{"rup", sOne, sThree, rmRUP, 0x3eaaaaab, flagNX},
+		{"rdn negative", sOne | sNegZ, sThree, rmRDN, 0xbeaaaaab, flagNX},
+		{"rup negative", sOne | sNegZ, sThree, rmRUP, 0xbeaaaaaa, flagNX},
+		{"exact", sThree, sThree, rmRNE, sOne, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, flags := single.div(tt.a, tt.b, tt.rm)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantFlags, flags)
+		})
+	}
+}
+
+func TestFloatOverflowUnderflow(t *testing.T) {
+	got, flags := single.mul(sMax, sTwo, rmRNE)
+	assert.Equal(t, uint64(sInf), got)
+	assert.Equal(t, flagOF|flagNX, flags)
+
+	got, flags = single.mul(sMax, sTwo, rmRTZ)
+	assert.Equal(t, uint64(sMax), got)
+	assert.Equal(t, flagOF|flagNX, flags)
+
+	// Exact subnormal result
+	got, flags = single.mul(0x00800000, sHalf, rmRNE)
+	assert.Equal(t, uint64(0x00400000), got)
+	assert.Equal(t, 0, flags)
+
+	// Half of the smallest subnormal is a tie rounded to the even zero
+	got, flags = single.mul(0x00000001, sHalf, rmRNE)
+	assert.Equal(t, uint64(0), got)
+	assert.Equal(t, flagUF|flagNX, flags)
+
+	got, flags = single.mul(0x00000001, sHalf, rmRUP)
+	assert.Equal(t, uint64(1), got)
+	assert.Equal(t, flagUF|flagNX, flags)
+}
+
+func TestFloatSpecialValues(t *testing.T) {
+	tests := []struct {
+		name      string
+		op        func() (uint64, int)
+		want      uint64
+		wantFlags int
+	}{
+		{"division by zero", func() (uint64, int) { return single.div(sOne, 0, rmRNE) }, sInf, flagDZ},
+		{"zero divided by zero", func() (uint64, int) { return single.div(0, 0, rmRNE) }, single.qnan, flagNV},
+		{"square root of a negative", func() (uint64, int) { return single.sqrt(sOne|sNegZ, rmRNE) }, single.qnan, flagNV},
+		{"square root", func() (uint64, int) { return double.sqrt(math.Float64bits(2), rmRNE) }, math.Float64bits(math.Sqrt2), flagNX},
+		{"infinity minus infinity", func() (uint64, int) { return single.sub(sInf, sInf, rmRNE) }, single.qnan, flagNV},
+		{"exact zero rounding down", func() (uint64, int) { return single.sub(sOne, sOne, rmRDN) }, sNegZ, 0},
+		{"exact zero", func() (uint64, int) { return single.sub(sOne, sOne, rmRNE) }, 0, 0},
+		{"signaling NaN", func() (uint64, int) { return single.add(sSNaN, sOne, rmRNE) }, single.qnan, flagNV},
+		{"quiet NaN", func() (uint64, int) { return single.add(single.qnan, sOne, rmRNE) }, single.qnan, 0},
+		{"fused multiply-add of infinity and zero", func() (uint64, int) {
+			return single.fma(sInf, 0, single.qnan, false, false, rmRNE)
+		}, single.qnan, flagNV},
+		{"fused multiply-add rounded once", func() (uint64, int) {
+			// (1+2^-23)^2 - (1+2^-22) = 2^-46 is lost if the product is rounded
+			return single.fma(0x3f800001, 0x3f800001, 0xbf800002, false, false, rmRNE)
+		}, 0x28800000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, flags := tt.op()
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantFlags, flags)
+		})
+	}
+}
+
+func TestFloatMinMax(t *testing.T) {
+	got, flags := single.minMax(sNegZ, 0, false)
+	assert.Equal(t, uint64(sNegZ), got)
+	assert.Equal(t, 0, flags)
+
+	got, flags = single.minMax(sNegZ, 0, true)
+	assert.Equal(t, uint64(0), got)
+	assert.Equal(t, 0, flags)
+
+	got, flags = single.minMax(single.qnan, sOne, false)
+	assert.Equal(t, uint64(sOne), got)
+	assert.Equal(t, 0, flags)
+
+	got, flags = single.minMax(sSNaN, sOne, true)
+	assert.Equal(t, uint64(sOne), got)
+	assert.Equal(t, flagNV, flags)
+
+	got, _ = single.minMax(sSNaN, single.qnan, true)
+	assert.Equal(t, single.qnan, got)
+}
+
+func TestFloatCompare(t *testing.T) {
+	v, flags := single.compare(single.qnan, sOne, FeqS)
+	assert.Equal(t, int32(0), v)
+	assert.Equal(t, 0, flags)
+
+	v, flags = single.compare(single.qnan, sOne, FltS)
+	assert.Equal(t, int32(0), v)
+	assert.Equal(t, flagNV, flags)
+
+	v, _ = single.compare(sNegZ, 0, FeqS)
+	assert.Equal(t, int32(1), v)
+	v, _ = single.compare(sNegZ, 0, FltS)
+	assert.Equal(t, int32(0), v)
+	v, _ = single.compare(sNegInf, sOne, FleS)
+	assert.Equal(t, int32(1), v)
+}
+
+func TestFloatClass(t *testing.T) {
+	for bits, want := range map[uint64]int32{
+		sNegInf:          1 << 0,
+		0xbf800000:       1 << 1,
+		0x80000001:       1 << 2,
+		sNegZ:            1 << 3,
+		0:                1 << 4,
+		0x00000001:       1 << 5,
+		sOne:             1 << 6,
+		sInf:             1 << 7,
+		sSNaN:            1 << 8,
+		single.qnan:      1 << 9,
+		double.qnan >> 1: 1 << 6,
+	} {
+		f := single
+		if bits > math.MaxUint32 {
+			f = double
+		}
+		assert.Equal(t, want, f.class(bits), "%#x", bits)
+	}
+}
+
+func TestFloatToInt(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     float32
+		unsigned  bool
+		rm        int
+		want      int32
+		wantFlags int
+	}{
+		{"ties to even", 2.5, false, rmRNE, 2, flagNX},
+		{"ties to max magnitude", 2.5, false, rmRMM, 3, flagNX},
+		{"round down", -2.5, false, rmRDN, -3, flagNX},
+		{"exact", -7, false, rmRNE, -7, 0},
+		{"overflow", 3e9, false, rmRNE, math.MaxInt32, flagNV},
+		{"unsigned", 3e9, true, rmRNE, -1294967296, 0},
+		{"unsigned negative", -1, true, rmRNE, 0, flagNV},
+		{"unsigned rounded to zero", -0.25, true, rmRNE, 0, flagNX},
+		{"NaN", float32(math.NaN()), false, rmRNE, math.MaxInt32, flagNV},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, flags := single.toInt(uint64(math.Float32bits(tt.value)), tt.unsigned, tt.rm)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantFlags, flags)
+		})
+	}
+}
+
+func TestFloatConversions(t *testing.T) {
+	got, flags := single.fromInt(16777217, rmRNE)
+	assert.Equal(t, uint64(0x4b800000), got)
+	assert.Equal(t, flagNX, flags)
+
+	got, _ = single.fromInt(16777217, rmRUP)
+	assert.Equal(t, uint64(0x4b800001), got)
+
+	got, flags = double.convert(math.Float64bits(0.1), single, rmRNE)
+	assert.Equal(t, uint64(math.Float32bits(0.1)), got)
+	assert.Equal(t, flagNX, flags)
+
+	got, flags = single.convert(sSNaN, double, rmRNE)
+	assert.Equal(t, double.qnan, got)
+	assert.Equal(t, flagNV, flags)
+
+	// A single that isn't NaN-boxed is read as the canonical NaN
+	assert.Equal(t, single.qnan, single.unbox(sOne))
+	assert.Equal(t, uint64(sOne), single.unbox(single.box(sOne)))
+}
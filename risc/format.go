@@ -0,0 +1,181 @@
+package risc
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// registerName returns the ABI name of a register, as written in assembly.
+func registerName(reg RegisterType) string {
+	return strings.ToLower(reg.String())
+}
+
+// formatTarget returns the target of a branch or a jump: its label or, if
+// there is none, its offset from the pc.
+func formatTarget(label string, offset int32) string {
+	if label != "" {
+		return label
+	}
+	return strconv.Itoa(int(offset))
+}
+
+// mnemonic returns the key of a parser table mapping a mnemonic to a value.
+func mnemonic[T comparable](table map[string]T, value T) string {
+	if name, exists := reverseLookup(table, value); exists {
+		return name
+	}
+	return fmt.Sprint(value)
+}
+
+// Format returns the source of an application in canonical assembly: the
+// instructions and their labels, followed by the data sections. Parsing the
+// source returns the same instructions, labels and memory image.
+func Format(app Application) string {
+	textLabels := make(map[int32][]string)
+	dataLabels := make(map[int32][]string)
+	for label, addr := range app.Labels {
+		if app.DataLabels[label] {
+			dataLabels[addr] = append(dataLabels[addr], label)
+		} else {
+			textLabels[addr] = append(textLabels[addr], label)
+		}
+	}
+
+	var sb strings.Builder
+	writeLabels(&sb, textLabels[0])
+	for pc := int32(0); pc < app.End(); pc += app.alignment() {
+		if pc != 0 {
+			writeLabels(&sb, textLabels[pc])
+		}
+		if runner, exists := app.Instruction(pc); exists {
+			fmt.Fprintf(&sb, "    %s\n", runner)
+		}
+	}
+	if app.End() != 0 {
+		writeLabels(&sb, textLabels[app.End()])
+	}
+
+	formatData(&sb, app.Segments, dataLabels)
+	return sb.String()
+}
+
+// formatData writes the data sections holding the segments of an application.
+// Each segment is written in its own section, in the order the sections are
+// laid out, and aligned on its address. If the segments can't be laid out
+// this way, they are all written in the data section, padded to their
+// address.
+func formatData(sb *strings.Builder, segments []Segment, labels map[int32][]string) {
+	if len(segments) == 0 {
+		if len(labels[0]) != 0 {
+			fmt.Fprintf(sb, "%s\n", mnemonic(sectionNames, sectionData))
+			writeLabels(sb, labels[0])
+		}
+		return
+	}
+
+	separate := separateSections(segments)
+	if !separate {
+		fmt.Fprintf(sb, "%s\n", mnemonic(sectionNames, sectionData))
+	}
+	var end int32
+	for i, segment := range segments {
+		switch {
+		case separate:
+			fmt.Fprintf(sb, "%s\n", mnemonic(sectionNames, dataSections[i]))
+			fmt.Fprintf(sb, "    .align %d\n", segmentAlignment(segment))
+		case segment.Address < end:
+			// An overlapping segment can't be written
+			continue
+		case segment.Address > end:
+			fmt.Fprintf(sb, "    .zero %d\n", segment.Address-end)
+		}
+		formatSegment(sb, segment, labels)
+		end = segment.Address + int32(len(segment.Data))
+		if i+1 == len(segments) || segments[i+1].Address != end {
+			writeLabels(sb, labels[end])
+			delete(labels, end)
+		}
+	}
+}
+
+// separateSections returns whether the segments are laid out as the data
+// sections would be: in order, each one aligned after the previous one, with
+// a zero-initialized .bss.
+func separateSections(segments []Segment) bool {
+	if len(segments) > len(dataSections) {
+		return false
+	}
+	var end int32
+	for i, segment := range segments {
+		alignment := max(4, int32(1)<<segmentAlignment(segment))
+		if segment.Address != alignAddress(end, alignment) {
+			return false
+		}
+		if dataSections[i] == sectionBss {
+			for _, b := range segment.Data {
+				if b != 0 {
+					return false
+				}
+			}
+		}
+		end = segment.Address + int32(len(segment.Data))
+	}
+	return true
+}
+
+// segmentAlignment returns the largest alignment of a segment, as a power of
+// two accepted by .align.
+func segmentAlignment(segment Segment) int {
+	n := 0
+	for n < 12 && segment.Address&(1<<n) == 0 {
+		n++
+	}
+	return n
+}
+
+// formatSegment writes the content of a segment along with its labels: the
+// runs of zeros with .zero and the other bytes with .byte.
+func formatSegment(sb *strings.Builder, segment Segment, labels map[int32][]string) {
+	var values []string
+	flush := func() {
+		if len(values) != 0 {
+			fmt.Fprintf(sb, "    .byte %s\n", strings.Join(values, ", "))
+			values = nil
+		}
+	}
+	for offset := 0; offset < len(segment.Data); {
+		addr := segment.Address + int32(offset)
+		if names := labels[addr]; len(names) != 0 {
+			flush()
+			writeLabels(sb, names)
+			delete(labels, addr)
+		}
+		zeros := 0
+		for offset+zeros < len(segment.Data) && segment.Data[offset+zeros] == 0 &&
+			(zeros == 0 || len(labels[addr+int32(zeros)]) == 0) {
+			zeros++
+		}
+		if zeros >= 4 {
+			flush()
+			fmt.Fprintf(sb, "    .zero %d\n", zeros)
+			offset += zeros
+			continue
+		}
+		values = append(values, strconv.Itoa(int(uint8(segment.Data[offset]))))
+		if len(values) == 16 {
+			flush()
+		}
+		offset++
+	}
+	flush()
+}
+
+// writeLabels writes labels defined at the same address, in order.
+func writeLabels(sb *strings.Builder, labels []string) {
+	sort.Strings(labels)
+	for _, label := range labels {
+		fmt.Fprintf(sb, "%s:\n", label)
+	}
+}
@@ -0,0 +1,155 @@
+package risc
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstructionString(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"add t0, t1, t2", "add t0, t1, t2"},
+		{"addi a0, sp, -16", "addi a0, sp, -16"},
+		{"sw ra, 12(sp)", "sw ra, 12(sp)"},
+		{"lbu t1, 0(t1)", "lbu t1, 0(t1)"},
+		{"lui t0, 0x12345", "lui t0, 0x12345"},
+		{"lui a0, 0x80000", "lui a0, 0x80000"},
+		{"lui a0, %hi(-4)", "lui a0, 0x0"},
+		{"auipc t0, 0xfffff", "auipc t0, 0xfffff"},
+		{"loop: bgt t0, t1, loop", "blt t1, t0, loop"},
+		{"beqz t0, 8", "beqz t0, 8"},
+		{"jal ra, foo\nfoo:", "jal ra, foo"},
+		{"jr t0", "jalr zero, t0, 0"},
+		{"li t0, 5", "li t0, 5"},
+		{"mv t0, t1", "mv t0, t1"},
+		{"ret", "ret"},
+		{"csrw mscratch, t0", "csrrw zero, mscratch, t0"},
+		{"csrrsi s1, frm, 1", "csrrsi s1, frm, 1"},
+		{"amoadd.w t2, t1, (t0)", "amoadd.w t2, t1, (t0)"},
+		{"lr.w t2, (t0)", "lr.w t2, (t0)"},
+		{"sc.w t3, t1, (t0)", "sc.w t3, t1, (t0)"},
+		{"fld ft0, 8(a0)", "fld ft0, 8(a0)"},
+		{"fsw fa0, 4(sp)", "fsw fa0, 4(sp)"},
+		{"fmadd.d ft0, ft1, ft2, ft3", "fmadd.d ft0, ft1, ft2, ft3"},
+		{"fcvt.w.s t0, ft0, rtz", "fcvt.w.s t0, ft0, rtz"},
+		{"feq.s t0, ft0, ft1", "feq.s t0, ft0, ft1"},
+		{"c.mv a0, a1", "c.mv a0, a1"},
+		{"c.li a0, -3", "c.li a0, -3"},
+		{"c.addi16sp sp, -64", "c.addi16sp sp, -64"},
+		{"c.addi4spn s0, sp, 8", "c.addi4spn s0, sp, 8"},
+		{"c.lwsp ra, 12(sp)", "c.lwsp ra, 12(sp)"},
+		{"c.sw a0, 4(s1)", "c.sw a0, 4(s1)"},
+		{"c.jr ra", "c.jr ra"},
+		{"c.lui a0, 0xfffff", "c.lui a0, 0xfffff"},
+		{"c.nop", "c.nop"},
+	}
+	for _, tt := range tests {
+		app, err := Parse(tt.source)
+		require.NoError(t, err, tt.source)
+		runner, exists := app.Instruction(0)
+		require.True(t, exists, tt.source)
+		assert.Equal(t, tt.want, runner.String(), tt.source)
+	}
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	sources := map[string]string{
+		"mixed": `.data
+msg: .asciz "hello"
+.align 3
+values: .word 1, -2, 0, 0, 0x7fffffff
+end:
+.rodata
+table: .half 1, 2, 3
+.bss
+buffer: .zero 64
+.text
+main:
+    la a0, msg
+    li t0, 0x12345678
+    li t1, values
+    lw t2, %lo(values)(t1)
+    call f
+    c.addi a0, 1
+    .align 3
+    fadd.s ft0, ft1, ft2, rne
+    csrr t0, cycle
+    unimp
+    tail f
+f:
+    c.addi16sp sp, -32
+    c.swsp ra, 4(sp)
+    bnez a0, done
+done:
+    c.jr ra`,
+		"empty data": `.data
+start:
+.text
+nop`,
+		"negative upper immediates": `li a0, -2147483648
+lui a1, 0x80000
+lui a2, -1
+auipc a3, 0xfffff
+c.lui a4, 0xfffe0`,
+	}
+	paths, err := filepath.Glob("../res/*.asm")
+	require.NoError(t, err)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		sources[path] = string(data)
+	}
+
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			app, err := Parse(source)
+			require.NoError(t, err)
+			assertFormatRoundTrip(t, app)
+		})
+	}
+
+	// The compliance tests, with their included macros
+	fsys := os.DirFS("../res/isa")
+	require.NoError(t, fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(path) != ".asm" {
+			return err
+		}
+		t.Run(path, func(t *testing.T) {
+			app, err := ParseFS(fsys, path)
+			require.NoError(t, err)
+			assertFormatRoundTrip(t, app)
+		})
+		return nil
+	}))
+}
+
+// assertFormatRoundTrip asserts that parsing the formatted application gives
+// the same application back.
+func assertFormatRoundTrip(t *testing.T, app Application) {
+	t.Helper()
+	formatted := Format(app)
+	got, err := Parse(formatted)
+	require.NoError(t, err, formatted)
+	assert.Equal(t, app.Instructions, got.Instructions, formatted)
+	assert.Equal(t, app.Compressed, got.Compressed)
+	assert.Equal(t, app.Labels, got.Labels, formatted)
+	assert.Equal(t, app.DataLabels, got.DataLabels)
+	assert.Equal(t, app.Segments, got.Segments, formatted)
+	// The formatted application has the same warnings, at other lines
+	assert.Equal(t, warningCodes(app), warningCodes(got), formatted)
+}
+
+func warningCodes(app Application) []ErrorCode {
+	var codes []ErrorCode
+	for _, w := range app.Warnings {
+		codes = append(codes, w.Code)
+	}
+	return codes
+}
@@ -0,0 +1,57 @@
+package risc
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Intel HEX record types.
+const (
+	hexData               = 0x00
+	hexEndOfFile          = 0x01
+	hexExtendedLinearAddr = 0x04
+	hexStartLinearAddr    = 0x05
+)
+
+// hexRecordLength is the maximum number of data bytes of a record.
+const hexRecordLength = 16
+
+// EncodeIntelHex assembles the instructions of an application into the Intel
+// HEX format, such as for an instruction memory: the image returned by Encode
+// followed by the entry point. The data, in a separate address space, isn't
+// part of it.
+func EncodeIntelHex(app Application) ([]byte, error) {
+	code, err := Encode(app)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	upper := uint32(0)
+	for i := 0; i < len(code); {
+		addr := uint32(i)
+		if addr>>16 != upper {
+			upper = addr >> 16
+			writeHexRecord(&out, hexExtendedLinearAddr, 0, []byte{byte(upper >> 8), byte(upper)})
+		}
+		// A record doesn't cross a 64 KiB boundary
+		n := min(hexRecordLength, len(code)-i, int(0x10000-addr&0xffff))
+		writeHexRecord(&out, hexData, uint16(addr), code[i:i+n])
+		i += n
+	}
+	entry := uint32(app.Entry)
+	writeHexRecord(&out, hexStartLinearAddr, 0, []byte{byte(entry >> 24), byte(entry >> 16), byte(entry >> 8), byte(entry)})
+	writeHexRecord(&out, hexEndOfFile, 0, nil)
+	return out.Bytes(), nil
+}
+
+// writeHexRecord writes a record, terminated by the two's complement of the
+// sum of its bytes.
+func writeHexRecord(out *bytes.Buffer, recordType byte, addr uint16, data []byte) {
+	record := append([]byte{byte(len(data)), byte(addr >> 8), byte(addr), recordType}, data...)
+	var sum byte
+	for _, b := range record {
+		sum += b
+	}
+	fmt.Fprintf(out, ":%X%02X\n", record, -sum)
+}
@@ -0,0 +1,347 @@
+package risc
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// inputLine is a line of an application, located in the source file it comes
+// from. err is set if the preprocessor failed on the line.
+type inputLine struct {
+	file string
+	line int
+	text string
+	err  error
+	// expandedFrom locates the macro invocations the line was expanded from,
+	// the innermost first.
+	expandedFrom []Location
+}
+
+func splitInputLines(file, s string) []inputLine {
+	var lines []inputLine
+	for i, text := range strings.Split(s, "\n") {
+		lines = append(lines, inputLine{file: file, line: i + 1, text: text})
+	}
+	return lines
+}
+
+// maxExpansionDepth bounds the nesting of the macros and of the inclusions,
+// which stops the recursive ones.
+const maxExpansionDepth = 64
+
+// preprocessor expands the macros, the repetitions, the inclusions and the
+// conditional blocks of an application. The expanded lines keep the position
+// of the line they come from.
+type preprocessor struct {
+	// fsys resolves the included files. It's nil if the source doesn't come
+	// from a file system.
+	fsys   fs.FS
+	macros map[string]*macro
+	// exprs evaluates the conditions and the repetition counts, which can refer
+	// to the constants defined so far but not to the labels.
+	exprs *expressions
+	// labels holds the labels defined so far, for .ifdef.
+	labels map[string]bool
+	// expansions counts the macro expansions, substituted to \@.
+	expansions int
+	out        []inputLine
+}
+
+// macro is a macro defined by .macro and .endm.
+type macro struct {
+	params []macroParam
+	body   []inputLine
+}
+
+// macroParam is a parameter of a macro, along with its default value.
+type macroParam struct {
+	name  string
+	value string
+}
+
+func preprocess(fsys fs.FS, file, s string) []inputLine {
+	p := &preprocessor{
+		fsys:   fsys,
+		macros: make(map[string]*macro),
+		exprs:  newExpressions(map[string]int32{}),
+		labels: make(map[string]bool),
+	}
+	p.process(splitInputLines(file, s), 0)
+	return p.out
+}
+
+// fail reports an error on a line, which is otherwise dropped.
+func (p *preprocessor) fail(l inputLine, err error) {
+	l.err = err
+	p.out = append(p.out, l)
+}
+
+func (p *preprocessor) process(lines []inputLine, depth int) {
+	for i := 0; i < len(lines); i++ {
+		l := lines[i]
+		source := splitSourceLine(l.text)
+		directive, args, _ := strings.Cut(source.statement, " ")
+		args = strings.TrimSpace(args)
+
+		switch directive {
+		case ".macro":
+			end, _ := blockEnd(lines, i, ".macro", ".endm")
+			if end == -1 {
+				p.fail(l, newDiagnostic(CodeSyntax, directive, ".macro without .endm"))
+				return
+			}
+			if err := p.define(args, lines[i+1:end]); err != nil {
+				p.fail(l, err)
+			}
+			i = end
+		case ".rept":
+			end, _ := blockEnd(lines, i, ".rept", ".endr")
+			if end == -1 {
+				p.fail(l, newDiagnostic(CodeSyntax, directive, ".rept without .endr"))
+				return
+			}
+			n, err := p.exprs.constant(args)
+			if err == nil && (n < 0 || n > 1<<16) {
+				err = newDiagnostic(CodeOutOfRange, args, "invalid repetition count: %d", n)
+			}
+			if err != nil {
+				p.fail(l, err)
+			} else {
+				for j := int64(0); j < n; j++ {
+					p.process(lines[i+1:end], depth)
+				}
+			}
+			i = end
+		case ".if", ".ifdef", ".ifndef":
+			end, elseIndex := blockEnd(lines, i, directive, ".endif")
+			if end == -1 {
+				p.fail(l, newDiagnostic(CodeSyntax, directive, "%s without .endif", directive))
+				return
+			}
+			cond, err := p.condition(directive, args)
+			if err != nil {
+				p.fail(l, err)
+				i = end
+				continue
+			}
+			then, otherwise := lines[i+1:end], []inputLine(nil)
+			if elseIndex != -1 {
+				then, otherwise = lines[i+1:elseIndex], lines[elseIndex+1:end]
+			}
+			if cond {
+				p.process(then, depth)
+			} else {
+				p.process(otherwise, depth)
+			}
+			i = end
+		case ".else", ".endif", ".endm", ".endr":
+			p.fail(l, newDiagnostic(CodeSyntax, directive, "unexpected %s", directive))
+		case ".include":
+			p.include(l, args, depth)
+		default:
+			if directive == ".equ" || directive == ".set" {
+				// The errors are reported by the parser
+				_ = p.exprs.define(args)
+			}
+			if source.label != "" {
+				p.labels[source.label] = true
+			}
+			m, exists := p.macros[directive]
+			if !exists {
+				p.out = append(p.out, l)
+				continue
+			}
+			if source.label != "" {
+				p.out = append(p.out, inputLine{file: l.file, line: l.line, text: source.label + ":", expandedFrom: l.expandedFrom})
+			}
+			if depth == maxExpansionDepth {
+				p.fail(l, newDiagnostic(CodeSyntax, directive, "too many nested expansions: %s", directive))
+				continue
+			}
+			expanded, err := p.expand(m, l, args)
+			if err != nil {
+				p.fail(l, err)
+				continue
+			}
+			p.process(expanded, depth+1)
+		}
+	}
+}
+
+// blockEnd returns the index of the directive closing the block opened at a
+// given line, or -1 if the block isn't closed, along with the index of the
+// .else of the block, or -1.
+func blockEnd(lines []inputLine, start int, open, end string) (int, int) {
+	isOpen := func(directive string) bool {
+		if end == ".endif" {
+			return directive == ".if" || directive == ".ifdef" || directive == ".ifndef"
+		}
+		return directive == open
+	}
+	level := 0
+	elseIndex := -1
+	for i := start + 1; i < len(lines); i++ {
+		directive, _, _ := strings.Cut(splitSourceLine(lines[i].text).statement, " ")
+		switch {
+		case isOpen(directive):
+			level++
+		case directive == end:
+			if level == 0 {
+				return i, elseIndex
+			}
+			level--
+		case directive == ".else" && end == ".endif" && level == 0 && elseIndex == -1:
+			elseIndex = i
+		}
+	}
+	return -1, -1
+}
+
+// define defines a macro: its name followed by its parameters, separated by
+// commas or spaces, with an optional default value such as n=1.
+func (p *preprocessor) define(args string, body []inputLine) error {
+	fields := strings.FieldsFunc(args, func(r rune) bool {
+		return r == ',' || r == ' '
+	})
+	if len(fields) == 0 || !isLabel(fields[0]) {
+		return newDiagnostic(CodeSyntax, args, "invalid macro name: %q", args)
+	}
+	m := &macro{body: body}
+	for _, param := range fields[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		if !isLabel(name) {
+			return newDiagnostic(CodeSyntax, name, "invalid macro parameter: %q", name)
+		}
+		m.params = append(m.params, macroParam{name: name, value: value})
+	}
+	p.macros[fields[0]] = m
+	return nil
+}
+
+// expand returns the body of a macro invoked at a given line, with its
+// parameters substituted by the arguments, either positional or named such as
+// n=2.
+func (p *preprocessor) expand(m *macro, invocation inputLine, args string) ([]inputLine, error) {
+	values := make(map[string]string, len(m.params))
+	for _, param := range m.params {
+		values[param.name] = param.value
+	}
+	if args != "" {
+		split, err := splitOperands(args)
+		if err != nil {
+			return nil, err
+		}
+		for i, arg := range split {
+			arg = strings.TrimSpace(arg)
+			if name, value, found := strings.Cut(arg, "="); found {
+				name = strings.TrimSpace(name)
+				if _, exists := values[name]; !exists {
+					return nil, newDiagnostic(CodeOperandCount, name, "unknown macro parameter: %s", name)
+				}
+				values[name] = strings.TrimSpace(value)
+				continue
+			}
+			if i >= len(m.params) {
+				return nil, newDiagnostic(CodeOperandCount, arg, "too many macro arguments: %s", args)
+			}
+			values[m.params[i].name] = arg
+		}
+	}
+
+	p.expansions++
+	from := append([]Location{{
+		File:   invocation.file,
+		Line:   invocation.line,
+		Column: splitSourceLine(invocation.text).column + 1,
+	}}, invocation.expandedFrom...)
+	expanded := make([]inputLine, 0, len(m.body))
+	for _, l := range m.body {
+		l.text = substitute(l.text, values, p.expansions)
+		l.expandedFrom = from
+		expanded = append(expanded, l)
+	}
+	return expanded, nil
+}
+
+// substitute replaces \param with the value of a parameter, \@ with the number
+// of the expansion, for unique labels, and removes \(), which separates a
+// parameter from the following characters.
+func substitute(text string, values map[string]string, expansion int) string {
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		if text[i] != '\\' {
+			sb.WriteByte(text[i])
+			continue
+		}
+		rest := text[i+1:]
+		switch {
+		case strings.HasPrefix(rest, "@"):
+			sb.WriteString(strconv.Itoa(expansion))
+			i++
+		case strings.HasPrefix(rest, "()"):
+			i += 2
+		default:
+			n := 0
+			for n < len(rest) && isSymbolChar(rest[n]) {
+				n++
+			}
+			value, exists := values[rest[:n]]
+			if n == 0 || !exists {
+				sb.WriteByte('\\')
+				continue
+			}
+			sb.WriteString(value)
+			i += n
+		}
+	}
+	return sb.String()
+}
+
+// condition evaluates the condition of a conditional block.
+func (p *preprocessor) condition(directive, args string) (bool, error) {
+	if directive == ".if" {
+		n, err := p.exprs.constant(args)
+		return n != 0, err
+	}
+	_, constant := p.exprs.constants[args]
+	defined := constant || p.labels[args]
+	return defined == (directive == ".ifdef"), nil
+}
+
+// include processes a file included by .include, whose path is relative to the
+// including file.
+func (p *preprocessor) include(l inputLine, args string, depth int) {
+	name, err := parseStringLiteral(args)
+	if err != nil {
+		p.fail(l, err)
+		return
+	}
+	if p.fsys == nil {
+		p.fail(l, newDiagnostic(CodeSyntax, args, "cannot include %s: no file system", name))
+		return
+	}
+	if depth == maxExpansionDepth {
+		p.fail(l, newDiagnostic(CodeSyntax, args, "too many nested inclusions: %s", name))
+		return
+	}
+	file := path.Join(path.Dir(l.file), name)
+	data, err := fs.ReadFile(p.fsys, file)
+	if err != nil {
+		p.fail(l, newDiagnostic(CodeSyntax, args, "cannot include %s: %v", name, err))
+		return
+	}
+	p.process(splitInputLines(file, string(data)), depth+1)
+}
+
+// ParseFS is Parse with a source file read from a file system, which resolves
+// the files it includes.
+func ParseFS(fsys fs.FS, name string) (Application, error) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return Application{}, fmt.Errorf("reading %s: %w", name, err)
+	}
+	return assembleLines(preprocess(fsys, name, string(data)))
+}
@@ -0,0 +1,158 @@
+package risc
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMacros(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{}, 0, map[int]int8{},
+		`.macro inc reg, n=1
+addi \reg, \reg, \n
+.endm
+.macro skip_if_zero reg
+beqz \reg, skip\@
+addi s2, s2, 1
+skip\@:
+.endm
+inc t0
+inc t0, 5
+inc n=10, reg=t1
+start: inc t2
+skip_if_zero zero
+skip_if_zero t0`, map[RegisterType]int32{T0: 6, T1: 10, T2: 1, S2: 1}, map[int]int8{})
+}
+
+func TestRept(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{}, 0, map[int]int8{},
+		`.equ N, 3
+.set i, 1
+.rept N
+addi t0, t0, i
+.set i, i * 2
+.endr
+.rept 0
+addi t1, t1, 1
+.endr`, map[RegisterType]int32{T0: 7, T1: 0}, map[int]int8{})
+}
+
+func TestConditionalAssembly(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{}, 0, map[int]int8{},
+		`.equ DEBUG, 1
+.ifdef DEBUG
+addi t0, t0, 1
+.if DEBUG - 1
+addi t0, t0, 10
+.else
+addi t0, t0, 100
+.endif
+.else
+addi t0, t0, 1000
+.endif
+.ifndef RELEASE
+addi t1, t1, 1
+.endif
+start:
+.ifdef start
+addi t2, t2, 1
+.endif`, map[RegisterType]int32{T0: 101, T1: 1, T2: 1}, map[int]int8{})
+}
+
+func TestConditionComparisons(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{}, 0, map[int]int8{},
+		`.equ N, 4
+.if N > 3
+addi t0, t0, 1
+.endif
+.if N < 3 || N >= 5
+addi t0, t0, 10
+.endif
+.if N == 4 && N != 5 && N <= 4
+addi t0, t0, 100
+.endif
+.if N <> 4
+addi t0, t0, 1000
+.endif
+li t1, N > 3
+li t2, (N == 4) + (N || 0)`, map[RegisterType]int32{T0: 101, T1: -1, T2: 0}, map[int]int8{})
+}
+
+func TestInclude(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/main.s":       {Data: []byte(".include \"lib/macros.s\"\nprologue\naddi t1, zero, VALUE")},
+		"src/lib/macros.s": {Data: []byte(".equ VALUE, 42\n.macro prologue\naddi t0, zero, 1\n.endm")},
+	}
+	app, err := ParseFS(fsys, "src/main.s")
+	require.NoError(t, err)
+	r := NewRunner(app, 0)
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(1), r.Ctx.Registers[T0])
+	assert.Equal(t, int32(42), r.Ctx.Registers[T1])
+
+	_, err = ParseFS(fsys, "missing.s")
+	assert.Error(t, err)
+}
+
+func TestExpandedLinePositions(t *testing.T) {
+	fsys := fstest.MapFS{
+		"main.s": {Data: []byte("nop\n.include \"lib.s\"\nbad_add t9\n.include \"missing.s\"")},
+		"lib.s":  {Data: []byte(".macro bad_add reg\n  nop\n  add \\reg, t0, t1\n.endm")},
+	}
+	_, err := ParseFS(fsys, "main.s")
+	var errs ParseErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 2)
+	assert.Equal(t, "lib.s", errs[0].File)
+	assert.Equal(t, 3, errs[0].Line)
+	assert.Equal(t, 7, errs[0].Column)
+	assert.Equal(t, CodeInvalidRegister, errs[0].Code)
+	// The invocation site
+	assert.Equal(t, []Location{{File: "main.s", Line: 3, Column: 1}}, errs[0].ExpandedFrom)
+	assert.Equal(t, "lib.s:3:7: error: unknown register: t9 [invalid-register] (expanded from main.s:3:1)", errs[0].Error())
+	assert.Equal(t, "main.s", errs[1].File)
+	assert.Equal(t, 4, errs[1].Line)
+	assert.Empty(t, errs[1].ExpandedFrom)
+}
+
+func TestNestedExpansionPositions(t *testing.T) {
+	_, err := ParseFile("main.s", `.macro inner reg
+  add \reg, t0, t1
+.endm
+.macro outer reg
+  nop
+  inner \reg
+.endm
+outer a0
+  outer t9`)
+	var errs ParseErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 1)
+	assert.Equal(t, 2, errs[0].Line)
+	assert.Equal(t, []Location{
+		{File: "main.s", Line: 6, Column: 3},
+		{File: "main.s", Line: 9, Column: 3},
+	}, errs[0].ExpandedFrom)
+}
+
+func TestPreprocessorErrors(t *testing.T) {
+	for _, s := range []string{
+		".macro m\nnop",
+		".endm",
+		".if 1\nnop",
+		".else",
+		".rept 2\nnop",
+		".rept label\n.endr\nlabel:",
+		".if UNDEFINED\n.endif",
+		".macro m\nm\n.endm\nm",
+		".macro m a\n.endm\nm 1, 2",
+		".macro m a\n.endm\nm b=1",
+		".include \"file.s\"",
+	} {
+		_, err := Parse(s)
+		assert.Error(t, err, s)
+	}
+}
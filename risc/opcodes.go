@@ -2,6 +2,7 @@ package risc
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/teivah/majorana/common/bytes"
 	"github.com/teivah/majorana/common/option"
@@ -16,6 +17,14 @@ type InstructionRunnerPc struct {
 	Forwarder       chan<- int32
 	Receiver        <-chan int32
 	ForwardRegister RegisterType
+
+	// ProgramOrder is the position of the instruction in the decoded stream.
+	// Unlike the sequence ID, it differs between two iterations of a loop.
+	ProgramOrder int64
+}
+
+func (r InstructionRunnerPc) String() string {
+	return fmt.Sprintf("%#x: %s", r.Pc, r.Runner)
 }
 
 type Forward struct {
@@ -27,29 +36,54 @@ func registerRead(ctx *Context, forward Forward, reg RegisterType, sequenceID in
 	if reg == forward.Register {
 		return forward.Value
 	}
+	return int32(rawRegisterRead(ctx, reg, sequenceID))
+}
 
+// fregisterRead returns the raw bits of a floating-point register. Forwarding
+// is restricted to the integer registers.
+func fregisterRead(ctx *Context, reg RegisterType, sequenceID int32) uint64 {
+	return uint64(rawRegisterRead(ctx, reg, sequenceID))
+}
+
+func rawRegisterRead(ctx *Context, reg RegisterType, sequenceID int32) int64 {
 	if ctx.rat {
-		if sequenceID == 0 {
-			if v, exists := ctx.transactionRAT.Read(reg); exists {
-				return v.value
-			}
-		} else {
-			// If a sequence ID is provided, we make sure not to read a register
-			// value written by an instruction following the current instruction
-			if v, exists := ctx.transactionRAT.Find(reg, func(v transactionUnit) bool {
-				return v.sequenceID <= sequenceID
-			}); exists {
-				return v.value
-			}
+		// The value of the youngest instruction is read, as the instructions
+		// don't necessarily complete in order. If a sequence ID is provided, we
+		// make sure not to read a register value written by an instruction
+		// following the current instruction, even if already committed.
+		preceding := func(v transactionUnit) bool {
+			return sequenceID == 0 || v.sequenceID <= sequenceID
+		}
+		if v, exists := ctx.transactionRAT.Max(reg, preceding, youngerTransaction); exists {
+			return v.value
+		}
+		if v, exists := ctx.committedRAT.Max(reg, preceding, youngerTransaction); exists {
+			return v.value
 		}
 		v, _ := ctx.committedRAT.Read(reg)
-		return v
+		return v.value
 	}
 
 	if v, exists := ctx.Transaction[reg]; exists {
 		return v.value
 	}
-	return ctx.Registers[reg]
+	if reg.IsFloat() {
+		return int64(ctx.FRegisters[reg])
+	}
+	return int64(ctx.Registers[reg])
+}
+
+// jumpAddress returns the destination of a branch or a jump: the address of
+// the label if the instruction refers to one, pc+offset otherwise.
+func jumpAddress(labels map[string]int32, label string, pc, offset int32) (int32, error) {
+	if label == "" {
+		return pc + offset, nil
+	}
+	addr, ok := labels[label]
+	if !ok {
+		return 0, fmt.Errorf("label %s does not exist", label)
+	}
+	return addr, nil
 }
 
 type InstructionRunner interface {
@@ -60,6 +94,9 @@ type InstructionRunner interface {
 	Forward(forward Forward)
 	MemoryRead(ctx *Context, sequenceID int32) []int32
 	MemoryWrite(ctx *Context, sequenceID int32) []int32
+	// String returns the instruction in canonical assembly, which parses back
+	// to the same instruction.
+	String() string
 }
 
 type add struct {
@@ -84,6 +121,10 @@ func (op *add) InstructionType() InstructionType {
 	return Add
 }
 
+func (op *add) String() string {
+	return fmt.Sprintf("add %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
 func (op *add) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -125,6 +166,10 @@ func (op *addi) InstructionType() InstructionType {
 	return Addi
 }
 
+func (op *addi) String() string {
+	return fmt.Sprintf("addi %s, %s, %d", registerName(op.rd), registerName(op.rs), op.imm)
+}
+
 func (op *addi) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs}
 }
@@ -145,6 +190,94 @@ func (op *addi) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
+// amo is an atomic memory operation: it loads a word into rd and stores the
+// result of the operation between the loaded word and rs2.
+type amo struct {
+	instructionType InstructionType
+	rd              RegisterType
+	rs1             RegisterType
+	rs2             RegisterType
+	forward         Forward
+}
+
+func (op *amo) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
+	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
+	if e := checkAccess(ctx, pc, rs1, 4, true); e != nil {
+		return Execution{Exception: e}, nil
+	}
+	n := bytes.I32FromBytes(memory[0], memory[1], memory[2], memory[3])
+
+	var result int32
+	switch op.instructionType {
+	case AmoswapW:
+		result = rs2
+	case AmoaddW:
+		result = n + rs2
+	case AmoandW:
+		result = n & rs2
+	case AmoorW:
+		result = n | rs2
+	case AmoxorW:
+		result = n ^ rs2
+	case AmominW:
+		result = min(n, rs2)
+	case AmomaxW:
+		result = max(n, rs2)
+	case AmominuW:
+		result = int32(min(uint32(n), uint32(rs2)))
+	case AmomaxuW:
+		result = int32(max(uint32(n), uint32(rs2)))
+	default:
+		panic(op.instructionType)
+	}
+
+	b := bytes.BytesFromLowBits(result)
+	register, value := IsRegisterChange(op.rd, n)
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+		MemoryChange:   true,
+		MemoryChanges: map[int32]int8{
+			rs1:     b[0],
+			rs1 + 1: b[1],
+			rs1 + 2: b[2],
+			rs1 + 3: b[3],
+		},
+	}, nil
+}
+
+func (op *amo) InstructionType() InstructionType {
+	return op.instructionType
+}
+
+func (op *amo) String() string {
+	return fmt.Sprintf("%s %s, %s, (%s)", mnemonic(amoInstructionTypes, op.instructionType), registerName(op.rd), registerName(op.rs2), registerName(op.rs1))
+}
+
+func (op *amo) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs1, op.rs2}
+}
+
+func (op *amo) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *amo) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *amo) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
+	return accessAddrs(ctx, rs1, 4, true)
+}
+
+func (op *amo) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
+	return accessAddrs(ctx, rs1, 4, true)
+}
+
 type and struct {
 	rd      RegisterType
 	rs1     RegisterType
@@ -167,6 +300,10 @@ func (op *and) InstructionType() InstructionType {
 	return And
 }
 
+func (op *and) String() string {
+	return fmt.Sprintf("and %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
 func (op *and) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -208,6 +345,10 @@ func (op *andi) InstructionType() InstructionType {
 	return Andi
 }
 
+func (op *andi) String() string {
+	return fmt.Sprintf("andi %s, %s, %d", registerName(op.rd), registerName(op.rs), op.imm)
+}
+
 func (op *andi) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs}
 }
@@ -246,6 +387,10 @@ func (op *auipc) InstructionType() InstructionType {
 	return Auipc
 }
 
+func (op *auipc) String() string {
+	return fmt.Sprintf("auipc %s, %#x", registerName(op.rd), uint32(op.imm)&0xfffff)
+}
+
 func (op *auipc) ReadRegisters() []RegisterType {
 	return nil
 }
@@ -269,6 +414,7 @@ type beq struct {
 	rs1     RegisterType
 	rs2     RegisterType
 	label   string
+	offset  int32
 	forward Forward
 }
 
@@ -276,9 +422,9 @@ func (op *beq) Run(ctx *Context, labels map[string]int32, pc int32, memory []int
 	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
 	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
 	if rs1 == rs2 {
-		addr, ok := labels[op.label]
-		if !ok {
-			return Execution{}, fmt.Errorf("label %s does not exist", op.label)
+		addr, err := jumpAddress(labels, op.label, pc, op.offset)
+		if err != nil {
+			return Execution{}, err
 		}
 		return Execution{
 			NextPc:   addr,
@@ -292,6 +438,10 @@ func (op *beq) InstructionType() InstructionType {
 	return Beq
 }
 
+func (op *beq) String() string {
+	return fmt.Sprintf("beq %s, %s, %s", registerName(op.rs1), registerName(op.rs2), formatTarget(op.label, op.offset))
+}
+
 func (op *beq) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -315,15 +465,16 @@ func (op *beq) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 type beqz struct {
 	rs      RegisterType
 	label   string
+	offset  int32
 	forward Forward
 }
 
 func (op *beqz) Run(ctx *Context, labels map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
 	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
 	if rs == 0 {
-		addr, ok := labels[op.label]
-		if !ok {
-			return Execution{}, fmt.Errorf("label %s does not exist", op.label)
+		addr, err := jumpAddress(labels, op.label, pc, op.offset)
+		if err != nil {
+			return Execution{}, err
 		}
 		return Execution{
 			NextPc:   addr,
@@ -337,6 +488,10 @@ func (op *beqz) InstructionType() InstructionType {
 	return Beqz
 }
 
+func (op *beqz) String() string {
+	return fmt.Sprintf("beqz %s, %s", registerName(op.rs), formatTarget(op.label, op.offset))
+}
+
 func (op *beqz) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs}
 }
@@ -361,6 +516,7 @@ type bge struct {
 	rs1     RegisterType
 	rs2     RegisterType
 	label   string
+	offset  int32
 	forward Forward
 }
 
@@ -368,9 +524,9 @@ func (op *bge) Run(ctx *Context, labels map[string]int32, pc int32, memory []int
 	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
 	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
 	if rs1 >= rs2 {
-		addr, ok := labels[op.label]
-		if !ok {
-			return Execution{}, fmt.Errorf("label %s does not exist", op.label)
+		addr, err := jumpAddress(labels, op.label, pc, op.offset)
+		if err != nil {
+			return Execution{}, err
 		}
 		if ctx.Debug {
 			fmt.Printf("\t\tRun: bge %d >= %d true %d\n", rs1, rs2, addr/4)
@@ -390,6 +546,10 @@ func (op *bge) InstructionType() InstructionType {
 	return Bge
 }
 
+func (op *bge) String() string {
+	return fmt.Sprintf("bge %s, %s, %s", registerName(op.rs1), registerName(op.rs2), formatTarget(op.label, op.offset))
+}
+
 func (op *bge) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -414,16 +574,17 @@ type bgeu struct {
 	rs1     RegisterType
 	rs2     RegisterType
 	label   string
+	offset  int32
 	forward Forward
 }
 
 func (op *bgeu) Run(ctx *Context, labels map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
 	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
 	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
-	if rs1 >= rs2 {
-		addr, ok := labels[op.label]
-		if !ok {
-			return Execution{}, fmt.Errorf("label %s does not exist", op.label)
+	if uint32(rs1) >= uint32(rs2) {
+		addr, err := jumpAddress(labels, op.label, pc, op.offset)
+		if err != nil {
+			return Execution{}, err
 		}
 		return Execution{
 			NextPc:   addr,
@@ -437,6 +598,10 @@ func (op *bgeu) InstructionType() InstructionType {
 	return Bgeu
 }
 
+func (op *bgeu) String() string {
+	return fmt.Sprintf("bgeu %s, %s, %s", registerName(op.rs1), registerName(op.rs2), formatTarget(op.label, op.offset))
+}
+
 func (op *bgeu) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -461,6 +626,7 @@ type ble struct {
 	rs1     RegisterType
 	rs2     RegisterType
 	label   string
+	offset  int32
 	forward Forward
 }
 
@@ -468,9 +634,9 @@ func (op *ble) Run(ctx *Context, labels map[string]int32, pc int32, memory []int
 	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
 	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
 	if rs1 <= rs2 {
-		addr, ok := labels[op.label]
-		if !ok {
-			return Execution{}, fmt.Errorf("label %s does not exist", op.label)
+		addr, err := jumpAddress(labels, op.label, pc, op.offset)
+		if err != nil {
+			return Execution{}, err
 		}
 		return Execution{
 			NextPc:   addr,
@@ -484,6 +650,10 @@ func (op *ble) InstructionType() InstructionType {
 	return Ble
 }
 
+func (op *ble) String() string {
+	return fmt.Sprintf("ble %s, %s, %s", registerName(op.rs1), registerName(op.rs2), formatTarget(op.label, op.offset))
+}
+
 func (op *ble) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -508,6 +678,7 @@ type blt struct {
 	rs1     RegisterType
 	rs2     RegisterType
 	label   string
+	offset  int32
 	forward Forward
 }
 
@@ -515,9 +686,9 @@ func (op *blt) Run(ctx *Context, labels map[string]int32, pc int32, memory []int
 	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
 	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
 	if rs1 < rs2 {
-		addr, ok := labels[op.label]
-		if !ok {
-			return Execution{}, fmt.Errorf("label %s does not exist", op.label)
+		addr, err := jumpAddress(labels, op.label, pc, op.offset)
+		if err != nil {
+			return Execution{}, err
 		}
 		return Execution{
 			NextPc:   addr,
@@ -531,6 +702,10 @@ func (op *blt) InstructionType() InstructionType {
 	return Blt
 }
 
+func (op *blt) String() string {
+	return fmt.Sprintf("blt %s, %s, %s", registerName(op.rs1), registerName(op.rs2), formatTarget(op.label, op.offset))
+}
+
 func (op *blt) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -555,16 +730,17 @@ type bltu struct {
 	rs1     RegisterType
 	rs2     RegisterType
 	label   string
+	offset  int32
 	forward Forward
 }
 
 func (op *bltu) Run(ctx *Context, labels map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
 	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
 	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
-	if rs1 < rs2 {
-		addr, ok := labels[op.label]
-		if !ok {
-			return Execution{}, fmt.Errorf("label %s does not exist", op.label)
+	if uint32(rs1) < uint32(rs2) {
+		addr, err := jumpAddress(labels, op.label, pc, op.offset)
+		if err != nil {
+			return Execution{}, err
 		}
 		return Execution{
 			NextPc:   addr,
@@ -578,6 +754,10 @@ func (op *bltu) InstructionType() InstructionType {
 	return Bltu
 }
 
+func (op *bltu) String() string {
+	return fmt.Sprintf("bltu %s, %s, %s", registerName(op.rs1), registerName(op.rs2), formatTarget(op.label, op.offset))
+}
+
 func (op *bltu) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -602,6 +782,7 @@ type bne struct {
 	rs1     RegisterType
 	rs2     RegisterType
 	label   string
+	offset  int32
 	forward Forward
 }
 
@@ -609,9 +790,9 @@ func (op *bne) Run(ctx *Context, labels map[string]int32, pc int32, memory []int
 	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
 	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
 	if rs1 != rs2 {
-		addr, ok := labels[op.label]
-		if !ok {
-			return Execution{}, fmt.Errorf("label %s does not exist", op.label)
+		addr, err := jumpAddress(labels, op.label, pc, op.offset)
+		if err != nil {
+			return Execution{}, err
 		}
 		return Execution{
 			NextPc:   addr,
@@ -625,6 +806,10 @@ func (op *bne) InstructionType() InstructionType {
 	return Bne
 }
 
+func (op *bne) String() string {
+	return fmt.Sprintf("bne %s, %s, %s", registerName(op.rs1), registerName(op.rs2), formatTarget(op.label, op.offset))
+}
+
 func (op *bne) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -648,15 +833,16 @@ func (op *bne) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 type bnez struct {
 	rs      RegisterType
 	label   string
+	offset  int32
 	forward Forward
 }
 
 func (op *bnez) Run(ctx *Context, labels map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
 	rs1 := registerRead(ctx, op.forward, op.rs, sequenceID)
 	if rs1 != 0 {
-		addr, ok := labels[op.label]
-		if !ok {
-			return Execution{}, fmt.Errorf("label %s does not exist", op.label)
+		addr, err := jumpAddress(labels, op.label, pc, op.offset)
+		if err != nil {
+			return Execution{}, err
 		}
 		return Execution{
 			NextPc:   addr,
@@ -670,6 +856,10 @@ func (op *bnez) InstructionType() InstructionType {
 	return Bnez
 }
 
+func (op *bnez) String() string {
+	return fmt.Sprintf("bnez %s, %s", registerName(op.rs), formatTarget(op.label, op.offset))
+}
+
 func (op *bnez) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs}
 }
@@ -690,20 +880,49 @@ func (op *bnez) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-type div struct {
-	rd      RegisterType
-	rs1     RegisterType
-	rs2     RegisterType
+type csr struct {
+	instructionType InstructionType
+	rd              RegisterType
+	rs              RegisterType
+	// uimm is the 5-bit source of the immediate forms.
+	uimm    int32
+	csr     CSR
 	forward Forward
 }
 
-func (op *div) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
-	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
-	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
-	if rs2 == 0 {
-		return Execution{}, fmt.Errorf("division by zero")
+// Run raises an illegal instruction exception if the CSR doesn't exist or is
+// read-only and written; as the instruction bits aren't known, mtval is zero.
+func (op *csr) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	old, err := ctx.ReadCSR(op.csr)
+	if err != nil {
+		return raise(CauseIllegalInstruction, pc, 0), nil
+	}
+
+	var src int32
+	// The set and clear forms don't write the CSR if the source is zero
+	write := true
+	switch op.instructionType {
+	case Csrrw, Csrrs, Csrrc:
+		src = registerRead(ctx, op.forward, op.rs, sequenceID)
+		write = op.instructionType == Csrrw || op.rs != Zero
+	default:
+		src = op.uimm
+		write = op.instructionType == Csrrwi || op.uimm != 0
+	}
+	if write {
+		value := src
+		switch op.instructionType {
+		case Csrrs, Csrrsi:
+			value = old | src
+		case Csrrc, Csrrci:
+			value = old &^ src
+		}
+		if err := ctx.WriteCSR(op.csr, value); err != nil {
+			return raise(CauseIllegalInstruction, pc, 0), nil
+		}
 	}
-	register, value := IsRegisterChange(op.rd, rs1/rs2)
+
+	register, value := IsRegisterChange(op.rd, old)
 	return Execution{
 		RegisterChange: true,
 		Register:       register,
@@ -711,234 +930,899 @@ func (op *div) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, se
 	}, nil
 }
 
-func (op *div) InstructionType() InstructionType {
-	return Div
-}
-
-func (op *div) ReadRegisters() []RegisterType {
-	return []RegisterType{op.rs1, op.rs2}
-}
-
-func (op *div) WriteRegisters() []RegisterType {
-	return []RegisterType{op.rd}
-}
-
-func (op *div) Forward(forward Forward) {
-	op.forward = forward
-}
-
-func (op *div) MemoryRead(ctx *Context, sequenceID int32) []int32 {
-	return nil
-}
-
-func (op *div) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
-	return nil
-}
-
-type j struct {
-	label string
+func (op *csr) InstructionType() InstructionType {
+	return op.instructionType
 }
 
-func (op *j) Run(ctx *Context, labels map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
-	addr, ok := labels[op.label]
-	if !ok {
-		return Execution{}, fmt.Errorf("label %s does not exist", op.label)
+func (op *csr) String() string {
+	name := mnemonic(csrInstructions, op.instructionType)
+	if strings.HasSuffix(name, "i") {
+		return fmt.Sprintf("%s %s, %v, %d", name, registerName(op.rd), op.csr, op.uimm)
 	}
-	return Execution{
-		NextPc:   addr,
-		PcChange: true,
-	}, nil
+	return fmt.Sprintf("%s %s, %v, %s", name, registerName(op.rd), op.csr, registerName(op.rs))
 }
 
-func (op *j) InstructionType() InstructionType {
-	return J
-}
-
-func (op *j) ReadRegisters() []RegisterType {
+func (op *csr) ReadRegisters() []RegisterType {
+	switch op.instructionType {
+	case Csrrw, Csrrs, Csrrc:
+		return []RegisterType{op.rs}
+	}
 	return nil
 }
 
-func (op *j) WriteRegisters() []RegisterType {
-	return nil
+func (op *csr) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
 }
 
-func (op *j) Forward(forward Forward) {
+func (op *csr) Forward(forward Forward) {
+	op.forward = forward
 }
 
-func (op *j) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+func (op *csr) MemoryRead(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-func (op *j) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+func (op *csr) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-type jal struct {
-	label   string
+type div struct {
 	rd      RegisterType
+	rs1     RegisterType
+	rs2     RegisterType
 	forward Forward
 }
 
-func (op *jal) Run(ctx *Context, labels map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
-	addr, ok := labels[op.label]
-	if !ok {
-		return Execution{}, fmt.Errorf("label %s does not exist", op.label)
+func (op *div) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
+	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
+	// Division by zero sets all the bits of the quotient. The overflow case
+	// (-2^31 / -1) doesn't need any special treatment as Go already returns the
+	// dividend.
+	result := int32(-1)
+	if rs2 != 0 {
+		result = rs1 / rs2
 	}
-	// TODO Shouldn't be a direct write
-	ctx.Registers[Ra] = pc
-	register, value := IsRegisterChange(op.rd, pc+4)
+	register, value := IsRegisterChange(op.rd, result)
 	return Execution{
 		RegisterChange: true,
 		Register:       register,
 		RegisterValue:  value,
-		NextPc:         addr,
-		PcChange:       true,
 	}, nil
 }
 
-func (op *jal) InstructionType() InstructionType {
-	return Jal
+func (op *div) InstructionType() InstructionType {
+	return Div
 }
 
-func (op *jal) ReadRegisters() []RegisterType {
-	return nil
+func (op *div) String() string {
+	return fmt.Sprintf("div %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
 }
 
-func (op *jal) WriteRegisters() []RegisterType {
+func (op *div) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs1, op.rs2}
+}
+
+func (op *div) WriteRegisters() []RegisterType {
 	return []RegisterType{op.rd}
 }
 
-func (op *jal) Forward(forward Forward) {
+func (op *div) Forward(forward Forward) {
 	op.forward = forward
 }
 
-func (op *jal) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+func (op *div) MemoryRead(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-func (op *jal) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+func (op *div) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-type jalr struct {
+type divu struct {
 	rd      RegisterType
-	rs      RegisterType
-	imm     int32
+	rs1     RegisterType
+	rs2     RegisterType
 	forward Forward
 }
 
-func (op *jalr) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
-	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
-	register, value := IsRegisterChange(op.rd, pc+4)
+func (op *divu) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
+	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
+	// Division by zero sets all the bits of the quotient
+	result := int32(-1)
+	if rs2 != 0 {
+		result = int32(uint32(rs1) / uint32(rs2))
+	}
+	register, value := IsRegisterChange(op.rd, result)
 	return Execution{
 		RegisterChange: true,
 		Register:       register,
 		RegisterValue:  value,
-		NextPc:         rs + op.imm,
-		PcChange:       true,
 	}, nil
 }
 
-func (op *jalr) InstructionType() InstructionType {
-	return Jalr
+func (op *divu) InstructionType() InstructionType {
+	return Divu
 }
 
-func (op *jalr) ReadRegisters() []RegisterType {
-	return []RegisterType{op.rs}
+func (op *divu) String() string {
+	return fmt.Sprintf("divu %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
 }
 
-func (op *jalr) WriteRegisters() []RegisterType {
+func (op *divu) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs1, op.rs2}
+}
+
+func (op *divu) WriteRegisters() []RegisterType {
 	return []RegisterType{op.rd}
 }
 
-func (op *jalr) Forward(forward Forward) {
+func (op *divu) Forward(forward Forward) {
 	op.forward = forward
 }
 
-func (op *jalr) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+func (op *divu) MemoryRead(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-func (op *jalr) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+func (op *divu) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-type lui struct {
-	rd  RegisterType
-	imm int32
+// ebreak hands control to the environment, as a jump to the next instruction.
+type ebreak struct{}
+
+// Run raises a breakpoint exception if a trap handler is installed and hands
+// the breakpoint to the environment otherwise.
+func (op *ebreak) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	if ctx.Mtvec != 0 {
+		return raise(CauseBreakpoint, pc, pc), nil
+	}
+	if ctx.Env == nil {
+		return Execution{}, fmt.Errorf("ebreak at pc %d: no environment", pc)
+	}
+	exe, err := ctx.Env.Breakpoint(ctx, pc)
+	if err != nil {
+		return Execution{}, err
+	}
+	return trapReturn(exe, pc), nil
 }
 
-func (op *lui) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
-	register, value := IsRegisterChange(op.rd, op.imm<<12)
-	return Execution{
-		RegisterChange: true,
-		Register:       register,
-		RegisterValue:  value,
-	}, nil
+func (op *ebreak) InstructionType() InstructionType {
+	return Ebreak
 }
 
-func (op *lui) InstructionType() InstructionType {
-	return Lui
+func (op *ebreak) String() string {
+	return "ebreak"
 }
 
-func (op *lui) ReadRegisters() []RegisterType {
+func (op *ebreak) ReadRegisters() []RegisterType {
 	return nil
 }
 
-func (op *lui) WriteRegisters() []RegisterType {
-	return []RegisterType{op.rd}
+func (op *ebreak) WriteRegisters() []RegisterType {
+	return nil
 }
 
-func (op *lui) Forward(forward Forward) {
+func (op *ebreak) Forward(forward Forward) {
 }
 
-func (op *lui) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+func (op *ebreak) MemoryRead(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-func (op *lui) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+func (op *ebreak) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-type lb struct {
-	rd      RegisterType
-	offset  int32
-	rs      RegisterType
+// ecall requests a system call from the environment, as a jump to the next
+// instruction.
+type ecall struct {
 	forward Forward
 }
 
-func (op *lb) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
-	n := memory[0]
-	register, value := IsRegisterChange(op.rd, int32(n))
-	return Execution{
-		RegisterChange: true,
-		Register:       register,
-		RegisterValue:  value,
-	}, nil
+func (op *ecall) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	if ctx.Env == nil {
+		return Execution{}, fmt.Errorf("ecall at pc %d: no environment", pc)
+	}
+	exe, err := ctx.Env.Syscall(ctx, op.syscall(ctx, sequenceID), memory)
+	if err != nil {
+		return Execution{}, err
+	}
+	return trapReturn(exe, pc), nil
 }
 
-func (op *lb) InstructionType() InstructionType {
-	return Lb
+func (op *ecall) syscall(ctx *Context, sequenceID int32) Syscall {
+	call := Syscall{Number: registerRead(ctx, op.forward, A7, sequenceID)}
+	for i, register := range []RegisterType{A0, A1, A2, A3, A4, A5} {
+		call.Args[i] = registerRead(ctx, op.forward, register, sequenceID)
+	}
+	return call
 }
 
-func (op *lb) ReadRegisters() []RegisterType {
-	return []RegisterType{op.rs}
+func (op *ecall) InstructionType() InstructionType {
+	return Ecall
 }
 
-func (op *lb) WriteRegisters() []RegisterType {
-	return []RegisterType{op.rd}
+func (op *ecall) String() string {
+	return "ecall"
 }
 
-func (op *lb) Forward(forward Forward) {
+func (op *ecall) ReadRegisters() []RegisterType {
+	return []RegisterType{A7, A0, A1, A2, A3, A4, A5}
+}
+
+func (op *ecall) WriteRegisters() []RegisterType {
+	return []RegisterType{A0}
+}
+
+func (op *ecall) Forward(forward Forward) {
 	op.forward = forward
 }
 
-func (op *lb) MemoryRead(ctx *Context, sequenceID int32) []int32 {
-	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
-	return []int32{rs + op.offset}
+func (op *ecall) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	if ctx.Env == nil {
+		return nil
+	}
+	return ctx.Env.MemoryRead(ctx, op.syscall(ctx, sequenceID))
 }
 
-func (op *lb) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+func (op *ecall) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+// trapReturn resumes the execution at the next instruction after a trap
+// handled by the environment, unless the environment jumps elsewhere.
+func trapReturn(exe Execution, pc int32) Execution {
+	if !exe.PcChange {
+		exe.PcChange = true
+		exe.NextPc = pc + 4
+	}
+	return exe
+}
+
+// fence orders the memory accesses. It has nothing to do itself: the processors
+// complete the previous instructions before executing the following ones.
+type fence struct{}
+
+func (op *fence) Run(ctx *Context, _ map[string]int32, _ int32, memory []int8, sequenceID int32) (Execution, error) {
+	return Execution{}, nil
+}
+
+func (op *fence) InstructionType() InstructionType {
+	return Fence
+}
+
+func (op *fence) String() string {
+	return "fence"
+}
+
+func (op *fence) ReadRegisters() []RegisterType {
+	return nil
+}
+
+func (op *fence) WriteRegisters() []RegisterType {
+	return nil
+}
+
+func (op *fence) Forward(forward Forward) {
+}
+
+func (op *fence) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+func (op *fence) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+// fload is flw or fld.
+type fload struct {
+	instructionType InstructionType
+	rd              RegisterType
+	offset          int32
+	rs              RegisterType
+	forward         Forward
+}
+
+func (op *fload) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	if e := checkAccess(ctx, pc, rs+op.offset, op.size(), false); e != nil {
+		return Execution{Exception: e}, nil
+	}
+	var value uint64
+	if op.instructionType == Fld {
+		low := uint32(bytes.I32FromBytes(memory[0], memory[1], memory[2], memory[3]))
+		high := uint32(bytes.I32FromBytes(memory[4], memory[5], memory[6], memory[7]))
+		value = uint64(high)<<32 | uint64(low)
+	} else {
+		value = single.box(uint64(uint32(bytes.I32FromBytes(memory[0], memory[1], memory[2], memory[3]))))
+	}
+	if ctx.Debug {
+		fmt.Printf("\t\tRun: %s %s %#x\n", op.instructionType, op.rd, value)
+	}
+	return Execution{
+		RegisterChange: true,
+		Register:       op.rd,
+		FRegisterValue: value,
+	}, nil
+}
+
+func (op *fload) InstructionType() InstructionType {
+	return op.instructionType
+}
+
+func (op *fload) String() string {
+	name := "flw"
+	if op.instructionType == Fld {
+		name = "fld"
+	}
+	return fmt.Sprintf("%s %s, %d(%s)", name, registerName(op.rd), op.offset, registerName(op.rs))
+}
+
+func (op *fload) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs}
+}
+
+func (op *fload) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *fload) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *fload) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	return accessAddrs(ctx, rs+op.offset, op.size(), false)
+}
+
+func (op *fload) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+// size returns the number of bytes loaded.
+func (op *fload) size() int32 {
+	if op.instructionType == Fld {
+		return 8
+	}
+	return 4
+}
+
+// fop is a floating-point instruction other than a load or a store. Depending
+// on the instruction, rd or rs1 is an integer register.
+type fop struct {
+	instructionType InstructionType
+	rd              RegisterType
+	rs1             RegisterType
+	rs2             RegisterType
+	rs3             RegisterType
+	rm              int32
+	forward         Forward
+}
+
+func (op *fop) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rm, err := ctx.roundingMode(op.rm)
+	if err != nil {
+		// A reserved rounding mode makes the instruction illegal
+		word, _ := encodeFop(op)
+		return raise(CauseIllegalInstruction, pc, int32(word)), nil
+	}
+
+	f := op.format()
+	read := func(reg RegisterType) uint64 {
+		return f.unbox(fregisterRead(ctx, reg, sequenceID))
+	}
+	var (
+		// result is the floating-point result, in the to format
+		result uint64
+		to     = f
+		// value is the result if rd is an integer register
+		value   int32
+		integer bool
+		flags   int
+	)
+	switch op.instructionType {
+	case FaddS, FaddD:
+		result, flags = f.add(read(op.rs1), read(op.rs2), rm)
+	case FsubS, FsubD:
+		result, flags = f.sub(read(op.rs1), read(op.rs2), rm)
+	case FmulS, FmulD:
+		result, flags = f.mul(read(op.rs1), read(op.rs2), rm)
+	case FdivS, FdivD:
+		result, flags = f.div(read(op.rs1), read(op.rs2), rm)
+	case FsqrtS, FsqrtD:
+		result, flags = f.sqrt(read(op.rs1), rm)
+	case FmaddS, FmaddD:
+		result, flags = f.fma(read(op.rs1), read(op.rs2), read(op.rs3), false, false, rm)
+	case FmsubS, FmsubD:
+		result, flags = f.fma(read(op.rs1), read(op.rs2), read(op.rs3), false, true, rm)
+	case FnmsubS, FnmsubD:
+		result, flags = f.fma(read(op.rs1), read(op.rs2), read(op.rs3), true, false, rm)
+	case FnmaddS, FnmaddD:
+		result, flags = f.fma(read(op.rs1), read(op.rs2), read(op.rs3), true, true, rm)
+	case FminS, FminD:
+		result, flags = f.minMax(read(op.rs1), read(op.rs2), false)
+	case FmaxS, FmaxD:
+		result, flags = f.minMax(read(op.rs1), read(op.rs2), true)
+	case FsgnjS, FsgnjD, FsgnjnS, FsgnjnD, FsgnjxS, FsgnjxD:
+		result = f.sign(read(op.rs1), read(op.rs2), op.instructionType)
+	case FeqS, FeqD, FltS, FltD, FleS, FleD:
+		value, flags = f.compare(read(op.rs1), read(op.rs2), op.instructionType)
+		integer = true
+	case FclassS, FclassD:
+		value = f.class(read(op.rs1))
+		integer = true
+	case FcvtWS, FcvtWD:
+		value, flags = f.toInt(read(op.rs1), false, rm)
+		integer = true
+	case FcvtWuS, FcvtWuD:
+		value, flags = f.toInt(read(op.rs1), true, rm)
+		integer = true
+	case FcvtSW, FcvtDW:
+		result, flags = f.fromInt(int64(registerRead(ctx, op.forward, op.rs1, sequenceID)), rm)
+	case FcvtSWu, FcvtDWu:
+		result, flags = f.fromInt(int64(uint32(registerRead(ctx, op.forward, op.rs1, sequenceID))), rm)
+	case FcvtSD:
+		to = single
+		result, flags = double.convert(read(op.rs1), single, rm)
+	case FcvtDS:
+		to = double
+		result, flags = single.convert(read(op.rs1), double, rm)
+	case FmvXW:
+		// The bits are moved as is, regardless of the NaN-boxing
+		value = int32(fregisterRead(ctx, op.rs1, sequenceID))
+		integer = true
+	case FmvWX:
+		result = uint64(uint32(registerRead(ctx, op.forward, op.rs1, sequenceID)))
+	default:
+		panic(op.instructionType)
+	}
+	ctx.raiseFlags(flags)
+
+	if integer {
+		register, value := IsRegisterChange(op.rd, value)
+		if ctx.Debug {
+			fmt.Printf("\t\tRun: %s %s %d\n", op.instructionType, register, value)
+		}
+		return Execution{
+			RegisterChange: true,
+			Register:       register,
+			RegisterValue:  value,
+		}, nil
+	}
+	if ctx.Debug {
+		fmt.Printf("\t\tRun: %s %s %#x\n", op.instructionType, op.rd, result)
+	}
+	return Execution{
+		RegisterChange: true,
+		Register:       op.rd,
+		FRegisterValue: to.box(result),
+	}, nil
+}
+
+// format returns the format of the floating-point operands, or of the result
+// if the operand is an integer.
+func (op *fop) format() fpFormat {
+	switch op.instructionType {
+	case FaddD, FclassD, FcvtDW, FcvtDWu, FcvtSD, FcvtWD, FcvtWuD, FdivD, FeqD, FleD, FltD,
+		FmaddD, FmaxD, FminD, FmsubD, FmulD, FnmaddD, FnmsubD, FsgnjD, FsgnjnD, FsgnjxD, FsqrtD, FsubD:
+		return double
+	default:
+		return single
+	}
+}
+
+func (op *fop) InstructionType() InstructionType {
+	return op.instructionType
+}
+
+func (op *fop) String() string {
+	for name, instruction := range fpInstructions {
+		if instruction.instructionType != op.instructionType {
+			continue
+		}
+		var operands []string
+		for _, reg := range []RegisterType{op.rd, op.rs1, op.rs2, op.rs3}[:len(instruction.operands)] {
+			operands = append(operands, registerName(reg))
+		}
+		if instruction.rounding && op.rm != rmDYN {
+			operands = append(operands, mnemonic(roundingModes, op.rm))
+		}
+		return name + " " + strings.Join(operands, ", ")
+	}
+	return op.instructionType.String()
+}
+
+func (op *fop) ReadRegisters() []RegisterType {
+	switch op.instructionType {
+	case FsqrtS, FsqrtD, FclassS, FclassD, FcvtWS, FcvtWD, FcvtWuS, FcvtWuD, FcvtSW, FcvtDW,
+		FcvtSWu, FcvtDWu, FcvtSD, FcvtDS, FmvXW, FmvWX:
+		return []RegisterType{op.rs1}
+	case FmaddS, FmaddD, FmsubS, FmsubD, FnmaddS, FnmaddD, FnmsubS, FnmsubD:
+		return []RegisterType{op.rs1, op.rs2, op.rs3}
+	default:
+		return []RegisterType{op.rs1, op.rs2}
+	}
+}
+
+func (op *fop) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *fop) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *fop) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+func (op *fop) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+// fstore is fsw or fsd.
+type fstore struct {
+	instructionType InstructionType
+	rs              RegisterType
+	offset          int32
+	rd              RegisterType
+	forward         Forward
+}
+
+func (op *fstore) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rd := registerRead(ctx, op.forward, op.rd, sequenceID)
+	rs := fregisterRead(ctx, op.rs, sequenceID)
+	idx := rd + op.offset
+	if e := checkAccess(ctx, pc, idx, op.size(), true); e != nil {
+		return Execution{Exception: e}, nil
+	}
+	if ctx.Debug {
+		fmt.Printf("\t\tRun: %s %d to %#x\n", op.instructionType, idx, rs)
+	}
+	low := bytes.BytesFromLowBits(int32(rs))
+	changes := map[int32]int8{
+		idx:     low[0],
+		idx + 1: low[1],
+		idx + 2: low[2],
+		idx + 3: low[3],
+	}
+	if op.instructionType == Fsd {
+		high := bytes.BytesFromLowBits(int32(rs >> 32))
+		changes[idx+4] = high[0]
+		changes[idx+5] = high[1]
+		changes[idx+6] = high[2]
+		changes[idx+7] = high[3]
+	}
+	return Execution{
+		MemoryChange:  true,
+		MemoryChanges: changes,
+	}, nil
+}
+
+func (op *fstore) InstructionType() InstructionType {
+	return op.instructionType
+}
+
+func (op *fstore) String() string {
+	name := "fsw"
+	if op.instructionType == Fsd {
+		name = "fsd"
+	}
+	return fmt.Sprintf("%s %s, %d(%s)", name, registerName(op.rs), op.offset, registerName(op.rd))
+}
+
+func (op *fstore) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rd, op.rs}
+}
+
+func (op *fstore) WriteRegisters() []RegisterType {
+	return nil
+}
+
+func (op *fstore) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *fstore) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+func (op *fstore) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	rd := registerRead(ctx, op.forward, op.rd, sequenceID)
+	return accessAddrs(ctx, rd+op.offset, op.size(), true)
+}
+
+// size returns the number of bytes stored.
+func (op *fstore) size() int32 {
+	if op.instructionType == Fsd {
+		return 8
+	}
+	return 4
+}
+
+type j struct {
+	label  string
+	offset int32
+}
+
+func (op *j) Run(ctx *Context, labels map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	addr, err := jumpAddress(labels, op.label, pc, op.offset)
+	if err != nil {
+		return Execution{}, err
+	}
+	return Execution{
+		NextPc:   addr,
+		PcChange: true,
+	}, nil
+}
+
+func (op *j) InstructionType() InstructionType {
+	return J
+}
+
+func (op *j) String() string {
+	return "j " + formatTarget(op.label, op.offset)
+}
+
+func (op *j) ReadRegisters() []RegisterType {
+	return nil
+}
+
+func (op *j) WriteRegisters() []RegisterType {
+	return nil
+}
+
+func (op *j) Forward(forward Forward) {
+}
+
+func (op *j) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+func (op *j) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+type jal struct {
+	label   string
+	offset  int32
+	rd      RegisterType
+	forward Forward
+}
+
+func (op *jal) Run(ctx *Context, labels map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	addr, err := jumpAddress(labels, op.label, pc, op.offset)
+	if err != nil {
+		return Execution{}, err
+	}
+	register, value := IsRegisterChange(op.rd, pc+4)
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+		NextPc:         addr,
+		PcChange:       true,
+	}, nil
+}
+
+func (op *jal) InstructionType() InstructionType {
+	return Jal
+}
+
+func (op *jal) String() string {
+	return fmt.Sprintf("jal %s, %s", registerName(op.rd), formatTarget(op.label, op.offset))
+}
+
+func (op *jal) ReadRegisters() []RegisterType {
+	return nil
+}
+
+func (op *jal) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *jal) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *jal) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+func (op *jal) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+type jalr struct {
+	rd      RegisterType
+	rs      RegisterType
+	imm     int32
+	forward Forward
+}
+
+func (op *jalr) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	register, value := IsRegisterChange(op.rd, pc+4)
+	// The least-significant bit of the target is cleared
+	target := (rs + op.imm) &^ 1
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+		NextPc:         target,
+		PcChange:       true,
+	}, nil
+}
+
+func (op *jalr) InstructionType() InstructionType {
+	return Jalr
+}
+
+func (op *jalr) String() string {
+	return fmt.Sprintf("jalr %s, %s, %d", registerName(op.rd), registerName(op.rs), op.imm)
+}
+
+func (op *jalr) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs}
+}
+
+func (op *jalr) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *jalr) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *jalr) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+func (op *jalr) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+type lui struct {
+	rd  RegisterType
+	imm int32
+}
+
+func (op *lui) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	register, value := IsRegisterChange(op.rd, op.imm<<12)
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+	}, nil
+}
+
+func (op *lui) InstructionType() InstructionType {
+	return Lui
+}
+
+func (op *lui) String() string {
+	return fmt.Sprintf("lui %s, %#x", registerName(op.rd), uint32(op.imm)&0xfffff)
+}
+
+func (op *lui) ReadRegisters() []RegisterType {
+	return nil
+}
+
+func (op *lui) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *lui) Forward(forward Forward) {
+}
+
+func (op *lui) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+func (op *lui) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+type lb struct {
+	rd      RegisterType
+	offset  int32
+	rs      RegisterType
+	forward Forward
+}
+
+func (op *lb) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	if e := checkAccess(ctx, pc, rs+op.offset, 1, false); e != nil {
+		return Execution{Exception: e}, nil
+	}
+	n := memory[0]
+	register, value := IsRegisterChange(op.rd, int32(n))
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+	}, nil
+}
+
+func (op *lb) InstructionType() InstructionType {
+	return Lb
+}
+
+func (op *lb) String() string {
+	return fmt.Sprintf("lb %s, %d(%s)", registerName(op.rd), op.offset, registerName(op.rs))
+}
+
+func (op *lb) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs}
+}
+
+func (op *lb) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *lb) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *lb) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	return accessAddrs(ctx, rs+op.offset, 1, false)
+}
+
+func (op *lb) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+type lbu struct {
+	rd      RegisterType
+	offset  int32
+	rs      RegisterType
+	forward Forward
+}
+
+func (op *lbu) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	if e := checkAccess(ctx, pc, rs+op.offset, 1, false); e != nil {
+		return Execution{Exception: e}, nil
+	}
+	n := int32(uint8(memory[0]))
+	register, value := IsRegisterChange(op.rd, n)
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+	}, nil
+}
+
+func (op *lbu) InstructionType() InstructionType {
+	return Lbu
+}
+
+func (op *lbu) String() string {
+	return fmt.Sprintf("lbu %s, %d(%s)", registerName(op.rd), op.offset, registerName(op.rs))
+}
+
+func (op *lbu) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs}
+}
+
+func (op *lbu) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *lbu) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *lbu) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	return accessAddrs(ctx, rs+op.offset, 1, false)
+}
+
+func (op *lbu) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
@@ -950,7 +1834,11 @@ type lh struct {
 }
 
 func (op *lh) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
-	n := bytes.I32FromBytes(memory[0], memory[1], 0, 0)
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	if e := checkAccess(ctx, pc, rs+op.offset, 2, false); e != nil {
+		return Execution{Exception: e}, nil
+	}
+	n := int32(int16(bytes.I32FromBytes(memory[0], memory[1], 0, 0)))
 	register, value := IsRegisterChange(op.rd, n)
 	return Execution{
 		RegisterChange: true,
@@ -963,12 +1851,16 @@ func (op *lh) InstructionType() InstructionType {
 	return Lh
 }
 
+func (op *lh) String() string {
+	return fmt.Sprintf("lh %s, %d(%s)", registerName(op.rd), op.offset, registerName(op.rs))
+}
+
 func (op *lh) ReadRegisters() []RegisterType {
-	return []RegisterType{op.rs, op.rd}
+	return []RegisterType{op.rs}
 }
 
 func (op *lh) WriteRegisters() []RegisterType {
-	return nil
+	return []RegisterType{op.rd}
 }
 
 func (op *lh) Forward(forward Forward) {
@@ -977,14 +1869,63 @@ func (op *lh) Forward(forward Forward) {
 
 func (op *lh) MemoryRead(ctx *Context, sequenceID int32) []int32 {
 	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
-	idx := rs + op.offset
-	return []int32{idx, idx + 1}
+	return accessAddrs(ctx, rs+op.offset, 2, false)
 }
 
 func (op *lh) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
+type lhu struct {
+	rd      RegisterType
+	offset  int32
+	rs      RegisterType
+	forward Forward
+}
+
+func (op *lhu) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	if e := checkAccess(ctx, pc, rs+op.offset, 2, false); e != nil {
+		return Execution{Exception: e}, nil
+	}
+	n := bytes.I32FromBytes(memory[0], memory[1], 0, 0)
+	register, value := IsRegisterChange(op.rd, n)
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+	}, nil
+}
+
+func (op *lhu) InstructionType() InstructionType {
+	return Lhu
+}
+
+func (op *lhu) String() string {
+	return fmt.Sprintf("lhu %s, %d(%s)", registerName(op.rd), op.offset, registerName(op.rs))
+}
+
+func (op *lhu) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs}
+}
+
+func (op *lhu) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *lhu) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *lhu) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	return accessAddrs(ctx, rs+op.offset, 2, false)
+}
+
+func (op *lhu) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
 type li struct {
 	rd  RegisterType
 	imm int32
@@ -1003,6 +1944,10 @@ func (op *li) InstructionType() InstructionType {
 	return Li
 }
 
+func (op *li) String() string {
+	return fmt.Sprintf("li %s, %d", registerName(op.rd), op.imm)
+}
+
 func (op *li) ReadRegisters() []RegisterType {
 	return nil
 }
@@ -1022,6 +1967,59 @@ func (op *li) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
+type lrw struct {
+	rd      RegisterType
+	rs      RegisterType
+	forward Forward
+}
+
+func (op *lrw) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	if e := checkAccess(ctx, pc, rs, 4, false); e != nil {
+		return Execution{Exception: e}, nil
+	}
+	n := bytes.I32FromBytes(memory[0], memory[1], memory[2], memory[3])
+	ctx.reserve(rs)
+	register, value := IsRegisterChange(op.rd, n)
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+	}, nil
+}
+
+func (op *lrw) InstructionType() InstructionType {
+	return LrW
+}
+
+func (op *lrw) String() string {
+	return fmt.Sprintf("lr.w %s, (%s)", registerName(op.rd), registerName(op.rs))
+}
+
+func (op *lrw) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs}
+}
+
+func (op *lrw) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *lrw) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *lrw) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	return accessAddrs(ctx, rs, 4, false)
+}
+
+// MemoryWrite returns the reserved word: even though lr.w doesn't write, the
+// reservation requires an exclusive ownership of the line.
+func (op *lrw) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	return accessAddrs(ctx, rs, 4, false)
+}
+
 type lw struct {
 	rd      RegisterType
 	offset  int32
@@ -1029,12 +2027,182 @@ type lw struct {
 	forward Forward
 }
 
-func (op *lw) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
-	n := bytes.I32FromBytes(memory[0], memory[1], memory[2], memory[3])
-	register, value := IsRegisterChange(op.rd, n)
-	if ctx.Debug {
-		fmt.Printf("\t\tRun: Lw %s %d\n", register, value)
-	}
+func (op *lw) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	n, device := ctx.loadDevice(rs + op.offset)
+	if !device {
+		if e := checkAccess(ctx, pc, rs+op.offset, 4, false); e != nil {
+			return Execution{Exception: e}, nil
+		}
+		n = bytes.I32FromBytes(memory[0], memory[1], memory[2], memory[3])
+	}
+	register, value := IsRegisterChange(op.rd, n)
+	if ctx.Debug {
+		fmt.Printf("\t\tRun: Lw %s %d\n", register, value)
+	}
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+	}, nil
+}
+
+func (op *lw) InstructionType() InstructionType {
+	return Lw
+}
+
+func (op *lw) String() string {
+	return fmt.Sprintf("lw %s, %d(%s)", registerName(op.rd), op.offset, registerName(op.rs))
+}
+
+func (op *lw) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs}
+}
+
+func (op *lw) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *lw) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *lw) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	return accessAddrs(ctx, rs+op.offset, 4, false)
+}
+
+func (op *lw) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+// mret returns from a trap handler to mepc.
+type mret struct{}
+
+func (op *mret) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	return Execution{
+		NextPc:   ctx.Mret(),
+		PcChange: true,
+	}, nil
+}
+
+func (op *mret) InstructionType() InstructionType {
+	return Mret
+}
+
+func (op *mret) String() string {
+	return "mret"
+}
+
+func (op *mret) ReadRegisters() []RegisterType {
+	return nil
+}
+
+func (op *mret) WriteRegisters() []RegisterType {
+	return nil
+}
+
+func (op *mret) Forward(forward Forward) {
+}
+
+func (op *mret) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+func (op *mret) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+type nop struct{}
+
+func (op *nop) Run(_ *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	return Execution{}, nil
+}
+
+func (op *nop) InstructionType() InstructionType {
+	return Nop
+}
+
+func (op *nop) String() string {
+	return "nop"
+}
+
+func (op *nop) ReadRegisters() []RegisterType {
+	return nil
+}
+
+func (op *nop) WriteRegisters() []RegisterType {
+	return nil
+}
+
+func (op *nop) Forward(forward Forward) {
+}
+
+func (op *nop) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+func (op *nop) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+type mul struct {
+	rd      RegisterType
+	rs1     RegisterType
+	rs2     RegisterType
+	forward Forward
+}
+
+func (op *mul) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
+	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
+	register, value := IsRegisterChange(op.rd, rs1*rs2)
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+	}, nil
+}
+
+func (op *mul) InstructionType() InstructionType {
+	return Mul
+}
+
+func (op *mul) String() string {
+	return fmt.Sprintf("mul %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
+func (op *mul) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs1, op.rs2}
+}
+
+func (op *mul) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *mul) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *mul) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+func (op *mul) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+type mulh struct {
+	rd      RegisterType
+	rs1     RegisterType
+	rs2     RegisterType
+	forward Forward
+}
+
+func (op *mulh) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
+	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
+	register, value := IsRegisterChange(op.rd, int32((int64(rs1)*int64(rs2))>>32))
 	return Execution{
 		RegisterChange: true,
 		Register:       register,
@@ -1042,72 +2210,91 @@ func (op *lw) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, seq
 	}, nil
 }
 
-func (op *lw) InstructionType() InstructionType {
-	return Lw
+func (op *mulh) InstructionType() InstructionType {
+	return Mulh
 }
 
-func (op *lw) ReadRegisters() []RegisterType {
-	return []RegisterType{op.rs}
+func (op *mulh) String() string {
+	return fmt.Sprintf("mulh %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
 }
 
-func (op *lw) WriteRegisters() []RegisterType {
+func (op *mulh) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs1, op.rs2}
+}
+
+func (op *mulh) WriteRegisters() []RegisterType {
 	return []RegisterType{op.rd}
 }
 
-func (op *lw) Forward(forward Forward) {
+func (op *mulh) Forward(forward Forward) {
 	op.forward = forward
 }
 
-func (op *lw) MemoryRead(ctx *Context, sequenceID int32) []int32 {
-	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
-	idx := rs + op.offset
-	return []int32{idx, idx + 1, idx + 2, idx + 3}
+func (op *mulh) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
 }
 
-func (op *lw) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+func (op *mulh) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-type nop struct{}
+type mulhsu struct {
+	rd      RegisterType
+	rs1     RegisterType
+	rs2     RegisterType
+	forward Forward
+}
 
-func (op *nop) Run(_ *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
-	return Execution{}, nil
+func (op *mulhsu) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
+	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
+	register, value := IsRegisterChange(op.rd, int32((int64(rs1)*int64(uint32(rs2)))>>32))
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+	}, nil
 }
 
-func (op *nop) InstructionType() InstructionType {
-	return Nop
+func (op *mulhsu) InstructionType() InstructionType {
+	return Mulhsu
 }
 
-func (op *nop) ReadRegisters() []RegisterType {
-	return nil
+func (op *mulhsu) String() string {
+	return fmt.Sprintf("mulhsu %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
 }
 
-func (op *nop) WriteRegisters() []RegisterType {
-	return nil
+func (op *mulhsu) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs1, op.rs2}
 }
 
-func (op *nop) Forward(forward Forward) {
+func (op *mulhsu) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
 }
 
-func (op *nop) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+func (op *mulhsu) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *mulhsu) MemoryRead(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-func (op *nop) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+func (op *mulhsu) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-type mul struct {
+type mulhu struct {
 	rd      RegisterType
 	rs1     RegisterType
 	rs2     RegisterType
 	forward Forward
 }
 
-func (op *mul) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+func (op *mulhu) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
 	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
 	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
-	register, value := IsRegisterChange(op.rd, rs1*rs2)
+	register, value := IsRegisterChange(op.rd, int32((uint64(uint32(rs1))*uint64(uint32(rs2)))>>32))
 	return Execution{
 		RegisterChange: true,
 		Register:       register,
@@ -1115,27 +2302,31 @@ func (op *mul) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, se
 	}, nil
 }
 
-func (op *mul) InstructionType() InstructionType {
-	return Mul
+func (op *mulhu) InstructionType() InstructionType {
+	return Mulhu
 }
 
-func (op *mul) ReadRegisters() []RegisterType {
+func (op *mulhu) String() string {
+	return fmt.Sprintf("mulhu %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
+func (op *mulhu) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
 
-func (op *mul) WriteRegisters() []RegisterType {
+func (op *mulhu) WriteRegisters() []RegisterType {
 	return []RegisterType{op.rd}
 }
 
-func (op *mul) Forward(forward Forward) {
+func (op *mulhu) Forward(forward Forward) {
 	op.forward = forward
 }
 
-func (op *mul) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+func (op *mulhu) MemoryRead(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-func (op *mul) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+func (op *mulhu) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
@@ -1159,6 +2350,10 @@ func (op *mv) InstructionType() InstructionType {
 	return Mv
 }
 
+func (op *mv) String() string {
+	return fmt.Sprintf("mv %s, %s", registerName(op.rd), registerName(op.rs))
+}
+
 func (op *mv) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs}
 }
@@ -1201,6 +2396,10 @@ func (op *or) InstructionType() InstructionType {
 	return Or
 }
 
+func (op *or) String() string {
+	return fmt.Sprintf("or %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
 func (op *or) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -1242,6 +2441,10 @@ func (op *ori) InstructionType() InstructionType {
 	return Ori
 }
 
+func (op *ori) String() string {
+	return fmt.Sprintf("ori %s, %s, %d", registerName(op.rd), registerName(op.rs), op.imm)
+}
+
 func (op *ori) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs}
 }
@@ -1275,7 +2478,12 @@ func (op *rem) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, se
 	if ctx.Debug {
 		fmt.Printf("\t\tRun: Rem %d %d\n", rs1, rs2)
 	}
-	register, value := IsRegisterChange(op.rd, rs1%rs2)
+	// The remainder of a division by zero is the dividend
+	result := rs1
+	if rs2 != 0 {
+		result = rs1 % rs2
+	}
+	register, value := IsRegisterChange(op.rd, result)
 	return Execution{
 		RegisterChange: true,
 		Register:       register,
@@ -1287,6 +2495,10 @@ func (op *rem) InstructionType() InstructionType {
 	return Rem
 }
 
+func (op *rem) String() string {
+	return fmt.Sprintf("rem %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
 func (op *rem) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -1307,18 +2519,80 @@ func (op *rem) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
-type ret struct{}
+type remu struct {
+	rd      RegisterType
+	rs1     RegisterType
+	rs2     RegisterType
+	forward Forward
+}
+
+func (op *remu) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
+	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
+	// The remainder of a division by zero is the dividend
+	result := rs1
+	if rs2 != 0 {
+		result = int32(uint32(rs1) % uint32(rs2))
+	}
+	register, value := IsRegisterChange(op.rd, result)
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+	}, nil
+}
+
+func (op *remu) InstructionType() InstructionType {
+	return Remu
+}
+
+func (op *remu) String() string {
+	return fmt.Sprintf("remu %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
+func (op *remu) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs1, op.rs2}
+}
+
+func (op *remu) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *remu) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *remu) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+func (op *remu) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+// ret returns from a function: it's a jalr zero, 0(ra).
+type ret struct {
+	forward Forward
+}
 
-func (op *ret) Run(_ *Context, _ map[string]int32, _ int32, memory []int8, sequenceID int32) (Execution, error) {
-	return Execution{Return: true}, nil
+func (op *ret) Run(ctx *Context, _ map[string]int32, _ int32, memory []int8, sequenceID int32) (Execution, error) {
+	ra := registerRead(ctx, op.forward, Ra, sequenceID)
+	return Execution{
+		NextPc:   ra &^ 1,
+		PcChange: true,
+	}, nil
 }
 
 func (op *ret) InstructionType() InstructionType {
 	return Ret
 }
 
+func (op *ret) String() string {
+	return "ret"
+}
+
 func (op *ret) ReadRegisters() []RegisterType {
-	return nil
+	return []RegisterType{Ra}
 }
 
 func (op *ret) WriteRegisters() []RegisterType {
@@ -1326,6 +2600,7 @@ func (op *ret) WriteRegisters() []RegisterType {
 }
 
 func (op *ret) Forward(forward Forward) {
+	op.forward = forward
 }
 
 func (op *ret) MemoryRead(ctx *Context, sequenceID int32) []int32 {
@@ -1347,6 +2622,9 @@ func (op *sb) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, seq
 	rd := registerRead(ctx, op.forward, op.rd, sequenceID)
 	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
 	idx := rd + op.offset
+	if e := checkAccess(ctx, pc, idx, 1, true); e != nil {
+		return Execution{Exception: e}, nil
+	}
 	n := rs
 	return Execution{
 		MemoryChange:  true,
@@ -1358,6 +2636,10 @@ func (op *sb) InstructionType() InstructionType {
 	return Sb
 }
 
+func (op *sb) String() string {
+	return fmt.Sprintf("sb %s, %d(%s)", registerName(op.rs), op.offset, registerName(op.rd))
+}
+
 func (op *sb) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rd, op.rs}
 }
@@ -1376,8 +2658,76 @@ func (op *sb) MemoryRead(ctx *Context, sequenceID int32) []int32 {
 
 func (op *sb) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	rd := registerRead(ctx, op.forward, op.rd, sequenceID)
-	idx := rd + op.offset
-	return []int32{idx}
+	return accessAddrs(ctx, rd+op.offset, 1, true)
+}
+
+type scw struct {
+	rd      RegisterType
+	rs1     RegisterType
+	rs2     RegisterType
+	forward Forward
+}
+
+func (op *scw) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
+	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
+	if e := checkAccess(ctx, pc, rs1, 4, true); e != nil {
+		return Execution{Exception: e}, nil
+	}
+	if !ctx.releaseReservation(rs1) {
+		// Failure: rd is set to a nonzero value and the memory is untouched
+		register, value := IsRegisterChange(op.rd, 1)
+		return Execution{
+			RegisterChange: true,
+			Register:       register,
+			RegisterValue:  value,
+		}, nil
+	}
+
+	b := bytes.BytesFromLowBits(rs2)
+	register, value := IsRegisterChange(op.rd, 0)
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+		MemoryChange:   true,
+		MemoryChanges: map[int32]int8{
+			rs1:     b[0],
+			rs1 + 1: b[1],
+			rs1 + 2: b[2],
+			rs1 + 3: b[3],
+		},
+	}, nil
+}
+
+func (op *scw) InstructionType() InstructionType {
+	return ScW
+}
+
+func (op *scw) String() string {
+	return fmt.Sprintf("sc.w %s, %s, (%s)", registerName(op.rd), registerName(op.rs2), registerName(op.rs1))
+}
+
+func (op *scw) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs1, op.rs2}
+}
+
+func (op *scw) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *scw) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *scw) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
+	return accessAddrs(ctx, rs1, 4, true)
+}
+
+func (op *scw) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
+	return accessAddrs(ctx, rs1, 4, true)
 }
 
 type sh struct {
@@ -1391,6 +2741,9 @@ func (op *sh) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, seq
 	rd := registerRead(ctx, op.forward, op.rd, sequenceID)
 	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
 	idx := rd + op.offset
+	if e := checkAccess(ctx, pc, idx, 2, true); e != nil {
+		return Execution{Exception: e}, nil
+	}
 	n := rs
 	b := bytes.BytesFromLowBits(n)
 	return Execution{
@@ -1406,6 +2759,10 @@ func (op *sh) InstructionType() InstructionType {
 	return Sh
 }
 
+func (op *sh) String() string {
+	return fmt.Sprintf("sh %s, %d(%s)", registerName(op.rs), op.offset, registerName(op.rd))
+}
+
 func (op *sh) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rd, op.rs}
 }
@@ -1424,8 +2781,7 @@ func (op *sh) MemoryRead(ctx *Context, sequenceID int32) []int32 {
 
 func (op *sh) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	rd := registerRead(ctx, op.forward, op.rd, sequenceID)
-	idx := rd + op.offset
-	return []int32{idx, idx + 1}
+	return accessAddrs(ctx, rd+op.offset, 2, true)
 }
 
 type sll struct {
@@ -1438,7 +2794,7 @@ type sll struct {
 func (op *sll) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
 	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
 	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
-	register, value := IsRegisterChange(op.rd, rs1<<uint(rs2))
+	register, value := IsRegisterChange(op.rd, rs1<<(rs2&0x1f))
 	return Execution{
 		RegisterChange: true,
 		Register:       register,
@@ -1450,6 +2806,10 @@ func (op *sll) InstructionType() InstructionType {
 	return Sll
 }
 
+func (op *sll) String() string {
+	return fmt.Sprintf("sll %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
 func (op *sll) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -1491,6 +2851,10 @@ func (op *slli) InstructionType() InstructionType {
 	return Slli
 }
 
+func (op *slli) String() string {
+	return fmt.Sprintf("slli %s, %s, %d", registerName(op.rd), registerName(op.rs), op.imm)
+}
+
 func (op *slli) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs}
 }
@@ -1539,6 +2903,10 @@ func (op *slt) InstructionType() InstructionType {
 	return Slt
 }
 
+func (op *slt) String() string {
+	return fmt.Sprintf("slt %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
 func (op *slt) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -1571,7 +2939,7 @@ func (op *sltu) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, s
 	var value int32
 	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
 	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
-	if rs1 < rs2 {
+	if uint32(rs1) < uint32(rs2) {
 		register, value = IsRegisterChange(op.rd, 1)
 	} else {
 		register, value = IsRegisterChange(op.rd, 0)
@@ -1587,6 +2955,10 @@ func (op *sltu) InstructionType() InstructionType {
 	return Sltu
 }
 
+func (op *sltu) String() string {
+	return fmt.Sprintf("sltu %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
 func (op *sltu) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -1634,6 +3006,10 @@ func (op *slti) InstructionType() InstructionType {
 	return Slti
 }
 
+func (op *slti) String() string {
+	return fmt.Sprintf("slti %s, %s, %d", registerName(op.rd), registerName(op.rs), op.imm)
+}
+
 func (op *slti) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs}
 }
@@ -1654,6 +3030,58 @@ func (op *slti) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	return nil
 }
 
+type sltiu struct {
+	rd      RegisterType
+	rs      RegisterType
+	imm     int32
+	forward Forward
+}
+
+// Run compares rs with the sign-extended immediate as unsigned numbers.
+func (op *sltiu) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	var register RegisterType
+	var value int32
+	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
+	if uint32(rs) < uint32(op.imm) {
+		register, value = IsRegisterChange(op.rd, 1)
+	} else {
+		register, value = IsRegisterChange(op.rd, 0)
+	}
+	return Execution{
+		RegisterChange: true,
+		Register:       register,
+		RegisterValue:  value,
+	}, nil
+}
+
+func (op *sltiu) InstructionType() InstructionType {
+	return Sltiu
+}
+
+func (op *sltiu) String() string {
+	return fmt.Sprintf("sltiu %s, %s, %d", registerName(op.rd), registerName(op.rs), op.imm)
+}
+
+func (op *sltiu) ReadRegisters() []RegisterType {
+	return []RegisterType{op.rs}
+}
+
+func (op *sltiu) WriteRegisters() []RegisterType {
+	return []RegisterType{op.rd}
+}
+
+func (op *sltiu) Forward(forward Forward) {
+	op.forward = forward
+}
+
+func (op *sltiu) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+func (op *sltiu) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
 type sra struct {
 	rd      RegisterType
 	rs1     RegisterType
@@ -1664,7 +3092,7 @@ type sra struct {
 func (op *sra) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
 	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
 	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
-	register, value := IsRegisterChange(op.rd, rs1>>rs2)
+	register, value := IsRegisterChange(op.rd, rs1>>(rs2&0x1f))
 	return Execution{
 		RegisterChange: true,
 		Register:       register,
@@ -1676,6 +3104,10 @@ func (op *sra) InstructionType() InstructionType {
 	return Sra
 }
 
+func (op *sra) String() string {
+	return fmt.Sprintf("sra %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
 func (op *sra) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -1717,6 +3149,10 @@ func (op *srai) InstructionType() InstructionType {
 	return Srai
 }
 
+func (op *srai) String() string {
+	return fmt.Sprintf("srai %s, %s, %d", registerName(op.rd), registerName(op.rs), op.imm)
+}
+
 func (op *srai) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs}
 }
@@ -1747,7 +3183,7 @@ type srl struct {
 func (op *srl) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
 	rs1 := registerRead(ctx, op.forward, op.rs1, sequenceID)
 	rs2 := registerRead(ctx, op.forward, op.rs2, sequenceID)
-	register, value := IsRegisterChange(op.rd, rs1>>rs2)
+	register, value := IsRegisterChange(op.rd, int32(uint32(rs1)>>(rs2&0x1f)))
 	return Execution{
 		RegisterChange: true,
 		Register:       register,
@@ -1759,6 +3195,10 @@ func (op *srl) InstructionType() InstructionType {
 	return Srl
 }
 
+func (op *srl) String() string {
+	return fmt.Sprintf("srl %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
 func (op *srl) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -1788,7 +3228,7 @@ type srli struct {
 
 func (op *srli) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
 	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
-	register, value := IsRegisterChange(op.rd, rs>>op.imm)
+	register, value := IsRegisterChange(op.rd, int32(uint32(rs)>>op.imm))
 	return Execution{
 		RegisterChange: true,
 		Register:       register,
@@ -1800,6 +3240,10 @@ func (op *srli) InstructionType() InstructionType {
 	return Srli
 }
 
+func (op *srli) String() string {
+	return fmt.Sprintf("srli %s, %s, %d", registerName(op.rd), registerName(op.rs), op.imm)
+}
+
 func (op *srli) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs}
 }
@@ -1842,6 +3286,10 @@ func (op *sub) InstructionType() InstructionType {
 	return Sub
 }
 
+func (op *sub) String() string {
+	return fmt.Sprintf("sub %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
 func (op *sub) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -1873,6 +3321,13 @@ func (op *sw) Run(ctx *Context, _ map[string]int32, pc int32, memory []int8, seq
 	rd := registerRead(ctx, op.forward, op.rd, sequenceID)
 	rs := registerRead(ctx, op.forward, op.rs, sequenceID)
 	idx := rd + op.offset
+	if ctx.storeDevice(idx, rs) {
+		// The device is written when the instruction is executed
+		return Execution{}, nil
+	}
+	if e := checkAccess(ctx, pc, idx, 4, true); e != nil {
+		return Execution{Exception: e}, nil
+	}
 	n := rs
 	b := bytes.BytesFromLowBits(n)
 	if ctx.Debug {
@@ -1893,6 +3348,10 @@ func (op *sw) InstructionType() InstructionType {
 	return Sw
 }
 
+func (op *sw) String() string {
+	return fmt.Sprintf("sw %s, %d(%s)", registerName(op.rs), op.offset, registerName(op.rd))
+}
+
 func (op *sw) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rd, op.rs}
 }
@@ -1911,8 +3370,42 @@ func (op *sw) MemoryRead(ctx *Context, sequenceID int32) []int32 {
 
 func (op *sw) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
 	rd := registerRead(ctx, op.forward, op.rd, sequenceID)
-	idx := rd + op.offset
-	return []int32{idx, idx + 1, idx + 2, idx + 3}
+	return accessAddrs(ctx, rd+op.offset, 4, true)
+}
+
+// wfi waits for an interrupt. Returning straight away is a valid
+// implementation, the hart resuming as if an interrupt was pending.
+type wfi struct{}
+
+func (op *wfi) Run(_ *Context, _ map[string]int32, pc int32, memory []int8, sequenceID int32) (Execution, error) {
+	return Execution{}, nil
+}
+
+func (op *wfi) InstructionType() InstructionType {
+	return Wfi
+}
+
+func (op *wfi) String() string {
+	return "wfi"
+}
+
+func (op *wfi) ReadRegisters() []RegisterType {
+	return nil
+}
+
+func (op *wfi) WriteRegisters() []RegisterType {
+	return nil
+}
+
+func (op *wfi) Forward(forward Forward) {
+}
+
+func (op *wfi) MemoryRead(ctx *Context, sequenceID int32) []int32 {
+	return nil
+}
+
+func (op *wfi) MemoryWrite(ctx *Context, sequenceID int32) []int32 {
+	return nil
 }
 
 type xor struct {
@@ -1937,6 +3430,10 @@ func (op *xor) InstructionType() InstructionType {
 	return Xor
 }
 
+func (op *xor) String() string {
+	return fmt.Sprintf("xor %s, %s, %s", registerName(op.rd), registerName(op.rs1), registerName(op.rs2))
+}
+
 func (op *xor) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs1, op.rs2}
 }
@@ -1978,6 +3475,10 @@ func (op *xori) InstructionType() InstructionType {
 	return Xori
 }
 
+func (op *xori) String() string {
+	return fmt.Sprintf("xori %s, %s, %d", registerName(op.rd), registerName(op.rs), op.imm)
+}
+
 func (op *xori) ReadRegisters() []RegisterType {
 	return []RegisterType{op.rs}
 }
@@ -1,6 +1,8 @@
 package risc
 
 import (
+	"math"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,6 +41,59 @@ func TestAddi(t *testing.T) {
 		"addi t0, t1, 1", map[RegisterType]int32{T0: 2}, map[int]int8{})
 }
 
+func TestAmoaddW(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: 4, T1: -6}, 8, map[int]int8{4: 5},
+		"amoadd.w t2, t1, (t0)", map[RegisterType]int32{T2: 5}, map[int]int8{4: -1, 5: -1, 6: -1, 7: -1})
+}
+
+func TestAmoandW(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: 4, T1: 6}, 8, map[int]int8{4: 5},
+		"amoand.w t2, t1, (t0)", map[RegisterType]int32{T2: 5}, map[int]int8{4: 4})
+}
+
+func TestAmomaxW(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: 4, T1: -6}, 8, map[int]int8{4: 5},
+		"amomax.w t2, t1, (t0)", map[RegisterType]int32{T2: 5}, map[int]int8{4: 5, 5: 0})
+}
+
+func TestAmomaxuW(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: 4, T1: -6}, 8, map[int]int8{4: 5},
+		"amomaxu.w t2, t1, (t0)", map[RegisterType]int32{T2: 5}, map[int]int8{4: -6, 5: -1})
+}
+
+func TestAmominW(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: 4, T1: -6}, 8, map[int]int8{4: 5},
+		"amomin.w t2, t1, (t0)", map[RegisterType]int32{T2: 5}, map[int]int8{4: -6, 5: -1})
+}
+
+func TestAmominuW(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: 4, T1: -6}, 8, map[int]int8{4: 5},
+		"amominu.w t2, t1, (t0)", map[RegisterType]int32{T2: 5}, map[int]int8{4: 5, 5: 0})
+}
+
+func TestAmoorW(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: 4, T1: 2}, 8, map[int]int8{4: 5},
+		"amoor.w t2, t1, (t0)", map[RegisterType]int32{T2: 5}, map[int]int8{4: 7})
+}
+
+func TestAmoswapW(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: 4, T1: 258}, 8, map[int]int8{4: 5},
+		"amoswap.w.aqrl t2, t1, (t0)", map[RegisterType]int32{T2: 5}, map[int]int8{4: 2, 5: 1})
+}
+
+func TestAmoxorW(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: 4, T1: 6}, 8, map[int]int8{4: 5},
+		"amoxor.w t2, t1, (t0)", map[RegisterType]int32{T2: 5}, map[int]int8{4: 3})
+}
+
+func TestAmoMisaligned(t *testing.T) {
+	app, err := Parse("amoadd.w t2, t1, (t0)")
+	require.NoError(t, err)
+	r := NewRunner(app, 8)
+	r.Ctx.Registers[T0] = 2
+	assert.Error(t, r.Run())
+}
+
 func TestAnd(t *testing.T) {
 	runAssert(t, map[RegisterType]int32{T1: 1, T2: 3}, 0, map[int]int8{},
 		"and t0, t1, t2", map[RegisterType]int32{T0: 1}, map[int]int8{})
@@ -145,12 +200,278 @@ foo:
 addi t1, zero, 1`, map[RegisterType]int32{T0: 1, T1: 1}, map[int]int8{})
 }
 
+func TestCompressed(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{Sp: 32}, 64, map[int]int8{}, `
+c.li s0, 10
+addi s1, zero, 0
+loop:
+c.add s1, s0
+c.addi s0, -1
+c.bnez s0, loop
+c.jal function
+c.j end
+function:
+c.swsp s1, 4(sp)
+c.lwsp a0, 4(sp)
+c.slli a0, 1
+c.jr ra
+end:
+c.nop`, map[RegisterType]int32{S1: 55, A0: 110, Ra: 14}, map[int]int8{36: 55})
+
+	app, err := Parse(`c.li a0, 1
+addi a0, a0, 1
+c.mv a1, a0`)
+	require.NoError(t, err)
+	assert.True(t, app.Compressed)
+	assert.Equal(t, int32(8), app.End())
+
+	for _, instruction := range []string{
+		"c.add zero, a0",
+		"c.addi a0, 32",
+		"c.addi a0, 0",
+		"c.and t0, a0",
+		"c.lw a0, 2(a1)",
+		"c.lw a0, 128(a1)",
+		"c.lwsp a0, 4(a1)",
+		"c.lui sp, 1",
+		"c.addi16sp sp, 8",
+		"c.addi4spn a0, sp, 0",
+		"c.slli a0, 32",
+		"c.fld ft0, 0(a0)",
+	} {
+		_, err := Parse(instruction)
+		assert.Error(t, err, instruction)
+	}
+}
+
+func TestCSR(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: 0x42}, 0, map[int]int8{}, `
+csrrw t1, fcsr, t0
+csrr t2, frm
+csrrci s0, fflags, 3
+csrrsi s1, frm, 1
+csrrs s2, fcsr, zero
+rdinstret s3
+rdcycle s4
+rdtimeh s5`, map[RegisterType]int32{T1: 0, T2: 2, S0: 2, S1: 2, S2: 0x60, S3: 5, S4: 6, S5: 0}, map[int]int8{})
+
+	app, err := Parse("csrw cycle, t0")
+	require.NoError(t, err)
+	assert.Error(t, NewRunner(app, 0).Run())
+
+	for _, instruction := range []string{
+		"csrrw t0, unknown, t1",
+		"csrrwi t0, fcsr, 32",
+		"csrrs t0, fcsr",
+		"rdcycle",
+	} {
+		_, err := Parse(instruction)
+		assert.Error(t, err, instruction)
+	}
+}
+
 func TestDiv(t *testing.T) {
 	runAssert(t, map[RegisterType]int32{T1: 4, T2: 2}, 0, map[int]int8{},
 		`div t0, t1, t2`, map[RegisterType]int32{T0: 2}, map[int]int8{})
 
 	runAssert(t, map[RegisterType]int32{T1: 4, T2: 3}, 0, map[int]int8{},
 		`div t0, t1, t2`, map[RegisterType]int32{T0: 1}, map[int]int8{})
+
+	runAssert(t, map[RegisterType]int32{T1: -7, T2: 2}, 0, map[int]int8{},
+		`div t0, t1, t2`, map[RegisterType]int32{T0: -3}, map[int]int8{})
+
+	// Division by zero
+	runAssert(t, map[RegisterType]int32{T1: 4, T2: 0}, 0, map[int]int8{},
+		`div t0, t1, t2`, map[RegisterType]int32{T0: -1}, map[int]int8{})
+
+	// Overflow
+	runAssert(t, map[RegisterType]int32{T1: math.MinInt32, T2: -1}, 0, map[int]int8{},
+		`div t0, t1, t2`, map[RegisterType]int32{T0: math.MinInt32}, map[int]int8{})
+}
+
+func TestDivu(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T1: -2, T2: 2}, 0, map[int]int8{},
+		`divu t0, t1, t2`, map[RegisterType]int32{T0: math.MaxInt32}, map[int]int8{})
+
+	// Division by zero
+	runAssert(t, map[RegisterType]int32{T1: 4, T2: 0}, 0, map[int]int8{},
+		`divu t0, t1, t2`, map[RegisterType]int32{T0: -1}, map[int]int8{})
+}
+
+func TestEbreak(t *testing.T) {
+	app, err := Parse("c.ebreak")
+	require.NoError(t, err)
+	assert.Error(t, NewRunner(app, 0).Run())
+}
+
+func TestEcall(t *testing.T) {
+	app, err := Parse(`addi a0, zero, 1
+addi a1, zero, 16
+addi a2, zero, 3
+addi a7, zero, 64
+ecall
+mv s0, a0
+addi a0, zero, 0
+addi a1, zero, 60
+addi a2, zero, 8
+addi a7, zero, 63
+ecall
+mv s1, a0
+addi a0, zero, 2
+addi a7, zero, 64
+ecall
+mv s2, a0
+addi a0, zero, 0
+addi a7, zero, 214
+ecall
+mv s3, a0
+addi a0, zero, 48
+ecall
+mv s4, a0
+addi a1, zero, 32
+addi a7, zero, 403
+ecall
+addi a7, zero, 1000
+ecall
+mv s5, a0
+addi a0, zero, 3
+addi a7, zero, 93
+ecall
+addi s6, zero, 1`)
+	require.NoError(t, err)
+	r := NewRunner(app, 64)
+	var stdout strings.Builder
+	r.Ctx.Env = &Linux{Stdin: strings.NewReader("abcdefgh"), Stdout: &stdout}
+	copy(r.Ctx.Memory[16:], []int8{'h', 'i', '\n'})
+
+	err = r.Run()
+	var exitErr *ExitError
+	require.ErrorAs(t, err, &exitErr)
+	assert.Equal(t, int32(3), exitErr.Code)
+	assert.Equal(t, "hi\n", stdout.String())
+	assert.Equal(t, int32(3), r.Ctx.Registers[S0])
+	// The read stops at the end of the 64-byte block
+	assert.Equal(t, int32(4), r.Ctx.Registers[S1])
+	assert.Equal(t, []int8{'a', 'b', 'c', 'd'}, r.Ctx.Memory[60:64])
+	// No stderr
+	assert.Equal(t, int32(-9), r.Ctx.Registers[S2])
+	assert.Equal(t, int32(0), r.Ctx.Registers[S3])
+	assert.Equal(t, int32(48), r.Ctx.Registers[S4])
+	// The timespec holds the number of cycles as nanoseconds
+	assert.Equal(t, []int8{0, 0, 0, 0, 0, 0, 0, 0, 25, 0, 0, 0, 0, 0, 0, 0}, r.Ctx.Memory[32:48])
+	// Unknown syscall
+	assert.Equal(t, int32(-38), r.Ctx.Registers[S5])
+	assert.Equal(t, int32(0), r.Ctx.Registers[S6])
+}
+
+func runFAssert(t *testing.T, initRegisters map[RegisterType]int32, initFRegisters map[RegisterType]uint64, instructions string, assertionsRegisters map[RegisterType]int32, assertionsFRegisters map[RegisterType]uint64) *Context {
+	app, err := Parse(instructions)
+	require.NoError(t, err)
+	r := NewRunner(app, 16)
+	for k, v := range initRegisters {
+		r.Ctx.Registers[k] = v
+	}
+	for k, v := range initFRegisters {
+		r.Ctx.FRegisters[k] = v
+	}
+
+	err = r.Run()
+	require.NoError(t, err)
+
+	for k, v := range assertionsRegisters {
+		assert.Equal(t, v, r.Ctx.Registers[k], "register")
+	}
+	for k, v := range assertionsFRegisters {
+		assert.Equal(t, v, r.Ctx.FRegisters[k], "floating-point register %v", k)
+	}
+	return r.Ctx
+}
+
+func boxed(f float32) uint64 {
+	return single.box(uint64(math.Float32bits(f)))
+}
+
+func TestFaddS(t *testing.T) {
+	ctx := runFAssert(t, nil, map[RegisterType]uint64{Fa1: boxed(1.5), Fa2: boxed(2.25)},
+		"fadd.s fa0, fa1, fa2", nil, map[RegisterType]uint64{Fa0: boxed(3.75)})
+	assert.Equal(t, int32(0), ctx.FCSR)
+}
+
+func TestFaddSNotBoxed(t *testing.T) {
+	runFAssert(t, nil, map[RegisterType]uint64{Fa1: uint64(math.Float32bits(1)), Fa2: boxed(2)},
+		"fadd.s fa0, fa1, fa2", nil, map[RegisterType]uint64{Fa0: single.box(single.qnan)})
+}
+
+func TestFdivD(t *testing.T) {
+	ctx := runFAssert(t, nil, map[RegisterType]uint64{Ft1: math.Float64bits(1), Ft2: math.Float64bits(3)},
+		"fdiv.d f0, f1, f2, rup", nil, map[RegisterType]uint64{Ft0: math.Float64bits(1.0/3) + 1})
+	assert.Equal(t, int32(flagNX), ctx.FCSR)
+}
+
+func TestFmaddD(t *testing.T) {
+	runFAssert(t, nil, map[RegisterType]uint64{Ft1: math.Float64bits(2), Ft2: math.Float64bits(3), Ft3: math.Float64bits(-1)},
+		"fmadd.d ft0, ft1, ft2, ft3\nfnmsub.d ft4, ft1, ft2, ft3", nil,
+		map[RegisterType]uint64{Ft0: math.Float64bits(5), Ft4: math.Float64bits(-7)})
+}
+
+func TestFcvt(t *testing.T) {
+	runFAssert(t, map[RegisterType]int32{A1: -3}, map[RegisterType]uint64{Fa1: boxed(2.5)},
+		`fcvt.w.s a0, fa1
+		fcvt.w.s a2, fa1, rmm
+		fcvt.d.w fa2, a1
+		fcvt.s.d fa3, fa2
+		fcvt.wu.s a3, fa3`,
+		map[RegisterType]int32{A0: 2, A2: 3, A3: 0},
+		map[RegisterType]uint64{Fa2: math.Float64bits(-3), Fa3: boxed(-3)})
+}
+
+func TestFcsrRoundingMode(t *testing.T) {
+	app, err := Parse("fcvt.w.s a0, fa1")
+	require.NoError(t, err)
+	r := NewRunner(app, 0)
+	r.Ctx.FRegisters[Fa1] = boxed(2.5)
+	r.Ctx.FCSR = rmRUP << 5
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(3), r.Ctx.Registers[A0])
+	assert.Equal(t, int32(rmRUP<<5|flagNX), r.Ctx.FCSR)
+
+	r = NewRunner(app, 0)
+	r.Ctx.FCSR = 5 << 5
+	assert.Error(t, r.Run())
+}
+
+func TestFcmp(t *testing.T) {
+	ctx := runFAssert(t, nil, map[RegisterType]uint64{Fa1: boxed(1), Fa2: boxed(2), Fa3: single.box(single.qnan)},
+		`feq.s a0, fa1, fa1
+		flt.s a1, fa1, fa2
+		fle.s a2, fa2, fa1
+		feq.s a3, fa1, fa3`,
+		map[RegisterType]int32{A0: 1, A1: 1, A2: 0, A3: 0}, nil)
+	assert.Equal(t, int32(0), ctx.FCSR)
+}
+
+func TestFlwFsw(t *testing.T) {
+	runFAssert(t, map[RegisterType]int32{T0: 8}, map[RegisterType]uint64{Fs0: boxed(-2)},
+		`fsw fs0, -4(t0)
+		flw fs1, 4(zero)`,
+		nil, map[RegisterType]uint64{Fs1: boxed(-2)})
+}
+
+func TestFldFsd(t *testing.T) {
+	runFAssert(t, map[RegisterType]int32{T0: 8}, map[RegisterType]uint64{Fs0: math.Float64bits(math.Pi)},
+		`fsd fs0, 0(t0)
+		fld fs1, 8(zero)`,
+		nil, map[RegisterType]uint64{Fs1: math.Float64bits(math.Pi)})
+}
+
+func TestFmv(t *testing.T) {
+	runFAssert(t, map[RegisterType]int32{A1: -1082130432}, nil,
+		`fmv.w.x fa0, a1
+		fsgnjn.s fa1, fa0, fa0
+		fmv.x.w a0, fa1
+		fclass.s a2, fa1`,
+		map[RegisterType]int32{A0: 0x3f800000, A2: 1 << 6},
+		map[RegisterType]uint64{Fa0: boxed(-1), Fa1: boxed(1)})
 }
 
 func TestJal(t *testing.T) {
@@ -186,11 +507,57 @@ func TestLi(t *testing.T) {
 		`li t0, 42`, map[RegisterType]int32{T0: 42}, map[int]int8{})
 }
 
+func TestLrWScW(t *testing.T) {
+	// Successful sequence
+	runAssert(t, map[RegisterType]int32{T0: 4, T1: 1}, 8, map[int]int8{4: 5},
+		`lr.w t2, (t0)
+add t2, t2, t1
+sc.w t3, t2, (t0)`, map[RegisterType]int32{T2: 6, T3: 0}, map[int]int8{4: 6})
+
+	// The reservation is consumed by the first sc.w
+	runAssert(t, map[RegisterType]int32{T0: 4, T1: 1}, 8, map[int]int8{4: 5},
+		`lr.w t2, (t0)
+sc.w t3, t1, (t0)
+sc.w t3, t2, (t0)`, map[RegisterType]int32{T3: 1}, map[int]int8{4: 1})
+
+	// No reservation
+	runAssert(t, map[RegisterType]int32{T0: 4, T1: 1}, 8, map[int]int8{4: 5},
+		"sc.w t3, t1, (t0)", map[RegisterType]int32{T3: 1}, map[int]int8{4: 5})
+
+	// Reservation on another address
+	runAssert(t, map[RegisterType]int32{T0: 4, T1: 1}, 8, map[int]int8{4: 5},
+		`lr.w t2, (zero)
+sc.w t3, t1, (t0)`, map[RegisterType]int32{T3: 1}, map[int]int8{4: 5})
+}
+
 func TestMul(t *testing.T) {
 	runAssert(t, map[RegisterType]int32{T1: 4, T2: 2}, 0, map[int]int8{},
 		`mul t0, t1, t2`, map[RegisterType]int32{T0: 8}, map[int]int8{})
 }
 
+func TestMulh(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T1: math.MaxInt32, T2: 4}, 0, map[int]int8{},
+		`mulh t0, t1, t2`, map[RegisterType]int32{T0: 1}, map[int]int8{})
+
+	runAssert(t, map[RegisterType]int32{T1: -1, T2: 4}, 0, map[int]int8{},
+		`mulh t0, t1, t2`, map[RegisterType]int32{T0: -1}, map[int]int8{})
+}
+
+func TestMulhsu(t *testing.T) {
+	// -1 * 0xffffffff
+	runAssert(t, map[RegisterType]int32{T1: -1, T2: -1}, 0, map[int]int8{},
+		`mulhsu t0, t1, t2`, map[RegisterType]int32{T0: -1}, map[int]int8{})
+
+	runAssert(t, map[RegisterType]int32{T1: 2, T2: -1}, 0, map[int]int8{},
+		`mulhsu t0, t1, t2`, map[RegisterType]int32{T0: 1}, map[int]int8{})
+}
+
+func TestMulhu(t *testing.T) {
+	// 0xffffffff * 0xffffffff
+	runAssert(t, map[RegisterType]int32{T1: -1, T2: -1}, 0, map[int]int8{},
+		`mulhu t0, t1, t2`, map[RegisterType]int32{T0: -2}, map[int]int8{})
+}
+
 func TestOr(t *testing.T) {
 	runAssert(t, map[RegisterType]int32{T1: 1, T2: 2}, 0, map[int]int8{},
 		`or t0, t1, t2`, map[RegisterType]int32{T0: 3}, map[int]int8{})
@@ -207,11 +574,35 @@ func TestRem(t *testing.T) {
 
 	runAssert(t, map[RegisterType]int32{T1: 4, T2: 3}, 0, map[int]int8{},
 		`rem t0, t1, t2`, map[RegisterType]int32{T0: 1}, map[int]int8{})
+
+	runAssert(t, map[RegisterType]int32{T1: -7, T2: 2}, 0, map[int]int8{},
+		`rem t0, t1, t2`, map[RegisterType]int32{T0: -1}, map[int]int8{})
+
+	// Division by zero
+	runAssert(t, map[RegisterType]int32{T1: 4, T2: 0}, 0, map[int]int8{},
+		`rem t0, t1, t2`, map[RegisterType]int32{T0: 4}, map[int]int8{})
+
+	// Overflow
+	runAssert(t, map[RegisterType]int32{T1: math.MinInt32, T2: -1}, 0, map[int]int8{},
+		`rem t0, t1, t2`, map[RegisterType]int32{T0: 0}, map[int]int8{})
+}
+
+func TestRemu(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T1: -1, T2: 10}, 0, map[int]int8{},
+		`remu t0, t1, t2`, map[RegisterType]int32{T0: 5}, map[int]int8{})
+
+	// Division by zero
+	runAssert(t, map[RegisterType]int32{T1: -4, T2: 0}, 0, map[int]int8{},
+		`remu t0, t1, t2`, map[RegisterType]int32{T0: -4}, map[int]int8{})
 }
 
 func TestSll(t *testing.T) {
 	runAssert(t, map[RegisterType]int32{T1: 1, T2: 2}, 0, map[int]int8{},
 		`sll t0, t1, t2`, map[RegisterType]int32{T0: 4}, map[int]int8{})
+
+	// Only the low 5 bits of rs2 hold the shift amount
+	runAssert(t, map[RegisterType]int32{T1: 1, T2: -31}, 0, map[int]int8{},
+		`sll t0, t1, t2`, map[RegisterType]int32{T0: 2}, map[int]int8{})
 }
 
 func TestSlli(t *testing.T) {
@@ -229,14 +620,35 @@ func TestSlti(t *testing.T) {
 		`slti t0, t1, 5`, map[RegisterType]int32{T0: 1}, map[int]int8{})
 }
 
+func TestSltiu(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T1: 2}, 0, map[int]int8{},
+		`sltiu t0, t1, 5`, map[RegisterType]int32{T0: 1}, map[int]int8{})
+
+	// The immediate is sign-extended, then compared as unsigned
+	runAssert(t, map[RegisterType]int32{T1: 2}, 0, map[int]int8{},
+		`sltiu t0, t1, -1`, map[RegisterType]int32{T0: 1}, map[int]int8{})
+
+	runAssert(t, map[RegisterType]int32{T1: -2}, 0, map[int]int8{},
+		`sltiu t0, t1, 5`, map[RegisterType]int32{T0: 0}, map[int]int8{})
+}
+
 func TestSltu(t *testing.T) {
 	runAssert(t, map[RegisterType]int32{T1: 2, T2: 3}, 0, map[int]int8{},
 		`sltu t0, t1, t2`, map[RegisterType]int32{T0: 1}, map[int]int8{})
+
+	runAssert(t, map[RegisterType]int32{T1: 2, T2: -3}, 0, map[int]int8{},
+		`sltu t0, t1, t2`, map[RegisterType]int32{T0: 1}, map[int]int8{})
+
+	runAssert(t, map[RegisterType]int32{T1: -2, T2: 3}, 0, map[int]int8{},
+		`sltu t0, t1, t2`, map[RegisterType]int32{T0: 0}, map[int]int8{})
 }
 
 func TestSra(t *testing.T) {
 	runAssert(t, map[RegisterType]int32{T1: 2, T2: 1}, 0, map[int]int8{},
 		`sra t0, t1, t2`, map[RegisterType]int32{T0: 1}, map[int]int8{})
+
+	runAssert(t, map[RegisterType]int32{T1: -8, T2: 33}, 0, map[int]int8{},
+		`sra t0, t1, t2`, map[RegisterType]int32{T0: -4}, map[int]int8{})
 }
 
 func TestSrai(t *testing.T) {
@@ -247,11 +659,18 @@ func TestSrai(t *testing.T) {
 func TestSrl(t *testing.T) {
 	runAssert(t, map[RegisterType]int32{T1: 4, T2: 2}, 0, map[int]int8{},
 		`srl t0, t1, t2`, map[RegisterType]int32{T0: 1}, map[int]int8{})
+
+	// The shift is logical
+	runAssert(t, map[RegisterType]int32{T1: -8, T2: 1}, 0, map[int]int8{},
+		`srl t0, t1, t2`, map[RegisterType]int32{T0: 0x7ffffffc}, map[int]int8{})
 }
 
 func TestSrli(t *testing.T) {
 	runAssert(t, map[RegisterType]int32{T1: 4}, 0, map[int]int8{},
 		`srli t0, t1, 2`, map[RegisterType]int32{T0: 1}, map[int]int8{})
+
+	runAssert(t, map[RegisterType]int32{T1: -1}, 0, map[int]int8{},
+		`srli t0, t1, 31`, map[RegisterType]int32{T0: 1}, map[int]int8{})
 }
 
 func TestSub(t *testing.T) {
@@ -261,34 +680,60 @@ func TestSub(t *testing.T) {
 
 func TestSbLb(t *testing.T) {
 	runAssert(t, map[RegisterType]int32{T0: 16, T1: 2}, 8, map[int]int8{},
-		`sb t0, 2, t1
-	lb t2, 2, t1`, map[RegisterType]int32{T2: 16}, map[int]int8{4: 16})
+		`sb t0, 2(t1)
+	lb t2, 2(t1)`, map[RegisterType]int32{T2: 16}, map[int]int8{4: 16})
 
 	runAssert(t, map[RegisterType]int32{T0: 2047, T1: 2}, 8, map[int]int8{},
-		`sb t0, 2, t1
-lb t2, 2, t1`, map[RegisterType]int32{T2: -1}, map[int]int8{4: -1})
+		`sb t0, 2(t1)
+lb t2, 2(t1)`, map[RegisterType]int32{T2: -1}, map[int]int8{4: -1})
 }
 
 func TestShLh(t *testing.T) {
 	runAssert(t, map[RegisterType]int32{T0: 64, T1: 2}, 8, map[int]int8{4: 1, 5: 1},
-		`sh t0, 2, t1
-lh t2, 2, t1`, map[RegisterType]int32{T2: 64}, map[int]int8{4: 64, 5: 0})
+		`sh t0, 2(t1)
+lh t2, 2(t1)`, map[RegisterType]int32{T2: 64}, map[int]int8{4: 64, 5: 0})
 
 	runAssert(t, map[RegisterType]int32{T0: 2047, T1: 2}, 8, map[int]int8{4: 1, 5: 1},
-		`sh t0, 2, t1
-lh t2, 2, t1`, map[RegisterType]int32{T2: 2047}, map[int]int8{4: -1, 5: 7})
+		`sh t0, 2(t1)
+lh t2, 2(t1)`, map[RegisterType]int32{T2: 2047}, map[int]int8{4: -1, 5: 7})
+}
+
+func TestSbLbu(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: 2047, T1: 2}, 8, map[int]int8{},
+		`sb t0, 2(t1)
+lbu t2, 2(t1)`, map[RegisterType]int32{T2: 255}, map[int]int8{4: -1})
+}
+
+func TestShLhSignExtension(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: -32767, T1: 2}, 8, map[int]int8{},
+		`sh t0, 2(t1)
+lh t2, 2(t1)`, map[RegisterType]int32{T2: -32767}, map[int]int8{4: 1, 5: -128})
+}
+
+func TestShLhu(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: -32767, T1: 2}, 8, map[int]int8{},
+		`sh t0, 2(t1)
+lhu t2, 2(t1)`, map[RegisterType]int32{T2: 32769}, map[int]int8{4: 1, 5: -128})
 }
 
 func TestSwLw(t *testing.T) {
 	runAssert(t, map[RegisterType]int32{T0: 258, T1: 2}, 8, map[int]int8{4: 1, 5: 1, 6: 1, 7: 1},
-		`sw t0, 2, t1
+		`sw t0, 2(t1)
 lw t2, 2(t1)`, map[RegisterType]int32{T2: 258}, map[int]int8{4: 2, 5: 1, 6: 0, 7: 0})
 
 	runAssert(t, map[RegisterType]int32{T0: 2047, T1: 2}, 8, map[int]int8{4: 1, 5: 1, 6: 1, 7: 1},
-		`sw t0, 2, t1
+		`sw t0, 2(t1)
 lw t2, 2(t1)`, map[RegisterType]int32{T2: 2047}, map[int]int8{4: -1, 5: 7, 6: 0, 7: 0})
 }
 
+func TestFence(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: 1}, 8, map[int]int8{},
+		`sw t0, 0(zero)
+fence
+fence rw, rw
+lw t1, 0(zero)`, map[RegisterType]int32{T1: 1}, map[int]int8{0: 1})
+}
+
 func TestXor(t *testing.T) {
 	runAssert(t, map[RegisterType]int32{T1: 3, T2: 4}, 0, map[int]int8{},
 		"xor t0, t1, t2", map[RegisterType]int32{T0: 7}, map[int]int8{})
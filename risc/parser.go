@@ -6,595 +6,973 @@ import (
 	"strings"
 )
 
+// Parse assembles an application. The error returned, if any, is the
+// ParseErrors listing all the errors of the source.
 func Parse(s string) (Application, error) {
-	var instructions []InstructionRunner
-	labels := make(map[string]int32)
-	var pc int32
+	return ParseFile("", s)
+}
 
-	for _, line := range strings.Split(s, "\n") {
-		line = strings.TrimSpace(line)
-		if len(line) == 0 || line[0] == '#' {
-			continue
-		}
+// ParseFile is Parse with the name of the source file, used to locate the
+// diagnostics. As there's no file system, the source can't include files.
+func ParseFile(filename, s string) (Application, error) {
+	return assembleLines(preprocess(nil, filename, s))
+}
 
-		firstWhitespace := strings.Index(line, " ")
-		lastCharacters := line[len(line)-1]
-		if firstWhitespace == -1 && lastCharacters == ':' {
-			labels[line[:len(line)-1]] = pc
-			continue
+// assembleLines assembles the preprocessed lines of an application in two
+// passes: the first one finds the addresses of the labels, which the
+// expressions of the second one refer to.
+func assembleLines(lines []inputLine) (Application, error) {
+	first, _ := parse(lines, nil)
+	app, errs := parse(lines, first.Labels)
+	if len(errs) > 0 {
+		return Application{}, errs
+	}
+	return app, nil
+}
+
+// assembler holds the state of a pass over the text section.
+type assembler struct {
+	pcs          []int32
+	instructions []InstructionRunner
+	labels       map[string]int32
+	pc           int32
+	exprs        *expressions
+	// input and source locate the line being assembled.
+	input    inputLine
+	source   sourceLine
+	warnings ParseErrors
+}
+
+// parse assembles the lines of an application. known holds the labels found by
+// the first pass, or nil during the first pass. The errors don't stop the
+// parse: the lines are all assembled, to report every error.
+func parse(lines []inputLine, known map[string]int32) (Application, ParseErrors) {
+	data, dataErrs := layoutData(lines, known)
+	a := &assembler{
+		labels: make(map[string]int32),
+		exprs:  newExpressions(known),
+	}
+	for label, addr := range data.labels {
+		a.labels[label] = addr
+	}
+	a.exprs.labels = a.labels
+	dataLabels := make(map[string]bool, len(data.labels))
+	for label := range data.labels {
+		dataLabels[label] = true
+	}
+
+	var errs ParseErrors
+
+	// assemble assembles a statement of the text section: a directive or an
+	// instruction.
+	assemble := func(line, directive, args string) error {
+		a.exprs.pc = a.pc
+		if line[0] == '.' {
+			switch {
+			case metadataDirectives[directive]:
+			case directive == ".align" || directive == ".p2align":
+				n, err := a.exprs.constant(args)
+				if err != nil {
+					return err
+				}
+				if n < 0 || n > 12 {
+					return newDiagnostic(CodeOutOfRange, args, "invalid alignment: %d", n)
+				}
+				// The text is padded with nops, c.nop if misaligned on 4 bytes
+				for alignment := int32(1) << n; a.pc%alignment != 0; {
+					if a.pc%4 != 0 {
+						a.emit(&compressed{&addi{rd: Zero, rs: Zero}})
+					} else {
+						a.emit(&nop{})
+					}
+				}
+			default:
+				return newDiagnostic(CodeUnsupportedDirective, directive, "unsupported directive in the text section: %s", directive)
+			}
+			return nil
 		}
 
+		firstWhitespace := strings.Index(line, " ")
 		remainingLine := line[firstWhitespace+1:]
-		comment := strings.Index(remainingLine, "#")
-		if comment != -1 {
-			remainingLine = strings.TrimSpace(remainingLine[:comment])
+		elements, err := splitOperands(remainingLine)
+		if err != nil {
+			return err
 		}
 
-		elements := strings.Split(remainingLine, ",")
-
 		del := firstWhitespace
 		if del == -1 {
 			del = len(line)
 		}
-		switch strings.ToLower(line[:del]) {
+		mnemonic := trimMemoryOrdering(strings.ToLower(line[:del]))
+		compressedMnemonic := strings.HasPrefix(mnemonic, "c.")
+		if compressedMnemonic {
+			var err error
+			mnemonic, elements, err = expandCompressed(mnemonic, elements, a.exprs)
+			if err != nil {
+				return err
+			}
+		}
+		mnemonic, elements = expandCSRPseudoInstruction(mnemonic, elements)
+		if runners, ok, err := expandPseudoInstruction(mnemonic, elements, a.exprs); ok {
+			if err != nil {
+				return err
+			}
+			for _, runner := range runners {
+				a.emit(runner)
+			}
+			return nil
+		}
+		switch mnemonic {
 		case "add":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &add{
+			a.instructions = append(a.instructions, &add{
 				rd:  rd,
 				rs1: rs1,
 				rs2: rs2,
 			})
+		case "amoadd.w", "amoand.w", "amomax.w", "amomaxu.w", "amomin.w", "amominu.w", "amoor.w", "amoswap.w", "amoxor.w":
+			if err := validateArgs(3, elements, remainingLine); err != nil {
+				return err
+			}
+			rd, err := parseRegister(strings.TrimSpace(elements[0]))
+			if err != nil {
+				return err
+			}
+			rs2, err := parseRegister(strings.TrimSpace(elements[1]))
+			if err != nil {
+				return err
+			}
+			rs1, err := parseAtomicAddress(strings.TrimSpace(elements[2]), a.exprs)
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &amo{
+				instructionType: amoInstructionTypes[mnemonic],
+				rd:              rd,
+				rs1:             rs1,
+				rs2:             rs2,
+			})
 		case "and":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &and{
+			a.instructions = append(a.instructions, &and{
 				rd:  rd,
 				rs1: rs1,
 				rs2: rs2,
 			})
 		case "addi":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			imm, err := strconv.ParseInt(strings.TrimSpace(elements[2]), 10, 32)
+			imm, err := a.exprs.immediate(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &addi{
+			a.instructions = append(a.instructions, &addi{
 				imm: int32(imm),
 				rd:  rd,
 				rs:  rs,
 			})
 		case "andi":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			imm, err := strconv.ParseInt(strings.TrimSpace(elements[2]), 10, 32)
+			imm, err := a.exprs.immediate(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &andi{
+			a.instructions = append(a.instructions, &andi{
 				imm: int32(imm),
 				rd:  rd,
 				rs:  rs,
 			})
 		case "auipc":
 			if err := validateArgs(2, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			imm, err := strconv.ParseInt(strings.TrimSpace(elements[1]), 10, 32)
+			imm, err := a.exprs.upperImmediate(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &auipc{
+			a.instructions = append(a.instructions, &auipc{
 				rd:  rd,
-				imm: int32(imm),
+				imm: imm,
 			})
 		case "beq":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
+			}
+			label, offset, err := a.exprs.target(strings.TrimSpace(elements[2]))
+			if err != nil {
+				return err
 			}
-			label := strings.TrimSpace(elements[2])
-			instructions = append(instructions, &beq{
-				rs1:   rd,
-				rs2:   rs,
-				label: label,
+			a.instructions = append(a.instructions, &beq{
+				rs1:    rd,
+				rs2:    rs,
+				label:  label,
+				offset: offset,
 			})
 		case "beqz":
 			if err := validateArgs(2, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			label := strings.TrimSpace(elements[1])
-			instructions = append(instructions, &beqz{
-				rs:    rs,
-				label: label,
+			label, offset, err := a.exprs.target(strings.TrimSpace(elements[1]))
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &beqz{
+				rs:     rs,
+				label:  label,
+				offset: offset,
 			})
 		case "bge":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
+			}
+			label, offset, err := a.exprs.target(strings.TrimSpace(elements[2]))
+			if err != nil {
+				return err
 			}
-			label := strings.TrimSpace(elements[2])
-			instructions = append(instructions, &bge{
-				rs1:   rs1,
-				rs2:   rs2,
-				label: label,
+			a.instructions = append(a.instructions, &bge{
+				rs1:    rs1,
+				rs2:    rs2,
+				label:  label,
+				offset: offset,
 			})
 		case "bgeu":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			label := strings.TrimSpace(elements[2])
-			instructions = append(instructions, &bgeu{
-				rs1:   rs1,
-				rs2:   rs2,
-				label: label,
+			label, offset, err := a.exprs.target(strings.TrimSpace(elements[2]))
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &bgeu{
+				rs1:    rs1,
+				rs2:    rs2,
+				label:  label,
+				offset: offset,
 			})
 		case "ble":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			label := strings.TrimSpace(elements[2])
-			instructions = append(instructions, &ble{
-				rs1:   rs1,
-				rs2:   rs2,
-				label: label,
+			label, offset, err := a.exprs.target(strings.TrimSpace(elements[2]))
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &ble{
+				rs1:    rs1,
+				rs2:    rs2,
+				label:  label,
+				offset: offset,
 			})
 		case "blt":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			label := strings.TrimSpace(elements[2])
-			instructions = append(instructions, &blt{
-				rs1:   rs1,
-				rs2:   rs2,
-				label: label,
+			label, offset, err := a.exprs.target(strings.TrimSpace(elements[2]))
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &blt{
+				rs1:    rs1,
+				rs2:    rs2,
+				label:  label,
+				offset: offset,
 			})
 		case "bltu":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
+			}
+			label, offset, err := a.exprs.target(strings.TrimSpace(elements[2]))
+			if err != nil {
+				return err
 			}
-			label := strings.TrimSpace(elements[2])
-			instructions = append(instructions, &bltu{
-				rs1:   rs1,
-				rs2:   rs2,
-				label: label,
+			a.instructions = append(a.instructions, &bltu{
+				rs1:    rs1,
+				rs2:    rs2,
+				label:  label,
+				offset: offset,
 			})
 		case "bne":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			label := strings.TrimSpace(elements[2])
-			instructions = append(instructions, &bne{
-				rs1:   rs1,
-				rs2:   rs2,
-				label: label,
+			label, offset, err := a.exprs.target(strings.TrimSpace(elements[2]))
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &bne{
+				rs1:    rs1,
+				rs2:    rs2,
+				label:  label,
+				offset: offset,
 			})
 		case "bnez":
 			if err := validateArgs(2, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			label := strings.TrimSpace(elements[1])
-			instructions = append(instructions, &bnez{
-				rs:    rs,
-				label: label,
+			label, offset, err := a.exprs.target(strings.TrimSpace(elements[1]))
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &bnez{
+				rs:     rs,
+				label:  label,
+				offset: offset,
 			})
+		case "csrrc", "csrrci", "csrrs", "csrrsi", "csrrw", "csrrwi":
+			op, err := parseCSRInstruction(mnemonic, elements, remainingLine, a.exprs)
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, op)
 		case "div":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &div{
+			a.instructions = append(a.instructions, &div{
 				rd:  rd,
 				rs1: rs1,
 				rs2: rs2,
 			})
+		case "divu":
+			if err := validateArgs(3, elements, remainingLine); err != nil {
+				return err
+			}
+			rd, err := parseRegister(strings.TrimSpace(elements[0]))
+			if err != nil {
+				return err
+			}
+			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
+			if err != nil {
+				return err
+			}
+			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &divu{
+				rd:  rd,
+				rs1: rs1,
+				rs2: rs2,
+			})
+		case "ebreak":
+			a.instructions = append(a.instructions, &ebreak{})
+		case "ecall":
+			a.instructions = append(a.instructions, &ecall{})
+		case "fence":
+			// The predecessor and successor sets are optional, as every fence
+			// orders all the memory accesses
+			if firstWhitespace != -1 {
+				if err := validateArgs(2, elements, remainingLine); err != nil {
+					return err
+				}
+				for _, element := range elements {
+					if err := validateFenceSet(strings.TrimSpace(element)); err != nil {
+						return err
+					}
+				}
+			}
+			a.instructions = append(a.instructions, &fence{})
+		case "fld", "flw":
+			if err := validateArgs(2, elements, remainingLine); err != nil {
+				return err
+			}
+			rd, err := parseFRegister(strings.TrimSpace(elements[0]))
+			if err != nil {
+				return err
+			}
+			offset, rs, err := parseOffsetReg(strings.TrimSpace(elements[1]), a.exprs)
+			if err != nil {
+				return err
+			}
+			instructionType := Flw
+			if mnemonic == "fld" {
+				instructionType = Fld
+			}
+			a.instructions = append(a.instructions, &fload{
+				instructionType: instructionType,
+				rd:              rd,
+				offset:          offset,
+				rs:              rs,
+			})
+		case "fsd", "fsw":
+			if err := validateArgs(2, elements, remainingLine); err != nil {
+				return err
+			}
+			rs2, err := parseFRegister(strings.TrimSpace(elements[0]))
+			if err != nil {
+				return err
+			}
+			offset, rs1, err := parseOffsetReg(strings.TrimSpace(elements[1]), a.exprs)
+			if err != nil {
+				return err
+			}
+			instructionType := Fsw
+			if mnemonic == "fsd" {
+				instructionType = Fsd
+			}
+			a.instructions = append(a.instructions, &fstore{
+				instructionType: instructionType,
+				rs:              rs2,
+				offset:          offset,
+				rd:              rs1,
+			})
 		case "j":
 			if err := validateArgs(1, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
+			}
+			label, offset, err := a.exprs.target(strings.TrimSpace(elements[0]))
+			if err != nil {
+				return err
 			}
-			label := strings.TrimSpace(elements[0])
-			instructions = append(instructions, &j{
-				label: label,
+			a.instructions = append(a.instructions, &j{
+				label:  label,
+				offset: offset,
 			})
 		case "jal":
 			if err := validateArgs(2, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			label := strings.TrimSpace(elements[1])
-			instructions = append(instructions, &jal{
-				label: label,
-				rd:    rd,
+			label, offset, err := a.exprs.target(strings.TrimSpace(elements[1]))
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &jal{
+				label:  label,
+				offset: offset,
+				rd:     rd,
 			})
 		case "jalr":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			imm, err := strconv.ParseInt(strings.TrimSpace(elements[2]), 10, 32)
+			imm, err := a.exprs.immediate(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &jalr{
+			a.instructions = append(a.instructions, &jalr{
 				rd:  rd,
 				rs:  rs,
 				imm: int32(imm),
 			})
 		case "lui":
 			if err := validateArgs(2, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			imm, err := strconv.ParseInt(strings.TrimSpace(elements[1]), 10, 32)
+			imm, err := a.exprs.upperImmediate(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &lui{
+			a.instructions = append(a.instructions, &lui{
 				rd:  rd,
-				imm: int32(imm),
+				imm: imm,
 			})
 		case "lb":
 			if err := validateArgs(2, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			offset, rs, err := parseOffsetReg(strings.TrimSpace(elements[1]))
+			offset, rs, err := parseOffsetReg(strings.TrimSpace(elements[1]), a.exprs)
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &lb{
+			a.instructions = append(a.instructions, &lb{
+				rd:     rd,
+				offset: offset,
+				rs:     rs,
+			})
+		case "lbu":
+			if err := validateArgs(2, elements, remainingLine); err != nil {
+				return err
+			}
+			rd, err := parseRegister(strings.TrimSpace(elements[0]))
+			if err != nil {
+				return err
+			}
+			offset, rs, err := parseOffsetReg(strings.TrimSpace(elements[1]), a.exprs)
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &lbu{
 				rd:     rd,
 				offset: offset,
 				rs:     rs,
 			})
 		case "lh":
 			if err := validateArgs(2, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			offset, rs, err := parseOffsetReg(strings.TrimSpace(elements[1]))
+			offset, rs, err := parseOffsetReg(strings.TrimSpace(elements[1]), a.exprs)
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &lh{
+			a.instructions = append(a.instructions, &lh{
 				rd:     rd,
 				offset: offset,
 				rs:     rs,
 			})
-		case "li":
+		case "lhu":
 			if err := validateArgs(2, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			imm, err := strconv.ParseInt(strings.TrimSpace(elements[1]), 10, 32)
+			offset, rs, err := parseOffsetReg(strings.TrimSpace(elements[1]), a.exprs)
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &li{
-				rd:  rd,
-				imm: int32(imm),
+			a.instructions = append(a.instructions, &lhu{
+				rd:     rd,
+				offset: offset,
+				rs:     rs,
+			})
+		case "lr.w":
+			if err := validateArgs(2, elements, remainingLine); err != nil {
+				return err
+			}
+			rd, err := parseRegister(strings.TrimSpace(elements[0]))
+			if err != nil {
+				return err
+			}
+			rs, err := parseAtomicAddress(strings.TrimSpace(elements[1]), a.exprs)
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &lrw{
+				rd: rd,
+				rs: rs,
 			})
 		case "lw":
 			if err := validateArgs(2, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			offset, rs, err := parseOffsetReg(strings.TrimSpace(elements[1]))
+			offset, rs, err := parseOffsetReg(strings.TrimSpace(elements[1]), a.exprs)
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &lw{
+			a.instructions = append(a.instructions, &lw{
 				rd:     rd,
 				offset: offset,
 				rs:     rs,
 			})
+		case "mret":
+			a.instructions = append(a.instructions, &mret{})
 		case "nop":
-			instructions = append(instructions, &nop{})
+			a.instructions = append(a.instructions, &nop{})
 		case "mul":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
+			}
+			rd, err := parseRegister(strings.TrimSpace(elements[0]))
+			if err != nil {
+				return err
+			}
+			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
+			if err != nil {
+				return err
+			}
+			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &mul{
+				rd:  rd,
+				rs1: rs1,
+				rs2: rs2,
+			})
+		case "mulh":
+			if err := validateArgs(3, elements, remainingLine); err != nil {
+				return err
+			}
+			rd, err := parseRegister(strings.TrimSpace(elements[0]))
+			if err != nil {
+				return err
+			}
+			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
+			if err != nil {
+				return err
+			}
+			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &mulh{
+				rd:  rd,
+				rs1: rs1,
+				rs2: rs2,
+			})
+		case "mulhsu":
+			if err := validateArgs(3, elements, remainingLine); err != nil {
+				return err
+			}
+			rd, err := parseRegister(strings.TrimSpace(elements[0]))
+			if err != nil {
+				return err
+			}
+			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
+			if err != nil {
+				return err
+			}
+			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &mulhsu{
+				rd:  rd,
+				rs1: rs1,
+				rs2: rs2,
+			})
+		case "mulhu":
+			if err := validateArgs(3, elements, remainingLine); err != nil {
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &mul{
+			a.instructions = append(a.instructions, &mulhu{
 				rd:  rd,
 				rs1: rs1,
 				rs2: rs2,
 			})
 		case "mv":
 			if err := validateArgs(2, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &mv{
+			a.instructions = append(a.instructions, &mv{
 				rd: rd,
 				rs: rs,
 			})
 		case "or":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &or{
+			a.instructions = append(a.instructions, &or{
 				rd:  rd,
 				rs1: rs1,
 				rs2: rs2,
 			})
 		case "ori":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			imm, err := strconv.ParseInt(strings.TrimSpace(elements[2]), 10, 32)
+			imm, err := a.exprs.immediate(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &ori{
+			a.instructions = append(a.instructions, &ori{
 				imm: int32(imm),
 				rd:  rd,
 				rs:  rs,
 			})
 		case "rem":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
+			}
+			rd, err := parseRegister(strings.TrimSpace(elements[0]))
+			if err != nil {
+				return err
+			}
+			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
+			if err != nil {
+				return err
+			}
+			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &rem{
+				rd:  rd,
+				rs1: rs1,
+				rs2: rs2,
+			})
+		case "remu":
+			if err := validateArgs(3, elements, remainingLine); err != nil {
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &rem{
+			a.instructions = append(a.instructions, &remu{
 				rd:  rd,
 				rs1: rs1,
 				rs2: rs2,
 			})
 		case "ret":
-			instructions = append(instructions, &ret{})
+			a.instructions = append(a.instructions, &ret{})
 		case "sb":
 			if err := validateArgs(2, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			offset, rs1, err := parseOffsetReg(strings.TrimSpace(elements[1]))
+			offset, rs1, err := parseOffsetReg(strings.TrimSpace(elements[1]), a.exprs)
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &sb{
+			a.instructions = append(a.instructions, &sb{
 				rs:     rs2,
 				offset: offset,
 				rd:     rs1,
 			})
-		case "sh":
+		case "sc.w":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			rs2, err := parseRegister(strings.TrimSpace(elements[0]))
+			rd, err := parseRegister(strings.TrimSpace(elements[0]))
+			if err != nil {
+				return err
+			}
+			rs2, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
+			}
+			rs1, err := parseAtomicAddress(strings.TrimSpace(elements[2]), a.exprs)
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &scw{
+				rd:  rd,
+				rs1: rs1,
+				rs2: rs2,
+			})
+		case "sh":
+			if err := validateArgs(2, elements, remainingLine); err != nil {
+				return err
 			}
-			offset, err := strconv.ParseInt(strings.TrimSpace(elements[1]), 10, 32)
+			rs2, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			rs1, err := parseRegister(strings.TrimSpace(elements[2]))
+			offset, rs1, err := parseOffsetReg(strings.TrimSpace(elements[1]), a.exprs)
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &sh{
+			a.instructions = append(a.instructions, &sh{
 				rs:     rs2,
-				offset: int32(offset),
+				offset: offset,
 				rd:     rs1,
 			})
 
 		case "sll":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &sll{
+			a.instructions = append(a.instructions, &sll{
 				rd:  rd,
 				rs1: rs1,
 				rs2: rs2,
@@ -602,21 +980,21 @@ func Parse(s string) (Application, error) {
 
 		case "slli":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			imm, err := strconv.ParseInt(strings.TrimSpace(elements[2]), 10, 32)
+			imm, err := a.exprs.immediate(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &slli{
+			a.instructions = append(a.instructions, &slli{
 				rd:  rd,
 				rs:  rs,
 				imm: int32(imm),
@@ -624,21 +1002,21 @@ func Parse(s string) (Application, error) {
 
 		case "slt":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &slt{
+			a.instructions = append(a.instructions, &slt{
 				rd:  rd,
 				rs1: rs1,
 				rs2: rs2,
@@ -646,147 +1024,168 @@ func Parse(s string) (Application, error) {
 
 		case "sltu":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &sltu{
+			a.instructions = append(a.instructions, &sltu{
 				rd:  rd,
 				rs1: rs1,
 				rs2: rs2,
 			})
 		case "slti":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			imm, err := strconv.ParseInt(strings.TrimSpace(elements[2]), 10, 32)
+			imm, err := a.exprs.immediate(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &slti{
+			a.instructions = append(a.instructions, &slti{
+				rd:  rd,
+				rs:  rs,
+				imm: int32(imm),
+			})
+		case "sltiu":
+			if err := validateArgs(3, elements, remainingLine); err != nil {
+				return err
+			}
+			rd, err := parseRegister(strings.TrimSpace(elements[0]))
+			if err != nil {
+				return err
+			}
+			rs, err := parseRegister(strings.TrimSpace(elements[1]))
+			if err != nil {
+				return err
+			}
+			imm, err := a.exprs.immediate(strings.TrimSpace(elements[2]))
+			if err != nil {
+				return err
+			}
+			a.instructions = append(a.instructions, &sltiu{
 				rd:  rd,
 				rs:  rs,
 				imm: int32(imm),
 			})
 		case "sra":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &sra{
+			a.instructions = append(a.instructions, &sra{
 				rd:  rd,
 				rs1: rs1,
 				rs2: rs2,
 			})
 		case "srai":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			imm, err := strconv.ParseInt(strings.TrimSpace(elements[2]), 10, 32)
+			imm, err := a.exprs.immediate(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &srai{
+			a.instructions = append(a.instructions, &srai{
 				rd:  rd,
 				rs:  rs,
 				imm: int32(imm),
 			})
 		case "srl":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &srl{
+			a.instructions = append(a.instructions, &srl{
 				rd:  rd,
 				rs1: rs1,
 				rs2: rs2,
 			})
 		case "srli":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			imm, err := strconv.ParseInt(strings.TrimSpace(elements[2]), 10, 32)
+			imm, err := a.exprs.immediate(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &srli{
+			a.instructions = append(a.instructions, &srli{
 				rd:  rd,
 				rs:  rs,
 				imm: int32(imm),
 			})
 		case "sub":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &sub{
+			a.instructions = append(a.instructions, &sub{
 				rd:  rd,
 				rs1: rs1,
 				rs2: rs2,
@@ -794,38 +1193,43 @@ func Parse(s string) (Application, error) {
 
 		case "sw":
 			if err := validateArgs(2, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			offset, rs1, err := parseOffsetReg(strings.TrimSpace(elements[1]))
+			offset, rs1, err := parseOffsetReg(strings.TrimSpace(elements[1]), a.exprs)
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &sw{
+			a.instructions = append(a.instructions, &sw{
 				rs:     rs2,
 				offset: offset,
 				rd:     rs1,
 			})
+		case "unimp":
+			// A write to a read-only CSR, raising an illegal instruction exception
+			a.instructions = append(a.instructions, &csr{instructionType: Csrrw, rd: Zero, rs: Zero, csr: CSRCycle})
+		case "wfi":
+			a.instructions = append(a.instructions, &wfi{})
 		case "xor":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs1, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs2, err := parseRegister(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &xor{
+			a.instructions = append(a.instructions, &xor{
 				rd:  rd,
 				rs1: rs1,
 				rs2: rs2,
@@ -833,40 +1237,155 @@ func Parse(s string) (Application, error) {
 
 		case "xori":
 			if err := validateArgs(3, elements, remainingLine); err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rd, err := parseRegister(strings.TrimSpace(elements[0]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
 			rs, err := parseRegister(strings.TrimSpace(elements[1]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			imm, err := strconv.ParseInt(strings.TrimSpace(elements[2]), 10, 32)
+			imm, err := a.exprs.immediate(strings.TrimSpace(elements[2]))
 			if err != nil {
-				return Application{}, fmt.Errorf("line %s: %v", remainingLine, err)
+				return err
 			}
-			instructions = append(instructions, &xori{
+			a.instructions = append(a.instructions, &xori{
 				imm: int32(imm),
 				rd:  rd,
 				rs:  rs,
 			})
 		default:
-			return Application{}, fmt.Errorf("invalid instruction type: %s", line)
+			if _, exists := fpInstructions[mnemonic]; exists {
+				op, err := parseFop(mnemonic, elements, remainingLine)
+				if err != nil {
+					return err
+				}
+				a.instructions = append(a.instructions, op)
+				break
+			}
+			return newDiagnostic(CodeUnknownInstruction, line[:del], "unknown instruction: %s", line[:del])
+		}
+		runner := a.instructions[len(a.instructions)-1]
+		if !compressedMnemonic && writesZero(runner) {
+			a.warn(newDiagnostic(CodeWriteToZero, "zero", "%s writes to zero: the result is discarded", line[:del]))
 		}
-		pc += 4
+		if compressedMnemonic {
+			runner = &compressed{runner}
+			a.instructions[len(a.instructions)-1] = runner
+		}
+		a.pcs = append(a.pcs, a.pc)
+		a.pc += InstructionSize(runner)
+		return nil
 	}
 
+	current := sectionText
+	for i, input := range lines {
+		source := splitSourceLine(input.text)
+		a.input, a.source = input, source
+		if input.err != nil {
+			errs = append(errs, a.error(input.err))
+			continue
+		}
+		// The errors of the data sections are reported in order
+		errs = append(errs, dataErrs[i]...)
+		line := source.statement
+		directive, args, _ := strings.Cut(line, " ")
+		args = strings.TrimSpace(args)
+		if sec, exists, _ := parseSectionDirective(directive, args); exists {
+			current = sec
+			line = ""
+		}
+		if directive == ".equ" || directive == ".set" {
+			if err := a.exprs.define(args); err != nil {
+				errs = append(errs, a.error(err))
+			}
+			line = ""
+		}
+		if current != sectionText {
+			// The data sections are laid out by layoutData
+			continue
+		}
+		if source.label != "" {
+			if _, exists := a.labels[source.label]; exists {
+				errs = append(errs, a.error(newDiagnostic(CodeDuplicateLabel, source.label, "label %s already defined", source.label)))
+			} else {
+				a.labels[source.label] = a.pc
+			}
+		}
+		if len(line) == 0 {
+			continue
+		}
+		if err := assemble(line, directive, args); err != nil {
+			errs = append(errs, a.error(err))
+		}
+	}
+
+	instructions, isCompressed := layout(a.pcs, a.instructions)
 	return Application{
 		Instructions: instructions,
-		Labels:       labels,
-	}, nil
+		Compressed:   isCompressed,
+		Labels:       a.labels,
+		DataLabels:   dataLabels,
+		Segments:     data.segments,
+		Warnings:     a.warnings,
+	}, errs
+}
+
+// error locates an error in the line being assembled.
+func (a *assembler) error(err error) *ParseError {
+	return newParseError(a.input, a.source, SeverityError, err)
+}
+
+// warn reports a warning on the line being assembled.
+func (a *assembler) warn(err error) {
+	a.warnings = append(a.warnings, newParseError(a.input, a.source, SeverityWarning, err))
+}
+
+// emit appends an instruction at the current pc.
+func (a *assembler) emit(runner InstructionRunner) {
+	a.pcs = append(a.pcs, a.pc)
+	a.instructions = append(a.instructions, runner)
+	a.pc += InstructionSize(runner)
+}
+
+// writesZero returns whether an instruction writes its result to zero, which
+// discards it. The jumps not linking, the CSR writes not reading the CSR and
+// the canonical nop aren't reported.
+func writesZero(runner InstructionRunner) bool {
+	switch op := runner.(type) {
+	case *jal, *jalr, *csr:
+		return false
+	case *addi:
+		if op.rs == Zero && op.imm == 0 {
+			return false
+		}
+	}
+	for _, register := range runner.WriteRegisters() {
+		if register == Zero {
+			return true
+		}
+	}
+	return false
 }
 
 func validateArgs(expected int, args []string, line string) error {
 	if len(args) != expected {
-		return fmt.Errorf("invalid line: expected %d arguments, got %d: %v", expected, len(args), line)
+		return newDiagnostic(CodeOperandCount, "", "invalid line: expected %d arguments, got %d: %v", expected, len(args), line)
+	}
+	return nil
+}
+
+// validateFenceSet checks a fence predecessor or successor set: a combination of
+// i, o, r and w, in this order.
+func validateFenceSet(s string) error {
+	remaining := s
+	for _, c := range "iorw" {
+		remaining = strings.TrimPrefix(remaining, string(c))
+	}
+	if s == "" || remaining != "" {
+		return fmt.Errorf("invalid fence set: %q", s)
 	}
 	return nil
 }
@@ -938,23 +1457,69 @@ func parseRegister(s string) (RegisterType, error) {
 	case "t6", "$t6":
 		return T6, nil
 	default:
-		return 0, fmt.Errorf("unknown register: %v", s)
+		return 0, newDiagnostic(CodeInvalidRegister, s, "unknown register: %v", s)
 	}
 }
 
-func parseOffsetReg(s string) (int32, RegisterType, error) {
-	firstParenthesis := strings.IndexRune(s, '(')
-	if firstParenthesis == -1 {
+// parseAtomicAddress parses the address operand of an atomic instruction,
+// either (reg) or 0(reg).
+func parseAtomicAddress(s string, exprs *expressions) (RegisterType, error) {
+	if strings.HasPrefix(s, "(") {
+		s = "0" + s
+	}
+	offset, reg, err := parseOffsetReg(s, exprs)
+	if err != nil {
+		return 0, err
+	}
+	if offset != 0 {
+		return 0, fmt.Errorf("invalid atomic address: %s: offset must be 0", s)
+	}
+	return reg, nil
+}
+
+// trimMemoryOrdering removes the memory ordering suffix of an atomic
+// instruction (.aq, .rl or .aqrl). The ordering bits are ignored as there's a
+// single hart.
+func trimMemoryOrdering(mnemonic string) string {
+	if !strings.HasPrefix(mnemonic, "amo") && !strings.HasPrefix(mnemonic, "lr.") && !strings.HasPrefix(mnemonic, "sc.") {
+		return mnemonic
+	}
+	for _, suffix := range []string{".aqrl", ".aq", ".rl"} {
+		if strings.HasSuffix(mnemonic, suffix) {
+			return strings.TrimSuffix(mnemonic, suffix)
+		}
+	}
+	return mnemonic
+}
+
+var amoInstructionTypes = map[string]InstructionType{
+	"amoadd.w":  AmoaddW,
+	"amoand.w":  AmoandW,
+	"amomax.w":  AmomaxW,
+	"amomaxu.w": AmomaxuW,
+	"amomin.w":  AmominW,
+	"amominu.w": AmominuW,
+	"amoor.w":   AmoorW,
+	"amoswap.w": AmoswapW,
+	"amoxor.w":  AmoxorW,
+}
+
+// parseOffsetReg parses an offset followed by a register in parentheses. The
+// offset is an expression, such as a data label standing for its address or
+// %lo(label).
+func parseOffsetReg(s string, exprs *expressions) (int32, RegisterType, error) {
+	lastParenthesis := strings.LastIndex(s, "(")
+	if lastParenthesis == -1 || !strings.HasSuffix(s, ")") {
 		return 0, 0, fmt.Errorf("invalid offset register: %s", s)
 	}
 
-	immString := strings.TrimSpace(s[:firstParenthesis])
-	imm, err := strconv.ParseInt(immString, 10, 32)
+	immString := strings.TrimSpace(s[:lastParenthesis])
+	imm, err := exprs.immediate(immString)
 	if err != nil {
 		return 0, 0, err
 	}
 
-	regString := strings.TrimSpace(s[firstParenthesis+1 : len(s)-1])
+	regString := strings.TrimSpace(s[lastParenthesis+1 : len(s)-1])
 
 	reg, err := parseRegister(regString)
 	if err != nil {
@@ -963,3 +1528,164 @@ func parseOffsetReg(s string) (int32, RegisterType, error) {
 
 	return int32(imm), reg, nil
 }
+
+// parseFRegister parses a floating-point register, either f0 to f31 or its
+// ABI name.
+func parseFRegister(s string) (RegisterType, error) {
+	name := strings.TrimPrefix(s, "$")
+	if n, err := strconv.Atoi(strings.TrimPrefix(name, "f")); err == nil && strings.HasPrefix(name, "f") && n >= 0 && n < 32 {
+		return Ft0 + RegisterType(n), nil
+	}
+	for reg := Ft0; reg <= Ft11; reg++ {
+		if name == strings.ToLower(reg.String()) {
+			return reg, nil
+		}
+	}
+	return 0, newDiagnostic(CodeInvalidRegister, s, "unknown floating-point register: %v", s)
+}
+
+var roundingModes = map[string]int32{
+	"rne": rmRNE,
+	"rtz": rmRTZ,
+	"rdn": rmRDN,
+	"rup": rmRUP,
+	"rmm": rmRMM,
+	"dyn": rmDYN,
+}
+
+// fpInstructions describes the operands of the floating-point instructions
+// other than the loads and stores: f is a floating-point register and x an
+// integer register. rounding is set if the instruction accepts an optional
+// rounding mode.
+var fpInstructions = map[string]struct {
+	instructionType InstructionType
+	operands        string
+	rounding        bool
+}{
+	"fadd.d":    {FaddD, "fff", true},
+	"fadd.s":    {FaddS, "fff", true},
+	"fclass.d":  {FclassD, "xf", false},
+	"fclass.s":  {FclassS, "xf", false},
+	"fcvt.d.s":  {FcvtDS, "ff", true},
+	"fcvt.d.w":  {FcvtDW, "fx", true},
+	"fcvt.d.wu": {FcvtDWu, "fx", true},
+	"fcvt.s.d":  {FcvtSD, "ff", true},
+	"fcvt.s.w":  {FcvtSW, "fx", true},
+	"fcvt.s.wu": {FcvtSWu, "fx", true},
+	"fcvt.w.d":  {FcvtWD, "xf", true},
+	"fcvt.w.s":  {FcvtWS, "xf", true},
+	"fcvt.wu.d": {FcvtWuD, "xf", true},
+	"fcvt.wu.s": {FcvtWuS, "xf", true},
+	"fdiv.d":    {FdivD, "fff", true},
+	"fdiv.s":    {FdivS, "fff", true},
+	"feq.d":     {FeqD, "xff", false},
+	"feq.s":     {FeqS, "xff", false},
+	"fle.d":     {FleD, "xff", false},
+	"fle.s":     {FleS, "xff", false},
+	"flt.d":     {FltD, "xff", false},
+	"flt.s":     {FltS, "xff", false},
+	"fmadd.d":   {FmaddD, "ffff", true},
+	"fmadd.s":   {FmaddS, "ffff", true},
+	"fmax.d":    {FmaxD, "fff", false},
+	"fmax.s":    {FmaxS, "fff", false},
+	"fmin.d":    {FminD, "fff", false},
+	"fmin.s":    {FminS, "fff", false},
+	"fmsub.d":   {FmsubD, "ffff", true},
+	"fmsub.s":   {FmsubS, "ffff", true},
+	"fmul.d":    {FmulD, "fff", true},
+	"fmul.s":    {FmulS, "fff", true},
+	"fmv.w.x":   {FmvWX, "fx", false},
+	"fmv.x.w":   {FmvXW, "xf", false},
+	"fnmadd.d":  {FnmaddD, "ffff", true},
+	"fnmadd.s":  {FnmaddS, "ffff", true},
+	"fnmsub.d":  {FnmsubD, "ffff", true},
+	"fnmsub.s":  {FnmsubS, "ffff", true},
+	"fsgnj.d":   {FsgnjD, "fff", false},
+	"fsgnj.s":   {FsgnjS, "fff", false},
+	"fsgnjn.d":  {FsgnjnD, "fff", false},
+	"fsgnjn.s":  {FsgnjnS, "fff", false},
+	"fsgnjx.d":  {FsgnjxD, "fff", false},
+	"fsgnjx.s":  {FsgnjxS, "fff", false},
+	"fsqrt.d":   {FsqrtD, "ff", true},
+	"fsqrt.s":   {FsqrtS, "ff", true},
+	"fsub.d":    {FsubD, "fff", true},
+	"fsub.s":    {FsubS, "fff", true},
+}
+
+// parseFop parses a floating-point instruction other than a load or a store.
+// Without an explicit rounding mode, the dynamic rounding mode is used.
+func parseFop(mnemonic string, elements []string, line string) (*fop, error) {
+	instruction := fpInstructions[mnemonic]
+	n := len(instruction.operands)
+	op := &fop{instructionType: instruction.instructionType}
+	if instruction.rounding {
+		op.rm = rmDYN
+		if len(elements) == n+1 {
+			rm, exists := roundingModes[strings.TrimSpace(elements[n])]
+			if !exists {
+				return nil, fmt.Errorf("invalid rounding mode: %s", strings.TrimSpace(elements[n]))
+			}
+			op.rm = rm
+			elements = elements[:n]
+		}
+	}
+	if err := validateArgs(n, elements, line); err != nil {
+		return nil, err
+	}
+
+	registers := []*RegisterType{&op.rd, &op.rs1, &op.rs2, &op.rs3}
+	for i, kind := range instruction.operands {
+		parse := parseRegister
+		if kind == 'f' {
+			parse = parseFRegister
+		}
+		reg, err := parse(strings.TrimSpace(elements[i]))
+		if err != nil {
+			return nil, err
+		}
+		*registers[i] = reg
+	}
+	return op, nil
+}
+
+var csrInstructions = map[string]InstructionType{
+	"csrrc":  Csrrc,
+	"csrrci": Csrrci,
+	"csrrs":  Csrrs,
+	"csrrsi": Csrrsi,
+	"csrrw":  Csrrw,
+	"csrrwi": Csrrwi,
+}
+
+// parseCSRInstruction parses a CSR instruction: rd, csr, then either rs1 or a
+// 5-bit unsigned immediate.
+func parseCSRInstruction(mnemonic string, elements []string, line string, exprs *expressions) (*csr, error) {
+	if err := validateArgs(3, elements, line); err != nil {
+		return nil, err
+	}
+	rd, err := parseRegister(strings.TrimSpace(elements[0]))
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseCSR(strings.TrimSpace(elements[1]))
+	if err != nil {
+		return nil, err
+	}
+	op := &csr{instructionType: csrInstructions[mnemonic], rd: rd, csr: c}
+	if strings.HasSuffix(mnemonic, "i") {
+		uimm, err := exprs.immediate(strings.TrimSpace(elements[2]))
+		if err != nil {
+			return nil, err
+		}
+		if uimm < 0 || uimm > 31 {
+			return nil, newDiagnostic(CodeOutOfRange, strings.TrimSpace(elements[2]), "invalid immediate %d: out of range", uimm)
+		}
+		op.uimm = int32(uimm)
+		return op, nil
+	}
+	op.rs, err = parseRegister(strings.TrimSpace(elements[2]))
+	if err != nil {
+		return nil, err
+	}
+	return op, nil
+}
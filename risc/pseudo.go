@@ -0,0 +1,190 @@
+package risc
+
+import (
+	"math"
+	"strings"
+)
+
+// splitImmediate splits a 32-bit immediate into the upper 20 bits loaded by
+// lui or auipc and the lower 12 bits added by addi. As the latter are
+// sign-extended, the upper bits are rounded accordingly.
+func splitImmediate(imm int32) (hi, lo int32) {
+	lo = imm << 20 >> 20
+	hi = (imm - lo) >> 12
+	return hi, lo
+}
+
+// isImmediate12 returns whether an immediate fits in the 12 bits of an I-type
+// instruction.
+func isImmediate12(imm int32) bool {
+	return imm >= -2048 && imm < 2048
+}
+
+// expandPseudoInstruction returns the base instructions of a pseudo-instruction,
+// the way the GNU assembler does, or false if the mnemonic isn't a
+// pseudo-instruction.
+func expandPseudoInstruction(mnemonic string, elements []string, exprs *expressions) ([]InstructionRunner, bool, error) {
+	switch mnemonic {
+	case "li":
+		registers, args, err := parsePseudoArgs(elements, 1, 1)
+		if err != nil {
+			return nil, true, err
+		}
+		v, err := exprs.eval(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		if v.value < math.MinInt32 || v.value > math.MaxUint32 {
+			return nil, true, newDiagnostic(CodeOutOfRange, args[0], "immediate out of range: %d", v.value)
+		}
+		rd := registers[0]
+		if !v.relocatable && isImmediate12(int32(v.value)) {
+			return []InstructionRunner{&li{rd: rd, imm: int32(v.value)}}, true, nil
+		}
+		// A large immediate is loaded in two instructions, unless its lower
+		// bits are zero. The expansion of an address has always two
+		// instructions, as its size can't depend on the address.
+		hi, lo := splitImmediate(int32(v.value))
+		runners := []InstructionRunner{&lui{rd: rd, imm: hi}}
+		if lo != 0 || v.relocatable {
+			runners = append(runners, &addi{rd: rd, rs: rd, imm: lo})
+		}
+		return runners, true, nil
+	case "la":
+		registers, args, err := parsePseudoArgs(elements, 1, 1)
+		if err != nil {
+			return nil, true, err
+		}
+		hi, lo, err := pcRelativeImmediates(args[0], exprs)
+		if err != nil {
+			return nil, true, err
+		}
+		return []InstructionRunner{
+			&auipc{rd: registers[0], imm: hi},
+			&addi{rd: registers[0], rs: registers[0], imm: lo},
+		}, true, nil
+	case "call", "tail":
+		_, args, err := parsePseudoArgs(elements, 0, 1)
+		if err != nil {
+			return nil, true, err
+		}
+		// call links the return address in ra, whereas tail uses t1 as a
+		// scratch register and doesn't link
+		link, scratch := Ra, Ra
+		if mnemonic == "tail" {
+			link, scratch = Zero, T1
+		}
+		hi, lo, err := pcRelativeImmediates(args[0], exprs)
+		if err != nil {
+			return nil, true, err
+		}
+		return []InstructionRunner{
+			&auipc{rd: scratch, imm: hi},
+			&jalr{rd: link, rs: scratch, imm: lo},
+		}, true, nil
+	case "jr":
+		registers, _, err := parsePseudoArgs(elements, 1, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		return []InstructionRunner{&jalr{rd: Zero, rs: registers[0]}}, true, nil
+	case "neg", "not", "seqz", "snez", "sltz", "sgtz":
+		registers, _, err := parsePseudoArgs(elements, 2, 0)
+		if err != nil {
+			return nil, true, err
+		}
+		rd, rs := registers[0], registers[1]
+		var runner InstructionRunner
+		switch mnemonic {
+		case "neg":
+			runner = &sub{rd: rd, rs1: Zero, rs2: rs}
+		case "not":
+			runner = &xori{rd: rd, rs: rs, imm: -1}
+		case "seqz":
+			runner = &sltiu{rd: rd, rs: rs, imm: 1}
+		case "snez":
+			runner = &sltu{rd: rd, rs1: Zero, rs2: rs}
+		case "sltz":
+			runner = &slt{rd: rd, rs1: rs, rs2: Zero}
+		case "sgtz":
+			runner = &slt{rd: rd, rs1: Zero, rs2: rs}
+		}
+		return []InstructionRunner{runner}, true, nil
+	case "bgt", "bgtu", "bleu":
+		// The operands of the base branch are swapped
+		registers, args, err := parsePseudoArgs(elements, 2, 1)
+		if err != nil {
+			return nil, true, err
+		}
+		rs1, rs2 := registers[0], registers[1]
+		label, offset, err := exprs.target(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		var runner InstructionRunner
+		switch mnemonic {
+		case "bgt":
+			runner = &blt{rs1: rs2, rs2: rs1, label: label, offset: offset}
+		case "bgtu":
+			runner = &bltu{rs1: rs2, rs2: rs1, label: label, offset: offset}
+		case "bleu":
+			runner = &bgeu{rs1: rs2, rs2: rs1, label: label, offset: offset}
+		}
+		return []InstructionRunner{runner}, true, nil
+	case "bltz", "bgez", "blez", "bgtz":
+		registers, args, err := parsePseudoArgs(elements, 1, 1)
+		if err != nil {
+			return nil, true, err
+		}
+		rs := registers[0]
+		label, offset, err := exprs.target(args[0])
+		if err != nil {
+			return nil, true, err
+		}
+		var runner InstructionRunner
+		switch mnemonic {
+		case "bltz":
+			runner = &blt{rs1: rs, rs2: Zero, label: label, offset: offset}
+		case "bgez":
+			runner = &bge{rs1: rs, rs2: Zero, label: label, offset: offset}
+		case "blez":
+			runner = &bge{rs1: Zero, rs2: rs, label: label, offset: offset}
+		case "bgtz":
+			runner = &blt{rs1: Zero, rs2: rs, label: label, offset: offset}
+		}
+		return []InstructionRunner{runner}, true, nil
+	}
+	return nil, false, nil
+}
+
+// pcRelativeImmediates returns the immediates of an auipc and of the
+// instruction following it, adding the distance from the auipc to an address.
+func pcRelativeImmediates(s string, exprs *expressions) (hi, lo int32, err error) {
+	addr, err := exprs.immediate(s)
+	if err != nil {
+		return 0, 0, err
+	}
+	hi, lo = splitImmediate(int32(addr) - exprs.pc)
+	return hi, lo, nil
+}
+
+// parsePseudoArgs parses the registers of a pseudo-instruction followed by its
+// other arguments, which are returned trimmed.
+func parsePseudoArgs(elements []string, registers, others int) ([]RegisterType, []string, error) {
+	if err := validateArgs(registers+others, elements, strings.Join(elements, ",")); err != nil {
+		return nil, nil, err
+	}
+	var rs []RegisterType
+	for _, element := range elements[:registers] {
+		register, err := parseRegister(strings.TrimSpace(element))
+		if err != nil {
+			return nil, nil, err
+		}
+		rs = append(rs, register)
+	}
+	var args []string
+	for _, element := range elements[registers:] {
+		args = append(args, strings.TrimSpace(element))
+	}
+	return rs, args, nil
+}
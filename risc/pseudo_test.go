@@ -0,0 +1,114 @@
+package risc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPseudoInstructionExpansion(t *testing.T) {
+	tests := []struct {
+		instruction string
+		expected    []InstructionType
+	}{
+		{"li t0, 2047", []InstructionType{Li}},
+		{"li t0, 4096", []InstructionType{Lui}},
+		{"li t0, 4097", []InstructionType{Lui, Addi}},
+		{"la t0, label", []InstructionType{Auipc, Addi}},
+		{"call label", []InstructionType{Auipc, Jalr}},
+		{"tail label", []InstructionType{Auipc, Jalr}},
+		{"jr t0", []InstructionType{Jalr}},
+		{"neg t0, t1", []InstructionType{Sub}},
+		{"not t0, t1", []InstructionType{Xori}},
+		{"seqz t0, t1", []InstructionType{Sltiu}},
+		{"snez t0, t1", []InstructionType{Sltu}},
+		{"sltz t0, t1", []InstructionType{Slt}},
+		{"sgtz t0, t1", []InstructionType{Slt}},
+		{"bgt t0, t1, label", []InstructionType{Blt}},
+		{"bgtu t0, t1, label", []InstructionType{Bltu}},
+		{"bleu t0, t1, label", []InstructionType{Bgeu}},
+		{"bltz t0, label", []InstructionType{Blt}},
+		{"bgez t0, label", []InstructionType{Bge}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.instruction, func(t *testing.T) {
+			app, err := Parse(tt.instruction + "\nlabel:")
+			require.NoError(t, err)
+			var got []InstructionType
+			for _, runner := range app.Instructions {
+				got = append(got, runner.InstructionType())
+			}
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestLiLargeImmediate(t *testing.T) {
+	for _, imm := range []int32{-2048, 2047, 2048, 4096, -4097, 0x12345fff, -0x7ffff801} {
+		app, err := Parse(fmt.Sprintf("li t0, %d", imm))
+		require.NoError(t, err)
+		r := NewRunner(app, 0)
+		require.NoError(t, r.Run())
+		assert.Equal(t, imm, r.Ctx.Registers[T0], imm)
+	}
+
+	_, err := Parse("li t0, 4294967296")
+	assert.Error(t, err)
+}
+
+func TestLa(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{}, 0, map[int]int8{},
+		`nop
+la t0, label
+nop
+label:
+nop`, map[RegisterType]int32{T0: 16}, map[int]int8{})
+}
+
+func TestCallTail(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{}, 0, map[int]int8{},
+		`call f
+addi s0, s0, 100
+j end
+f:
+addi s0, s0, 1
+tail g
+addi s0, s0, 100
+g:
+addi s0, s0, 2
+end:`, map[RegisterType]int32{S0: 3, Ra: 8, T1: 20}, map[int]int8{})
+}
+
+func TestSetPseudoInstructions(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: -5, T1: 0, T2: 3}, 0, map[int]int8{},
+		`neg s0, t0
+not s1, t2
+seqz s2, t1
+snez s3, t0
+sltz s4, t0
+sgtz s5, t2`, map[RegisterType]int32{S0: 5, S1: -4, S2: 1, S3: 1, S4: 1, S5: 1}, map[int]int8{})
+}
+
+func TestBranchPseudoInstructions(t *testing.T) {
+	runAssert(t, map[RegisterType]int32{T0: -1, T1: 1}, 0, map[int]int8{},
+		`bgt t1, t0, 1
+addi s0, s0, 1
+1:
+bgtu t0, t1, 2
+addi s0, s0, 1
+2:
+bleu t1, t0, 3
+addi s0, s0, 1
+3:
+bltz t0, 4
+addi s0, s0, 1
+4:
+bgez zero, 5
+addi s0, s0, 1
+5:
+bgt t0, t1, 6
+addi s1, s1, 1
+6:`, map[RegisterType]int32{S0: 0, S1: 1}, map[int]int8{})
+}
@@ -0,0 +1,141 @@
+package risc
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Retirement is an instruction completed along with its architectural effects.
+// A processor retires the instructions in program order: comparing the
+// retirements of two processors locates the first instruction on which they
+// diverge.
+type Retirement struct {
+	Pc          int32
+	Instruction string
+	// Register and Value, the raw value of an integer or floating-point
+	// register, are set if RegisterChange is. Writing x0 isn't a change.
+	RegisterChange bool
+	Register       RegisterType
+	Value          int64
+	// MemoryChanges holds the bytes written, by address.
+	MemoryChanges map[int32]int8
+}
+
+// NewRetirement returns the retirement of an instruction executed at pc.
+func NewRetirement(pc int32, runner InstructionRunner, exe Execution) Retirement {
+	r := Retirement{
+		Pc:          pc,
+		Instruction: runner.String(),
+	}
+	if exe.RegisterChange && exe.Register != Zero {
+		r.RegisterChange = true
+		r.Register = exe.Register
+		r.Value = exe.value()
+	}
+	if exe.MemoryChange && len(exe.MemoryChanges) != 0 {
+		r.MemoryChanges = maps.Clone(exe.MemoryChanges)
+	}
+	return r
+}
+
+// Equal returns whether two retirements have the same instruction and effects.
+func (r Retirement) Equal(other Retirement) bool {
+	return r.Pc == other.Pc &&
+		r.Instruction == other.Instruction &&
+		r.RegisterChange == other.RegisterChange &&
+		r.Register == other.Register &&
+		r.Value == other.Value &&
+		maps.Equal(r.MemoryChanges, other.MemoryChanges)
+}
+
+// String returns the pc, the instruction and its effects, for instance
+// "0x10: sw t0, 4(zero) [mem[0x4]=5 mem[0x5]=0 mem[0x6]=0 mem[0x7]=0]".
+func (r Retirement) String() string {
+	var effects []string
+	if r.RegisterChange {
+		if r.Register.IsFloat() {
+			effects = append(effects, fmt.Sprintf("%s=%#x", registerName(r.Register), uint64(r.Value)))
+		} else {
+			effects = append(effects, fmt.Sprintf("%s=%d", registerName(r.Register), r.Value))
+		}
+	}
+	addrs := make([]int32, 0, len(r.MemoryChanges))
+	for addr := range r.MemoryChanges {
+		addrs = append(addrs, addr)
+	}
+	slices.Sort(addrs)
+	for _, addr := range addrs {
+		effects = append(effects, fmt.Sprintf("mem[%#x]=%d", addr, r.MemoryChanges[addr]))
+	}
+	s := fmt.Sprintf("%#x: %s", r.Pc, r.Instruction)
+	if len(effects) != 0 {
+		s += " [" + strings.Join(effects, " ") + "]"
+	}
+	return s
+}
+
+// speculativeRetirement is a retirement buffered until the instructions
+// preceding it are known to be retired as well.
+type speculativeRetirement struct {
+	sequenceID   int32
+	programOrder int64
+	retirement   Retirement
+}
+
+// Retire notifies OnRetire, if set, of an instruction retired in program order.
+func (ctx *Context) Retire(pc int32, runner InstructionRunner, exe Execution) error {
+	if ctx.OnRetire == nil {
+		return nil
+	}
+	return ctx.OnRetire(NewRetirement(pc, runner, exe))
+}
+
+// NextProgramOrder returns the program order of the instruction decoded.
+func (ctx *Context) NextProgramOrder() int64 {
+	ctx.decoded++
+	return ctx.decoded
+}
+
+// RetireSpeculative buffers an instruction executed out of order, possibly
+// following a branch not resolved yet. The instruction is counted by instret
+// and OnRetire is notified once the retirement is committed.
+func (ctx *Context) RetireSpeculative(r InstructionRunnerPc, exe Execution) {
+	retirement := speculativeRetirement{
+		sequenceID:   r.SequenceID,
+		programOrder: r.ProgramOrder,
+	}
+	if ctx.OnRetire != nil {
+		retirement.retirement = NewRetirement(r.Pc, r.Runner, exe)
+	}
+	ctx.speculativeRetirements = append(ctx.speculativeRetirements, retirement)
+}
+
+// CommitRetirements retires the buffered instructions up to a sequence ID,
+// notifying OnRetire in program order, and discards the following ones,
+// flushed from the pipeline. The instructions up to the sequence ID have to be
+// completed.
+func (ctx *Context) CommitRetirements(sequenceID int32) error {
+	retirements := ctx.speculativeRetirements
+	ctx.speculativeRetirements = nil
+	if ctx.OnRetire != nil {
+		slices.SortFunc(retirements, func(a, b speculativeRetirement) int {
+			return cmp.Compare(a.programOrder, b.programOrder)
+		})
+	}
+	for _, r := range retirements {
+		if r.sequenceID > sequenceID {
+			continue
+		}
+		ctx.Instret++
+		if ctx.OnRetire == nil {
+			continue
+		}
+		if err := ctx.OnRetire(r.retirement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
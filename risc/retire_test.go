@@ -0,0 +1,56 @@
+package risc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRetirement(t *testing.T) {
+	app, err := Parse(`sw t0, 4(zero)`)
+	require.NoError(t, err)
+	runner := app.Instructions[0]
+
+	r := NewRetirement(8, runner, Execution{MemoryChange: true, MemoryChanges: map[int32]int8{4: 5, 5: 0}})
+	assert.Equal(t, "0x8: sw t0, 4(zero) [mem[0x4]=5 mem[0x5]=0]", r.String())
+	assert.False(t, r.RegisterChange)
+
+	r = NewRetirement(8, runner, Execution{RegisterChange: true, Register: Zero, RegisterValue: 1})
+	assert.False(t, r.RegisterChange, "writing x0 isn't a change")
+	assert.True(t, r.Equal(NewRetirement(8, runner, Execution{})))
+}
+
+func TestCommitRetirements(t *testing.T) {
+	app, err := Parse(`addi t0, t0, 1
+addi t1, t1, 1`)
+	require.NoError(t, err)
+
+	ctx := NewContext(false, 16, false)
+	var retired []int32
+	ctx.OnRetire = func(r Retirement) error {
+		retired = append(retired, r.Pc)
+		return nil
+	}
+	retire := func(sequenceID, pc int32, programOrder int64) {
+		ctx.RetireSpeculative(InstructionRunnerPc{
+			Runner:       app.Instructions[pc/4],
+			Pc:           pc,
+			SequenceID:   sequenceID,
+			ProgramOrder: programOrder,
+		}, Execution{})
+	}
+
+	// Two iterations of a loop share the same sequence IDs, the second one is
+	// flushed from the second instruction
+	retire(4, 4, 2)
+	retire(0, 0, 3)
+	retire(0, 0, 1)
+	retire(4, 4, 4)
+	require.NoError(t, ctx.CommitRetirements(0))
+	assert.Equal(t, []int32{0, 0}, retired)
+
+	retired = nil
+	require.NoError(t, ctx.CommitRetirements(0))
+	assert.Empty(t, retired)
+}
@@ -35,6 +35,39 @@ const (
 	T4
 	T5
 	T6
+	// Floating-point registers
+	Ft0
+	Ft1
+	Ft2
+	Ft3
+	Ft4
+	Ft5
+	Ft6
+	Ft7
+	Fs0
+	Fs1
+	Fa0
+	Fa1
+	Fa2
+	Fa3
+	Fa4
+	Fa5
+	Fa6
+	Fa7
+	Fs2
+	Fs3
+	Fs4
+	Fs5
+	Fs6
+	Fs7
+	Fs8
+	Fs9
+	Fs10
+	Fs11
+	Ft8
+	Ft9
+	Ft10
+	Ft11
 )
 
 func (reg RegisterType) String() string {
@@ -103,16 +136,95 @@ func (reg RegisterType) String() string {
 		return "T5"
 	case T6:
 		return "T6"
+	case Ft0:
+		return "Ft0"
+	case Ft1:
+		return "Ft1"
+	case Ft2:
+		return "Ft2"
+	case Ft3:
+		return "Ft3"
+	case Ft4:
+		return "Ft4"
+	case Ft5:
+		return "Ft5"
+	case Ft6:
+		return "Ft6"
+	case Ft7:
+		return "Ft7"
+	case Fs0:
+		return "Fs0"
+	case Fs1:
+		return "Fs1"
+	case Fa0:
+		return "Fa0"
+	case Fa1:
+		return "Fa1"
+	case Fa2:
+		return "Fa2"
+	case Fa3:
+		return "Fa3"
+	case Fa4:
+		return "Fa4"
+	case Fa5:
+		return "Fa5"
+	case Fa6:
+		return "Fa6"
+	case Fa7:
+		return "Fa7"
+	case Fs2:
+		return "Fs2"
+	case Fs3:
+		return "Fs3"
+	case Fs4:
+		return "Fs4"
+	case Fs5:
+		return "Fs5"
+	case Fs6:
+		return "Fs6"
+	case Fs7:
+		return "Fs7"
+	case Fs8:
+		return "Fs8"
+	case Fs9:
+		return "Fs9"
+	case Fs10:
+		return "Fs10"
+	case Fs11:
+		return "Fs11"
+	case Ft8:
+		return "Ft8"
+	case Ft9:
+		return "Ft9"
+	case Ft10:
+		return "Ft10"
+	case Ft11:
+		return "Ft11"
 	default:
 		panic(reg)
 	}
 }
 
+// IsFloat returns true if the register belongs to the floating-point register
+// file.
+func (reg RegisterType) IsFloat() bool {
+	return reg >= Ft0 && reg <= Ft11
+}
+
 type InstructionType uint64
 
 const (
 	Add InstructionType = iota
 	Addi
+	AmoaddW
+	AmoandW
+	AmomaxW
+	AmomaxuW
+	AmominW
+	AmominuW
+	AmoorW
+	AmoswapW
+	AmoxorW
 	And
 	Andi
 	Auipc
@@ -125,35 +237,109 @@ const (
 	Bltu
 	Bne
 	Bnez
+	Csrrc
+	Csrrci
+	Csrrs
+	Csrrsi
+	Csrrw
+	Csrrwi
 	Div
+	Divu
+	Ebreak
+	Ecall
+	FaddD
+	FaddS
+	FclassD
+	FclassS
+	FcvtDS
+	FcvtDW
+	FcvtDWu
+	FcvtSD
+	FcvtSW
+	FcvtSWu
+	FcvtWD
+	FcvtWS
+	FcvtWuD
+	FcvtWuS
+	FdivD
+	FdivS
+	Fence
+	FeqD
+	FeqS
+	Fld
+	FleD
+	FleS
+	FltD
+	FltS
+	Flw
+	FmaddD
+	FmaddS
+	FmaxD
+	FmaxS
+	FminD
+	FminS
+	FmsubD
+	FmsubS
+	FmulD
+	FmulS
+	FmvWX
+	FmvXW
+	FnmaddD
+	FnmaddS
+	FnmsubD
+	FnmsubS
+	Fsd
+	FsgnjD
+	FsgnjS
+	FsgnjnD
+	FsgnjnS
+	FsgnjxD
+	FsgnjxS
+	FsqrtD
+	FsqrtS
+	FsubD
+	FsubS
+	Fsw
+	Illegal
 	J
 	Jal
 	Jalr
 	Lui
 	Lb
+	Lbu
 	Lh
+	Lhu
 	Li
+	LrW
 	Lw
+	Mret
 	Nop
 	Mul
+	Mulh
+	Mulhsu
+	Mulhu
 	Mv
 	Or
 	Ori
 	Rem
+	Remu
 	Ret
 	Sb
+	ScW
 	Sh
 	Sll
 	Slli
 	Slt
 	Sltu
 	Slti
+	Sltiu
 	Sra
 	Srai
 	Srl
 	Srli
 	Sub
 	Sw
+	Wfi
 	Xor
 	Xori
 )
@@ -164,6 +350,24 @@ func (ins InstructionType) String() string {
 		return "Add"
 	case Addi:
 		return "Addi"
+	case AmoaddW:
+		return "AmoaddW"
+	case AmoandW:
+		return "AmoandW"
+	case AmomaxW:
+		return "AmomaxW"
+	case AmomaxuW:
+		return "AmomaxuW"
+	case AmominW:
+		return "AmominW"
+	case AmominuW:
+		return "AmominuW"
+	case AmoorW:
+		return "AmoorW"
+	case AmoswapW:
+		return "AmoswapW"
+	case AmoxorW:
+		return "AmoxorW"
 	case And:
 		return "And"
 	case Andi:
@@ -188,8 +392,134 @@ func (ins InstructionType) String() string {
 		return "Bne"
 	case Bnez:
 		return "Bnez"
+	case Csrrc:
+		return "Csrrc"
+	case Csrrci:
+		return "Csrrci"
+	case Csrrs:
+		return "Csrrs"
+	case Csrrsi:
+		return "Csrrsi"
+	case Csrrw:
+		return "Csrrw"
+	case Csrrwi:
+		return "Csrrwi"
 	case Div:
 		return "Div"
+	case Divu:
+		return "Divu"
+	case Ebreak:
+		return "Ebreak"
+	case Ecall:
+		return "Ecall"
+	case FaddD:
+		return "FaddD"
+	case FaddS:
+		return "FaddS"
+	case FclassD:
+		return "FclassD"
+	case FclassS:
+		return "FclassS"
+	case FcvtDS:
+		return "FcvtDS"
+	case FcvtDW:
+		return "FcvtDW"
+	case FcvtDWu:
+		return "FcvtDWu"
+	case FcvtSD:
+		return "FcvtSD"
+	case FcvtSW:
+		return "FcvtSW"
+	case FcvtSWu:
+		return "FcvtSWu"
+	case FcvtWD:
+		return "FcvtWD"
+	case FcvtWS:
+		return "FcvtWS"
+	case FcvtWuD:
+		return "FcvtWuD"
+	case FcvtWuS:
+		return "FcvtWuS"
+	case FdivD:
+		return "FdivD"
+	case FdivS:
+		return "FdivS"
+	case Fence:
+		return "Fence"
+	case FeqD:
+		return "FeqD"
+	case FeqS:
+		return "FeqS"
+	case Fld:
+		return "Fld"
+	case FleD:
+		return "FleD"
+	case FleS:
+		return "FleS"
+	case FltD:
+		return "FltD"
+	case FltS:
+		return "FltS"
+	case Flw:
+		return "Flw"
+	case FmaddD:
+		return "FmaddD"
+	case FmaddS:
+		return "FmaddS"
+	case FmaxD:
+		return "FmaxD"
+	case FmaxS:
+		return "FmaxS"
+	case FminD:
+		return "FminD"
+	case FminS:
+		return "FminS"
+	case FmsubD:
+		return "FmsubD"
+	case FmsubS:
+		return "FmsubS"
+	case FmulD:
+		return "FmulD"
+	case FmulS:
+		return "FmulS"
+	case FmvWX:
+		return "FmvWX"
+	case FmvXW:
+		return "FmvXW"
+	case FnmaddD:
+		return "FnmaddD"
+	case FnmaddS:
+		return "FnmaddS"
+	case FnmsubD:
+		return "FnmsubD"
+	case FnmsubS:
+		return "FnmsubS"
+	case Fsd:
+		return "Fsd"
+	case FsgnjD:
+		return "FsgnjD"
+	case FsgnjS:
+		return "FsgnjS"
+	case FsgnjnD:
+		return "FsgnjnD"
+	case FsgnjnS:
+		return "FsgnjnS"
+	case FsgnjxD:
+		return "FsgnjxD"
+	case FsgnjxS:
+		return "FsgnjxS"
+	case FsqrtD:
+		return "FsqrtD"
+	case FsqrtS:
+		return "FsqrtS"
+	case FsubD:
+		return "FsubD"
+	case FsubS:
+		return "FsubS"
+	case Fsw:
+		return "Fsw"
+	case Illegal:
+		return "Illegal"
 	case J:
 		return "J"
 	case Jal:
@@ -200,16 +530,30 @@ func (ins InstructionType) String() string {
 		return "Lui"
 	case Lb:
 		return "Lb"
+	case Lbu:
+		return "Lbu"
 	case Lh:
 		return "Lh"
+	case Lhu:
+		return "Lhu"
 	case Li:
 		return "Li"
+	case LrW:
+		return "LrW"
 	case Lw:
 		return "Lw"
+	case Mret:
+		return "Mret"
 	case Nop:
 		return "Nop"
 	case Mul:
 		return "Mul"
+	case Mulh:
+		return "Mulh"
+	case Mulhsu:
+		return "Mulhsu"
+	case Mulhu:
+		return "Mulhu"
 	case Mv:
 		return "Mv"
 	case Or:
@@ -218,10 +562,14 @@ func (ins InstructionType) String() string {
 		return "Ori"
 	case Rem:
 		return "Rem"
+	case Remu:
+		return "Remu"
 	case Ret:
 		return "Ret"
 	case Sb:
 		return "Sb"
+	case ScW:
+		return "ScW"
 	case Sh:
 		return "Sh"
 	case Sll:
@@ -234,6 +582,8 @@ func (ins InstructionType) String() string {
 		return "Sltu"
 	case Slti:
 		return "Slti"
+	case Sltiu:
+		return "Sltiu"
 	case Sra:
 		return "Sra"
 	case Srai:
@@ -246,6 +596,8 @@ func (ins InstructionType) String() string {
 		return "Sub"
 	case Sw:
 		return "Sw"
+	case Wfi:
+		return "Wfi"
 	case Xor:
 		return "Xor"
 	case Xori:
@@ -255,125 +607,52 @@ func (ins InstructionType) String() string {
 	}
 }
 
-func (ins InstructionType) Cycles() int {
+func (ins InstructionType) IsMemoryWrite() bool {
 	switch ins {
-	case Add:
-		return 1
-	case Addi:
-		return 1
-	case And:
-		return 1
-	case Andi:
-		return 1
-	case Auipc:
-		return 1
-	case Beq:
-		return 1
-	case Beqz:
-		return 1
-	case Bge:
-		return 1
-	case Ble:
-		return 1
-	case Bgeu:
-		return 1
-	case Blt:
-		return 1
-	case Bltu:
-		return 1
-	case Bne:
-		return 1
-	case Bnez:
-		return 1
-	case Div:
-		return 1
-	case J:
-		return 1
-	case Jal:
-		return 1
-	case Jalr:
-		return 1
-	case Lui:
-		return 1
-	case Lb:
-		return 50
-	case Lh:
-		return 50
-	case Li:
-		return 1
-	case Lw:
-		return 50
-	case Nop:
-		return 1
-	case Mul:
-		return 1
-	case Mv:
-		return 1
-	case Or:
-		return 1
-	case Ori:
-		return 1
-	case Rem:
-		return 1
-	case Ret:
-		return 1
-	case Sb:
-		// Write back
-		return 1
-	case Sh:
-		// Write back
-		return 1
-	case Sll:
-		return 1
-	case Slli:
-		return 1
-	case Slt:
-		return 1
-	case Sltu:
-		return 1
-	case Slti:
-		return 1
-	case Sra:
-		return 1
-	case Srai:
-		return 1
-	case Srl:
-		return 1
-	case Srli:
-		return 1
-	case Sub:
-		return 1
-	case Sw:
-		// Write back
-		return 1
-	case Xor:
-		return 1
-	case Xori:
-		return 1
-	default:
-		panic(ins)
+	case Sb, Sw, Sh, Fsw, Fsd:
+		return true
 	}
+	return false
 }
 
-func (ins InstructionType) IsMemoryWrite() bool {
+func (ins InstructionType) IsMemoryRead() bool {
 	switch ins {
-	case Sb, Sw, Sh:
+	case Lb, Lbu, Lw, Lh, Lhu, Flw, Fld:
 		return true
 	}
 	return false
 }
 
-func (ins InstructionType) IsMemoryRead() bool {
+// IsAtomic returns true if the instruction reads and writes a memory word
+// atomically.
+func (ins InstructionType) IsAtomic() bool {
+	switch ins {
+	case LrW, ScW, AmoaddW, AmoandW, AmomaxW, AmomaxuW, AmominW, AmominuW, AmoorW, AmoswapW, AmoxorW:
+		return true
+	}
+	return false
+}
+
+// IsSerializing returns true if the instruction has to wait for the previous
+// ones to be executed, as it acts outside the pipeline. A CSR instruction
+// mustn't be executed speculatively, as its write can't be rolled back; the
+// same goes for mret, which also updates mstatus. fence orders the memory
+// accesses by waiting for the previous ones.
+func (ins InstructionType) IsSerializing() bool {
 	switch ins {
-	case Lb, Lw, Lh:
+	case Ecall, Ebreak, Csrrc, Csrrci, Csrrs, Csrrsi, Csrrw, Csrrwi, Fence, Mret, Wfi:
 		return true
 	}
 	return false
 }
 
+// IsUnconditionalBranch returns true if the instruction always jumps. ecall and
+// ebreak are jumps to the next instruction, so that nothing following them is
+// executed before the environment handled them. mret jumps to mepc and ret to
+// ra.
 func (ins InstructionType) IsUnconditionalBranch() bool {
 	switch ins {
-	case J, Jal, Jalr:
+	case J, Jal, Jalr, Ret, Ecall, Ebreak, Mret:
 		return true
 	}
 	return false
@@ -381,7 +660,7 @@ func (ins InstructionType) IsUnconditionalBranch() bool {
 
 func (ins InstructionType) IsConditionalBranch() bool {
 	switch ins {
-	case Beq, Beqz, Bne, Bnez, Blt, Ble, Bge, Bgeu:
+	case Beq, Beqz, Bne, Bnez, Blt, Bltu, Ble, Bge, Bgeu:
 		return true
 	}
 	return false
@@ -13,24 +13,50 @@ func NewRunner(app Application, memoryBytes int) *Runner {
 }
 
 func (r *Runner) Run() error {
-	var pc int32
-	for pc/4 < int32(len(r.App.Instructions)) {
-		runner := r.App.Instructions[pc/4]
-		exe, err := runner.Run(r.Ctx, r.App.Labels, pc, nil, 0)
+	if err := r.Ctx.Load(r.App); err != nil {
+		return err
+	}
+	pc := r.App.Entry
+	for pc < r.App.End() {
+		// Each instruction takes a single cycle
+		r.Ctx.Cycles = r.Ctx.Instret
+		if e := r.Ctx.Interrupt(pc); e != nil {
+			var err error
+			if pc, err = r.Ctx.Trap(e); err != nil {
+				return err
+			}
+			continue
+		}
+		runner := r.App.Fetch(pc)
+		var memory []int8
+		for _, addr := range runner.MemoryRead(r.Ctx, 0) {
+			memory = append(memory, r.Ctx.Memory[addr])
+		}
+		exe, err := runner.Run(r.Ctx, r.App.Labels, pc, memory, 0)
 		if err != nil {
 			return err
 		}
+		if exe.Exception != nil {
+			// The instruction doesn't retire
+			pc, err = r.Ctx.Trap(exe.Exception)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		r.Ctx.Instret++
 		if exe.RegisterChange {
 			r.Ctx.WriteRegister(exe)
-		} else if exe.MemoryChange {
+		}
+		if exe.MemoryChange {
 			r.Ctx.WriteMemory(exe)
 		}
 
 		if exe.PcChange {
 			pc = exe.NextPc
 		} else {
-			pc += 4
+			pc += InstructionSize(runner)
 		}
 	}
-	return nil
+	return r.Ctx.ExitError()
 }
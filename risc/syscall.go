@@ -0,0 +1,243 @@
+package risc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Environment is the execution environment servicing the ecall and ebreak
+// instructions of an application.
+type Environment interface {
+	// MemoryRead returns the addresses read by a system call.
+	MemoryRead(ctx *Context, call Syscall) []int32
+	// Syscall executes a system call; memory holds the bytes read at the
+	// addresses returned by MemoryRead.
+	Syscall(ctx *Context, call Syscall, memory []int8) (Execution, error)
+	// Breakpoint handles an ebreak at a given pc.
+	Breakpoint(ctx *Context, pc int32) (Execution, error)
+}
+
+// Syscall is a system call: its number, passed in a7, and its arguments,
+// passed in a0 to a5.
+type Syscall struct {
+	Number int32
+	Args   [6]int32
+}
+
+// Linux system call numbers of the RV32 ABI.
+const (
+	SysRead         = 63
+	SysWrite        = 64
+	SysExit         = 93
+	SysExitGroup    = 94
+	SysBrk          = 214
+	SysClockGettime = 403
+)
+
+// ExitPc is the pc an application jumps to when it exits, either with the exit
+// syscall or by returning from its entry point, as ra initially holds it. As
+// it follows any instruction, the processor completes the pending instructions
+// and stops.
+const ExitPc int32 = math.MaxInt32 &^ 0x3
+
+// Linux error numbers, returned negated in a0.
+const (
+	eio    = 5
+	ebadf  = 9
+	efault = 14
+	enosys = 38
+)
+
+// syscallBlockSize bounds the memory accessed by a system call: a transfer
+// stops at the end of the 64-byte block containing its buffer, so that it fits
+// in a single cache line. As with Linux, read and write may therefore transfer
+// fewer bytes than requested.
+const syscallBlockSize = 64
+
+// Linux implements a subset of the Linux user-mode system calls.
+type Linux struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// NewLinux returns a Linux environment bound to the standard streams of the
+// host process.
+func NewLinux() *Linux {
+	return &Linux{
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+func (env *Linux) MemoryRead(ctx *Context, call Syscall) []int32 {
+	if call.Number != SysWrite {
+		return nil
+	}
+	buf, n, ok := transfer(ctx, call.Args[1], call.Args[2])
+	if !ok {
+		return nil
+	}
+	addrs := make([]int32, 0, n)
+	for i := int32(0); i < n; i++ {
+		addrs = append(addrs, buf+i)
+	}
+	return addrs
+}
+
+func (env *Linux) Syscall(ctx *Context, call Syscall, memory []int8) (Execution, error) {
+	switch call.Number {
+	case SysRead:
+		return env.read(ctx, call.Args[0], call.Args[1], call.Args[2]), nil
+	case SysWrite:
+		return env.write(ctx, call.Args[0], call.Args[1], call.Args[2], memory), nil
+	case SysExit, SysExitGroup:
+		ctx.exit(call.Args[0])
+		return Execution{PcChange: true, NextPc: ExitPc}, nil
+	case SysBrk:
+		return syscallResult(ctx.setBreak(call.Args[0])), nil
+	case SysClockGettime:
+		return clockGettime(ctx, call.Args[1]), nil
+	}
+	return syscallResult(-enosys), nil
+}
+
+func (env *Linux) Breakpoint(_ *Context, pc int32) (Execution, error) {
+	return Execution{}, fmt.Errorf("trace/breakpoint trap at pc %d", pc)
+}
+
+func (env *Linux) read(ctx *Context, fd, buf, count int32) Execution {
+	if fd != 0 || env.Stdin == nil {
+		return syscallResult(-ebadf)
+	}
+	buf, n, ok := transfer(ctx, buf, count)
+	if !ok {
+		return syscallResult(-efault)
+	}
+	if n == 0 {
+		return syscallResult(0)
+	}
+
+	data := make([]byte, n)
+	read, err := env.Stdin.Read(data)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return syscallResult(-eio)
+	}
+	exe := syscallResult(int32(read))
+	if read != 0 {
+		exe.MemoryChange = true
+		exe.MemoryChanges = make(map[int32]int8, read)
+		for i, b := range data[:read] {
+			exe.MemoryChanges[buf+int32(i)] = int8(b)
+		}
+	}
+	return exe
+}
+
+func (env *Linux) write(ctx *Context, fd, buf, count int32, memory []int8) Execution {
+	var w io.Writer
+	switch fd {
+	case 1:
+		w = env.Stdout
+	case 2:
+		w = env.Stderr
+	}
+	if w == nil {
+		return syscallResult(-ebadf)
+	}
+	if _, _, ok := transfer(ctx, buf, count); !ok {
+		return syscallResult(-efault)
+	}
+
+	data := make([]byte, len(memory))
+	for i, b := range memory {
+		data[i] = byte(b)
+	}
+	written, err := w.Write(data)
+	if err != nil {
+		return syscallResult(-eio)
+	}
+	return syscallResult(int32(written))
+}
+
+// clockGettime writes the time elapsed since the start of the application to
+// a 64-bit timespec. A cycle lasts a nanosecond.
+func clockGettime(ctx *Context, tp int32) Execution {
+	if tp < 0 || int(tp)+16 > len(ctx.Memory) {
+		return syscallResult(-efault)
+	}
+	const nanoseconds = 1_000_000_000
+	sec := ctx.Cycles / nanoseconds
+	nsec := ctx.Cycles % nanoseconds
+
+	exe := syscallResult(0)
+	exe.MemoryChange = true
+	exe.MemoryChanges = make(map[int32]int8, 16)
+	for i := int32(0); i < 8; i++ {
+		exe.MemoryChanges[tp+i] = int8(sec >> (8 * i))
+		exe.MemoryChanges[tp+8+i] = int8(nsec >> (8 * i))
+	}
+	return exe
+}
+
+// transfer returns the part of a buffer a system call transfers, and false if
+// the buffer is out of memory.
+func transfer(ctx *Context, buf, count int32) (int32, int32, bool) {
+	if count <= 0 {
+		return buf, 0, true
+	}
+	if buf < 0 {
+		return 0, 0, false
+	}
+	n := min(count, syscallBlockSize-buf%syscallBlockSize)
+	if int(buf)+int(n) > len(ctx.Memory) {
+		return 0, 0, false
+	}
+	return buf, n, true
+}
+
+// syscallResult returns the execution of a system call returning a value in
+// a0.
+func syscallResult(value int32) Execution {
+	return Execution{
+		RegisterChange: true,
+		Register:       A0,
+		RegisterValue:  value,
+	}
+}
+
+// ExitError is returned by Run if the application exited with a non-zero code.
+type ExitError struct {
+	Code int32
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+func (ctx *Context) exit(code int32) {
+	ctx.Exited = true
+	ctx.ExitCode = code
+}
+
+// ExitError returns an *ExitError if the application exited with a non-zero
+// code, nil otherwise.
+func (ctx *Context) ExitError() error {
+	if !ctx.Exited || ctx.ExitCode == 0 {
+		return nil
+	}
+	return &ExitError{Code: ctx.ExitCode}
+}
+
+// setBreak moves the program break, provided it stays between its initial
+// value and the end of the memory, and returns the current break.
+func (ctx *Context) setBreak(addr int32) int32 {
+	if addr >= ctx.initialBreak && int(addr) <= len(ctx.Memory) {
+		ctx.brk = addr
+	}
+	return ctx.brk
+}
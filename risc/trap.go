@@ -0,0 +1,198 @@
+package risc
+
+import (
+	"fmt"
+	"math"
+)
+
+// ExceptionCause is the mcause code of a synchronous exception.
+type ExceptionCause int32
+
+const (
+	CauseInstructionAddressMisaligned ExceptionCause = 0
+	CauseInstructionAccessFault       ExceptionCause = 1
+	CauseIllegalInstruction           ExceptionCause = 2
+	CauseBreakpoint                   ExceptionCause = 3
+	CauseLoadAddressMisaligned        ExceptionCause = 4
+	CauseLoadAccessFault              ExceptionCause = 5
+	CauseStoreAddressMisaligned       ExceptionCause = 6
+	CauseStoreAccessFault             ExceptionCause = 7
+
+	CauseMachineSoftwareInterrupt = ExceptionCause(math.MinInt32 | 3)
+	CauseMachineTimerInterrupt    = ExceptionCause(math.MinInt32 | 7)
+	CauseMachineExternalInterrupt = ExceptionCause(math.MinInt32 | 11)
+)
+
+// IsInterrupt returns true if the cause is an interrupt, which is encoded in
+// the most significant bit of mcause.
+func (cause ExceptionCause) IsInterrupt() bool {
+	return cause < 0
+}
+
+func (cause ExceptionCause) String() string {
+	switch cause {
+	case CauseInstructionAddressMisaligned:
+		return "instruction address misaligned"
+	case CauseInstructionAccessFault:
+		return "instruction access fault"
+	case CauseIllegalInstruction:
+		return "illegal instruction"
+	case CauseBreakpoint:
+		return "breakpoint"
+	case CauseLoadAddressMisaligned:
+		return "load address misaligned"
+	case CauseLoadAccessFault:
+		return "load access fault"
+	case CauseStoreAddressMisaligned:
+		return "store address misaligned"
+	case CauseStoreAccessFault:
+		return "store access fault"
+	case CauseMachineSoftwareInterrupt:
+		return "machine software interrupt"
+	case CauseMachineTimerInterrupt:
+		return "machine timer interrupt"
+	case CauseMachineExternalInterrupt:
+		return "machine external interrupt"
+	}
+	return fmt.Sprintf("exception %d", int32(cause))
+}
+
+// Exception is a synchronous exception raised by an instruction. The
+// instruction doesn't have any other effect: it is up to the processor to
+// take the exception once the instruction is known to be executed.
+// An interrupt is described the same way, the instruction being the one
+// interrupted.
+type Exception struct {
+	Cause ExceptionCause
+	// Pc is the pc of the instruction, written to mepc.
+	Pc int32
+	// Value is written to mtval: the faulting address, or zero.
+	Value int32
+}
+
+func (e *Exception) Error() string {
+	return fmt.Sprintf("%v at pc %d (mtval %#x)", e.Cause, e.Pc, uint32(e.Value))
+}
+
+// raise returns the execution of an instruction raising an exception.
+func raise(cause ExceptionCause, pc, value int32) Execution {
+	return Execution{Exception: &Exception{Cause: cause, Pc: pc, Value: value}}
+}
+
+// Trap takes an exception: it records it in mepc, mcause and mtval, disables
+// the interrupts and returns the pc of the trap handler, held by mtvec.
+// A zero mtvec means no handler is installed, in which case the exception is
+// returned as an error and the application stops.
+func (ctx *Context) Trap(e *Exception) (int32, error) {
+	if ctx.Mtvec == 0 {
+		return 0, e
+	}
+	ctx.Mepc = e.Pc
+	ctx.Mcause = int32(e.Cause)
+	ctx.Mtval = e.Value
+	mpie := int32(0)
+	if ctx.Mstatus&MstatusMIE != 0 {
+		mpie = MstatusMPIE
+	}
+	ctx.Mstatus = ctx.Mstatus&^(MstatusMIE|MstatusMPIE) | mpie
+	base := ctx.Mtvec &^ 0x3
+	if e.Cause.IsInterrupt() && ctx.Mtvec&0x1 != 0 {
+		// Vectored mode
+		return base + 4*int32(e.Cause&^math.MinInt32), nil
+	}
+	// Exceptions jump to the base address, even in vectored mode
+	return base, nil
+}
+
+// Mret returns from a trap handler: the interrupts are enabled again if they
+// were before the trap, and the pc held by mepc is returned.
+func (ctx *Context) Mret() int32 {
+	mie := int32(0)
+	if ctx.Mstatus&MstatusMPIE != 0 {
+		mie = MstatusMIE
+	}
+	ctx.Mstatus = ctx.Mstatus&^MstatusMIE | mie | MstatusMPIE
+	return ctx.Mepc
+}
+
+// checkAccess returns the exception raised by a load, or a store if store is
+// set, of size bytes at a given address: the address has to be naturally
+// aligned and within the memory. As only lw and sw access the devices, an
+// access overlapping a device register faults.
+func checkAccess(ctx *Context, pc, addr, size int32, store bool) *Exception {
+	misaligned, fault := CauseLoadAddressMisaligned, CauseLoadAccessFault
+	if store {
+		misaligned, fault = CauseStoreAddressMisaligned, CauseStoreAccessFault
+	}
+	if addr%size != 0 {
+		return &Exception{Cause: misaligned, Pc: pc, Value: addr}
+	}
+	if addr < 0 || int(addr)+int(size) > len(ctx.Memory) || overlapsDevice(addr, size) {
+		return &Exception{Cause: fault, Pc: pc, Value: addr}
+	}
+	return nil
+}
+
+// accessAddrs returns the addresses of an access of size bytes, or nil if the
+// access raises an exception, in which case the memory mustn't be accessed.
+func accessAddrs(ctx *Context, addr, size int32, store bool) []int32 {
+	if checkAccess(ctx, 0, addr, size, store) != nil {
+		return nil
+	}
+	addrs := make([]int32, 0, size)
+	for i := int32(0); i < size; i++ {
+		addrs = append(addrs, addr+i)
+	}
+	return addrs
+}
+
+// illegal is an instruction raising an exception whenever it is executed: an
+// instruction that can't be decoded, or the lack of instruction at a pc.
+type illegal struct {
+	cause ExceptionCause
+	value int32
+}
+
+func (op *illegal) Run(_ *Context, _ map[string]int32, pc int32, _ []int8, _ int32) (Execution, error) {
+	return raise(op.cause, pc, op.value), nil
+}
+
+func (op *illegal) InstructionType() InstructionType {
+	return Illegal
+}
+
+// String returns unimp, which raises the same illegal instruction exception.
+func (op *illegal) String() string {
+	return "unimp"
+}
+
+func (op *illegal) ReadRegisters() []RegisterType {
+	return nil
+}
+
+func (op *illegal) WriteRegisters() []RegisterType {
+	return nil
+}
+
+func (op *illegal) Forward(Forward) {}
+
+func (op *illegal) MemoryRead(*Context, int32) []int32 {
+	return nil
+}
+
+func (op *illegal) MemoryWrite(*Context, int32) []int32 {
+	return nil
+}
+
+// Fetch returns the instruction at a given pc or, if there is none, an
+// instruction raising an instruction address misaligned exception or an
+// instruction access fault.
+func (app Application) Fetch(pc int32) InstructionRunner {
+	if runner, exists := app.Instruction(pc); exists {
+		return runner
+	}
+	if pc%2 != 0 {
+		return &illegal{cause: CauseInstructionAddressMisaligned, value: pc}
+	}
+	return &illegal{cause: CauseInstructionAccessFault, value: pc}
+}
@@ -0,0 +1,230 @@
+package risc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraps(t *testing.T) {
+	app, err := Parse(`addi t0, zero, 40
+csrw mtvec, t0
+addi t1, zero, 2
+lw t2, 0(t1)
+sw t2, 64(zero)
+unimp
+ebreak
+csrw 0x7ff, zero
+addi s2, zero, 1
+j end
+addi s0, s0, 1
+slli s1, s1, 4
+csrr t3, mcause
+or s1, s1, t3
+csrr t3, mtval
+add s3, s3, t3
+csrr s4, mepc
+addi t4, s4, 4
+jalr zero, t4, 0
+end:`)
+	require.NoError(t, err)
+	r := NewRunner(app, 32)
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(5), r.Ctx.Registers[S0])
+	assert.Equal(t, int32(0x47232), r.Ctx.Registers[S1])
+	// mtval: the faulting addresses, then the pc of ebreak
+	assert.Equal(t, int32(2+64+24), r.Ctx.Registers[S3])
+	assert.Equal(t, int32(28), r.Ctx.Registers[S4])
+	assert.Equal(t, int32(1), r.Ctx.Registers[S2])
+	assert.Equal(t, int32(0), r.Ctx.Registers[T2])
+	// The trapping instructions don't retire
+	assert.Equal(t, int64(5+5*9), r.Ctx.Instret)
+}
+
+func TestUnhandledException(t *testing.T) {
+	app, err := Parse(`addi t0, zero, 1
+lh t1, 0(t0)`)
+	require.NoError(t, err)
+	var e *Exception
+	require.ErrorAs(t, NewRunner(app, 8).Run(), &e)
+	assert.Equal(t, Exception{Cause: CauseLoadAddressMisaligned, Pc: 4, Value: 1}, *e)
+
+	app, err = Parse("jalr zero, zero, -64")
+	require.NoError(t, err)
+	require.ErrorAs(t, NewRunner(app, 8).Run(), &e)
+	assert.Equal(t, Exception{Cause: CauseInstructionAccessFault, Pc: -64, Value: -64}, *e)
+}
+
+func TestReservedRoundingMode(t *testing.T) {
+	// The dynamic rounding mode is reserved once frm is set to 5: the
+	// instruction is executed again by the trap handler with frm reset.
+	app, err := Parse(`addi t0, zero, 20
+csrw mtvec, t0
+csrwi frm, 5
+fadd.s ft0, ft1, ft2
+j end
+csrr s0, mcause
+csrr s1, mtval
+csrr s2, mepc
+csrwi frm, 0
+mret
+end:`)
+	require.NoError(t, err)
+	r := NewRunner(app, 0)
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(CauseIllegalInstruction), r.Ctx.Registers[S0])
+	// fadd.s ft0, ft1, ft2, dyn
+	assert.Equal(t, int32(0x0020f053), r.Ctx.Registers[S1])
+	assert.Equal(t, int32(12), r.Ctx.Registers[S2])
+}
+
+func TestDeviceAccessFault(t *testing.T) {
+	// Only lw and sw access the CLINT, even though the memory spans its
+	// addresses
+	for source, cause := range map[string]ExceptionCause{
+		"sb t2, 0(t1)":           CauseStoreAccessFault,
+		"sh t2, 2(t1)":           CauseStoreAccessFault,
+		"fsw ft0, 0(t1)":         CauseStoreAccessFault,
+		"fsd ft0, 0(t1)":         CauseStoreAccessFault,
+		"amoswap.w t3, t2, (t1)": CauseStoreAccessFault,
+		"sc.w t3, t2, (t1)":      CauseStoreAccessFault,
+		"lbu t3, 1(t1)":          CauseLoadAccessFault,
+		"lh t3, 0(t1)":           CauseLoadAccessFault,
+		"flw ft0, 0(t1)":         CauseLoadAccessFault,
+		"lr.w t3, (t1)":          CauseLoadAccessFault,
+	} {
+		app, err := Parse("li t1, 33554432\naddi t2, zero, 1\n" + source)
+		require.NoError(t, err, source)
+		r := NewRunner(app, int(CLINTMtime)+8)
+		var e *Exception
+		require.ErrorAs(t, r.Run(), &e, source)
+		assert.Equal(t, cause, e.Cause, source)
+		assert.Equal(t, int32(0), r.Ctx.CLINT.Msip, source)
+		assert.Equal(t, int8(0), r.Ctx.Memory[CLINTMsip], source)
+	}
+
+	// The 64-bit mtimecmp is written a word at a time
+	app, err := Parse(`li t1, 33570816
+addi t2, zero, 100
+sw t2, 0(t1)
+sw zero, 4(t1)
+fsd ft0, 0(t1)`)
+	require.NoError(t, err)
+	r := NewRunner(app, int(CLINTMtime)+8)
+	var e *Exception
+	require.ErrorAs(t, r.Run(), &e)
+	assert.Equal(t, Exception{Cause: CauseStoreAccessFault, Pc: 16, Value: CLINTMtimecmp}, *e)
+	assert.Equal(t, uint64(100), r.Ctx.CLINT.Mtimecmp)
+}
+
+func TestUnknownLabel(t *testing.T) {
+	_, err := Parse("beq t0, t1, unknown")
+	assert.Error(t, err)
+}
+
+func TestMachineMode(t *testing.T) {
+	app, err := Parse(`addi t0, zero, 48
+csrw mtvec, t0
+csrsi mstatus, 8
+addi sp, zero, 7
+csrwi mscratch, 9
+csrr s0, misa
+csrr s1, mhartid
+csrw mip, t0
+csrr s2, mip
+ebreak
+csrr s5, mstatus
+j end
+csrrw sp, mscratch, sp
+csrr s3, mstatus
+csrr s4, mepc
+addi s4, s4, 4
+csrw mepc, s4
+wfi
+mret
+end:`)
+	require.NoError(t, err)
+	r := NewRunner(app, 0)
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(0x4000112d), r.Ctx.Registers[S0])
+	assert.Equal(t, int32(0), r.Ctx.Registers[S1])
+	assert.Equal(t, int32(0), r.Ctx.Registers[S2])
+	// The interrupts are disabled while the trap is handled
+	assert.Equal(t, MstatusMPP|MstatusMPIE, r.Ctx.Registers[S3])
+	assert.Equal(t, int32(40), r.Ctx.Registers[S4])
+	assert.Equal(t, MstatusMPP|MstatusMPIE|MstatusMIE, r.Ctx.Registers[S5])
+	assert.Equal(t, int32(9), r.Ctx.Registers[Sp])
+	assert.Equal(t, int32(7), r.Ctx.Mscratch)
+
+	app, err = Parse("csrw mhartid, t0")
+	require.NoError(t, err)
+	var e *Exception
+	require.ErrorAs(t, NewRunner(app, 0).Run(), &e)
+	assert.Equal(t, CauseIllegalInstruction, e.Cause)
+}
+
+func TestTimerInterrupt(t *testing.T) {
+	app, err := Parse(`addi t0, zero, 44
+csrw mtvec, t0
+li t1, 33570816
+addi t2, zero, 100
+sw t2, 0(t1)
+sw zero, 4(t1)
+addi t3, zero, 128
+csrw mie, t3
+csrsi mstatus, 8
+loop:
+beq s0, zero, loop
+j end
+addi s0, s0, 1
+csrr s1, mcause
+csrr s2, mepc
+csrr s3, mip
+addi t2, zero, -1
+sw t2, 4(t1)
+csrr s4, mip
+mret
+end:`)
+	require.NoError(t, err)
+	r := NewRunner(app, 0)
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(1), r.Ctx.Registers[S0])
+	assert.Equal(t, int32(CauseMachineTimerInterrupt), r.Ctx.Registers[S1])
+	assert.Equal(t, int32(36), r.Ctx.Registers[S2])
+	assert.Equal(t, MTIP, r.Ctx.Registers[S3])
+	assert.Equal(t, int32(0), r.Ctx.Registers[S4])
+	// The loop runs until mtime reaches mtimecmp
+	assert.Greater(t, r.Ctx.Instret, int64(100))
+}
+
+func TestSoftwareInterrupt(t *testing.T) {
+	app, err := Parse(`addi t0, zero, 37
+csrw mtvec, t0
+li t1, 33554432
+addi t2, zero, 1
+csrwi mie, 8
+csrsi mstatus, 8
+sw t2, 0(t1)
+addi s0, s0, 1
+addi s0, s0, 1
+j end
+nop
+nop
+addi s1, s1, 1
+csrr s2, mcause
+csrr s3, mepc
+sw zero, 0(t1)
+lw s4, 0(t1)
+mret
+end:`)
+	require.NoError(t, err)
+	r := NewRunner(app, 0)
+	require.NoError(t, r.Run())
+	assert.Equal(t, int32(2), r.Ctx.Registers[S0])
+	assert.Equal(t, int32(1), r.Ctx.Registers[S1])
+	// Vectored mode
+	assert.Equal(t, int32(CauseMachineSoftwareInterrupt), r.Ctx.Registers[S2])
+	assert.Equal(t, int32(28), r.Ctx.Registers[S3])
+	assert.Equal(t, int32(0), r.Ctx.Registers[S4])
+}
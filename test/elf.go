@@ -0,0 +1,181 @@
+package test
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ELFSegment is a loadable segment of an executable built by ELF.
+type ELFSegment struct {
+	Addr uint32
+	Data []byte
+	// MemSize is the size of the segment in memory; the bytes after Data are
+	// zeroed (.bss).
+	MemSize uint32
+	Exec    bool
+}
+
+// ELF builds a minimal statically linked RV32 executable. Each segment gets
+// its own section (.text if executable, .data otherwise) and the symbols are
+// written to a symbol table.
+func ELF(t *testing.T, entry uint32, symbols map[string]uint32, segments ...ELFSegment) []byte {
+	const (
+		ehdrSize  = 52
+		phdrSize  = 32
+		shdrSize  = 40
+		symSize   = 16
+		alignment = 4
+	)
+
+	var (
+		body     bytes.Buffer
+		shstrtab = []byte{0}
+		strtab   = []byte{0}
+		sections = []elf.Section32{{}}
+		progs    []elf.Prog32
+	)
+	offset := func() uint32 {
+		return uint32(ehdrSize + phdrSize*len(segments) + body.Len())
+	}
+	align := func() {
+		for body.Len()%alignment != 0 {
+			body.WriteByte(0)
+		}
+	}
+	name := func(table *[]byte, s string) uint32 {
+		idx := uint32(len(*table))
+		*table = append(append(*table, s...), 0)
+		return idx
+	}
+
+	for _, segment := range segments {
+		align()
+		memSize := max(segment.MemSize, uint32(len(segment.Data)))
+		flags := elf.PF_R | elf.PF_W
+		sectionName := ".data"
+		sectionFlags := elf.SHF_ALLOC | elf.SHF_WRITE
+		if segment.Exec {
+			flags = elf.PF_R | elf.PF_X
+			sectionName = ".text"
+			sectionFlags = elf.SHF_ALLOC | elf.SHF_EXECINSTR
+		}
+		progs = append(progs, elf.Prog32{
+			Type:   uint32(elf.PT_LOAD),
+			Off:    offset(),
+			Vaddr:  segment.Addr,
+			Paddr:  segment.Addr,
+			Filesz: uint32(len(segment.Data)),
+			Memsz:  memSize,
+			Flags:  uint32(flags),
+			Align:  alignment,
+		})
+		sections = append(sections, elf.Section32{
+			Name:      name(&shstrtab, sectionName),
+			Type:      uint32(elf.SHT_PROGBITS),
+			Flags:     uint32(sectionFlags),
+			Addr:      segment.Addr,
+			Off:       offset(),
+			Size:      uint32(len(segment.Data)),
+			Addralign: alignment,
+		})
+		body.Write(segment.Data)
+		if memSize > uint32(len(segment.Data)) {
+			sections = append(sections, elf.Section32{
+				Name:      name(&shstrtab, ".bss"),
+				Type:      uint32(elf.SHT_NOBITS),
+				Flags:     uint32(elf.SHF_ALLOC | elf.SHF_WRITE),
+				Addr:      segment.Addr + uint32(len(segment.Data)),
+				Off:       offset(),
+				Size:      memSize - uint32(len(segment.Data)),
+				Addralign: alignment,
+			})
+		}
+	}
+
+	names := make([]string, 0, len(symbols))
+	for s := range symbols {
+		names = append(names, s)
+	}
+	sort.Strings(names)
+	syms := []elf.Sym32{{}}
+	for _, s := range names {
+		addr := symbols[s]
+		shndx := uint16(elf.SHN_ABS)
+		for i, section := range sections {
+			if i != 0 && addr >= section.Addr && addr < section.Addr+section.Size {
+				shndx = uint16(i)
+				break
+			}
+		}
+		syms = append(syms, elf.Sym32{
+			Name:  name(&strtab, s),
+			Value: addr,
+			Info:  elf.ST_INFO(elf.STB_GLOBAL, elf.STT_NOTYPE),
+			Shndx: shndx,
+		})
+	}
+
+	align()
+	symtabIdx := len(sections)
+	sections = append(sections, elf.Section32{
+		Name:      name(&shstrtab, ".symtab"),
+		Type:      uint32(elf.SHT_SYMTAB),
+		Off:       offset(),
+		Size:      uint32(symSize * len(syms)),
+		Link:      uint32(symtabIdx + 1),
+		Info:      1,
+		Addralign: alignment,
+		Entsize:   symSize,
+	})
+	require.NoError(t, binary.Write(&body, binary.LittleEndian, syms))
+	sections = append(sections, elf.Section32{
+		Name:      name(&shstrtab, ".strtab"),
+		Type:      uint32(elf.SHT_STRTAB),
+		Off:       offset(),
+		Size:      uint32(len(strtab)),
+		Addralign: 1,
+	})
+	body.Write(strtab)
+	shstrndx := len(sections)
+	sections = append(sections, elf.Section32{
+		Name:      name(&shstrtab, ".shstrtab"),
+		Type:      uint32(elf.SHT_STRTAB),
+		Off:       offset(),
+		Addralign: 1,
+	})
+	sections[shstrndx].Size = uint32(len(shstrtab))
+	body.Write(shstrtab)
+	align()
+	shoff := offset()
+
+	hdr := elf.Header32{
+		Type:      uint16(elf.ET_EXEC),
+		Machine:   uint16(elf.EM_RISCV),
+		Version:   uint32(elf.EV_CURRENT),
+		Entry:     entry,
+		Phoff:     ehdrSize,
+		Shoff:     shoff,
+		Ehsize:    ehdrSize,
+		Phentsize: phdrSize,
+		Phnum:     uint16(len(progs)),
+		Shentsize: shdrSize,
+		Shnum:     uint16(len(sections)),
+		Shstrndx:  uint16(shstrndx),
+	}
+	copy(hdr.Ident[:], elf.ELFMAG)
+	hdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS32)
+	hdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	hdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+
+	var out bytes.Buffer
+	require.NoError(t, binary.Write(&out, binary.LittleEndian, hdr))
+	require.NoError(t, binary.Write(&out, binary.LittleEndian, progs))
+	out.Write(body.Bytes())
+	require.NoError(t, binary.Write(&out, binary.LittleEndian, sections))
+	return out.Bytes()
+}
@@ -0,0 +1,268 @@
+package test
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strings"
+)
+
+// ProgramConfig configures RandomProgram.
+type ProgramConfig struct {
+	// Instructions is the number of instructions to generate, besides the ones
+	// setting the registers up and maintaining the loop counters.
+	Instructions int
+	// ALU, Immediate, Multiply, Load, Store and Branch are the relative weights
+	// of the register-register, register-immediate, M extension, load, store
+	// and branch instructions.
+	ALU       int
+	Immediate int
+	Multiply  int
+	Load      int
+	Store     int
+	Branch    int
+	// Dependency is the probability for a source register to be one of the
+	// last registers written, rather than any register.
+	Dependency float64
+	// Loop is the probability to start a loop at a given instruction. A loop
+	// isn't nested and runs up to MaxIterations times.
+	Loop          float64
+	MaxIterations int
+	// MaxSkip is the maximum number of instructions skipped by a forward
+	// branch.
+	MaxSkip int
+	// Memory is the memory footprint in bytes, a power of two between 8 and
+	// 2048. Every memory access is naturally aligned and within it.
+	Memory int
+}
+
+// DefaultProgramConfig returns a configuration mixing all the instructions.
+func DefaultProgramConfig() ProgramConfig {
+	return ProgramConfig{
+		Instructions:  64,
+		ALU:           4,
+		Immediate:     4,
+		Multiply:      1,
+		Load:          2,
+		Store:         2,
+		Branch:        2,
+		Dependency:    0.5,
+		Loop:          0.05,
+		MaxIterations: 8,
+		MaxSkip:       4,
+		Memory:        256,
+	}
+}
+
+const (
+	// loopCounter is reserved for the loops and addressRegister for the
+	// memory addresses computed from a register.
+	loopCounter     = "s11"
+	addressRegister = "t6"
+	// recentRegisters is the number of the last registers written a dependent
+	// source register is picked from.
+	recentRegisters = 4
+)
+
+var (
+	dataRegisters = []string{
+		"t0", "t1", "t2", "t3", "t4", "t5",
+		"a0", "a1", "a2", "a3", "a4", "a5", "a6", "a7",
+		"s0", "s1", "s2", "s3", "s4", "s5", "s6", "s7", "s8", "s9", "s10",
+	}
+	aluOps       = []string{"add", "sub", "sll", "slt", "sltu", "xor", "srl", "sra", "or", "and"}
+	immediateOps = []string{"addi", "slti", "sltiu", "xori", "ori", "andi"}
+	shiftOps     = []string{"slli", "srli", "srai"}
+	multiplyOps  = []string{"mul", "mulh", "mulhsu", "mulhu", "div", "divu", "rem", "remu"}
+	branchOps    = []string{"beq", "bne", "blt", "bge", "bltu", "bgeu"}
+	loads        = []access{{"lb", 1}, {"lbu", 1}, {"lh", 2}, {"lhu", 2}, {"lw", 4}}
+	stores       = []access{{"sb", 1}, {"sh", 2}, {"sw", 4}}
+	// edgeValues are the register values most likely to reveal a bug.
+	edgeValues = []int32{0, 1, -1, 2, 31, 32, math.MinInt32, math.MaxInt32, math.MinInt32 + 1, 0x7ff, -0x800}
+)
+
+type access struct {
+	op   string
+	size int32
+}
+
+type generator struct {
+	rnd    *rand.Rand
+	config ProgramConfig
+	recent []string
+	labels int
+}
+
+// RandomProgram returns the source of a random RV32IM program that always
+// terminates: the branches only go forward, except the ones closing a loop,
+// whose counter is only written by the loop itself.
+func RandomProgram(rnd *rand.Rand, config ProgramConfig) string {
+	g := &generator{rnd: rnd, config: config}
+	var sb strings.Builder
+	for _, reg := range dataRegisters {
+		fmt.Fprintf(&sb, "li %s, %d\n", reg, g.value())
+	}
+	remaining := config.Instructions
+	for remaining > 0 {
+		if config.MaxIterations > 0 && g.rnd.Float64() < config.Loop {
+			n := min(remaining, 2+g.rnd.IntN(7))
+			label := g.label()
+			fmt.Fprintf(&sb, "li %s, %d\n", loopCounter, 1+g.rnd.IntN(config.MaxIterations))
+			sb.WriteString(label + ":\n")
+			g.block(&sb, n)
+			fmt.Fprintf(&sb, "addi %s, %s, -1\n", loopCounter, loopCounter)
+			fmt.Fprintf(&sb, "bnez %s, %s\n", loopCounter, label)
+			remaining -= n
+			continue
+		}
+		n := min(remaining, 1+g.rnd.IntN(16))
+		g.block(&sb, n)
+		remaining -= n
+	}
+	return sb.String()
+}
+
+// block generates n instructions, the forward branches targeting an
+// instruction of the block or its end.
+func (g *generator) block(sb *strings.Builder, n int) {
+	targets := make(map[int][]string)
+	for i := 0; i < n; i++ {
+		for _, label := range targets[i] {
+			sb.WriteString(label + ":\n")
+		}
+		if g.pick(g.config.Branch) {
+			label := g.label()
+			skip := 1
+			if g.config.MaxSkip > 1 {
+				skip += g.rnd.IntN(g.config.MaxSkip)
+			}
+			target := min(i+1+skip, n)
+			targets[target] = append(targets[target], label)
+			g.branch(sb, label)
+			continue
+		}
+		g.instruction(sb)
+	}
+	for _, label := range targets[n] {
+		sb.WriteString(label + ":\n")
+	}
+}
+
+// pick returns true with the probability of an instruction kind to be
+// generated.
+func (g *generator) pick(weight int) bool {
+	c := g.config
+	total := c.ALU + c.Immediate + c.Multiply + c.Load + c.Store + c.Branch
+	return weight > 0 && g.rnd.IntN(total) < weight
+}
+
+func (g *generator) instruction(sb *strings.Builder) {
+	c := g.config
+	total := c.ALU + c.Immediate + c.Multiply + c.Load + c.Store
+	if total == 0 {
+		sb.WriteString("nop\n")
+		return
+	}
+	n := g.rnd.IntN(total)
+	switch {
+	case n < c.ALU:
+		rs1, rs2 := g.source(), g.source()
+		fmt.Fprintf(sb, "%s %s, %s, %s\n", oneOf(g.rnd, aluOps), g.destination(), rs1, rs2)
+	case n < c.ALU+c.Immediate:
+		rs := g.source()
+		switch g.rnd.IntN(4) {
+		case 0:
+			fmt.Fprintf(sb, "%s %s, %s, %d\n", oneOf(g.rnd, shiftOps), g.destination(), rs, g.rnd.IntN(32))
+		case 1:
+			fmt.Fprintf(sb, "lui %s, %d\n", g.destination(), g.rnd.IntN(1<<20))
+		default:
+			fmt.Fprintf(sb, "%s %s, %s, %d\n", oneOf(g.rnd, immediateOps), g.destination(), rs, g.immediate())
+		}
+	case n < c.ALU+c.Immediate+c.Multiply:
+		rs1, rs2 := g.source(), g.source()
+		fmt.Fprintf(sb, "%s %s, %s, %s\n", oneOf(g.rnd, multiplyOps), g.destination(), rs1, rs2)
+	case n < c.ALU+c.Immediate+c.Multiply+c.Load:
+		load := oneOf(g.rnd, loads)
+		base, offset := g.address(sb, load.size)
+		fmt.Fprintf(sb, "%s %s, %d(%s)\n", load.op, g.destination(), offset, base)
+	default:
+		store := oneOf(g.rnd, stores)
+		rs := g.source()
+		base, offset := g.address(sb, store.size)
+		fmt.Fprintf(sb, "%s %s, %d(%s)\n", store.op, rs, offset, base)
+	}
+}
+
+func (g *generator) branch(sb *strings.Builder, label string) {
+	switch g.rnd.IntN(8) {
+	case 0:
+		// Always taken
+		fmt.Fprintf(sb, "j %s\n", label)
+	case 1:
+		// Never taken
+		rs := g.source()
+		fmt.Fprintf(sb, "bne %s, %s, %s\n", rs, rs, label)
+	default:
+		rs1, rs2 := g.source(), g.source()
+		fmt.Fprintf(sb, "%s %s, %s, %s\n", oneOf(g.rnd, branchOps), rs1, rs2, label)
+	}
+}
+
+// address returns the base register and the offset of a memory access of a
+// given size. The address is either a constant or derived from a register.
+func (g *generator) address(sb *strings.Builder, size int32) (string, int32) {
+	mask := (int32(g.config.Memory) - 1) &^ (size - 1)
+	if g.rnd.IntN(2) == 0 {
+		return "zero", g.rnd.Int32N(int32(g.config.Memory)) & mask
+	}
+	fmt.Fprintf(sb, "andi %s, %s, %d\n", addressRegister, g.source(), mask)
+	return addressRegister, 0
+}
+
+// source returns a source register: one of the last registers written, with
+// the dependency probability, or any register.
+func (g *generator) source() string {
+	if len(g.recent) > 0 && g.rnd.Float64() < g.config.Dependency {
+		return oneOf(g.rnd, g.recent)
+	}
+	if g.rnd.IntN(16) == 0 {
+		return "zero"
+	}
+	return oneOf(g.rnd, dataRegisters)
+}
+
+// destination returns a destination register, possibly x0.
+func (g *generator) destination() string {
+	if g.rnd.IntN(32) == 0 {
+		return "zero"
+	}
+	reg := oneOf(g.rnd, dataRegisters)
+	g.recent = append(g.recent, reg)
+	if len(g.recent) > recentRegisters {
+		g.recent = g.recent[1:]
+	}
+	return reg
+}
+
+func (g *generator) immediate() int32 {
+	if g.rnd.IntN(4) == 0 {
+		return oneOf(g.rnd, []int32{0, 1, -1, 0x7ff, -0x800})
+	}
+	return g.rnd.Int32N(1<<12) - 1<<11
+}
+
+func (g *generator) value() int32 {
+	if g.rnd.IntN(2) == 0 {
+		return oneOf(g.rnd, edgeValues)
+	}
+	return int32(g.rnd.Uint32())
+}
+
+func (g *generator) label() string {
+	g.labels++
+	return fmt.Sprintf("l%d", g.labels)
+}
+
+func oneOf[T any](rnd *rand.Rand, s []T) T {
+	return s[rnd.IntN(len(s))]
+}